@@ -12,13 +12,22 @@ import (
 
 	"github.com/Jomesi149/Implementasi-LASTI/backend/internal/account"
 	"github.com/Jomesi149/Implementasi-LASTI/backend/internal/analytics"
+	"github.com/Jomesi149/Implementasi-LASTI/backend/internal/attachment"
+	"github.com/Jomesi149/Implementasi-LASTI/backend/internal/bill"
 	"github.com/Jomesi149/Implementasi-LASTI/backend/internal/budget"
 	"github.com/Jomesi149/Implementasi-LASTI/backend/internal/config"
+	"github.com/Jomesi149/Implementasi-LASTI/backend/internal/currency"
 	"github.com/Jomesi149/Implementasi-LASTI/backend/internal/database"
+	"github.com/Jomesi149/Implementasi-LASTI/backend/internal/goal"
+	"github.com/Jomesi149/Implementasi-LASTI/backend/internal/household"
 	httpapi "github.com/Jomesi149/Implementasi-LASTI/backend/internal/http"
+	"github.com/Jomesi149/Implementasi-LASTI/backend/internal/notification"
 	"github.com/Jomesi149/Implementasi-LASTI/backend/internal/otp"
+	"github.com/Jomesi149/Implementasi-LASTI/backend/internal/recurring"
 	"github.com/Jomesi149/Implementasi-LASTI/backend/internal/security"
 	"github.com/Jomesi149/Implementasi-LASTI/backend/internal/server"
+	"github.com/Jomesi149/Implementasi-LASTI/backend/internal/split"
+	"github.com/Jomesi149/Implementasi-LASTI/backend/internal/storage"
 	"github.com/Jomesi149/Implementasi-LASTI/backend/internal/token"
 	"github.com/Jomesi149/Implementasi-LASTI/backend/internal/transaction"
 )
@@ -59,9 +68,23 @@ func main() {
 
 	handler := account.NewHTTPHandler(service)
 
+	// currencies & exchange rates
+	rateProvider, err := currency.NewProvider(currency.ProviderConfig{
+		Driver:      cfg.RateProvider,
+		URL:         cfg.RateProviderURL,
+		Timeout:     10 * time.Second,
+		StaticRates: cfg.RateStaticRates,
+	})
+	if err != nil {
+		log.Fatalf("init rate provider: %v", err)
+	}
+	currencyService := currency.NewService(currency.ServiceDeps{Repo: currency.NewRepository(db), Provider: rateProvider})
+	currencyHandler := currency.NewHTTPHandler(currencyService)
+	go currency.NewRefresher(currencyService, cfg.RateRefreshInterval).Run(ctx)
+
 	// transaction service
-	transService := transaction.NewService(transaction.ServiceDeps{Repo: transRepo})
-	transHandler := transaction.NewHTTPHandler(transService)
+	transService := transaction.NewService(transaction.ServiceDeps{Repo: transRepo, TrashRetention: cfg.TrashRetention, Currencies: currencyService})
+	go transaction.NewPlannedPoster(transService, cfg.PlannedInterval).Run(ctx)
 
 	// budgets
 	budgetRepo := budget.NewRepository(db)
@@ -73,7 +96,63 @@ func main() {
 	analyticsService := analytics.NewService(analyticsRepo)
 	analyticsHandler := analytics.NewHTTPHandler(analyticsService)
 
-	router := httpapi.NewRouter(handler, transHandler, budgetHandler, analyticsHandler)
+	// recurring transactions
+	recurringRepo := recurring.NewRepository(db)
+	recurringService := recurring.NewService(recurring.ServiceDeps{Repo: recurringRepo, Transactions: transService})
+	recurringHandler := recurring.NewHTTPHandler(recurringService)
+	go recurring.NewScheduler(recurringService, cfg.RecurringInterval).Run(ctx)
+
+	// wallet projections include the occurrences of recurring rules
+	transHandler := transaction.NewHTTPHandler(transService, recurringService.Projected)
+
+	// savings goals
+	goalService := goal.NewService(goal.ServiceDeps{Repo: goal.NewRepository(db), Transactions: transService, Currencies: currencyService})
+	goalHandler := goal.NewHTTPHandler(goalService)
+
+	// attachments
+	store, err := storage.New(storage.Config{
+		Driver:      cfg.StorageDriver,
+		LocalDir:    cfg.StorageDir,
+		S3Endpoint:  cfg.S3Endpoint,
+		S3Region:    cfg.S3Region,
+		S3Bucket:    cfg.S3Bucket,
+		S3AccessKey: cfg.S3AccessKey,
+		S3SecretKey: cfg.S3SecretKey,
+		S3PathStyle: cfg.S3PathStyle,
+	})
+	if err != nil {
+		log.Fatalf("init storage: %v", err)
+	}
+	attachmentService := attachment.NewService(attachment.ServiceDeps{
+		Repo:         attachment.NewRepository(db),
+		Store:        store,
+		Transactions: transService,
+		SigningKey:   []byte(cfg.AttachmentURLSecret),
+		URLTTL:       cfg.AttachmentURLTTL,
+		BasePath:     "/api/v1",
+	})
+	attachmentHandler := attachment.NewHTTPHandler(attachmentService)
+	go transaction.NewTrashPurger(transService, cfg.TrashPurgeInterval, store).Run(ctx)
+
+	// scheduled bills
+	billService := bill.NewService(bill.ServiceDeps{Repo: bill.NewRepository(db), Transactions: transService})
+	billHandler := bill.NewHTTPHandler(billService)
+	go bill.NewMatcher(billService, cfg.BillMatchInterval).Run(ctx)
+
+	// notifications & reminders
+	notificationService := notification.NewService(notification.NewRepository(db))
+	notificationHandler := notification.NewHTTPHandler(notificationService)
+	go notification.NewScheduler(notificationService, cfg.NotificationInterval, transService.CreditCardReminders, billService.Reminders, transService.PlannedPrompts).Run(ctx)
+
+	// shared households & wallets
+	householdService := household.NewService(household.ServiceDeps{Repo: household.NewRepository(db), Transactions: transService, Notifications: notificationService})
+	householdHandler := household.NewHTTPHandler(householdService)
+
+	// group expense splitting
+	splitService := split.NewService(split.ServiceDeps{Repo: split.NewRepository(db), Transactions: transService, Currencies: currencyService})
+	splitHandler := split.NewHTTPHandler(splitService)
+
+	router := httpapi.NewRouter(handler, transHandler, budgetHandler, analyticsHandler, recurringHandler, attachmentHandler, currencyHandler, notificationHandler, goalHandler, householdHandler, splitHandler, billHandler)
 
 	srv := server.New(cfg.HTTPPort, router)
 
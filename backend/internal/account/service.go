@@ -11,6 +11,7 @@ import (
 	"github.com/go-playground/validator/v10"
 	"github.com/google/uuid"
 
+	"github.com/Jomesi149/Implementasi-LASTI/backend/internal/currency"
 	"github.com/Jomesi149/Implementasi-LASTI/backend/internal/otp"
 	"github.com/Jomesi149/Implementasi-LASTI/backend/internal/security"
 	"github.com/Jomesi149/Implementasi-LASTI/backend/internal/token"
@@ -96,13 +97,14 @@ func (s *Service) Register(ctx context.Context, req RegisterRequest) (*RegisterR
 		return nil, err
 	}
 
-	// Create default wallet for new user (1 user = 1 wallet)
+	// Create default wallet for new user; more wallets, and wallets shared by others, come later
 	wallet := transaction.Wallet{
-		ID:      uuid.New(),
-		UserID:  userID,
-		Type:    "cash",
-		Name:    "Dompet Utama",
-		Balance: "0",
+		ID:       uuid.New(),
+		UserID:   userID,
+		Type:     "cash",
+		Name:     "Dompet Utama",
+		Balance:  "0",
+		Currency: currency.DefaultCurrency,
 	}
 	fmt.Printf("\n[WALLET_CREATE] Attempting to create wallet for user %s\n", userID.String())
 	if err := s.transactionRepo.CreateWallet(ctx, wallet); err != nil {
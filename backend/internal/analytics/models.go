@@ -1,9 +1,17 @@
 package analytics
 
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
 // CategoryBreakdown untuk Pie Chart
 type CategoryBreakdown struct {
 	CategoryName string `json:"name"`
 	TotalAmount  string `json:"value"` 
+	// Currency adalah mata uang dasar user; semua total sudah dikonversi ke sini
+	Currency     string `json:"currency"`
 }
 
 // MonthlySummary untuk Bar Chart
@@ -11,4 +19,29 @@ type MonthlySummary struct {
 	Month   string `json:"month"`   
 	Income  string `json:"income"`
 	Expense string `json:"expense"`
-}
\ No newline at end of file
+	Currency string `json:"currency"`
+}
+
+// LabelTotal adalah total pemasukan/pengeluaran untuk satu tag atau payee
+type LabelTotal struct {
+	ID      string `json:"id"`
+	Name    string `json:"name"`
+	Income  string `json:"income"`
+	Expense string `json:"expense"`
+	Count   int    `json:"count"`
+	Currency string `json:"currency"`
+}
+
+// Period membatasi rentang waktu laporan; nil berarti tanpa batas
+type Period struct {
+	From *time.Time
+	To   *time.Time
+}
+
+// Scope menentukan data yang dilaporkan: milik UserID sendiri, atau jika HouseholdID diisi,
+// dompet household tersebut. OwnerID (pemilik data) diisi oleh Service
+type Scope struct {
+	UserID      uuid.UUID
+	HouseholdID *uuid.UUID
+	OwnerID     uuid.UUID
+}
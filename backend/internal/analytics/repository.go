@@ -3,14 +3,18 @@ package analytics
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 
 	"github.com/google/uuid"
 )
 
 type Repository interface {
-	GetExpenseByCategory(ctx context.Context, userID uuid.UUID) ([]CategoryBreakdown, error)
-	GetMonthlySummary(ctx context.Context, userID uuid.UUID) ([]MonthlySummary, error)
+	GetExpenseByCategory(ctx context.Context, s Scope) ([]CategoryBreakdown, error)
+	GetMonthlySummary(ctx context.Context, s Scope) ([]MonthlySummary, error)
+	GetTotalsByTag(ctx context.Context, s Scope, p Period) ([]LabelTotal, error)
+	GetTotalsByPayee(ctx context.Context, s Scope, p Period) ([]LabelTotal, error)
+	HouseholdOwner(ctx context.Context, userID, householdID uuid.UUID) (uuid.UUID, error)
 }
 
 type SQLRepository struct {
@@ -21,18 +25,47 @@ func NewRepository(db *sql.DB) *SQLRepository {
 	return &SQLRepository{db: db}
 }
 
-// GetExpenseByCategory: Menghitung total pengeluaran per kategori
-func (r *SQLRepository) GetExpenseByCategory(ctx context.Context, userID uuid.UUID) ([]CategoryBreakdown, error) {
-	query := `
-		SELECT c.name, COALESCE(SUM(t.amount), 0)::TEXT as total
-		FROM finance.transactions t
-		JOIN finance.categories c ON t.category_id = c.id
-		WHERE t.user_id = $1 AND t.kind = 'out'
-		GROUP BY c.name
-		ORDER BY SUM(t.amount) DESC
-	`
-	fmt.Printf("[ANALYTICS] GetExpenseByCategory for user: %s\n", userID)
-	rows, err := r.db.QueryContext(ctx, query, userID)
+// baseAmount converts t.amount into the base currency of user $1 with the rate on the
+// transaction date; it is NULL, and left out of sums, while no rate is known.
+const baseAmount = `finance.convert_amount(t.amount, t.currency, finance.base_currency($1), t.occurred_at::DATE, $1)`
+
+// scopeFilter membatasi t ke dompet household $2 jika diisi; $3 (user yang meminta) harus
+// anggota household tersebut. $1 adalah pemilik data
+const scopeFilter = `($2::UUID IS NULL OR t.wallet_id IN (SELECT w.id FROM finance.wallets w
+			WHERE w.household_id = $2 AND w.user_id = $1 AND finance.household_role($2, $3) IS NOT NULL))`
+
+// scopeArgs adalah $1-$3 untuk query yang memakai scopeFilter
+func scopeArgs(s Scope) []any {
+	return []any{s.OwnerID, s.HouseholdID, s.UserID}
+}
+
+// HouseholdOwner: pemilik household, hanya jika userID anggotanya
+func (r *SQLRepository) HouseholdOwner(ctx context.Context, userID, householdID uuid.UUID) (uuid.UUID, error) {
+	var owner uuid.UUID
+	err := r.db.QueryRowContext(ctx, `SELECT owner_id FROM finance.households
+		WHERE id = $1 AND finance.household_role(id, $2) IS NOT NULL`, householdID, userID).Scan(&owner)
+	if errors.Is(err, sql.ErrNoRows) {
+		return uuid.Nil, ErrHouseholdNotFound
+	}
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("select household: %w", err)
+	}
+	return owner, nil
+}
+
+// GetExpenseByCategory: Menghitung total pengeluaran per kategori (sub-kategori digabung ke induknya, transaksi split dihitung per baris)
+func (r *SQLRepository) GetExpenseByCategory(ctx context.Context, s Scope) ([]CategoryBreakdown, error) {
+	query := fmt.Sprintf(`
+		SELECT COALESCE(p.name, c.name), COALESCE(SUM(%[1]s), 0)::TEXT as total, finance.base_currency($1)
+		FROM finance.transaction_lines t
+		JOIN finance.categories c ON t.category_id = c.id AND c.deleted_at IS NULL
+		LEFT JOIN finance.categories p ON c.parent_id = p.id
+		WHERE t.user_id = $1 AND t.kind = 'out' AND %[2]s
+		GROUP BY COALESCE(p.name, c.name)
+		ORDER BY SUM(%[1]s) DESC NULLS LAST
+	`, baseAmount, scopeFilter)
+	fmt.Printf("[ANALYTICS] GetExpenseByCategory for user: %s\n", s.UserID)
+	rows, err := r.db.QueryContext(ctx, query, scopeArgs(s)...)
 	if err != nil {
 		fmt.Printf("[ANALYTICS_ERROR] query breakdown: %v\n", err)
 		return nil, fmt.Errorf("query breakdown: %w", err)
@@ -42,7 +75,7 @@ func (r *SQLRepository) GetExpenseByCategory(ctx context.Context, userID uuid.UU
 	var data []CategoryBreakdown
 	for rows.Next() {
 		var d CategoryBreakdown
-		if err := rows.Scan(&d.CategoryName, &d.TotalAmount); err != nil {
+		if err := rows.Scan(&d.CategoryName, &d.TotalAmount, &d.Currency); err != nil {
 			fmt.Printf("[ANALYTICS_ERROR] scan error: %v\n", err)
 			return nil, err
 		}
@@ -54,20 +87,21 @@ func (r *SQLRepository) GetExpenseByCategory(ctx context.Context, userID uuid.UU
 }
 
 // GetMonthlySummary: Rekap Pemasukan vs Pengeluaran 6 bulan terakhir
-func (r *SQLRepository) GetMonthlySummary(ctx context.Context, userID uuid.UUID) ([]MonthlySummary, error) {
-	query := `
+func (r *SQLRepository) GetMonthlySummary(ctx context.Context, s Scope) ([]MonthlySummary, error) {
+	query := fmt.Sprintf(`
 		SELECT 
-			TO_CHAR(occurred_at, 'Mon YYYY') as month_label,
-			COALESCE(SUM(CASE WHEN kind = 'in' THEN amount ELSE 0 END), 0)::TEXT as income,
-			COALESCE(SUM(CASE WHEN kind = 'out' THEN amount ELSE 0 END), 0)::TEXT as expense
-		FROM finance.transactions
-		WHERE user_id = $1
-		GROUP BY TO_CHAR(occurred_at, 'Mon YYYY'), date_trunc('month', occurred_at)
-		ORDER BY date_trunc('month', occurred_at) ASC
+			TO_CHAR(t.occurred_at, 'Mon YYYY') as month_label,
+			COALESCE(SUM(CASE WHEN t.kind = 'in' THEN %[1]s ELSE 0 END), 0)::TEXT as income,
+			COALESCE(SUM(CASE WHEN t.kind = 'out' THEN %[1]s ELSE 0 END), 0)::TEXT as expense,
+			finance.base_currency($1)
+		FROM finance.transactions t
+		WHERE t.user_id = $1 AND t.deleted_at IS NULL AND t.transfer_id IS NULL AND t.debt_id IS NULL AND NOT t.planned AND %[2]s
+		GROUP BY TO_CHAR(t.occurred_at, 'Mon YYYY'), date_trunc('month', t.occurred_at)
+		ORDER BY date_trunc('month', t.occurred_at) ASC
 		LIMIT 6
-	`
-	fmt.Printf("[ANALYTICS] GetMonthlySummary for user: %s\n", userID)
-	rows, err := r.db.QueryContext(ctx, query, userID)
+	`, baseAmount, scopeFilter)
+	fmt.Printf("[ANALYTICS] GetMonthlySummary for user: %s\n", s.UserID)
+	rows, err := r.db.QueryContext(ctx, query, scopeArgs(s)...)
 	if err != nil {
 		fmt.Printf("[ANALYTICS_ERROR] query monthly: %v\n", err)
 		return nil, fmt.Errorf("query monthly: %w", err)
@@ -77,7 +111,7 @@ func (r *SQLRepository) GetMonthlySummary(ctx context.Context, userID uuid.UUID)
 	var data []MonthlySummary
 	for rows.Next() {
 		var d MonthlySummary
-		if err := rows.Scan(&d.Month, &d.Income, &d.Expense); err != nil {
+		if err := rows.Scan(&d.Month, &d.Income, &d.Expense, &d.Currency); err != nil {
 			fmt.Printf("[ANALYTICS_ERROR] scan error: %v\n", err)
 			return nil, err
 		}
@@ -87,3 +121,63 @@ func (r *SQLRepository) GetMonthlySummary(ctx context.Context, userID uuid.UUID)
 	fmt.Printf("[ANALYTICS] Found %d months of data\n", len(data))
 	return data, nil
 }
+
+// GetTotalsByTag: Total pemasukan & pengeluaran per tag. Transaksi dengan beberapa tag
+// dihitung penuh di setiap tag-nya.
+func (r *SQLRepository) GetTotalsByTag(ctx context.Context, s Scope, p Period) ([]LabelTotal, error) {
+	query := fmt.Sprintf(`
+		SELECT g.id::TEXT, g.name,
+			COALESCE(SUM(CASE WHEN t.kind = 'in' THEN %[1]s ELSE 0 END), 0)::TEXT,
+			COALESCE(SUM(CASE WHEN t.kind = 'out' THEN %[1]s ELSE 0 END), 0)::TEXT,
+			COUNT(t.id), finance.base_currency($1)
+		FROM finance.tags g
+		JOIN finance.transaction_tags tt ON tt.tag_id = g.id
+		JOIN finance.transactions t ON t.id = tt.transaction_id
+		WHERE g.user_id = $1 AND t.deleted_at IS NULL AND t.transfer_id IS NULL AND t.debt_id IS NULL AND NOT t.planned
+			AND ($4::TIMESTAMPTZ IS NULL OR t.occurred_at >= $4)
+			AND ($5::TIMESTAMPTZ IS NULL OR t.occurred_at < $5) AND %[2]s
+		GROUP BY g.id, g.name
+		ORDER BY COALESCE(SUM(CASE WHEN t.kind = 'out' THEN %[1]s ELSE 0 END), 0) DESC, g.name ASC
+	`, baseAmount, scopeFilter)
+	fmt.Printf("[ANALYTICS] GetTotalsByTag for user: %s\n", s.UserID)
+	return r.queryLabelTotals(ctx, query, s, p)
+}
+
+// GetTotalsByPayee: Total pemasukan & pengeluaran per payee/merchant
+func (r *SQLRepository) GetTotalsByPayee(ctx context.Context, s Scope, p Period) ([]LabelTotal, error) {
+	query := fmt.Sprintf(`
+		SELECT py.id::TEXT, py.name,
+			COALESCE(SUM(CASE WHEN t.kind = 'in' THEN %[1]s ELSE 0 END), 0)::TEXT,
+			COALESCE(SUM(CASE WHEN t.kind = 'out' THEN %[1]s ELSE 0 END), 0)::TEXT,
+			COUNT(t.id), finance.base_currency($1)
+		FROM finance.payees py
+		JOIN finance.transactions t ON t.payee_id = py.id
+		WHERE py.user_id = $1 AND t.deleted_at IS NULL AND t.transfer_id IS NULL AND t.debt_id IS NULL AND NOT t.planned
+			AND ($4::TIMESTAMPTZ IS NULL OR t.occurred_at >= $4)
+			AND ($5::TIMESTAMPTZ IS NULL OR t.occurred_at < $5) AND %[2]s
+		GROUP BY py.id, py.name
+		ORDER BY COALESCE(SUM(CASE WHEN t.kind = 'out' THEN %[1]s ELSE 0 END), 0) DESC, py.name ASC
+	`, baseAmount, scopeFilter)
+	fmt.Printf("[ANALYTICS] GetTotalsByPayee for user: %s\n", s.UserID)
+	return r.queryLabelTotals(ctx, query, s, p)
+}
+
+func (r *SQLRepository) queryLabelTotals(ctx context.Context, query string, s Scope, p Period) ([]LabelTotal, error) {
+	rows, err := r.db.QueryContext(ctx, query, append(scopeArgs(s), p.From, p.To)...)
+	if err != nil {
+		fmt.Printf("[ANALYTICS_ERROR] query label totals: %v\n", err)
+		return nil, fmt.Errorf("query label totals: %w", err)
+	}
+	defer rows.Close()
+
+	data := []LabelTotal{}
+	for rows.Next() {
+		var d LabelTotal
+		if err := rows.Scan(&d.ID, &d.Name, &d.Income, &d.Expense, &d.Count, &d.Currency); err != nil {
+			fmt.Printf("[ANALYTICS_ERROR] scan error: %v\n", err)
+			return nil, err
+		}
+		data = append(data, d)
+	}
+	return data, rows.Err()
+}
@@ -2,9 +2,12 @@ package analytics
 
 import (
 	"context"
-	"github.com/google/uuid"
+	"errors"
 )
 
+// ErrHouseholdNotFound: household tidak ada atau user bukan anggotanya
+var ErrHouseholdNotFound = errors.New("household_not_found")
+
 type Service struct {
 	repo Repository
 }
@@ -13,14 +16,32 @@ func NewService(repo Repository) *Service {
 	return &Service{repo: repo}
 }
 
+// resolve mengisi pemilik data scope: user itu sendiri, atau pemilik household
+func (s *Service) resolve(ctx context.Context, sc Scope) (Scope, error) {
+	sc.OwnerID = sc.UserID
+	if sc.HouseholdID == nil {
+		return sc, nil
+	}
+	owner, err := s.repo.HouseholdOwner(ctx, sc.UserID, *sc.HouseholdID)
+	if err != nil {
+		return sc, err
+	}
+	sc.OwnerID = owner
+	return sc, nil
+}
+
 // GetDashboardData memanggil kedua repository dan menggabungkannya
-func (s *Service) GetDashboardData(ctx context.Context, userID uuid.UUID) (map[string]interface{}, error) {
-	breakdown, err := s.repo.GetExpenseByCategory(ctx, userID)
+func (s *Service) GetDashboardData(ctx context.Context, sc Scope) (map[string]interface{}, error) {
+	sc, err := s.resolve(ctx, sc)
+	if err != nil {
+		return nil, err
+	}
+	breakdown, err := s.repo.GetExpenseByCategory(ctx, sc)
 	if err != nil {
 		return nil, err
 	}
 	
-	monthly, err := s.repo.GetMonthlySummary(ctx, userID)
+	monthly, err := s.repo.GetMonthlySummary(ctx, sc)
 	if err != nil {
 		return nil, err
 	}
@@ -29,4 +50,22 @@ func (s *Service) GetDashboardData(ctx context.Context, userID uuid.UUID) (map[s
 		"breakdown": breakdown,
 		"monthly":   monthly,
 	}, nil
-}
\ No newline at end of file
+}
+
+// GetTagTotals: total per tag dalam periode p
+func (s *Service) GetTagTotals(ctx context.Context, sc Scope, p Period) ([]LabelTotal, error) {
+	sc, err := s.resolve(ctx, sc)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.GetTotalsByTag(ctx, sc, p)
+}
+
+// GetPayeeTotals: total per payee dalam periode p
+func (s *Service) GetPayeeTotals(ctx context.Context, sc Scope, p Period) ([]LabelTotal, error) {
+	sc, err := s.resolve(ctx, sc)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.GetTotalsByPayee(ctx, sc, p)
+}
@@ -1,7 +1,10 @@
 package analytics
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
+	"time"
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
 	"github.com/Jomesi149/Implementasi-LASTI/backend/pkg/response"
@@ -17,6 +20,8 @@ func NewHTTPHandler(s *Service) *HTTPHandler {
 
 func (h *HTTPHandler) RegisterRoutes(r chi.Router) {
 	r.Get("/analytics", h.handleGetAnalytics)
+	r.Get("/analytics/tags", h.handleGetTagTotals)
+	r.Get("/analytics/payees", h.handleGetPayeeTotals)
 }
 
 func (h *HTTPHandler) handleGetAnalytics(w http.ResponseWriter, r *http.Request) {
@@ -27,11 +32,110 @@ func (h *HTTPHandler) handleGetAnalytics(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	data, err := h.service.GetDashboardData(r.Context(), uid)
+	sc, err := parseScope(r, uid)
 	if err != nil {
-		response.Error(w, http.StatusInternalServerError, err.Error())
+		response.Error(w, http.StatusBadRequest, err.Error())
 		return
 	}
 
+	data, err := h.service.GetDashboardData(r.Context(), sc)
+	if err != nil {
+		response.Error(w, errorStatus(err), err.Error())
+		return
+	}
+
+	response.JSON(w, http.StatusOK, data)
+}
+
+func (h *HTTPHandler) handleGetTagTotals(w http.ResponseWriter, r *http.Request) {
+	uid, err := uuid.Parse(r.Header.Get("X-User-ID"))
+	if err != nil {
+		response.Error(w, http.StatusUnauthorized, "invalid user id")
+		return
+	}
+	p, err := parsePeriod(r)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	sc, err := parseScope(r, uid)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	data, err := h.service.GetTagTotals(r.Context(), sc, p)
+	if err != nil {
+		response.Error(w, errorStatus(err), err.Error())
+		return
+	}
 	response.JSON(w, http.StatusOK, data)
-}
\ No newline at end of file
+}
+
+func (h *HTTPHandler) handleGetPayeeTotals(w http.ResponseWriter, r *http.Request) {
+	uid, err := uuid.Parse(r.Header.Get("X-User-ID"))
+	if err != nil {
+		response.Error(w, http.StatusUnauthorized, "invalid user id")
+		return
+	}
+	p, err := parsePeriod(r)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	sc, err := parseScope(r, uid)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	data, err := h.service.GetPayeeTotals(r.Context(), sc, p)
+	if err != nil {
+		response.Error(w, errorStatus(err), err.Error())
+		return
+	}
+	response.JSON(w, http.StatusOK, data)
+}
+
+func errorStatus(err error) int {
+	if errors.Is(err, ErrHouseholdNotFound) {
+		return http.StatusNotFound
+	}
+	return http.StatusInternalServerError
+}
+
+// parseScope membaca ?household_id=; tanpa parameter ini laporan mencakup data user sendiri
+func parseScope(r *http.Request, uid uuid.UUID) (Scope, error) {
+	sc := Scope{UserID: uid}
+	if v := r.URL.Query().Get("household_id"); v != "" {
+		id, err := uuid.Parse(v)
+		if err != nil {
+			return sc, fmt.Errorf("invalid household_id")
+		}
+		sc.HouseholdID = &id
+	}
+	return sc, nil
+}
+
+// parsePeriod membaca ?from=YYYY-MM-DD&to=YYYY-MM-DD; tanggal "to" ikut dihitung
+func parsePeriod(r *http.Request) (Period, error) {
+	var p Period
+	if v := r.URL.Query().Get("from"); v != "" {
+		t, err := time.Parse("2006-01-02", v)
+		if err != nil {
+			return p, fmt.Errorf("invalid from")
+		}
+		p.From = &t
+	}
+	if v := r.URL.Query().Get("to"); v != "" {
+		t, err := time.Parse("2006-01-02", v)
+		if err != nil {
+			return p, fmt.Errorf("invalid to")
+		}
+		t = t.AddDate(0, 0, 1)
+		p.To = &t
+	}
+	return p, nil
+}
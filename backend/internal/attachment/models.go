@@ -0,0 +1,29 @@
+package attachment
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Attachment is a receipt photo or invoice stored for a transaction.
+type Attachment struct {
+	ID            uuid.UUID `json:"id"`
+	UserID        uuid.UUID `json:"user_id"`
+	TransactionID uuid.UUID `json:"transaction_id"`
+	Filename      string    `json:"filename"`
+	ContentType   string    `json:"content_type"`
+	Size          int64     `json:"size"`
+	HasThumbnail  bool      `json:"has_thumbnail"`
+	StorageKey    string    `json:"-"`
+	ThumbnailKey  *string   `json:"-"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// SignedURLs are short-lived download links that need no X-User-ID header, so they can
+// be used directly in <img> tags and browser downloads.
+type SignedURLs struct {
+	URL          string    `json:"url"`
+	ThumbnailURL string    `json:"thumbnail_url,omitempty"`
+	ExpiresAt    time.Time `json:"expires_at"`
+}
@@ -0,0 +1,104 @@
+package attachment
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+// Repository persists attachment metadata; file contents live in storage.
+type Repository interface {
+	Create(ctx context.Context, a Attachment) error
+	List(ctx context.Context, userID, transactionID uuid.UUID) ([]Attachment, error)
+	Get(ctx context.Context, attachmentID uuid.UUID) (*Attachment, error)
+	Delete(ctx context.Context, attachmentID uuid.UUID) error
+}
+
+type SQLRepository struct {
+	db *sql.DB
+}
+
+func NewRepository(db *sql.DB) *SQLRepository {
+	return &SQLRepository{db: db}
+}
+
+const attachmentColumns = `id, user_id, transaction_id, filename, content_type, size_bytes, storage_key, thumbnail_key, created_at`
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanAttachment(row rowScanner) (Attachment, error) {
+	var a Attachment
+	var thumb sql.NullString
+	if err := row.Scan(&a.ID, &a.UserID, &a.TransactionID, &a.Filename, &a.ContentType, &a.Size, &a.StorageKey, &thumb, &a.CreatedAt); err != nil {
+		return a, err
+	}
+	if thumb.Valid {
+		k := thumb.String
+		a.ThumbnailKey = &k
+		a.HasThumbnail = true
+	}
+	return a, nil
+}
+
+func (r *SQLRepository) Create(ctx context.Context, a Attachment) error {
+	query := `INSERT INTO finance.attachments (id, user_id, transaction_id, filename, content_type, size_bytes, storage_key, thumbnail_key, created_at)
+		VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9)`
+	_, err := r.db.ExecContext(ctx, query, a.ID, a.UserID, a.TransactionID, a.Filename, a.ContentType, a.Size, a.StorageKey, a.ThumbnailKey, a.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("insert attachment: %w", err)
+	}
+	return nil
+}
+
+func (r *SQLRepository) List(ctx context.Context, userID, transactionID uuid.UUID) ([]Attachment, error) {
+	query := `SELECT ` + attachmentColumns + ` FROM finance.attachments
+		WHERE user_id = $1 AND transaction_id = $2 ORDER BY created_at ASC`
+	rows, err := r.db.QueryContext(ctx, query, userID, transactionID)
+	if err != nil {
+		return nil, fmt.Errorf("list attachments: %w", err)
+	}
+	defer rows.Close()
+
+	out := []Attachment{}
+	for rows.Next() {
+		a, err := scanAttachment(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, a)
+	}
+	return out, rows.Err()
+}
+
+// Get loads an attachment by id; callers check ownership.
+func (r *SQLRepository) Get(ctx context.Context, attachmentID uuid.UUID) (*Attachment, error) {
+	query := `SELECT ` + attachmentColumns + ` FROM finance.attachments WHERE id = $1`
+	a, err := scanAttachment(r.db.QueryRowContext(ctx, query, attachmentID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrAttachmentNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get attachment: %w", err)
+	}
+	return &a, nil
+}
+
+func (r *SQLRepository) Delete(ctx context.Context, attachmentID uuid.UUID) error {
+	res, err := r.db.ExecContext(ctx, `DELETE FROM finance.attachments WHERE id = $1`, attachmentID)
+	if err != nil {
+		return fmt.Errorf("delete attachment: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrAttachmentNotFound
+	}
+	return nil
+}
@@ -0,0 +1,272 @@
+package attachment
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/Jomesi149/Implementasi-LASTI/backend/internal/storage"
+	"github.com/Jomesi149/Implementasi-LASTI/backend/internal/transaction"
+)
+
+// MaxAttachmentSize caps one uploaded file.
+const MaxAttachmentSize = 10 << 20
+
+// Download variants.
+const (
+	VariantOriginal  = "original"
+	VariantThumbnail = "thumbnail"
+)
+
+var (
+	// ErrAttachmentNotFound is returned when the attachment does not exist for the user.
+	ErrAttachmentNotFound = errors.New("attachment_not_found")
+	// ErrInvalidAttachment indicates an empty file or a type that is not accepted.
+	ErrInvalidAttachment = errors.New("invalid_attachment")
+	// ErrAttachmentTooLarge is returned for files over MaxAttachmentSize.
+	ErrAttachmentTooLarge = errors.New("attachment_too_large")
+	// ErrInvalidSignature is returned for a download link that is forged or expired.
+	ErrInvalidSignature = errors.New("invalid_signature")
+)
+
+// allowedTypes maps accepted content types, detected from the file bytes rather than
+// the client's header, to the extension used in the storage key.
+var allowedTypes = map[string]string{
+	"image/jpeg":      ".jpg",
+	"image/png":       ".png",
+	"image/gif":       ".gif",
+	"image/webp":      ".webp",
+	"application/pdf": ".pdf",
+}
+
+type ServiceDeps struct {
+	Repo         Repository
+	Store        storage.Store
+	Transactions *transaction.Service
+	// SigningKey authenticates download links; URLTTL is how long they stay valid.
+	SigningKey []byte
+	URLTTL     time.Duration
+	// BasePath prefixes generated download links, e.g. /api/v1.
+	BasePath string
+}
+
+type Service struct {
+	repo         Repository
+	store        storage.Store
+	transactions *transaction.Service
+	signingKey   []byte
+	urlTTL       time.Duration
+	basePath     string
+	now          func() time.Time
+}
+
+func NewService(deps ServiceDeps) *Service {
+	ttl := deps.URLTTL
+	if ttl <= 0 {
+		ttl = 5 * time.Minute
+	}
+	return &Service{
+		repo:         deps.Repo,
+		store:        deps.Store,
+		transactions: deps.Transactions,
+		signingKey:   deps.SigningKey,
+		urlTTL:       ttl,
+		basePath:     strings.TrimSuffix(deps.BasePath, "/"),
+		now:          time.Now,
+	}
+}
+
+// Upload validates content, stores it (plus a thumbnail for images) and records the
+// attachment on the transaction.
+func (s *Service) Upload(ctx context.Context, userID, transactionID uuid.UUID, filename string, content []byte) (*Attachment, error) {
+	if _, err := s.transactions.GetTransaction(ctx, userID, transactionID); err != nil {
+		return nil, err
+	}
+	if len(content) == 0 {
+		return nil, fmt.Errorf("%w: file is empty", ErrInvalidAttachment)
+	}
+	if len(content) > MaxAttachmentSize {
+		return nil, fmt.Errorf("%w: limit is %d MB", ErrAttachmentTooLarge, MaxAttachmentSize>>20)
+	}
+	contentType := http.DetectContentType(content)
+	if i := strings.IndexByte(contentType, ';'); i >= 0 {
+		contentType = contentType[:i]
+	}
+	ext, ok := allowedTypes[contentType]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s is not an accepted file type", ErrInvalidAttachment, contentType)
+	}
+
+	a := Attachment{
+		ID:            uuid.New(),
+		UserID:        userID,
+		TransactionID: transactionID,
+		Filename:      cleanFilename(filename, ext),
+		ContentType:   contentType,
+		Size:          int64(len(content)),
+		CreatedAt:     s.now(),
+	}
+	a.StorageKey = fmt.Sprintf("attachments/%s/%s%s", userID, a.ID, ext)
+	if err := s.store.Put(ctx, a.StorageKey, bytes.NewReader(content), a.Size, contentType); err != nil {
+		return nil, fmt.Errorf("store attachment: %w", err)
+	}
+
+	if thumbnailable[contentType] {
+		// Gagal membuat thumbnail tidak menggagalkan upload
+		if thumb, err := makeThumbnail(content); err != nil {
+			log.Printf("[ATTACHMENT] no thumbnail for %s: %v", a.ID, err)
+		} else {
+			key := fmt.Sprintf("attachments/%s/%s_thumb.jpg", userID, a.ID)
+			if err := s.store.Put(ctx, key, bytes.NewReader(thumb), int64(len(thumb)), "image/jpeg"); err != nil {
+				log.Printf("[ATTACHMENT_ERROR] store thumbnail %s: %v", a.ID, err)
+			} else {
+				a.ThumbnailKey = &key
+				a.HasThumbnail = true
+			}
+		}
+	}
+
+	if err := s.repo.Create(ctx, a); err != nil {
+		s.removeObjects(ctx, a)
+		return nil, err
+	}
+	return &a, nil
+}
+
+// List returns the attachments of a transaction, oldest first.
+func (s *Service) List(ctx context.Context, userID, transactionID uuid.UUID) ([]Attachment, error) {
+	if _, err := s.transactions.GetTransaction(ctx, userID, transactionID); err != nil {
+		return nil, err
+	}
+	return s.repo.List(ctx, userID, transactionID)
+}
+
+// Delete removes the attachment record and its stored files.
+func (s *Service) Delete(ctx context.Context, userID, transactionID, attachmentID uuid.UUID) error {
+	a, err := s.get(ctx, userID, transactionID, attachmentID)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.Delete(ctx, a.ID); err != nil {
+		return err
+	}
+	s.removeObjects(ctx, *a)
+	return nil
+}
+
+// SignURLs issues download links for the attachment and its thumbnail.
+func (s *Service) SignURLs(ctx context.Context, userID, transactionID, attachmentID uuid.UUID) (*SignedURLs, error) {
+	a, err := s.get(ctx, userID, transactionID, attachmentID)
+	if err != nil {
+		return nil, err
+	}
+	expires := s.now().Add(s.urlTTL).Truncate(time.Second)
+	out := &SignedURLs{URL: s.signedURL(a.ID, VariantOriginal, expires), ExpiresAt: expires}
+	if a.HasThumbnail {
+		out.ThumbnailURL = s.signedURL(a.ID, VariantThumbnail, expires)
+	}
+	return out, nil
+}
+
+// Open checks a download link and returns the attachment with a reader for the
+// requested variant. The caller closes the reader.
+func (s *Service) Open(ctx context.Context, attachmentID uuid.UUID, variant string, expires int64, sig string) (*Attachment, io.ReadCloser, error) {
+	if s.now().Unix() > expires {
+		return nil, nil, fmt.Errorf("%w: link expired", ErrInvalidSignature)
+	}
+	want := s.signature(attachmentID, variant, expires)
+	if !hmac.Equal([]byte(sig), []byte(want)) {
+		return nil, nil, ErrInvalidSignature
+	}
+
+	a, err := s.repo.Get(ctx, attachmentID)
+	if err != nil {
+		return nil, nil, err
+	}
+	key := a.StorageKey
+	if variant == VariantThumbnail {
+		if a.ThumbnailKey == nil {
+			return nil, nil, ErrAttachmentNotFound
+		}
+		key = *a.ThumbnailKey
+		a.ContentType = "image/jpeg"
+	}
+	rc, err := s.store.Get(ctx, key)
+	if errors.Is(err, storage.ErrNotFound) {
+		return nil, nil, ErrAttachmentNotFound
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	return a, rc, nil
+}
+
+func (s *Service) get(ctx context.Context, userID, transactionID, attachmentID uuid.UUID) (*Attachment, error) {
+	a, err := s.repo.Get(ctx, attachmentID)
+	if err != nil {
+		return nil, err
+	}
+	if a.UserID != userID || a.TransactionID != transactionID {
+		return nil, ErrAttachmentNotFound
+	}
+	return a, nil
+}
+
+func (s *Service) removeObjects(ctx context.Context, a Attachment) {
+	keys := []string{a.StorageKey}
+	if a.ThumbnailKey != nil {
+		keys = append(keys, *a.ThumbnailKey)
+	}
+	for _, key := range keys {
+		if err := s.store.Delete(ctx, key); err != nil {
+			log.Printf("[ATTACHMENT_ERROR] delete object %s: %v", key, err)
+		}
+	}
+}
+
+func (s *Service) signedURL(id uuid.UUID, variant string, expires time.Time) string {
+	q := url.Values{}
+	q.Set("variant", variant)
+	q.Set("expires", strconv.FormatInt(expires.Unix(), 10))
+	q.Set("sig", s.signature(id, variant, expires.Unix()))
+	return fmt.Sprintf("%s/attachments/%s/download?%s", s.basePath, id, q.Encode())
+}
+
+func (s *Service) signature(id uuid.UUID, variant string, expires int64) string {
+	mac := hmac.New(sha256.New, s.signingKey)
+	fmt.Fprintf(mac, "%s|%s|%d", id, variant, expires)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// cleanFilename keeps only the base name of the client's file name and falls back to a
+// generic name with the detected extension.
+func cleanFilename(name, ext string) string {
+	name = strings.TrimSpace(filepath.Base(strings.ReplaceAll(name, "\\", "/")))
+	name = strings.Map(func(r rune) rune {
+		if r < 0x20 || r == 0x7f {
+			return -1
+		}
+		return r
+	}, name)
+	if name == "" || name == "." || name == "/" {
+		return "attachment" + ext
+	}
+	if len(name) > 255 {
+		name = name[:255]
+	}
+	return name
+}
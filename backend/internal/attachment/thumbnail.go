@@ -0,0 +1,94 @@
+package attachment
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+
+	// Decoder untuk format yang bisa dibuatkan thumbnail
+	_ "image/gif"
+	_ "image/png"
+)
+
+const (
+	thumbnailSize    = 320
+	thumbnailQuality = 80
+	// maxImagePixels guards against decompression bombs: a small file can declare a
+	// huge canvas.
+	maxImagePixels = 50_000_000
+)
+
+// thumbnailable lists the types the standard library can decode.
+var thumbnailable = map[string]bool{"image/jpeg": true, "image/png": true, "image/gif": true}
+
+// makeThumbnail scales an image down to fit a thumbnailSize square and encodes it as JPEG.
+// Smaller images keep their size.
+func makeThumbnail(data []byte) ([]byte, error) {
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Width*cfg.Height > maxImagePixels {
+		return nil, image.ErrFormat
+	}
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, scaleDown(src, thumbnailSize), &jpeg.Options{Quality: thumbnailQuality}); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// scaleDown resizes src with a box filter so each output pixel averages the source
+// pixels it covers. Transparent areas are flattened onto white since JPEG has no alpha.
+func scaleDown(src image.Image, max int) image.Image {
+	b := src.Bounds()
+	w, h := b.Dx(), b.Dy()
+	dw, dh := w, h
+	if w > max || h > max {
+		if w >= h {
+			dw, dh = max, h*max/w
+		} else {
+			dw, dh = w*max/h, max
+		}
+	}
+	if dw < 1 {
+		dw = 1
+	}
+	if dh < 1 {
+		dh = 1
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+	for y := 0; y < dh; y++ {
+		y0, y1 := b.Min.Y+y*h/dh, b.Min.Y+(y+1)*h/dh
+		if y1 == y0 {
+			y1++
+		}
+		for x := 0; x < dw; x++ {
+			x0, x1 := b.Min.X+x*w/dw, b.Min.X+(x+1)*w/dw
+			if x1 == x0 {
+				x1++
+			}
+			var r, g, bl, n uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					cr, cg, cb, ca := src.At(sx, sy).RGBA()
+					// Premultiplied alpha: tambahkan putih untuk bagian transparan
+					white := 0xffff - uint64(ca)
+					r += uint64(cr) + white
+					g += uint64(cg) + white
+					bl += uint64(cb) + white
+					n++
+				}
+			}
+			dst.Set(x, y, color.RGBA64{R: uint16(r / n), G: uint16(g / n), B: uint16(bl / n), A: 0xffff})
+		}
+	}
+	return dst
+}
@@ -0,0 +1,193 @@
+package attachment
+
+import (
+	"errors"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"github.com/Jomesi149/Implementasi-LASTI/backend/internal/transaction"
+	"github.com/Jomesi149/Implementasi-LASTI/backend/pkg/response"
+)
+
+type HTTPHandler struct {
+	service *Service
+}
+
+func NewHTTPHandler(s *Service) *HTTPHandler {
+	return &HTTPHandler{service: s}
+}
+
+func (h *HTTPHandler) RegisterRoutes(r chi.Router) {
+	r.Route("/transactions/{id}/attachments", func(r chi.Router) {
+		r.Post("/", h.handleUpload)
+		r.Get("/", h.handleList)
+		r.Get("/{attachmentID}/url", h.handleSignURL)
+		r.Delete("/{attachmentID}", h.handleDelete)
+	})
+	// Tanpa X-User-ID: link ditandatangani dan hanya berlaku sebentar
+	r.Get("/attachments/{attachmentID}/download", h.handleDownload)
+}
+
+// errorStatus maps service sentinel errors onto HTTP status codes.
+func errorStatus(err error) int {
+	switch {
+	case errors.Is(err, ErrAttachmentNotFound), errors.Is(err, transaction.ErrTransactionNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, ErrInvalidAttachment):
+		return http.StatusBadRequest
+	case errors.Is(err, ErrAttachmentTooLarge):
+		return http.StatusRequestEntityTooLarge
+	case errors.Is(err, ErrInvalidSignature):
+		return http.StatusForbidden
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// pathParams reads the user id header, the transaction {id} and, when withAttachment
+// is set, the {attachmentID} URL parameter.
+func pathParams(w http.ResponseWriter, r *http.Request, withAttachment bool) (uid, txID, attachmentID uuid.UUID, ok bool) {
+	uid, err := uuid.Parse(r.Header.Get("X-User-ID"))
+	if err != nil {
+		response.Error(w, http.StatusUnauthorized, "missing X-User-ID header")
+		return
+	}
+	if txID, err = uuid.Parse(chi.URLParam(r, "id")); err != nil {
+		response.Error(w, http.StatusBadRequest, "invalid transaction id")
+		return
+	}
+	if withAttachment {
+		if attachmentID, err = uuid.Parse(chi.URLParam(r, "attachmentID")); err != nil {
+			response.Error(w, http.StatusBadRequest, "invalid attachment id")
+			return
+		}
+	}
+	return uid, txID, attachmentID, true
+}
+
+// handleUpload accepts a multipart form with a single "file" field.
+func (h *HTTPHandler) handleUpload(w http.ResponseWriter, r *http.Request) {
+	uid, txID, _, ok := pathParams(w, r, false)
+	if !ok {
+		return
+	}
+	r.Body = http.MaxBytesReader(w, r.Body, MaxAttachmentSize+1<<20)
+	if err := r.ParseMultipartForm(MaxAttachmentSize); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			response.Error(w, http.StatusRequestEntityTooLarge, "file is too large")
+			return
+		}
+		response.Error(w, http.StatusBadRequest, "invalid multipart payload")
+		return
+	}
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "file is required")
+		return
+	}
+	defer file.Close()
+
+	content, err := io.ReadAll(io.LimitReader(file, MaxAttachmentSize+1))
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "cannot read file")
+		return
+	}
+	a, err := h.service.Upload(r.Context(), uid, txID, header.Filename, content)
+	if err != nil {
+		response.Error(w, errorStatus(err), err.Error())
+		return
+	}
+	response.JSON(w, http.StatusCreated, a)
+}
+
+func (h *HTTPHandler) handleList(w http.ResponseWriter, r *http.Request) {
+	uid, txID, _, ok := pathParams(w, r, false)
+	if !ok {
+		return
+	}
+	list, err := h.service.List(r.Context(), uid, txID)
+	if err != nil {
+		response.Error(w, errorStatus(err), err.Error())
+		return
+	}
+	response.JSON(w, http.StatusOK, list)
+}
+
+func (h *HTTPHandler) handleSignURL(w http.ResponseWriter, r *http.Request) {
+	uid, txID, aid, ok := pathParams(w, r, true)
+	if !ok {
+		return
+	}
+	urls, err := h.service.SignURLs(r.Context(), uid, txID, aid)
+	if err != nil {
+		response.Error(w, errorStatus(err), err.Error())
+		return
+	}
+	response.JSON(w, http.StatusOK, urls)
+}
+
+func (h *HTTPHandler) handleDelete(w http.ResponseWriter, r *http.Request) {
+	uid, txID, aid, ok := pathParams(w, r, true)
+	if !ok {
+		return
+	}
+	if err := h.service.Delete(r.Context(), uid, txID, aid); err != nil {
+		response.Error(w, errorStatus(err), err.Error())
+		return
+	}
+	response.JSON(w, http.StatusOK, map[string]string{"status": "attachment deleted"})
+}
+
+// handleDownload streams a file for a link issued by handleSignURL.
+func (h *HTTPHandler) handleDownload(w http.ResponseWriter, r *http.Request) {
+	aid, err := uuid.Parse(chi.URLParam(r, "attachmentID"))
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "invalid attachment id")
+		return
+	}
+	q := r.URL.Query()
+	expires, err := strconv.ParseInt(q.Get("expires"), 10, 64)
+	if err != nil {
+		response.Error(w, http.StatusForbidden, ErrInvalidSignature.Error())
+		return
+	}
+	variant := q.Get("variant")
+	if variant == "" {
+		variant = VariantOriginal
+	}
+
+	a, rc, err := h.service.Open(r.Context(), aid, variant, expires, q.Get("sig"))
+	if err != nil {
+		response.Error(w, errorStatus(err), err.Error())
+		return
+	}
+	defer rc.Close()
+
+	w.Header().Set("Content-Type", a.ContentType)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	if variant == VariantOriginal {
+		w.Header().Set("Content-Length", strconv.FormatInt(a.Size, 10))
+	}
+	disposition := mime.FormatMediaType("inline", map[string]string{"filename": a.Filename})
+	if disposition == "" {
+		disposition = "inline"
+	}
+	w.Header().Set("Content-Disposition", disposition)
+	maxAge := expires - time.Now().Unix()
+	if maxAge < 0 {
+		maxAge = 0
+	}
+	w.Header().Set("Cache-Control", "private, max-age="+strconv.FormatInt(maxAge, 10))
+	w.WriteHeader(http.StatusOK)
+	if _, err := io.Copy(w, rc); err != nil {
+		log.Printf("[ATTACHMENT_ERROR] download %s: %v", aid, err)
+	}
+}
@@ -0,0 +1,43 @@
+package bill
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// Matcher periodically pairs the open due dates of bills with the transactions that
+// paid them, so reading bills never writes.
+type Matcher struct {
+	service  *Service
+	interval time.Duration
+}
+
+func NewMatcher(service *Service, interval time.Duration) *Matcher {
+	if interval <= 0 {
+		interval = 10 * time.Minute
+	}
+	return &Matcher{service: service, interval: interval}
+}
+
+// Run matches immediately and then on every tick until ctx is cancelled.
+func (m *Matcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(m.interval)
+	defer ticker.Stop()
+
+	for {
+		n, err := m.service.MatchPayments(ctx, time.Now())
+		if err != nil && ctx.Err() == nil {
+			log.Printf("[BILL_ERROR] match: %v", err)
+		}
+		if n > 0 {
+			log.Printf("[BILL] matched %d bill payment(s)", n)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package bill
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Payment methods.
+const (
+	MethodAuto   = "auto"
+	MethodManual = "manual"
+)
+
+// Due statuses.
+const (
+	StatusDue     = "due"
+	StatusOverdue = "overdue"
+	StatusPaid    = "paid"
+)
+
+// Bill is a payment expected from a wallet to a payee on a schedule. Unlike a recurring
+// rule it never posts a transaction: it is paid by one the user records, which is
+// matched to the due date by payee and amount. Dates are calendar dates stored as UTC
+// midnight.
+type Bill struct {
+	ID           uuid.UUID  `json:"id"`
+	UserID       uuid.UUID  `json:"user_id"`
+	Name         string     `json:"name"`
+	PayeeID      uuid.UUID  `json:"payee_id"`
+	PayeeName    string     `json:"payee_name"`
+	WalletID     uuid.UUID  `json:"wallet_id"`
+	Currency     string     `json:"currency"`
+	Amount       string     `json:"amount"`
+	Tolerance    string     `json:"tolerance"`
+	Frequency    string     `json:"frequency"`
+	Interval     int        `json:"interval"`
+	DayOfMonth   *int       `json:"day_of_month,omitempty"`
+	StartDate    time.Time  `json:"start_date"`
+	EndDate      *time.Time `json:"end_date,omitempty"`
+	ReminderDays int        `json:"reminder_days"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
+
+// Payment marks one due date of a bill as paid, by a transaction or, when TransactionID
+// is nil, by hand.
+type Payment struct {
+	BillID        uuid.UUID  `json:"bill_id"`
+	DueDate       time.Time  `json:"due_date"`
+	TransactionID *uuid.UUID `json:"transaction_id,omitempty"`
+	Amount        *string    `json:"amount,omitempty"`
+	PaidAt        *time.Time `json:"paid_at,omitempty"`
+	Method        string     `json:"method"`
+	MatchedAt     time.Time  `json:"matched_at"`
+}
+
+// BillDetail is a bill with its next due date and recent payments.
+type BillDetail struct {
+	Bill
+	NextDue  *time.Time `json:"next_due,omitempty"`
+	Payments []Payment  `json:"payments"`
+}
+
+// Due is one due date of a bill in the upcoming view.
+type Due struct {
+	BillID    uuid.UUID `json:"bill_id"`
+	Name      string    `json:"name"`
+	PayeeID   uuid.UUID `json:"payee_id"`
+	PayeeName string    `json:"payee_name"`
+	WalletID  uuid.UUID `json:"wallet_id"`
+	Currency  string    `json:"currency"`
+	Amount    string    `json:"amount"`
+	DueDate   time.Time `json:"due_date"`
+	DaysUntil int       `json:"days_until"`
+	Status    string    `json:"status"`
+	Payment   *Payment  `json:"payment,omitempty"`
+}
+
+// BillRequest is the payload to create or edit a bill. Dates use the YYYY-MM-DD format.
+type BillRequest struct {
+	Name         string  `json:"name"`
+	PayeeID      string  `json:"payee_id"`
+	WalletID     string  `json:"wallet_id"`
+	Amount       string  `json:"amount"`
+	Tolerance    *string `json:"tolerance"`
+	Frequency    string  `json:"frequency"`
+	Interval     int     `json:"interval"`
+	DayOfMonth   *int    `json:"day_of_month"`
+	StartDate    string  `json:"start_date"`
+	EndDate      *string `json:"end_date"`
+	ReminderDays *int    `json:"reminder_days"`
+}
+
+// PaymentRequest marks a due date as paid, by the given transaction or without one.
+type PaymentRequest struct {
+	DueDate       string  `json:"due_date"`
+	TransactionID *string `json:"transaction_id"`
+}
@@ -0,0 +1,293 @@
+package bill
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
+
+	"github.com/Jomesi149/Implementasi-LASTI/backend/internal/sqlutil"
+)
+
+// Repository persists bills and the payments matched to their due dates.
+type Repository interface {
+	Create(ctx context.Context, b Bill) error
+	List(ctx context.Context, userID uuid.UUID) ([]Bill, error)
+	All(ctx context.Context) ([]Bill, error)
+	Get(ctx context.Context, userID, id uuid.UUID) (*Bill, error)
+	Update(ctx context.Context, b Bill) error
+	Delete(ctx context.Context, userID, id uuid.UUID) error
+	PayeeExists(ctx context.Context, userID, payeeID uuid.UUID) (bool, error)
+
+	Payments(ctx context.Context, billIDs []uuid.UUID, from, to time.Time, limit int) ([]Payment, error)
+	Candidates(ctx context.Context, b Bill, from, to time.Time) ([]Candidate, error)
+	RecordMatch(ctx context.Context, billID uuid.UUID, due time.Time, transactionID uuid.UUID) (bool, error)
+	SetPayment(ctx context.Context, billID uuid.UUID, due time.Time, transactionID *uuid.UUID) error
+	DeletePayment(ctx context.Context, billID uuid.UUID, due time.Time) error
+}
+
+// Candidate is a transaction that could pay a bill.
+type Candidate struct {
+	ID         uuid.UUID
+	OccurredAt time.Time
+	Amount     string
+}
+
+type SQLRepository struct {
+	db *sql.DB
+}
+
+func NewRepository(db *sql.DB) *SQLRepository {
+	return &SQLRepository{db: db}
+}
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+// billColumns selects a bill with its payee name and wallet currency from billTables.
+// Bills of a wallet in the trash are hidden until the wallet is restored.
+const (
+	billColumns = `b.id, b.user_id, b.name, b.payee_id, p.name, b.wallet_id, w.currency, b.amount::TEXT, b.tolerance::TEXT,
+	b.frequency, b.interval_count, b.day_of_month, b.start_date, b.end_date, b.reminder_days, b.created_at, b.updated_at`
+	billTables = `finance.bills b JOIN finance.payees p ON p.id = b.payee_id
+	JOIN finance.wallets w ON w.id = b.wallet_id AND w.deleted_at IS NULL`
+)
+
+func scanBill(row rowScanner) (Bill, error) {
+	var b Bill
+	var dayOfMonth sql.NullInt64
+	var endDate sql.NullTime
+	if err := row.Scan(&b.ID, &b.UserID, &b.Name, &b.PayeeID, &b.PayeeName, &b.WalletID, &b.Currency, &b.Amount, &b.Tolerance,
+		&b.Frequency, &b.Interval, &dayOfMonth, &b.StartDate, &endDate, &b.ReminderDays, &b.CreatedAt, &b.UpdatedAt); err != nil {
+		return b, err
+	}
+	if dayOfMonth.Valid {
+		d := int(dayOfMonth.Int64)
+		b.DayOfMonth = &d
+	}
+	if endDate.Valid {
+		d := endDate.Time
+		b.EndDate = &d
+	}
+	return b, nil
+}
+
+func (r *SQLRepository) Create(ctx context.Context, b Bill) error {
+	query := `INSERT INTO finance.bills (id, user_id, name, payee_id, wallet_id, amount, tolerance, frequency, interval_count,
+		day_of_month, start_date, end_date, reminder_days, created_at, updated_at)
+		VALUES ($1,$2,$3,$4,$5,$6::NUMERIC,$7::NUMERIC,$8,$9,$10,$11::DATE,$12::DATE,$13,$14,$14)`
+	if _, err := r.db.ExecContext(ctx, query, b.ID, b.UserID, b.Name, b.PayeeID, b.WalletID, b.Amount, b.Tolerance, b.Frequency,
+		b.Interval, b.DayOfMonth, b.StartDate.Format(dateLayout), sqlutil.DateParam(b.EndDate), b.ReminderDays, b.CreatedAt); err != nil {
+		return fmt.Errorf("insert bill: %w", err)
+	}
+	return nil
+}
+
+func (r *SQLRepository) list(ctx context.Context, where string, args ...any) ([]Bill, error) {
+	query := fmt.Sprintf(`SELECT %s FROM %s %s ORDER BY b.name, b.id`, billColumns, billTables, where)
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("select bills: %w", err)
+	}
+	defer rows.Close()
+
+	out := []Bill{}
+	for rows.Next() {
+		b, err := scanBill(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, b)
+	}
+	return out, rows.Err()
+}
+
+func (r *SQLRepository) List(ctx context.Context, userID uuid.UUID) ([]Bill, error) {
+	return r.list(ctx, `WHERE b.user_id = $1`, userID)
+}
+
+// All lists the bills of every user, for the reminder pass.
+func (r *SQLRepository) All(ctx context.Context) ([]Bill, error) {
+	return r.list(ctx, ``)
+}
+
+func (r *SQLRepository) Get(ctx context.Context, userID, id uuid.UUID) (*Bill, error) {
+	query := fmt.Sprintf(`SELECT %s FROM %s WHERE b.user_id = $1 AND b.id = $2`, billColumns, billTables)
+	b, err := scanBill(r.db.QueryRowContext(ctx, query, userID, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrBillNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("select bill: %w", err)
+	}
+	return &b, nil
+}
+
+func (r *SQLRepository) Update(ctx context.Context, b Bill) error {
+	query := `UPDATE finance.bills SET name = $3, payee_id = $4, wallet_id = $5, amount = $6::NUMERIC, tolerance = $7::NUMERIC,
+		frequency = $8, interval_count = $9, day_of_month = $10, start_date = $11::DATE, end_date = $12::DATE, reminder_days = $13,
+		updated_at = NOW()
+		WHERE id = $1 AND user_id = $2`
+	res, err := r.db.ExecContext(ctx, query, b.ID, b.UserID, b.Name, b.PayeeID, b.WalletID, b.Amount, b.Tolerance, b.Frequency,
+		b.Interval, b.DayOfMonth, b.StartDate.Format(dateLayout), sqlutil.DateParam(b.EndDate), b.ReminderDays)
+	if err != nil {
+		return fmt.Errorf("update bill: %w", err)
+	}
+	return sqlutil.ExpectAffected(res, ErrBillNotFound)
+}
+
+// Delete removes a bill and its payments; the transactions stay.
+func (r *SQLRepository) Delete(ctx context.Context, userID, id uuid.UUID) error {
+	res, err := r.db.ExecContext(ctx, `DELETE FROM finance.bills WHERE id = $1 AND user_id = $2`, id, userID)
+	if err != nil {
+		return fmt.Errorf("delete bill: %w", err)
+	}
+	return sqlutil.ExpectAffected(res, ErrBillNotFound)
+}
+
+func (r *SQLRepository) PayeeExists(ctx context.Context, userID, payeeID uuid.UUID) (bool, error) {
+	var ok bool
+	err := r.db.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM finance.payees WHERE id = $1 AND user_id = $2)`,
+		payeeID, userID).Scan(&ok)
+	if err != nil {
+		return false, fmt.Errorf("select payee: %w", err)
+	}
+	return ok, nil
+}
+
+// Payments lists the payments of billIDs due from from through to, latest first, at most
+// limit of them when limit is positive. A payment whose transaction is in the trash does
+// not count.
+func (r *SQLRepository) Payments(ctx context.Context, billIDs []uuid.UUID, from, to time.Time, limit int) ([]Payment, error) {
+	var max any
+	if limit > 0 {
+		max = limit
+	}
+	query := `SELECT p.bill_id, p.due_date, p.transaction_id, t.amount::TEXT, t.occurred_at, p.method, p.matched_at
+		FROM finance.bill_payments p
+		LEFT JOIN finance.transactions t ON t.id = p.transaction_id
+		WHERE p.bill_id = ANY($1::uuid[]) AND p.due_date BETWEEN $2::DATE AND $3::DATE
+			AND (p.transaction_id IS NULL OR t.deleted_at IS NULL)
+		ORDER BY p.due_date DESC
+		LIMIT $4`
+	rows, err := r.db.QueryContext(ctx, query, uuidStrings(billIDs), from.Format(dateLayout), to.Format(dateLayout), max)
+	if err != nil {
+		return nil, fmt.Errorf("select bill payments: %w", err)
+	}
+	defer rows.Close()
+
+	out := []Payment{}
+	for rows.Next() {
+		var p Payment
+		var transactionID uuid.NullUUID
+		var amount sql.NullString
+		var paidAt sql.NullTime
+		if err := rows.Scan(&p.BillID, &p.DueDate, &transactionID, &amount, &paidAt, &p.Method, &p.MatchedAt); err != nil {
+			return nil, err
+		}
+		if transactionID.Valid {
+			id := transactionID.UUID
+			p.TransactionID = &id
+		}
+		if amount.Valid {
+			p.Amount = &amount.String
+		}
+		if paidAt.Valid {
+			t := paidAt.Time
+			p.PaidAt = &t
+		}
+		out = append(out, p)
+	}
+	return out, rows.Err()
+}
+
+// Candidates lists the live outgoing transactions to the bill's payee from its wallet,
+// between from and to and within the amount tolerance, that pay no bill yet.
+func (r *SQLRepository) Candidates(ctx context.Context, b Bill, from, to time.Time) ([]Candidate, error) {
+	query := `SELECT t.id, t.occurred_at, t.amount::TEXT FROM finance.transactions t
+		WHERE t.user_id = $1 AND t.wallet_id = $2 AND t.payee_id = $3 AND t.kind = 'out' AND t.deleted_at IS NULL AND NOT t.planned
+			AND t.amount BETWEEN $4::NUMERIC - $5::NUMERIC AND $4::NUMERIC + $5::NUMERIC
+			AND t.occurred_at >= $6::DATE AND t.occurred_at < $7::DATE + 1
+			AND NOT EXISTS (SELECT 1 FROM finance.bill_payments p WHERE p.transaction_id = t.id)
+		ORDER BY t.occurred_at, t.id`
+	rows, err := r.db.QueryContext(ctx, query, b.UserID, b.WalletID, b.PayeeID, b.Amount, b.Tolerance,
+		from.Format(dateLayout), to.Format(dateLayout))
+	if err != nil {
+		return nil, fmt.Errorf("select bill candidates: %w", err)
+	}
+	defer rows.Close()
+
+	var out []Candidate
+	for rows.Next() {
+		var c Candidate
+		if err := rows.Scan(&c.ID, &c.OccurredAt, &c.Amount); err != nil {
+			return nil, err
+		}
+		out = append(out, c)
+	}
+	return out, rows.Err()
+}
+
+// RecordMatch stores an automatic match. It does not replace a manual payment or one
+// whose transaction is live, and reports false when the due date or the transaction was
+// taken in the meantime.
+func (r *SQLRepository) RecordMatch(ctx context.Context, billID uuid.UUID, due time.Time, transactionID uuid.UUID) (bool, error) {
+	query := `INSERT INTO finance.bill_payments (bill_id, due_date, transaction_id, method, matched_at)
+		VALUES ($1, $2::DATE, $3, 'auto', NOW())
+		ON CONFLICT (bill_id, due_date) DO UPDATE
+			SET transaction_id = EXCLUDED.transaction_id, method = EXCLUDED.method, matched_at = EXCLUDED.matched_at
+			WHERE finance.bill_payments.transaction_id IS NOT NULL AND NOT EXISTS (
+				SELECT 1 FROM finance.transactions t WHERE t.id = finance.bill_payments.transaction_id AND t.deleted_at IS NULL)`
+	res, err := r.db.ExecContext(ctx, query, billID, due.Format(dateLayout), transactionID)
+	if isUniqueViolation(err, "idx_bill_payments_transaction_id") {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("insert bill payment: %w", err)
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// SetPayment marks a due date as paid by hand, replacing any earlier payment.
+func (r *SQLRepository) SetPayment(ctx context.Context, billID uuid.UUID, due time.Time, transactionID *uuid.UUID) error {
+	query := `INSERT INTO finance.bill_payments (bill_id, due_date, transaction_id, method, matched_at)
+		VALUES ($1, $2::DATE, $3, 'manual', NOW())
+		ON CONFLICT (bill_id, due_date) DO UPDATE
+			SET transaction_id = EXCLUDED.transaction_id, method = EXCLUDED.method, matched_at = EXCLUDED.matched_at`
+	_, err := r.db.ExecContext(ctx, query, billID, due.Format(dateLayout), transactionID)
+	if isUniqueViolation(err, "idx_bill_payments_transaction_id") {
+		return fmt.Errorf("%w: the transaction already pays a bill", ErrInvalidBill)
+	}
+	if err != nil {
+		return fmt.Errorf("insert bill payment: %w", err)
+	}
+	return nil
+}
+
+func (r *SQLRepository) DeletePayment(ctx context.Context, billID uuid.UUID, due time.Time) error {
+	res, err := r.db.ExecContext(ctx, `DELETE FROM finance.bill_payments WHERE bill_id = $1 AND due_date = $2::DATE`,
+		billID, due.Format(dateLayout))
+	if err != nil {
+		return fmt.Errorf("delete bill payment: %w", err)
+	}
+	return sqlutil.ExpectAffected(res, ErrPaymentNotFound)
+}
+
+func uuidStrings(ids []uuid.UUID) []string {
+	out := make([]string, len(ids))
+	for i, id := range ids {
+		out[i] = id.String()
+	}
+	return out
+}
+
+func isUniqueViolation(err error, constraint string) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23505" && pgErr.ConstraintName == constraint
+}
@@ -0,0 +1,474 @@
+package bill
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/Jomesi149/Implementasi-LASTI/backend/internal/notification"
+	"github.com/Jomesi149/Implementasi-LASTI/backend/internal/recurring"
+	"github.com/Jomesi149/Implementasi-LASTI/backend/internal/sqlutil"
+	"github.com/Jomesi149/Implementasi-LASTI/backend/internal/transaction"
+)
+
+var (
+	// ErrBillNotFound is returned when the bill does not exist for the user.
+	ErrBillNotFound = errors.New("bill_not_found")
+	// ErrPaymentNotFound is returned when the due date of a bill has no payment.
+	ErrPaymentNotFound = errors.New("bill_payment_not_found")
+	// ErrInvalidBill indicates a bill or payment payload with a bad schedule, amount or date.
+	ErrInvalidBill = errors.New("invalid_bill")
+)
+
+// NotificationBillDue is the notification kind of bill reminders.
+const NotificationBillDue = "bill_due"
+
+const (
+	dateLayout = "2006-01-02"
+	maxName    = 100
+	// matchWindowDays is how many days before or after a due date a payment may be made.
+	matchWindowDays = 7
+	// overdueDays is how far back unpaid due dates are matched and listed as overdue.
+	overdueDays         = 31
+	defaultReminderDays = 3
+	maxReminderDays     = 60
+	// historyLimit is how many payments a bill detail shows.
+	historyLimit = 12
+	// DefaultUpcomingDays and MaxUpcomingDays bound the upcoming view.
+	DefaultUpcomingDays = 30
+	MaxUpcomingDays     = 366
+)
+
+type ServiceDeps struct {
+	Repo         Repository
+	Transactions *transaction.Service
+}
+
+type Service struct {
+	repo         Repository
+	transactions *transaction.Service
+	now          func() time.Time
+}
+
+func NewService(deps ServiceDeps) *Service {
+	return &Service{repo: deps.Repo, transactions: deps.Transactions, now: time.Now}
+}
+
+// dateOf returns the calendar date of t (in t's location) as UTC midnight.
+func dateOf(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+// dueDates lists the due dates of b from from through to. The schedule works like that
+// of a recurring rule, which never pauses or runs out of occurrences here.
+func dueDates(b Bill, from, to time.Time) []time.Time {
+	return recurring.Upcoming(recurring.Rule{
+		Frequency:  b.Frequency,
+		Interval:   b.Interval,
+		DayOfMonth: b.DayOfMonth,
+		StartDate:  b.StartDate,
+		EndDate:    b.EndDate,
+		Status:     recurring.StatusActive,
+	}, from, to)
+}
+
+func (s *Service) CreateBill(ctx context.Context, userID uuid.UUID, req BillRequest) (*Bill, error) {
+	b := Bill{ID: uuid.New(), UserID: userID, CreatedAt: s.now()}
+	if err := s.applyRequest(ctx, &b, req); err != nil {
+		return nil, err
+	}
+	if err := s.repo.Create(ctx, b); err != nil {
+		return nil, err
+	}
+	return s.saved(ctx, userID, b.ID)
+}
+
+func (s *Service) ListBills(ctx context.Context, userID uuid.UUID) ([]Bill, error) {
+	return s.repo.List(ctx, userID)
+}
+
+// GetBill returns a bill with its next due date and recent payments.
+func (s *Service) GetBill(ctx context.Context, userID, id uuid.UUID) (*BillDetail, error) {
+	b, err := s.repo.Get(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+	today := dateOf(s.now())
+	d := &BillDetail{Bill: *b}
+	// Dalam rentang N tahun (N = interval) pasti ada satu tanggal jatuh tempo, kecuali sudah berakhir
+	if next := dueDates(*b, today, today.AddDate(b.Interval, 0, 0)); len(next) > 0 {
+		d.NextDue = &next[0]
+	}
+	d.Payments, err = s.repo.Payments(ctx, []uuid.UUID{b.ID}, b.StartDate, today.AddDate(0, 0, MaxUpcomingDays), historyLimit)
+	if err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+func (s *Service) UpdateBill(ctx context.Context, userID, id uuid.UUID, req BillRequest) (*Bill, error) {
+	b, err := s.repo.Get(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.applyRequest(ctx, b, req); err != nil {
+		return nil, err
+	}
+	if err := s.repo.Update(ctx, *b); err != nil {
+		return nil, err
+	}
+	return s.saved(ctx, userID, id)
+}
+
+// saved reloads a bill after a write and matches its open due dates right away, so a
+// payment made before the bill was added or changed shows without waiting for the
+// Matcher.
+func (s *Service) saved(ctx context.Context, userID, id uuid.UUID) (*Bill, error) {
+	b, err := s.repo.Get(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.match(ctx, []Bill{*b}, dateOf(s.now())); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+func (s *Service) DeleteBill(ctx context.Context, userID, id uuid.UUID) error {
+	return s.repo.Delete(ctx, userID, id)
+}
+
+// Upcoming lists the due dates of the user's bills over the next days days, paid or not,
+// and the unpaid ones of the last overdueDays days, soonest first. Payments are as
+// matched by the last run of the Matcher.
+func (s *Service) Upcoming(ctx context.Context, userID uuid.UUID, days int) ([]Due, error) {
+	if days == 0 {
+		days = DefaultUpcomingDays
+	}
+	if days < 1 || days > MaxUpcomingDays {
+		return nil, fmt.Errorf("%w: days must be between 1 and %d", ErrInvalidBill, MaxUpcomingDays)
+	}
+	bills, err := s.repo.List(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	today := dateOf(s.now())
+	from, to := today.AddDate(0, 0, -overdueDays), today.AddDate(0, 0, days)
+	paid, err := s.payments(ctx, bills, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	out := []Due{}
+	for _, b := range bills {
+		for _, d := range dueDates(b, from, to) {
+			p := paid[paymentKey{b.ID, d}]
+			status := StatusDue
+			switch {
+			case p != nil && d.Before(today):
+				continue
+			case p != nil:
+				status = StatusPaid
+			case d.Before(today):
+				status = StatusOverdue
+			}
+			out = append(out, Due{BillID: b.ID, Name: b.Name, PayeeID: b.PayeeID, PayeeName: b.PayeeName, WalletID: b.WalletID,
+				Currency: b.Currency, Amount: b.Amount, DueDate: d, DaysUntil: int(d.Sub(today).Hours() / 24), Status: status, Payment: p})
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		if !out[i].DueDate.Equal(out[j].DueDate) {
+			return out[i].DueDate.Before(out[j].DueDate)
+		}
+		return out[i].Name < out[j].Name
+	})
+	return out, nil
+}
+
+// MarkPaid records by hand that a due date was paid, by a transaction of the user or
+// without one. It replaces an automatic match.
+func (s *Service) MarkPaid(ctx context.Context, userID, id uuid.UUID, req PaymentRequest) (*BillDetail, error) {
+	b, err := s.repo.Get(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+	due, err := time.Parse(dateLayout, req.DueDate)
+	if err != nil {
+		return nil, fmt.Errorf("%w: due_date must be YYYY-MM-DD", ErrInvalidBill)
+	}
+	if len(dueDates(*b, due, due)) == 0 {
+		return nil, fmt.Errorf("%w: the bill is not due on %s", ErrInvalidBill, req.DueDate)
+	}
+	var transactionID *uuid.UUID
+	if req.TransactionID != nil && *req.TransactionID != "" {
+		tid, err := uuid.Parse(*req.TransactionID)
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid transaction_id", ErrInvalidBill)
+		}
+		t, err := s.transactions.GetTransaction(ctx, userID, tid)
+		if err != nil {
+			return nil, err
+		}
+		if t.Kind != "out" {
+			return nil, fmt.Errorf("%w: a bill is paid by an outgoing transaction", ErrInvalidBill)
+		}
+		transactionID = &tid
+	}
+	if err := s.repo.SetPayment(ctx, b.ID, due, transactionID); err != nil {
+		return nil, err
+	}
+	return s.GetBill(ctx, userID, id)
+}
+
+// Unmark removes the payment of a due date. The next matching run pairs a matching
+// transaction with it again; mark the due date paid by another transaction to change an
+// automatic match.
+func (s *Service) Unmark(ctx context.Context, userID, id uuid.UUID, due time.Time) error {
+	if _, err := s.repo.Get(ctx, userID, id); err != nil {
+		return err
+	}
+	return s.repo.DeletePayment(ctx, id, due)
+}
+
+// Reminders returns a reminder for every unpaid due date, of any user's bill, that falls
+// within the bill's reminder days. It is a notification.Source; the dedupe key is per
+// bill and due date, so each due date is reminded of once.
+func (s *Service) Reminders(ctx context.Context, now time.Time) ([]notification.Notification, error) {
+	bills, err := s.repo.All(ctx)
+	if err != nil {
+		return nil, err
+	}
+	today := dateOf(now)
+	paid, err := s.payments(ctx, bills, today, today.AddDate(0, 0, maxReminderDays))
+	if err != nil {
+		return nil, err
+	}
+
+	var out []notification.Notification
+	for _, b := range bills {
+		for _, d := range dueDates(b, today, today.AddDate(0, 0, b.ReminderDays)) {
+			if paid[paymentKey{b.ID, d}] != nil {
+				continue
+			}
+			billID, dueDate := b.ID, d
+			out = append(out, notification.Notification{
+				UserID:      b.UserID,
+				Kind:        NotificationBillDue,
+				Title:       fmt.Sprintf("%s due %s", b.Name, d.Format("2 Jan 2006")),
+				Body:        fmt.Sprintf("%s %s to %s is due in %d day(s).", b.Amount, b.Currency, b.PayeeName, int(d.Sub(today).Hours()/24)),
+				ReferenceID: &billID,
+				DueDate:     &dueDate,
+				DedupeKey:   fmt.Sprintf("%s:%s:%s", NotificationBillDue, b.ID, d.Format(dateLayout)),
+			})
+		}
+	}
+	return out, nil
+}
+
+type paymentKey struct {
+	billID uuid.UUID
+	due    time.Time
+}
+
+// payments loads the payments of bills due from from through to by bill and due date.
+func (s *Service) payments(ctx context.Context, bills []Bill, from, to time.Time) (map[paymentKey]*Payment, error) {
+	out := map[paymentKey]*Payment{}
+	if len(bills) == 0 {
+		return out, nil
+	}
+	ids := make([]uuid.UUID, len(bills))
+	for i, b := range bills {
+		ids[i] = b.ID
+	}
+	payments, err := s.repo.Payments(ctx, ids, from, to, 0)
+	if err != nil {
+		return nil, err
+	}
+	for i := range payments {
+		p := &payments[i]
+		out[paymentKey{p.BillID, dateOf(p.DueDate)}] = p
+	}
+	return out, nil
+}
+
+// MatchPayments pairs the open due dates of every user's bills with the transactions
+// that paid them and returns how many were matched. The Matcher runs it periodically.
+func (s *Service) MatchPayments(ctx context.Context, now time.Time) (int, error) {
+	bills, err := s.repo.All(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return s.match(ctx, bills, dateOf(now))
+}
+
+// match pairs the unpaid due dates of bills, from overdueDays ago up to the match window
+// ahead, with the transactions that paid them. It returns how many were matched.
+func (s *Service) match(ctx context.Context, bills []Bill, today time.Time) (int, error) {
+	n := 0
+	for _, b := range bills {
+		due := dueDates(b, today.AddDate(0, 0, -overdueDays), today.AddDate(0, 0, matchWindowDays))
+		if len(due) == 0 {
+			continue
+		}
+		paid, err := s.payments(ctx, []Bill{b}, due[0], due[len(due)-1])
+		if err != nil {
+			return n, err
+		}
+		var open []time.Time
+		for _, d := range due {
+			if paid[paymentKey{b.ID, d}] == nil {
+				open = append(open, d)
+			}
+		}
+		if len(open) == 0 {
+			continue
+		}
+
+		// Transaksi bertanggal setelah hari ini belum dibayar, jadi tidak ikut dicocokkan
+		to := open[len(open)-1].AddDate(0, 0, matchWindowDays)
+		if to.After(today) {
+			to = today
+		}
+		candidates, err := s.repo.Candidates(ctx, b, open[0].AddDate(0, 0, -matchWindowDays), to)
+		if err != nil {
+			return n, err
+		}
+		for d, c := range pair(open, candidates) {
+			ok, err := s.repo.RecordMatch(ctx, b.ID, d, c.ID)
+			if err != nil {
+				return n, err
+			}
+			if ok {
+				n++
+			}
+		}
+	}
+	return n, nil
+}
+
+// pair gives each due date, in order, the unused candidate closest to it within the match
+// window; of two equally close, the earlier one.
+func pair(due []time.Time, candidates []Candidate) map[time.Time]Candidate {
+	out := map[time.Time]Candidate{}
+	used := make([]bool, len(candidates))
+	for _, d := range due {
+		best, bestDays := -1, matchWindowDays+1
+		for i, c := range candidates {
+			if used[i] {
+				continue
+			}
+			days := int(dateOf(c.OccurredAt).Sub(d).Hours() / 24)
+			if days < 0 {
+				days = -days
+			}
+			if days < bestDays {
+				best, bestDays = i, days
+			}
+		}
+		if best >= 0 {
+			used[best] = true
+			out[d] = candidates[best]
+		}
+	}
+	return out
+}
+
+// applyRequest validates req and copies it onto b.
+func (s *Service) applyRequest(ctx context.Context, b *Bill, req BillRequest) error {
+	name := strings.TrimSpace(req.Name)
+	if name == "" || len(name) > maxName {
+		return fmt.Errorf("%w: name must be 1-%d characters", ErrInvalidBill, maxName)
+	}
+	payeeID, err := uuid.Parse(req.PayeeID)
+	if err != nil {
+		return fmt.Errorf("%w: invalid payee_id", ErrInvalidBill)
+	}
+	ok, err := s.repo.PayeeExists(ctx, b.UserID, payeeID)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return transaction.ErrPayeeNotFound
+	}
+	walletID, err := uuid.Parse(req.WalletID)
+	if err != nil {
+		return fmt.Errorf("%w: invalid wallet_id", ErrInvalidBill)
+	}
+	if _, err := s.transactions.GetWallet(ctx, b.UserID, walletID); err != nil {
+		return err
+	}
+
+	amount, ok := sqlutil.ToCents(req.Amount)
+	if !ok || amount <= 0 {
+		return fmt.Errorf("%w: amount must be a positive number", ErrInvalidBill)
+	}
+	tolerance := int64(0)
+	if req.Tolerance != nil && strings.TrimSpace(*req.Tolerance) != "" {
+		if tolerance, ok = sqlutil.ToCents(*req.Tolerance); !ok || tolerance < 0 {
+			return fmt.Errorf("%w: tolerance must be zero or more", ErrInvalidBill)
+		}
+	}
+	reminderDays := defaultReminderDays
+	if req.ReminderDays != nil {
+		reminderDays = *req.ReminderDays
+	}
+	if reminderDays < 0 || reminderDays > maxReminderDays {
+		return fmt.Errorf("%w: reminder_days must be between 0 and %d", ErrInvalidBill, maxReminderDays)
+	}
+
+	frequency := strings.ToLower(req.Frequency)
+	switch frequency {
+	case recurring.Daily, recurring.Weekly, recurring.Monthly, recurring.Yearly:
+	default:
+		return fmt.Errorf("%w: frequency must be daily, weekly, monthly or yearly", ErrInvalidBill)
+	}
+	interval := req.Interval
+	if interval == 0 {
+		interval = 1
+	}
+	if interval < 0 {
+		return fmt.Errorf("%w: interval must be positive", ErrInvalidBill)
+	}
+	if req.DayOfMonth != nil {
+		if frequency != recurring.Monthly && frequency != recurring.Yearly {
+			return fmt.Errorf("%w: day_of_month only applies to monthly and yearly bills", ErrInvalidBill)
+		}
+		if *req.DayOfMonth < 1 || *req.DayOfMonth > 31 {
+			return fmt.Errorf("%w: day_of_month must be between 1 and 31", ErrInvalidBill)
+		}
+	}
+	start, err := time.Parse(dateLayout, req.StartDate)
+	if err != nil {
+		return fmt.Errorf("%w: start_date must be YYYY-MM-DD", ErrInvalidBill)
+	}
+	var end *time.Time
+	if req.EndDate != nil && *req.EndDate != "" {
+		e, err := time.Parse(dateLayout, *req.EndDate)
+		if err != nil {
+			return fmt.Errorf("%w: end_date must be YYYY-MM-DD", ErrInvalidBill)
+		}
+		if e.Before(start) {
+			return fmt.Errorf("%w: end_date is before start_date", ErrInvalidBill)
+		}
+		end = &e
+	}
+
+	b.Name = name
+	b.PayeeID = payeeID
+	b.WalletID = walletID
+	b.Amount = sqlutil.CentsString(amount)
+	b.Tolerance = sqlutil.CentsString(tolerance)
+	b.Frequency = frequency
+	b.Interval = interval
+	b.DayOfMonth = req.DayOfMonth
+	b.StartDate = start
+	b.EndDate = end
+	b.ReminderDays = reminderDays
+	return nil
+}
@@ -0,0 +1,115 @@
+package bill
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// fakeRepo keeps bills and payments in memory and counts writes. Methods the tests do
+// not need panic through the embedded nil Repository.
+type fakeRepo struct {
+	Repository
+	bills      []Bill
+	payments   []Payment
+	candidates []Candidate
+	writes     int
+}
+
+func (f *fakeRepo) List(ctx context.Context, userID uuid.UUID) ([]Bill, error) { return f.bills, nil }
+func (f *fakeRepo) All(ctx context.Context) ([]Bill, error)                    { return f.bills, nil }
+
+func (f *fakeRepo) Get(ctx context.Context, userID, id uuid.UUID) (*Bill, error) {
+	for _, b := range f.bills {
+		if b.ID == id {
+			return &b, nil
+		}
+	}
+	return nil, ErrBillNotFound
+}
+
+func (f *fakeRepo) Payments(ctx context.Context, billIDs []uuid.UUID, from, to time.Time, limit int) ([]Payment, error) {
+	var out []Payment
+	for _, p := range f.payments {
+		if !p.DueDate.Before(from) && !p.DueDate.After(to) {
+			out = append(out, p)
+		}
+	}
+	return out, nil
+}
+
+func (f *fakeRepo) Candidates(ctx context.Context, b Bill, from, to time.Time) ([]Candidate, error) {
+	var out []Candidate
+	for _, c := range f.candidates {
+		if !c.OccurredAt.Before(from) && c.OccurredAt.Before(to.AddDate(0, 0, 1)) {
+			out = append(out, c)
+		}
+	}
+	return out, nil
+}
+
+func (f *fakeRepo) RecordMatch(ctx context.Context, billID uuid.UUID, due time.Time, transactionID uuid.UUID) (bool, error) {
+	f.writes++
+	f.payments = append(f.payments, Payment{BillID: billID, DueDate: due, TransactionID: &transactionID, Method: "auto"})
+	return true, nil
+}
+
+func TestReadsDoNotMatch(t *testing.T) {
+	today := time.Date(2024, 3, 12, 0, 0, 0, 0, time.UTC)
+	day := 10
+	b := Bill{ID: uuid.New(), UserID: uuid.New(), Name: "Listrik", Amount: "350000.00", Tolerance: "0.00",
+		Frequency: "monthly", Interval: 1, DayOfMonth: &day, StartDate: time.Date(2024, 1, 10, 0, 0, 0, 0, time.UTC), ReminderDays: 3}
+	repo := &fakeRepo{
+		bills:      []Bill{b},
+		candidates: []Candidate{{ID: uuid.New(), OccurredAt: time.Date(2024, 3, 9, 8, 0, 0, 0, time.UTC), Amount: "350000.00"}},
+	}
+	s := &Service{repo: repo, now: func() time.Time { return today }}
+	ctx := context.Background()
+
+	if _, err := s.GetBill(ctx, b.UserID, b.ID); err != nil {
+		t.Fatal(err)
+	}
+	due, err := s.Upcoming(ctx, b.UserID, 30)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Reminders(ctx, today); err != nil {
+		t.Fatal(err)
+	}
+	if repo.writes != 0 {
+		t.Fatalf("reads wrote %d payment(s)", repo.writes)
+	}
+	march := time.Date(2024, 3, 10, 0, 0, 0, 0, time.UTC)
+	if status := statusOn(due, march); status != StatusOverdue {
+		t.Fatalf("before matching, 10 Mar is %q, want overdue", status)
+	}
+
+	n, err := s.MatchPayments(ctx, today)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 1 || repo.writes != 1 {
+		t.Fatalf("MatchPayments matched %d with %d write(s), want 1", n, repo.writes)
+	}
+	if due, err = s.Upcoming(ctx, b.UserID, 30); err != nil {
+		t.Fatal(err)
+	}
+	// Tanggal jatuh tempo yang sudah lewat dan sudah dibayar tidak ditampilkan lagi
+	if status := statusOn(due, march); status != "" {
+		t.Errorf("after matching, 10 Mar is %q, want it gone", status)
+	}
+	if status := statusOn(due, march.AddDate(0, -1, 0)); status != StatusOverdue {
+		t.Errorf("10 Feb is %q, want still overdue", status)
+	}
+}
+
+func statusOn(due []Due, d time.Time) string {
+	for _, x := range due {
+		if x.DueDate.Equal(d) {
+			return x.Status
+		}
+	}
+	return ""
+}
@@ -0,0 +1,203 @@
+package bill
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"github.com/Jomesi149/Implementasi-LASTI/backend/internal/transaction"
+	"github.com/Jomesi149/Implementasi-LASTI/backend/pkg/response"
+)
+
+type HTTPHandler struct {
+	service *Service
+}
+
+func NewHTTPHandler(s *Service) *HTTPHandler {
+	return &HTTPHandler{service: s}
+}
+
+func (h *HTTPHandler) RegisterRoutes(r chi.Router) {
+	r.Route("/bills", func(r chi.Router) {
+		r.Post("/", h.handleCreateBill)
+		r.Get("/", h.handleListBills)
+		r.Get("/upcoming", h.handleUpcoming)
+		r.Get("/{id}", h.handleGetBill)
+		r.Put("/{id}", h.handleUpdateBill)
+		r.Delete("/{id}", h.handleDeleteBill)
+		r.Post("/{id}/payments", h.handleMarkPaid)
+		r.Delete("/{id}/payments/{date}", h.handleUnmark)
+	})
+}
+
+func getUserIDFromHeader(r *http.Request) (uuid.UUID, error) {
+	s := r.Header.Get("X-User-ID")
+	return uuid.Parse(s)
+}
+
+// errorStatus maps service sentinel errors onto HTTP status codes.
+func errorStatus(err error) int {
+	switch {
+	case errors.Is(err, ErrBillNotFound), errors.Is(err, ErrPaymentNotFound), errors.Is(err, transaction.ErrWalletNotFound),
+		errors.Is(err, transaction.ErrPayeeNotFound), errors.Is(err, transaction.ErrTransactionNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, ErrInvalidBill):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// billParams reads the user id header and the {id} URL parameter.
+func billParams(w http.ResponseWriter, r *http.Request) (uuid.UUID, uuid.UUID, bool) {
+	uid, err := getUserIDFromHeader(r)
+	if err != nil {
+		response.Error(w, http.StatusUnauthorized, "missing X-User-ID header")
+		return uuid.Nil, uuid.Nil, false
+	}
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "invalid bill id")
+		return uuid.Nil, uuid.Nil, false
+	}
+	return uid, id, true
+}
+
+func (h *HTTPHandler) handleCreateBill(w http.ResponseWriter, r *http.Request) {
+	uid, err := getUserIDFromHeader(r)
+	if err != nil {
+		response.Error(w, http.StatusUnauthorized, "missing X-User-ID header")
+		return
+	}
+	var req BillRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.Error(w, http.StatusBadRequest, "invalid payload")
+		return
+	}
+	b, err := h.service.CreateBill(r.Context(), uid, req)
+	if err != nil {
+		response.Error(w, errorStatus(err), err.Error())
+		return
+	}
+	response.JSON(w, http.StatusCreated, b)
+}
+
+func (h *HTTPHandler) handleListBills(w http.ResponseWriter, r *http.Request) {
+	uid, err := getUserIDFromHeader(r)
+	if err != nil {
+		response.Error(w, http.StatusUnauthorized, "missing X-User-ID header")
+		return
+	}
+	out, err := h.service.ListBills(r.Context(), uid)
+	if err != nil {
+		response.Error(w, errorStatus(err), err.Error())
+		return
+	}
+	response.JSON(w, http.StatusOK, out)
+}
+
+func (h *HTTPHandler) handleUpcoming(w http.ResponseWriter, r *http.Request) {
+	uid, err := getUserIDFromHeader(r)
+	if err != nil {
+		response.Error(w, http.StatusUnauthorized, "missing X-User-ID header")
+		return
+	}
+	days := DefaultUpcomingDays
+	if v := r.URL.Query().Get("days"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			response.Error(w, http.StatusBadRequest, "invalid days")
+			return
+		}
+		days = n
+	}
+	out, err := h.service.Upcoming(r.Context(), uid, days)
+	if err != nil {
+		response.Error(w, errorStatus(err), err.Error())
+		return
+	}
+	response.JSON(w, http.StatusOK, out)
+}
+
+func (h *HTTPHandler) handleGetBill(w http.ResponseWriter, r *http.Request) {
+	uid, id, ok := billParams(w, r)
+	if !ok {
+		return
+	}
+	b, err := h.service.GetBill(r.Context(), uid, id)
+	if err != nil {
+		response.Error(w, errorStatus(err), err.Error())
+		return
+	}
+	response.JSON(w, http.StatusOK, b)
+}
+
+func (h *HTTPHandler) handleUpdateBill(w http.ResponseWriter, r *http.Request) {
+	uid, id, ok := billParams(w, r)
+	if !ok {
+		return
+	}
+	var req BillRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.Error(w, http.StatusBadRequest, "invalid payload")
+		return
+	}
+	b, err := h.service.UpdateBill(r.Context(), uid, id, req)
+	if err != nil {
+		response.Error(w, errorStatus(err), err.Error())
+		return
+	}
+	response.JSON(w, http.StatusOK, b)
+}
+
+func (h *HTTPHandler) handleDeleteBill(w http.ResponseWriter, r *http.Request) {
+	uid, id, ok := billParams(w, r)
+	if !ok {
+		return
+	}
+	if err := h.service.DeleteBill(r.Context(), uid, id); err != nil {
+		response.Error(w, errorStatus(err), err.Error())
+		return
+	}
+	response.JSON(w, http.StatusOK, map[string]string{"status": "bill deleted"})
+}
+
+func (h *HTTPHandler) handleMarkPaid(w http.ResponseWriter, r *http.Request) {
+	uid, id, ok := billParams(w, r)
+	if !ok {
+		return
+	}
+	var req PaymentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.Error(w, http.StatusBadRequest, "invalid payload")
+		return
+	}
+	b, err := h.service.MarkPaid(r.Context(), uid, id, req)
+	if err != nil {
+		response.Error(w, errorStatus(err), err.Error())
+		return
+	}
+	response.JSON(w, http.StatusOK, b)
+}
+
+func (h *HTTPHandler) handleUnmark(w http.ResponseWriter, r *http.Request) {
+	uid, id, ok := billParams(w, r)
+	if !ok {
+		return
+	}
+	due, err := time.Parse(dateLayout, chi.URLParam(r, "date"))
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "date must be YYYY-MM-DD")
+		return
+	}
+	if err := h.service.Unmark(r.Context(), uid, id, due); err != nil {
+		response.Error(w, errorStatus(err), err.Error())
+		return
+	}
+	response.JSON(w, http.StatusOK, map[string]string{"status": "bill payment removed"})
+}
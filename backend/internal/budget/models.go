@@ -12,6 +12,10 @@ type Budget struct {
 	CategoryName string    `json:"category_name"` 
 	Amount       string    `json:"amount"`        
 	Spent        string    `json:"spent"`         
+	// Currency adalah mata uang dasar user: amount dan spent dalam mata uang ini
+	Currency     string    `json:"currency"`
+	// HouseholdID diisi untuk budget bersama; UserID-nya adalah pemilik household
+	HouseholdID  *uuid.UUID `json:"household_id,omitempty"`
 	CreatedAt    time.Time `json:"created_at"`
 }
 
@@ -19,4 +23,6 @@ type Budget struct {
 type SetBudgetRequest struct {
 	CategoryID string `json:"category_id" validate:"required,uuid"`
 	Amount     string `json:"amount" validate:"required,numeric"`
+	// HouseholdID opsional: budget untuk dompet household, bukan budget pribadi
+	HouseholdID string `json:"household_id" validate:"omitempty,uuid"`
 }
\ No newline at end of file
@@ -12,6 +12,9 @@ import (
 type Repository interface {
 	UpsertBudget(ctx context.Context, b Budget) error
 	ListBudgets(ctx context.Context, userID uuid.UUID) ([]Budget, error)
+	HouseholdRole(ctx context.Context, userID, householdID uuid.UUID) (string, error)
+	UpsertHouseholdBudget(ctx context.Context, userID uuid.UUID, b Budget) error
+	ListHouseholdBudgets(ctx context.Context, userID, householdID uuid.UUID) ([]Budget, error)
 }
 
 type SQLRepository struct {
@@ -33,7 +36,7 @@ func (r *SQLRepository) UpsertBudget(ctx context.Context, b Budget) error {
 	query := `
 		INSERT INTO finance.budgets (id, user_id, category_id, amount, created_at, updated_at)
 		VALUES ($1, $2, $3, $4, NOW(), NOW())
-		ON CONFLICT (user_id, category_id) 
+		ON CONFLICT (user_id, category_id) WHERE household_id IS NULL
 		DO UPDATE SET amount = EXCLUDED.amount, updated_at = NOW()
 	`
 	fmt.Printf("[BUDGET_UPSERT] Attempting to upsert budget: ID=%s, UserID=%s, CategoryID=%s, Amount=%f\n",
@@ -54,15 +57,21 @@ func (r *SQLRepository) ListBudgets(ctx context.Context, userID uuid.UUID) ([]Bu
 			b.category_id, 
 			c.name, 
 			b.amount::TEXT,
-			COALESCE(SUM(t.amount), 0)::TEXT as spent,
+			-- dikonversi ke mata uang dasar dengan kurs pada tanggal transaksi
+			COALESCE(SUM(finance.convert_amount(t.amount, t.currency, finance.base_currency($1), t.occurred_at::DATE, $1)), 0)::TEXT as spent,
+			finance.base_currency($1),
 			b.created_at
 		FROM finance.budgets b
 		JOIN finance.categories c ON b.category_id = c.id
-		LEFT JOIN finance.transactions t ON t.category_id = b.category_id 
+		-- sub-kategori ikut dihitung ke budget induknya
+		LEFT JOIN finance.categories sc ON sc.user_id = b.user_id AND sc.deleted_at IS NULL
+			AND (sc.id = b.category_id OR sc.parent_id = b.category_id)
+		-- transaction_lines memecah transaksi split per kategori
+		LEFT JOIN finance.transaction_lines t ON t.category_id = sc.id
 			AND t.user_id = b.user_id
 			AND t.kind = 'out'
 			AND date_trunc('month', t.occurred_at) = date_trunc('month', CURRENT_DATE)
-		WHERE b.user_id = $1
+		WHERE b.user_id = $1 AND b.household_id IS NULL AND c.deleted_at IS NULL
 		GROUP BY b.id, b.category_id, c.name, b.amount, b.created_at
 		ORDER BY c.name ASC
 	`
@@ -78,7 +87,7 @@ func (r *SQLRepository) ListBudgets(ctx context.Context, userID uuid.UUID) ([]Bu
 	var budgets []Budget
 	for rows.Next() {
 		var b Budget
-		if err := rows.Scan(&b.ID, &b.CategoryID, &b.CategoryName, &b.Amount, &b.Spent, &b.CreatedAt); err != nil {
+		if err := rows.Scan(&b.ID, &b.CategoryID, &b.CategoryName, &b.Amount, &b.Spent, &b.Currency, &b.CreatedAt); err != nil {
 			fmt.Printf("[BUDGET_LIST_ERROR] Scan error: %v\n", err)
 			return nil, err
 		}
@@ -88,3 +97,92 @@ func (r *SQLRepository) ListBudgets(ctx context.Context, userID uuid.UUID) ([]Bu
 	fmt.Printf("[BUDGET_LIST_SUCCESS] Found %d budgets\n", len(budgets))
 	return budgets, nil
 }
+
+// HouseholdRole: peran userID di household, ErrHouseholdNotFound jika bukan anggota
+func (r *SQLRepository) HouseholdRole(ctx context.Context, userID, householdID uuid.UUID) (string, error) {
+	var role sql.NullString
+	if err := r.db.QueryRowContext(ctx, `SELECT finance.household_role($1, $2)`, householdID, userID).Scan(&role); err != nil {
+		return "", fmt.Errorf("select household role: %w", err)
+	}
+	if !role.Valid {
+		return "", ErrHouseholdNotFound
+	}
+	return role.String, nil
+}
+
+// UpsertHouseholdBudget menyimpan budget household atas nama pemiliknya. Hanya owner/editor
+// yang bisa, dan kategorinya harus milik pemilik household
+func (r *SQLRepository) UpsertHouseholdBudget(ctx context.Context, userID uuid.UUID, b Budget) error {
+	query := `
+		INSERT INTO finance.budgets (id, user_id, household_id, category_id, amount, created_at, updated_at)
+		SELECT $1, h.owner_id, h.id, c.id, $5::NUMERIC, NOW(), NOW()
+		FROM finance.households h
+		JOIN finance.categories c ON c.id = $4 AND c.user_id = h.owner_id AND c.deleted_at IS NULL
+		WHERE h.id = $3 AND finance.household_role(h.id, $2) IN ('owner', 'editor')
+		ON CONFLICT (household_id, category_id) WHERE household_id IS NOT NULL
+		DO UPDATE SET amount = EXCLUDED.amount, updated_at = NOW()
+	`
+	fmt.Printf("[BUDGET_UPSERT] Household budget: HouseholdID=%s, UserID=%s, CategoryID=%s, Amount=%s\n",
+		b.HouseholdID, userID, b.CategoryID, b.Amount)
+	res, err := r.db.ExecContext(ctx, query, b.ID, userID, b.HouseholdID, b.CategoryID, b.Amount)
+	if err != nil {
+		fmt.Printf("[BUDGET_UPSERT_ERROR] %v\n", err)
+		return fmt.Errorf("upsert household budget: %w", err)
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return ErrCategoryNotFound
+	}
+	return nil
+}
+
+// ListHouseholdBudgets: budget household beserta pengeluaran bulan ini di dompet household,
+// dalam mata uang dasar pemilik. Kosong jika userID bukan anggota
+func (r *SQLRepository) ListHouseholdBudgets(ctx context.Context, userID, householdID uuid.UUID) ([]Budget, error) {
+	query := `
+		SELECT 
+			b.id, 
+			b.category_id, 
+			c.name, 
+			b.amount::TEXT,
+			COALESCE(SUM(finance.convert_amount(t.amount, t.currency, finance.base_currency(b.user_id), t.occurred_at::DATE, b.user_id)), 0)::TEXT as spent,
+			finance.base_currency(b.user_id),
+			b.created_at,
+			b.user_id
+		FROM finance.budgets b
+		JOIN finance.categories c ON b.category_id = c.id
+		LEFT JOIN finance.categories sc ON sc.user_id = b.user_id AND sc.deleted_at IS NULL
+			AND (sc.id = b.category_id OR sc.parent_id = b.category_id)
+		-- hanya transaksi di dompet yang masuk household
+		LEFT JOIN finance.transaction_lines t ON t.category_id = sc.id
+			AND t.user_id = b.user_id
+			AND t.kind = 'out'
+			AND date_trunc('month', t.occurred_at) = date_trunc('month', CURRENT_DATE)
+			AND t.wallet_id IN (SELECT w.id FROM finance.wallets w WHERE w.household_id = b.household_id)
+		WHERE b.household_id = $1 AND finance.household_role($1, $2) IS NOT NULL AND c.deleted_at IS NULL
+		GROUP BY b.id, b.category_id, c.name, b.amount, b.created_at, b.user_id
+		ORDER BY c.name ASC
+	`
+
+	fmt.Printf("[BUDGET_LIST] Running household query: household %s, user %s\n", householdID, userID)
+	rows, err := r.db.QueryContext(ctx, query, householdID, userID)
+	if err != nil {
+		fmt.Printf("[BUDGET_LIST_ERROR] Query error: %v\n", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	budgets := []Budget{}
+	for rows.Next() {
+		var b Budget
+		if err := rows.Scan(&b.ID, &b.CategoryID, &b.CategoryName, &b.Amount, &b.Spent, &b.Currency, &b.CreatedAt, &b.UserID); err != nil {
+			fmt.Printf("[BUDGET_LIST_ERROR] Scan error: %v\n", err)
+			return nil, err
+		}
+		id := householdID
+		b.HouseholdID = &id
+		budgets = append(budgets, b)
+	}
+	return budgets, rows.Err()
+}
@@ -2,11 +2,22 @@ package budget
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strconv"
 
 	"github.com/google/uuid"
 )
 
+var (
+	// ErrHouseholdNotFound: household tidak ada atau user bukan anggotanya
+	ErrHouseholdNotFound = errors.New("household_not_found")
+	// ErrForbidden: viewer household tidak boleh mengubah budget
+	ErrForbidden = errors.New("forbidden")
+	// ErrCategoryNotFound: kategori tidak ada atau bukan milik pemilik household
+	ErrCategoryNotFound = errors.New("category_not_found")
+)
+
 type Service struct {
 	repo Repository
 }
@@ -41,3 +52,37 @@ func (s *Service) GetBudgets(ctx context.Context, userID uuid.UUID) ([]Budget, e
 	fmt.Printf("[BUDGET_LIST_SUCCESS] Found %d budgets\n", len(budgets))
 	return budgets, nil
 }
+
+// SetHouseholdBudget mengatur budget bersama household; hanya owner/editor
+func (s *Service) SetHouseholdBudget(ctx context.Context, userID, householdID uuid.UUID, categoryIDStr, amount string) error {
+	catID, err := uuid.Parse(categoryIDStr)
+	if err != nil {
+		return fmt.Errorf("invalid category id")
+	}
+	if _, err := strconv.ParseFloat(amount, 64); err != nil {
+		return fmt.Errorf("invalid amount format: %w", err)
+	}
+	role, err := s.repo.HouseholdRole(ctx, userID, householdID)
+	if err != nil {
+		return err
+	}
+	if role != "owner" && role != "editor" {
+		return fmt.Errorf("%w: viewers cannot set budgets", ErrForbidden)
+	}
+
+	b := Budget{
+		ID:          uuid.New(),
+		HouseholdID: &householdID,
+		CategoryID:  catID,
+		Amount:      amount,
+	}
+	return s.repo.UpsertHouseholdBudget(ctx, userID, b)
+}
+
+// GetHouseholdBudgets: budget household untuk anggotanya
+func (s *Service) GetHouseholdBudgets(ctx context.Context, userID, householdID uuid.UUID) ([]Budget, error) {
+	if _, err := s.repo.HouseholdRole(ctx, userID, householdID); err != nil {
+		return nil, err
+	}
+	return s.repo.ListHouseholdBudgets(ctx, userID, householdID)
+}
@@ -2,6 +2,7 @@ package budget
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
@@ -37,7 +38,18 @@ func (h *HTTPHandler) handleSetBudget(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := h.service.SetBudget(r.Context(), uid, req.CategoryID, req.Amount); err != nil {
+	if req.HouseholdID != "" {
+		hid, err := uuid.Parse(req.HouseholdID)
+		if err != nil {
+			response.Error(w, http.StatusBadRequest, "invalid household id")
+			return
+		}
+		err = h.service.SetHouseholdBudget(r.Context(), uid, hid, req.CategoryID, req.Amount)
+		if err != nil {
+			response.Error(w, errorStatus(err), err.Error())
+			return
+		}
+	} else if err := h.service.SetBudget(r.Context(), uid, req.CategoryID, req.Amount); err != nil {
 		response.Error(w, http.StatusInternalServerError, err.Error())
 		return
 	}
@@ -53,11 +65,37 @@ func (h *HTTPHandler) handleListBudgets(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	budgets, err := h.service.GetBudgets(r.Context(), uid)
-	if err != nil {
-		response.Error(w, http.StatusInternalServerError, err.Error())
-		return
+	// ?household_id= menampilkan budget bersama household, bukan budget pribadi
+	var budgets []Budget
+	if v := r.URL.Query().Get("household_id"); v != "" {
+		hid, err := uuid.Parse(v)
+		if err != nil {
+			response.Error(w, http.StatusBadRequest, "invalid household id")
+			return
+		}
+		budgets, err = h.service.GetHouseholdBudgets(r.Context(), uid, hid)
+		if err != nil {
+			response.Error(w, errorStatus(err), err.Error())
+			return
+		}
+	} else {
+		budgets, err = h.service.GetBudgets(r.Context(), uid)
+		if err != nil {
+			response.Error(w, http.StatusInternalServerError, err.Error())
+			return
+		}
 	}
 
 	response.JSON(w, http.StatusOK, budgets)
+}
+
+func errorStatus(err error) int {
+	switch {
+	case errors.Is(err, ErrHouseholdNotFound), errors.Is(err, ErrCategoryNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, ErrForbidden):
+		return http.StatusForbidden
+	default:
+		return http.StatusInternalServerError
+	}
 }
\ No newline at end of file
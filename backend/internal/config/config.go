@@ -18,6 +18,43 @@ type Config struct {
 	AccessTokenTTL  time.Duration
 	RefreshTokenTTL time.Duration
 	OTPLifetime     time.Duration
+	// RecurringInterval is how often the recurring transaction scheduler runs.
+	RecurringInterval time.Duration
+	// PlannedInterval is how often due planned transactions are auto-posted.
+	PlannedInterval time.Duration
+
+	// Attachment storage: STORAGE_DRIVER is local (files under STORAGE_DIR) or s3.
+	StorageDriver string
+	StorageDir    string
+	S3Endpoint    string
+	S3Region      string
+	S3Bucket      string
+	S3AccessKey   string
+	S3SecretKey   string
+	S3PathStyle   bool
+	// AttachmentURLSecret signs attachment download links; defaults to JWTSecret.
+	AttachmentURLSecret string
+	AttachmentURLTTL    time.Duration
+
+	// TrashRetention is how long soft-deleted records stay restorable before the purge
+	// job removes them; TrashPurgeInterval is how often that job runs.
+	TrashRetention     time.Duration
+	TrashPurgeInterval time.Duration
+
+	// RateProvider is http, static or none; empty means http when RateProviderURL is
+	// set. RateStaticRates prices each currency in IDR for the static provider.
+	// RateRefreshInterval is how often the rates of every currency in use are pulled.
+	RateProvider        string
+	RateProviderURL     string
+	RateStaticRates     string
+	RateRefreshInterval time.Duration
+
+	// BillMatchInterval is how often bill due dates are matched with the transactions
+	// that paid them.
+	BillMatchInterval time.Duration
+
+	// NotificationInterval is how often reminders such as credit card due dates are checked.
+	NotificationInterval time.Duration
 }
 
 // MustLoad loads configuration from the environment or panics when required values are missing.
@@ -51,6 +88,30 @@ func Load() (Config, error) {
 	cfg.AccessTokenTTL = parseDurationOrDefault("ACCESS_TOKEN_TTL", 15*time.Minute)
 	cfg.RefreshTokenTTL = parseDurationOrDefault("REFRESH_TOKEN_TTL", 7*24*time.Hour)
 	cfg.OTPLifetime = parseDurationOrDefault("OTP_WINDOW_SECONDS", 5*time.Minute)
+	cfg.RecurringInterval = parseDurationOrDefault("RECURRING_INTERVAL", time.Minute)
+	cfg.PlannedInterval = parseDurationOrDefault("PLANNED_INTERVAL", time.Minute)
+
+	cfg.StorageDriver = getEnv("STORAGE_DRIVER", "local")
+	cfg.StorageDir = getEnv("STORAGE_DIR", "./data/attachments")
+	cfg.S3Endpoint = os.Getenv("S3_ENDPOINT")
+	cfg.S3Region = getEnv("S3_REGION", "us-east-1")
+	cfg.S3Bucket = os.Getenv("S3_BUCKET")
+	cfg.S3AccessKey = os.Getenv("S3_ACCESS_KEY")
+	cfg.S3SecretKey = os.Getenv("S3_SECRET_KEY")
+	cfg.S3PathStyle = getEnv("S3_PATH_STYLE", "true") == "true"
+	cfg.AttachmentURLSecret = getEnv("ATTACHMENT_URL_SECRET", cfg.JWTSecret)
+	cfg.AttachmentURLTTL = parseDurationOrDefault("ATTACHMENT_URL_TTL", 5*time.Minute)
+
+	cfg.TrashRetention = parseDurationOrDefault("TRASH_RETENTION", 30*24*time.Hour)
+	cfg.TrashPurgeInterval = parseDurationOrDefault("TRASH_PURGE_INTERVAL", time.Hour)
+
+	cfg.RateProvider = os.Getenv("RATE_PROVIDER")
+	cfg.RateProviderURL = os.Getenv("RATE_PROVIDER_URL")
+	cfg.RateStaticRates = os.Getenv("RATE_STATIC_RATES")
+	cfg.RateRefreshInterval = parseDurationOrDefault("RATE_REFRESH_INTERVAL", 6*time.Hour)
+
+	cfg.BillMatchInterval = parseDurationOrDefault("BILL_MATCH_INTERVAL", 10*time.Minute)
+	cfg.NotificationInterval = parseDurationOrDefault("NOTIFICATION_INTERVAL", time.Hour)
 
 	return cfg, nil
 }
@@ -0,0 +1,80 @@
+package currency
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DefaultCurrency is the currency of users and wallets that never chose one.
+const DefaultCurrency = "IDR"
+
+// Rate sources.
+const (
+	SourceManual   = "manual"
+	SourceProvider = "provider"
+)
+
+// Settings holds the currency preferences of a user. Budgets and analytics report in
+// BaseCurrency.
+type Settings struct {
+	UserID       uuid.UUID `json:"user_id"`
+	BaseCurrency string    `json:"base_currency"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// Rate says one Base is worth Rate Quote on RateDate. UserID is nil for provider rates,
+// which every user shares; manual rates belong to one user and win on the same date.
+type Rate struct {
+	ID        uuid.UUID  `json:"id"`
+	UserID    *uuid.UUID `json:"user_id,omitempty"`
+	Base      string     `json:"base_currency"`
+	Quote     string     `json:"quote_currency"`
+	RateDate  time.Time  `json:"rate_date"`
+	Rate      string     `json:"rate"`
+	Source    string     `json:"source"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// Quote is the rate used to convert From into To on a date: the latest one on or before
+// it, taken from the inverse pair when only that is known. RateDate is the date of the
+// rate actually used.
+type Quote struct {
+	From     string    `json:"from"`
+	To       string    `json:"to"`
+	Rate     string    `json:"rate"`
+	RateDate time.Time `json:"rate_date"`
+}
+
+// Conversion is an amount converted with Quote and rounded to cents.
+type Conversion struct {
+	Amount    string `json:"amount"`
+	From      string `json:"from"`
+	Converted string `json:"converted"`
+	To        string `json:"to"`
+	Quote     Quote  `json:"quote"`
+}
+
+// codes are the active ISO 4217 currency codes.
+var codes = map[string]bool{
+	"AED": true, "AFN": true, "ALL": true, "AMD": true, "ANG": true, "AOA": true, "ARS": true, "AUD": true,
+	"AWG": true, "AZN": true, "BAM": true, "BBD": true, "BDT": true, "BGN": true, "BHD": true, "BIF": true,
+	"BMD": true, "BND": true, "BOB": true, "BRL": true, "BSD": true, "BTN": true, "BWP": true, "BYN": true,
+	"BZD": true, "CAD": true, "CDF": true, "CHF": true, "CLP": true, "CNY": true, "COP": true, "CRC": true,
+	"CUP": true, "CVE": true, "CZK": true, "DJF": true, "DKK": true, "DOP": true, "DZD": true, "EGP": true,
+	"ERN": true, "ETB": true, "EUR": true, "FJD": true, "FKP": true, "GBP": true, "GEL": true, "GHS": true,
+	"GIP": true, "GMD": true, "GNF": true, "GTQ": true, "GYD": true, "HKD": true, "HNL": true, "HTG": true,
+	"HUF": true, "IDR": true, "ILS": true, "INR": true, "IQD": true, "IRR": true, "ISK": true, "JMD": true,
+	"JOD": true, "JPY": true, "KES": true, "KGS": true, "KHR": true, "KMF": true, "KPW": true, "KRW": true,
+	"KWD": true, "KYD": true, "KZT": true, "LAK": true, "LBP": true, "LKR": true, "LRD": true, "LSL": true,
+	"LYD": true, "MAD": true, "MDL": true, "MGA": true, "MKD": true, "MMK": true, "MNT": true, "MOP": true,
+	"MRU": true, "MUR": true, "MVR": true, "MWK": true, "MXN": true, "MYR": true, "MZN": true, "NAD": true,
+	"NGN": true, "NIO": true, "NOK": true, "NPR": true, "NZD": true, "OMR": true, "PAB": true, "PEN": true,
+	"PGK": true, "PHP": true, "PKR": true, "PLN": true, "PYG": true, "QAR": true, "RON": true, "RSD": true,
+	"RUB": true, "RWF": true, "SAR": true, "SBD": true, "SCR": true, "SDG": true, "SEK": true, "SGD": true,
+	"SHP": true, "SLE": true, "SOS": true, "SRD": true, "SSP": true, "STN": true, "SVC": true, "SYP": true,
+	"SZL": true, "THB": true, "TJS": true, "TMT": true, "TND": true, "TOP": true, "TRY": true, "TTD": true,
+	"TWD": true, "TZS": true, "UAH": true, "UGX": true, "USD": true, "UYU": true, "UZS": true, "VES": true,
+	"VND": true, "VUV": true, "WST": true, "XAF": true, "XCD": true, "XOF": true, "XPF": true, "YER": true,
+	"ZAR": true, "ZMW": true, "ZWL": true,
+}
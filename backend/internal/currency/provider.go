@@ -0,0 +1,218 @@
+package currency
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Provider fetches market exchange rates.
+type Provider interface {
+	// Rates returns what one base is worth in other currencies, as published for the
+	// latest date on or before on. date is the date the rates are for.
+	Rates(ctx context.Context, base string, on time.Time) (date time.Time, rates map[string]string, err error)
+}
+
+// ProviderConfig selects and configures a rate provider.
+type ProviderConfig struct {
+	Driver  string // http | static | none
+	URL     string
+	Timeout time.Duration
+	// StaticRates lists what one unit of each currency costs in DefaultCurrency, e.g.
+	// "USD=15500,SGD=11600.50".
+	StaticRates string
+}
+
+// NewProvider builds the provider named by cfg.Driver. Empty means http when a URL is
+// set and none otherwise; none returns a nil Provider, for manual rates only.
+func NewProvider(cfg ProviderConfig) (Provider, error) {
+	driver := cfg.Driver
+	if driver == "" && cfg.URL != "" {
+		driver = "http"
+	}
+	switch driver {
+	case "", "none":
+		return nil, nil
+	case "http":
+		if cfg.URL == "" {
+			return nil, fmt.Errorf("rate provider http needs a URL")
+		}
+		return NewHTTPProvider(cfg.URL, cfg.Timeout), nil
+	case "static":
+		prices, err := ParseStaticRates(cfg.StaticRates)
+		if err != nil {
+			return nil, err
+		}
+		return NewStaticProvider(DefaultCurrency, prices), nil
+	default:
+		return nil, fmt.Errorf("unknown rate provider %q", cfg.Driver)
+	}
+}
+
+// HTTPProvider reads rates from an API shaped like frankfurter.app:
+//
+//	GET {baseURL}/{YYYY-MM-DD}?from=IDR  ->  {"date":"2024-01-02","rates":{"USD":0.0000645}}
+//
+// For local development point it at any stub that serves the same JSON.
+type HTTPProvider struct {
+	baseURL string
+	client  *http.Client
+}
+
+func NewHTTPProvider(baseURL string, timeout time.Duration) *HTTPProvider {
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+	return &HTTPProvider{baseURL: strings.TrimRight(baseURL, "/"), client: &http.Client{Timeout: timeout}}
+}
+
+type providerResponse struct {
+	Date  string                 `json:"date"`
+	Rates map[string]json.Number `json:"rates"`
+}
+
+func (p *HTTPProvider) Rates(ctx context.Context, base string, on time.Time) (time.Time, map[string]string, error) {
+	u := fmt.Sprintf("%s/%s?from=%s", p.baseURL, on.Format("2006-01-02"), url.QueryEscape(base))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return time.Time{}, nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return time.Time{}, nil, fmt.Errorf("fetch rates: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return time.Time{}, nil, fmt.Errorf("fetch rates: provider returned %s", resp.Status)
+	}
+
+	var body providerResponse
+	dec := json.NewDecoder(resp.Body)
+	dec.UseNumber()
+	if err := dec.Decode(&body); err != nil {
+		return time.Time{}, nil, fmt.Errorf("decode rates: %w", err)
+	}
+	date, err := time.Parse("2006-01-02", body.Date)
+	if err != nil {
+		return time.Time{}, nil, fmt.Errorf("decode rates: invalid date %q", body.Date)
+	}
+	rates := make(map[string]string, len(body.Rates))
+	for code, n := range body.Rates {
+		code = strings.ToUpper(code)
+		// Kode yang tidak dikenal atau kurs yang tidak valid dilewati saja
+		if !codes[code] || code == base {
+			continue
+		}
+		if _, ok := parseRate(n.String()); ok {
+			rates[code] = n.String()
+		}
+	}
+	return date, rates, nil
+}
+
+// StaticProvider serves fixed rates derived from the price of each currency in one
+// pivot currency. It never fails and reports every date as having rates, which suits
+// development, tests and offline installs.
+type StaticProvider struct {
+	pivot  string
+	prices map[string]*big.Rat
+}
+
+// NewStaticProvider builds a provider from what one unit of each currency costs in
+// pivot. Prices that are not positive numbers are ignored.
+func NewStaticProvider(pivot string, prices map[string]string) *StaticProvider {
+	p := &StaticProvider{pivot: pivot, prices: map[string]*big.Rat{pivot: big.NewRat(1, 1)}}
+	for code, price := range prices {
+		if r, ok := parseRate(price); ok && code != pivot {
+			p.prices[code] = r
+		}
+	}
+	return p
+}
+
+// ParseStaticRates parses "USD=15500,SGD=11600.50" into prices by currency code.
+func ParseStaticRates(s string) (map[string]string, error) {
+	prices := map[string]string{}
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		code, price, ok := strings.Cut(part, "=")
+		code = strings.ToUpper(strings.TrimSpace(code))
+		if !ok || !codes[code] {
+			return nil, fmt.Errorf("invalid static rate %q: want CODE=price", part)
+		}
+		if _, ok := parseRate(price); !ok {
+			return nil, fmt.Errorf("invalid static rate %q: price must be a positive number", part)
+		}
+		prices[code] = strings.TrimSpace(price)
+	}
+	return prices, nil
+}
+
+func (p *StaticProvider) Rates(_ context.Context, base string, on time.Time) (time.Time, map[string]string, error) {
+	date := time.Date(on.Year(), on.Month(), on.Day(), 0, 0, 0, 0, time.UTC)
+	from, ok := p.prices[base]
+	if !ok {
+		return date, map[string]string{}, nil
+	}
+	rates := make(map[string]string, len(p.prices)-1)
+	for code, price := range p.prices {
+		if code == base {
+			continue
+		}
+		// Kolom kurs NUMERIC(24,10): dibulatkan ke 10 desimal
+		rate := new(big.Rat).Quo(from, price).FloatString(10)
+		rate = strings.TrimRight(strings.TrimRight(rate, "0"), ".")
+		if _, ok := parseRate(rate); ok {
+			rates[code] = rate
+		}
+	}
+	return date, rates, nil
+}
+
+// decimalPattern is a plain decimal number as Postgres NUMERIC reads it. big.Rat alone
+// would also take fractions such as "1/3" and hex floats.
+var decimalPattern = regexp.MustCompile(`^[-+]?([0-9]+\.?[0-9]*|\.[0-9]+)([eE][-+]?[0-9]+)?$`)
+
+// maxRate is the largest rate a NUMERIC(24,10) column holds.
+var maxRate, _ = new(big.Rat).SetString("99999999999999")
+
+// parseDecimal parses a plain decimal number.
+func parseDecimal(s string) (*big.Rat, bool) {
+	s = strings.TrimSpace(s)
+	if !decimalPattern.MatchString(s) {
+		return nil, false
+	}
+	return new(big.Rat).SetString(s)
+}
+
+// parseRate parses a positive decimal rate that survives storage with ten decimals.
+func parseRate(s string) (*big.Rat, bool) {
+	r, ok := parseDecimal(s)
+	if !ok || r.Sign() <= 0 || r.Cmp(maxRate) > 0 || strings.Trim(r.FloatString(10), "0.") == "" {
+		return nil, false
+	}
+	return r, true
+}
+
+// convert multiplies amount by rate and rounds to cents, halves away from zero.
+func convert(amount, rate string) (string, bool) {
+	a, ok := parseDecimal(amount)
+	if !ok {
+		return "", false
+	}
+	r, ok := parseRate(rate)
+	if !ok {
+		return "", false
+	}
+	return new(big.Rat).Mul(a, r).FloatString(2), true
+}
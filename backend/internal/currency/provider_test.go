@@ -0,0 +1,219 @@
+package currency
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseRate(t *testing.T) {
+	tests := []struct {
+		in string
+		ok bool
+	}{
+		{"0.0000645", true},
+		{" 15500 ", true},
+		{"1.", true},
+		{".5", true},
+		{"6.45e-05", true},
+		{"1E3", true},
+		{"+2", true},
+		{"0", false},
+		{"0.00", false},
+		{"-1", false},
+		{"", false},
+		{"abc", false},
+		{"1/3", false},
+		{"0x10", false},
+		{"1_000", false},
+		{"1,5", false},
+		{"0.00000000001", false},
+		{"100000000000000", false},
+	}
+	for _, tt := range tests {
+		if _, ok := parseRate(tt.in); ok != tt.ok {
+			t.Errorf("parseRate(%q) ok = %v, want %v", tt.in, ok, tt.ok)
+		}
+	}
+}
+
+func TestConvertRounding(t *testing.T) {
+	tests := []struct {
+		amount, rate string
+		want         string
+		ok           bool
+	}{
+		{"100", "1", "100.00", true},
+		{"1.5", "3", "4.50", true},
+		{" 12.34 ", "2", "24.68", true},
+		// Setengah sen dibulatkan menjauhi nol
+		{"10.005", "1", "10.01", true},
+		{"-10.005", "1", "-10.01", true},
+		{"1", "0.005", "0.01", true},
+		{"-1", "0.005", "-0.01", true},
+		{"1", "0.0049999", "0.00", true},
+		{"100", "0.0000645", "0.01", true},
+		{"100000", "0.0000645", "6.45", true},
+		{"1000000", "0.0000645161", "64.52", true},
+		{"123456789012.34", "15500.5", "1913641958085776.17", true},
+		{"abc", "1", "", false},
+		{"1/3", "1", "", false},
+		{"10", "0", "", false},
+		{"10", "-1.5", "", false},
+	}
+	for _, tt := range tests {
+		got, ok := convert(tt.amount, tt.rate)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("convert(%q, %q) = %q, %v; want %q, %v", tt.amount, tt.rate, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestHTTPProviderRates(t *testing.T) {
+	var gotPath, gotQuery, gotAccept string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath, gotQuery, gotAccept = r.URL.Path, r.URL.RawQuery, r.Header.Get("Accept")
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"amount":1.0,"base":"IDR","date":"2024-01-02","rates":{
+			"USD":0.0000645,"eur":0.0000590,"SGD":8.6e-05,"IDR":1,"XXX":2,"JPY":0,"GBP":-1}}`))
+	}))
+	defer srv.Close()
+
+	p := NewHTTPProvider(srv.URL+"/", time.Second)
+	date, rates, err := p.Rates(context.Background(), "IDR", time.Date(2024, 1, 3, 18, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gotPath != "/2024-01-03" || gotQuery != "from=IDR" || gotAccept != "application/json" {
+		t.Errorf("request = %s?%s Accept %q", gotPath, gotQuery, gotAccept)
+	}
+	if !date.Equal(time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("date = %v, want 2024-01-02", date)
+	}
+	// Kode tak dikenal, mata uang dasar dan kurs tidak positif dibuang; angka dipertahankan apa adanya
+	want := map[string]string{"USD": "0.0000645", "EUR": "0.0000590", "SGD": "8.6e-05"}
+	if !reflect.DeepEqual(rates, want) {
+		t.Errorf("rates = %v, want %v", rates, want)
+	}
+}
+
+func TestHTTPProviderErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		body   string
+		want   string
+	}{
+		{"server error", http.StatusBadGateway, `{}`, "502"},
+		{"not found", http.StatusNotFound, `{"message":"not found"}`, "404"},
+		{"malformed json", http.StatusOK, `{"date":`, "decode rates"},
+		{"bad date", http.StatusOK, `{"date":"02/01/2024","rates":{}}`, "invalid date"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			}))
+			defer srv.Close()
+
+			_, _, err := NewHTTPProvider(srv.URL, time.Second).Rates(context.Background(), "IDR", time.Now())
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("err = %v, want one mentioning %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestHTTPProviderTimeout(t *testing.T) {
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	_, _, err := NewHTTPProvider(srv.URL, 50*time.Millisecond).Rates(context.Background(), "IDR", time.Now())
+	if err == nil || !strings.Contains(err.Error(), "fetch rates") {
+		t.Errorf("err = %v, want a fetch timeout", err)
+	}
+}
+
+func TestStaticProviderRates(t *testing.T) {
+	prices, err := ParseStaticRates(" usd=15500, SGD=11600.5 ,,")
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := NewStaticProvider("IDR", prices)
+	on := time.Date(2024, 5, 17, 23, 30, 0, 0, time.FixedZone("WIB", 7*3600))
+
+	tests := []struct {
+		base string
+		want map[string]string
+	}{
+		{"IDR", map[string]string{"USD": "0.0000645161", "SGD": "0.0000862032"}},
+		{"USD", map[string]string{"IDR": "15500", "SGD": "1.3361493039"}},
+		{"SGD", map[string]string{"IDR": "11600.5", "USD": "0.7484193548"}},
+		{"EUR", map[string]string{}},
+	}
+	for _, tt := range tests {
+		date, rates, err := p.Rates(context.Background(), tt.base, on)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !date.Equal(time.Date(2024, 5, 17, 0, 0, 0, 0, time.UTC)) {
+			t.Errorf("%s: date = %v, want 2024-05-17", tt.base, date)
+		}
+		if !reflect.DeepEqual(rates, tt.want) {
+			t.Errorf("%s: rates = %v, want %v", tt.base, rates, tt.want)
+		}
+	}
+}
+
+func TestParseStaticRatesInvalid(t *testing.T) {
+	for _, in := range []string{"USD", "USD=", "USD=0", "USD=-1", "ABC=1", "USD=1/3"} {
+		if _, err := ParseStaticRates(in); err == nil {
+			t.Errorf("ParseStaticRates(%q) succeeded", in)
+		}
+	}
+}
+
+func TestNewProvider(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     ProviderConfig
+		want    string
+		wantErr bool
+	}{
+		{"nothing configured", ProviderConfig{}, "<nil>", false},
+		{"none", ProviderConfig{Driver: "none", URL: "http://rates"}, "<nil>", false},
+		{"url only", ProviderConfig{URL: "http://rates"}, "*currency.HTTPProvider", false},
+		{"http", ProviderConfig{Driver: "http", URL: "http://rates"}, "*currency.HTTPProvider", false},
+		{"http without url", ProviderConfig{Driver: "http"}, "", true},
+		{"static", ProviderConfig{Driver: "static", StaticRates: "USD=15500"}, "*currency.StaticProvider", false},
+		{"static with bad rates", ProviderConfig{Driver: "static", StaticRates: "USD=x"}, "", true},
+		{"unknown", ProviderConfig{Driver: "ecb"}, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := NewProvider(tt.cfg)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			got := "<nil>"
+			if p != nil {
+				got = reflect.TypeOf(p).String()
+			}
+			if got != tt.want {
+				t.Errorf("provider = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
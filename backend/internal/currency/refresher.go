@@ -0,0 +1,43 @@
+package currency
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// Refresher periodically pulls the provider's rates for every currency in use, so
+// conversions in budgets and analytics do not wait for a lookup.
+type Refresher struct {
+	service  *Service
+	interval time.Duration
+}
+
+func NewRefresher(service *Service, interval time.Duration) *Refresher {
+	if interval <= 0 {
+		interval = 6 * time.Hour
+	}
+	return &Refresher{service: service, interval: interval}
+}
+
+// Run refreshes immediately and then on every tick until ctx is cancelled.
+func (r *Refresher) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		n, err := r.service.RefreshRates(ctx, time.Now())
+		if err != nil && ctx.Err() == nil {
+			log.Printf("[CURRENCY_ERROR] refresh: %v", err)
+		}
+		if n > 0 {
+			log.Printf("[CURRENCY] refreshed rates for %d currenc(ies)", n)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,197 @@
+package currency
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Repository persists currency settings and exchange rates.
+type Repository interface {
+	BaseCurrency(ctx context.Context, userID uuid.UUID) (string, error)
+	SetBaseCurrency(ctx context.Context, userID uuid.UUID, code string) (*Settings, error)
+	SaveRate(ctx context.Context, r Rate) (*Rate, error)
+	SaveProviderRates(ctx context.Context, base string, date time.Time, rates map[string]string) error
+	ListRates(ctx context.Context, userID uuid.UUID, base, quote string, limit int) ([]Rate, error)
+	DeleteRate(ctx context.Context, userID, id uuid.UUID) error
+	Quote(ctx context.Context, userID uuid.UUID, from, to string, on time.Time) (*Quote, error)
+	UsedCurrencies(ctx context.Context) ([]string, error)
+}
+
+type SQLRepository struct {
+	db *sql.DB
+}
+
+func NewRepository(db *sql.DB) *SQLRepository {
+	return &SQLRepository{db: db}
+}
+
+const rateColumns = `id, user_id, base_currency, quote_currency, rate_date, rate::TEXT, source, created_at`
+
+// rateConflict matches idx_exchange_rates_unique: one rate per owner, pair and date.
+const rateConflict = `ON CONFLICT (COALESCE(user_id, '00000000-0000-0000-0000-000000000000'::UUID), base_currency, quote_currency, rate_date)`
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanRate(row rowScanner) (*Rate, error) {
+	var r Rate
+	var userID uuid.NullUUID
+	if err := row.Scan(&r.ID, &userID, &r.Base, &r.Quote, &r.RateDate, &r.Rate, &r.Source, &r.CreatedAt); err != nil {
+		return nil, err
+	}
+	if userID.Valid {
+		id := userID.UUID
+		r.UserID = &id
+	}
+	return &r, nil
+}
+
+func (r *SQLRepository) BaseCurrency(ctx context.Context, userID uuid.UUID) (string, error) {
+	var code string
+	if err := r.db.QueryRowContext(ctx, `SELECT finance.base_currency($1)`, userID).Scan(&code); err != nil {
+		return "", fmt.Errorf("select base currency: %w", err)
+	}
+	return code, nil
+}
+
+func (r *SQLRepository) SetBaseCurrency(ctx context.Context, userID uuid.UUID, code string) (*Settings, error) {
+	query := `INSERT INTO finance.user_settings (user_id, base_currency, updated_at) VALUES ($1,$2,NOW())
+		ON CONFLICT (user_id) DO UPDATE SET base_currency = EXCLUDED.base_currency, updated_at = NOW()
+		RETURNING user_id, base_currency, updated_at`
+	var s Settings
+	if err := r.db.QueryRowContext(ctx, query, userID, code).Scan(&s.UserID, &s.BaseCurrency, &s.UpdatedAt); err != nil {
+		return nil, fmt.Errorf("upsert settings: %w", err)
+	}
+	return &s, nil
+}
+
+// SaveRate stores a rate, replacing the one of the same owner, pair and date.
+func (r *SQLRepository) SaveRate(ctx context.Context, rate Rate) (*Rate, error) {
+	query := fmt.Sprintf(`INSERT INTO finance.exchange_rates (id, user_id, base_currency, quote_currency, rate_date, rate, source, created_at)
+		VALUES ($1,$2,$3,$4,$5::DATE,$6::NUMERIC,$7,NOW())
+		%s DO UPDATE SET rate = EXCLUDED.rate, source = EXCLUDED.source, created_at = NOW()
+		RETURNING %s`, rateConflict, rateColumns)
+	saved, err := scanRate(r.db.QueryRowContext(ctx, query, rate.ID, rate.UserID, rate.Base, rate.Quote,
+		rate.RateDate.Format("2006-01-02"), rate.Rate, rate.Source))
+	if err != nil {
+		return nil, fmt.Errorf("upsert rate: %w", err)
+	}
+	return saved, nil
+}
+
+// SaveProviderRates stores shared provider rates of base for one date.
+func (r *SQLRepository) SaveProviderRates(ctx context.Context, base string, date time.Time, rates map[string]string) (err error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	stmt, err := tx.PrepareContext(ctx, fmt.Sprintf(`INSERT INTO finance.exchange_rates (id, user_id, base_currency, quote_currency, rate_date, rate, source, created_at)
+		VALUES (gen_random_uuid(),NULL,$1,$2,$3::DATE,$4::NUMERIC,$5,NOW())
+		%s DO UPDATE SET rate = EXCLUDED.rate, created_at = NOW()`, rateConflict))
+	if err != nil {
+		return fmt.Errorf("prepare rate insert: %w", err)
+	}
+	defer stmt.Close()
+
+	day := date.Format("2006-01-02")
+	for quote, rate := range rates {
+		if _, err = stmt.ExecContext(ctx, base, quote, day, rate, SourceProvider); err != nil {
+			return fmt.Errorf("insert rate %s/%s: %w", base, quote, err)
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("commit tx: %w", err)
+	}
+	return nil
+}
+
+// ListRates returns the user's manual rates and the provider rates, newest first,
+// optionally narrowed to one base and/or quote currency.
+func (r *SQLRepository) ListRates(ctx context.Context, userID uuid.UUID, base, quote string, limit int) ([]Rate, error) {
+	query := fmt.Sprintf(`SELECT %s FROM finance.exchange_rates
+		WHERE (user_id = $1 OR user_id IS NULL) AND ($2 = '' OR base_currency = $2) AND ($3 = '' OR quote_currency = $3)
+		ORDER BY rate_date DESC, base_currency, quote_currency, user_id NULLS LAST
+		LIMIT $4`, rateColumns)
+	rows, err := r.db.QueryContext(ctx, query, userID, base, quote, limit)
+	if err != nil {
+		return nil, fmt.Errorf("list rates: %w", err)
+	}
+	defer rows.Close()
+
+	out := []Rate{}
+	for rows.Next() {
+		rate, err := scanRate(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *rate)
+	}
+	return out, rows.Err()
+}
+
+// DeleteRate removes a manual rate of the user; provider rates cannot be deleted.
+func (r *SQLRepository) DeleteRate(ctx context.Context, userID, id uuid.UUID) error {
+	res, err := r.db.ExecContext(ctx, `DELETE FROM finance.exchange_rates WHERE id = $1 AND user_id = $2`, id, userID)
+	if err != nil {
+		return fmt.Errorf("delete rate: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrRateNotFound
+	}
+	return nil
+}
+
+// Quote looks up the rate for from -> to on a date with finance.exchange_rate_quote, the
+// same lookup budgets and analytics use.
+func (r *SQLRepository) Quote(ctx context.Context, userID uuid.UUID, from, to string, on time.Time) (*Quote, error) {
+	q := Quote{From: from, To: to}
+	err := r.db.QueryRowContext(ctx, `SELECT rate::TEXT, rate_date FROM finance.exchange_rate_quote($1, $2, $3::DATE, $4)`,
+		from, to, on.Format("2006-01-02"), userID).Scan(&q.Rate, &q.RateDate)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrRateNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("select rate: %w", err)
+	}
+	return &q, nil
+}
+
+// UsedCurrencies returns every currency that is a base currency or a wallet currency.
+func (r *SQLRepository) UsedCurrencies(ctx context.Context) ([]string, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT base_currency FROM finance.user_settings
+		UNION SELECT currency FROM finance.wallets WHERE deleted_at IS NULL
+		UNION SELECT $1
+		ORDER BY 1`, DefaultCurrency)
+	if err != nil {
+		return nil, fmt.Errorf("list currencies: %w", err)
+	}
+	defer rows.Close()
+
+	var out []string
+	for rows.Next() {
+		var code string
+		if err := rows.Scan(&code); err != nil {
+			return nil, err
+		}
+		out = append(out, code)
+	}
+	return out, rows.Err()
+}
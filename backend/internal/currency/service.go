@@ -0,0 +1,213 @@
+package currency
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// maxRates caps one ListRates response.
+const maxRates = 500
+
+var (
+	// ErrInvalidCurrency indicates a code that is not an active ISO 4217 currency.
+	ErrInvalidCurrency = errors.New("invalid_currency")
+	// ErrInvalidRate indicates a rate payload with a bad pair, date or value.
+	ErrInvalidRate = errors.New("invalid_exchange_rate")
+	// ErrRateNotFound is returned when no rate is known for a pair on or before a date,
+	// or when a manual rate to delete does not exist for the user.
+	ErrRateNotFound = errors.New("exchange_rate_not_found")
+)
+
+// Normalize upper-cases code and checks it is an active ISO 4217 currency.
+func Normalize(code string) (string, error) {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if !codes[code] {
+		return "", fmt.Errorf("%w: %q is not an ISO 4217 code", ErrInvalidCurrency, code)
+	}
+	return code, nil
+}
+
+// day truncates t to its calendar date in UTC.
+func day(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+type Service struct {
+	repo     Repository
+	provider Provider
+}
+
+type ServiceDeps struct {
+	Repo Repository
+	// Provider supplies market rates; nil means manual rates only.
+	Provider Provider
+}
+
+func NewService(d ServiceDeps) *Service {
+	return &Service{repo: d.Repo, provider: d.Provider}
+}
+
+// BaseCurrency returns the user's base currency, DefaultCurrency until they choose one.
+func (s *Service) BaseCurrency(ctx context.Context, userID uuid.UUID) (string, error) {
+	return s.repo.BaseCurrency(ctx, userID)
+}
+
+// Settings returns the user's currency settings.
+func (s *Service) Settings(ctx context.Context, userID uuid.UUID) (*Settings, error) {
+	code, err := s.repo.BaseCurrency(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return &Settings{UserID: userID, BaseCurrency: code}, nil
+}
+
+// SetBaseCurrency changes the currency budgets and analytics report in.
+func (s *Service) SetBaseCurrency(ctx context.Context, userID uuid.UUID, code string) (*Settings, error) {
+	code, err := Normalize(code)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.SetBaseCurrency(ctx, userID, code)
+}
+
+// AddRate stores a manual rate of the user: one base is worth rate quote on date. It
+// replaces the user's earlier rate for the same pair and date.
+func (s *Service) AddRate(ctx context.Context, userID uuid.UUID, base, quote string, date time.Time, rate string) (*Rate, error) {
+	base, err := Normalize(base)
+	if err != nil {
+		return nil, err
+	}
+	quote, err = Normalize(quote)
+	if err != nil {
+		return nil, err
+	}
+	if base == quote {
+		return nil, fmt.Errorf("%w: base and quote currency must differ", ErrInvalidRate)
+	}
+	if date.IsZero() {
+		return nil, fmt.Errorf("%w: rate_date is required", ErrInvalidRate)
+	}
+	if _, ok := parseRate(rate); !ok {
+		return nil, fmt.Errorf("%w: rate must be a positive number", ErrInvalidRate)
+	}
+	return s.repo.SaveRate(ctx, Rate{
+		ID:       uuid.New(),
+		UserID:   &userID,
+		Base:     base,
+		Quote:    quote,
+		RateDate: day(date),
+		Rate:     strings.TrimSpace(rate),
+		Source:   SourceManual,
+	})
+}
+
+// ListRates returns the user's manual rates and the provider rates, newest first.
+// base and quote are optional filters.
+func (s *Service) ListRates(ctx context.Context, userID uuid.UUID, base, quote string) ([]Rate, error) {
+	var err error
+	if base != "" {
+		if base, err = Normalize(base); err != nil {
+			return nil, err
+		}
+	}
+	if quote != "" {
+		if quote, err = Normalize(quote); err != nil {
+			return nil, err
+		}
+	}
+	return s.repo.ListRates(ctx, userID, base, quote, maxRates)
+}
+
+// DeleteRate removes one of the user's manual rates.
+func (s *Service) DeleteRate(ctx context.Context, userID, id uuid.UUID) error {
+	return s.repo.DeleteRate(ctx, userID, id)
+}
+
+// Quote returns the rate that converts from into to on a date. When the stored rates are
+// older than the date, the provider (if any) is asked first; if it fails the latest
+// stored rate is still used.
+func (s *Service) Quote(ctx context.Context, userID uuid.UUID, from, to string, on time.Time) (*Quote, error) {
+	from, err := Normalize(from)
+	if err != nil {
+		return nil, err
+	}
+	to, err = Normalize(to)
+	if err != nil {
+		return nil, err
+	}
+	on = day(on)
+	if from == to {
+		return &Quote{From: from, To: to, Rate: "1", RateDate: on}, nil
+	}
+
+	q, err := s.repo.Quote(ctx, userID, from, to, on)
+	if err != nil && !errors.Is(err, ErrRateNotFound) {
+		return nil, err
+	}
+	if s.provider != nil && (q == nil || q.RateDate.Before(on)) && !on.After(day(time.Now())) {
+		if ferr := s.fetch(ctx, from, on); ferr != nil {
+			log.Printf("[CURRENCY_ERROR] fetch %s rates for %s: %v", from, on.Format("2006-01-02"), ferr)
+		} else if q, err = s.repo.Quote(ctx, userID, from, to, on); err != nil && !errors.Is(err, ErrRateNotFound) {
+			return nil, err
+		}
+	}
+	if q == nil {
+		return nil, fmt.Errorf("%w: no %s/%s rate on or before %s", ErrRateNotFound, from, to, on.Format("2006-01-02"))
+	}
+	return q, nil
+}
+
+// Convert converts amount from one currency into another with the rate on a date,
+// rounding to cents.
+func (s *Service) Convert(ctx context.Context, userID uuid.UUID, amount, from, to string, on time.Time) (*Conversion, error) {
+	q, err := s.Quote(ctx, userID, from, to, on)
+	if err != nil {
+		return nil, err
+	}
+	converted, ok := convert(amount, q.Rate)
+	if !ok {
+		return nil, fmt.Errorf("%w: amount must be a number", ErrInvalidRate)
+	}
+	return &Conversion{Amount: strings.TrimSpace(amount), From: q.From, Converted: converted, To: q.To, Quote: *q}, nil
+}
+
+// fetch stores the provider rates of base for a date.
+func (s *Service) fetch(ctx context.Context, base string, on time.Time) error {
+	date, rates, err := s.provider.Rates(ctx, base, on)
+	if err != nil {
+		return err
+	}
+	if len(rates) == 0 {
+		return nil
+	}
+	return s.repo.SaveProviderRates(ctx, base, date, rates)
+}
+
+// RefreshRates fetches the provider rates of now for every currency in use. It returns
+// how many currencies were refreshed; without a provider it does nothing.
+func (s *Service) RefreshRates(ctx context.Context, now time.Time) (int, error) {
+	if s.provider == nil {
+		return 0, nil
+	}
+	used, err := s.repo.UsedCurrencies(ctx)
+	if err != nil {
+		return 0, err
+	}
+	var n int
+	var errs []error
+	for _, code := range used {
+		if err := s.fetch(ctx, code, day(now)); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", code, err))
+			continue
+		}
+		n++
+	}
+	return n, errors.Join(errs...)
+}
@@ -0,0 +1,204 @@
+package currency
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"github.com/Jomesi149/Implementasi-LASTI/backend/pkg/response"
+)
+
+type HTTPHandler struct {
+	service *Service
+}
+
+func NewHTTPHandler(s *Service) *HTTPHandler {
+	return &HTTPHandler{service: s}
+}
+
+func (h *HTTPHandler) RegisterRoutes(r chi.Router) {
+	r.Get("/settings/currency", h.handleGetSettings)
+	r.Put("/settings/currency", h.handleSetBaseCurrency)
+	r.Route("/exchange-rates", func(r chi.Router) {
+		r.Get("/", h.handleListRates)
+		r.Post("/", h.handleAddRate)
+		r.Get("/quote", h.handleQuote)
+		r.Get("/convert", h.handleConvert)
+		r.Delete("/{id}", h.handleDeleteRate)
+	})
+}
+
+func getUserIDFromHeader(r *http.Request) (uuid.UUID, error) {
+	s := r.Header.Get("X-User-ID")
+	return uuid.Parse(s)
+}
+
+// errorStatus maps service sentinel errors onto HTTP status codes.
+func errorStatus(err error) int {
+	switch {
+	case errors.Is(err, ErrRateNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, ErrInvalidCurrency), errors.Is(err, ErrInvalidRate):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// userID reads the user id header, answering 401 when it is missing.
+func userID(w http.ResponseWriter, r *http.Request) (uuid.UUID, bool) {
+	uid, err := getUserIDFromHeader(r)
+	if err != nil {
+		response.Error(w, http.StatusUnauthorized, "missing X-User-ID header")
+		return uuid.Nil, false
+	}
+	return uid, true
+}
+
+// dateParam parses an optional YYYY-MM-DD query parameter, defaulting to today.
+func dateParam(r *http.Request, name string) (time.Time, bool) {
+	v := r.URL.Query().Get(name)
+	if v == "" {
+		return time.Now(), true
+	}
+	t, err := time.Parse("2006-01-02", v)
+	return t, err == nil
+}
+
+func (h *HTTPHandler) handleGetSettings(w http.ResponseWriter, r *http.Request) {
+	uid, ok := userID(w, r)
+	if !ok {
+		return
+	}
+	s, err := h.service.Settings(r.Context(), uid)
+	if err != nil {
+		response.Error(w, errorStatus(err), err.Error())
+		return
+	}
+	response.JSON(w, http.StatusOK, s)
+}
+
+type setBaseCurrencyReq struct {
+	BaseCurrency string `json:"base_currency"`
+}
+
+func (h *HTTPHandler) handleSetBaseCurrency(w http.ResponseWriter, r *http.Request) {
+	uid, ok := userID(w, r)
+	if !ok {
+		return
+	}
+	var req setBaseCurrencyReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.Error(w, http.StatusBadRequest, "invalid payload")
+		return
+	}
+	s, err := h.service.SetBaseCurrency(r.Context(), uid, req.BaseCurrency)
+	if err != nil {
+		response.Error(w, errorStatus(err), err.Error())
+		return
+	}
+	response.JSON(w, http.StatusOK, s)
+}
+
+func (h *HTTPHandler) handleListRates(w http.ResponseWriter, r *http.Request) {
+	uid, ok := userID(w, r)
+	if !ok {
+		return
+	}
+	q := r.URL.Query()
+	rates, err := h.service.ListRates(r.Context(), uid, q.Get("base"), q.Get("quote"))
+	if err != nil {
+		response.Error(w, errorStatus(err), err.Error())
+		return
+	}
+	response.JSON(w, http.StatusOK, rates)
+}
+
+type addRateReq struct {
+	BaseCurrency  string `json:"base_currency"`
+	QuoteCurrency string `json:"quote_currency"`
+	RateDate      string `json:"rate_date"`
+	Rate          string `json:"rate"`
+}
+
+func (h *HTTPHandler) handleAddRate(w http.ResponseWriter, r *http.Request) {
+	uid, ok := userID(w, r)
+	if !ok {
+		return
+	}
+	var req addRateReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.Error(w, http.StatusBadRequest, "invalid payload")
+		return
+	}
+	date, err := time.Parse("2006-01-02", req.RateDate)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "invalid rate_date, expected YYYY-MM-DD")
+		return
+	}
+	rate, err := h.service.AddRate(r.Context(), uid, req.BaseCurrency, req.QuoteCurrency, date, req.Rate)
+	if err != nil {
+		response.Error(w, errorStatus(err), err.Error())
+		return
+	}
+	response.JSON(w, http.StatusCreated, rate)
+}
+
+func (h *HTTPHandler) handleDeleteRate(w http.ResponseWriter, r *http.Request) {
+	uid, ok := userID(w, r)
+	if !ok {
+		return
+	}
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "invalid rate id")
+		return
+	}
+	if err := h.service.DeleteRate(r.Context(), uid, id); err != nil {
+		response.Error(w, errorStatus(err), err.Error())
+		return
+	}
+	response.JSON(w, http.StatusOK, map[string]string{"status": "rate deleted"})
+}
+
+func (h *HTTPHandler) handleQuote(w http.ResponseWriter, r *http.Request) {
+	uid, ok := userID(w, r)
+	if !ok {
+		return
+	}
+	date, ok := dateParam(r, "date")
+	if !ok {
+		response.Error(w, http.StatusBadRequest, "invalid date, expected YYYY-MM-DD")
+		return
+	}
+	q := r.URL.Query()
+	quote, err := h.service.Quote(r.Context(), uid, q.Get("from"), q.Get("to"), date)
+	if err != nil {
+		response.Error(w, errorStatus(err), err.Error())
+		return
+	}
+	response.JSON(w, http.StatusOK, quote)
+}
+
+func (h *HTTPHandler) handleConvert(w http.ResponseWriter, r *http.Request) {
+	uid, ok := userID(w, r)
+	if !ok {
+		return
+	}
+	date, ok := dateParam(r, "date")
+	if !ok {
+		response.Error(w, http.StatusBadRequest, "invalid date, expected YYYY-MM-DD")
+		return
+	}
+	q := r.URL.Query()
+	c, err := h.service.Convert(r.Context(), uid, q.Get("amount"), q.Get("from"), q.Get("to"), date)
+	if err != nil {
+		response.Error(w, errorStatus(err), err.Error())
+		return
+	}
+	response.JSON(w, http.StatusOK, c)
+}
@@ -0,0 +1,65 @@
+package goal
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Goal is a savings target. Money counts towards it either as the balance of a
+// dedicated wallet or as contributions earmarked from transactions. The progress fields
+// are derived when the goal is read; amounts are in the goal's currency.
+type Goal struct {
+	ID           uuid.UUID  `json:"id"`
+	UserID       uuid.UUID  `json:"user_id"`
+	Name         string     `json:"name"`
+	TargetAmount string     `json:"target_amount"`
+	Currency     string     `json:"currency"`
+	Deadline     *time.Time `json:"deadline,omitempty"`
+	WalletID     *uuid.UUID `json:"wallet_id,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+
+	Saved     string  `json:"saved"`
+	Remaining string  `json:"remaining"`
+	Progress  float64 `json:"progress_percent"`
+	Achieved  bool    `json:"achieved"`
+	// MonthlyPace is the average saved per month over the recent window; MonthlyRequired
+	// what is still needed per month to reach the target by the deadline.
+	MonthlyPace         string     `json:"monthly_pace"`
+	MonthlyRequired     *string    `json:"monthly_required,omitempty"`
+	ProjectedCompletion *time.Time `json:"projected_completion,omitempty"`
+	OnTrack             *bool      `json:"on_track,omitempty"`
+
+	// recent is what was saved within the pace window, as read from the database.
+	recent string
+}
+
+// Contribution earmarks part of a transaction for an earmark goal.
+type Contribution struct {
+	GoalID        uuid.UUID `json:"goal_id"`
+	TransactionID uuid.UUID `json:"transaction_id"`
+	Amount        string    `json:"amount"`
+	WalletID      uuid.UUID `json:"wallet_id"`
+	Kind          string    `json:"kind"`
+	Note          *string   `json:"note,omitempty"`
+	OccurredAt    time.Time `json:"occurred_at"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// GoalRequest is the payload to create or edit a goal. Deadline uses the YYYY-MM-DD
+// format. WalletID and Currency only apply on create: a wallet goal takes the wallet's
+// currency, an earmark goal defaults to the user's base currency.
+type GoalRequest struct {
+	Name         string  `json:"name"`
+	TargetAmount string  `json:"target_amount"`
+	Deadline     *string `json:"deadline"`
+	WalletID     *string `json:"wallet_id"`
+	Currency     string  `json:"currency"`
+}
+
+// ContributionRequest earmarks a transaction; an empty amount takes what is not yet
+// earmarked for other goals.
+type ContributionRequest struct {
+	TransactionID string `json:"transaction_id"`
+	Amount        string `json:"amount"`
+}
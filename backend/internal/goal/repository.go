@@ -0,0 +1,223 @@
+package goal
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/Jomesi149/Implementasi-LASTI/backend/internal/sqlutil"
+)
+
+// Repository persists goals and their contributions.
+type Repository interface {
+	Create(ctx context.Context, g Goal) error
+	List(ctx context.Context, userID uuid.UUID, since time.Time) ([]Goal, error)
+	Get(ctx context.Context, userID, id uuid.UUID, since time.Time) (*Goal, error)
+	Update(ctx context.Context, g Goal) error
+	Delete(ctx context.Context, userID, id uuid.UUID) error
+	AddContribution(ctx context.Context, userID uuid.UUID, g Goal, transactionID uuid.UUID, amount string) (*Contribution, error)
+	ListContributions(ctx context.Context, userID, goalID uuid.UUID) ([]Contribution, error)
+	RemoveContribution(ctx context.Context, userID, goalID, transactionID uuid.UUID) error
+}
+
+type SQLRepository struct {
+	db *sql.DB
+}
+
+func NewRepository(db *sql.DB) *SQLRepository {
+	return &SQLRepository{db: db}
+}
+
+// goalColumns selects a goal with what it has saved in total and since $2. A wallet goal
+// saved its wallet balance, and recently the wallet's net inflow; an earmark goal its
+// contributions from live transactions.
+const goalColumns = `g.id, g.user_id, g.name, g.target_amount::TEXT, g.currency, g.deadline, g.wallet_id, g.created_at,
+	(CASE WHEN g.wallet_id IS NOT NULL
+		THEN COALESCE((SELECT w.balance FROM finance.wallets w WHERE w.id = g.wallet_id AND w.deleted_at IS NULL), 0)
+		ELSE COALESCE((SELECT SUM(c.amount) FROM finance.goal_contributions c
+			JOIN finance.transactions t ON t.id = c.transaction_id AND t.deleted_at IS NULL AND NOT t.planned
+			WHERE c.goal_id = g.id), 0)
+	END)::TEXT,
+	(CASE WHEN g.wallet_id IS NOT NULL
+		THEN COALESCE((SELECT SUM(CASE WHEN t.kind = 'in' THEN t.amount ELSE -t.amount END) FROM finance.transactions t
+			WHERE t.wallet_id = g.wallet_id AND t.deleted_at IS NULL AND NOT t.planned AND t.occurred_at >= $2::DATE), 0)
+		ELSE COALESCE((SELECT SUM(c.amount) FROM finance.goal_contributions c
+			JOIN finance.transactions t ON t.id = c.transaction_id AND t.deleted_at IS NULL AND NOT t.planned
+			WHERE c.goal_id = g.id AND t.occurred_at >= $2::DATE), 0)
+	END)::TEXT`
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanGoal(row rowScanner) (Goal, error) {
+	var g Goal
+	var deadline sql.NullTime
+	var walletID uuid.NullUUID
+	if err := row.Scan(&g.ID, &g.UserID, &g.Name, &g.TargetAmount, &g.Currency, &deadline, &walletID, &g.CreatedAt, &g.Saved, &g.recent); err != nil {
+		return g, err
+	}
+	if deadline.Valid {
+		d := deadline.Time
+		g.Deadline = &d
+	}
+	if walletID.Valid {
+		id := walletID.UUID
+		g.WalletID = &id
+	}
+	return g, nil
+}
+
+func (r *SQLRepository) Create(ctx context.Context, g Goal) error {
+	query := `INSERT INTO finance.goals (id, user_id, name, target_amount, currency, deadline, wallet_id, created_at, updated_at)
+		VALUES ($1,$2,$3,$4::NUMERIC,$5,$6::DATE,$7,NOW(),NOW())`
+	if _, err := r.db.ExecContext(ctx, query, g.ID, g.UserID, g.Name, g.TargetAmount, g.Currency, sqlutil.DateParam(g.Deadline), g.WalletID); err != nil {
+		return fmt.Errorf("insert goal: %w", err)
+	}
+	return nil
+}
+
+func (r *SQLRepository) List(ctx context.Context, userID uuid.UUID, since time.Time) ([]Goal, error) {
+	query := fmt.Sprintf(`SELECT %s FROM finance.goals g WHERE g.user_id = $1
+		ORDER BY g.deadline ASC NULLS LAST, g.created_at ASC`, goalColumns)
+	rows, err := r.db.QueryContext(ctx, query, userID, since.Format(dateLayout))
+	if err != nil {
+		return nil, fmt.Errorf("select goals: %w", err)
+	}
+	defer rows.Close()
+
+	out := []Goal{}
+	for rows.Next() {
+		g, err := scanGoal(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, g)
+	}
+	return out, rows.Err()
+}
+
+func (r *SQLRepository) Get(ctx context.Context, userID, id uuid.UUID, since time.Time) (*Goal, error) {
+	query := fmt.Sprintf(`SELECT %s FROM finance.goals g WHERE g.user_id = $1 AND g.id = $3`, goalColumns)
+	g, err := scanGoal(r.db.QueryRowContext(ctx, query, userID, since.Format(dateLayout), id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrGoalNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("select goal: %w", err)
+	}
+	return &g, nil
+}
+
+func (r *SQLRepository) Update(ctx context.Context, g Goal) error {
+	query := `UPDATE finance.goals SET name = $3, target_amount = $4::NUMERIC, deadline = $5::DATE, updated_at = NOW()
+		WHERE id = $1 AND user_id = $2`
+	res, err := r.db.ExecContext(ctx, query, g.ID, g.UserID, g.Name, g.TargetAmount, sqlutil.DateParam(g.Deadline))
+	if err != nil {
+		return fmt.Errorf("update goal: %w", err)
+	}
+	return sqlutil.ExpectAffected(res, ErrGoalNotFound)
+}
+
+// Delete removes a goal and its contributions; the transactions stay.
+func (r *SQLRepository) Delete(ctx context.Context, userID, id uuid.UUID) error {
+	res, err := r.db.ExecContext(ctx, `DELETE FROM finance.goals WHERE id = $1 AND user_id = $2`, id, userID)
+	if err != nil {
+		return fmt.Errorf("delete goal: %w", err)
+	}
+	return sqlutil.ExpectAffected(res, ErrGoalNotFound)
+}
+
+// AddContribution earmarks amount of a live transaction for g, replacing an earlier
+// earmark of the same transaction. The transaction row is locked so that concurrent
+// earmarks cannot exceed its amount together.
+func (r *SQLRepository) AddContribution(ctx context.Context, userID uuid.UUID, g Goal, transactionID uuid.UUID, amount string) (c *Contribution, err error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("begin tx: %w", err)
+	}
+
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	c = &Contribution{GoalID: g.ID, TransactionID: transactionID}
+	var total, others, code string
+	err = tx.QueryRowContext(ctx, `SELECT t.amount::TEXT, t.currency, t.wallet_id, t.kind, t.note, t.occurred_at,
+			COALESCE((SELECT SUM(c.amount) FROM finance.goal_contributions c WHERE c.transaction_id = t.id AND c.goal_id <> $3), 0)::TEXT
+		FROM finance.transactions t WHERE t.id = $1 AND t.user_id = $2 AND t.deleted_at IS NULL FOR UPDATE`,
+		transactionID, userID, g.ID).Scan(&total, &code, &c.WalletID, &c.Kind, &c.Note, &c.OccurredAt, &others)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrTransactionNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("select transaction: %w", err)
+	}
+	if code != g.Currency {
+		return nil, fmt.Errorf("%w: transaction is in %s, the goal in %s", ErrInvalidGoal, code, g.Currency)
+	}
+	t, _ := sqlutil.ToCents(total)
+	o, _ := sqlutil.ToCents(others)
+	available := t - o
+	want := available
+	if amount != "" {
+		want, _ = sqlutil.ToCents(amount)
+	}
+	if want <= 0 || want > available {
+		return nil, fmt.Errorf("%w: only %s of the transaction is not earmarked yet", ErrInvalidGoal, sqlutil.CentsString(max(available, 0)))
+	}
+	c.Amount = sqlutil.CentsString(want)
+
+	err = tx.QueryRowContext(ctx, `INSERT INTO finance.goal_contributions (goal_id, transaction_id, amount, created_at)
+		VALUES ($1,$2,$3::NUMERIC,NOW())
+		ON CONFLICT (goal_id, transaction_id) DO UPDATE SET amount = EXCLUDED.amount
+		RETURNING created_at`, g.ID, transactionID, c.Amount).Scan(&c.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("insert contribution: %w", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("commit tx: %w", err)
+	}
+	return c, nil
+}
+
+// ListContributions returns the contributions of a goal from live transactions, newest
+// first.
+func (r *SQLRepository) ListContributions(ctx context.Context, userID, goalID uuid.UUID) ([]Contribution, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT c.goal_id, c.transaction_id, c.amount::TEXT, t.wallet_id, t.kind, t.note, t.occurred_at, c.created_at
+		FROM finance.goal_contributions c
+		JOIN finance.goals g ON g.id = c.goal_id
+		JOIN finance.transactions t ON t.id = c.transaction_id AND t.deleted_at IS NULL AND NOT t.planned
+		WHERE c.goal_id = $1 AND g.user_id = $2
+		ORDER BY t.occurred_at DESC, t.id DESC`, goalID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("select contributions: %w", err)
+	}
+	defer rows.Close()
+
+	out := []Contribution{}
+	for rows.Next() {
+		var c Contribution
+		if err := rows.Scan(&c.GoalID, &c.TransactionID, &c.Amount, &c.WalletID, &c.Kind, &c.Note, &c.OccurredAt, &c.CreatedAt); err != nil {
+			return nil, err
+		}
+		out = append(out, c)
+	}
+	return out, rows.Err()
+}
+
+func (r *SQLRepository) RemoveContribution(ctx context.Context, userID, goalID, transactionID uuid.UUID) error {
+	res, err := r.db.ExecContext(ctx, `DELETE FROM finance.goal_contributions c USING finance.goals g
+		WHERE c.goal_id = g.id AND c.goal_id = $1 AND c.transaction_id = $2 AND g.user_id = $3`, goalID, transactionID, userID)
+	if err != nil {
+		return fmt.Errorf("delete contribution: %w", err)
+	}
+	return sqlutil.ExpectAffected(res, ErrContributionNotFound)
+}
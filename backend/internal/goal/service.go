@@ -0,0 +1,248 @@
+package goal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/Jomesi149/Implementasi-LASTI/backend/internal/currency"
+	"github.com/Jomesi149/Implementasi-LASTI/backend/internal/sqlutil"
+	"github.com/Jomesi149/Implementasi-LASTI/backend/internal/transaction"
+)
+
+var (
+	// ErrGoalNotFound is returned when the goal does not exist for the user.
+	ErrGoalNotFound = errors.New("goal_not_found")
+	// ErrInvalidGoal indicates a goal or contribution with a bad amount, deadline or
+	// source.
+	ErrInvalidGoal = errors.New("invalid_goal")
+	// ErrContributionNotFound is returned when the transaction is not earmarked for the goal.
+	ErrContributionNotFound = errors.New("goal_contribution_not_found")
+	// ErrTransactionNotFound is returned when the transaction to earmark does not exist
+	// for the user.
+	ErrTransactionNotFound = errors.New("transaction_not_found")
+)
+
+const (
+	dateLayout  = "2006-01-02"
+	maxGoalName = 100
+	// paceWindowDays is how far back the saving pace is measured.
+	paceWindowDays = 90
+	// daysPerMonth is the average month length used to turn daily amounts into monthly ones.
+	daysPerMonth = 365.25 / 12
+)
+
+type ServiceDeps struct {
+	Repo         Repository
+	Transactions *transaction.Service
+	// Currencies supplies the base currency new earmark goals default to.
+	Currencies *currency.Service
+}
+
+type Service struct {
+	repo         Repository
+	transactions *transaction.Service
+	currencies   *currency.Service
+	now          func() time.Time
+}
+
+func NewService(deps ServiceDeps) *Service {
+	return &Service{repo: deps.Repo, transactions: deps.Transactions, currencies: deps.Currencies, now: time.Now}
+}
+
+// today is the current calendar date as UTC midnight.
+func (s *Service) today() time.Time {
+	now := s.now()
+	return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// paceSince is the first day of the pace window.
+func (s *Service) paceSince() time.Time {
+	return s.today().AddDate(0, 0, -paceWindowDays)
+}
+
+// CreateGoal validates req and stores a new goal.
+func (s *Service) CreateGoal(ctx context.Context, userID uuid.UUID, req GoalRequest) (*Goal, error) {
+	g := Goal{ID: uuid.New(), UserID: userID, CreatedAt: s.now()}
+	if err := applyRequest(&g, req); err != nil {
+		return nil, err
+	}
+
+	if req.WalletID != nil && *req.WalletID != "" {
+		walletID, err := uuid.Parse(*req.WalletID)
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid wallet_id", ErrInvalidGoal)
+		}
+		w, err := s.transactions.GetWallet(ctx, userID, walletID)
+		if err != nil {
+			return nil, err
+		}
+		if req.Currency != "" && !strings.EqualFold(strings.TrimSpace(req.Currency), w.Currency) {
+			return nil, fmt.Errorf("%w: currency differs from the wallet", ErrInvalidGoal)
+		}
+		g.WalletID, g.Currency = &w.ID, w.Currency
+	} else {
+		code, err := s.goalCurrency(ctx, userID, req.Currency)
+		if err != nil {
+			return nil, err
+		}
+		g.Currency = code
+	}
+
+	if err := s.repo.Create(ctx, g); err != nil {
+		return nil, err
+	}
+	return s.GetGoal(ctx, userID, g.ID)
+}
+
+// goalCurrency validates the currency of an earmark goal, defaulting to the user's base
+// currency.
+func (s *Service) goalCurrency(ctx context.Context, userID uuid.UUID, code string) (string, error) {
+	if strings.TrimSpace(code) != "" {
+		return currency.Normalize(code)
+	}
+	if s.currencies == nil {
+		return currency.DefaultCurrency, nil
+	}
+	return s.currencies.BaseCurrency(ctx, userID)
+}
+
+// ListGoals returns the user's goals with their progress, nearest deadline first.
+func (s *Service) ListGoals(ctx context.Context, userID uuid.UUID) ([]Goal, error) {
+	goals, err := s.repo.List(ctx, userID, s.paceSince())
+	if err != nil {
+		return nil, err
+	}
+	today := s.today()
+	for i := range goals {
+		progress(&goals[i], today)
+	}
+	return goals, nil
+}
+
+// GetGoal returns a goal with its progress.
+func (s *Service) GetGoal(ctx context.Context, userID, id uuid.UUID) (*Goal, error) {
+	g, err := s.repo.Get(ctx, userID, id, s.paceSince())
+	if err != nil {
+		return nil, err
+	}
+	progress(g, s.today())
+	return g, nil
+}
+
+// UpdateGoal changes the name, target or deadline of a goal; its wallet and currency
+// stay.
+func (s *Service) UpdateGoal(ctx context.Context, userID, id uuid.UUID, req GoalRequest) (*Goal, error) {
+	g, err := s.repo.Get(ctx, userID, id, s.paceSince())
+	if err != nil {
+		return nil, err
+	}
+	if err := applyRequest(g, req); err != nil {
+		return nil, err
+	}
+	if err := s.repo.Update(ctx, *g); err != nil {
+		return nil, err
+	}
+	return s.GetGoal(ctx, userID, id)
+}
+
+func (s *Service) DeleteGoal(ctx context.Context, userID, id uuid.UUID) error {
+	return s.repo.Delete(ctx, userID, id)
+}
+
+// AddContribution earmarks part of a transaction for an earmark goal.
+func (s *Service) AddContribution(ctx context.Context, userID, goalID uuid.UUID, req ContributionRequest) (*Contribution, error) {
+	g, err := s.repo.Get(ctx, userID, goalID, s.paceSince())
+	if err != nil {
+		return nil, err
+	}
+	if g.WalletID != nil {
+		return nil, fmt.Errorf("%w: the goal tracks the balance of its wallet", ErrInvalidGoal)
+	}
+	transactionID, err := uuid.Parse(req.TransactionID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid transaction_id", ErrInvalidGoal)
+	}
+	if req.Amount != "" {
+		if cents, ok := sqlutil.ToCents(req.Amount); !ok || cents <= 0 {
+			return nil, fmt.Errorf("%w: amount must be a positive number", ErrInvalidGoal)
+		}
+	}
+	return s.repo.AddContribution(ctx, userID, *g, transactionID, req.Amount)
+}
+
+func (s *Service) ListContributions(ctx context.Context, userID, goalID uuid.UUID) ([]Contribution, error) {
+	if _, err := s.repo.Get(ctx, userID, goalID, s.paceSince()); err != nil {
+		return nil, err
+	}
+	return s.repo.ListContributions(ctx, userID, goalID)
+}
+
+func (s *Service) RemoveContribution(ctx context.Context, userID, goalID, transactionID uuid.UUID) error {
+	return s.repo.RemoveContribution(ctx, userID, goalID, transactionID)
+}
+
+// applyRequest validates the editable fields of req and copies them onto g.
+func applyRequest(g *Goal, req GoalRequest) error {
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidGoal)
+	}
+	if len([]rune(name)) > maxGoalName {
+		return fmt.Errorf("%w: name is longer than %d characters", ErrInvalidGoal, maxGoalName)
+	}
+	target, ok := sqlutil.ToCents(req.TargetAmount)
+	if !ok || target <= 0 {
+		return fmt.Errorf("%w: target_amount must be a positive number", ErrInvalidGoal)
+	}
+	var deadline *time.Time
+	if req.Deadline != nil && *req.Deadline != "" {
+		d, err := time.Parse(dateLayout, *req.Deadline)
+		if err != nil {
+			return fmt.Errorf("%w: deadline must be YYYY-MM-DD", ErrInvalidGoal)
+		}
+		deadline = &d
+	}
+	g.Name = name
+	g.TargetAmount = sqlutil.CentsString(target)
+	g.Deadline = deadline
+	return nil
+}
+
+// progress derives the progress fields of g as of today. The pace is what was saved in
+// the last paceWindowDays; the projection assumes it continues.
+func progress(g *Goal, today time.Time) {
+	target, _ := sqlutil.ToCents(g.TargetAmount)
+	saved, _ := sqlutil.ToCents(g.Saved)
+	recent, _ := sqlutil.ToCents(g.recent)
+	remaining := max(target-saved, 0)
+
+	g.Remaining = sqlutil.CentsString(remaining)
+	g.Progress = math.Round(float64(saved)/float64(target)*1000) / 10
+	g.Achieved = saved >= target
+	g.MonthlyPace = sqlutil.CentsString(int64(math.Round(float64(recent) * daysPerMonth / paceWindowDays)))
+	g.MonthlyRequired, g.ProjectedCompletion, g.OnTrack = nil, nil, nil
+
+	if !g.Achieved && recent > 0 {
+		days := math.Ceil(float64(remaining) / (float64(recent) / paceWindowDays))
+		done := today.AddDate(0, 0, int(days))
+		g.ProjectedCompletion = &done
+	}
+	if g.Deadline == nil {
+		return
+	}
+	onTrack := g.Achieved || (g.ProjectedCompletion != nil && !g.ProjectedCompletion.After(*g.Deadline))
+	g.OnTrack = &onTrack
+	if g.Achieved {
+		return
+	}
+	// Tenggat lewat atau kurang dari sebulan: sisa target harus terkumpul sekaligus
+	months := math.Max(g.Deadline.Sub(today).Hours()/24/daysPerMonth, 1)
+	required := sqlutil.CentsString(int64(math.Ceil(float64(remaining) / months)))
+	g.MonthlyRequired = &required
+}
@@ -0,0 +1,192 @@
+package goal
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"github.com/Jomesi149/Implementasi-LASTI/backend/internal/currency"
+	"github.com/Jomesi149/Implementasi-LASTI/backend/internal/transaction"
+	"github.com/Jomesi149/Implementasi-LASTI/backend/pkg/response"
+)
+
+type HTTPHandler struct {
+	service *Service
+}
+
+func NewHTTPHandler(s *Service) *HTTPHandler {
+	return &HTTPHandler{service: s}
+}
+
+func (h *HTTPHandler) RegisterRoutes(r chi.Router) {
+	r.Route("/goals", func(r chi.Router) {
+		r.Post("/", h.handleCreateGoal)
+		r.Get("/", h.handleListGoals)
+		r.Get("/{id}", h.handleGetGoal)
+		r.Put("/{id}", h.handleUpdateGoal)
+		r.Delete("/{id}", h.handleDeleteGoal)
+		r.Get("/{id}/contributions", h.handleListContributions)
+		r.Post("/{id}/contributions", h.handleAddContribution)
+		r.Delete("/{id}/contributions/{transactionId}", h.handleRemoveContribution)
+	})
+}
+
+func getUserIDFromHeader(r *http.Request) (uuid.UUID, error) {
+	s := r.Header.Get("X-User-ID")
+	return uuid.Parse(s)
+}
+
+// errorStatus maps service sentinel errors onto HTTP status codes.
+func errorStatus(err error) int {
+	switch {
+	case errors.Is(err, ErrGoalNotFound), errors.Is(err, ErrContributionNotFound), errors.Is(err, ErrTransactionNotFound),
+		errors.Is(err, transaction.ErrWalletNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, ErrInvalidGoal), errors.Is(err, currency.ErrInvalidCurrency):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// goalParams reads the user id header and the {id} URL parameter.
+func goalParams(w http.ResponseWriter, r *http.Request) (uuid.UUID, uuid.UUID, bool) {
+	uid, err := getUserIDFromHeader(r)
+	if err != nil {
+		response.Error(w, http.StatusUnauthorized, "missing X-User-ID header")
+		return uuid.Nil, uuid.Nil, false
+	}
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "invalid goal id")
+		return uuid.Nil, uuid.Nil, false
+	}
+	return uid, id, true
+}
+
+func (h *HTTPHandler) handleCreateGoal(w http.ResponseWriter, r *http.Request) {
+	uid, err := getUserIDFromHeader(r)
+	if err != nil {
+		response.Error(w, http.StatusUnauthorized, "missing X-User-ID header")
+		return
+	}
+	var req GoalRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.Error(w, http.StatusBadRequest, "invalid payload")
+		return
+	}
+	g, err := h.service.CreateGoal(r.Context(), uid, req)
+	if err != nil {
+		response.Error(w, errorStatus(err), err.Error())
+		return
+	}
+	response.JSON(w, http.StatusCreated, g)
+}
+
+func (h *HTTPHandler) handleListGoals(w http.ResponseWriter, r *http.Request) {
+	uid, err := getUserIDFromHeader(r)
+	if err != nil {
+		response.Error(w, http.StatusUnauthorized, "missing X-User-ID header")
+		return
+	}
+	goals, err := h.service.ListGoals(r.Context(), uid)
+	if err != nil {
+		response.Error(w, errorStatus(err), err.Error())
+		return
+	}
+	response.JSON(w, http.StatusOK, goals)
+}
+
+func (h *HTTPHandler) handleGetGoal(w http.ResponseWriter, r *http.Request) {
+	uid, id, ok := goalParams(w, r)
+	if !ok {
+		return
+	}
+	g, err := h.service.GetGoal(r.Context(), uid, id)
+	if err != nil {
+		response.Error(w, errorStatus(err), err.Error())
+		return
+	}
+	response.JSON(w, http.StatusOK, g)
+}
+
+func (h *HTTPHandler) handleUpdateGoal(w http.ResponseWriter, r *http.Request) {
+	uid, id, ok := goalParams(w, r)
+	if !ok {
+		return
+	}
+	var req GoalRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.Error(w, http.StatusBadRequest, "invalid payload")
+		return
+	}
+	g, err := h.service.UpdateGoal(r.Context(), uid, id, req)
+	if err != nil {
+		response.Error(w, errorStatus(err), err.Error())
+		return
+	}
+	response.JSON(w, http.StatusOK, g)
+}
+
+func (h *HTTPHandler) handleDeleteGoal(w http.ResponseWriter, r *http.Request) {
+	uid, id, ok := goalParams(w, r)
+	if !ok {
+		return
+	}
+	if err := h.service.DeleteGoal(r.Context(), uid, id); err != nil {
+		response.Error(w, errorStatus(err), err.Error())
+		return
+	}
+	response.JSON(w, http.StatusOK, map[string]string{"status": "deleted"})
+}
+
+func (h *HTTPHandler) handleListContributions(w http.ResponseWriter, r *http.Request) {
+	uid, id, ok := goalParams(w, r)
+	if !ok {
+		return
+	}
+	list, err := h.service.ListContributions(r.Context(), uid, id)
+	if err != nil {
+		response.Error(w, errorStatus(err), err.Error())
+		return
+	}
+	response.JSON(w, http.StatusOK, list)
+}
+
+func (h *HTTPHandler) handleAddContribution(w http.ResponseWriter, r *http.Request) {
+	uid, id, ok := goalParams(w, r)
+	if !ok {
+		return
+	}
+	var req ContributionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.Error(w, http.StatusBadRequest, "invalid payload")
+		return
+	}
+	c, err := h.service.AddContribution(r.Context(), uid, id, req)
+	if err != nil {
+		response.Error(w, errorStatus(err), err.Error())
+		return
+	}
+	response.JSON(w, http.StatusCreated, c)
+}
+
+func (h *HTTPHandler) handleRemoveContribution(w http.ResponseWriter, r *http.Request) {
+	uid, id, ok := goalParams(w, r)
+	if !ok {
+		return
+	}
+	transactionID, err := uuid.Parse(chi.URLParam(r, "transactionId"))
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "invalid transaction id")
+		return
+	}
+	if err := h.service.RemoveContribution(r.Context(), uid, id, transactionID); err != nil {
+		response.Error(w, errorStatus(err), err.Error())
+		return
+	}
+	response.JSON(w, http.StatusOK, map[string]string{"status": "removed"})
+}
@@ -0,0 +1,80 @@
+package household
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/Jomesi149/Implementasi-LASTI/backend/internal/transaction"
+)
+
+// Household is a space shared by its owner with other users. Only the owner's wallets can
+// be part of it; Role is the role of the user who asked for it.
+type Household struct {
+	ID        uuid.UUID `json:"id"`
+	OwnerID   uuid.UUID `json:"owner_id"`
+	Name      string    `json:"name"`
+	Role      string    `json:"role"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Member is a user with access to a household or a shared wallet.
+type Member struct {
+	UserID    uuid.UUID `json:"user_id"`
+	Email     string    `json:"email"`
+	Role      string    `json:"role"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Invite asks the user with Email to join a household or a single wallet. TargetName is
+// the name of the household or wallet.
+type Invite struct {
+	ID          uuid.UUID  `json:"id"`
+	HouseholdID *uuid.UUID `json:"household_id,omitempty"`
+	WalletID    *uuid.UUID `json:"wallet_id,omitempty"`
+	TargetName  string     `json:"target_name"`
+	Email       string     `json:"email"`
+	Role        string     `json:"role"`
+	InvitedBy   uuid.UUID  `json:"invited_by"`
+	Status      string     `json:"status"`
+	CreatedAt   time.Time  `json:"created_at"`
+	RespondedAt *time.Time `json:"responded_at,omitempty"`
+}
+
+// SharedWallet is a wallet of another user the caller can access, directly or through a
+// household, with the caller's strongest role on it.
+type SharedWallet struct {
+	transaction.Wallet
+	Role        string     `json:"role"`
+	HouseholdID *uuid.UUID `json:"household_id,omitempty"`
+}
+
+type HouseholdRequest struct {
+	Name string `json:"name"`
+}
+
+type InviteRequest struct {
+	Email string `json:"email"`
+	Role  string `json:"role"`
+}
+
+type RoleRequest struct {
+	Role string `json:"role"`
+}
+
+type WalletRequest struct {
+	WalletID string `json:"wallet_id"`
+}
+
+// TransactionRequest is a transaction recorded in a shared wallet. Categories, payees and
+// tags are those of the wallet owner.
+type TransactionRequest struct {
+	CategoryID *string    `json:"category_id"`
+	Amount     string     `json:"amount"`
+	Kind       string     `json:"kind"`
+	Note       *string    `json:"note"`
+	OccurredAt *time.Time `json:"occurred_at"`
+	PayeeID    *string    `json:"payee_id"`
+	TagIDs     []string   `json:"tag_ids"`
+}
@@ -0,0 +1,472 @@
+package household
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
+
+	"github.com/Jomesi149/Implementasi-LASTI/backend/internal/sqlutil"
+)
+
+// Repository persists households, shared wallets and invites. Every query checks the
+// role of the acting user itself, through finance.household_role and finance.wallet_role.
+type Repository interface {
+	Create(ctx context.Context, h Household) error
+	List(ctx context.Context, userID uuid.UUID) ([]Household, error)
+	Get(ctx context.Context, userID, id uuid.UUID) (*Household, error)
+	Rename(ctx context.Context, ownerID, id uuid.UUID, name string) error
+	Delete(ctx context.Context, ownerID, id uuid.UUID) error
+	Members(ctx context.Context, userID, id uuid.UUID) ([]Member, error)
+	SetMemberRole(ctx context.Context, ownerID, id, memberID uuid.UUID, role string) error
+	RemoveMember(ctx context.Context, actorID, id, memberID uuid.UUID) error
+	AttachWallet(ctx context.Context, ownerID, id, walletID uuid.UUID) error
+	DetachWallet(ctx context.Context, ownerID, id, walletID uuid.UUID) error
+	HouseholdWallets(ctx context.Context, userID, id uuid.UUID) ([]SharedWallet, error)
+
+	SharedWallets(ctx context.Context, userID uuid.UUID) ([]SharedWallet, error)
+	WalletAccess(ctx context.Context, userID, walletID uuid.UUID) (ownerID uuid.UUID, role string, err error)
+	WalletMembers(ctx context.Context, userID, walletID uuid.UUID) ([]Member, error)
+	SetWalletMemberRole(ctx context.Context, ownerID, walletID, memberID uuid.UUID, role string) error
+	RemoveWalletMember(ctx context.Context, actorID, walletID, memberID uuid.UUID) error
+
+	UserIDByEmail(ctx context.Context, email string) (uuid.UUID, bool, error)
+	CreateInvite(ctx context.Context, inv *Invite) error
+	ReceivedInvites(ctx context.Context, userID uuid.UUID) ([]Invite, error)
+	SentInvites(ctx context.Context, ownerID uuid.UUID, householdID, walletID *uuid.UUID) ([]Invite, error)
+	RespondInvite(ctx context.Context, userID, id uuid.UUID, status string) (*Invite, error)
+	RevokeInvite(ctx context.Context, userID, id uuid.UUID) error
+}
+
+type SQLRepository struct {
+	db *sql.DB
+}
+
+func NewRepository(db *sql.DB) *SQLRepository {
+	return &SQLRepository{db: db}
+}
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+// Create stores h with its owner as the first member.
+func (r *SQLRepository) Create(ctx context.Context, h Household) (err error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	if _, err = tx.ExecContext(ctx, `INSERT INTO finance.households (id, owner_id, name, created_at, updated_at)
+		VALUES ($1,$2,$3,$4,$4)`, h.ID, h.OwnerID, h.Name, h.CreatedAt); err != nil {
+		return fmt.Errorf("insert household: %w", err)
+	}
+	if _, err = tx.ExecContext(ctx, `INSERT INTO finance.household_members (household_id, user_id, role, created_at)
+		VALUES ($1,$2,$3,$4)`, h.ID, h.OwnerID, RoleOwner, h.CreatedAt); err != nil {
+		return fmt.Errorf("insert household owner: %w", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("commit tx: %w", err)
+	}
+	return nil
+}
+
+const householdColumns = `h.id, h.owner_id, h.name, m.role, h.created_at, h.updated_at`
+
+func scanHousehold(row rowScanner) (Household, error) {
+	var h Household
+	err := row.Scan(&h.ID, &h.OwnerID, &h.Name, &h.Role, &h.CreatedAt, &h.UpdatedAt)
+	return h, err
+}
+
+// List returns the households userID is a member of.
+func (r *SQLRepository) List(ctx context.Context, userID uuid.UUID) ([]Household, error) {
+	query := fmt.Sprintf(`SELECT %s FROM finance.households h
+		JOIN finance.household_members m ON m.household_id = h.id AND m.user_id = $1
+		ORDER BY h.name ASC, h.id ASC`, householdColumns)
+	rows, err := r.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("select households: %w", err)
+	}
+	defer rows.Close()
+
+	out := []Household{}
+	for rows.Next() {
+		h, err := scanHousehold(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, h)
+	}
+	return out, rows.Err()
+}
+
+func (r *SQLRepository) Get(ctx context.Context, userID, id uuid.UUID) (*Household, error) {
+	query := fmt.Sprintf(`SELECT %s FROM finance.households h
+		JOIN finance.household_members m ON m.household_id = h.id AND m.user_id = $1
+		WHERE h.id = $2`, householdColumns)
+	h, err := scanHousehold(r.db.QueryRowContext(ctx, query, userID, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrHouseholdNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("select household: %w", err)
+	}
+	return &h, nil
+}
+
+func (r *SQLRepository) Rename(ctx context.Context, ownerID, id uuid.UUID, name string) error {
+	res, err := r.db.ExecContext(ctx, `UPDATE finance.households SET name = $3, updated_at = NOW()
+		WHERE id = $1 AND owner_id = $2`, id, ownerID, name)
+	if err != nil {
+		return fmt.Errorf("update household: %w", err)
+	}
+	return sqlutil.ExpectAffected(res, ErrHouseholdNotFound)
+}
+
+// Delete removes a household with its members, invites and budgets; its wallets go back
+// to being private wallets of the owner.
+func (r *SQLRepository) Delete(ctx context.Context, ownerID, id uuid.UUID) error {
+	res, err := r.db.ExecContext(ctx, `DELETE FROM finance.households WHERE id = $1 AND owner_id = $2`, id, ownerID)
+	if err != nil {
+		return fmt.Errorf("delete household: %w", err)
+	}
+	return sqlutil.ExpectAffected(res, ErrHouseholdNotFound)
+}
+
+func (r *SQLRepository) queryMembers(ctx context.Context, query string, args ...any) ([]Member, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("select members: %w", err)
+	}
+	defer rows.Close()
+
+	out := []Member{}
+	for rows.Next() {
+		var m Member
+		if err := rows.Scan(&m.UserID, &m.Email, &m.Role, &m.CreatedAt); err != nil {
+			return nil, err
+		}
+		out = append(out, m)
+	}
+	return out, rows.Err()
+}
+
+// Members returns the members of a household userID belongs to, the owner first.
+func (r *SQLRepository) Members(ctx context.Context, userID, id uuid.UUID) ([]Member, error) {
+	return r.queryMembers(ctx, `SELECT m.user_id, COALESCE(u.email, ''), m.role, m.created_at
+		FROM finance.household_members m
+		LEFT JOIN identity.users u ON u.id = m.user_id
+		WHERE m.household_id = $1 AND finance.household_role($1, $2) IS NOT NULL
+		ORDER BY CASE m.role WHEN 'owner' THEN 0 WHEN 'editor' THEN 1 ELSE 2 END, u.email ASC`, id, userID)
+}
+
+// SetMemberRole changes the role of a member other than the owner.
+func (r *SQLRepository) SetMemberRole(ctx context.Context, ownerID, id, memberID uuid.UUID, role string) error {
+	res, err := r.db.ExecContext(ctx, `UPDATE finance.household_members SET role = $4
+		WHERE household_id = $1 AND user_id = $3 AND role <> 'owner' AND finance.household_role($1, $2) = 'owner'`,
+		id, ownerID, memberID, role)
+	if err != nil {
+		return fmt.Errorf("update member: %w", err)
+	}
+	return sqlutil.ExpectAffected(res, ErrMemberNotFound)
+}
+
+// RemoveMember removes a member other than the owner; the owner removes anyone, other
+// members only themselves.
+func (r *SQLRepository) RemoveMember(ctx context.Context, actorID, id, memberID uuid.UUID) error {
+	res, err := r.db.ExecContext(ctx, `DELETE FROM finance.household_members
+		WHERE household_id = $1 AND user_id = $3 AND role <> 'owner'
+			AND ($3 = $2 OR finance.household_role($1, $2) = 'owner')`, id, actorID, memberID)
+	if err != nil {
+		return fmt.Errorf("delete member: %w", err)
+	}
+	return sqlutil.ExpectAffected(res, ErrMemberNotFound)
+}
+
+// AttachWallet moves a live wallet of the owner into the household.
+func (r *SQLRepository) AttachWallet(ctx context.Context, ownerID, id, walletID uuid.UUID) error {
+	res, err := r.db.ExecContext(ctx, `UPDATE finance.wallets w SET household_id = $1
+		FROM finance.households h
+		WHERE h.id = $1 AND h.owner_id = $2 AND w.id = $3 AND w.user_id = $2 AND w.deleted_at IS NULL`, id, ownerID, walletID)
+	if err != nil {
+		return fmt.Errorf("attach wallet: %w", err)
+	}
+	return sqlutil.ExpectAffected(res, ErrWalletNotFound)
+}
+
+func (r *SQLRepository) DetachWallet(ctx context.Context, ownerID, id, walletID uuid.UUID) error {
+	res, err := r.db.ExecContext(ctx, `UPDATE finance.wallets SET household_id = NULL
+		WHERE id = $3 AND household_id = $1 AND user_id = $2`, id, ownerID, walletID)
+	if err != nil {
+		return fmt.Errorf("detach wallet: %w", err)
+	}
+	return sqlutil.ExpectAffected(res, ErrWalletNotFound)
+}
+
+// sharedWalletColumns selects a wallet with the role of user $1 on it.
+const sharedWalletColumns = `w.id, w.user_id, w.type, w.name, w.balance::TEXT, w.currency, w.created_at,
+	finance.wallet_role(w.id, $1), w.household_id`
+
+func (r *SQLRepository) queryWallets(ctx context.Context, query string, args ...any) ([]SharedWallet, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("select wallets: %w", err)
+	}
+	defer rows.Close()
+
+	out := []SharedWallet{}
+	for rows.Next() {
+		var w SharedWallet
+		var householdID uuid.NullUUID
+		if err := rows.Scan(&w.ID, &w.UserID, &w.Type, &w.Name, &w.Balance, &w.Currency, &w.CreatedAt, &w.Role, &householdID); err != nil {
+			return nil, err
+		}
+		if householdID.Valid {
+			id := householdID.UUID
+			w.HouseholdID = &id
+		}
+		out = append(out, w)
+	}
+	return out, rows.Err()
+}
+
+// HouseholdWallets returns the live wallets of a household userID belongs to.
+func (r *SQLRepository) HouseholdWallets(ctx context.Context, userID, id uuid.UUID) ([]SharedWallet, error) {
+	query := fmt.Sprintf(`SELECT %s FROM finance.wallets w
+		WHERE w.household_id = $2 AND w.deleted_at IS NULL AND finance.household_role($2, $1) IS NOT NULL
+		ORDER BY w.name ASC, w.id ASC`, sharedWalletColumns)
+	return r.queryWallets(ctx, query, userID, id)
+}
+
+// SharedWallets returns the live wallets of other users userID can access.
+func (r *SQLRepository) SharedWallets(ctx context.Context, userID uuid.UUID) ([]SharedWallet, error) {
+	query := fmt.Sprintf(`SELECT %s FROM finance.wallets w
+		WHERE w.user_id <> $1 AND w.deleted_at IS NULL AND (
+			EXISTS (SELECT 1 FROM finance.wallet_members s WHERE s.wallet_id = w.id AND s.user_id = $1)
+			OR EXISTS (SELECT 1 FROM finance.household_members m WHERE m.household_id = w.household_id AND m.user_id = $1))
+		ORDER BY w.name ASC, w.id ASC`, sharedWalletColumns)
+	return r.queryWallets(ctx, query, userID)
+}
+
+// WalletAccess returns the owner of a live wallet and the role of userID on it.
+func (r *SQLRepository) WalletAccess(ctx context.Context, userID, walletID uuid.UUID) (uuid.UUID, string, error) {
+	var ownerID uuid.UUID
+	var role sql.NullString
+	err := r.db.QueryRowContext(ctx, `SELECT w.user_id, finance.wallet_role(w.id, $2)
+		FROM finance.wallets w WHERE w.id = $1 AND w.deleted_at IS NULL`, walletID, userID).Scan(&ownerID, &role)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && !role.Valid) {
+		return uuid.Nil, "", ErrWalletNotFound
+	}
+	if err != nil {
+		return uuid.Nil, "", fmt.Errorf("select wallet role: %w", err)
+	}
+	return ownerID, role.String, nil
+}
+
+// WalletMembers returns the users a wallet was shared with directly.
+func (r *SQLRepository) WalletMembers(ctx context.Context, userID, walletID uuid.UUID) ([]Member, error) {
+	return r.queryMembers(ctx, `SELECT s.user_id, COALESCE(u.email, ''), s.role, s.created_at
+		FROM finance.wallet_members s
+		LEFT JOIN identity.users u ON u.id = s.user_id
+		WHERE s.wallet_id = $1 AND finance.wallet_role($1, $2) IS NOT NULL
+		ORDER BY CASE s.role WHEN 'editor' THEN 0 ELSE 1 END, u.email ASC`, walletID, userID)
+}
+
+func (r *SQLRepository) SetWalletMemberRole(ctx context.Context, ownerID, walletID, memberID uuid.UUID, role string) error {
+	res, err := r.db.ExecContext(ctx, `UPDATE finance.wallet_members s SET role = $4
+		FROM finance.wallets w
+		WHERE s.wallet_id = $1 AND s.user_id = $3 AND w.id = s.wallet_id AND w.user_id = $2`, walletID, ownerID, memberID, role)
+	if err != nil {
+		return fmt.Errorf("update wallet member: %w", err)
+	}
+	return sqlutil.ExpectAffected(res, ErrMemberNotFound)
+}
+
+// RemoveWalletMember stops sharing a wallet with memberID; the owner removes anyone,
+// other members only themselves.
+func (r *SQLRepository) RemoveWalletMember(ctx context.Context, actorID, walletID, memberID uuid.UUID) error {
+	res, err := r.db.ExecContext(ctx, `DELETE FROM finance.wallet_members s USING finance.wallets w
+		WHERE s.wallet_id = $1 AND s.user_id = $3 AND w.id = s.wallet_id AND ($3 = $2 OR w.user_id = $2)`,
+		walletID, actorID, memberID)
+	if err != nil {
+		return fmt.Errorf("delete wallet member: %w", err)
+	}
+	return sqlutil.ExpectAffected(res, ErrMemberNotFound)
+}
+
+// UserIDByEmail looks up a registered user; the bool is false when there is none.
+func (r *SQLRepository) UserIDByEmail(ctx context.Context, email string) (uuid.UUID, bool, error) {
+	var id uuid.UUID
+	err := r.db.QueryRowContext(ctx, `SELECT id FROM identity.users WHERE LOWER(email) = LOWER($1)`, email).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return uuid.Nil, false, nil
+	}
+	if err != nil {
+		return uuid.Nil, false, fmt.Errorf("select user: %w", err)
+	}
+	return id, true, nil
+}
+
+// CreateInvite stores inv if its inviter owns the household or wallet, and fills in the
+// target name.
+func (r *SQLRepository) CreateInvite(ctx context.Context, inv *Invite) error {
+	err := r.db.QueryRowContext(ctx, `INSERT INTO finance.share_invites (id, household_id, wallet_id, email, role, invited_by, status, created_at)
+		SELECT $1::UUID, $2::UUID, $3::UUID, $4::TEXT, $5::TEXT, $6::UUID, 'pending', $7::TIMESTAMPTZ
+		WHERE ($2::UUID IS NULL OR EXISTS (SELECT 1 FROM finance.households h WHERE h.id = $2 AND h.owner_id = $6))
+			AND ($3::UUID IS NULL OR EXISTS (SELECT 1 FROM finance.wallets w WHERE w.id = $3 AND w.user_id = $6 AND w.deleted_at IS NULL))
+		RETURNING COALESCE((SELECT name FROM finance.households WHERE id = $2), (SELECT name FROM finance.wallets WHERE id = $3))`,
+		inv.ID, inv.HouseholdID, inv.WalletID, inv.Email, inv.Role, inv.InvitedBy, inv.CreatedAt).Scan(&inv.TargetName)
+	if errors.Is(err, sql.ErrNoRows) {
+		if inv.HouseholdID != nil {
+			return ErrHouseholdNotFound
+		}
+		return ErrWalletNotFound
+	}
+	if isUniqueViolation(err, "idx_share_invites_pending") {
+		return ErrInviteExists
+	}
+	if err != nil {
+		return fmt.Errorf("insert invite: %w", err)
+	}
+	return nil
+}
+
+const inviteColumns = `i.id, i.household_id, i.wallet_id, COALESCE(h.name, w.name, ''), i.email, i.role, i.invited_by, i.status,
+	i.created_at, i.responded_at`
+
+const inviteJoins = `LEFT JOIN finance.households h ON h.id = i.household_id
+	LEFT JOIN finance.wallets w ON w.id = i.wallet_id`
+
+func scanInvite(row rowScanner) (Invite, error) {
+	var inv Invite
+	var householdID, walletID uuid.NullUUID
+	var respondedAt sql.NullTime
+	if err := row.Scan(&inv.ID, &householdID, &walletID, &inv.TargetName, &inv.Email, &inv.Role, &inv.InvitedBy, &inv.Status,
+		&inv.CreatedAt, &respondedAt); err != nil {
+		return inv, err
+	}
+	if householdID.Valid {
+		id := householdID.UUID
+		inv.HouseholdID = &id
+	}
+	if walletID.Valid {
+		id := walletID.UUID
+		inv.WalletID = &id
+	}
+	if respondedAt.Valid {
+		at := respondedAt.Time
+		inv.RespondedAt = &at
+	}
+	return inv, nil
+}
+
+func (r *SQLRepository) queryInvites(ctx context.Context, query string, args ...any) ([]Invite, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("select invites: %w", err)
+	}
+	defer rows.Close()
+
+	out := []Invite{}
+	for rows.Next() {
+		inv, err := scanInvite(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, inv)
+	}
+	return out, rows.Err()
+}
+
+// ReceivedInvites returns the pending invites to the email of userID, newest first.
+func (r *SQLRepository) ReceivedInvites(ctx context.Context, userID uuid.UUID) ([]Invite, error) {
+	query := fmt.Sprintf(`SELECT %s FROM finance.share_invites i %s
+		WHERE i.status = 'pending' AND LOWER(i.email) = (SELECT LOWER(email) FROM identity.users WHERE id = $1)
+		ORDER BY i.created_at DESC`, inviteColumns, inviteJoins)
+	return r.queryInvites(ctx, query, userID)
+}
+
+// SentInvites returns the pending invites to the household or wallet of ownerID.
+func (r *SQLRepository) SentInvites(ctx context.Context, ownerID uuid.UUID, householdID, walletID *uuid.UUID) ([]Invite, error) {
+	query := fmt.Sprintf(`SELECT %s FROM finance.share_invites i %s
+		WHERE i.status = 'pending' AND (i.household_id = $2 OR i.wallet_id = $3)
+			AND (h.owner_id = $1 OR w.user_id = $1)
+		ORDER BY i.created_at DESC`, inviteColumns, inviteJoins)
+	return r.queryInvites(ctx, query, ownerID, householdID, walletID)
+}
+
+// RespondInvite accepts or declines a pending invite to the email of userID. Accepting
+// makes userID a member; an existing membership of a wallet takes the invited role.
+func (r *SQLRepository) RespondInvite(ctx context.Context, userID, id uuid.UUID, status string) (inv *Invite, err error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("begin tx: %w", err)
+	}
+
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	res, err := tx.ExecContext(ctx, `UPDATE finance.share_invites SET status = $3, responded_at = NOW()
+		WHERE id = $1 AND status = 'pending' AND LOWER(email) = (SELECT LOWER(email) FROM identity.users WHERE id = $2)`,
+		id, userID, status)
+	if err != nil {
+		return nil, fmt.Errorf("update invite: %w", err)
+	}
+	if err = sqlutil.ExpectAffected(res, ErrInviteNotFound); err != nil {
+		return nil, err
+	}
+	query := fmt.Sprintf(`SELECT %s FROM finance.share_invites i %s WHERE i.id = $1`, inviteColumns, inviteJoins)
+	got, err := scanInvite(tx.QueryRowContext(ctx, query, id))
+	if err != nil {
+		return nil, fmt.Errorf("select invite: %w", err)
+	}
+
+	if status == InviteAccepted {
+		if got.HouseholdID != nil {
+			_, err = tx.ExecContext(ctx, `INSERT INTO finance.household_members (household_id, user_id, role, created_at)
+				VALUES ($1,$2,$3,NOW()) ON CONFLICT (household_id, user_id) DO NOTHING`, got.HouseholdID, userID, got.Role)
+		} else {
+			_, err = tx.ExecContext(ctx, `INSERT INTO finance.wallet_members (wallet_id, user_id, role, created_at)
+				SELECT $1::UUID, $2::UUID, $3::TEXT, NOW() FROM finance.wallets WHERE id = $1 AND user_id <> $2
+				ON CONFLICT (wallet_id, user_id) DO UPDATE SET role = EXCLUDED.role`, got.WalletID, userID, got.Role)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("insert member: %w", err)
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("commit tx: %w", err)
+	}
+	return &got, nil
+}
+
+// RevokeInvite withdraws a pending invite sent by userID.
+func (r *SQLRepository) RevokeInvite(ctx context.Context, userID, id uuid.UUID) error {
+	res, err := r.db.ExecContext(ctx, `UPDATE finance.share_invites SET status = 'revoked', responded_at = NOW()
+		WHERE id = $1 AND invited_by = $2 AND status = 'pending'`, id, userID)
+	if err != nil {
+		return fmt.Errorf("revoke invite: %w", err)
+	}
+	return sqlutil.ExpectAffected(res, ErrInviteNotFound)
+}
+
+// isUniqueViolation reports whether err is a PostgreSQL unique violation on constraint.
+func isUniqueViolation(err error, constraint string) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23505" && pgErr.ConstraintName == constraint
+}
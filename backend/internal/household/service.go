@@ -0,0 +1,404 @@
+package household
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/mail"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/Jomesi149/Implementasi-LASTI/backend/internal/notification"
+	"github.com/Jomesi149/Implementasi-LASTI/backend/internal/transaction"
+)
+
+var (
+	// ErrHouseholdNotFound is returned when the household does not exist or the user is
+	// not a member of it.
+	ErrHouseholdNotFound = errors.New("household_not_found")
+	// ErrWalletNotFound is returned when the wallet does not exist or is not shared with
+	// the user.
+	ErrWalletNotFound = errors.New("wallet_not_found")
+	// ErrMemberNotFound is returned when the user is not a member that can be changed.
+	ErrMemberNotFound = errors.New("member_not_found")
+	// ErrInviteNotFound is returned when there is no pending invite for the user.
+	ErrInviteNotFound = errors.New("invite_not_found")
+	// ErrInvalidHousehold indicates a bad name, email or role.
+	ErrInvalidHousehold = errors.New("invalid_household")
+	// ErrInviteExists is returned when the email already has a pending invite.
+	ErrInviteExists = errors.New("invite_exists")
+	// ErrAlreadyMember is returned when the invited user can already access the target.
+	ErrAlreadyMember = errors.New("already_member")
+	// ErrForbidden is returned when the user's role does not allow the change.
+	ErrForbidden = errors.New("forbidden")
+)
+
+// Member roles. Viewers only read; editors also record transactions and set budgets;
+// the owner manages members and wallets.
+const (
+	RoleOwner  = "owner"
+	RoleEditor = "editor"
+	RoleViewer = "viewer"
+)
+
+// Invite statuses.
+const (
+	InvitePending  = "pending"
+	InviteAccepted = "accepted"
+	InviteDeclined = "declined"
+)
+
+const (
+	// SourceHousehold marks transaction changes made by a member of a shared wallet.
+	SourceHousehold = "household"
+	// NotificationInvite is the kind of the notification sent to an invited user.
+	NotificationInvite = "share_invite"
+	maxHouseholdName   = 100
+)
+
+type ServiceDeps struct {
+	Repo          Repository
+	Transactions  *transaction.Service
+	Notifications *notification.Service
+}
+
+type Service struct {
+	repo          Repository
+	transactions  *transaction.Service
+	notifications *notification.Service
+	now           func() time.Time
+}
+
+func NewService(deps ServiceDeps) *Service {
+	return &Service{repo: deps.Repo, transactions: deps.Transactions, notifications: deps.Notifications, now: time.Now}
+}
+
+// canEdit reports whether role may record transactions and set budgets.
+func canEdit(role string) bool {
+	return role == RoleOwner || role == RoleEditor
+}
+
+func validateName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || len(name) > maxHouseholdName {
+		return "", fmt.Errorf("%w: name must be 1-%d characters", ErrInvalidHousehold, maxHouseholdName)
+	}
+	return name, nil
+}
+
+// validateRole accepts the roles that can be given to invited users.
+func validateRole(role string) error {
+	if role != RoleEditor && role != RoleViewer {
+		return fmt.Errorf("%w: role must be editor or viewer", ErrInvalidHousehold)
+	}
+	return nil
+}
+
+func (s *Service) CreateHousehold(ctx context.Context, userID uuid.UUID, req HouseholdRequest) (*Household, error) {
+	name, err := validateName(req.Name)
+	if err != nil {
+		return nil, err
+	}
+	now := s.now()
+	h := Household{ID: uuid.New(), OwnerID: userID, Name: name, Role: RoleOwner, CreatedAt: now, UpdatedAt: now}
+	if err := s.repo.Create(ctx, h); err != nil {
+		return nil, err
+	}
+	return &h, nil
+}
+
+func (s *Service) ListHouseholds(ctx context.Context, userID uuid.UUID) ([]Household, error) {
+	return s.repo.List(ctx, userID)
+}
+
+func (s *Service) GetHousehold(ctx context.Context, userID, id uuid.UUID) (*Household, error) {
+	return s.repo.Get(ctx, userID, id)
+}
+
+// ownHousehold returns the household if userID owns it.
+func (s *Service) ownHousehold(ctx context.Context, userID, id uuid.UUID) (*Household, error) {
+	h, err := s.repo.Get(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+	if h.Role != RoleOwner {
+		return nil, fmt.Errorf("%w: only the owner can do this", ErrForbidden)
+	}
+	return h, nil
+}
+
+func (s *Service) RenameHousehold(ctx context.Context, userID, id uuid.UUID, req HouseholdRequest) (*Household, error) {
+	name, err := validateName(req.Name)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.ownHousehold(ctx, userID, id); err != nil {
+		return nil, err
+	}
+	if err := s.repo.Rename(ctx, userID, id, name); err != nil {
+		return nil, err
+	}
+	return s.repo.Get(ctx, userID, id)
+}
+
+func (s *Service) DeleteHousehold(ctx context.Context, userID, id uuid.UUID) error {
+	if _, err := s.ownHousehold(ctx, userID, id); err != nil {
+		return err
+	}
+	return s.repo.Delete(ctx, userID, id)
+}
+
+func (s *Service) ListMembers(ctx context.Context, userID, id uuid.UUID) ([]Member, error) {
+	if _, err := s.repo.Get(ctx, userID, id); err != nil {
+		return nil, err
+	}
+	return s.repo.Members(ctx, userID, id)
+}
+
+func (s *Service) SetMemberRole(ctx context.Context, userID, id, memberID uuid.UUID, req RoleRequest) error {
+	if err := validateRole(req.Role); err != nil {
+		return err
+	}
+	if _, err := s.ownHousehold(ctx, userID, id); err != nil {
+		return err
+	}
+	return s.repo.SetMemberRole(ctx, userID, id, memberID, req.Role)
+}
+
+// RemoveMember lets the owner remove a member, or a member leave. The owner cannot leave;
+// they delete the household instead.
+func (s *Service) RemoveMember(ctx context.Context, userID, id, memberID uuid.UUID) error {
+	h, err := s.repo.Get(ctx, userID, id)
+	if err != nil {
+		return err
+	}
+	if memberID == h.OwnerID {
+		return fmt.Errorf("%w: the owner cannot leave the household", ErrInvalidHousehold)
+	}
+	if memberID != userID && h.Role != RoleOwner {
+		return fmt.Errorf("%w: only the owner can remove other members", ErrForbidden)
+	}
+	return s.repo.RemoveMember(ctx, userID, id, memberID)
+}
+
+// AttachWallet adds a wallet of the owner to the household, sharing it with all members.
+func (s *Service) AttachWallet(ctx context.Context, userID, id, walletID uuid.UUID) ([]SharedWallet, error) {
+	if _, err := s.ownHousehold(ctx, userID, id); err != nil {
+		return nil, err
+	}
+	if err := s.repo.AttachWallet(ctx, userID, id, walletID); err != nil {
+		return nil, err
+	}
+	return s.repo.HouseholdWallets(ctx, userID, id)
+}
+
+func (s *Service) DetachWallet(ctx context.Context, userID, id, walletID uuid.UUID) error {
+	if _, err := s.ownHousehold(ctx, userID, id); err != nil {
+		return err
+	}
+	return s.repo.DetachWallet(ctx, userID, id, walletID)
+}
+
+func (s *Service) ListHouseholdWallets(ctx context.Context, userID, id uuid.UUID) ([]SharedWallet, error) {
+	if _, err := s.repo.Get(ctx, userID, id); err != nil {
+		return nil, err
+	}
+	return s.repo.HouseholdWallets(ctx, userID, id)
+}
+
+// InviteToHousehold invites an email to the household of userID.
+func (s *Service) InviteToHousehold(ctx context.Context, userID, id uuid.UUID, req InviteRequest) (*Invite, error) {
+	if _, err := s.ownHousehold(ctx, userID, id); err != nil {
+		return nil, err
+	}
+	return s.invite(ctx, userID, &id, nil, req)
+}
+
+// InviteToWallet invites an email to a single wallet of userID.
+func (s *Service) InviteToWallet(ctx context.Context, userID, walletID uuid.UUID, req InviteRequest) (*Invite, error) {
+	if err := s.ownWallet(ctx, userID, walletID); err != nil {
+		return nil, err
+	}
+	return s.invite(ctx, userID, nil, &walletID, req)
+}
+
+// invite stores an invite to the household or wallet and notifies the invitee if they
+// already have an account.
+func (s *Service) invite(ctx context.Context, userID uuid.UUID, householdID, walletID *uuid.UUID, req InviteRequest) (*Invite, error) {
+	addr, err := mail.ParseAddress(strings.TrimSpace(req.Email))
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid email", ErrInvalidHousehold)
+	}
+	if err := validateRole(req.Role); err != nil {
+		return nil, err
+	}
+	email := strings.ToLower(addr.Address)
+
+	inviteeID, registered, err := s.repo.UserIDByEmail(ctx, email)
+	if err != nil {
+		return nil, err
+	}
+	if registered {
+		var accessErr error
+		if householdID != nil {
+			_, accessErr = s.repo.Get(ctx, inviteeID, *householdID)
+		} else {
+			_, _, accessErr = s.repo.WalletAccess(ctx, inviteeID, *walletID)
+		}
+		if accessErr == nil {
+			return nil, ErrAlreadyMember
+		}
+		if !errors.Is(accessErr, ErrHouseholdNotFound) && !errors.Is(accessErr, ErrWalletNotFound) {
+			return nil, accessErr
+		}
+	}
+
+	inv := Invite{ID: uuid.New(), HouseholdID: householdID, WalletID: walletID, Email: email, Role: req.Role,
+		InvitedBy: userID, Status: InvitePending, CreatedAt: s.now()}
+	if err := s.repo.CreateInvite(ctx, &inv); err != nil {
+		return nil, err
+	}
+
+	if registered && s.notifications != nil {
+		n := notification.Notification{UserID: inviteeID, Kind: NotificationInvite,
+			Title:       fmt.Sprintf("Invitation to %s", inv.TargetName),
+			Body:        fmt.Sprintf("You were invited to %s as %s.", inv.TargetName, inv.Role),
+			ReferenceID: &inv.ID, DedupeKey: "share_invite:" + inv.ID.String()}
+		// Undangan tetap berlaku walau notifikasinya gagal; invitee juga melihatnya di GET /invites
+		if _, err := s.notifications.Notify(ctx, n); err != nil {
+			log.Printf("[HOUSEHOLD] notify invite %s: %v", inv.ID, err)
+		}
+	}
+	return &inv, nil
+}
+
+func (s *Service) ListHouseholdInvites(ctx context.Context, userID, id uuid.UUID) ([]Invite, error) {
+	if _, err := s.ownHousehold(ctx, userID, id); err != nil {
+		return nil, err
+	}
+	return s.repo.SentInvites(ctx, userID, &id, nil)
+}
+
+func (s *Service) ListWalletInvites(ctx context.Context, userID, walletID uuid.UUID) ([]Invite, error) {
+	if err := s.ownWallet(ctx, userID, walletID); err != nil {
+		return nil, err
+	}
+	return s.repo.SentInvites(ctx, userID, nil, &walletID)
+}
+
+// ListInvites returns the pending invites to the email of userID.
+func (s *Service) ListInvites(ctx context.Context, userID uuid.UUID) ([]Invite, error) {
+	return s.repo.ReceivedInvites(ctx, userID)
+}
+
+func (s *Service) AcceptInvite(ctx context.Context, userID, id uuid.UUID) (*Invite, error) {
+	return s.repo.RespondInvite(ctx, userID, id, InviteAccepted)
+}
+
+func (s *Service) DeclineInvite(ctx context.Context, userID, id uuid.UUID) (*Invite, error) {
+	return s.repo.RespondInvite(ctx, userID, id, InviteDeclined)
+}
+
+// RevokeInvite withdraws a pending invite sent by userID.
+func (s *Service) RevokeInvite(ctx context.Context, userID, id uuid.UUID) error {
+	return s.repo.RevokeInvite(ctx, userID, id)
+}
+
+// ListSharedWallets returns the wallets of other users shared with userID.
+func (s *Service) ListSharedWallets(ctx context.Context, userID uuid.UUID) ([]SharedWallet, error) {
+	return s.repo.SharedWallets(ctx, userID)
+}
+
+// walletAccess returns the owner of walletID and the role of userID on it.
+func (s *Service) walletAccess(ctx context.Context, userID, walletID uuid.UUID, write bool) (uuid.UUID, error) {
+	ownerID, role, err := s.repo.WalletAccess(ctx, userID, walletID)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	if write && !canEdit(role) {
+		return uuid.Nil, fmt.Errorf("%w: viewers cannot change the wallet", ErrForbidden)
+	}
+	return ownerID, nil
+}
+
+// ownWallet checks that userID owns walletID.
+func (s *Service) ownWallet(ctx context.Context, userID, walletID uuid.UUID) error {
+	ownerID, err := s.walletAccess(ctx, userID, walletID, false)
+	if err != nil {
+		return err
+	}
+	if ownerID != userID {
+		return fmt.Errorf("%w: only the owner can do this", ErrForbidden)
+	}
+	return nil
+}
+
+func (s *Service) ListWalletMembers(ctx context.Context, userID, walletID uuid.UUID) ([]Member, error) {
+	if _, err := s.walletAccess(ctx, userID, walletID, false); err != nil {
+		return nil, err
+	}
+	return s.repo.WalletMembers(ctx, userID, walletID)
+}
+
+func (s *Service) SetWalletMemberRole(ctx context.Context, userID, walletID, memberID uuid.UUID, req RoleRequest) error {
+	if err := validateRole(req.Role); err != nil {
+		return err
+	}
+	if err := s.ownWallet(ctx, userID, walletID); err != nil {
+		return err
+	}
+	return s.repo.SetWalletMemberRole(ctx, userID, walletID, memberID, req.Role)
+}
+
+// RemoveWalletMember lets the owner stop sharing a wallet with a member, or a member
+// leave it.
+func (s *Service) RemoveWalletMember(ctx context.Context, userID, walletID, memberID uuid.UUID) error {
+	ownerID, err := s.walletAccess(ctx, userID, walletID, false)
+	if err != nil {
+		return err
+	}
+	if memberID != userID && ownerID != userID {
+		return fmt.Errorf("%w: only the owner can remove other members", ErrForbidden)
+	}
+	return s.repo.RemoveWalletMember(ctx, userID, walletID, memberID)
+}
+
+// memberChange marks transaction changes as made by userID in a shared wallet.
+func memberChange(ctx context.Context, userID, walletID uuid.UUID) context.Context {
+	return transaction.WithChange(ctx, transaction.Change{Source: SourceHousehold, ActorID: &userID, ReferenceID: &walletID})
+}
+
+// ListWalletTransactions lists the transactions of a wallet userID can access.
+func (s *Service) ListWalletTransactions(ctx context.Context, userID, walletID uuid.UUID, f transaction.TransactionFilter) (*transaction.TransactionPage, error) {
+	ownerID, err := s.walletAccess(ctx, userID, walletID, false)
+	if err != nil {
+		return nil, err
+	}
+	f.WalletIDs = []uuid.UUID{walletID}
+	return s.transactions.ListTransactions(ctx, ownerID, f)
+}
+
+// CreateWalletTransaction records a transaction in a wallet userID may edit. It belongs
+// to the wallet owner and records userID as its creator.
+func (s *Service) CreateWalletTransaction(ctx context.Context, userID, walletID uuid.UUID, in transaction.NewTransaction) (*transaction.Transaction, error) {
+	ownerID, err := s.walletAccess(ctx, userID, walletID, true)
+	if err != nil {
+		return nil, err
+	}
+	in.UserID = ownerID
+	in.WalletID = walletID
+	return s.transactions.CreateTransaction(memberChange(ctx, userID, walletID), in)
+}
+
+// DeleteWalletTransaction moves a transaction of a wallet userID may edit to the owner's
+// trash. The transaction repository checks userID's role on every leg it removes.
+func (s *Service) DeleteWalletTransaction(ctx context.Context, userID, walletID, transactionID uuid.UUID) error {
+	ownerID, err := s.walletAccess(ctx, userID, walletID, true)
+	if err != nil {
+		return err
+	}
+	return s.transactions.DeleteSharedTransaction(memberChange(ctx, userID, walletID), ownerID, userID, walletID, transactionID)
+}
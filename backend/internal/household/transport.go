@@ -0,0 +1,534 @@
+package household
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"github.com/Jomesi149/Implementasi-LASTI/backend/internal/transaction"
+	"github.com/Jomesi149/Implementasi-LASTI/backend/pkg/response"
+)
+
+type HTTPHandler struct {
+	service *Service
+}
+
+func NewHTTPHandler(s *Service) *HTTPHandler {
+	return &HTTPHandler{service: s}
+}
+
+func (h *HTTPHandler) RegisterRoutes(r chi.Router) {
+	r.Route("/households", func(r chi.Router) {
+		r.Post("/", h.handleCreateHousehold)
+		r.Get("/", h.handleListHouseholds)
+		r.Get("/{id}", h.handleGetHousehold)
+		r.Put("/{id}", h.handleRenameHousehold)
+		r.Delete("/{id}", h.handleDeleteHousehold)
+		r.Get("/{id}/members", h.handleListMembers)
+		r.Put("/{id}/members/{userId}", h.handleSetMemberRole)
+		r.Delete("/{id}/members/{userId}", h.handleRemoveMember)
+		r.Get("/{id}/invites", h.handleListHouseholdInvites)
+		r.Post("/{id}/invites", h.handleInviteToHousehold)
+		r.Get("/{id}/wallets", h.handleListHouseholdWallets)
+		r.Post("/{id}/wallets", h.handleAttachWallet)
+		r.Delete("/{id}/wallets/{walletId}", h.handleDetachWallet)
+	})
+	r.Route("/invites", func(r chi.Router) {
+		r.Get("/", h.handleListInvites)
+		r.Post("/{id}/accept", h.handleAcceptInvite)
+		r.Post("/{id}/decline", h.handleDeclineInvite)
+		r.Delete("/{id}", h.handleRevokeInvite)
+	})
+	r.Route("/shared/wallets", func(r chi.Router) {
+		r.Get("/", h.handleListSharedWallets)
+		r.Get("/{id}/members", h.handleListWalletMembers)
+		r.Put("/{id}/members/{userId}", h.handleSetWalletMemberRole)
+		r.Delete("/{id}/members/{userId}", h.handleRemoveWalletMember)
+		r.Get("/{id}/invites", h.handleListWalletInvites)
+		r.Post("/{id}/invites", h.handleInviteToWallet)
+		r.Get("/{id}/transactions", h.handleListWalletTransactions)
+		r.Post("/{id}/transactions", h.handleCreateWalletTransaction)
+		r.Delete("/{id}/transactions/{transactionId}", h.handleDeleteWalletTransaction)
+	})
+}
+
+func getUserIDFromHeader(r *http.Request) (uuid.UUID, error) {
+	s := r.Header.Get("X-User-ID")
+	return uuid.Parse(s)
+}
+
+// errorStatus maps service sentinel errors, and those of the transaction service it
+// delegates to, onto HTTP status codes.
+func errorStatus(err error) int {
+	switch {
+	case errors.Is(err, ErrHouseholdNotFound), errors.Is(err, ErrWalletNotFound), errors.Is(err, ErrMemberNotFound),
+		errors.Is(err, ErrInviteNotFound), errors.Is(err, transaction.ErrWalletNotFound), errors.Is(err, transaction.ErrTransactionNotFound),
+		errors.Is(err, transaction.ErrCategoryNotFound), errors.Is(err, transaction.ErrPayeeNotFound), errors.Is(err, transaction.ErrTagNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, ErrInvalidHousehold), errors.Is(err, transaction.ErrInvalidTransaction), errors.Is(err, transaction.ErrInvalidFilter),
+		errors.Is(err, transaction.ErrInvalidCursor):
+		return http.StatusBadRequest
+	case errors.Is(err, ErrForbidden), errors.Is(err, transaction.ErrWalletForbidden):
+		return http.StatusForbidden
+	case errors.Is(err, ErrInviteExists), errors.Is(err, ErrAlreadyMember), errors.Is(err, transaction.ErrDuplicateTransaction):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// idParams reads the user id header and the {id} URL parameter; what names the id in the
+// error message.
+func idParams(w http.ResponseWriter, r *http.Request, what string) (uuid.UUID, uuid.UUID, bool) {
+	uid, err := getUserIDFromHeader(r)
+	if err != nil {
+		response.Error(w, http.StatusUnauthorized, "missing X-User-ID header")
+		return uuid.Nil, uuid.Nil, false
+	}
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "invalid "+what+" id")
+		return uuid.Nil, uuid.Nil, false
+	}
+	return uid, id, true
+}
+
+// subIDParams reads idParams plus the second URL parameter key.
+func subIDParams(w http.ResponseWriter, r *http.Request, what, key, subWhat string) (uuid.UUID, uuid.UUID, uuid.UUID, bool) {
+	uid, id, ok := idParams(w, r, what)
+	if !ok {
+		return uuid.Nil, uuid.Nil, uuid.Nil, false
+	}
+	sub, err := uuid.Parse(chi.URLParam(r, key))
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "invalid "+subWhat+" id")
+		return uuid.Nil, uuid.Nil, uuid.Nil, false
+	}
+	return uid, id, sub, true
+}
+
+func (h *HTTPHandler) handleCreateHousehold(w http.ResponseWriter, r *http.Request) {
+	uid, err := getUserIDFromHeader(r)
+	if err != nil {
+		response.Error(w, http.StatusUnauthorized, "missing X-User-ID header")
+		return
+	}
+	var req HouseholdRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.Error(w, http.StatusBadRequest, "invalid payload")
+		return
+	}
+	hh, err := h.service.CreateHousehold(r.Context(), uid, req)
+	if err != nil {
+		response.Error(w, errorStatus(err), err.Error())
+		return
+	}
+	response.JSON(w, http.StatusCreated, hh)
+}
+
+func (h *HTTPHandler) handleListHouseholds(w http.ResponseWriter, r *http.Request) {
+	uid, err := getUserIDFromHeader(r)
+	if err != nil {
+		response.Error(w, http.StatusUnauthorized, "missing X-User-ID header")
+		return
+	}
+	out, err := h.service.ListHouseholds(r.Context(), uid)
+	if err != nil {
+		response.Error(w, errorStatus(err), err.Error())
+		return
+	}
+	response.JSON(w, http.StatusOK, out)
+}
+
+func (h *HTTPHandler) handleGetHousehold(w http.ResponseWriter, r *http.Request) {
+	uid, id, ok := idParams(w, r, "household")
+	if !ok {
+		return
+	}
+	hh, err := h.service.GetHousehold(r.Context(), uid, id)
+	if err != nil {
+		response.Error(w, errorStatus(err), err.Error())
+		return
+	}
+	response.JSON(w, http.StatusOK, hh)
+}
+
+func (h *HTTPHandler) handleRenameHousehold(w http.ResponseWriter, r *http.Request) {
+	uid, id, ok := idParams(w, r, "household")
+	if !ok {
+		return
+	}
+	var req HouseholdRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.Error(w, http.StatusBadRequest, "invalid payload")
+		return
+	}
+	hh, err := h.service.RenameHousehold(r.Context(), uid, id, req)
+	if err != nil {
+		response.Error(w, errorStatus(err), err.Error())
+		return
+	}
+	response.JSON(w, http.StatusOK, hh)
+}
+
+func (h *HTTPHandler) handleDeleteHousehold(w http.ResponseWriter, r *http.Request) {
+	uid, id, ok := idParams(w, r, "household")
+	if !ok {
+		return
+	}
+	if err := h.service.DeleteHousehold(r.Context(), uid, id); err != nil {
+		response.Error(w, errorStatus(err), err.Error())
+		return
+	}
+	response.JSON(w, http.StatusOK, map[string]string{"status": "deleted"})
+}
+
+func (h *HTTPHandler) handleListMembers(w http.ResponseWriter, r *http.Request) {
+	uid, id, ok := idParams(w, r, "household")
+	if !ok {
+		return
+	}
+	out, err := h.service.ListMembers(r.Context(), uid, id)
+	if err != nil {
+		response.Error(w, errorStatus(err), err.Error())
+		return
+	}
+	response.JSON(w, http.StatusOK, out)
+}
+
+func (h *HTTPHandler) handleSetMemberRole(w http.ResponseWriter, r *http.Request) {
+	uid, id, memberID, ok := subIDParams(w, r, "household", "userId", "user")
+	if !ok {
+		return
+	}
+	var req RoleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.Error(w, http.StatusBadRequest, "invalid payload")
+		return
+	}
+	if err := h.service.SetMemberRole(r.Context(), uid, id, memberID, req); err != nil {
+		response.Error(w, errorStatus(err), err.Error())
+		return
+	}
+	response.JSON(w, http.StatusOK, map[string]string{"status": "updated"})
+}
+
+func (h *HTTPHandler) handleRemoveMember(w http.ResponseWriter, r *http.Request) {
+	uid, id, memberID, ok := subIDParams(w, r, "household", "userId", "user")
+	if !ok {
+		return
+	}
+	if err := h.service.RemoveMember(r.Context(), uid, id, memberID); err != nil {
+		response.Error(w, errorStatus(err), err.Error())
+		return
+	}
+	response.JSON(w, http.StatusOK, map[string]string{"status": "removed"})
+}
+
+func (h *HTTPHandler) handleListHouseholdInvites(w http.ResponseWriter, r *http.Request) {
+	uid, id, ok := idParams(w, r, "household")
+	if !ok {
+		return
+	}
+	out, err := h.service.ListHouseholdInvites(r.Context(), uid, id)
+	if err != nil {
+		response.Error(w, errorStatus(err), err.Error())
+		return
+	}
+	response.JSON(w, http.StatusOK, out)
+}
+
+func (h *HTTPHandler) handleInviteToHousehold(w http.ResponseWriter, r *http.Request) {
+	uid, id, ok := idParams(w, r, "household")
+	if !ok {
+		return
+	}
+	var req InviteRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.Error(w, http.StatusBadRequest, "invalid payload")
+		return
+	}
+	inv, err := h.service.InviteToHousehold(r.Context(), uid, id, req)
+	if err != nil {
+		response.Error(w, errorStatus(err), err.Error())
+		return
+	}
+	response.JSON(w, http.StatusCreated, inv)
+}
+
+func (h *HTTPHandler) handleListHouseholdWallets(w http.ResponseWriter, r *http.Request) {
+	uid, id, ok := idParams(w, r, "household")
+	if !ok {
+		return
+	}
+	out, err := h.service.ListHouseholdWallets(r.Context(), uid, id)
+	if err != nil {
+		response.Error(w, errorStatus(err), err.Error())
+		return
+	}
+	response.JSON(w, http.StatusOK, out)
+}
+
+func (h *HTTPHandler) handleAttachWallet(w http.ResponseWriter, r *http.Request) {
+	uid, id, ok := idParams(w, r, "household")
+	if !ok {
+		return
+	}
+	var req WalletRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.Error(w, http.StatusBadRequest, "invalid payload")
+		return
+	}
+	walletID, err := uuid.Parse(req.WalletID)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "invalid wallet id")
+		return
+	}
+	out, err := h.service.AttachWallet(r.Context(), uid, id, walletID)
+	if err != nil {
+		response.Error(w, errorStatus(err), err.Error())
+		return
+	}
+	response.JSON(w, http.StatusOK, out)
+}
+
+func (h *HTTPHandler) handleDetachWallet(w http.ResponseWriter, r *http.Request) {
+	uid, id, walletID, ok := subIDParams(w, r, "household", "walletId", "wallet")
+	if !ok {
+		return
+	}
+	if err := h.service.DetachWallet(r.Context(), uid, id, walletID); err != nil {
+		response.Error(w, errorStatus(err), err.Error())
+		return
+	}
+	response.JSON(w, http.StatusOK, map[string]string{"status": "detached"})
+}
+
+func (h *HTTPHandler) handleListInvites(w http.ResponseWriter, r *http.Request) {
+	uid, err := getUserIDFromHeader(r)
+	if err != nil {
+		response.Error(w, http.StatusUnauthorized, "missing X-User-ID header")
+		return
+	}
+	out, err := h.service.ListInvites(r.Context(), uid)
+	if err != nil {
+		response.Error(w, errorStatus(err), err.Error())
+		return
+	}
+	response.JSON(w, http.StatusOK, out)
+}
+
+func (h *HTTPHandler) handleAcceptInvite(w http.ResponseWriter, r *http.Request) {
+	uid, id, ok := idParams(w, r, "invite")
+	if !ok {
+		return
+	}
+	inv, err := h.service.AcceptInvite(r.Context(), uid, id)
+	if err != nil {
+		response.Error(w, errorStatus(err), err.Error())
+		return
+	}
+	response.JSON(w, http.StatusOK, inv)
+}
+
+func (h *HTTPHandler) handleDeclineInvite(w http.ResponseWriter, r *http.Request) {
+	uid, id, ok := idParams(w, r, "invite")
+	if !ok {
+		return
+	}
+	inv, err := h.service.DeclineInvite(r.Context(), uid, id)
+	if err != nil {
+		response.Error(w, errorStatus(err), err.Error())
+		return
+	}
+	response.JSON(w, http.StatusOK, inv)
+}
+
+func (h *HTTPHandler) handleRevokeInvite(w http.ResponseWriter, r *http.Request) {
+	uid, id, ok := idParams(w, r, "invite")
+	if !ok {
+		return
+	}
+	if err := h.service.RevokeInvite(r.Context(), uid, id); err != nil {
+		response.Error(w, errorStatus(err), err.Error())
+		return
+	}
+	response.JSON(w, http.StatusOK, map[string]string{"status": "revoked"})
+}
+
+func (h *HTTPHandler) handleListSharedWallets(w http.ResponseWriter, r *http.Request) {
+	uid, err := getUserIDFromHeader(r)
+	if err != nil {
+		response.Error(w, http.StatusUnauthorized, "missing X-User-ID header")
+		return
+	}
+	out, err := h.service.ListSharedWallets(r.Context(), uid)
+	if err != nil {
+		response.Error(w, errorStatus(err), err.Error())
+		return
+	}
+	response.JSON(w, http.StatusOK, out)
+}
+
+func (h *HTTPHandler) handleListWalletMembers(w http.ResponseWriter, r *http.Request) {
+	uid, id, ok := idParams(w, r, "wallet")
+	if !ok {
+		return
+	}
+	out, err := h.service.ListWalletMembers(r.Context(), uid, id)
+	if err != nil {
+		response.Error(w, errorStatus(err), err.Error())
+		return
+	}
+	response.JSON(w, http.StatusOK, out)
+}
+
+func (h *HTTPHandler) handleSetWalletMemberRole(w http.ResponseWriter, r *http.Request) {
+	uid, id, memberID, ok := subIDParams(w, r, "wallet", "userId", "user")
+	if !ok {
+		return
+	}
+	var req RoleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.Error(w, http.StatusBadRequest, "invalid payload")
+		return
+	}
+	if err := h.service.SetWalletMemberRole(r.Context(), uid, id, memberID, req); err != nil {
+		response.Error(w, errorStatus(err), err.Error())
+		return
+	}
+	response.JSON(w, http.StatusOK, map[string]string{"status": "updated"})
+}
+
+func (h *HTTPHandler) handleRemoveWalletMember(w http.ResponseWriter, r *http.Request) {
+	uid, id, memberID, ok := subIDParams(w, r, "wallet", "userId", "user")
+	if !ok {
+		return
+	}
+	if err := h.service.RemoveWalletMember(r.Context(), uid, id, memberID); err != nil {
+		response.Error(w, errorStatus(err), err.Error())
+		return
+	}
+	response.JSON(w, http.StatusOK, map[string]string{"status": "removed"})
+}
+
+func (h *HTTPHandler) handleListWalletInvites(w http.ResponseWriter, r *http.Request) {
+	uid, id, ok := idParams(w, r, "wallet")
+	if !ok {
+		return
+	}
+	out, err := h.service.ListWalletInvites(r.Context(), uid, id)
+	if err != nil {
+		response.Error(w, errorStatus(err), err.Error())
+		return
+	}
+	response.JSON(w, http.StatusOK, out)
+}
+
+func (h *HTTPHandler) handleInviteToWallet(w http.ResponseWriter, r *http.Request) {
+	uid, id, ok := idParams(w, r, "wallet")
+	if !ok {
+		return
+	}
+	var req InviteRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.Error(w, http.StatusBadRequest, "invalid payload")
+		return
+	}
+	inv, err := h.service.InviteToWallet(r.Context(), uid, id, req)
+	if err != nil {
+		response.Error(w, errorStatus(err), err.Error())
+		return
+	}
+	response.JSON(w, http.StatusCreated, inv)
+}
+
+// handleListWalletTransactions accepts the filters of GET /transactions; wallet_id is
+// always the wallet in the path.
+func (h *HTTPHandler) handleListWalletTransactions(w http.ResponseWriter, r *http.Request) {
+	uid, id, ok := idParams(w, r, "wallet")
+	if !ok {
+		return
+	}
+	f, err := transaction.ParseTransactionFilter(r)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	page, err := h.service.ListWalletTransactions(r.Context(), uid, id, f)
+	if err != nil {
+		response.Error(w, errorStatus(err), err.Error())
+		return
+	}
+	if page.NextCursor != "" {
+		w.Header().Set("X-Next-Cursor", page.NextCursor)
+	}
+	response.JSON(w, http.StatusOK, page.Items)
+}
+
+func (h *HTTPHandler) handleCreateWalletTransaction(w http.ResponseWriter, r *http.Request) {
+	uid, id, ok := idParams(w, r, "wallet")
+	if !ok {
+		return
+	}
+	var req TransactionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.Error(w, http.StatusBadRequest, "invalid payload")
+		return
+	}
+	in := transaction.NewTransaction{Amount: req.Amount, Kind: req.Kind, Note: req.Note, OccurredAt: time.Now()}
+	if req.OccurredAt != nil {
+		in.OccurredAt = *req.OccurredAt
+	}
+	var err error
+	if in.CategoryID, err = parseOptionalUUID(req.CategoryID); err != nil {
+		response.Error(w, http.StatusBadRequest, "invalid category id")
+		return
+	}
+	if in.PayeeID, err = parseOptionalUUID(req.PayeeID); err != nil {
+		response.Error(w, http.StatusBadRequest, "invalid payee id")
+		return
+	}
+	for _, s := range req.TagIDs {
+		tagID, err := uuid.Parse(s)
+		if err != nil {
+			response.Error(w, http.StatusBadRequest, "invalid tag id")
+			return
+		}
+		in.TagIDs = append(in.TagIDs, tagID)
+	}
+	t, err := h.service.CreateWalletTransaction(r.Context(), uid, id, in)
+	if err != nil {
+		response.Error(w, errorStatus(err), err.Error())
+		return
+	}
+	response.JSON(w, http.StatusCreated, t)
+}
+
+func (h *HTTPHandler) handleDeleteWalletTransaction(w http.ResponseWriter, r *http.Request) {
+	uid, id, transactionID, ok := subIDParams(w, r, "wallet", "transactionId", "transaction")
+	if !ok {
+		return
+	}
+	if err := h.service.DeleteWalletTransaction(r.Context(), uid, id, transactionID); err != nil {
+		response.Error(w, errorStatus(err), err.Error())
+		return
+	}
+	response.JSON(w, http.StatusOK, map[string]string{"status": "deleted"})
+}
+
+// parseOptionalUUID parses a nullable id from a JSON payload; empty strings count as nil.
+func parseOptionalUUID(s *string) (*uuid.UUID, error) {
+	if s == nil || *s == "" {
+		return nil, nil
+	}
+	id, err := uuid.Parse(*s)
+	if err != nil {
+		return nil, err
+	}
+	return &id, nil
+}
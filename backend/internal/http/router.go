@@ -10,19 +10,27 @@ import (
 
 	"github.com/Jomesi149/Implementasi-LASTI/backend/internal/account"
 	"github.com/Jomesi149/Implementasi-LASTI/backend/internal/analytics"
+	"github.com/Jomesi149/Implementasi-LASTI/backend/internal/attachment"
+	"github.com/Jomesi149/Implementasi-LASTI/backend/internal/bill"
 	"github.com/Jomesi149/Implementasi-LASTI/backend/internal/budget"
+	"github.com/Jomesi149/Implementasi-LASTI/backend/internal/currency"
+	"github.com/Jomesi149/Implementasi-LASTI/backend/internal/goal"
+	"github.com/Jomesi149/Implementasi-LASTI/backend/internal/household"
+	"github.com/Jomesi149/Implementasi-LASTI/backend/internal/notification"
+	"github.com/Jomesi149/Implementasi-LASTI/backend/internal/recurring"
+	"github.com/Jomesi149/Implementasi-LASTI/backend/internal/split"
 	"github.com/Jomesi149/Implementasi-LASTI/backend/internal/transaction"
 )
 
 // NewRouter wires middlewares and HTTP handlers.
-func NewRouter(accountHandler *account.HTTPHandler, transactionHandler *transaction.HTTPHandler, budgetHandler *budget.HTTPHandler, analyticsHandler *analytics.HTTPHandler) http.Handler {
+func NewRouter(accountHandler *account.HTTPHandler, transactionHandler *transaction.HTTPHandler, budgetHandler *budget.HTTPHandler, analyticsHandler *analytics.HTTPHandler, recurringHandler *recurring.HTTPHandler, attachmentHandler *attachment.HTTPHandler, currencyHandler *currency.HTTPHandler, notificationHandler *notification.HTTPHandler, goalHandler *goal.HTTPHandler, householdHandler *household.HTTPHandler, splitHandler *split.HTTPHandler, billHandler *bill.HTTPHandler) http.Handler {
 	r := chi.NewRouter()
 
 	r.Use(cors.Handler(cors.Options{
 		AllowedOrigins:   []string{"http://localhost:3000", "http://localhost:3001", "http://localhost:4000"},
 		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token", "X-User-ID"},
-		ExposedHeaders:   []string{"Link"},
+		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token", "X-User-ID", "Idempotency-Key"},
+		ExposedHeaders:   []string{"Link", "X-Next-Cursor", "Idempotent-Replayed"},
 		AllowCredentials: true,
 		MaxAge:           300,
 	}))
@@ -43,6 +51,14 @@ func NewRouter(accountHandler *account.HTTPHandler, transactionHandler *transact
 		transactionHandler.RegisterRoutes(r)
 		budgetHandler.RegisterRoutes(r)
 		analyticsHandler.RegisterRoutes(r)
+		recurringHandler.RegisterRoutes(r)
+		attachmentHandler.RegisterRoutes(r)
+		currencyHandler.RegisterRoutes(r)
+		notificationHandler.RegisterRoutes(r)
+		goalHandler.RegisterRoutes(r)
+		householdHandler.RegisterRoutes(r)
+		splitHandler.RegisterRoutes(r)
+		billHandler.RegisterRoutes(r)
 	})
 
 	return r
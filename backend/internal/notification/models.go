@@ -0,0 +1,23 @@
+package notification
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Notification is an in-app message for a user, such as a payment reminder. DedupeKey
+// identifies what it is about, so a reminder raised on every scheduler pass is stored
+// only once.
+type Notification struct {
+	ID          uuid.UUID  `json:"id"`
+	UserID      uuid.UUID  `json:"user_id"`
+	Kind        string     `json:"kind"`
+	Title       string     `json:"title"`
+	Body        string     `json:"body"`
+	ReferenceID *uuid.UUID `json:"reference_id,omitempty"`
+	DueDate     *time.Time `json:"due_date,omitempty"`
+	DedupeKey   string     `json:"-"`
+	CreatedAt   time.Time  `json:"created_at"`
+	ReadAt      *time.Time `json:"read_at,omitempty"`
+}
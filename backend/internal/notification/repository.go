@@ -0,0 +1,107 @@
+package notification
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+// Repository persists notifications.
+type Repository interface {
+	Create(ctx context.Context, n Notification) (bool, error)
+	List(ctx context.Context, userID uuid.UUID, unreadOnly bool, limit int) ([]Notification, error)
+	MarkRead(ctx context.Context, userID, id uuid.UUID) error
+	MarkAllRead(ctx context.Context, userID uuid.UUID) (int, error)
+}
+
+type SQLRepository struct {
+	db *sql.DB
+}
+
+func NewRepository(db *sql.DB) *SQLRepository {
+	return &SQLRepository{db: db}
+}
+
+const notificationColumns = `id, user_id, kind, title, body, reference_id, due_date, created_at, read_at`
+
+// Create stores n unless the user already has a notification with its dedupe key; it
+// reports whether a row was inserted.
+func (r *SQLRepository) Create(ctx context.Context, n Notification) (bool, error) {
+	var due any
+	if n.DueDate != nil {
+		due = n.DueDate.Format("2006-01-02")
+	}
+	res, err := r.db.ExecContext(ctx, `INSERT INTO finance.notifications (id, user_id, kind, title, body, reference_id, due_date, dedupe_key, created_at)
+		VALUES ($1,$2,$3,$4,$5,$6,$7::DATE,$8,NOW())
+		ON CONFLICT (user_id, dedupe_key) DO NOTHING`,
+		n.ID, n.UserID, n.Kind, n.Title, n.Body, n.ReferenceID, due, n.DedupeKey)
+	if err != nil {
+		return false, fmt.Errorf("insert notification: %w", err)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected > 0, nil
+}
+
+func (r *SQLRepository) List(ctx context.Context, userID uuid.UUID, unreadOnly bool, limit int) ([]Notification, error) {
+	query := fmt.Sprintf(`SELECT %s FROM finance.notifications
+		WHERE user_id = $1 AND (NOT $2 OR read_at IS NULL)
+		ORDER BY created_at DESC, id DESC LIMIT $3`, notificationColumns)
+	rows, err := r.db.QueryContext(ctx, query, userID, unreadOnly, limit)
+	if err != nil {
+		return nil, fmt.Errorf("list notifications: %w", err)
+	}
+	defer rows.Close()
+
+	out := []Notification{}
+	for rows.Next() {
+		var n Notification
+		var refID uuid.NullUUID
+		var due, readAt sql.NullTime
+		if err := rows.Scan(&n.ID, &n.UserID, &n.Kind, &n.Title, &n.Body, &refID, &due, &n.CreatedAt, &readAt); err != nil {
+			return nil, err
+		}
+		if refID.Valid {
+			id := refID.UUID
+			n.ReferenceID = &id
+		}
+		if due.Valid {
+			d := due.Time
+			n.DueDate = &d
+		}
+		if readAt.Valid {
+			at := readAt.Time
+			n.ReadAt = &at
+		}
+		out = append(out, n)
+	}
+	return out, rows.Err()
+}
+
+func (r *SQLRepository) MarkRead(ctx context.Context, userID, id uuid.UUID) error {
+	res, err := r.db.ExecContext(ctx, `UPDATE finance.notifications SET read_at = COALESCE(read_at, NOW()) WHERE id = $1 AND user_id = $2`, id, userID)
+	if err != nil {
+		return fmt.Errorf("mark notification read: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrNotificationNotFound
+	}
+	return nil
+}
+
+func (r *SQLRepository) MarkAllRead(ctx context.Context, userID uuid.UUID) (int, error) {
+	res, err := r.db.ExecContext(ctx, `UPDATE finance.notifications SET read_at = NOW() WHERE user_id = $1 AND read_at IS NULL`, userID)
+	if err != nil {
+		return 0, fmt.Errorf("mark notifications read: %w", err)
+	}
+	n, err := res.RowsAffected()
+	return int(n), err
+}
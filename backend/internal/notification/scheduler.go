@@ -0,0 +1,43 @@
+package notification
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// Scheduler periodically collects reminders from its sources.
+type Scheduler struct {
+	service  *Service
+	interval time.Duration
+	sources  []Source
+}
+
+func NewScheduler(service *Service, interval time.Duration, sources ...Source) *Scheduler {
+	if interval <= 0 {
+		interval = time.Hour
+	}
+	return &Scheduler{service: service, interval: interval, sources: sources}
+}
+
+// Run collects immediately and then on every tick until ctx is cancelled.
+func (s *Scheduler) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		created, err := s.service.Collect(ctx, time.Now(), s.sources...)
+		if err != nil && ctx.Err() == nil {
+			log.Printf("[NOTIFY_ERROR] collect: %v", err)
+		}
+		if created > 0 {
+			log.Printf("[NOTIFY] created %d reminder(s)", created)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
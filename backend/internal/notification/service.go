@@ -0,0 +1,73 @@
+package notification
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// maxNotifications caps one List response.
+const maxNotifications = 100
+
+// ErrNotificationNotFound is returned when the notification does not exist for the user.
+var ErrNotificationNotFound = errors.New("notification_not_found")
+
+// Source returns the reminders that are due at now. Sources may return the same
+// reminder on every pass; its dedupe key keeps it from being stored twice.
+type Source func(ctx context.Context, now time.Time) ([]Notification, error)
+
+type Service struct {
+	repo Repository
+}
+
+func NewService(repo Repository) *Service {
+	return &Service{repo: repo}
+}
+
+// Notify stores n and reports whether it is new.
+func (s *Service) Notify(ctx context.Context, n Notification) (bool, error) {
+	if n.ID == uuid.Nil {
+		n.ID = uuid.New()
+	}
+	return s.repo.Create(ctx, n)
+}
+
+// List returns the user's newest notifications.
+func (s *Service) List(ctx context.Context, userID uuid.UUID, unreadOnly bool) ([]Notification, error) {
+	return s.repo.List(ctx, userID, unreadOnly, maxNotifications)
+}
+
+func (s *Service) MarkRead(ctx context.Context, userID, id uuid.UUID) error {
+	return s.repo.MarkRead(ctx, userID, id)
+}
+
+func (s *Service) MarkAllRead(ctx context.Context, userID uuid.UUID) (int, error) {
+	return s.repo.MarkAllRead(ctx, userID)
+}
+
+// Collect asks every source for its reminders and stores the new ones, returning how
+// many were created. A failing source does not stop the others.
+func (s *Service) Collect(ctx context.Context, now time.Time, sources ...Source) (int, error) {
+	var created int
+	var errs []error
+	for _, source := range sources {
+		reminders, err := source(ctx, now)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		for _, n := range reminders {
+			isNew, err := s.Notify(ctx, n)
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			if isNew {
+				created++
+			}
+		}
+	}
+	return created, errors.Join(errs...)
+}
@@ -0,0 +1,89 @@
+package notification
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"github.com/Jomesi149/Implementasi-LASTI/backend/pkg/response"
+)
+
+type HTTPHandler struct {
+	service *Service
+}
+
+func NewHTTPHandler(s *Service) *HTTPHandler {
+	return &HTTPHandler{service: s}
+}
+
+func (h *HTTPHandler) RegisterRoutes(r chi.Router) {
+	r.Route("/notifications", func(r chi.Router) {
+		r.Get("/", h.handleList)
+		r.Post("/read", h.handleMarkAllRead)
+		r.Post("/{id}/read", h.handleMarkRead)
+	})
+}
+
+func getUserIDFromHeader(r *http.Request) (uuid.UUID, error) {
+	s := r.Header.Get("X-User-ID")
+	return uuid.Parse(s)
+}
+
+// errorStatus maps service sentinel errors onto HTTP status codes.
+func errorStatus(err error) int {
+	switch {
+	case errors.Is(err, ErrNotificationNotFound):
+		return http.StatusNotFound
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// handleList returns the newest notifications; ?unread=true leaves out read ones.
+func (h *HTTPHandler) handleList(w http.ResponseWriter, r *http.Request) {
+	uid, err := getUserIDFromHeader(r)
+	if err != nil {
+		response.Error(w, http.StatusUnauthorized, "missing X-User-ID header")
+		return
+	}
+	list, err := h.service.List(r.Context(), uid, r.URL.Query().Get("unread") == "true")
+	if err != nil {
+		response.Error(w, errorStatus(err), err.Error())
+		return
+	}
+	response.JSON(w, http.StatusOK, list)
+}
+
+func (h *HTTPHandler) handleMarkRead(w http.ResponseWriter, r *http.Request) {
+	uid, err := getUserIDFromHeader(r)
+	if err != nil {
+		response.Error(w, http.StatusUnauthorized, "missing X-User-ID header")
+		return
+	}
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "invalid notification id")
+		return
+	}
+	if err := h.service.MarkRead(r.Context(), uid, id); err != nil {
+		response.Error(w, errorStatus(err), err.Error())
+		return
+	}
+	response.JSON(w, http.StatusOK, map[string]string{"status": "read"})
+}
+
+func (h *HTTPHandler) handleMarkAllRead(w http.ResponseWriter, r *http.Request) {
+	uid, err := getUserIDFromHeader(r)
+	if err != nil {
+		response.Error(w, http.StatusUnauthorized, "missing X-User-ID header")
+		return
+	}
+	n, err := h.service.MarkAllRead(r.Context(), uid)
+	if err != nil {
+		response.Error(w, errorStatus(err), err.Error())
+		return
+	}
+	response.JSON(w, http.StatusOK, map[string]int{"marked": n})
+}
@@ -0,0 +1,88 @@
+package recurring
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Rule frequencies.
+const (
+	Daily   = "daily"
+	Weekly  = "weekly"
+	Monthly = "monthly"
+	Yearly  = "yearly"
+)
+
+// Rule statuses.
+const (
+	StatusActive    = "active"
+	StatusPaused    = "paused"
+	StatusCompleted = "completed"
+)
+
+// Occurrence statuses.
+const (
+	OccurrencePending = "pending"
+	OccurrencePosted  = "posted"
+	OccurrenceSkipped = "skipped"
+)
+
+// Rule describes a transaction that repeats on a schedule. Dates are calendar dates
+// stored as UTC midnight.
+type Rule struct {
+	ID               uuid.UUID  `json:"id"`
+	UserID           uuid.UUID  `json:"user_id"`
+	WalletID         uuid.UUID  `json:"wallet_id"`
+	CategoryID       *uuid.UUID `json:"category_id,omitempty"`
+	Amount           string     `json:"amount"`
+	Kind             string     `json:"kind"`
+	Note             *string    `json:"note,omitempty"`
+	Frequency        string     `json:"frequency"`
+	Interval         int        `json:"interval"`
+	DayOfMonth       *int       `json:"day_of_month,omitempty"`
+	StartDate        time.Time  `json:"start_date"`
+	EndDate          *time.Time `json:"end_date,omitempty"`
+	MaxOccurrences   *int       `json:"max_occurrences,omitempty"`
+	OccurrencesCount int        `json:"occurrences_count"`
+	NextRunDate      *time.Time `json:"next_run_date,omitempty"`
+	Status           string     `json:"status"`
+	CreatedAt        time.Time  `json:"created_at"`
+}
+
+// Occurrence records what happened on one due date of a rule.
+type Occurrence struct {
+	ID            uuid.UUID  `json:"id"`
+	RuleID        uuid.UUID  `json:"rule_id"`
+	DueDate       time.Time  `json:"due_date"`
+	TransactionID *uuid.UUID `json:"transaction_id,omitempty"`
+	Status        string     `json:"status"`
+}
+
+// RuleDetail is a rule with its recent history and next due dates.
+type RuleDetail struct {
+	Rule
+	Upcoming    []time.Time  `json:"upcoming"`
+	Occurrences []Occurrence `json:"occurrences"`
+}
+
+// RuleRequest is the payload to create a rule or edit its future occurrences.
+// Dates use the YYYY-MM-DD format.
+type RuleRequest struct {
+	WalletID       string  `json:"wallet_id"`
+	CategoryID     *string `json:"category_id"`
+	Amount         string  `json:"amount"`
+	Kind           string  `json:"kind"`
+	Note           *string `json:"note"`
+	Frequency      string  `json:"frequency"`
+	Interval       int     `json:"interval"`
+	DayOfMonth     *int    `json:"day_of_month"`
+	StartDate      string  `json:"start_date"`
+	EndDate        *string `json:"end_date"`
+	MaxOccurrences *int    `json:"max_occurrences"`
+}
+
+// SkipRequest names the due date to skip; empty means the next one.
+type SkipRequest struct {
+	Date string `json:"date"`
+}
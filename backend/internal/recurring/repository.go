@@ -0,0 +1,350 @@
+package recurring
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/Jomesi149/Implementasi-LASTI/backend/internal/sqlutil"
+)
+
+// Repository persists recurring rules and their occurrences.
+type Repository interface {
+	CreateRule(ctx context.Context, r Rule) error
+	GetRule(ctx context.Context, userID, ruleID uuid.UUID) (*Rule, error)
+	LoadRule(ctx context.Context, ruleID uuid.UUID) (*Rule, error)
+	ListRules(ctx context.Context, userID uuid.UUID) ([]Rule, error)
+	UpdateRule(ctx context.Context, r Rule) error
+	DeleteRule(ctx context.Context, userID, ruleID uuid.UUID) error
+	ListOccurrences(ctx context.Context, ruleID uuid.UUID, limit int) ([]Occurrence, error)
+	SkipOccurrence(ctx context.Context, ruleID uuid.UUID, dueDate time.Time) error
+	DueRuleIDs(ctx context.Context, today time.Time, limit int) ([]uuid.UUID, error)
+	ClaimOccurrence(ctx context.Context, ruleID uuid.UUID, today time.Time) (*Rule, *Occurrence, error)
+	PendingOccurrences(ctx context.Context, olderThan time.Time, limit int) ([]Occurrence, error)
+	MarkPosted(ctx context.Context, occurrenceID uuid.UUID) error
+}
+
+type SQLRepository struct {
+	db *sql.DB
+}
+
+func NewRepository(db *sql.DB) *SQLRepository {
+	return &SQLRepository{db: db}
+}
+
+const ruleColumns = `id, user_id, wallet_id, category_id, amount::TEXT, kind, note, frequency, interval_count,
+	day_of_month, start_date, end_date, max_occurrences, occurrences_count, next_run_date, status, created_at`
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanRule(row rowScanner) (Rule, error) {
+	var r Rule
+	var catID uuid.NullUUID
+	var note sql.NullString
+	var dayOfMonth, maxOccurrences sql.NullInt32
+	var endDate, nextRun sql.NullTime
+
+	err := row.Scan(&r.ID, &r.UserID, &r.WalletID, &catID, &r.Amount, &r.Kind, &note, &r.Frequency, &r.Interval,
+		&dayOfMonth, &r.StartDate, &endDate, &maxOccurrences, &r.OccurrencesCount, &nextRun, &r.Status, &r.CreatedAt)
+	if err != nil {
+		return r, err
+	}
+	if catID.Valid {
+		id := catID.UUID
+		r.CategoryID = &id
+	}
+	if note.Valid {
+		s := note.String
+		r.Note = &s
+	}
+	if dayOfMonth.Valid {
+		d := int(dayOfMonth.Int32)
+		r.DayOfMonth = &d
+	}
+	if maxOccurrences.Valid {
+		m := int(maxOccurrences.Int32)
+		r.MaxOccurrences = &m
+	}
+	if endDate.Valid {
+		d := dateOf(endDate.Time)
+		r.EndDate = &d
+	}
+	if nextRun.Valid {
+		d := dateOf(nextRun.Time)
+		r.NextRunDate = &d
+	}
+	r.StartDate = dateOf(r.StartDate)
+	return r, nil
+}
+
+func (r *SQLRepository) CreateRule(ctx context.Context, rule Rule) error {
+	amount, err := strconv.ParseFloat(rule.Amount, 64)
+	if err != nil {
+		return fmt.Errorf("invalid amount: %w", err)
+	}
+	query := `INSERT INTO finance.recurring_rules (id, user_id, wallet_id, category_id, amount, kind, note, frequency,
+		interval_count, day_of_month, start_date, end_date, max_occurrences, next_run_date, status, created_at, updated_at)
+		VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13,$14,$15,NOW(),NOW())`
+	_, err = r.db.ExecContext(ctx, query, rule.ID, rule.UserID, rule.WalletID, rule.CategoryID, amount, rule.Kind, rule.Note,
+		rule.Frequency, rule.Interval, rule.DayOfMonth, sqlutil.DateParam(&rule.StartDate), sqlutil.DateParam(rule.EndDate), rule.MaxOccurrences,
+		sqlutil.DateParam(rule.NextRunDate), rule.Status)
+	if err != nil {
+		return fmt.Errorf("create recurring rule: %w", err)
+	}
+	return nil
+}
+
+func (r *SQLRepository) GetRule(ctx context.Context, userID, ruleID uuid.UUID) (*Rule, error) {
+	query := `SELECT ` + ruleColumns + ` FROM finance.recurring_rules WHERE id = $1 AND user_id = $2`
+	rule, err := scanRule(r.db.QueryRowContext(ctx, query, ruleID, userID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrRuleNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get recurring rule: %w", err)
+	}
+	return &rule, nil
+}
+
+// LoadRule fetches a rule without an owner check, for the scheduler.
+func (r *SQLRepository) LoadRule(ctx context.Context, ruleID uuid.UUID) (*Rule, error) {
+	query := `SELECT ` + ruleColumns + ` FROM finance.recurring_rules WHERE id = $1`
+	rule, err := scanRule(r.db.QueryRowContext(ctx, query, ruleID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrRuleNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("load recurring rule: %w", err)
+	}
+	return &rule, nil
+}
+
+func (r *SQLRepository) ListRules(ctx context.Context, userID uuid.UUID) ([]Rule, error) {
+	query := `SELECT ` + ruleColumns + ` FROM finance.recurring_rules WHERE user_id = $1
+		ORDER BY next_run_date ASC NULLS LAST, created_at DESC`
+	rows, err := r.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("list recurring rules: %w", err)
+	}
+	defer rows.Close()
+
+	var out []Rule
+	for rows.Next() {
+		rule, err := scanRule(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, rule)
+	}
+	return out, rows.Err()
+}
+
+// UpdateRule saves every editable field, the status and next_run_date. The occurrence
+// counter is owned by the scheduler and is left untouched.
+func (r *SQLRepository) UpdateRule(ctx context.Context, rule Rule) error {
+	amount, err := strconv.ParseFloat(rule.Amount, 64)
+	if err != nil {
+		return fmt.Errorf("invalid amount: %w", err)
+	}
+	query := `UPDATE finance.recurring_rules SET wallet_id = $3, category_id = $4, amount = $5, kind = $6, note = $7,
+		frequency = $8, interval_count = $9, day_of_month = $10, start_date = $11, end_date = $12, max_occurrences = $13,
+		next_run_date = $14, status = $15, updated_at = NOW()
+		WHERE id = $1 AND user_id = $2`
+	res, err := r.db.ExecContext(ctx, query, rule.ID, rule.UserID, rule.WalletID, rule.CategoryID, amount, rule.Kind, rule.Note,
+		rule.Frequency, rule.Interval, rule.DayOfMonth, sqlutil.DateParam(&rule.StartDate), sqlutil.DateParam(rule.EndDate), rule.MaxOccurrences,
+		sqlutil.DateParam(rule.NextRunDate), rule.Status)
+	if err != nil {
+		return fmt.Errorf("update recurring rule: %w", err)
+	}
+	return sqlutil.ExpectAffected(res, ErrRuleNotFound)
+}
+
+// DeleteRule removes the rule and its occurrence log. Transactions it already posted stay.
+func (r *SQLRepository) DeleteRule(ctx context.Context, userID, ruleID uuid.UUID) error {
+	res, err := r.db.ExecContext(ctx, `DELETE FROM finance.recurring_rules WHERE id = $1 AND user_id = $2`, ruleID, userID)
+	if err != nil {
+		return fmt.Errorf("delete recurring rule: %w", err)
+	}
+	return sqlutil.ExpectAffected(res, ErrRuleNotFound)
+}
+
+func (r *SQLRepository) ListOccurrences(ctx context.Context, ruleID uuid.UUID, limit int) ([]Occurrence, error) {
+	query := `SELECT id, rule_id, due_date, transaction_id, status FROM finance.recurring_occurrences
+		WHERE rule_id = $1 ORDER BY due_date DESC LIMIT $2`
+	rows, err := r.db.QueryContext(ctx, query, ruleID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("list occurrences: %w", err)
+	}
+	defer rows.Close()
+
+	out := []Occurrence{}
+	for rows.Next() {
+		o, err := scanOccurrence(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, o)
+	}
+	return out, rows.Err()
+}
+
+func scanOccurrence(row rowScanner) (Occurrence, error) {
+	var o Occurrence
+	var txID uuid.NullUUID
+	if err := row.Scan(&o.ID, &o.RuleID, &o.DueDate, &txID, &o.Status); err != nil {
+		return o, err
+	}
+	o.DueDate = dateOf(o.DueDate)
+	if txID.Valid {
+		id := txID.UUID
+		o.TransactionID = &id
+	}
+	return o, nil
+}
+
+// SkipOccurrence records dueDate as skipped so the scheduler passes over it. A date
+// that was already posted cannot be skipped.
+func (r *SQLRepository) SkipOccurrence(ctx context.Context, ruleID uuid.UUID, dueDate time.Time) error {
+	query := `INSERT INTO finance.recurring_occurrences (id, rule_id, due_date, status, created_at, updated_at)
+		VALUES ($1, $2, $3, 'skipped', NOW(), NOW())
+		ON CONFLICT (rule_id, due_date) DO UPDATE SET status = 'skipped', updated_at = NOW()
+		WHERE finance.recurring_occurrences.status = 'skipped'
+		RETURNING id`
+	var id uuid.UUID
+	err := r.db.QueryRowContext(ctx, query, uuid.New(), ruleID, sqlutil.DateParam(&dueDate)).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("%w: %s is already posted", ErrRuleState, dueDate.Format(dateLayout))
+	}
+	if err != nil {
+		return fmt.Errorf("skip occurrence: %w", err)
+	}
+	return nil
+}
+
+// DueRuleIDs lists active rules whose next run date is on or before today. Rules on a
+// wallet in the trash are skipped.
+func (r *SQLRepository) DueRuleIDs(ctx context.Context, today time.Time, limit int) ([]uuid.UUID, error) {
+	query := `SELECT id FROM finance.recurring_rules r
+		WHERE status = 'active' AND next_run_date <= $1
+		AND NOT EXISTS (SELECT 1 FROM finance.wallets w WHERE w.id = r.wallet_id AND w.deleted_at IS NOT NULL)
+		ORDER BY next_run_date ASC LIMIT $2`
+	rows, err := r.db.QueryContext(ctx, query, sqlutil.DateParam(&today), limit)
+	if err != nil {
+		return nil, fmt.Errorf("due recurring rules: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// ClaimOccurrence locks a due rule, records its next occurrence as pending and advances
+// the rule past it, all in one database transaction. Rules locked by another instance
+// are skipped. It returns a nil occurrence when the rule is not due or the date was
+// already handled (skipped or posted), in which case the rule is still advanced.
+func (r *SQLRepository) ClaimOccurrence(ctx context.Context, ruleID uuid.UUID, today time.Time) (rule *Rule, occ *Occurrence, err error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("begin tx: %w", err)
+	}
+
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	query := `SELECT ` + ruleColumns + ` FROM finance.recurring_rules
+		WHERE id = $1 AND status = 'active' AND next_run_date <= $2
+		FOR UPDATE SKIP LOCKED`
+	claimed, err := scanRule(tx.QueryRowContext(ctx, query, ruleID, sqlutil.DateParam(&today)))
+	if errors.Is(err, sql.ErrNoRows) {
+		err = nil
+		_ = tx.Rollback()
+		return nil, nil, nil
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("lock recurring rule: %w", err)
+	}
+	due := *claimed.NextRunDate
+
+	o := Occurrence{ID: uuid.New(), RuleID: claimed.ID, DueDate: due, Status: OccurrencePending}
+	txID := transactionID(claimed.ID, due)
+	o.TransactionID = &txID
+	insert := `INSERT INTO finance.recurring_occurrences (id, rule_id, due_date, transaction_id, status, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, 'pending', NOW(), NOW())
+		ON CONFLICT (rule_id, due_date) DO NOTHING`
+	res, err := tx.ExecContext(ctx, insert, o.ID, o.RuleID, sqlutil.DateParam(&due), txID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("insert occurrence: %w", err)
+	}
+	inserted, err := res.RowsAffected()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Tanggal yang di-skip tidak dihitung sebagai kejadian
+	if inserted > 0 {
+		claimed.OccurrencesCount++
+	}
+	advance(&claimed, due)
+
+	update := `UPDATE finance.recurring_rules SET occurrences_count = $2, next_run_date = $3, status = $4, updated_at = NOW()
+		WHERE id = $1`
+	if _, err = tx.ExecContext(ctx, update, claimed.ID, claimed.OccurrencesCount, sqlutil.DateParam(claimed.NextRunDate), claimed.Status); err != nil {
+		return nil, nil, fmt.Errorf("advance recurring rule: %w", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, nil, fmt.Errorf("commit tx: %w", err)
+	}
+	if inserted == 0 {
+		return &claimed, nil, nil
+	}
+	return &claimed, &o, nil
+}
+
+// PendingOccurrences lists claimed occurrences whose transaction was never confirmed,
+// e.g. because the process stopped between claiming and posting.
+func (r *SQLRepository) PendingOccurrences(ctx context.Context, olderThan time.Time, limit int) ([]Occurrence, error) {
+	query := `SELECT id, rule_id, due_date, transaction_id, status FROM finance.recurring_occurrences
+		WHERE status = 'pending' AND created_at < $1
+		ORDER BY created_at ASC LIMIT $2`
+	rows, err := r.db.QueryContext(ctx, query, olderThan, limit)
+	if err != nil {
+		return nil, fmt.Errorf("pending occurrences: %w", err)
+	}
+	defer rows.Close()
+
+	var out []Occurrence
+	for rows.Next() {
+		o, err := scanOccurrence(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, o)
+	}
+	return out, rows.Err()
+}
+
+func (r *SQLRepository) MarkPosted(ctx context.Context, occurrenceID uuid.UUID) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE finance.recurring_occurrences SET status = 'posted', updated_at = NOW() WHERE id = $1`, occurrenceID)
+	if err != nil {
+		return fmt.Errorf("mark occurrence posted: %w", err)
+	}
+	return nil
+}
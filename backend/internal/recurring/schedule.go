@@ -0,0 +1,138 @@
+package recurring
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const dateLayout = "2006-01-02"
+
+// dateOf returns the calendar date of t (in t's location) as UTC midnight.
+func dateOf(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+// nextDueDate returns the first due date of r on or after from. The schedule is always
+// computed from StartDate, so a monthly rule on the 31st gives Jan 31, Feb 29, Mar 31
+// instead of drifting to the 29th. It reports false once EndDate has passed; the
+// occurrence limit is checked by the caller.
+func nextDueDate(r Rule, from time.Time) (time.Time, bool) {
+	start := dateOf(r.StartDate)
+	from = dateOf(from)
+	if from.Before(start) {
+		from = start
+	}
+	interval := r.Interval
+	if interval < 1 {
+		interval = 1
+	}
+
+	var due time.Time
+	switch r.Frequency {
+	case Daily, Weekly:
+		step := interval
+		if r.Frequency == Weekly {
+			step *= 7
+		}
+		days := int(from.Sub(start).Hours() / 24)
+		n := (days + step - 1) / step
+		due = start.AddDate(0, 0, n*step)
+	case Monthly, Yearly:
+		step := interval
+		if r.Frequency == Yearly {
+			step *= 12
+		}
+		day := start.Day()
+		if r.DayOfMonth != nil {
+			day = *r.DayOfMonth
+		}
+		months := (from.Year()-start.Year())*12 + int(from.Month()-start.Month())
+		n := months / step
+		if n > 0 {
+			n--
+		}
+		for {
+			due = monthDate(start.Year(), start.Month()+time.Month(n*step), day)
+			if !due.Before(from) {
+				break
+			}
+			n++
+		}
+	default:
+		return time.Time{}, false
+	}
+
+	if r.EndDate != nil && due.After(dateOf(*r.EndDate)) {
+		return time.Time{}, false
+	}
+	return due, true
+}
+
+// monthDate builds year/month/day, clamping day to the last day of the month.
+// time.Date normalises month overflow, so month may exceed 12.
+func monthDate(year int, month time.Month, day int) time.Time {
+	first := time.Date(year, month, 1, 0, 0, 0, 0, time.UTC)
+	last := first.AddDate(0, 1, -1).Day()
+	if day > last {
+		day = last
+	}
+	return first.AddDate(0, 0, day-1)
+}
+
+// Upcoming lists the due dates of r from from through until, honouring the end date
+// and the remaining occurrence count. Paused and completed rules have none.
+func Upcoming(r Rule, from, until time.Time) []time.Time {
+	return dueDates(r, from, dateOf(until), -1)
+}
+
+// dueDates walks the schedule from from (or the next run date, if later), stopping at
+// until when it is non-zero and after limit dates when limit is positive.
+func dueDates(r Rule, from, until time.Time, limit int) []time.Time {
+	if r.Status != StatusActive {
+		return nil
+	}
+	if r.NextRunDate != nil && from.Before(*r.NextRunDate) {
+		from = *r.NextRunDate
+	}
+	remaining := -1
+	if r.MaxOccurrences != nil {
+		remaining = *r.MaxOccurrences - r.OccurrencesCount
+		if remaining <= 0 {
+			return nil
+		}
+	}
+
+	var out []time.Time
+	for d := dateOf(from); remaining != 0 && limit != 0; {
+		due, ok := nextDueDate(r, d)
+		if !ok || (!until.IsZero() && due.After(until)) {
+			break
+		}
+		out = append(out, due)
+		remaining--
+		limit--
+		d = due.AddDate(0, 0, 1)
+	}
+	return out
+}
+
+// advance moves r past due: it sets the next run date, or marks the rule completed when
+// the end date or the occurrence limit has been reached.
+func advance(r *Rule, due time.Time) {
+	next, ok := nextDueDate(*r, due.AddDate(0, 0, 1))
+	if ok && (r.MaxOccurrences == nil || r.OccurrencesCount < *r.MaxOccurrences) {
+		r.NextRunDate = &next
+		return
+	}
+	r.NextRunDate = nil
+	r.Status = StatusCompleted
+}
+
+// transactionID derives the id of the transaction posted for one due date. Posting the
+// same occurrence twice therefore hits the transactions primary key instead of creating
+// a second row.
+func transactionID(ruleID uuid.UUID, due time.Time) uuid.UUID {
+	return uuid.NewSHA1(ruleID, []byte(due.Format(dateLayout)))
+}
@@ -0,0 +1,43 @@
+package recurring
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// Scheduler periodically posts due recurring transactions.
+type Scheduler struct {
+	service  *Service
+	interval time.Duration
+}
+
+func NewScheduler(service *Service, interval time.Duration) *Scheduler {
+	if interval <= 0 {
+		interval = time.Minute
+	}
+	return &Scheduler{service: service, interval: interval}
+}
+
+// Run processes due rules immediately and then on every tick until ctx is cancelled.
+// Missed dates (e.g. after downtime) are caught up on the first pass.
+func (s *Scheduler) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		posted, err := s.service.RunDue(ctx, time.Now())
+		if err != nil && ctx.Err() == nil {
+			log.Printf("[RECURRING_ERROR] run: %v", err)
+		}
+		if posted > 0 {
+			log.Printf("[RECURRING] posted %d transaction(s)", posted)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
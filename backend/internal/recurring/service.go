@@ -0,0 +1,404 @@
+package recurring
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/Jomesi149/Implementasi-LASTI/backend/internal/transaction"
+)
+
+var (
+	// ErrRuleNotFound is returned when the rule does not exist for the user.
+	ErrRuleNotFound = errors.New("recurring_rule_not_found")
+	// ErrInvalidRule indicates a rule payload with a bad schedule, amount or wallet.
+	ErrInvalidRule = errors.New("invalid_recurring_rule")
+	// ErrRuleState is returned for an action the rule or occurrence status does not allow.
+	ErrRuleState = errors.New("recurring_rule_state_conflict")
+)
+
+const (
+	// runBatchSize bounds how many rules or pending occurrences one pass loads at a time.
+	runBatchSize = 100
+	// pendingGrace is how long a claimed occurrence may stay pending before another
+	// pass retries it.
+	pendingGrace = time.Minute
+	// historyLimit is how many past occurrences a rule detail shows.
+	historyLimit = 20
+	// MaxUpcoming caps the number of dates the upcoming endpoint returns.
+	MaxUpcoming = 366
+)
+
+type ServiceDeps struct {
+	Repo         Repository
+	Transactions *transaction.Service
+}
+
+type Service struct {
+	repo         Repository
+	transactions *transaction.Service
+	now          func() time.Time
+}
+
+func NewService(deps ServiceDeps) *Service {
+	return &Service{repo: deps.Repo, transactions: deps.Transactions, now: time.Now}
+}
+
+// CreateRule validates req and stores a new active rule. Due dates before today are
+// not back-filled; the first run is the first due date on or after today.
+func (s *Service) CreateRule(ctx context.Context, userID uuid.UUID, req RuleRequest) (*Rule, error) {
+	r := Rule{ID: uuid.New(), UserID: userID, Status: StatusActive, CreatedAt: s.now()}
+	if err := s.applyRequest(ctx, &r, req); err != nil {
+		return nil, err
+	}
+	s.schedule(&r)
+	if err := s.repo.CreateRule(ctx, r); err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+// ListRules returns every rule of the user, soonest first.
+func (s *Service) ListRules(ctx context.Context, userID uuid.UUID) ([]Rule, error) {
+	rules, err := s.repo.ListRules(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if rules == nil {
+		rules = []Rule{}
+	}
+	return rules, nil
+}
+
+// GetRule returns a rule with its recent occurrences and next few due dates.
+func (s *Service) GetRule(ctx context.Context, userID, ruleID uuid.UUID) (*RuleDetail, error) {
+	r, err := s.repo.GetRule(ctx, userID, ruleID)
+	if err != nil {
+		return nil, err
+	}
+	occurrences, err := s.repo.ListOccurrences(ctx, r.ID, historyLimit)
+	if err != nil {
+		return nil, err
+	}
+	upcoming := dueDates(*r, dateOf(s.now()), time.Time{}, 5)
+	if upcoming == nil {
+		upcoming = []time.Time{}
+	}
+	return &RuleDetail{Rule: *r, Upcoming: upcoming, Occurrences: occurrences}, nil
+}
+
+// UpdateRule replaces the rule's settings. Only future occurrences are affected:
+// transactions already posted are kept and the next run is recomputed from today.
+func (s *Service) UpdateRule(ctx context.Context, userID, ruleID uuid.UUID, req RuleRequest) (*Rule, error) {
+	r, err := s.repo.GetRule(ctx, userID, ruleID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.applyRequest(ctx, r, req); err != nil {
+		return nil, err
+	}
+	// Aturan yang sudah selesai bisa aktif lagi jika tanggal akhir/limit diperpanjang
+	if r.Status == StatusCompleted {
+		r.Status = StatusActive
+	}
+	s.schedule(r)
+	if err := s.repo.UpdateRule(ctx, *r); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// DeleteRule stops the rule for good. Transactions it already posted are kept.
+func (s *Service) DeleteRule(ctx context.Context, userID, ruleID uuid.UUID) error {
+	return s.repo.DeleteRule(ctx, userID, ruleID)
+}
+
+// PauseRule stops the scheduler from posting the rule until it is resumed.
+func (s *Service) PauseRule(ctx context.Context, userID, ruleID uuid.UUID) (*Rule, error) {
+	r, err := s.repo.GetRule(ctx, userID, ruleID)
+	if err != nil {
+		return nil, err
+	}
+	if r.Status != StatusActive {
+		return nil, fmt.Errorf("%w: rule is %s", ErrRuleState, r.Status)
+	}
+	r.Status = StatusPaused
+	if err := s.repo.UpdateRule(ctx, *r); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// ResumeRule reactivates a paused rule. Dates missed while paused are not posted.
+func (s *Service) ResumeRule(ctx context.Context, userID, ruleID uuid.UUID) (*Rule, error) {
+	r, err := s.repo.GetRule(ctx, userID, ruleID)
+	if err != nil {
+		return nil, err
+	}
+	if r.Status != StatusPaused {
+		return nil, fmt.Errorf("%w: rule is %s", ErrRuleState, r.Status)
+	}
+	r.Status = StatusActive
+	s.schedule(r)
+	if err := s.repo.UpdateRule(ctx, *r); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// SkipOccurrence marks one future due date as skipped; nil means the next run date.
+// Skipped dates do not count towards max_occurrences.
+func (s *Service) SkipOccurrence(ctx context.Context, userID, ruleID uuid.UUID, date *time.Time) (*Rule, error) {
+	r, err := s.repo.GetRule(ctx, userID, ruleID)
+	if err != nil {
+		return nil, err
+	}
+	if r.NextRunDate == nil {
+		return nil, fmt.Errorf("%w: rule has no upcoming occurrence", ErrRuleState)
+	}
+
+	due := *r.NextRunDate
+	if date != nil {
+		due = dateOf(*date)
+	}
+	if due.Before(*r.NextRunDate) {
+		return nil, fmt.Errorf("%w: %s has already been processed", ErrRuleState, due.Format(dateLayout))
+	}
+	if next, ok := nextDueDate(*r, due); !ok || !next.Equal(due) {
+		return nil, fmt.Errorf("%w: %s is not a due date of this rule", ErrInvalidRule, due.Format(dateLayout))
+	}
+
+	if err := s.repo.SkipOccurrence(ctx, r.ID, due); err != nil {
+		return nil, err
+	}
+	if due.Equal(*r.NextRunDate) {
+		advance(r, due)
+		if err := s.repo.UpdateRule(ctx, *r); err != nil {
+			return nil, err
+		}
+	}
+	return r, nil
+}
+
+// UpcomingDates lists the next count due dates of a rule.
+func (s *Service) UpcomingDates(ctx context.Context, userID, ruleID uuid.UUID, count int) ([]time.Time, error) {
+	r, err := s.repo.GetRule(ctx, userID, ruleID)
+	if err != nil {
+		return nil, err
+	}
+	if count <= 0 || count > MaxUpcoming {
+		return nil, fmt.Errorf("%w: count must be between 1 and %d", ErrInvalidRule, MaxUpcoming)
+	}
+	dates := dueDates(*r, dateOf(s.now()), time.Time{}, count)
+	if dates == nil {
+		dates = []time.Time{}
+	}
+	return dates, nil
+}
+
+// Projected is a transaction.ProjectionSource listing the occurrences of the user's
+// rules on a wallet from from through until.
+func (s *Service) Projected(ctx context.Context, userID, walletID uuid.UUID, from, until time.Time) ([]transaction.ProjectedItem, error) {
+	rules, err := s.repo.ListRules(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	var out []transaction.ProjectedItem
+	for _, r := range rules {
+		if r.WalletID != walletID {
+			continue
+		}
+		for _, d := range Upcoming(r, from, until) {
+			out = append(out, transaction.ProjectedItem{Date: d, Source: transaction.SourceRecurring, ReferenceID: r.ID, Kind: r.Kind, Amount: r.Amount, Note: r.Note})
+		}
+	}
+	return out, nil
+}
+
+// RunDue posts every occurrence due on or before now and returns how many transactions
+// were created. Each due date is claimed in its own database transaction and posted
+// with a transaction id derived from the rule and date, so a date is posted at most
+// once even with several API instances or a crash between claiming and posting.
+func (s *Service) RunDue(ctx context.Context, now time.Time) (int, error) {
+	posted := 0
+
+	// Sisa klaim yang belum sempat dibukukan (mis. proses mati di tengah jalan)
+	pending, err := s.repo.PendingOccurrences(ctx, now.Add(-pendingGrace), runBatchSize)
+	if err != nil {
+		return posted, err
+	}
+	for _, o := range pending {
+		r, err := s.repo.LoadRule(ctx, o.RuleID)
+		if err != nil {
+			log.Printf("[RECURRING_ERROR] load rule %s: %v", o.RuleID, err)
+			continue
+		}
+		if err := s.post(ctx, *r, o); err != nil {
+			log.Printf("[RECURRING_ERROR] retry occurrence %s: %v", o.ID, err)
+			continue
+		}
+		posted++
+	}
+
+	today := dateOf(now)
+	for {
+		ids, err := s.repo.DueRuleIDs(ctx, today, runBatchSize)
+		if err != nil {
+			return posted, err
+		}
+		progress := false
+		for _, id := range ids {
+			if ctx.Err() != nil {
+				return posted, ctx.Err()
+			}
+			r, o, err := s.repo.ClaimOccurrence(ctx, id, today)
+			if err != nil {
+				log.Printf("[RECURRING_ERROR] claim rule %s: %v", id, err)
+				continue
+			}
+			if r == nil {
+				continue
+			}
+			progress = true
+			if o == nil {
+				continue
+			}
+			if err := s.post(ctx, *r, *o); err != nil {
+				log.Printf("[RECURRING_ERROR] post occurrence %s: %v", o.ID, err)
+				continue
+			}
+			posted++
+		}
+		// Berhenti jika semua aturan yang jatuh tempo sedang dikunci instance lain
+		if !progress {
+			return posted, nil
+		}
+	}
+}
+
+// post creates the occurrence's transaction and marks it posted. A transaction that
+// already exists means an earlier attempt succeeded, which counts as posted too.
+func (s *Service) post(ctx context.Context, r Rule, o Occurrence) error {
+	id := transactionID(r.ID, o.DueDate)
+	if o.TransactionID != nil {
+		id = *o.TransactionID
+	}
+	y, m, d := o.DueDate.Date()
+	// Transaksi dari aturan berulang dicatat di riwayat sebagai perubahan sistem
+	ctx = transaction.WithChange(ctx, transaction.Change{Source: transaction.SourceRecurring, ReferenceID: &r.ID})
+	_, err := s.transactions.CreateTransaction(ctx, transaction.NewTransaction{
+		ID:         id,
+		UserID:     r.UserID,
+		WalletID:   r.WalletID,
+		CategoryID: r.CategoryID,
+		Amount:     r.Amount,
+		Kind:       r.Kind,
+		Note:       r.Note,
+		OccurredAt: time.Date(y, m, d, 0, 0, 0, 0, time.Local),
+	})
+	if err != nil && !errors.Is(err, transaction.ErrDuplicateTransaction) {
+		return err
+	}
+	return s.repo.MarkPosted(ctx, o.ID)
+}
+
+// schedule sets the next run date to the first due date on or after today and
+// completes rules that have nothing left to run.
+func (s *Service) schedule(r *Rule) {
+	if r.Status == StatusCompleted {
+		return
+	}
+	next, ok := nextDueDate(*r, dateOf(s.now()))
+	if !ok || (r.MaxOccurrences != nil && r.OccurrencesCount >= *r.MaxOccurrences) {
+		r.NextRunDate = nil
+		r.Status = StatusCompleted
+		return
+	}
+	r.NextRunDate = &next
+}
+
+// applyRequest validates req and copies it onto r.
+func (s *Service) applyRequest(ctx context.Context, r *Rule, req RuleRequest) error {
+	walletID, err := uuid.Parse(req.WalletID)
+	if err != nil {
+		return fmt.Errorf("%w: invalid wallet_id", ErrInvalidRule)
+	}
+	if _, err := s.transactions.GetWallet(ctx, r.UserID, walletID); err != nil {
+		return err
+	}
+	var categoryID *uuid.UUID
+	if req.CategoryID != nil && *req.CategoryID != "" {
+		id, err := uuid.Parse(*req.CategoryID)
+		if err != nil {
+			return fmt.Errorf("%w: invalid category_id", ErrInvalidRule)
+		}
+		categoryID = &id
+	}
+
+	if req.Kind != "in" && req.Kind != "out" {
+		return fmt.Errorf("%w: kind must be in or out", ErrInvalidRule)
+	}
+	if amount, err := strconv.ParseFloat(req.Amount, 64); err != nil || amount <= 0 {
+		return fmt.Errorf("%w: amount must be a positive number", ErrInvalidRule)
+	}
+
+	frequency := strings.ToLower(req.Frequency)
+	switch frequency {
+	case Daily, Weekly, Monthly, Yearly:
+	default:
+		return fmt.Errorf("%w: frequency must be daily, weekly, monthly or yearly", ErrInvalidRule)
+	}
+	interval := req.Interval
+	if interval == 0 {
+		interval = 1
+	}
+	if interval < 0 {
+		return fmt.Errorf("%w: interval must be positive", ErrInvalidRule)
+	}
+	if req.DayOfMonth != nil {
+		if frequency != Monthly && frequency != Yearly {
+			return fmt.Errorf("%w: day_of_month only applies to monthly and yearly rules", ErrInvalidRule)
+		}
+		if *req.DayOfMonth < 1 || *req.DayOfMonth > 31 {
+			return fmt.Errorf("%w: day_of_month must be between 1 and 31", ErrInvalidRule)
+		}
+	}
+
+	start, err := time.Parse(dateLayout, req.StartDate)
+	if err != nil {
+		return fmt.Errorf("%w: start_date must be YYYY-MM-DD", ErrInvalidRule)
+	}
+	var end *time.Time
+	if req.EndDate != nil && *req.EndDate != "" {
+		e, err := time.Parse(dateLayout, *req.EndDate)
+		if err != nil {
+			return fmt.Errorf("%w: end_date must be YYYY-MM-DD", ErrInvalidRule)
+		}
+		if e.Before(start) {
+			return fmt.Errorf("%w: end_date is before start_date", ErrInvalidRule)
+		}
+		end = &e
+	}
+	if req.MaxOccurrences != nil && *req.MaxOccurrences < 1 {
+		return fmt.Errorf("%w: max_occurrences must be positive", ErrInvalidRule)
+	}
+
+	r.WalletID = walletID
+	r.CategoryID = categoryID
+	r.Amount = req.Amount
+	r.Kind = req.Kind
+	r.Note = req.Note
+	r.Frequency = frequency
+	r.Interval = interval
+	r.DayOfMonth = req.DayOfMonth
+	r.StartDate = start
+	r.EndDate = end
+	r.MaxOccurrences = req.MaxOccurrences
+	return nil
+}
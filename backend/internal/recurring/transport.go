@@ -0,0 +1,229 @@
+package recurring
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"github.com/Jomesi149/Implementasi-LASTI/backend/internal/transaction"
+	"github.com/Jomesi149/Implementasi-LASTI/backend/pkg/response"
+)
+
+type HTTPHandler struct {
+	service *Service
+}
+
+func NewHTTPHandler(s *Service) *HTTPHandler {
+	return &HTTPHandler{service: s}
+}
+
+func (h *HTTPHandler) RegisterRoutes(r chi.Router) {
+	r.Route("/recurring", func(r chi.Router) {
+		r.Post("/", h.handleCreateRule)
+		r.Get("/", h.handleListRules)
+		r.Get("/{id}", h.handleGetRule)
+		r.Put("/{id}", h.handleUpdateRule)
+		r.Delete("/{id}", h.handleDeleteRule)
+		r.Post("/{id}/pause", h.handlePauseRule)
+		r.Post("/{id}/resume", h.handleResumeRule)
+		r.Post("/{id}/skip", h.handleSkipOccurrence)
+		r.Get("/{id}/upcoming", h.handleUpcoming)
+	})
+}
+
+func getUserIDFromHeader(r *http.Request) (uuid.UUID, error) {
+	s := r.Header.Get("X-User-ID")
+	return uuid.Parse(s)
+}
+
+// errorStatus maps service sentinel errors onto HTTP status codes.
+func errorStatus(err error) int {
+	switch {
+	case errors.Is(err, ErrRuleNotFound), errors.Is(err, transaction.ErrWalletNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, ErrInvalidRule):
+		return http.StatusBadRequest
+	case errors.Is(err, ErrRuleState):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// ruleParams reads the user id header and the {id} URL parameter.
+func ruleParams(w http.ResponseWriter, r *http.Request) (uuid.UUID, uuid.UUID, bool) {
+	uid, err := getUserIDFromHeader(r)
+	if err != nil {
+		response.Error(w, http.StatusUnauthorized, "missing X-User-ID header")
+		return uuid.Nil, uuid.Nil, false
+	}
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "invalid rule id")
+		return uuid.Nil, uuid.Nil, false
+	}
+	return uid, id, true
+}
+
+func (h *HTTPHandler) handleCreateRule(w http.ResponseWriter, r *http.Request) {
+	uid, err := getUserIDFromHeader(r)
+	if err != nil {
+		response.Error(w, http.StatusUnauthorized, "missing X-User-ID header")
+		return
+	}
+	var req RuleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.Error(w, http.StatusBadRequest, "invalid payload")
+		return
+	}
+	rule, err := h.service.CreateRule(r.Context(), uid, req)
+	if err != nil {
+		response.Error(w, errorStatus(err), err.Error())
+		return
+	}
+	response.JSON(w, http.StatusCreated, rule)
+}
+
+func (h *HTTPHandler) handleListRules(w http.ResponseWriter, r *http.Request) {
+	uid, err := getUserIDFromHeader(r)
+	if err != nil {
+		response.Error(w, http.StatusUnauthorized, "missing X-User-ID header")
+		return
+	}
+	rules, err := h.service.ListRules(r.Context(), uid)
+	if err != nil {
+		response.Error(w, errorStatus(err), err.Error())
+		return
+	}
+	response.JSON(w, http.StatusOK, rules)
+}
+
+func (h *HTTPHandler) handleGetRule(w http.ResponseWriter, r *http.Request) {
+	uid, id, ok := ruleParams(w, r)
+	if !ok {
+		return
+	}
+	detail, err := h.service.GetRule(r.Context(), uid, id)
+	if err != nil {
+		response.Error(w, errorStatus(err), err.Error())
+		return
+	}
+	response.JSON(w, http.StatusOK, detail)
+}
+
+func (h *HTTPHandler) handleUpdateRule(w http.ResponseWriter, r *http.Request) {
+	uid, id, ok := ruleParams(w, r)
+	if !ok {
+		return
+	}
+	var req RuleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.Error(w, http.StatusBadRequest, "invalid payload")
+		return
+	}
+	rule, err := h.service.UpdateRule(r.Context(), uid, id, req)
+	if err != nil {
+		response.Error(w, errorStatus(err), err.Error())
+		return
+	}
+	response.JSON(w, http.StatusOK, rule)
+}
+
+func (h *HTTPHandler) handleDeleteRule(w http.ResponseWriter, r *http.Request) {
+	uid, id, ok := ruleParams(w, r)
+	if !ok {
+		return
+	}
+	if err := h.service.DeleteRule(r.Context(), uid, id); err != nil {
+		response.Error(w, errorStatus(err), err.Error())
+		return
+	}
+	response.JSON(w, http.StatusOK, map[string]string{"status": "recurring rule deleted"})
+}
+
+func (h *HTTPHandler) handlePauseRule(w http.ResponseWriter, r *http.Request) {
+	uid, id, ok := ruleParams(w, r)
+	if !ok {
+		return
+	}
+	rule, err := h.service.PauseRule(r.Context(), uid, id)
+	if err != nil {
+		response.Error(w, errorStatus(err), err.Error())
+		return
+	}
+	response.JSON(w, http.StatusOK, rule)
+}
+
+func (h *HTTPHandler) handleResumeRule(w http.ResponseWriter, r *http.Request) {
+	uid, id, ok := ruleParams(w, r)
+	if !ok {
+		return
+	}
+	rule, err := h.service.ResumeRule(r.Context(), uid, id)
+	if err != nil {
+		response.Error(w, errorStatus(err), err.Error())
+		return
+	}
+	response.JSON(w, http.StatusOK, rule)
+}
+
+func (h *HTTPHandler) handleSkipOccurrence(w http.ResponseWriter, r *http.Request) {
+	uid, id, ok := ruleParams(w, r)
+	if !ok {
+		return
+	}
+	// Body boleh kosong: berarti lewati jadwal berikutnya
+	var req SkipRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			response.Error(w, http.StatusBadRequest, "invalid payload")
+			return
+		}
+	}
+	var date *time.Time
+	if req.Date != "" {
+		d, err := time.Parse(dateLayout, req.Date)
+		if err != nil {
+			response.Error(w, http.StatusBadRequest, "date must be YYYY-MM-DD")
+			return
+		}
+		date = &d
+	}
+	rule, err := h.service.SkipOccurrence(r.Context(), uid, id, date)
+	if err != nil {
+		response.Error(w, errorStatus(err), err.Error())
+		return
+	}
+	response.JSON(w, http.StatusOK, rule)
+}
+
+func (h *HTTPHandler) handleUpcoming(w http.ResponseWriter, r *http.Request) {
+	uid, id, ok := ruleParams(w, r)
+	if !ok {
+		return
+	}
+	count := 10
+	if v := r.URL.Query().Get("count"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			response.Error(w, http.StatusBadRequest, "invalid count")
+			return
+		}
+		count = n
+	}
+	dates, err := h.service.UpcomingDates(r.Context(), uid, id, count)
+	if err != nil {
+		response.Error(w, errorStatus(err), err.Error())
+		return
+	}
+	out := make([]string, len(dates))
+	for i, d := range dates {
+		out[i] = d.Format(dateLayout)
+	}
+	response.JSON(w, http.StatusOK, map[string][]string{"dates": out})
+}
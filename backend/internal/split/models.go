@@ -0,0 +1,134 @@
+package split
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Group is a set of users sharing costs in one currency. Balance of each member is only
+// filled by GetGroup.
+type Group struct {
+	ID        uuid.UUID `json:"id"`
+	Name      string    `json:"name"`
+	Currency  string    `json:"currency"`
+	CreatedBy uuid.UUID `json:"created_by"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	Members   []Member  `json:"members,omitempty"`
+}
+
+// Member is a user in a group. WalletID is the member's own wallet, in the group
+// currency, where their group expenses and settlements are recorded; nil records nothing.
+type Member struct {
+	UserID   uuid.UUID  `json:"user_id"`
+	Email    string     `json:"email"`
+	WalletID *uuid.UUID `json:"wallet_id,omitempty"`
+	Balance  string     `json:"balance,omitempty"`
+	JoinedAt time.Time  `json:"joined_at"`
+}
+
+// Expense is a cost paid by one member and shared between some of them.
+type Expense struct {
+	ID            uuid.UUID  `json:"id"`
+	GroupID       uuid.UUID  `json:"group_id"`
+	Description   string     `json:"description"`
+	Amount        string     `json:"amount"`
+	PaidBy        uuid.UUID  `json:"paid_by"`
+	Method        string     `json:"method"`
+	OccurredOn    time.Time  `json:"occurred_on"`
+	TransactionID *uuid.UUID `json:"transaction_id,omitempty"`
+	CreatedBy     uuid.UUID  `json:"created_by"`
+	CreatedAt     time.Time  `json:"created_at"`
+	Shares        []Share    `json:"shares"`
+}
+
+// Share is what one member owes of an expense. Percent is set for percentage splits.
+type Share struct {
+	UserID  uuid.UUID `json:"user_id"`
+	Amount  string    `json:"amount"`
+	Percent *string   `json:"percent,omitempty"`
+}
+
+// Settlement is a payment from one member to another that pays off what they owe.
+type Settlement struct {
+	ID                uuid.UUID  `json:"id"`
+	GroupID           uuid.UUID  `json:"group_id"`
+	FromUserID        uuid.UUID  `json:"from_user_id"`
+	ToUserID          uuid.UUID  `json:"to_user_id"`
+	Amount            string     `json:"amount"`
+	OccurredOn        time.Time  `json:"occurred_on"`
+	FromTransactionID *uuid.UUID `json:"from_transaction_id,omitempty"`
+	ToTransactionID   *uuid.UUID `json:"to_transaction_id,omitempty"`
+	CreatedBy         uuid.UUID  `json:"created_by"`
+	CreatedAt         time.Time  `json:"created_at"`
+}
+
+// Payment is an amount one member owes, or should pay, another.
+type Payment struct {
+	FromUserID uuid.UUID `json:"from_user_id"`
+	ToUserID   uuid.UUID `json:"to_user_id"`
+	Amount     string    `json:"amount"`
+}
+
+// MemberBalance sums a member's part in a group. Balance is Paid - Share + Sent -
+// Received; positive means the others owe the member.
+type MemberBalance struct {
+	UserID   uuid.UUID `json:"user_id"`
+	Email    string    `json:"email"`
+	Paid     string    `json:"paid"`
+	Share    string    `json:"share"`
+	Sent     string    `json:"sent"`
+	Received string    `json:"received"`
+	Balance  string    `json:"balance"`
+}
+
+// Balances is who owes whom in a group: Debts are the netted debts between each pair of
+// members, as they arose from the expenses.
+type Balances struct {
+	Currency string          `json:"currency"`
+	Members  []MemberBalance `json:"members"`
+	Debts    []Payment       `json:"debts"`
+}
+
+type GroupRequest struct {
+	Name         string   `json:"name"`
+	Currency     string   `json:"currency"`
+	MemberEmails []string `json:"member_emails"`
+}
+
+type MemberRequest struct {
+	Email string `json:"email"`
+}
+
+// WalletRequest sets the caller's group wallet; null stops recording transactions.
+type WalletRequest struct {
+	WalletID *string `json:"wallet_id"`
+}
+
+// ExpenseRequest creates an expense. PaidBy defaults to the caller. For the equal method
+// Shares only lists the members taking part (all members when empty); percent needs a
+// percent and exact an amount for every line.
+type ExpenseRequest struct {
+	Description string         `json:"description"`
+	Amount      string         `json:"amount"`
+	PaidBy      *string        `json:"paid_by"`
+	Method      string         `json:"method"`
+	OccurredOn  *string        `json:"occurred_on"`
+	Shares      []ShareRequest `json:"shares"`
+}
+
+type ShareRequest struct {
+	UserID  string  `json:"user_id"`
+	Percent *string `json:"percent"`
+	Amount  *string `json:"amount"`
+}
+
+// SettlementRequest records a payment. FromUserID defaults to the caller, who must be
+// one of the two sides.
+type SettlementRequest struct {
+	FromUserID *string `json:"from_user_id"`
+	ToUserID   string  `json:"to_user_id"`
+	Amount     string  `json:"amount"`
+	OccurredOn *string `json:"occurred_on"`
+}
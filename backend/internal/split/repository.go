@@ -0,0 +1,419 @@
+package split
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+
+	"github.com/Jomesi149/Implementasi-LASTI/backend/internal/sqlutil"
+)
+
+// Repository persists groups, expenses and settlements. Every query is limited to groups
+// the acting user is a member of.
+type Repository interface {
+	CreateGroup(ctx context.Context, g Group, memberIDs []uuid.UUID) error
+	ListGroups(ctx context.Context, userID uuid.UUID) ([]Group, error)
+	GetGroup(ctx context.Context, userID, id uuid.UUID) (*Group, error)
+	AddMember(ctx context.Context, userID, groupID, memberID uuid.UUID) error
+	RemoveMember(ctx context.Context, userID, groupID, memberID uuid.UUID) error
+	SetMemberWallet(ctx context.Context, userID, groupID uuid.UUID, walletID *uuid.UUID) error
+	UserIDByEmail(ctx context.Context, email string) (uuid.UUID, bool, error)
+
+	CreateExpense(ctx context.Context, userID uuid.UUID, e Expense) error
+	ListExpenses(ctx context.Context, userID, groupID uuid.UUID) ([]Expense, error)
+	DeleteExpense(ctx context.Context, userID, groupID, id uuid.UUID) (*Expense, error)
+
+	CreateSettlement(ctx context.Context, userID uuid.UUID, s Settlement) error
+	ListSettlements(ctx context.Context, userID, groupID uuid.UUID) ([]Settlement, error)
+	DeleteSettlement(ctx context.Context, userID, groupID, id uuid.UUID) (*Settlement, error)
+}
+
+type SQLRepository struct {
+	db *sql.DB
+}
+
+func NewRepository(db *sql.DB) *SQLRepository {
+	return &SQLRepository{db: db}
+}
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+// isMember is true when user $2 belongs to group $1.
+const isMember = `EXISTS (SELECT 1 FROM finance.split_group_members gm WHERE gm.group_id = $1 AND gm.user_id = $2)`
+
+// CreateGroup stores g with its members; memberIDs includes the creator.
+func (r *SQLRepository) CreateGroup(ctx context.Context, g Group, memberIDs []uuid.UUID) (err error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	if _, err = tx.ExecContext(ctx, `INSERT INTO finance.split_groups (id, name, currency, created_by, created_at, updated_at)
+		VALUES ($1,$2,$3,$4,$5,$5)`, g.ID, g.Name, g.Currency, g.CreatedBy, g.CreatedAt); err != nil {
+		return fmt.Errorf("insert group: %w", err)
+	}
+	for _, id := range memberIDs {
+		if _, err = tx.ExecContext(ctx, `INSERT INTO finance.split_group_members (group_id, user_id, created_at)
+			VALUES ($1,$2,$3) ON CONFLICT DO NOTHING`, g.ID, id, g.CreatedAt); err != nil {
+			return fmt.Errorf("insert group member: %w", err)
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("commit tx: %w", err)
+	}
+	return nil
+}
+
+const groupColumns = `g.id, g.name, g.currency, g.created_by, g.created_at, g.updated_at`
+
+func scanGroup(row rowScanner) (Group, error) {
+	var g Group
+	err := row.Scan(&g.ID, &g.Name, &g.Currency, &g.CreatedBy, &g.CreatedAt, &g.UpdatedAt)
+	return g, err
+}
+
+func (r *SQLRepository) ListGroups(ctx context.Context, userID uuid.UUID) ([]Group, error) {
+	query := fmt.Sprintf(`SELECT %s FROM finance.split_groups g
+		JOIN finance.split_group_members m ON m.group_id = g.id AND m.user_id = $1
+		ORDER BY g.updated_at DESC, g.id ASC`, groupColumns)
+	rows, err := r.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("select groups: %w", err)
+	}
+	defer rows.Close()
+
+	out := []Group{}
+	for rows.Next() {
+		g, err := scanGroup(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, g)
+	}
+	return out, rows.Err()
+}
+
+// GetGroup returns a group userID belongs to with its members.
+func (r *SQLRepository) GetGroup(ctx context.Context, userID, id uuid.UUID) (*Group, error) {
+	query := fmt.Sprintf(`SELECT %s FROM finance.split_groups g WHERE g.id = $1 AND %s`, groupColumns, isMember)
+	g, err := scanGroup(r.db.QueryRowContext(ctx, query, id, userID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrGroupNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("select group: %w", err)
+	}
+
+	rows, err := r.db.QueryContext(ctx, `SELECT m.user_id, COALESCE(u.email, ''), m.wallet_id, m.created_at
+		FROM finance.split_group_members m
+		LEFT JOIN identity.users u ON u.id = m.user_id
+		WHERE m.group_id = $1
+		ORDER BY m.created_at ASC, m.user_id ASC`, id)
+	if err != nil {
+		return nil, fmt.Errorf("select group members: %w", err)
+	}
+	defer rows.Close()
+
+	g.Members = []Member{}
+	for rows.Next() {
+		var m Member
+		var walletID uuid.NullUUID
+		if err := rows.Scan(&m.UserID, &m.Email, &walletID, &m.JoinedAt); err != nil {
+			return nil, err
+		}
+		if walletID.Valid {
+			id := walletID.UUID
+			m.WalletID = &id
+		}
+		g.Members = append(g.Members, m)
+	}
+	return &g, rows.Err()
+}
+
+// AddMember adds memberID to a group userID belongs to.
+func (r *SQLRepository) AddMember(ctx context.Context, userID, groupID, memberID uuid.UUID) error {
+	res, err := r.db.ExecContext(ctx, fmt.Sprintf(`INSERT INTO finance.split_group_members (group_id, user_id, created_at)
+		SELECT $1::UUID, $3::UUID, NOW() WHERE %s
+		ON CONFLICT DO NOTHING`, isMember), groupID, userID, memberID)
+	if err != nil {
+		return fmt.Errorf("insert group member: %w", err)
+	}
+	return sqlutil.ExpectAffected(res, ErrGroupNotFound)
+}
+
+func (r *SQLRepository) RemoveMember(ctx context.Context, userID, groupID, memberID uuid.UUID) error {
+	// Anggota boleh keluar sendiri; hanya pembuat grup yang mengeluarkan anggota lain
+	res, err := r.db.ExecContext(ctx, fmt.Sprintf(`DELETE FROM finance.split_group_members
+		WHERE group_id = $1 AND user_id = $3 AND %s
+			AND ($3 = $2 OR EXISTS (SELECT 1 FROM finance.split_groups g WHERE g.id = $1 AND g.created_by = $2))`, isMember),
+		groupID, userID, memberID)
+	if err != nil {
+		return fmt.Errorf("delete group member: %w", err)
+	}
+	return sqlutil.ExpectAffected(res, ErrMemberNotFound)
+}
+
+// SetMemberWallet sets the group wallet of userID; it must be their own live wallet in
+// the group currency.
+func (r *SQLRepository) SetMemberWallet(ctx context.Context, userID, groupID uuid.UUID, walletID *uuid.UUID) error {
+	res, err := r.db.ExecContext(ctx, `UPDATE finance.split_group_members m SET wallet_id = $3
+		WHERE m.group_id = $1 AND m.user_id = $2 AND ($3::UUID IS NULL OR EXISTS (
+			SELECT 1 FROM finance.wallets w JOIN finance.split_groups g ON g.id = m.group_id
+			WHERE w.id = $3 AND w.user_id = $2 AND w.deleted_at IS NULL AND w.currency = g.currency))`,
+		groupID, userID, walletID)
+	if err != nil {
+		return fmt.Errorf("update group wallet: %w", err)
+	}
+	return sqlutil.ExpectAffected(res, fmt.Errorf("%w: the wallet must be yours and in the group currency", ErrInvalidSplit))
+}
+
+// UserIDByEmail looks up a registered user; the bool is false when there is none.
+func (r *SQLRepository) UserIDByEmail(ctx context.Context, email string) (uuid.UUID, bool, error) {
+	var id uuid.UUID
+	err := r.db.QueryRowContext(ctx, `SELECT id FROM identity.users WHERE LOWER(email) = LOWER($1)`, email).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return uuid.Nil, false, nil
+	}
+	if err != nil {
+		return uuid.Nil, false, fmt.Errorf("select user: %w", err)
+	}
+	return id, true, nil
+}
+
+// touchGroup marks a group as changed so that active groups list first.
+func touchGroup(ctx context.Context, tx *sql.Tx, groupID uuid.UUID) error {
+	if _, err := tx.ExecContext(ctx, `UPDATE finance.split_groups SET updated_at = NOW() WHERE id = $1`, groupID); err != nil {
+		return fmt.Errorf("touch group: %w", err)
+	}
+	return nil
+}
+
+// CreateExpense stores e with its shares if userID belongs to the group.
+func (r *SQLRepository) CreateExpense(ctx context.Context, userID uuid.UUID, e Expense) (err error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	res, err := tx.ExecContext(ctx, fmt.Sprintf(`INSERT INTO finance.split_expenses
+			(id, group_id, description, amount, paid_by, method, occurred_on, transaction_id, created_by, created_at)
+		SELECT $3::UUID, $1::UUID, $4::TEXT, $5::NUMERIC, $6::UUID, $7::TEXT, $8::DATE, $9::UUID, $2::UUID, $10::TIMESTAMPTZ
+		WHERE %s`, isMember),
+		e.GroupID, userID, e.ID, e.Description, e.Amount, e.PaidBy, e.Method, e.OccurredOn.Format(dateLayout), e.TransactionID, e.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("insert expense: %w", err)
+	}
+	if err = sqlutil.ExpectAffected(res, ErrGroupNotFound); err != nil {
+		return err
+	}
+	for _, s := range e.Shares {
+		if _, err = tx.ExecContext(ctx, `INSERT INTO finance.split_shares (expense_id, user_id, amount, percent)
+			VALUES ($1,$2,$3::NUMERIC,$4::NUMERIC)`, e.ID, s.UserID, s.Amount, s.Percent); err != nil {
+			return fmt.Errorf("insert share: %w", err)
+		}
+	}
+	if err = touchGroup(ctx, tx, e.GroupID); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("commit tx: %w", err)
+	}
+	return nil
+}
+
+const expenseColumns = `e.id, e.group_id, e.description, e.amount::TEXT, e.paid_by, e.method, e.occurred_on, e.transaction_id,
+	e.created_by, e.created_at`
+
+func scanExpense(row rowScanner) (Expense, error) {
+	var e Expense
+	var transactionID uuid.NullUUID
+	if err := row.Scan(&e.ID, &e.GroupID, &e.Description, &e.Amount, &e.PaidBy, &e.Method, &e.OccurredOn, &transactionID,
+		&e.CreatedBy, &e.CreatedAt); err != nil {
+		return e, err
+	}
+	if transactionID.Valid {
+		id := transactionID.UUID
+		e.TransactionID = &id
+	}
+	e.Shares = []Share{}
+	return e, nil
+}
+
+// ListExpenses returns the expenses of a group with their shares, newest first.
+func (r *SQLRepository) ListExpenses(ctx context.Context, userID, groupID uuid.UUID) ([]Expense, error) {
+	query := fmt.Sprintf(`SELECT %s FROM finance.split_expenses e WHERE e.group_id = $1 AND %s
+		ORDER BY e.occurred_on DESC, e.created_at DESC`, expenseColumns, isMember)
+	rows, err := r.db.QueryContext(ctx, query, groupID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("select expenses: %w", err)
+	}
+	defer rows.Close()
+
+	out := []Expense{}
+	index := map[uuid.UUID]int{}
+	for rows.Next() {
+		e, err := scanExpense(rows)
+		if err != nil {
+			return nil, err
+		}
+		index[e.ID] = len(out)
+		out = append(out, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(out) == 0 {
+		return out, nil
+	}
+
+	shares, err := r.db.QueryContext(ctx, fmt.Sprintf(`SELECT s.expense_id, s.user_id, s.amount::TEXT, s.percent::TEXT
+		FROM finance.split_shares s JOIN finance.split_expenses e ON e.id = s.expense_id
+		WHERE e.group_id = $1 AND %s
+		ORDER BY s.amount DESC, s.user_id ASC`, isMember), groupID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("select shares: %w", err)
+	}
+	defer shares.Close()
+	for shares.Next() {
+		var expenseID uuid.UUID
+		var s Share
+		var percent sql.NullString
+		if err := shares.Scan(&expenseID, &s.UserID, &s.Amount, &percent); err != nil {
+			return nil, err
+		}
+		if percent.Valid {
+			p := percent.String
+			s.Percent = &p
+		}
+		if i, ok := index[expenseID]; ok {
+			out[i].Shares = append(out[i].Shares, s)
+		}
+	}
+	return out, shares.Err()
+}
+
+// DeleteExpense removes an expense userID created or paid and returns it, so its
+// transaction can be removed too.
+func (r *SQLRepository) DeleteExpense(ctx context.Context, userID, groupID, id uuid.UUID) (*Expense, error) {
+	query := fmt.Sprintf(`DELETE FROM finance.split_expenses e WHERE e.group_id = $1 AND e.id = $3 AND %s
+			AND $2 IN (e.created_by, e.paid_by)
+		RETURNING %s`, isMember, expenseColumns)
+	e, err := scanExpense(r.db.QueryRowContext(ctx, query, groupID, userID, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrExpenseNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("delete expense: %w", err)
+	}
+	return &e, nil
+}
+
+// CreateSettlement stores s if userID belongs to the group.
+func (r *SQLRepository) CreateSettlement(ctx context.Context, userID uuid.UUID, s Settlement) (err error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	res, err := tx.ExecContext(ctx, fmt.Sprintf(`INSERT INTO finance.split_settlements
+			(id, group_id, from_user, to_user, amount, occurred_on, from_transaction_id, to_transaction_id, created_by, created_at)
+		SELECT $3::UUID, $1::UUID, $4::UUID, $5::UUID, $6::NUMERIC, $7::DATE, $8::UUID, $9::UUID, $2::UUID, $10::TIMESTAMPTZ
+		WHERE %s`, isMember),
+		s.GroupID, userID, s.ID, s.FromUserID, s.ToUserID, s.Amount, s.OccurredOn.Format(dateLayout), s.FromTransactionID, s.ToTransactionID, s.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("insert settlement: %w", err)
+	}
+	if err = sqlutil.ExpectAffected(res, ErrGroupNotFound); err != nil {
+		return err
+	}
+	if err = touchGroup(ctx, tx, s.GroupID); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("commit tx: %w", err)
+	}
+	return nil
+}
+
+const settlementColumns = `s.id, s.group_id, s.from_user, s.to_user, s.amount::TEXT, s.occurred_on, s.from_transaction_id,
+	s.to_transaction_id, s.created_by, s.created_at`
+
+func scanSettlement(row rowScanner) (Settlement, error) {
+	var s Settlement
+	var fromTx, toTx uuid.NullUUID
+	if err := row.Scan(&s.ID, &s.GroupID, &s.FromUserID, &s.ToUserID, &s.Amount, &s.OccurredOn, &fromTx, &toTx,
+		&s.CreatedBy, &s.CreatedAt); err != nil {
+		return s, err
+	}
+	if fromTx.Valid {
+		id := fromTx.UUID
+		s.FromTransactionID = &id
+	}
+	if toTx.Valid {
+		id := toTx.UUID
+		s.ToTransactionID = &id
+	}
+	return s, nil
+}
+
+func (r *SQLRepository) ListSettlements(ctx context.Context, userID, groupID uuid.UUID) ([]Settlement, error) {
+	query := fmt.Sprintf(`SELECT %s FROM finance.split_settlements s WHERE s.group_id = $1 AND %s
+		ORDER BY s.occurred_on DESC, s.created_at DESC`, settlementColumns, isMember)
+	rows, err := r.db.QueryContext(ctx, query, groupID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("select settlements: %w", err)
+	}
+	defer rows.Close()
+
+	out := []Settlement{}
+	for rows.Next() {
+		s, err := scanSettlement(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, s)
+	}
+	return out, rows.Err()
+}
+
+// DeleteSettlement removes a settlement userID created or took part in and returns it,
+// so its transactions can be removed too.
+func (r *SQLRepository) DeleteSettlement(ctx context.Context, userID, groupID, id uuid.UUID) (*Settlement, error) {
+	query := fmt.Sprintf(`DELETE FROM finance.split_settlements s WHERE s.group_id = $1 AND s.id = $3 AND %s
+			AND $2 IN (s.created_by, s.from_user, s.to_user)
+		RETURNING %s`, isMember, settlementColumns)
+	s, err := scanSettlement(r.db.QueryRowContext(ctx, query, groupID, userID, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrSettlementNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("delete settlement: %w", err)
+	}
+	return &s, nil
+}
@@ -0,0 +1,682 @@
+package split
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/mail"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/Jomesi149/Implementasi-LASTI/backend/internal/currency"
+	"github.com/Jomesi149/Implementasi-LASTI/backend/internal/sqlutil"
+	"github.com/Jomesi149/Implementasi-LASTI/backend/internal/transaction"
+)
+
+var (
+	// ErrGroupNotFound is returned when the group does not exist or the user is not a
+	// member of it.
+	ErrGroupNotFound = errors.New("split_group_not_found")
+	// ErrMemberNotFound is returned when the user is not a member of the group.
+	ErrMemberNotFound = errors.New("split_member_not_found")
+	// ErrExpenseNotFound is returned when the expense does not exist in the group.
+	ErrExpenseNotFound = errors.New("split_expense_not_found")
+	// ErrSettlementNotFound is returned when the settlement does not exist in the group.
+	ErrSettlementNotFound = errors.New("split_settlement_not_found")
+	// ErrInvalidSplit indicates a bad group, expense, share or settlement.
+	ErrInvalidSplit = errors.New("invalid_split")
+	// ErrAlreadyMember is returned when the user is already in the group.
+	ErrAlreadyMember = errors.New("split_already_member")
+	// ErrUnsettled is returned when a member with a non-zero balance leaves the group.
+	ErrUnsettled = errors.New("split_balance_not_settled")
+	// ErrForbidden is returned when a member changes what only another member may.
+	ErrForbidden = errors.New("split_forbidden")
+)
+
+// Split methods.
+const (
+	MethodEqual   = "equal"
+	MethodPercent = "percent"
+	MethodExact   = "exact"
+)
+
+const (
+	// SourceSplit marks the transactions recorded for group expenses and settlements.
+	SourceSplit    = "split"
+	dateLayout     = "2006-01-02"
+	maxGroupName   = 100
+	maxDescription = 200
+)
+
+type ServiceDeps struct {
+	Repo         Repository
+	Transactions *transaction.Service
+	// Currencies supplies the base currency new groups default to.
+	Currencies *currency.Service
+}
+
+type Service struct {
+	repo         Repository
+	transactions *transaction.Service
+	currencies   *currency.Service
+	now          func() time.Time
+}
+
+func NewService(deps ServiceDeps) *Service {
+	return &Service{repo: deps.Repo, transactions: deps.Transactions, currencies: deps.Currencies, now: time.Now}
+}
+
+// today is the current calendar date as UTC midnight.
+func (s *Service) today() time.Time {
+	now := s.now()
+	return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// parseDay reads an optional YYYY-MM-DD date, defaulting to today.
+func (s *Service) parseDay(v *string) (time.Time, error) {
+	if v == nil || *v == "" {
+		return s.today(), nil
+	}
+	d, err := time.Parse(dateLayout, *v)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%w: dates must be YYYY-MM-DD", ErrInvalidSplit)
+	}
+	return d, nil
+}
+
+// userByEmail resolves a registered user by email.
+func (s *Service) userByEmail(ctx context.Context, email string) (uuid.UUID, error) {
+	addr, err := mail.ParseAddress(strings.TrimSpace(email))
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("%w: invalid email %q", ErrInvalidSplit, email)
+	}
+	id, ok, err := s.repo.UserIDByEmail(ctx, addr.Address)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	if !ok {
+		return uuid.Nil, fmt.Errorf("%w: no user with email %s", ErrInvalidSplit, addr.Address)
+	}
+	return id, nil
+}
+
+// CreateGroup creates a group of the caller and the registered users in MemberEmails.
+// The currency defaults to the caller's base currency.
+func (s *Service) CreateGroup(ctx context.Context, userID uuid.UUID, req GroupRequest) (*Group, error) {
+	name := strings.TrimSpace(req.Name)
+	if name == "" || len(name) > maxGroupName {
+		return nil, fmt.Errorf("%w: name must be 1-%d characters", ErrInvalidSplit, maxGroupName)
+	}
+	code := req.Currency
+	if strings.TrimSpace(code) == "" {
+		base, err := s.currencies.BaseCurrency(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+		code = base
+	}
+	code, err := currency.Normalize(code)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSplit, err)
+	}
+
+	memberIDs := []uuid.UUID{userID}
+	seen := map[uuid.UUID]bool{userID: true}
+	for _, email := range req.MemberEmails {
+		id, err := s.userByEmail(ctx, email)
+		if err != nil {
+			return nil, err
+		}
+		if !seen[id] {
+			seen[id] = true
+			memberIDs = append(memberIDs, id)
+		}
+	}
+
+	now := s.now()
+	g := Group{ID: uuid.New(), Name: name, Currency: code, CreatedBy: userID, CreatedAt: now, UpdatedAt: now}
+	if err := s.repo.CreateGroup(ctx, g, memberIDs); err != nil {
+		return nil, err
+	}
+	return s.GetGroup(ctx, userID, g.ID)
+}
+
+func (s *Service) ListGroups(ctx context.Context, userID uuid.UUID) ([]Group, error) {
+	return s.repo.ListGroups(ctx, userID)
+}
+
+// GetGroup returns a group with its members and their balances.
+func (s *Service) GetGroup(ctx context.Context, userID, id uuid.UUID) (*Group, error) {
+	g, b, err := s.balances(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+	for i := range g.Members {
+		g.Members[i].Balance = sqlutil.CentsString(b.net[g.Members[i].UserID])
+	}
+	return g, nil
+}
+
+// AddMember adds the registered user with email to the group.
+func (s *Service) AddMember(ctx context.Context, userID, groupID uuid.UUID, req MemberRequest) (*Group, error) {
+	g, err := s.repo.GetGroup(ctx, userID, groupID)
+	if err != nil {
+		return nil, err
+	}
+	memberID, err := s.userByEmail(ctx, req.Email)
+	if err != nil {
+		return nil, err
+	}
+	if findMember(g, memberID) != nil {
+		return nil, ErrAlreadyMember
+	}
+	if err := s.repo.AddMember(ctx, userID, groupID, memberID); err != nil {
+		return nil, err
+	}
+	return s.GetGroup(ctx, userID, groupID)
+}
+
+// RemoveMember removes a member whose balance is settled. Members can leave; only the
+// group creator removes others.
+func (s *Service) RemoveMember(ctx context.Context, userID, groupID, memberID uuid.UUID) error {
+	g, b, err := s.balances(ctx, userID, groupID)
+	if err != nil {
+		return err
+	}
+	if memberID != userID && g.CreatedBy != userID {
+		return fmt.Errorf("%w: only the group creator can remove other members", ErrForbidden)
+	}
+	if findMember(g, memberID) == nil {
+		return ErrMemberNotFound
+	}
+	if b.net[memberID] != 0 {
+		return fmt.Errorf("%w: the member's balance is %s %s", ErrUnsettled, sqlutil.CentsString(b.net[memberID]), g.Currency)
+	}
+	return s.repo.RemoveMember(ctx, userID, groupID, memberID)
+}
+
+// SetWallet sets the caller's wallet for the group's transactions; nil stops recording
+// them.
+func (s *Service) SetWallet(ctx context.Context, userID, groupID uuid.UUID, walletID *uuid.UUID) (*Group, error) {
+	if _, err := s.repo.GetGroup(ctx, userID, groupID); err != nil {
+		return nil, err
+	}
+	if err := s.repo.SetMemberWallet(ctx, userID, groupID, walletID); err != nil {
+		return nil, err
+	}
+	return s.GetGroup(ctx, userID, groupID)
+}
+
+func findMember(g *Group, userID uuid.UUID) *Member {
+	for i := range g.Members {
+		if g.Members[i].UserID == userID {
+			return &g.Members[i]
+		}
+	}
+	return nil
+}
+
+// memberID parses a user id that must belong to a member of g.
+func memberID(g *Group, s string) (uuid.UUID, error) {
+	id, err := uuid.Parse(s)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("%w: invalid user id %q", ErrInvalidSplit, s)
+	}
+	if findMember(g, id) == nil {
+		return uuid.Nil, fmt.Errorf("%w: user %s is not in the group", ErrInvalidSplit, id)
+	}
+	return id, nil
+}
+
+// CreateExpense splits an expense between members. When the caller paid and has a group
+// wallet the full amount is recorded there as an outgoing transaction; an expense paid
+// by another member never touches their wallet.
+func (s *Service) CreateExpense(ctx context.Context, userID, groupID uuid.UUID, req ExpenseRequest) (*Expense, error) {
+	g, err := s.repo.GetGroup(ctx, userID, groupID)
+	if err != nil {
+		return nil, err
+	}
+	description := strings.TrimSpace(req.Description)
+	if description == "" || len(description) > maxDescription {
+		return nil, fmt.Errorf("%w: description must be 1-%d characters", ErrInvalidSplit, maxDescription)
+	}
+	total, ok := sqlutil.ToCents(req.Amount)
+	if !ok || total <= 0 {
+		return nil, fmt.Errorf("%w: amount must be a positive number", ErrInvalidSplit)
+	}
+	paidBy := userID
+	if req.PaidBy != nil && *req.PaidBy != "" {
+		if paidBy, err = memberID(g, *req.PaidBy); err != nil {
+			return nil, err
+		}
+	}
+	occurredOn, err := s.parseDay(req.OccurredOn)
+	if err != nil {
+		return nil, err
+	}
+	shares, err := allocate(g, total, req.Method, req.Shares)
+	if err != nil {
+		return nil, err
+	}
+
+	e := Expense{ID: uuid.New(), GroupID: groupID, Description: description, Amount: sqlutil.CentsString(total), PaidBy: paidBy,
+		Method: req.Method, OccurredOn: occurredOn, CreatedBy: userID, CreatedAt: s.now(), Shares: shares}
+	ctx = transaction.WithChange(ctx, transaction.Change{Source: SourceSplit, ActorID: &userID, ReferenceID: &e.ID})
+	if wallet := findMember(g, paidBy).WalletID; wallet != nil && paidBy == userID {
+		t, err := s.record(ctx, paidBy, *wallet, e.Amount, "out", fmt.Sprintf("%s: %s", g.Name, description), occurredOn)
+		if err != nil {
+			return nil, err
+		}
+		e.TransactionID = &t.ID
+	}
+	if err := s.repo.CreateExpense(ctx, userID, e); err != nil {
+		s.unrecord(ctx, paidBy, e.TransactionID)
+		return nil, err
+	}
+	return &e, nil
+}
+
+// allocate turns the share lines of a request into amounts in cents that sum to total.
+func allocate(g *Group, total int64, method string, lines []ShareRequest) ([]Share, error) {
+	seen := map[uuid.UUID]bool{}
+	ids := make([]uuid.UUID, 0, len(lines))
+	for _, line := range lines {
+		id, err := memberID(g, line.UserID)
+		if err != nil {
+			return nil, err
+		}
+		if seen[id] {
+			return nil, fmt.Errorf("%w: user %s is listed twice", ErrInvalidSplit, id)
+		}
+		seen[id] = true
+		ids = append(ids, id)
+	}
+
+	switch method {
+	case MethodEqual:
+		if len(ids) == 0 {
+			for _, m := range g.Members {
+				ids = append(ids, m.UserID)
+			}
+		}
+		weights := make([]int64, len(ids))
+		for i := range weights {
+			weights[i] = 1
+		}
+		parts, err := distribute(total, weights)
+		if err != nil {
+			return nil, err
+		}
+		return shareLines(ids, parts, nil), nil
+
+	case MethodPercent:
+		if len(ids) == 0 {
+			return nil, fmt.Errorf("%w: a percentage split needs share lines", ErrInvalidSplit)
+		}
+		// Persentase disimpan dengan dua desimal, jadi dihitung dalam seperseratus persen
+		weights := make([]int64, len(ids))
+		percents := make([]*string, len(ids))
+		var sum int64
+		for i, line := range lines {
+			if line.Percent == nil {
+				return nil, fmt.Errorf("%w: share line %d has no percent", ErrInvalidSplit, i+1)
+			}
+			p, ok := sqlutil.ToCents(*line.Percent)
+			if !ok || p <= 0 {
+				return nil, fmt.Errorf("%w: share line %d needs a positive percent", ErrInvalidSplit, i+1)
+			}
+			weights[i] = p
+			text := sqlutil.CentsString(p)
+			percents[i] = &text
+			sum += p
+		}
+		if sum != 100*100 {
+			return nil, fmt.Errorf("%w: percentages add up to %s, not 100", ErrInvalidSplit, sqlutil.CentsString(sum))
+		}
+		parts, err := distribute(total, weights)
+		if err != nil {
+			return nil, err
+		}
+		return shareLines(ids, parts, percents), nil
+
+	case MethodExact:
+		if len(ids) == 0 {
+			return nil, fmt.Errorf("%w: an exact split needs share lines", ErrInvalidSplit)
+		}
+		amounts := make([]int64, len(ids))
+		var sum int64
+		for i, line := range lines {
+			if line.Amount == nil {
+				return nil, fmt.Errorf("%w: share line %d has no amount", ErrInvalidSplit, i+1)
+			}
+			a, ok := sqlutil.ToCents(*line.Amount)
+			if !ok || a < 0 {
+				return nil, fmt.Errorf("%w: share line %d needs an amount of zero or more", ErrInvalidSplit, i+1)
+			}
+			amounts[i] = a
+			sum += a
+		}
+		if sum != total {
+			return nil, fmt.Errorf("%w: shares add up to %s, not %s", ErrInvalidSplit, sqlutil.CentsString(sum), sqlutil.CentsString(total))
+		}
+		return shareLines(ids, amounts, nil), nil
+
+	default:
+		return nil, fmt.Errorf("%w: method must be equal, percent or exact", ErrInvalidSplit)
+	}
+}
+
+// distribute splits total cents in proportion to weights. Each part is rounded down and
+// the cents left over go to the parts with the largest remainders, earlier parts first.
+// There must be at least one positive weight.
+func distribute(total int64, weights []int64) ([]int64, error) {
+	var sum int64
+	for _, w := range weights {
+		sum += w
+	}
+	if sum <= 0 {
+		return nil, fmt.Errorf("%w: there is nobody to split between", ErrInvalidSplit)
+	}
+	parts := make([]int64, len(weights))
+	remainders := make([]int64, len(weights))
+	left := total
+	for i, w := range weights {
+		parts[i] = total * w / sum
+		remainders[i] = total * w % sum
+		left -= parts[i]
+	}
+	order := make([]int, len(weights))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool { return remainders[order[a]] > remainders[order[b]] })
+	for i := 0; left > 0; i, left = i+1, left-1 {
+		parts[order[i%len(order)]]++
+	}
+	return parts, nil
+}
+
+func shareLines(ids []uuid.UUID, amounts []int64, percents []*string) []Share {
+	out := make([]Share, len(ids))
+	for i, id := range ids {
+		out[i] = Share{UserID: id, Amount: sqlutil.CentsString(amounts[i])}
+		if percents != nil {
+			out[i].Percent = percents[i]
+		}
+	}
+	return out
+}
+
+func (s *Service) ListExpenses(ctx context.Context, userID, groupID uuid.UUID) ([]Expense, error) {
+	if _, err := s.repo.GetGroup(ctx, userID, groupID); err != nil {
+		return nil, err
+	}
+	return s.repo.ListExpenses(ctx, userID, groupID)
+}
+
+// DeleteExpense removes an expense the caller created or paid. Its transaction goes to
+// the trash only when the caller is the payer who owns it.
+func (s *Service) DeleteExpense(ctx context.Context, userID, groupID, id uuid.UUID) error {
+	e, err := s.repo.DeleteExpense(ctx, userID, groupID, id)
+	if err != nil {
+		return err
+	}
+	if e.PaidBy == userID {
+		ctx = transaction.WithChange(ctx, transaction.Change{Source: SourceSplit, ActorID: &userID, ReferenceID: &e.ID})
+		s.unrecord(ctx, e.PaidBy, e.TransactionID)
+	}
+	return nil
+}
+
+// CreateSettlement records a payment between two members, one of them the caller. The
+// caller's side is booked in their group wallet: outgoing for the payer, incoming for
+// the receiver.
+func (s *Service) CreateSettlement(ctx context.Context, userID, groupID uuid.UUID, req SettlementRequest) (*Settlement, error) {
+	g, err := s.repo.GetGroup(ctx, userID, groupID)
+	if err != nil {
+		return nil, err
+	}
+	from := userID
+	if req.FromUserID != nil && *req.FromUserID != "" {
+		if from, err = memberID(g, *req.FromUserID); err != nil {
+			return nil, err
+		}
+	}
+	to, err := memberID(g, req.ToUserID)
+	if err != nil {
+		return nil, err
+	}
+	if from == to {
+		return nil, fmt.Errorf("%w: a member cannot pay themselves", ErrInvalidSplit)
+	}
+	if userID != from && userID != to {
+		return nil, fmt.Errorf("%w: you can only record payments you made or received", ErrInvalidSplit)
+	}
+	amount, ok := sqlutil.ToCents(req.Amount)
+	if !ok || amount <= 0 {
+		return nil, fmt.Errorf("%w: amount must be a positive number", ErrInvalidSplit)
+	}
+	occurredOn, err := s.parseDay(req.OccurredOn)
+	if err != nil {
+		return nil, err
+	}
+
+	st := Settlement{ID: uuid.New(), GroupID: groupID, FromUserID: from, ToUserID: to, Amount: sqlutil.CentsString(amount),
+		OccurredOn: occurredOn, CreatedBy: userID, CreatedAt: s.now()}
+	ctx = transaction.WithChange(ctx, transaction.Change{Source: SourceSplit, ActorID: &userID, ReferenceID: &st.ID})
+	note := fmt.Sprintf("%s: settle-up", g.Name)
+	if wallet := findMember(g, from).WalletID; wallet != nil && from == userID {
+		t, err := s.record(ctx, from, *wallet, st.Amount, "out", note, occurredOn)
+		if err != nil {
+			return nil, err
+		}
+		st.FromTransactionID = &t.ID
+	}
+	if wallet := findMember(g, to).WalletID; wallet != nil && to == userID {
+		t, err := s.record(ctx, to, *wallet, st.Amount, "in", note, occurredOn)
+		if err != nil {
+			s.unrecord(ctx, from, st.FromTransactionID)
+			return nil, err
+		}
+		st.ToTransactionID = &t.ID
+	}
+	if err := s.repo.CreateSettlement(ctx, userID, st); err != nil {
+		s.unrecord(ctx, from, st.FromTransactionID)
+		s.unrecord(ctx, to, st.ToTransactionID)
+		return nil, err
+	}
+	return &st, nil
+}
+
+func (s *Service) ListSettlements(ctx context.Context, userID, groupID uuid.UUID) ([]Settlement, error) {
+	if _, err := s.repo.GetGroup(ctx, userID, groupID); err != nil {
+		return nil, err
+	}
+	return s.repo.ListSettlements(ctx, userID, groupID)
+}
+
+// DeleteSettlement removes a settlement the caller took part in and moves the caller's
+// transaction of it to the trash.
+func (s *Service) DeleteSettlement(ctx context.Context, userID, groupID, id uuid.UUID) error {
+	st, err := s.repo.DeleteSettlement(ctx, userID, groupID, id)
+	if err != nil {
+		return err
+	}
+	ctx = transaction.WithChange(ctx, transaction.Change{Source: SourceSplit, ActorID: &userID, ReferenceID: &st.ID})
+	if st.FromUserID == userID {
+		s.unrecord(ctx, st.FromUserID, st.FromTransactionID)
+	}
+	if st.ToUserID == userID {
+		s.unrecord(ctx, st.ToUserID, st.ToTransactionID)
+	}
+	return nil
+}
+
+// record books a group transaction in a member's wallet.
+func (s *Service) record(ctx context.Context, owner, walletID uuid.UUID, amount, kind, note string, on time.Time) (*transaction.Transaction, error) {
+	return s.transactions.CreateTransaction(ctx, transaction.NewTransaction{UserID: owner, WalletID: walletID,
+		Amount: amount, Kind: kind, Note: &note, OccurredAt: on})
+}
+
+// unrecord moves a group transaction to its owner's trash. The group record is what
+// counts, so a failure is only logged; a transaction the owner already removed is fine.
+func (s *Service) unrecord(ctx context.Context, owner uuid.UUID, transactionID *uuid.UUID) {
+	if transactionID == nil {
+		return
+	}
+	err := s.transactions.DeleteTransaction(ctx, owner, *transactionID)
+	if err != nil && !errors.Is(err, transaction.ErrTransactionNotFound) {
+		log.Printf("[SPLIT] delete transaction %s: %v", *transactionID, err)
+	}
+}
+
+// ledger is the state of a group in cents: net balance per member and what each member
+// owes each other member.
+type ledger struct {
+	net                         map[uuid.UUID]int64
+	paid, share, sent, received map[uuid.UUID]int64
+	owes                        map[[2]uuid.UUID]int64
+}
+
+// balances loads a group with its expenses and settlements and sums them up.
+func (s *Service) balances(ctx context.Context, userID, groupID uuid.UUID) (*Group, *ledger, error) {
+	g, err := s.repo.GetGroup(ctx, userID, groupID)
+	if err != nil {
+		return nil, nil, err
+	}
+	expenses, err := s.repo.ListExpenses(ctx, userID, groupID)
+	if err != nil {
+		return nil, nil, err
+	}
+	settlements, err := s.repo.ListSettlements(ctx, userID, groupID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return g, buildLedger(expenses, settlements), nil
+}
+
+func buildLedger(expenses []Expense, settlements []Settlement) *ledger {
+	l := &ledger{net: map[uuid.UUID]int64{}, paid: map[uuid.UUID]int64{}, share: map[uuid.UUID]int64{},
+		sent: map[uuid.UUID]int64{}, received: map[uuid.UUID]int64{}, owes: map[[2]uuid.UUID]int64{}}
+	for _, e := range expenses {
+		amount, _ := sqlutil.ToCents(e.Amount)
+		l.paid[e.PaidBy] += amount
+		l.net[e.PaidBy] += amount
+		for _, sh := range e.Shares {
+			a, _ := sqlutil.ToCents(sh.Amount)
+			l.share[sh.UserID] += a
+			l.net[sh.UserID] -= a
+			if sh.UserID != e.PaidBy {
+				l.owes[[2]uuid.UUID{sh.UserID, e.PaidBy}] += a
+			}
+		}
+	}
+	for _, st := range settlements {
+		amount, _ := sqlutil.ToCents(st.Amount)
+		l.sent[st.FromUserID] += amount
+		l.received[st.ToUserID] += amount
+		l.net[st.FromUserID] += amount
+		l.net[st.ToUserID] -= amount
+		l.owes[[2]uuid.UUID{st.FromUserID, st.ToUserID}] -= amount
+	}
+	return l
+}
+
+// GetBalances reports each member's balance and who owes whom.
+func (s *Service) GetBalances(ctx context.Context, userID, groupID uuid.UUID) (*Balances, error) {
+	g, l, err := s.balances(ctx, userID, groupID)
+	if err != nil {
+		return nil, err
+	}
+	out := &Balances{Currency: g.Currency, Members: make([]MemberBalance, len(g.Members)), Debts: []Payment{}}
+	for i, m := range g.Members {
+		out.Members[i] = MemberBalance{UserID: m.UserID, Email: m.Email, Paid: sqlutil.CentsString(l.paid[m.UserID]),
+			Share: sqlutil.CentsString(l.share[m.UserID]), Sent: sqlutil.CentsString(l.sent[m.UserID]),
+			Received: sqlutil.CentsString(l.received[m.UserID]), Balance: sqlutil.CentsString(l.net[m.UserID])}
+	}
+
+	// Utang dua arah antara sepasang anggota saling dikurangkan
+	for i, a := range g.Members {
+		for _, b := range g.Members[i+1:] {
+			d := l.owes[[2]uuid.UUID{a.UserID, b.UserID}] - l.owes[[2]uuid.UUID{b.UserID, a.UserID}]
+			switch {
+			case d > 0:
+				out.Debts = append(out.Debts, Payment{FromUserID: a.UserID, ToUserID: b.UserID, Amount: sqlutil.CentsString(d)})
+			case d < 0:
+				out.Debts = append(out.Debts, Payment{FromUserID: b.UserID, ToUserID: a.UserID, Amount: sqlutil.CentsString(-d)})
+			}
+		}
+	}
+	return out, nil
+}
+
+// SettleUp suggests the payments that bring every balance to zero with as few transfers
+// as possible.
+func (s *Service) SettleUp(ctx context.Context, userID, groupID uuid.UUID) ([]Payment, error) {
+	g, l, err := s.balances(ctx, userID, groupID)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]uuid.UUID, len(g.Members))
+	for i, m := range g.Members {
+		ids[i] = m.UserID
+	}
+	return settleUp(ids, l.net), nil
+}
+
+type party struct {
+	id    uuid.UUID
+	cents int64
+}
+
+// settleUp turns net balances (positive: is owed) into payments. A debtor and a creditor
+// with exactly opposite balances are paired first, as that settles both in one payment;
+// the rest goes from the largest debtor to the largest creditor, so n members never need
+// more than n-1 payments.
+func settleUp(ids []uuid.UUID, net map[uuid.UUID]int64) []Payment {
+	var debtors, creditors []*party
+	for _, id := range ids {
+		switch c := net[id]; {
+		case c < 0:
+			debtors = append(debtors, &party{id, -c})
+		case c > 0:
+			creditors = append(creditors, &party{id, c})
+		}
+	}
+
+	out := []Payment{}
+	pay := func(d, c *party, cents int64) {
+		out = append(out, Payment{FromUserID: d.id, ToUserID: c.id, Amount: sqlutil.CentsString(cents)})
+		d.cents -= cents
+		c.cents -= cents
+	}
+	for _, d := range debtors {
+		for _, c := range creditors {
+			if c.cents > 0 && c.cents == d.cents {
+				pay(d, c, d.cents)
+				break
+			}
+		}
+	}
+
+	largest := func(ps []*party) *party {
+		var best *party
+		for _, p := range ps {
+			if p.cents > 0 && (best == nil || p.cents > best.cents) {
+				best = p
+			}
+		}
+		return best
+	}
+	for {
+		d, c := largest(debtors), largest(creditors)
+		if d == nil || c == nil {
+			return out
+		}
+		pay(d, c, min(d.cents, c.cents))
+	}
+}
@@ -0,0 +1,127 @@
+package split
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"github.com/Jomesi149/Implementasi-LASTI/backend/internal/sqlutil"
+)
+
+func TestDistribute(t *testing.T) {
+	tests := []struct {
+		name    string
+		total   int64
+		weights []int64
+		want    []int64
+		wantErr bool
+	}{
+		// Sisa sen jatuh ke bagian paling awal bila sisanya sama
+		{"equal thirds", 10000, []int64{1, 1, 1}, []int64{3334, 3333, 3333}, false},
+		{"equal with several cents left", 5, []int64{1, 1, 1, 1}, []int64{2, 1, 1, 1}, false},
+		// Persentase dalam seperseratus persen; 33.34% punya sisa terbesar
+		{"percent thirds", 100, []int64{3333, 3333, 3334}, []int64{33, 33, 34}, false},
+		{"largest remainder wins", 1000, []int64{1, 2}, []int64{333, 667}, false},
+		{"exact", 10000, []int64{2500, 7500}, []int64{2500, 7500}, false},
+		{"zero total", 0, []int64{1, 1}, []int64{0, 0}, false},
+		{"single member", 999, []int64{1}, []int64{999}, false},
+		{"no members", 10000, nil, nil, true},
+		{"zero weights", 10000, []int64{0, 0}, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := distribute(tt.total, tt.weights)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidSplit) {
+					t.Fatalf("err = %v, want ErrInvalidSplit", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("distribute(%d, %v) = %v, want %v", tt.total, tt.weights, got, tt.want)
+			}
+			var sum int64
+			for _, p := range got {
+				sum += p
+			}
+			if sum != tt.total {
+				t.Errorf("parts sum to %d, want %d", sum, tt.total)
+			}
+		})
+	}
+}
+
+func TestSettleUp(t *testing.T) {
+	a, b, c, d, e := uuid.New(), uuid.New(), uuid.New(), uuid.New(), uuid.New()
+	ids := []uuid.UUID{a, b, c, d, e}
+	tests := []struct {
+		name string
+		net  map[uuid.UUID]int64
+		// Pembayaran yang wajib ada karena saldonya tepat berlawanan
+		wantPairs []Payment
+	}{
+		{"settled", map[uuid.UUID]int64{}, nil},
+		{"two members", map[uuid.UUID]int64{a: -50000, b: 50000}, []Payment{{a, b, "500.00"}}},
+		{"one creditor", map[uuid.UUID]int64{a: -30000, b: -20000, c: 50000}, nil},
+		{"one debtor", map[uuid.UUID]int64{a: -90001, b: 30000, c: 30000, d: 30001}, nil},
+		{
+			// Pembayar terbesar ke penerima terbesar saja akan butuh empat pembayaran
+			"opposite balances paired first",
+			map[uuid.UUID]int64{a: -60000, b: -40000, c: 40000, d: 30000, e: 30000},
+			[]Payment{{b, c, "400.00"}},
+		},
+		{
+			"several opposite pairs",
+			map[uuid.UUID]int64{a: -70000, b: -30000, c: 30000, d: 70000},
+			[]Payment{{a, d, "700.00"}, {b, c, "300.00"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := settleUp(ids, tt.net)
+
+			members := 0
+			for _, cents := range tt.net {
+				if cents != 0 {
+					members++
+				}
+			}
+			if members > 0 && len(got) > members-1 {
+				t.Errorf("got %d payments for %d members, want at most %d", len(got), members, members-1)
+			}
+
+			left := map[uuid.UUID]int64{}
+			for id, cents := range tt.net {
+				left[id] = cents
+			}
+			for _, p := range got {
+				cents, ok := sqlutil.ToCents(p.Amount)
+				if !ok || cents <= 0 {
+					t.Fatalf("payment %+v has a bad amount", p)
+				}
+				left[p.FromUserID] += cents
+				left[p.ToUserID] -= cents
+			}
+			for id, cents := range left {
+				if cents != 0 {
+					t.Errorf("member %s still at %d after settling", id, cents)
+				}
+			}
+
+			for _, want := range tt.wantPairs {
+				found := false
+				for _, p := range got {
+					found = found || p == want
+				}
+				if !found {
+					t.Errorf("payments %+v miss %+v", got, want)
+				}
+			}
+		})
+	}
+}
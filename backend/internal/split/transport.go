@@ -0,0 +1,307 @@
+package split
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"github.com/Jomesi149/Implementasi-LASTI/backend/internal/transaction"
+	"github.com/Jomesi149/Implementasi-LASTI/backend/pkg/response"
+)
+
+type HTTPHandler struct {
+	service *Service
+}
+
+func NewHTTPHandler(s *Service) *HTTPHandler {
+	return &HTTPHandler{service: s}
+}
+
+func (h *HTTPHandler) RegisterRoutes(r chi.Router) {
+	r.Route("/split-groups", func(r chi.Router) {
+		r.Post("/", h.handleCreateGroup)
+		r.Get("/", h.handleListGroups)
+		r.Get("/{id}", h.handleGetGroup)
+		r.Post("/{id}/members", h.handleAddMember)
+		r.Delete("/{id}/members/{userId}", h.handleRemoveMember)
+		r.Put("/{id}/wallet", h.handleSetWallet)
+		r.Post("/{id}/expenses", h.handleCreateExpense)
+		r.Get("/{id}/expenses", h.handleListExpenses)
+		r.Delete("/{id}/expenses/{expenseId}", h.handleDeleteExpense)
+		r.Get("/{id}/balances", h.handleGetBalances)
+		r.Get("/{id}/settle-up", h.handleSettleUp)
+		r.Post("/{id}/settlements", h.handleCreateSettlement)
+		r.Get("/{id}/settlements", h.handleListSettlements)
+		r.Delete("/{id}/settlements/{settlementId}", h.handleDeleteSettlement)
+	})
+}
+
+func getUserIDFromHeader(r *http.Request) (uuid.UUID, error) {
+	s := r.Header.Get("X-User-ID")
+	return uuid.Parse(s)
+}
+
+// errorStatus maps service sentinel errors, and those of the transactions recorded in
+// member wallets, onto HTTP status codes.
+func errorStatus(err error) int {
+	switch {
+	case errors.Is(err, ErrGroupNotFound), errors.Is(err, ErrMemberNotFound), errors.Is(err, ErrExpenseNotFound),
+		errors.Is(err, ErrSettlementNotFound), errors.Is(err, transaction.ErrWalletNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, ErrInvalidSplit), errors.Is(err, transaction.ErrInvalidTransaction):
+		return http.StatusBadRequest
+	case errors.Is(err, ErrForbidden):
+		return http.StatusForbidden
+	case errors.Is(err, ErrAlreadyMember), errors.Is(err, ErrUnsettled):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// idParams reads the user id header and the {id} group parameter.
+func idParams(w http.ResponseWriter, r *http.Request) (uuid.UUID, uuid.UUID, bool) {
+	uid, err := getUserIDFromHeader(r)
+	if err != nil {
+		response.Error(w, http.StatusUnauthorized, "missing X-User-ID header")
+		return uuid.Nil, uuid.Nil, false
+	}
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "invalid group id")
+		return uuid.Nil, uuid.Nil, false
+	}
+	return uid, id, true
+}
+
+// subIDParams reads idParams plus the second URL parameter key.
+func subIDParams(w http.ResponseWriter, r *http.Request, key, what string) (uuid.UUID, uuid.UUID, uuid.UUID, bool) {
+	uid, id, ok := idParams(w, r)
+	if !ok {
+		return uuid.Nil, uuid.Nil, uuid.Nil, false
+	}
+	sub, err := uuid.Parse(chi.URLParam(r, key))
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "invalid "+what+" id")
+		return uuid.Nil, uuid.Nil, uuid.Nil, false
+	}
+	return uid, id, sub, true
+}
+
+func (h *HTTPHandler) handleCreateGroup(w http.ResponseWriter, r *http.Request) {
+	uid, err := getUserIDFromHeader(r)
+	if err != nil {
+		response.Error(w, http.StatusUnauthorized, "missing X-User-ID header")
+		return
+	}
+	var req GroupRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.Error(w, http.StatusBadRequest, "invalid payload")
+		return
+	}
+	g, err := h.service.CreateGroup(r.Context(), uid, req)
+	if err != nil {
+		response.Error(w, errorStatus(err), err.Error())
+		return
+	}
+	response.JSON(w, http.StatusCreated, g)
+}
+
+func (h *HTTPHandler) handleListGroups(w http.ResponseWriter, r *http.Request) {
+	uid, err := getUserIDFromHeader(r)
+	if err != nil {
+		response.Error(w, http.StatusUnauthorized, "missing X-User-ID header")
+		return
+	}
+	out, err := h.service.ListGroups(r.Context(), uid)
+	if err != nil {
+		response.Error(w, errorStatus(err), err.Error())
+		return
+	}
+	response.JSON(w, http.StatusOK, out)
+}
+
+func (h *HTTPHandler) handleGetGroup(w http.ResponseWriter, r *http.Request) {
+	uid, id, ok := idParams(w, r)
+	if !ok {
+		return
+	}
+	g, err := h.service.GetGroup(r.Context(), uid, id)
+	if err != nil {
+		response.Error(w, errorStatus(err), err.Error())
+		return
+	}
+	response.JSON(w, http.StatusOK, g)
+}
+
+func (h *HTTPHandler) handleAddMember(w http.ResponseWriter, r *http.Request) {
+	uid, id, ok := idParams(w, r)
+	if !ok {
+		return
+	}
+	var req MemberRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.Error(w, http.StatusBadRequest, "invalid payload")
+		return
+	}
+	g, err := h.service.AddMember(r.Context(), uid, id, req)
+	if err != nil {
+		response.Error(w, errorStatus(err), err.Error())
+		return
+	}
+	response.JSON(w, http.StatusOK, g)
+}
+
+func (h *HTTPHandler) handleRemoveMember(w http.ResponseWriter, r *http.Request) {
+	uid, id, memberID, ok := subIDParams(w, r, "userId", "user")
+	if !ok {
+		return
+	}
+	if err := h.service.RemoveMember(r.Context(), uid, id, memberID); err != nil {
+		response.Error(w, errorStatus(err), err.Error())
+		return
+	}
+	response.JSON(w, http.StatusOK, map[string]string{"status": "removed"})
+}
+
+func (h *HTTPHandler) handleSetWallet(w http.ResponseWriter, r *http.Request) {
+	uid, id, ok := idParams(w, r)
+	if !ok {
+		return
+	}
+	var req WalletRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.Error(w, http.StatusBadRequest, "invalid payload")
+		return
+	}
+	var walletID *uuid.UUID
+	if req.WalletID != nil {
+		v, err := uuid.Parse(*req.WalletID)
+		if err != nil {
+			response.Error(w, http.StatusBadRequest, "invalid wallet id")
+			return
+		}
+		walletID = &v
+	}
+	g, err := h.service.SetWallet(r.Context(), uid, id, walletID)
+	if err != nil {
+		response.Error(w, errorStatus(err), err.Error())
+		return
+	}
+	response.JSON(w, http.StatusOK, g)
+}
+
+func (h *HTTPHandler) handleCreateExpense(w http.ResponseWriter, r *http.Request) {
+	uid, id, ok := idParams(w, r)
+	if !ok {
+		return
+	}
+	var req ExpenseRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.Error(w, http.StatusBadRequest, "invalid payload")
+		return
+	}
+	e, err := h.service.CreateExpense(r.Context(), uid, id, req)
+	if err != nil {
+		response.Error(w, errorStatus(err), err.Error())
+		return
+	}
+	response.JSON(w, http.StatusCreated, e)
+}
+
+func (h *HTTPHandler) handleListExpenses(w http.ResponseWriter, r *http.Request) {
+	uid, id, ok := idParams(w, r)
+	if !ok {
+		return
+	}
+	out, err := h.service.ListExpenses(r.Context(), uid, id)
+	if err != nil {
+		response.Error(w, errorStatus(err), err.Error())
+		return
+	}
+	response.JSON(w, http.StatusOK, out)
+}
+
+func (h *HTTPHandler) handleDeleteExpense(w http.ResponseWriter, r *http.Request) {
+	uid, id, expenseID, ok := subIDParams(w, r, "expenseId", "expense")
+	if !ok {
+		return
+	}
+	if err := h.service.DeleteExpense(r.Context(), uid, id, expenseID); err != nil {
+		response.Error(w, errorStatus(err), err.Error())
+		return
+	}
+	response.JSON(w, http.StatusOK, map[string]string{"status": "deleted"})
+}
+
+func (h *HTTPHandler) handleGetBalances(w http.ResponseWriter, r *http.Request) {
+	uid, id, ok := idParams(w, r)
+	if !ok {
+		return
+	}
+	out, err := h.service.GetBalances(r.Context(), uid, id)
+	if err != nil {
+		response.Error(w, errorStatus(err), err.Error())
+		return
+	}
+	response.JSON(w, http.StatusOK, out)
+}
+
+func (h *HTTPHandler) handleSettleUp(w http.ResponseWriter, r *http.Request) {
+	uid, id, ok := idParams(w, r)
+	if !ok {
+		return
+	}
+	out, err := h.service.SettleUp(r.Context(), uid, id)
+	if err != nil {
+		response.Error(w, errorStatus(err), err.Error())
+		return
+	}
+	response.JSON(w, http.StatusOK, out)
+}
+
+func (h *HTTPHandler) handleCreateSettlement(w http.ResponseWriter, r *http.Request) {
+	uid, id, ok := idParams(w, r)
+	if !ok {
+		return
+	}
+	var req SettlementRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.Error(w, http.StatusBadRequest, "invalid payload")
+		return
+	}
+	st, err := h.service.CreateSettlement(r.Context(), uid, id, req)
+	if err != nil {
+		response.Error(w, errorStatus(err), err.Error())
+		return
+	}
+	response.JSON(w, http.StatusCreated, st)
+}
+
+func (h *HTTPHandler) handleListSettlements(w http.ResponseWriter, r *http.Request) {
+	uid, id, ok := idParams(w, r)
+	if !ok {
+		return
+	}
+	out, err := h.service.ListSettlements(r.Context(), uid, id)
+	if err != nil {
+		response.Error(w, errorStatus(err), err.Error())
+		return
+	}
+	response.JSON(w, http.StatusOK, out)
+}
+
+func (h *HTTPHandler) handleDeleteSettlement(w http.ResponseWriter, r *http.Request) {
+	uid, id, settlementID, ok := subIDParams(w, r, "settlementId", "settlement")
+	if !ok {
+		return
+	}
+	if err := h.service.DeleteSettlement(r.Context(), uid, id, settlementID); err != nil {
+		response.Error(w, errorStatus(err), err.Error())
+		return
+	}
+	response.JSON(w, http.StatusOK, map[string]string{"status": "deleted"})
+}
@@ -0,0 +1,62 @@
+// Package sqlutil holds the conversions shared by the repositories and services that
+// store amounts in NUMERIC and dates in DATE columns.
+package sqlutil
+
+import (
+	"database/sql"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DateLayout is the text form of a DATE column.
+const DateLayout = "2006-01-02"
+
+// maxCents keeps converted amounts well inside int64.
+const maxCents = 1 << 62
+
+// ToCents parses a decimal amount into whole cents, rounding half away from zero. It
+// rejects NaN, infinities and amounts too large for int64 cents.
+func ToCents(amount string) (int64, bool) {
+	v, err := strconv.ParseFloat(strings.TrimSpace(amount), 64)
+	if err != nil || math.IsNaN(v) || math.IsInf(v, 0) {
+		return 0, false
+	}
+	cents := math.Round(v * 100)
+	if math.Abs(cents) >= maxCents {
+		return 0, false
+	}
+	return int64(cents), true
+}
+
+// CentsString formats cents as a decimal amount, e.g. -1234 as "-12.34".
+func CentsString(cents int64) string {
+	sign := ""
+	if cents < 0 {
+		sign, cents = "-", -cents
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, cents/100, cents%100)
+}
+
+// DateParam formats an optional calendar date for a DATE column so the session time
+// zone cannot shift it.
+func DateParam(t *time.Time) any {
+	if t == nil {
+		return nil
+	}
+	return t.Format(DateLayout)
+}
+
+// ExpectAffected turns an UPDATE/DELETE that touched no rows into notFound.
+func ExpectAffected(res sql.Result, notFound error) error {
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return notFound
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package sqlutil
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestToCents(t *testing.T) {
+	tests := []struct {
+		in   string
+		want int64
+		ok   bool
+	}{
+		{"12.34", 1234, true},
+		{" 12.34 ", 1234, true},
+		{"-12.34", -1234, true},
+		{"0.005", 1, true},
+		{"-0.005", -1, true},
+		{"1e3", 100000, true},
+		{"7500000", 750000000, true},
+		{"", 0, false},
+		{"abc", 0, false},
+		{"NaN", 0, false},
+		{"Inf", 0, false},
+		{"-Inf", 0, false},
+		{"1e400", 0, false},
+		{"1e17", 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := ToCents(tt.in)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("ToCents(%q) = %d, %v; want %d, %v", tt.in, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestCentsString(t *testing.T) {
+	tests := []struct {
+		in   int64
+		want string
+	}{
+		{0, "0.00"},
+		{5, "0.05"},
+		{-5, "-0.05"},
+		{1234, "12.34"},
+		{-1234, "-12.34"},
+		{750000000, "7500000.00"},
+	}
+	for _, tt := range tests {
+		if got := CentsString(tt.in); got != tt.want {
+			t.Errorf("CentsString(%d) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+type affected int64
+
+func (a affected) LastInsertId() (int64, error) { return 0, nil }
+func (a affected) RowsAffected() (int64, error) { return int64(a), nil }
+
+func TestExpectAffected(t *testing.T) {
+	notFound := errors.New("not_found")
+	if err := ExpectAffected(affected(1), notFound); err != nil {
+		t.Errorf("one row: err = %v", err)
+	}
+	if err := ExpectAffected(affected(0), notFound); !errors.Is(err, notFound) {
+		t.Errorf("no rows: err = %v, want notFound", err)
+	}
+}
+
+func TestDateParam(t *testing.T) {
+	if got := DateParam(nil); got != nil {
+		t.Errorf("DateParam(nil) = %v", got)
+	}
+	d := time.Date(2024, 2, 29, 23, 30, 0, 0, time.FixedZone("WIB", 7*3600))
+	if got := DateParam(&d); got != "2024-02-29" {
+		t.Errorf("DateParam = %v, want 2024-02-29", got)
+	}
+}
@@ -0,0 +1,371 @@
+package transaction
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/Jomesi149/Implementasi-LASTI/backend/internal/sqlutil"
+)
+
+// maxBulkOperations caps one bulk request.
+const maxBulkOperations = 500
+
+// Bulk operation kinds.
+const (
+	BulkCreate       = "create"
+	BulkUpdate       = "update"
+	BulkDelete       = "delete"
+	BulkRecategorise = "recategorise"
+)
+
+// Per-item bulk statuses. NotApplied items were valid but the request was rejected
+// because another item failed.
+const (
+	BulkApplied    = "applied"
+	BulkFailed     = "failed"
+	BulkNotApplied = "not_applied"
+)
+
+var (
+	// ErrInvalidBulk indicates a bulk request that is empty or too large.
+	ErrInvalidBulk = errors.New("invalid_bulk_request")
+	// ErrBulkRejected is returned with the report when at least one operation failed;
+	// nothing was applied.
+	ErrBulkRejected = errors.New("bulk_request_rejected")
+	// ErrBulkConflict is returned when a transaction changed while the bulk request ran.
+	ErrBulkConflict = errors.New("bulk_conflict")
+)
+
+// BulkOperation is one item of a bulk request. Create needs WalletID, Amount and Kind;
+// update changes only the fields that are set, with TagIDs replacing the tags when
+// non-nil; delete needs only ID and moves the transaction to the trash; recategorise
+// sets CategoryID. Split transactions can be deleted but their amount, kind and
+// category cannot be changed in bulk.
+type BulkOperation struct {
+	Op         string
+	ID         uuid.UUID
+	WalletID   *uuid.UUID
+	CategoryID *uuid.UUID
+	Amount     *string
+	Kind       *string
+	Note       *string
+	OccurredAt *time.Time
+	PayeeID    *uuid.UUID
+	TagIDs     []uuid.UUID
+}
+
+// BulkItemResult reports the outcome of one operation, in request order.
+type BulkItemResult struct {
+	Index  int        `json:"index"`
+	Op     string     `json:"op"`
+	ID     *uuid.UUID `json:"id,omitempty"`
+	Status string     `json:"status"`
+	Error  string     `json:"error,omitempty"`
+}
+
+// BulkReport is the result of a bulk request. WalletDeltas is the net balance change
+// applied to each touched wallet.
+type BulkReport struct {
+	Applied      bool                 `json:"applied"`
+	Results      []BulkItemResult     `json:"results"`
+	WalletDeltas map[uuid.UUID]string `json:"wallet_deltas,omitempty"`
+}
+
+// bulkPlan is a validated bulk request ready to be written in one database transaction.
+type bulkPlan struct {
+	creates []Transaction
+	updates []Transaction
+	deletes []uuid.UUID
+	// retag holds the new tags of updated transactions whose tags are replaced.
+	retag map[uuid.UUID][]uuid.UUID
+	// expected is the state each updated or deleted row must still have when locked.
+	expected map[uuid.UUID]Transaction
+	// deltas is the net balance change per wallet in cents.
+	deltas map[uuid.UUID]int64
+}
+
+// bulkEnv is what validation needs, loaded once per request.
+type bulkEnv struct {
+	wallets    map[uuid.UUID]bool
+	categories []Category
+	rules      ruleSet
+	existing   map[uuid.UUID]Transaction
+}
+
+// BulkTransactions validates every operation first and then applies all of them in one
+// database transaction, booking each change on the ledger. If any operation fails
+// nothing is written and the report comes back with ErrBulkRejected.
+func (s *Service) BulkTransactions(ctx context.Context, userID uuid.UUID, ops []BulkOperation) (*BulkReport, error) {
+	if len(ops) == 0 {
+		return nil, fmt.Errorf("%w: no operations", ErrInvalidBulk)
+	}
+	if len(ops) > maxBulkOperations {
+		return nil, fmt.Errorf("%w: at most %d operations per request", ErrInvalidBulk, maxBulkOperations)
+	}
+	env, err := s.loadBulkEnv(ctx, userID, ops)
+	if err != nil {
+		return nil, err
+	}
+
+	plan := bulkPlan{
+		retag:    map[uuid.UUID][]uuid.UUID{},
+		expected: map[uuid.UUID]Transaction{},
+		deltas:   map[uuid.UUID]int64{},
+	}
+	report := &BulkReport{Results: make([]BulkItemResult, len(ops))}
+	touched := map[uuid.UUID]int{}
+	failed := false
+	for i, op := range ops {
+		res := BulkItemResult{Index: i, Op: op.Op, Status: BulkApplied}
+		id, opErr := op.ID, error(nil)
+		if op.Op != BulkCreate {
+			if prev, ok := touched[op.ID]; ok {
+				opErr = fmt.Errorf("%w: transaction is already changed by operation %d", ErrInvalidBulk, prev)
+			} else {
+				touched[op.ID] = i
+			}
+		}
+		if opErr == nil {
+			id, opErr = s.planBulkOp(ctx, userID, op, env, &plan)
+		}
+		if id != uuid.Nil {
+			res.ID = &id
+		}
+		if opErr != nil {
+			res.Status, res.Error = BulkFailed, opErr.Error()
+			failed = true
+		}
+		report.Results[i] = res
+	}
+	if failed {
+		for i := range report.Results {
+			if report.Results[i].Status == BulkApplied {
+				report.Results[i].Status = BulkNotApplied
+			}
+		}
+		return report, ErrBulkRejected
+	}
+
+	ctx = userChange(ctx, SourceBulk, userID, nil)
+	if err := s.repo.ApplyBulk(ctx, userID, plan); err != nil {
+		return nil, err
+	}
+	s.forget(userID)
+	report.Applied = true
+	report.WalletDeltas = make(map[uuid.UUID]string, len(plan.deltas))
+	for walletID, cents := range plan.deltas {
+		report.WalletDeltas[walletID] = sqlutil.CentsString(cents)
+	}
+	return report, nil
+}
+
+func (s *Service) loadBulkEnv(ctx context.Context, userID uuid.UUID, ops []BulkOperation) (*bulkEnv, error) {
+	wallets, err := s.repo.ListWallets(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	categories, err := s.listAllCategories(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	rules, err := s.loadRuleSet(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	var ids []uuid.UUID
+	for _, op := range ops {
+		if op.Op != BulkCreate && op.ID != uuid.Nil {
+			ids = append(ids, op.ID)
+		}
+	}
+	existing, err := s.repo.TransactionsByID(ctx, userID, uniqueIDs(ids))
+	if err != nil {
+		return nil, err
+	}
+
+	env := &bulkEnv{wallets: map[uuid.UUID]bool{}, categories: categories, rules: rules, existing: existing}
+	for _, w := range wallets {
+		env.wallets[w.ID] = true
+	}
+	return env, nil
+}
+
+// planBulkOp validates op and adds its writes and balance effect to plan. It returns
+// the id of the affected transaction.
+func (s *Service) planBulkOp(ctx context.Context, userID uuid.UUID, op BulkOperation, env *bulkEnv, plan *bulkPlan) (uuid.UUID, error) {
+	if op.Op == BulkCreate {
+		return s.planBulkCreate(ctx, userID, op, env, plan)
+	}
+	old, ok := env.existing[op.ID]
+	if !ok {
+		return op.ID, ErrTransactionNotFound
+	}
+	if old.TransferID != nil {
+		return old.ID, fmt.Errorf("%w: a transfer leg can only be changed through its transfer", ErrInvalidTransaction)
+	}
+
+	switch op.Op {
+	case BulkDelete:
+		plan.deletes = append(plan.deletes, old.ID)
+		plan.expected[old.ID] = old
+		plan.deltas[old.WalletID] -= postedCents(old)
+		return old.ID, nil
+
+	case BulkRecategorise:
+		if op.CategoryID == nil {
+			return old.ID, fmt.Errorf("%w: category_id is required", ErrInvalidTransaction)
+		}
+		return old.ID, s.planBulkUpdate(ctx, userID, old, BulkOperation{CategoryID: op.CategoryID}, env, plan)
+
+	case BulkUpdate:
+		return old.ID, s.planBulkUpdate(ctx, userID, old, op, env, plan)
+
+	default:
+		return old.ID, fmt.Errorf("%w: unknown op %q", ErrInvalidBulk, op.Op)
+	}
+}
+
+func (s *Service) planBulkCreate(ctx context.Context, userID uuid.UUID, op BulkOperation, env *bulkEnv, plan *bulkPlan) (uuid.UUID, error) {
+	if op.WalletID == nil || op.Amount == nil || op.Kind == nil {
+		return uuid.Nil, fmt.Errorf("%w: wallet_id, amount and kind are required", ErrInvalidTransaction)
+	}
+	t := Transaction{
+		ID:         uuid.New(),
+		UserID:     userID,
+		WalletID:   *op.WalletID,
+		CategoryID: op.CategoryID,
+		Amount:     *op.Amount,
+		Kind:       *op.Kind,
+		Note:       op.Note,
+		OccurredAt: time.Now(),
+		PayeeID:    op.PayeeID,
+		TagIDs:     uniqueIDs(op.TagIDs),
+		CreatedAt:  time.Now(),
+	}
+	if op.OccurredAt != nil {
+		t.OccurredAt = *op.OccurredAt
+	}
+	schedule(&t, t.CreatedAt)
+	if err := s.checkBulkTransaction(ctx, t, env); err != nil {
+		return uuid.Nil, err
+	}
+	if t.CategoryID == nil {
+		if r := env.rules.match(t); r != nil {
+			id := r.CategoryID
+			t.CategoryID = &id
+		}
+	}
+	plan.creates = append(plan.creates, t)
+	plan.deltas[t.WalletID] += postedCents(t)
+	return t.ID, nil
+}
+
+// planBulkUpdate applies the set fields of op to old.
+func (s *Service) planBulkUpdate(ctx context.Context, userID uuid.UUID, old Transaction, op BulkOperation, env *bulkEnv, plan *bulkPlan) error {
+	t := old
+	if op.WalletID != nil {
+		t.WalletID = *op.WalletID
+	}
+	if op.CategoryID != nil {
+		t.CategoryID = op.CategoryID
+	}
+	if op.Amount != nil {
+		t.Amount = *op.Amount
+	}
+	if op.Kind != nil {
+		t.Kind = *op.Kind
+	}
+	if op.Note != nil {
+		t.Note = op.Note
+	}
+	if op.OccurredAt != nil {
+		t.OccurredAt = *op.OccurredAt
+		// Dipindah ke hari ini atau sebelumnya berarti terposting, ke sesudahnya terencana
+		if now := time.Now(); t.OccurredAt.Before(plannedFrom(now)) {
+			t.Planned = false
+		} else {
+			schedule(&t, now)
+		}
+	}
+	if op.PayeeID != nil {
+		t.PayeeID = op.PayeeID
+	}
+	if op.TagIDs != nil {
+		t.TagIDs = uniqueIDs(op.TagIDs)
+		plan.retag[t.ID] = t.TagIDs
+	}
+
+	// Arah dan dompet transaksi utang mengikuti utangnya
+	if old.DebtID != nil && (t.Kind != old.Kind || t.WalletID != old.WalletID) {
+		return fmt.Errorf("%w: kind and wallet of a debt transaction can only be changed through its debt", ErrInvalidTransaction)
+	}
+	if len(old.Splits) > 0 {
+		oldCents, _ := sqlutil.ToCents(old.Amount)
+		newCents, _ := sqlutil.ToCents(t.Amount)
+		if t.Kind != old.Kind || newCents != oldCents || op.CategoryID != nil {
+			return fmt.Errorf("%w: amount, kind and category of a split transaction cannot be changed in bulk", ErrInvalidTransaction)
+		}
+	}
+	check := t
+	if op.TagIDs == nil {
+		check.TagIDs = nil
+	}
+	if op.PayeeID == nil {
+		check.PayeeID = nil
+	}
+	if err := s.checkBulkTransaction(ctx, check, env); err != nil {
+		return err
+	}
+
+	plan.updates = append(plan.updates, t)
+	plan.expected[old.ID] = old
+	plan.deltas[old.WalletID] -= postedCents(old)
+	plan.deltas[t.WalletID] += postedCents(t)
+	return nil
+}
+
+// checkBulkTransaction validates kind, amount, wallet, category and labels of t.
+func (s *Service) checkBulkTransaction(ctx context.Context, t Transaction, env *bulkEnv) error {
+	if t.Kind != "in" && t.Kind != "out" {
+		return fmt.Errorf("%w: kind must be in or out", ErrInvalidTransaction)
+	}
+	if amount, err := strconv.ParseFloat(t.Amount, 64); err != nil || amount <= 0 {
+		return fmt.Errorf("%w: amount must be a positive number", ErrInvalidTransaction)
+	}
+	if !env.wallets[t.WalletID] {
+		return ErrWalletNotFound
+	}
+	if t.CategoryID != nil {
+		c := findCategory(env.categories, *t.CategoryID)
+		if c == nil {
+			return ErrCategoryNotFound
+		}
+		if c.Kind != t.Kind {
+			return fmt.Errorf("%w: category is not an %s category", ErrInvalidTransaction, t.Kind)
+		}
+	}
+	return s.checkLabels(ctx, t.UserID, t.PayeeID, t.TagIDs)
+}
+
+// signedCents is the effect of t on its wallet balance in cents.
+func signedCents(t Transaction) int64 {
+	cents, _ := sqlutil.ToCents(t.Amount)
+	if t.Kind == "out" {
+		return -cents
+	}
+	return cents
+}
+
+// postedCents is signedCents of a posted transaction; planned ones do not touch the
+// balance yet.
+func postedCents(t Transaction) int64 {
+	if t.Planned {
+		return 0
+	}
+	return signedCents(t)
+}
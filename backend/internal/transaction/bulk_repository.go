@@ -0,0 +1,153 @@
+package transaction
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+
+	"github.com/Jomesi149/Implementasi-LASTI/backend/internal/sqlutil"
+)
+
+// TransactionsByID loads the given transactions of userID with their splits and tags;
+// unknown ids are left out.
+func (r *SQLRepository) TransactionsByID(ctx context.Context, userID uuid.UUID, ids []uuid.UUID) (map[uuid.UUID]Transaction, error) {
+	if len(ids) == 0 {
+		return map[uuid.UUID]Transaction{}, nil
+	}
+	strs := make([]string, len(ids))
+	for i, id := range ids {
+		strs[i] = id.String()
+	}
+	return r.transactionsByID(ctx, userID, strs)
+}
+
+// ApplyBulk writes a validated bulk plan in one database transaction. Rows that are
+// updated or deleted are locked first and must still match plan.expected, so a
+// concurrent change cannot throw the wallet deltas off; each wallet balance is then
+// moved once, by exactly plan.deltas.
+func (r *SQLRepository) ApplyBulk(ctx context.Context, userID uuid.UUID, plan bulkPlan) (err error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	if len(plan.expected) > 0 {
+		ids := make([]string, 0, len(plan.expected))
+		for id := range plan.expected {
+			ids = append(ids, id.String())
+		}
+		rows, qerr := tx.QueryContext(ctx, `SELECT id, wallet_id, amount::TEXT, kind, planned FROM finance.transactions
+			WHERE user_id = $1 AND id = ANY($2::uuid[]) AND deleted_at IS NULL FOR UPDATE`, userID, ids)
+		if qerr != nil {
+			return fmt.Errorf("lock transactions: %w", qerr)
+		}
+		locked := 0
+		for rows.Next() {
+			var cur Transaction
+			if err = rows.Scan(&cur.ID, &cur.WalletID, &cur.Amount, &cur.Kind, &cur.Planned); err != nil {
+				rows.Close()
+				return err
+			}
+			want := plan.expected[cur.ID]
+			if cur.WalletID != want.WalletID || cur.Kind != want.Kind || cur.Planned != want.Planned || signedCents(cur) != signedCents(want) {
+				rows.Close()
+				return fmt.Errorf("%w: transaction %s", ErrBulkConflict, cur.ID)
+			}
+			locked++
+		}
+		rows.Close()
+		if err = rows.Err(); err != nil {
+			return err
+		}
+		if locked != len(plan.expected) {
+			return fmt.Errorf("%w: a transaction was deleted meanwhile", ErrBulkConflict)
+		}
+	}
+
+	ids := make([]uuid.UUID, 0, len(plan.creates)+len(plan.expected))
+	for id := range plan.expected {
+		ids = append(ids, id)
+	}
+	before, err := snapshotHistory(ctx, tx, ids)
+	if err != nil {
+		return err
+	}
+	for _, t := range plan.creates {
+		ids = append(ids, t.ID)
+	}
+
+	for _, t := range plan.creates {
+		q := `INSERT INTO finance.transactions (id, user_id, wallet_id, category_id, amount, kind, note, occurred_at, payee_id, planned, auto_post, currency, created_at)
+			VALUES ($1,$2,$3,$4,$5::NUMERIC,$6,$7,$8,$9,$10,$11,(SELECT currency FROM finance.wallets WHERE id = $3),NOW())`
+		if _, err = tx.ExecContext(ctx, q, t.ID, t.UserID, t.WalletID, t.CategoryID, t.Amount, t.Kind, t.Note, t.OccurredAt, t.PayeeID, t.Planned, t.AutoPost || !t.Planned); err != nil {
+			return fmt.Errorf("insert transaction: %w", err)
+		}
+		if err = insertTags(ctx, tx, t.ID, t.TagIDs); err != nil {
+			return err
+		}
+	}
+
+	for _, t := range plan.updates {
+		q := `UPDATE finance.transactions SET wallet_id = $3, category_id = $4, amount = $5::NUMERIC, kind = $6, note = $7, occurred_at = $8, payee_id = $9,
+				planned = $10, auto_post = $11, currency = (SELECT currency FROM finance.wallets WHERE id = $3)
+			WHERE id = $1 AND user_id = $2`
+		if _, err = tx.ExecContext(ctx, q, t.ID, userID, t.WalletID, t.CategoryID, t.Amount, t.Kind, t.Note, t.OccurredAt, t.PayeeID, t.Planned, t.AutoPost || !t.Planned); err != nil {
+			return fmt.Errorf("update transaction: %w", err)
+		}
+		if tagIDs, ok := plan.retag[t.ID]; ok {
+			if _, err = tx.ExecContext(ctx, `DELETE FROM finance.transaction_tags WHERE transaction_id = $1`, t.ID); err != nil {
+				return fmt.Errorf("clear tags: %w", err)
+			}
+			if err = insertTags(ctx, tx, t.ID, tagIDs); err != nil {
+				return err
+			}
+		}
+	}
+
+	if len(plan.deletes) > 0 {
+		ids := make([]string, len(plan.deletes))
+		for i, id := range plan.deletes {
+			ids[i] = id.String()
+		}
+		// Dihapus ke trash; saldo dibalik oleh posting buku besar di recordChanges
+		if _, err = tx.ExecContext(ctx, `UPDATE finance.transactions SET deleted_at = NOW() WHERE user_id = $1 AND id = ANY($2::uuid[])`, userID, ids); err != nil {
+			return fmt.Errorf("delete transactions: %w", err)
+		}
+	}
+
+	// Saldo tiap dompet diubah sekali untuk seluruh permintaan dan harus sama dengan rencana
+	applied, err := recordWalletChanges(ctx, tx, ids, before)
+	if err != nil {
+		return err
+	}
+	for walletID := range mergeKeys(applied, plan.deltas) {
+		if applied[walletID] != plan.deltas[walletID] {
+			return fmt.Errorf("%w: wallet %s moved by %s instead of %s", ErrBulkConflict, walletID,
+				sqlutil.CentsString(applied[walletID]), sqlutil.CentsString(plan.deltas[walletID]))
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("commit tx: %w", err)
+	}
+	return nil
+}
+
+// mergeKeys returns the wallets in either map.
+func mergeKeys(a, b map[uuid.UUID]int64) map[uuid.UUID]bool {
+	out := make(map[uuid.UUID]bool, len(a)+len(b))
+	for id := range a {
+		out[id] = true
+	}
+	for id := range b {
+		out[id] = true
+	}
+	return out
+}
@@ -0,0 +1,109 @@
+package transaction
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestPlanBulkOpLinkedTransactions(t *testing.T) {
+	userID, walletA, walletB := uuid.New(), uuid.New(), uuid.New()
+	transferID, debtID := uuid.New(), uuid.New()
+	leg := Transaction{ID: uuid.New(), UserID: userID, WalletID: walletA, Amount: "50000.00", Kind: "out", TransferID: &transferID}
+	debt := Transaction{ID: uuid.New(), UserID: userID, WalletID: walletA, Amount: "200000.00", Kind: "out", DebtID: &debtID}
+	plain := Transaction{ID: uuid.New(), UserID: userID, WalletID: walletA, Amount: "10000.00", Kind: "out"}
+
+	in, out, amount, note := "in", "out", "150000.00", "cicilan"
+	tests := []struct {
+		name    string
+		op      BulkOperation
+		wantErr bool
+	}{
+		{"transfer leg update", BulkOperation{Op: BulkUpdate, ID: leg.ID, Note: &note}, true},
+		{"transfer leg delete", BulkOperation{Op: BulkDelete, ID: leg.ID}, true},
+		{"debt kind", BulkOperation{Op: BulkUpdate, ID: debt.ID, Kind: &in}, true},
+		{"debt wallet", BulkOperation{Op: BulkUpdate, ID: debt.ID, WalletID: &walletB}, true},
+		{"debt same kind and wallet", BulkOperation{Op: BulkUpdate, ID: debt.ID, Kind: &out, WalletID: &walletA}, false},
+		{"debt amount and note", BulkOperation{Op: BulkUpdate, ID: debt.ID, Amount: &amount, Note: &note}, false},
+		{"debt delete", BulkOperation{Op: BulkDelete, ID: debt.ID}, false},
+		{"plain kind and wallet", BulkOperation{Op: BulkUpdate, ID: plain.ID, Kind: &in, WalletID: &walletB}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := &bulkEnv{
+				wallets:  map[uuid.UUID]bool{walletA: true, walletB: true},
+				existing: map[uuid.UUID]Transaction{leg.ID: leg, debt.ID: debt, plain.ID: plain},
+			}
+			plan := &bulkPlan{retag: map[uuid.UUID][]uuid.UUID{}, expected: map[uuid.UUID]Transaction{}, deltas: map[uuid.UUID]int64{}}
+			_, err := (&Service{}).planBulkOp(context.Background(), userID, tt.op, env, plan)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidTransaction) {
+					t.Errorf("err = %v, want ErrInvalidTransaction", err)
+				}
+				if len(plan.updates)+len(plan.deletes) > 0 {
+					t.Error("rejected operation was planned")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+func TestPlanBulkOpDateMoves(t *testing.T) {
+	userID, walletID := uuid.New(), uuid.New()
+	now := time.Now()
+	lastWeek, nextMonth := now.AddDate(0, 0, -7), now.AddDate(0, 1, 0)
+	posted := Transaction{ID: uuid.New(), UserID: userID, WalletID: walletID, Amount: "75000.00", Kind: "out", OccurredAt: lastWeek, AutoPost: true}
+	planned := Transaction{ID: uuid.New(), UserID: userID, WalletID: walletID, Amount: "75000.00", Kind: "out", OccurredAt: nextMonth, Planned: true, AutoPost: true}
+	confirm := Transaction{ID: uuid.New(), UserID: userID, WalletID: walletID, Amount: "75000.00", Kind: "out", OccurredAt: nextMonth, Planned: true}
+	note := "dipindah"
+
+	tests := []struct {
+		name         string
+		old          Transaction
+		op           BulkOperation
+		wantPlanned  bool
+		wantAutoPost bool
+		wantDelta    int64
+	}{
+		// Transaksi terposting yang dipindah ke bulan depan keluar dari saldo
+		{"posted to the future", posted, BulkOperation{OccurredAt: &nextMonth}, true, true, 7500000},
+		// Transaksi terencana yang dipindah ke masa lalu masuk ke saldo
+		{"planned to the past", planned, BulkOperation{OccurredAt: &lastWeek}, false, true, -7500000},
+		{"planned to today", planned, BulkOperation{OccurredAt: &now}, false, true, -7500000},
+		{"posted within the past", posted, BulkOperation{OccurredAt: &now}, false, true, 0},
+		{"planned within the future keeps confirmation", confirm, BulkOperation{OccurredAt: &nextMonth}, true, false, 0},
+		{"confirmation-only planned to the past", confirm, BulkOperation{OccurredAt: &lastWeek}, false, false, -7500000},
+		{"date untouched", planned, BulkOperation{Note: &note}, true, true, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := &bulkEnv{
+				wallets:  map[uuid.UUID]bool{walletID: true},
+				existing: map[uuid.UUID]Transaction{tt.old.ID: tt.old},
+			}
+			plan := &bulkPlan{retag: map[uuid.UUID][]uuid.UUID{}, expected: map[uuid.UUID]Transaction{}, deltas: map[uuid.UUID]int64{}}
+			op := tt.op
+			op.Op, op.ID = BulkUpdate, tt.old.ID
+			if _, err := (&Service{}).planBulkOp(context.Background(), userID, op, env, plan); err != nil {
+				t.Fatal(err)
+			}
+			if len(plan.updates) != 1 {
+				t.Fatalf("planned %d updates, want 1", len(plan.updates))
+			}
+			got := plan.updates[0]
+			if got.Planned != tt.wantPlanned || (got.Planned && got.AutoPost != tt.wantAutoPost) {
+				t.Errorf("planned/auto_post = %v/%v, want %v/%v", got.Planned, got.AutoPost, tt.wantPlanned, tt.wantAutoPost)
+			}
+			if delta := plan.deltas[walletID]; delta != tt.wantDelta {
+				t.Errorf("wallet delta = %d, want %d", delta, tt.wantDelta)
+			}
+		})
+	}
+}
@@ -0,0 +1,94 @@
+package transaction
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/Jomesi149/Implementasi-LASTI/backend/pkg/response"
+)
+
+type bulkReq struct {
+	Operations []bulkOperationReq `json:"operations"`
+}
+
+type bulkOperationReq struct {
+	Op         string     `json:"op"`
+	ID         string     `json:"id"`
+	WalletID   *string    `json:"wallet_id"`
+	CategoryID *string    `json:"category_id"`
+	Amount     *string    `json:"amount"`
+	Kind       *string    `json:"kind"`
+	Note       *string    `json:"note"`
+	OccurredAt *time.Time `json:"occurred_at"`
+	PayeeID    *string    `json:"payee_id"`
+	TagIDs     []string   `json:"tag_ids"`
+}
+
+// parseBulkOperation converts one request item; the returned message names the bad field.
+func parseBulkOperation(req bulkOperationReq) (BulkOperation, string) {
+	op := BulkOperation{Op: req.Op, Amount: req.Amount, Kind: req.Kind, Note: req.Note, OccurredAt: req.OccurredAt}
+	var err error
+	if req.Op != BulkCreate {
+		if op.ID, err = uuid.Parse(req.ID); err != nil {
+			return op, "invalid transaction id"
+		}
+	}
+	if op.WalletID, err = parseOptionalUUID(req.WalletID); err != nil {
+		return op, "invalid wallet id"
+	}
+	if op.CategoryID, err = parseOptionalUUID(req.CategoryID); err != nil {
+		return op, "invalid category id"
+	}
+	if op.PayeeID, err = parseOptionalUUID(req.PayeeID); err != nil {
+		return op, "invalid payee id"
+	}
+	if req.TagIDs != nil {
+		if op.TagIDs, err = parseUUIDList(req.TagIDs); err != nil {
+			return op, "invalid tag id"
+		}
+		if op.TagIDs == nil {
+			op.TagIDs = []uuid.UUID{}
+		}
+	}
+	return op, ""
+}
+
+// handleBulkTransactions runs create, update, delete and recategorise operations all
+// or nothing. A rejected request answers 422 with the per-item report.
+func (h *HTTPHandler) handleBulkTransactions(w http.ResponseWriter, r *http.Request) {
+	uid, err := getUserIDFromHeader(r)
+	if err != nil {
+		response.Error(w, http.StatusUnauthorized, "missing X-User-ID header")
+		return
+	}
+	var req bulkReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.Error(w, http.StatusBadRequest, "invalid payload")
+		return
+	}
+	ops := make([]BulkOperation, len(req.Operations))
+	for i, item := range req.Operations {
+		op, msg := parseBulkOperation(item)
+		if msg != "" {
+			response.Error(w, http.StatusBadRequest, "operation "+strconv.Itoa(i)+": "+msg)
+			return
+		}
+		ops[i] = op
+	}
+
+	report, err := h.service.BulkTransactions(r.Context(), uid, ops)
+	if errors.Is(err, ErrBulkRejected) {
+		response.JSON(w, http.StatusUnprocessableEntity, report)
+		return
+	}
+	if err != nil {
+		response.Error(w, errorStatus(err), err.Error())
+		return
+	}
+	response.JSON(w, http.StatusOK, report)
+}
@@ -0,0 +1,333 @@
+package transaction
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/Jomesi149/Implementasi-LASTI/backend/internal/notification"
+	"github.com/Jomesi149/Implementasi-LASTI/backend/internal/sqlutil"
+)
+
+// WalletCreditCard is the wallet type of credit cards. Their balance is the negated
+// debt: purchases are outgoing transactions and payments are transfers into the card.
+const WalletCreditCard = "credit_card"
+
+// NotificationCreditCardDue is the notification kind of payment reminders.
+const NotificationCreditCardDue = "credit_card_due"
+
+// creditCardPaymentNote is the note of payments made without one.
+const creditCardPaymentNote = "Credit card payment"
+
+// defaultReminderDays is how many days before the due date reminders start.
+const defaultReminderDays = 3
+
+var (
+	// ErrCreditCardNotFound is returned when the wallet is not a credit card of the user.
+	ErrCreditCardNotFound = errors.New("credit_card_not_found")
+	// ErrInvalidCreditCard indicates a credit card with a bad limit or statement cycle.
+	ErrInvalidCreditCard = errors.New("invalid_credit_card")
+)
+
+// CreditCard holds the statement cycle of a credit card wallet. A statement closes on
+// StatementDay, or the last day of shorter months, and is due PaymentDueDays later.
+type CreditCard struct {
+	WalletID       uuid.UUID `json:"wallet_id"`
+	UserID         uuid.UUID `json:"user_id"`
+	Name           string    `json:"name"`
+	Currency       string    `json:"currency"`
+	Balance        string    `json:"balance"`
+	CreditLimit    string    `json:"credit_limit"`
+	StatementDay   int       `json:"statement_day"`
+	PaymentDueDays int       `json:"payment_due_days"`
+	ReminderDays   int       `json:"reminder_days"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// CreditCardStatement is the state of a card on a given day. StatementBalance is the
+// debt when the last statement closed and AmountDue what is left of it after the
+// payments since; NextStatementBalance is the debt so far for the statement that closes
+// next. Payments are transfers into the card; other incoming transactions, such as
+// refunds, are credits that lower the next statement but not the amount due. Amounts
+// are positive debts.
+type CreditCardStatement struct {
+	Card                  CreditCard `json:"card"`
+	CurrentDebt           string     `json:"current_debt"`
+	AvailableCredit       string     `json:"available_credit"`
+	StatementDate         time.Time  `json:"statement_date"`
+	DueDate               time.Time  `json:"due_date"`
+	StatementBalance      string     `json:"statement_balance"`
+	PaidSinceStatement    string     `json:"paid_since_statement"`
+	CreditsSinceStatement string     `json:"credits_since_statement"`
+	AmountDue             string     `json:"amount_due"`
+	NewCharges            string     `json:"new_charges"`
+	NextStatementDate     time.Time  `json:"next_statement_date"`
+	NextDueDate           time.Time  `json:"next_due_date"`
+	NextStatementBalance  string     `json:"next_statement_balance"`
+	Overdue               bool       `json:"overdue"`
+}
+
+// NewCreditCard is the input for Service.CreateCreditCard. Debt is the balance owed when
+// the card is added; ReminderDays nil means defaultReminderDays.
+type NewCreditCard struct {
+	UserID         uuid.UUID
+	Name           string
+	Currency       string
+	Debt           string
+	CreditLimit    string
+	StatementDay   int
+	PaymentDueDays int
+	ReminderDays   *int
+}
+
+// cardActivity is what a statement is computed from: the wallet balance now and the
+// transactions after the statement closed, in cents. Payments are incoming transfer
+// legs and Credits the other incoming transactions.
+type cardActivity struct {
+	Balance  int64
+	Payments int64
+	Credits  int64
+	Charges  int64
+}
+
+// calendarDay truncates t to its date in UTC.
+func calendarDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// closingDate is the statement date in the given month, clamped to its last day.
+func closingDate(year int, month time.Month, day int) time.Time {
+	first := time.Date(year, month, 1, 0, 0, 0, 0, time.UTC)
+	if last := first.AddDate(0, 1, -1).Day(); day > last {
+		day = last
+	}
+	return first.AddDate(0, 0, day-1)
+}
+
+// statementDates returns the last statement date on or before day and the one after it.
+func statementDates(day time.Time, statementDay int) (time.Time, time.Time) {
+	closed := closingDate(day.Year(), day.Month(), statementDay)
+	if closed.After(day) {
+		closed = closingDate(day.Year(), day.Month()-1, statementDay)
+	}
+	next := closingDate(closed.Year(), closed.Month()+1, statementDay)
+	return closed, next
+}
+
+func validateCreditCard(c CreditCard) error {
+	limit, ok := sqlutil.ToCents(c.CreditLimit)
+	if !ok || limit < 0 {
+		return fmt.Errorf("%w: credit_limit must be a non-negative number", ErrInvalidCreditCard)
+	}
+	if c.StatementDay < 1 || c.StatementDay > 31 {
+		return fmt.Errorf("%w: statement_day must be between 1 and 31", ErrInvalidCreditCard)
+	}
+	if c.PaymentDueDays < 1 || c.PaymentDueDays > 28 {
+		return fmt.Errorf("%w: payment_due_days must be between 1 and 28", ErrInvalidCreditCard)
+	}
+	if c.ReminderDays < 0 || c.ReminderDays > 28 {
+		return fmt.Errorf("%w: reminder_days must be between 0 and 28", ErrInvalidCreditCard)
+	}
+	return nil
+}
+
+// CreateCreditCard adds a credit card wallet. An opening debt becomes a negative
+// opening balance.
+func (s *Service) CreateCreditCard(ctx context.Context, in NewCreditCard) (*CreditCardStatement, error) {
+	if strings.TrimSpace(in.Name) == "" {
+		return nil, fmt.Errorf("%w: name is required", ErrInvalidCreditCard)
+	}
+	debt := int64(0)
+	if in.Debt != "" {
+		var ok bool
+		if debt, ok = sqlutil.ToCents(in.Debt); !ok || debt < 0 {
+			return nil, fmt.Errorf("%w: debt must be a non-negative number", ErrInvalidCreditCard)
+		}
+	}
+	code, err := s.walletCurrency(ctx, in.UserID, in.Currency)
+	if err != nil {
+		return nil, err
+	}
+	c := CreditCard{
+		WalletID:       uuid.New(),
+		UserID:         in.UserID,
+		Name:           strings.TrimSpace(in.Name),
+		Currency:       code,
+		Balance:        sqlutil.CentsString(-debt),
+		CreditLimit:    in.CreditLimit,
+		StatementDay:   in.StatementDay,
+		PaymentDueDays: in.PaymentDueDays,
+		ReminderDays:   defaultReminderDays,
+		CreatedAt:      time.Now(),
+	}
+	if in.ReminderDays != nil {
+		c.ReminderDays = *in.ReminderDays
+	}
+	if err := validateCreditCard(c); err != nil {
+		return nil, err
+	}
+	limit, _ := sqlutil.ToCents(c.CreditLimit)
+	c.CreditLimit = sqlutil.CentsString(limit)
+
+	w := Wallet{ID: c.WalletID, UserID: c.UserID, Type: WalletCreditCard, Name: c.Name, Balance: c.Balance, Currency: c.Currency, CreatedAt: c.CreatedAt}
+	if err := s.repo.CreateCreditCard(ctx, w, c); err != nil {
+		return nil, fmt.Errorf("create credit card: %w", err)
+	}
+	return s.creditCardStatement(ctx, c, time.Now())
+}
+
+// ListCreditCards returns the current statement of every credit card of the user.
+func (s *Service) ListCreditCards(ctx context.Context, userID uuid.UUID) ([]CreditCardStatement, error) {
+	cards, err := s.repo.ListCreditCards(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	out := make([]CreditCardStatement, 0, len(cards))
+	for _, c := range cards {
+		st, err := s.creditCardStatement(ctx, c, now)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *st)
+	}
+	return out, nil
+}
+
+// GetCreditCard returns the current statement of a credit card.
+func (s *Service) GetCreditCard(ctx context.Context, userID, walletID uuid.UUID) (*CreditCardStatement, error) {
+	c, err := s.repo.GetCreditCard(ctx, userID, walletID)
+	if err != nil {
+		return nil, err
+	}
+	return s.creditCardStatement(ctx, *c, time.Now())
+}
+
+// CreditCardUpdate changes the limit and statement cycle of a card; nil fields are kept.
+type CreditCardUpdate struct {
+	CreditLimit    *string
+	StatementDay   *int
+	PaymentDueDays *int
+	ReminderDays   *int
+}
+
+// UpdateCreditCard changes the limit or statement cycle of a credit card.
+func (s *Service) UpdateCreditCard(ctx context.Context, userID, walletID uuid.UUID, u CreditCardUpdate) (*CreditCardStatement, error) {
+	c, err := s.repo.GetCreditCard(ctx, userID, walletID)
+	if err != nil {
+		return nil, err
+	}
+	if u.CreditLimit != nil {
+		c.CreditLimit = *u.CreditLimit
+	}
+	if u.StatementDay != nil {
+		c.StatementDay = *u.StatementDay
+	}
+	if u.PaymentDueDays != nil {
+		c.PaymentDueDays = *u.PaymentDueDays
+	}
+	if u.ReminderDays != nil {
+		c.ReminderDays = *u.ReminderDays
+	}
+	if err := validateCreditCard(*c); err != nil {
+		return nil, err
+	}
+	limit, _ := sqlutil.ToCents(c.CreditLimit)
+	c.CreditLimit = sqlutil.CentsString(limit)
+	if err := s.repo.UpdateCreditCard(ctx, *c); err != nil {
+		return nil, err
+	}
+	return s.creditCardStatement(ctx, *c, time.Now())
+}
+
+// PayCreditCard pays a credit card from another wallet of the user. The payment is a
+// transfer into the card, so it is not counted as an expense; in.ToWalletID is ignored.
+func (s *Service) PayCreditCard(ctx context.Context, walletID uuid.UUID, in NewTransfer) (*Transfer, error) {
+	if _, err := s.repo.GetCreditCard(ctx, in.UserID, walletID); err != nil {
+		return nil, err
+	}
+	in.ToWalletID = walletID
+	if in.Note == nil || strings.TrimSpace(*in.Note) == "" {
+		note := creditCardPaymentNote
+		in.Note = &note
+	}
+	return s.CreateTransfer(ctx, in)
+}
+
+// creditCardStatement computes the statement of c as of now.
+func (s *Service) creditCardStatement(ctx context.Context, c CreditCard, now time.Time) (*CreditCardStatement, error) {
+	today := calendarDay(now)
+	closed, next := statementDates(today, c.StatementDay)
+	a, err := s.repo.CreditCardActivity(ctx, c.WalletID, closed)
+	if err != nil {
+		return nil, err
+	}
+	c.Balance = sqlutil.CentsString(a.Balance)
+	return buildStatement(c, *a, today, closed, next), nil
+}
+
+// buildStatement derives a statement from the card activity after the statement that
+// closed on closed.
+func buildStatement(c CreditCard, a cardActivity, today, closed, next time.Time) *CreditCardStatement {
+	limit, _ := sqlutil.ToCents(c.CreditLimit)
+	debt := max(-a.Balance, 0)
+	// Saldo saat tagihan dicetak = saldo sekarang tanpa transaksi sesudahnya
+	atClose := max(-(a.Balance - a.Payments - a.Credits + a.Charges), 0)
+	due := max(atClose-a.Payments, 0)
+	dueDate := closed.AddDate(0, 0, c.PaymentDueDays)
+
+	return &CreditCardStatement{
+		Card:                  c,
+		CurrentDebt:           sqlutil.CentsString(debt),
+		AvailableCredit:       sqlutil.CentsString(max(limit+a.Balance, 0)),
+		StatementDate:         closed,
+		DueDate:               dueDate,
+		StatementBalance:      sqlutil.CentsString(atClose),
+		PaidSinceStatement:    sqlutil.CentsString(a.Payments),
+		CreditsSinceStatement: sqlutil.CentsString(a.Credits),
+		AmountDue:             sqlutil.CentsString(due),
+		NewCharges:            sqlutil.CentsString(a.Charges),
+		NextStatementDate:     next,
+		NextDueDate:           next.AddDate(0, 0, c.PaymentDueDays),
+		NextStatementBalance:  sqlutil.CentsString(debt),
+		Overdue:               due > 0 && today.After(dueDate),
+	}
+}
+
+// CreditCardReminders returns a payment reminder for every card, of any user, whose
+// amount due is unpaid and falls due within its reminder days. It is a
+// notification.Source; the dedupe key is per card and due date, so each statement is
+// reminded of once.
+func (s *Service) CreditCardReminders(ctx context.Context, now time.Time) ([]notification.Notification, error) {
+	cards, err := s.repo.AllCreditCards(ctx)
+	if err != nil {
+		return nil, err
+	}
+	today := calendarDay(now)
+	var out []notification.Notification
+	for _, c := range cards {
+		st, err := s.creditCardStatement(ctx, c, now)
+		if err != nil {
+			return nil, err
+		}
+		days := int(st.DueDate.Sub(today).Hours() / 24)
+		if st.AmountDue == sqlutil.CentsString(0) || days < 0 || days > c.ReminderDays {
+			continue
+		}
+		walletID, dueDate := c.WalletID, st.DueDate
+		out = append(out, notification.Notification{
+			UserID:      c.UserID,
+			Kind:        NotificationCreditCardDue,
+			Title:       fmt.Sprintf("%s payment due %s", c.Name, dueDate.Format("2 Jan 2006")),
+			Body:        fmt.Sprintf("%s %s of the statement from %s is due in %d day(s).", st.AmountDue, c.Currency, st.StatementDate.Format("2 Jan 2006"), days),
+			ReferenceID: &walletID,
+			DueDate:     &dueDate,
+			DedupeKey:   fmt.Sprintf("%s:%s:%s", NotificationCreditCardDue, c.WalletID, dueDate.Format("2006-01-02")),
+		})
+	}
+	return out, nil
+}
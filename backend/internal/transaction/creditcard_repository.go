@@ -0,0 +1,131 @@
+package transaction
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/Jomesi149/Implementasi-LASTI/backend/internal/sqlutil"
+)
+
+const creditCardColumns = `w.id, w.user_id, w.name, w.currency, w.balance, c.credit_limit, c.statement_day, c.payment_due_days, c.reminder_days, c.created_at`
+
+func scanCreditCard(row rowScanner) (CreditCard, error) {
+	var c CreditCard
+	err := row.Scan(&c.WalletID, &c.UserID, &c.Name, &c.Currency, &c.Balance, &c.CreditLimit, &c.StatementDay, &c.PaymentDueDays, &c.ReminderDays, &c.CreatedAt)
+	return c, err
+}
+
+// CreateCreditCard inserts the card wallet with its opening debt and its statement
+// settings in one database transaction.
+func (r *SQLRepository) CreateCreditCard(ctx context.Context, w Wallet, c CreditCard) (err error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	if err = insertWallet(ctx, tx, w); err != nil {
+		return err
+	}
+	query := `INSERT INTO finance.credit_cards (wallet_id, user_id, credit_limit, statement_day, payment_due_days, reminder_days, created_at, updated_at)
+		VALUES ($1,$2,$3,$4,$5,$6,NOW(),NOW())`
+	if _, err = tx.ExecContext(ctx, query, c.WalletID, c.UserID, c.CreditLimit, c.StatementDay, c.PaymentDueDays, c.ReminderDays); err != nil {
+		return fmt.Errorf("insert credit card: %w", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("commit tx: %w", err)
+	}
+	return nil
+}
+
+func (r *SQLRepository) ListCreditCards(ctx context.Context, userID uuid.UUID) ([]CreditCard, error) {
+	return r.queryCreditCards(ctx, `WHERE c.user_id = $1`, userID)
+}
+
+// AllCreditCards returns the live credit cards of every user, for reminders.
+func (r *SQLRepository) AllCreditCards(ctx context.Context) ([]CreditCard, error) {
+	return r.queryCreditCards(ctx, ``)
+}
+
+func (r *SQLRepository) queryCreditCards(ctx context.Context, where string, args ...any) ([]CreditCard, error) {
+	query := fmt.Sprintf(`SELECT %s FROM finance.credit_cards c
+		JOIN finance.wallets w ON w.id = c.wallet_id AND w.deleted_at IS NULL
+		%s ORDER BY w.name ASC, w.id ASC`, creditCardColumns, where)
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("select credit cards: %w", err)
+	}
+	defer rows.Close()
+
+	out := []CreditCard{}
+	for rows.Next() {
+		c, err := scanCreditCard(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, c)
+	}
+	return out, rows.Err()
+}
+
+func (r *SQLRepository) GetCreditCard(ctx context.Context, userID, walletID uuid.UUID) (*CreditCard, error) {
+	query := fmt.Sprintf(`SELECT %s FROM finance.credit_cards c
+		JOIN finance.wallets w ON w.id = c.wallet_id AND w.deleted_at IS NULL
+		WHERE c.wallet_id = $1 AND c.user_id = $2`, creditCardColumns)
+	c, err := scanCreditCard(r.db.QueryRowContext(ctx, query, walletID, userID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrCreditCardNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("select credit card: %w", err)
+	}
+	return &c, nil
+}
+
+func (r *SQLRepository) UpdateCreditCard(ctx context.Context, c CreditCard) error {
+	query := `UPDATE finance.credit_cards SET credit_limit = $3, statement_day = $4, payment_due_days = $5, reminder_days = $6, updated_at = NOW()
+		WHERE wallet_id = $1 AND user_id = $2`
+	res, err := r.db.ExecContext(ctx, query, c.WalletID, c.UserID, c.CreditLimit, c.StatementDay, c.PaymentDueDays, c.ReminderDays)
+	if err != nil {
+		return fmt.Errorf("update credit card: %w", err)
+	}
+	return sqlutil.ExpectAffected(res, ErrCreditCardNotFound)
+}
+
+// CreditCardActivity returns the card balance and the payments and charges booked after
+// the statement that closed on closedOn.
+func (r *SQLRepository) CreditCardActivity(ctx context.Context, walletID uuid.UUID, closedOn time.Time) (*cardActivity, error) {
+	query := `SELECT w.balance::TEXT,
+			COALESCE(SUM(t.amount) FILTER (WHERE t.kind = 'in' AND t.transfer_id IS NOT NULL), 0)::TEXT,
+			COALESCE(SUM(t.amount) FILTER (WHERE t.kind = 'in' AND t.transfer_id IS NULL), 0)::TEXT,
+			COALESCE(SUM(t.amount) FILTER (WHERE t.kind = 'out'), 0)::TEXT
+		FROM finance.wallets w
+		LEFT JOIN finance.transactions t ON t.wallet_id = w.id AND t.deleted_at IS NULL AND NOT t.planned AND t.occurred_at >= $2::DATE + 1
+		WHERE w.id = $1
+		GROUP BY w.balance`
+	var balance, payments, credits, charges string
+	err := r.db.QueryRowContext(ctx, query, walletID, closedOn.Format("2006-01-02")).Scan(&balance, &payments, &credits, &charges)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrCreditCardNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("select credit card activity: %w", err)
+	}
+	a := &cardActivity{}
+	a.Balance, _ = sqlutil.ToCents(balance)
+	a.Payments, _ = sqlutil.ToCents(payments)
+	a.Credits, _ = sqlutil.ToCents(credits)
+	a.Charges, _ = sqlutil.ToCents(charges)
+	return a, nil
+}
@@ -0,0 +1,144 @@
+package transaction
+
+import (
+	"testing"
+	"time"
+)
+
+func date(y int, m time.Month, d int) time.Time {
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+func TestClosingDate(t *testing.T) {
+	tests := []struct {
+		year  int
+		month time.Month
+		day   int
+		want  time.Time
+	}{
+		{2024, time.March, 15, date(2024, 3, 15)},
+		{2024, time.January, 31, date(2024, 1, 31)},
+		// Bulan pendek: dijepit ke hari terakhir
+		{2024, time.February, 29, date(2024, 2, 29)},
+		{2024, time.February, 30, date(2024, 2, 29)},
+		{2024, time.February, 31, date(2024, 2, 29)},
+		{2023, time.February, 29, date(2023, 2, 28)},
+		{2023, time.February, 31, date(2023, 2, 28)},
+		{2024, time.April, 31, date(2024, 4, 30)},
+		{2024, time.November, 31, date(2024, 11, 30)},
+		// Bulan di luar 1..12 bergulir ke tahun sebelum/sesudahnya
+		{2024, 0, 31, date(2023, 12, 31)},
+		{2024, 13, 29, date(2025, 1, 29)},
+		{2025, 14, 30, date(2026, 2, 28)},
+	}
+	for _, tt := range tests {
+		if got := closingDate(tt.year, tt.month, tt.day); !got.Equal(tt.want) {
+			t.Errorf("closingDate(%d, %d, %d) = %s, want %s", tt.year, tt.month, tt.day, got.Format("2006-01-02"), tt.want.Format("2006-01-02"))
+		}
+	}
+}
+
+func TestStatementDates(t *testing.T) {
+	tests := []struct {
+		name         string
+		today        time.Time
+		statementDay int
+		closed, next time.Time
+	}{
+		{"mid cycle", date(2024, 3, 20), 15, date(2024, 3, 15), date(2024, 4, 15)},
+		{"on the statement day", date(2024, 3, 15), 15, date(2024, 3, 15), date(2024, 4, 15)},
+		{"day before the statement day", date(2024, 3, 14), 15, date(2024, 2, 15), date(2024, 3, 15)},
+		{"year rollover back", date(2024, 1, 10), 25, date(2023, 12, 25), date(2024, 1, 25)},
+		{"year rollover forward", date(2023, 12, 28), 25, date(2023, 12, 25), date(2024, 1, 25)},
+		{"day 31 in february", date(2024, 2, 29), 31, date(2024, 2, 29), date(2024, 3, 31)},
+		{"day 31 before february closes", date(2024, 2, 28), 31, date(2024, 1, 31), date(2024, 2, 29)},
+		{"day 31 after a 30-day month", date(2024, 5, 1), 31, date(2024, 4, 30), date(2024, 5, 31)},
+		{"day 30 in a non-leap february", date(2023, 3, 1), 30, date(2023, 2, 28), date(2023, 3, 30)},
+		{"day 29 in a leap february", date(2024, 2, 29), 29, date(2024, 2, 29), date(2024, 3, 29)},
+		{"day 29 in a non-leap february", date(2023, 2, 28), 29, date(2023, 2, 28), date(2023, 3, 29)},
+		{"day 29 before a non-leap february closes", date(2023, 2, 27), 29, date(2023, 1, 29), date(2023, 2, 28)},
+		{"day 31 over new year", date(2024, 1, 1), 31, date(2023, 12, 31), date(2024, 1, 31)},
+		{"day 1", date(2024, 12, 31), 1, date(2024, 12, 1), date(2025, 1, 1)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			closed, next := statementDates(tt.today, tt.statementDay)
+			if !closed.Equal(tt.closed) || !next.Equal(tt.next) {
+				t.Errorf("statementDates(%s, %d) = %s, %s; want %s, %s", tt.today.Format("2006-01-02"), tt.statementDay,
+					closed.Format("2006-01-02"), next.Format("2006-01-02"), tt.closed.Format("2006-01-02"), tt.next.Format("2006-01-02"))
+			}
+		})
+	}
+}
+
+func TestBuildStatement(t *testing.T) {
+	card := CreditCard{CreditLimit: "10000000.00", StatementDay: 25, PaymentDueDays: 15}
+	closed, next := date(2023, 12, 25), date(2024, 1, 25)
+	tests := []struct {
+		name  string
+		a     cardActivity
+		today time.Time
+		want  CreditCardStatement
+	}{
+		{
+			name:  "nothing since the statement",
+			a:     cardActivity{Balance: -200000000},
+			today: date(2024, 1, 2),
+			want: CreditCardStatement{CurrentDebt: "2000000.00", AvailableCredit: "8000000.00", StatementBalance: "2000000.00",
+				PaidSinceStatement: "0.00", CreditsSinceStatement: "0.00", AmountDue: "2000000.00", NewCharges: "0.00", NextStatementBalance: "2000000.00"},
+		},
+		{
+			name:  "partial payment and new charges",
+			a:     cardActivity{Balance: -180000000, Payments: 50000000, Charges: 30000000},
+			today: date(2024, 1, 5),
+			want: CreditCardStatement{CurrentDebt: "1800000.00", AvailableCredit: "8200000.00", StatementBalance: "2000000.00",
+				PaidSinceStatement: "500000.00", CreditsSinceStatement: "0.00", AmountDue: "1500000.00", NewCharges: "300000.00", NextStatementBalance: "1800000.00"},
+		},
+		{
+			// Refund bukan pembayaran: tagihan tetap harus dibayar, refund mengurangi tagihan berikutnya
+			name:  "refund is a credit, not a payment",
+			a:     cardActivity{Balance: -170000000, Credits: 30000000},
+			today: date(2024, 1, 5),
+			want: CreditCardStatement{CurrentDebt: "1700000.00", AvailableCredit: "8300000.00", StatementBalance: "2000000.00",
+				PaidSinceStatement: "0.00", CreditsSinceStatement: "300000.00", AmountDue: "2000000.00", NewCharges: "0.00", NextStatementBalance: "1700000.00"},
+		},
+		{
+			name:  "overpaid",
+			a:     cardActivity{Balance: 50000000, Payments: 250000000},
+			today: date(2024, 1, 5),
+			want: CreditCardStatement{CurrentDebt: "0.00", AvailableCredit: "10500000.00", StatementBalance: "2000000.00",
+				PaidSinceStatement: "2500000.00", CreditsSinceStatement: "0.00", AmountDue: "0.00", NewCharges: "0.00", NextStatementBalance: "0.00"},
+		},
+		{
+			name:  "unpaid after the due date",
+			a:     cardActivity{Balance: -120000000, Payments: 10000000, Credits: 20000000, Charges: 50000000},
+			today: date(2024, 1, 10),
+			want: CreditCardStatement{CurrentDebt: "1200000.00", AvailableCredit: "8800000.00", StatementBalance: "1000000.00",
+				PaidSinceStatement: "100000.00", CreditsSinceStatement: "200000.00", AmountDue: "900000.00", NewCharges: "500000.00", NextStatementBalance: "1200000.00", Overdue: true},
+		},
+		{
+			name:  "over the limit",
+			a:     cardActivity{Balance: -1100000000, Charges: 100000000},
+			today: date(2024, 1, 9),
+			want: CreditCardStatement{CurrentDebt: "11000000.00", AvailableCredit: "0.00", StatementBalance: "10000000.00",
+				PaidSinceStatement: "0.00", CreditsSinceStatement: "0.00", AmountDue: "10000000.00", NewCharges: "1000000.00", NextStatementBalance: "11000000.00"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			st := buildStatement(card, tt.a, tt.today, closed, next)
+			if !st.DueDate.Equal(date(2024, 1, 9)) || !st.NextDueDate.Equal(date(2024, 2, 9)) {
+				t.Errorf("due dates = %s, %s", st.DueDate.Format("2006-01-02"), st.NextDueDate.Format("2006-01-02"))
+			}
+			got := []any{st.CurrentDebt, st.AvailableCredit, st.StatementBalance, st.PaidSinceStatement, st.CreditsSinceStatement, st.AmountDue, st.NewCharges, st.NextStatementBalance, st.Overdue}
+			w := tt.want
+			want := []any{w.CurrentDebt, w.AvailableCredit, w.StatementBalance, w.PaidSinceStatement, w.CreditsSinceStatement, w.AmountDue, w.NewCharges, w.NextStatementBalance, w.Overdue}
+			for i := range want {
+				if got[i] != want[i] {
+					t.Errorf("statement = %v, want %v", got, want)
+					break
+				}
+			}
+		})
+	}
+}
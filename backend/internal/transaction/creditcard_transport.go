@@ -0,0 +1,155 @@
+package transaction
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"github.com/Jomesi149/Implementasi-LASTI/backend/pkg/response"
+)
+
+func (h *HTTPHandler) registerCreditCardRoutes(r chi.Router) {
+	r.Route("/credit-cards", func(r chi.Router) {
+		r.Post("/", h.handleCreateCreditCard)
+		r.Get("/", h.handleListCreditCards)
+		r.Get("/{id}", h.handleGetCreditCard)
+		r.Put("/{id}", h.handleUpdateCreditCard)
+		r.Post("/{id}/payments", h.handlePayCreditCard)
+	})
+}
+
+type createCreditCardReq struct {
+	Name     string `json:"name"`
+	Currency string `json:"currency"`
+	// Debt is the balance owed when the card is added, as a positive amount.
+	Debt           string `json:"debt"`
+	CreditLimit    string `json:"credit_limit"`
+	StatementDay   int    `json:"statement_day"`
+	PaymentDueDays int    `json:"payment_due_days"`
+	ReminderDays   *int   `json:"reminder_days"`
+}
+
+func (h *HTTPHandler) handleCreateCreditCard(w http.ResponseWriter, r *http.Request) {
+	uid, err := getUserIDFromHeader(r)
+	if err != nil {
+		response.Error(w, http.StatusUnauthorized, "missing X-User-ID header")
+		return
+	}
+	var req createCreditCardReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.Error(w, http.StatusBadRequest, "invalid payload")
+		return
+	}
+	st, err := h.service.CreateCreditCard(r.Context(), NewCreditCard{
+		UserID:         uid,
+		Name:           req.Name,
+		Currency:       req.Currency,
+		Debt:           req.Debt,
+		CreditLimit:    req.CreditLimit,
+		StatementDay:   req.StatementDay,
+		PaymentDueDays: req.PaymentDueDays,
+		ReminderDays:   req.ReminderDays,
+	})
+	if err != nil {
+		response.Error(w, errorStatus(err), err.Error())
+		return
+	}
+	response.JSON(w, http.StatusCreated, st)
+}
+
+func (h *HTTPHandler) handleListCreditCards(w http.ResponseWriter, r *http.Request) {
+	uid, err := getUserIDFromHeader(r)
+	if err != nil {
+		response.Error(w, http.StatusUnauthorized, "missing X-User-ID header")
+		return
+	}
+	list, err := h.service.ListCreditCards(r.Context(), uid)
+	if err != nil {
+		response.Error(w, errorStatus(err), err.Error())
+		return
+	}
+	response.JSON(w, http.StatusOK, list)
+}
+
+func (h *HTTPHandler) handleGetCreditCard(w http.ResponseWriter, r *http.Request) {
+	uid, id, ok := idParams(w, r, "credit card")
+	if !ok {
+		return
+	}
+	st, err := h.service.GetCreditCard(r.Context(), uid, id)
+	if err != nil {
+		response.Error(w, errorStatus(err), err.Error())
+		return
+	}
+	response.JSON(w, http.StatusOK, st)
+}
+
+type updateCreditCardReq struct {
+	CreditLimit    *string `json:"credit_limit"`
+	StatementDay   *int    `json:"statement_day"`
+	PaymentDueDays *int    `json:"payment_due_days"`
+	ReminderDays   *int    `json:"reminder_days"`
+}
+
+func (h *HTTPHandler) handleUpdateCreditCard(w http.ResponseWriter, r *http.Request) {
+	uid, id, ok := idParams(w, r, "credit card")
+	if !ok {
+		return
+	}
+	var req updateCreditCardReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.Error(w, http.StatusBadRequest, "invalid payload")
+		return
+	}
+	st, err := h.service.UpdateCreditCard(r.Context(), uid, id, CreditCardUpdate{
+		CreditLimit:    req.CreditLimit,
+		StatementDay:   req.StatementDay,
+		PaymentDueDays: req.PaymentDueDays,
+		ReminderDays:   req.ReminderDays,
+	})
+	if err != nil {
+		response.Error(w, errorStatus(err), err.Error())
+		return
+	}
+	response.JSON(w, http.StatusOK, st)
+}
+
+type payCreditCardReq struct {
+	FromWalletID string `json:"from_wallet_id"`
+	Amount       string `json:"amount"`
+	// ToAmount is optional; it is the amount credited to a card in another currency.
+	ToAmount   string     `json:"to_amount"`
+	Note       *string    `json:"note"`
+	OccurredAt *time.Time `json:"occurred_at"`
+}
+
+// handlePayCreditCard books a payment from another wallet as a transfer into the card.
+func (h *HTTPHandler) handlePayCreditCard(w http.ResponseWriter, r *http.Request) {
+	uid, id, ok := idParams(w, r, "credit card")
+	if !ok {
+		return
+	}
+	var req payCreditCardReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.Error(w, http.StatusBadRequest, "invalid payload")
+		return
+	}
+	from, err := uuid.Parse(req.FromWalletID)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "invalid from_wallet_id")
+		return
+	}
+	in := NewTransfer{UserID: uid, FromWalletID: from, Amount: req.Amount, ToAmount: req.ToAmount, Note: req.Note}
+	if req.OccurredAt != nil {
+		in.OccurredAt = *req.OccurredAt
+	}
+	tr, err := h.service.PayCreditCard(r.Context(), id, in)
+	if err != nil {
+		response.Error(w, errorStatus(err), err.Error())
+		return
+	}
+	response.JSON(w, http.StatusCreated, tr)
+}
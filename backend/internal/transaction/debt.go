@@ -0,0 +1,564 @@
+package transaction
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/Jomesi149/Implementasi-LASTI/backend/internal/sqlutil"
+)
+
+// Debt directions.
+const (
+	// DebtLent is money the user lent out; the counterparty owes it.
+	DebtLent = "lent"
+	// DebtBorrowed is money the user borrowed; they owe the counterparty.
+	DebtBorrowed = "borrowed"
+)
+
+// Debt statuses, derived from the outstanding amount.
+const (
+	DebtOpen    = "open"
+	DebtSettled = "settled"
+)
+
+// SourceDebt marks history rows written when transactions are linked to or unlinked
+// from a debt.
+const SourceDebt = "debt"
+
+var (
+	// ErrDebtNotFound is returned when the debt does not exist for the user.
+	ErrDebtNotFound = errors.New("debt_not_found")
+	// ErrInvalidDebt indicates a debt or repayment with a bad direction, amount or
+	// transaction.
+	ErrInvalidDebt = errors.New("invalid_debt")
+	// ErrDebtState is returned when a transaction is already linked elsewhere, or a
+	// debt still has transactions when it is deleted.
+	ErrDebtState = errors.New("debt_state_conflict")
+)
+
+// Debt is money lent to or borrowed from a counterparty, which is a payee. The money
+// moving in and out of wallets are ordinary transactions carrying the debt's id: the
+// disbursement ("out" when lent, "in" when borrowed) and repayments the other way.
+// Interest is simple, InterestRate percent a year on the principal from StartedOn until
+// the debt is settled.
+type Debt struct {
+	ID             uuid.UUID  `json:"id"`
+	UserID         uuid.UUID  `json:"user_id"`
+	CounterpartyID uuid.UUID  `json:"counterparty_id"`
+	Counterparty   string     `json:"counterparty"`
+	Direction      string     `json:"direction"`
+	Principal      string     `json:"principal"`
+	Currency       string     `json:"currency"`
+	InterestRate   string     `json:"interest_rate"`
+	StartedOn      time.Time  `json:"started_on"`
+	DueDate        *time.Time `json:"due_date,omitempty"`
+	Note           *string    `json:"note,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+
+	// Derived from the linked transactions when the debt is read.
+	Interest     string        `json:"interest"`
+	Repaid       string        `json:"repaid"`
+	Outstanding  string        `json:"outstanding"`
+	Status       string        `json:"status"`
+	SettledOn    *time.Time    `json:"settled_on,omitempty"`
+	Overdue      bool          `json:"overdue"`
+	Transactions []Transaction `json:"transactions,omitempty"`
+}
+
+// CounterpartyBalance sums the open debts with one counterparty in one currency.
+// Receivable is what they owe the user, Payable what the user owes them.
+type CounterpartyBalance struct {
+	CounterpartyID uuid.UUID `json:"counterparty_id"`
+	Counterparty   string    `json:"counterparty"`
+	Currency       string    `json:"currency"`
+	Receivable     string    `json:"receivable"`
+	Payable        string    `json:"payable"`
+	Net            string    `json:"net"`
+	OpenDebts      int       `json:"open_debts"`
+}
+
+// NewDebt is the input for Service.CreateDebt. The counterparty is given by id or by
+// name, which creates the payee when needed. With WalletID the disbursement is booked
+// in that wallet; with TransactionID an existing transaction becomes the disbursement
+// and Amount may be left empty. Without either only the debt is recorded.
+type NewDebt struct {
+	UserID         uuid.UUID
+	CounterpartyID *uuid.UUID
+	Counterparty   string
+	Direction      string
+	Amount         string
+	Currency       string
+	InterestRate   string
+	StartedOn      time.Time
+	DueDate        *time.Time
+	Note           *string
+	WalletID       *uuid.UUID
+	TransactionID  *uuid.UUID
+}
+
+// DebtUpdate changes the terms of a debt; nil fields are kept and ClearDueDate removes
+// the due date.
+type DebtUpdate struct {
+	InterestRate *string
+	DueDate      *time.Time
+	ClearDueDate bool
+	Note         *string
+}
+
+// DebtPayment books or links a repayment. Either WalletID with Amount books a new
+// transaction, or TransactionID links an existing one.
+type DebtPayment struct {
+	WalletID      *uuid.UUID
+	Amount        string
+	OccurredAt    time.Time
+	Note          *string
+	TransactionID *uuid.UUID
+}
+
+// disbursementKind is the kind of the transaction that hands out the money of a debt;
+// repayments have the other kind.
+func disbursementKind(direction string) string {
+	if direction == DebtLent {
+		return "out"
+	}
+	return "in"
+}
+
+func repaymentKind(direction string) string {
+	if direction == DebtLent {
+		return "in"
+	}
+	return "out"
+}
+
+func parseInterestRate(rate string) (string, error) {
+	if strings.TrimSpace(rate) == "" {
+		return "0", nil
+	}
+	v, err := strconv.ParseFloat(strings.TrimSpace(rate), 64)
+	if err != nil || v < 0 || v >= 1000 || math.IsNaN(v) {
+		return "", fmt.Errorf("%w: interest_rate must be a percentage between 0 and 1000", ErrInvalidDebt)
+	}
+	return strconv.FormatFloat(v, 'f', -1, 64), nil
+}
+
+// accruedInterest is the simple interest in cents on principal from start to end.
+func accruedInterest(principal int64, rate string, start, end time.Time) int64 {
+	r, _ := strconv.ParseFloat(rate, 64)
+	days := math.Floor(calendarDay(end).Sub(calendarDay(start)).Hours() / 24)
+	if r <= 0 || days <= 0 {
+		return 0
+	}
+	return int64(math.Round(float64(principal) * r / 100 * days / 365))
+}
+
+// settle fills the derived fields of d from its live transactions as of today. The
+// debt is settled on the first repayment that covers the principal plus the interest
+// accrued by then.
+func (d *Debt) settle(txs []Transaction, today time.Time) {
+	principal, _ := sqlutil.ToCents(d.Principal)
+	repaymentsKind := repaymentKind(d.Direction)
+	var repayments []Transaction
+	for _, t := range txs {
+		if t.Kind == repaymentsKind {
+			repayments = append(repayments, t)
+		}
+	}
+	sort.SliceStable(repayments, func(i, j int) bool { return repayments[i].OccurredAt.Before(repayments[j].OccurredAt) })
+
+	var repaid int64
+	d.SettledOn = nil
+	for _, t := range repayments {
+		cents, _ := sqlutil.ToCents(t.Amount)
+		repaid += cents
+		if d.SettledOn == nil && repaid >= principal+accruedInterest(principal, d.InterestRate, d.StartedOn, t.OccurredAt) {
+			on := calendarDay(t.OccurredAt)
+			d.SettledOn = &on
+		}
+	}
+	end := today
+	if d.SettledOn != nil {
+		end = *d.SettledOn
+	}
+	interest := accruedInterest(principal, d.InterestRate, d.StartedOn, end)
+
+	d.Interest = sqlutil.CentsString(interest)
+	d.Repaid = sqlutil.CentsString(repaid)
+	d.Outstanding = sqlutil.CentsString(max(principal+interest-repaid, 0))
+	d.Status = DebtOpen
+	if d.SettledOn != nil {
+		d.Status = DebtSettled
+	}
+	d.Overdue = d.Status == DebtOpen && d.DueDate != nil && today.After(calendarDay(*d.DueDate))
+}
+
+// CreateDebt records money lent or borrowed.
+func (s *Service) CreateDebt(ctx context.Context, in NewDebt) (*Debt, error) {
+	if in.Direction != DebtLent && in.Direction != DebtBorrowed {
+		return nil, fmt.Errorf("%w: direction must be %q or %q", ErrInvalidDebt, DebtLent, DebtBorrowed)
+	}
+	if in.WalletID != nil && in.TransactionID != nil {
+		return nil, fmt.Errorf("%w: give either wallet_id or transaction_id", ErrInvalidDebt)
+	}
+	rate, err := parseInterestRate(in.InterestRate)
+	if err != nil {
+		return nil, err
+	}
+	startGiven := !in.StartedOn.IsZero()
+	if !startGiven {
+		in.StartedOn = time.Now()
+	}
+	d := Debt{
+		ID:           uuid.New(),
+		UserID:       in.UserID,
+		Direction:    in.Direction,
+		InterestRate: rate,
+		StartedOn:    calendarDay(in.StartedOn),
+		DueDate:      in.DueDate,
+		Note:         in.Note,
+		CreatedAt:    time.Now(),
+	}
+	if d.DueDate != nil {
+		due := calendarDay(*d.DueDate)
+		if due.Before(d.StartedOn) {
+			return nil, fmt.Errorf("%w: due_date is before the start of the debt", ErrInvalidDebt)
+		}
+		d.DueDate = &due
+	}
+
+	if err := s.resolveCounterparty(ctx, &d, in); err != nil {
+		return nil, err
+	}
+
+	var movement *Transaction
+	var linkID *uuid.UUID
+	switch {
+	case in.TransactionID != nil:
+		t, err := s.repo.GetTransaction(ctx, in.UserID, *in.TransactionID)
+		if err != nil {
+			return nil, err
+		}
+		if err := checkDebtTransaction(d, *t, disbursementKind(d.Direction)); err != nil {
+			return nil, err
+		}
+		if in.Amount != "" && !sameCents(in.Amount, t.Amount) {
+			return nil, fmt.Errorf("%w: amount differs from the transaction", ErrInvalidDebt)
+		}
+		d.Principal, d.Currency, linkID = t.Amount, t.Currency, &t.ID
+		if !startGiven {
+			d.StartedOn = calendarDay(t.OccurredAt)
+		}
+	case in.WalletID != nil:
+		w, err := s.repo.GetWallet(ctx, in.UserID, *in.WalletID)
+		if err != nil {
+			return nil, err
+		}
+		if in.Currency != "" && !strings.EqualFold(strings.TrimSpace(in.Currency), w.Currency) {
+			return nil, fmt.Errorf("%w: currency differs from the wallet", ErrInvalidDebt)
+		}
+		d.Currency = w.Currency
+		movement = &Transaction{ID: uuid.New(), UserID: in.UserID, WalletID: w.ID, Kind: disbursementKind(d.Direction),
+			Note: in.Note, OccurredAt: in.StartedOn, PayeeID: &d.CounterpartyID, DebtID: &d.ID, CreatedAt: time.Now()}
+	default:
+		if d.Currency, err = s.walletCurrency(ctx, in.UserID, in.Currency); err != nil {
+			return nil, err
+		}
+	}
+	if linkID == nil {
+		cents, ok := sqlutil.ToCents(in.Amount)
+		if !ok || cents <= 0 {
+			return nil, fmt.Errorf("%w: amount must be a positive number", ErrInvalidDebt)
+		}
+		d.Principal = sqlutil.CentsString(cents)
+		if movement != nil {
+			movement.Amount = d.Principal
+		}
+	}
+
+	ctx = userChange(ctx, SourceDebt, in.UserID, &d.ID)
+	if err := s.repo.CreateDebt(ctx, d, movement, linkID); err != nil {
+		return nil, err
+	}
+	return s.GetDebt(ctx, in.UserID, d.ID)
+}
+
+// resolveCounterparty sets the counterparty of d from its id or name.
+func (s *Service) resolveCounterparty(ctx context.Context, d *Debt, in NewDebt) error {
+	if in.CounterpartyID != nil {
+		n, err := s.repo.CountLabels(ctx, PayeeLabels, in.UserID, []uuid.UUID{*in.CounterpartyID})
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			return ErrPayeeNotFound
+		}
+		d.CounterpartyID = *in.CounterpartyID
+		return nil
+	}
+	name, err := normalizeLabel(in.Counterparty)
+	if err != nil {
+		return fmt.Errorf("%w: counterparty is required", ErrInvalidDebt)
+	}
+	p, err := s.repo.FindOrCreatePayee(ctx, in.UserID, name)
+	if err != nil {
+		return err
+	}
+	d.CounterpartyID, d.Counterparty = p.ID, p.Name
+	return nil
+}
+
+// checkDebtTransaction checks that t can be linked to d with the given kind.
+func checkDebtTransaction(d Debt, t Transaction, kind string) error {
+	switch {
+	case t.TransferID != nil:
+		return fmt.Errorf("%w: a transfer leg cannot belong to a debt", ErrInvalidDebt)
+	case t.DebtID != nil && *t.DebtID != d.ID:
+		return fmt.Errorf("%w: transaction already belongs to another debt", ErrDebtState)
+	case t.DebtID != nil:
+		return fmt.Errorf("%w: transaction already belongs to this debt", ErrDebtState)
+	case t.Kind != kind:
+		return fmt.Errorf("%w: transaction must be of kind %q", ErrInvalidDebt, kind)
+	case d.Currency != "" && t.Currency != d.Currency:
+		return fmt.Errorf("%w: transaction is in %s, the debt in %s", ErrInvalidDebt, t.Currency, d.Currency)
+	case len(t.Splits) > 0:
+		return fmt.Errorf("%w: a split transaction cannot belong to a debt", ErrInvalidDebt)
+	}
+	return nil
+}
+
+func sameCents(a, b string) bool {
+	ca, okA := sqlutil.ToCents(a)
+	cb, okB := sqlutil.ToCents(b)
+	return okA && okB && ca == cb
+}
+
+// ListDebts returns the user's debts, optionally only with one counterparty or in one
+// status.
+func (s *Service) ListDebts(ctx context.Context, userID uuid.UUID, counterpartyID *uuid.UUID, status string) ([]Debt, error) {
+	if status != "" && status != DebtOpen && status != DebtSettled {
+		return nil, fmt.Errorf("%w: status must be %q or %q", ErrInvalidFilter, DebtOpen, DebtSettled)
+	}
+	debts, err := s.loadDebts(ctx, userID, counterpartyID)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]Debt, 0, len(debts))
+	for _, d := range debts {
+		if status == "" || d.Status == status {
+			d.Transactions = nil
+			out = append(out, d)
+		}
+	}
+	return out, nil
+}
+
+// GetDebt returns a debt with its live transactions.
+func (s *Service) GetDebt(ctx context.Context, userID, id uuid.UUID) (*Debt, error) {
+	d, err := s.repo.GetDebt(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+	txs, err := s.repo.DebtTransactions(ctx, userID, []uuid.UUID{id})
+	if err != nil {
+		return nil, err
+	}
+	d.Transactions = txs[id]
+	d.settle(d.Transactions, calendarDay(time.Now()))
+	return d, nil
+}
+
+// loadDebts reads debts with their derived fields.
+func (s *Service) loadDebts(ctx context.Context, userID uuid.UUID, counterpartyID *uuid.UUID) ([]Debt, error) {
+	debts, err := s.repo.ListDebts(ctx, userID, counterpartyID)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]uuid.UUID, len(debts))
+	for i, d := range debts {
+		ids[i] = d.ID
+	}
+	txs, err := s.repo.DebtTransactions(ctx, userID, ids)
+	if err != nil {
+		return nil, err
+	}
+	today := calendarDay(time.Now())
+	for i := range debts {
+		debts[i].Transactions = txs[debts[i].ID]
+		debts[i].settle(debts[i].Transactions, today)
+	}
+	return debts, nil
+}
+
+// CounterpartyBalances sums the outstanding amounts of the open debts per counterparty
+// and currency, largest net amount first.
+func (s *Service) CounterpartyBalances(ctx context.Context, userID uuid.UUID) ([]CounterpartyBalance, error) {
+	debts, err := s.loadDebts(ctx, userID, nil)
+	if err != nil {
+		return nil, err
+	}
+	type key struct {
+		id       uuid.UUID
+		currency string
+	}
+	type sums struct {
+		name                string
+		receivable, payable int64
+		open                int
+	}
+	totals := map[key]*sums{}
+	var order []key
+	for _, d := range debts {
+		if d.Status != DebtOpen {
+			continue
+		}
+		k := key{d.CounterpartyID, d.Currency}
+		t, ok := totals[k]
+		if !ok {
+			t = &sums{name: d.Counterparty}
+			totals[k] = t
+			order = append(order, k)
+		}
+		cents, _ := sqlutil.ToCents(d.Outstanding)
+		if d.Direction == DebtLent {
+			t.receivable += cents
+		} else {
+			t.payable += cents
+		}
+		t.open++
+	}
+
+	out := make([]CounterpartyBalance, 0, len(order))
+	for _, k := range order {
+		t := totals[k]
+		out = append(out, CounterpartyBalance{
+			CounterpartyID: k.id,
+			Counterparty:   t.name,
+			Currency:       k.currency,
+			Receivable:     sqlutil.CentsString(t.receivable),
+			Payable:        sqlutil.CentsString(t.payable),
+			Net:            sqlutil.CentsString(t.receivable - t.payable),
+			OpenDebts:      t.open,
+		})
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		ni, _ := sqlutil.ToCents(out[i].Net)
+		nj, _ := sqlutil.ToCents(out[j].Net)
+		if abs(ni) != abs(nj) {
+			return abs(ni) > abs(nj)
+		}
+		return out[i].Counterparty < out[j].Counterparty
+	})
+	return out, nil
+}
+
+func abs(n int64) int64 {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// UpdateDebt changes the interest rate, due date or note of a debt.
+func (s *Service) UpdateDebt(ctx context.Context, userID, id uuid.UUID, u DebtUpdate) (*Debt, error) {
+	d, err := s.repo.GetDebt(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+	if u.InterestRate != nil {
+		if d.InterestRate, err = parseInterestRate(*u.InterestRate); err != nil {
+			return nil, err
+		}
+	}
+	switch {
+	case u.ClearDueDate:
+		d.DueDate = nil
+	case u.DueDate != nil:
+		due := calendarDay(*u.DueDate)
+		if due.Before(d.StartedOn) {
+			return nil, fmt.Errorf("%w: due_date is before the start of the debt", ErrInvalidDebt)
+		}
+		d.DueDate = &due
+	}
+	if u.Note != nil {
+		d.Note = u.Note
+	}
+	if err := s.repo.UpdateDebt(ctx, *d); err != nil {
+		return nil, err
+	}
+	return s.GetDebt(ctx, userID, id)
+}
+
+// DeleteDebt removes a debt that no longer has live transactions.
+func (s *Service) DeleteDebt(ctx context.Context, userID, id uuid.UUID) error {
+	return s.repo.DeleteDebt(ctx, userID, id)
+}
+
+// AddDebtPayment books or links a repayment of a debt.
+func (s *Service) AddDebtPayment(ctx context.Context, userID, debtID uuid.UUID, p DebtPayment) (*Debt, error) {
+	d, err := s.repo.GetDebt(ctx, userID, debtID)
+	if err != nil {
+		return nil, err
+	}
+	ctx = userChange(ctx, SourceDebt, userID, &d.ID)
+	kind := repaymentKind(d.Direction)
+
+	switch {
+	case p.TransactionID != nil && p.WalletID != nil:
+		return nil, fmt.Errorf("%w: give either wallet_id or transaction_id", ErrInvalidDebt)
+	case p.TransactionID != nil:
+		t, err := s.repo.GetTransaction(ctx, userID, *p.TransactionID)
+		if err != nil {
+			return nil, err
+		}
+		if err := checkDebtTransaction(*d, *t, kind); err != nil {
+			return nil, err
+		}
+		if err := s.repo.LinkDebtTransaction(ctx, userID, d.ID, t.ID, kind, d.Currency); err != nil {
+			return nil, err
+		}
+	case p.WalletID != nil:
+		cents, ok := sqlutil.ToCents(p.Amount)
+		if !ok || cents <= 0 {
+			return nil, fmt.Errorf("%w: amount must be a positive number", ErrInvalidDebt)
+		}
+		w, err := s.repo.GetWallet(ctx, userID, *p.WalletID)
+		if err != nil {
+			return nil, err
+		}
+		if w.Currency != d.Currency {
+			return nil, fmt.Errorf("%w: wallet is in %s, the debt in %s", ErrInvalidDebt, w.Currency, d.Currency)
+		}
+		if p.OccurredAt.IsZero() {
+			p.OccurredAt = time.Now()
+		}
+		t := Transaction{ID: uuid.New(), UserID: userID, WalletID: w.ID, Amount: sqlutil.CentsString(cents), Kind: kind,
+			Note: p.Note, OccurredAt: p.OccurredAt, PayeeID: &d.CounterpartyID, DebtID: &d.ID, CreatedAt: time.Now()}
+		if err := s.repo.CreateTransaction(ctx, t); err != nil {
+			return nil, fmt.Errorf("create repayment: %w", err)
+		}
+	default:
+		return nil, fmt.Errorf("%w: wallet_id or transaction_id is required", ErrInvalidDebt)
+	}
+	return s.GetDebt(ctx, userID, d.ID)
+}
+
+// UnlinkDebtTransaction detaches a transaction from a debt; it counts as income or
+// expense again.
+func (s *Service) UnlinkDebtTransaction(ctx context.Context, userID, debtID, transactionID uuid.UUID) (*Debt, error) {
+	if _, err := s.repo.GetDebt(ctx, userID, debtID); err != nil {
+		return nil, err
+	}
+	ctx = userChange(ctx, SourceDebt, userID, &debtID)
+	if err := s.repo.UnlinkDebtTransaction(ctx, userID, debtID, transactionID); err != nil {
+		return nil, err
+	}
+	return s.GetDebt(ctx, userID, debtID)
+}
@@ -0,0 +1,258 @@
+package transaction
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+
+	"github.com/Jomesi149/Implementasi-LASTI/backend/internal/sqlutil"
+)
+
+const debtColumns = `d.id, d.user_id, d.counterparty_id, p.name, d.direction, d.principal::TEXT, d.currency, d.interest_rate::FLOAT8::TEXT,
+	d.started_on, d.due_date, d.note, d.created_at`
+
+func scanDebt(row rowScanner) (Debt, error) {
+	var d Debt
+	var due sql.NullTime
+	var note sql.NullString
+	if err := row.Scan(&d.ID, &d.UserID, &d.CounterpartyID, &d.Counterparty, &d.Direction, &d.Principal, &d.Currency, &d.InterestRate,
+		&d.StartedOn, &due, &note, &d.CreatedAt); err != nil {
+		return d, err
+	}
+	if due.Valid {
+		at := due.Time
+		d.DueDate = &at
+	}
+	if note.Valid {
+		s := note.String
+		d.Note = &s
+	}
+	return d, nil
+}
+
+// FindOrCreatePayee returns the user's payee with name, ignoring case, creating it when
+// there is none.
+func (r *SQLRepository) FindOrCreatePayee(ctx context.Context, userID uuid.UUID, name string) (*Label, error) {
+	l := Label{UserID: userID}
+	err := r.db.QueryRowContext(ctx, `INSERT INTO finance.payees (id, user_id, name, created_at) VALUES ($1, $2, $3, NOW())
+		ON CONFLICT (user_id, LOWER(name)) DO UPDATE SET name = finance.payees.name
+		RETURNING id, name, created_at`, uuid.New(), userID, name).Scan(&l.ID, &l.Name, &l.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("find or create payee: %w", err)
+	}
+	return &l, nil
+}
+
+// CreateDebt inserts d together with its disbursement: a new transaction, an existing
+// transaction to link, or neither.
+func (r *SQLRepository) CreateDebt(ctx context.Context, d Debt, movement *Transaction, linkID *uuid.UUID) (err error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	query := `INSERT INTO finance.debts (id, user_id, counterparty_id, direction, principal, currency, interest_rate, started_on, due_date, note, created_at, updated_at)
+		VALUES ($1,$2,$3,$4,$5::NUMERIC,$6,$7::NUMERIC,$8::DATE,$9::DATE,$10,NOW(),NOW())`
+	if _, err = tx.ExecContext(ctx, query, d.ID, d.UserID, d.CounterpartyID, d.Direction, d.Principal, d.Currency, d.InterestRate,
+		d.StartedOn.Format("2006-01-02"), sqlutil.DateParam(d.DueDate), d.Note); err != nil {
+		return fmt.Errorf("insert debt: %w", err)
+	}
+	switch {
+	case movement != nil:
+		err = insertTransaction(ctx, tx, *movement)
+	case linkID != nil:
+		err = linkDebtTransaction(ctx, tx, d.UserID, d.ID, *linkID, disbursementKind(d.Direction), d.Currency)
+	}
+	if err != nil {
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("commit tx: %w", err)
+	}
+	return nil
+}
+
+func (r *SQLRepository) GetDebt(ctx context.Context, userID, id uuid.UUID) (*Debt, error) {
+	query := fmt.Sprintf(`SELECT %s FROM finance.debts d JOIN finance.payees p ON p.id = d.counterparty_id
+		WHERE d.id = $1 AND d.user_id = $2`, debtColumns)
+	d, err := scanDebt(r.db.QueryRowContext(ctx, query, id, userID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrDebtNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("select debt: %w", err)
+	}
+	return &d, nil
+}
+
+// ListDebts returns the user's debts, newest first, optionally with one counterparty.
+func (r *SQLRepository) ListDebts(ctx context.Context, userID uuid.UUID, counterpartyID *uuid.UUID) ([]Debt, error) {
+	query := fmt.Sprintf(`SELECT %s FROM finance.debts d JOIN finance.payees p ON p.id = d.counterparty_id
+		WHERE d.user_id = $1 AND ($2::UUID IS NULL OR d.counterparty_id = $2)
+		ORDER BY d.started_on DESC, d.created_at DESC`, debtColumns)
+	rows, err := r.db.QueryContext(ctx, query, userID, counterpartyID)
+	if err != nil {
+		return nil, fmt.Errorf("select debts: %w", err)
+	}
+	defer rows.Close()
+
+	out := []Debt{}
+	for rows.Next() {
+		d, err := scanDebt(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, d)
+	}
+	return out, rows.Err()
+}
+
+// DebtTransactions returns the live transactions of each of debtIDs, oldest first.
+func (r *SQLRepository) DebtTransactions(ctx context.Context, userID uuid.UUID, debtIDs []uuid.UUID) (map[uuid.UUID][]Transaction, error) {
+	out := map[uuid.UUID][]Transaction{}
+	if len(debtIDs) == 0 {
+		return out, nil
+	}
+	query := fmt.Sprintf(`SELECT %s FROM finance.transactions t
+		WHERE t.user_id = $1 AND t.debt_id = ANY($2::uuid[]) AND t.deleted_at IS NULL
+		ORDER BY t.occurred_at ASC, t.id ASC`, transactionColumns)
+	rows, err := r.db.QueryContext(ctx, query, userID, uuidStrings(debtIDs))
+	if err != nil {
+		return nil, fmt.Errorf("select debt transactions: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		t, err := scanTransaction(rows)
+		if err != nil {
+			return nil, err
+		}
+		out[*t.DebtID] = append(out[*t.DebtID], t)
+	}
+	return out, rows.Err()
+}
+
+func (r *SQLRepository) UpdateDebt(ctx context.Context, d Debt) error {
+	query := `UPDATE finance.debts SET interest_rate = $3::NUMERIC, due_date = $4::DATE, note = $5, updated_at = NOW()
+		WHERE id = $1 AND user_id = $2`
+	res, err := r.db.ExecContext(ctx, query, d.ID, d.UserID, d.InterestRate, sqlutil.DateParam(d.DueDate), d.Note)
+	if err != nil {
+		return fmt.Errorf("update debt: %w", err)
+	}
+	return sqlutil.ExpectAffected(res, ErrDebtNotFound)
+}
+
+// DeleteDebt deletes a debt without live transactions. Trashed transactions keep no
+// link; the foreign key clears it.
+func (r *SQLRepository) DeleteDebt(ctx context.Context, userID, id uuid.UUID) error {
+	var live int
+	err := r.db.QueryRowContext(ctx, `SELECT COUNT(t.id) FROM finance.debts d
+		LEFT JOIN finance.transactions t ON t.debt_id = d.id AND t.deleted_at IS NULL
+		WHERE d.id = $1 AND d.user_id = $2 GROUP BY d.id`, id, userID).Scan(&live)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrDebtNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("count debt transactions: %w", err)
+	}
+	if live > 0 {
+		return fmt.Errorf("%w: unlink or delete its %d transaction(s) first", ErrDebtState, live)
+	}
+	res, err := r.db.ExecContext(ctx, `DELETE FROM finance.debts d WHERE d.id = $1 AND d.user_id = $2
+		AND NOT EXISTS (SELECT 1 FROM finance.transactions t WHERE t.debt_id = d.id AND t.deleted_at IS NULL)`, id, userID)
+	if err != nil {
+		return fmt.Errorf("delete debt: %w", err)
+	}
+	return sqlutil.ExpectAffected(res, ErrDebtState)
+}
+
+// LinkDebtTransaction attaches an existing transaction of the given kind and currency
+// to a debt.
+func (r *SQLRepository) LinkDebtTransaction(ctx context.Context, userID, debtID, transactionID uuid.UUID, kind, currency string) (err error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	if err = linkDebtTransaction(ctx, tx, userID, debtID, transactionID, kind, currency); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("commit tx: %w", err)
+	}
+	return nil
+}
+
+// linkDebtTransaction sets the debt of a live, unlinked transaction; the ledger moves
+// its counter-posting from income or expense to the debt account.
+func linkDebtTransaction(ctx context.Context, tx *sql.Tx, userID, debtID, transactionID uuid.UUID, kind, currency string) error {
+	ids := []uuid.UUID{transactionID}
+	before, err := snapshotHistory(ctx, tx, ids)
+	if err != nil {
+		return err
+	}
+	res, err := tx.ExecContext(ctx, `UPDATE finance.transactions SET debt_id = $3,
+			payee_id = COALESCE(payee_id, (SELECT counterparty_id FROM finance.debts WHERE id = $3))
+		WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL AND debt_id IS NULL AND transfer_id IS NULL
+			AND kind = $4 AND currency = $5`, transactionID, userID, debtID, kind, currency)
+	if err != nil {
+		return fmt.Errorf("link debt transaction: %w", err)
+	}
+	if err := sqlutil.ExpectAffected(res, fmt.Errorf("%w: transaction changed before it could be linked", ErrDebtState)); err != nil {
+		return err
+	}
+	return recordChanges(ctx, tx, ids, before)
+}
+
+// UnlinkDebtTransaction detaches a live transaction from a debt.
+func (r *SQLRepository) UnlinkDebtTransaction(ctx context.Context, userID, debtID, transactionID uuid.UUID) (err error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	ids := []uuid.UUID{transactionID}
+	before, err := snapshotHistory(ctx, tx, ids)
+	if err != nil {
+		return err
+	}
+	res, err := tx.ExecContext(ctx, `UPDATE finance.transactions SET debt_id = NULL
+		WHERE id = $1 AND user_id = $2 AND debt_id = $3 AND deleted_at IS NULL`, transactionID, userID, debtID)
+	if err != nil {
+		return fmt.Errorf("unlink debt transaction: %w", err)
+	}
+	if err = sqlutil.ExpectAffected(res, ErrTransactionNotFound); err != nil {
+		return err
+	}
+	if err = recordChanges(ctx, tx, ids, before); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("commit tx: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,249 @@
+package transaction
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"github.com/Jomesi149/Implementasi-LASTI/backend/pkg/response"
+)
+
+func (h *HTTPHandler) registerDebtRoutes(r chi.Router) {
+	r.Route("/debts", func(r chi.Router) {
+		r.Post("/", h.handleCreateDebt)
+		r.Get("/", h.handleListDebts)
+		r.Get("/counterparties", h.handleCounterpartyBalances)
+		r.Get("/{id}", h.handleGetDebt)
+		r.Put("/{id}", h.handleUpdateDebt)
+		r.Delete("/{id}", h.handleDeleteDebt)
+		r.Post("/{id}/payments", h.handleAddDebtPayment)
+		r.Delete("/{id}/transactions/{transactionId}", h.handleUnlinkDebtTransaction)
+	})
+}
+
+// parseOptionalDate parses a nullable YYYY-MM-DD date; empty strings count as nil.
+func parseOptionalDate(s *string) (*time.Time, error) {
+	if s == nil || *s == "" {
+		return nil, nil
+	}
+	d, err := time.Parse("2006-01-02", *s)
+	if err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+type createDebtReq struct {
+	// The counterparty is a payee, given by id or by name.
+	CounterpartyID *string `json:"counterparty_id"`
+	Counterparty   string  `json:"counterparty"`
+	Direction      string  `json:"direction"`
+	Amount         string  `json:"amount"`
+	Currency       string  `json:"currency"`
+	InterestRate   string  `json:"interest_rate"`
+	StartedOn      *string `json:"started_on"`
+	DueDate        *string `json:"due_date"`
+	Note           *string `json:"note"`
+	// WalletID books the disbursement; TransactionID links an existing one instead.
+	WalletID      *string `json:"wallet_id"`
+	TransactionID *string `json:"transaction_id"`
+}
+
+func (h *HTTPHandler) handleCreateDebt(w http.ResponseWriter, r *http.Request) {
+	uid, err := getUserIDFromHeader(r)
+	if err != nil {
+		response.Error(w, http.StatusUnauthorized, "missing X-User-ID header")
+		return
+	}
+	var req createDebtReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.Error(w, http.StatusBadRequest, "invalid payload")
+		return
+	}
+	in := NewDebt{UserID: uid, Counterparty: req.Counterparty, Direction: req.Direction, Amount: req.Amount,
+		Currency: req.Currency, InterestRate: req.InterestRate, Note: req.Note}
+	if in.CounterpartyID, err = parseOptionalUUID(req.CounterpartyID); err != nil {
+		response.Error(w, http.StatusBadRequest, "invalid counterparty_id")
+		return
+	}
+	if in.WalletID, err = parseOptionalUUID(req.WalletID); err != nil {
+		response.Error(w, http.StatusBadRequest, "invalid wallet_id")
+		return
+	}
+	if in.TransactionID, err = parseOptionalUUID(req.TransactionID); err != nil {
+		response.Error(w, http.StatusBadRequest, "invalid transaction_id")
+		return
+	}
+	started, err := parseOptionalDate(req.StartedOn)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "invalid started_on, expected YYYY-MM-DD")
+		return
+	}
+	if started != nil {
+		in.StartedOn = *started
+	}
+	if in.DueDate, err = parseOptionalDate(req.DueDate); err != nil {
+		response.Error(w, http.StatusBadRequest, "invalid due_date, expected YYYY-MM-DD")
+		return
+	}
+	d, err := h.service.CreateDebt(r.Context(), in)
+	if err != nil {
+		response.Error(w, errorStatus(err), err.Error())
+		return
+	}
+	response.JSON(w, http.StatusCreated, d)
+}
+
+// handleListDebts lists debts; ?counterparty_id= and ?status=open|settled narrow it.
+func (h *HTTPHandler) handleListDebts(w http.ResponseWriter, r *http.Request) {
+	uid, err := getUserIDFromHeader(r)
+	if err != nil {
+		response.Error(w, http.StatusUnauthorized, "missing X-User-ID header")
+		return
+	}
+	q := r.URL.Query()
+	cp := q.Get("counterparty_id")
+	counterpartyID, err := parseOptionalUUID(&cp)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "invalid counterparty_id")
+		return
+	}
+	list, err := h.service.ListDebts(r.Context(), uid, counterpartyID, q.Get("status"))
+	if err != nil {
+		response.Error(w, errorStatus(err), err.Error())
+		return
+	}
+	response.JSON(w, http.StatusOK, list)
+}
+
+func (h *HTTPHandler) handleCounterpartyBalances(w http.ResponseWriter, r *http.Request) {
+	uid, err := getUserIDFromHeader(r)
+	if err != nil {
+		response.Error(w, http.StatusUnauthorized, "missing X-User-ID header")
+		return
+	}
+	list, err := h.service.CounterpartyBalances(r.Context(), uid)
+	if err != nil {
+		response.Error(w, errorStatus(err), err.Error())
+		return
+	}
+	response.JSON(w, http.StatusOK, list)
+}
+
+func (h *HTTPHandler) handleGetDebt(w http.ResponseWriter, r *http.Request) {
+	uid, id, ok := idParams(w, r, "debt")
+	if !ok {
+		return
+	}
+	d, err := h.service.GetDebt(r.Context(), uid, id)
+	if err != nil {
+		response.Error(w, errorStatus(err), err.Error())
+		return
+	}
+	response.JSON(w, http.StatusOK, d)
+}
+
+type updateDebtReq struct {
+	InterestRate *string `json:"interest_rate"`
+	// DueDate "" removes the due date.
+	DueDate *string `json:"due_date"`
+	Note    *string `json:"note"`
+}
+
+func (h *HTTPHandler) handleUpdateDebt(w http.ResponseWriter, r *http.Request) {
+	uid, id, ok := idParams(w, r, "debt")
+	if !ok {
+		return
+	}
+	var req updateDebtReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.Error(w, http.StatusBadRequest, "invalid payload")
+		return
+	}
+	u := DebtUpdate{InterestRate: req.InterestRate, Note: req.Note, ClearDueDate: req.DueDate != nil && *req.DueDate == ""}
+	due, err := parseOptionalDate(req.DueDate)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "invalid due_date, expected YYYY-MM-DD")
+		return
+	}
+	u.DueDate = due
+	d, err := h.service.UpdateDebt(r.Context(), uid, id, u)
+	if err != nil {
+		response.Error(w, errorStatus(err), err.Error())
+		return
+	}
+	response.JSON(w, http.StatusOK, d)
+}
+
+func (h *HTTPHandler) handleDeleteDebt(w http.ResponseWriter, r *http.Request) {
+	uid, id, ok := idParams(w, r, "debt")
+	if !ok {
+		return
+	}
+	if err := h.service.DeleteDebt(r.Context(), uid, id); err != nil {
+		response.Error(w, errorStatus(err), err.Error())
+		return
+	}
+	response.JSON(w, http.StatusOK, map[string]string{"status": "deleted"})
+}
+
+type debtPaymentReq struct {
+	// WalletID with Amount books a repayment; TransactionID links an existing one.
+	WalletID      *string    `json:"wallet_id"`
+	Amount        string     `json:"amount"`
+	OccurredAt    *time.Time `json:"occurred_at"`
+	Note          *string    `json:"note"`
+	TransactionID *string    `json:"transaction_id"`
+}
+
+func (h *HTTPHandler) handleAddDebtPayment(w http.ResponseWriter, r *http.Request) {
+	uid, id, ok := idParams(w, r, "debt")
+	if !ok {
+		return
+	}
+	var req debtPaymentReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.Error(w, http.StatusBadRequest, "invalid payload")
+		return
+	}
+	p := DebtPayment{Amount: req.Amount, Note: req.Note}
+	var err error
+	if p.WalletID, err = parseOptionalUUID(req.WalletID); err != nil {
+		response.Error(w, http.StatusBadRequest, "invalid wallet_id")
+		return
+	}
+	if p.TransactionID, err = parseOptionalUUID(req.TransactionID); err != nil {
+		response.Error(w, http.StatusBadRequest, "invalid transaction_id")
+		return
+	}
+	if req.OccurredAt != nil {
+		p.OccurredAt = *req.OccurredAt
+	}
+	d, err := h.service.AddDebtPayment(r.Context(), uid, id, p)
+	if err != nil {
+		response.Error(w, errorStatus(err), err.Error())
+		return
+	}
+	response.JSON(w, http.StatusCreated, d)
+}
+
+func (h *HTTPHandler) handleUnlinkDebtTransaction(w http.ResponseWriter, r *http.Request) {
+	uid, id, ok := idParams(w, r, "debt")
+	if !ok {
+		return
+	}
+	transactionID, err := uuid.Parse(chi.URLParam(r, "transactionId"))
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "invalid transaction id")
+		return
+	}
+	d, err := h.service.UnlinkDebtTransaction(r.Context(), uid, id, transactionID)
+	if err != nil {
+		response.Error(w, errorStatus(err), err.Error())
+		return
+	}
+	response.JSON(w, http.StatusOK, d)
+}
@@ -0,0 +1,204 @@
+package transaction
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/google/uuid"
+
+	"github.com/Jomesi149/Implementasi-LASTI/backend/internal/sqlutil"
+)
+
+const (
+	// duplicateWindow is how far apart two transactions may be and still look like
+	// the same payment entered twice.
+	duplicateWindow = 3 * 24 * time.Hour
+	// minNoteSimilarity is the word overlap two notes need when both are filled in.
+	minNoteSimilarity = 0.5
+	// duplicateScanFactor over-fetches candidate pairs because some are filtered out on
+	// their notes.
+	duplicateScanFactor    = 4
+	defaultDuplicatesLimit = 50
+	maxDuplicatesLimit     = 200
+)
+
+// ErrInvalidDuplicate indicates a merge or dismiss of two transactions that cannot be
+// duplicates of each other.
+var ErrInvalidDuplicate = errors.New("invalid_duplicate_pair")
+
+// DuplicatePair is two transactions that look like the same payment, older first.
+// Score runs from 0 to 1 and weighs note similarity and the gap between the dates.
+type DuplicatePair struct {
+	Transactions [2]Transaction `json:"transactions"`
+	Score        float64        `json:"score"`
+}
+
+// duplicateScore reports whether a and b look like the same payment: same wallet, kind
+// and amount, close dates and, when both have a note, similar notes.
+func duplicateScore(a, b Transaction) (float64, bool) {
+	if a.WalletID != b.WalletID || a.Kind != b.Kind {
+		return 0, false
+	}
+	ca, okA := sqlutil.ToCents(a.Amount)
+	cb, okB := sqlutil.ToCents(b.Amount)
+	if !okA || !okB || ca != cb {
+		return 0, false
+	}
+	gap := a.OccurredAt.Sub(b.OccurredAt)
+	if gap < 0 {
+		gap = -gap
+	}
+	if gap > duplicateWindow {
+		return 0, false
+	}
+
+	similarity := 0.5
+	wa, wb := noteWords(a.Note), noteWords(b.Note)
+	if len(wa) > 0 && len(wb) > 0 {
+		similarity = jaccard(wa, wb)
+		if similarity < minNoteSimilarity {
+			return 0, false
+		}
+	}
+	closeness := 1 - float64(gap)/float64(duplicateWindow)
+	return math.Round((0.6*similarity+0.4*closeness)*100) / 100, true
+}
+
+// noteWords is the set of lower-cased words in a note, ignoring punctuation.
+func noteWords(note *string) map[string]bool {
+	if note == nil {
+		return nil
+	}
+	out := map[string]bool{}
+	for _, w := range strings.FieldsFunc(strings.ToLower(*note), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		out[w] = true
+	}
+	return out
+}
+
+func jaccard(a, b map[string]bool) float64 {
+	shared := 0
+	for w := range a {
+		if b[w] {
+			shared++
+		}
+	}
+	return float64(shared) / float64(len(a)+len(b)-shared)
+}
+
+// orderedPair returns the ids in the order duplicate_dismissals stores them.
+func orderedPair(a, b uuid.UUID) (uuid.UUID, uuid.UUID) {
+	if b.String() < a.String() {
+		return b, a
+	}
+	return a, b
+}
+
+// ListDuplicates returns likely duplicate pairs the user has not dismissed, best
+// matches first.
+func (s *Service) ListDuplicates(ctx context.Context, userID uuid.UUID, limit int) ([]DuplicatePair, error) {
+	if limit <= 0 {
+		limit = defaultDuplicatesLimit
+	}
+	if limit > maxDuplicatesLimit {
+		limit = maxDuplicatesLimit
+	}
+	candidates, err := s.repo.DuplicateCandidates(ctx, userID, duplicateWindow, limit*duplicateScanFactor)
+	if err != nil {
+		return nil, err
+	}
+
+	out := []DuplicatePair{}
+	for _, c := range candidates {
+		score, ok := duplicateScore(c[0], c[1])
+		if !ok {
+			continue
+		}
+		if c[1].OccurredAt.Before(c[0].OccurredAt) {
+			c[0], c[1] = c[1], c[0]
+		}
+		out = append(out, DuplicatePair{Transactions: c, Score: score})
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Score > out[j].Score })
+	if len(out) > limit {
+		out = out[:limit]
+	}
+	return out, nil
+}
+
+// MergeDuplicates deletes removeID and reverses its effect on the wallet balance. Its
+// tags and attachments move to keepID, and fields keepID lacks (category or splits,
+// note, payee, external id) are taken from it. removeID is deleted for good rather
+// than moved to the trash, since its external id now belongs to keepID.
+func (s *Service) MergeDuplicates(ctx context.Context, userID, keepID, removeID uuid.UUID) (*Transaction, error) {
+	if keepID == removeID {
+		return nil, fmt.Errorf("%w: cannot merge a transaction into itself", ErrInvalidDuplicate)
+	}
+	ctx = userChange(ctx, SourceMerge, userID, nil)
+	if err := s.repo.MergeDuplicate(ctx, userID, keepID, removeID); err != nil {
+		return nil, err
+	}
+	s.forget(userID)
+	return s.repo.GetTransaction(ctx, userID, keepID)
+}
+
+// DismissDuplicate marks two transactions as distinct so the pair leaves the review queue.
+func (s *Service) DismissDuplicate(ctx context.Context, userID, a, b uuid.UUID) error {
+	if a == b {
+		return fmt.Errorf("%w: a transaction cannot duplicate itself", ErrInvalidDuplicate)
+	}
+	a, b = orderedPair(a, b)
+	return s.repo.DismissDuplicate(ctx, userID, a, b)
+}
+
+// flagPossibleDuplicates warns about valid import rows that look like a transaction
+// already on the wallet. Unlike exact external id matches they are still imported;
+// the user sorts them out in the duplicates queue.
+func (s *Service) flagPossibleDuplicates(ctx context.Context, walletID uuid.UUID, rows []ImportRow) error {
+	var from, to time.Time
+	for _, row := range rows {
+		if len(row.Errors) > 0 || row.Duplicate || row.OccurredAt == nil {
+			continue
+		}
+		if from.IsZero() || row.OccurredAt.Before(from) {
+			from = *row.OccurredAt
+		}
+		if to.IsZero() || row.OccurredAt.After(to) {
+			to = *row.OccurredAt
+		}
+	}
+	if from.IsZero() {
+		return nil
+	}
+	existing, err := s.repo.WalletTransactionsBetween(ctx, walletID, from.Add(-duplicateWindow), to.Add(duplicateWindow))
+	if err != nil {
+		return err
+	}
+
+	for i, row := range rows {
+		if len(row.Errors) > 0 || row.Duplicate || row.OccurredAt == nil {
+			continue
+		}
+		candidate := Transaction{WalletID: walletID, Amount: row.Amount, Kind: row.Kind, Note: row.Note, OccurredAt: *row.OccurredAt}
+		best := -1.0
+		for _, t := range existing {
+			if score, ok := duplicateScore(candidate, t); ok && score > best {
+				best = score
+				id := t.ID
+				rows[i].PossibleDuplicateOf = &id
+			}
+		}
+		if rows[i].PossibleDuplicateOf != nil {
+			rows[i].Warnings = append(rows[i].Warnings, "looks like an existing transaction")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,229 @@
+package transaction
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/Jomesi149/Implementasi-LASTI/backend/internal/sqlutil"
+)
+
+// DuplicateCandidates returns up to limit undismissed pairs on the same wallet with the
+// same kind and amount at most window apart, newest first. Notes are compared by the
+// caller.
+func (r *SQLRepository) DuplicateCandidates(ctx context.Context, userID uuid.UUID, window time.Duration, limit int) ([][2]Transaction, error) {
+	query := `SELECT a.id, b.id FROM finance.transactions a
+		JOIN finance.transactions b ON b.wallet_id = a.wallet_id AND b.amount = a.amount AND b.kind = a.kind
+			AND b.id > a.id
+			AND b.occurred_at BETWEEN a.occurred_at - make_interval(secs => $2) AND a.occurred_at + make_interval(secs => $2)
+		WHERE a.user_id = $1 AND b.user_id = $1 AND a.deleted_at IS NULL AND b.deleted_at IS NULL
+		AND a.transfer_id IS NULL AND b.transfer_id IS NULL
+		AND NOT EXISTS (SELECT 1 FROM finance.duplicate_dismissals d WHERE d.transaction_a = a.id AND d.transaction_b = b.id)
+		ORDER BY GREATEST(a.occurred_at, b.occurred_at) DESC LIMIT $3`
+	rows, err := r.db.QueryContext(ctx, query, userID, window.Seconds(), limit)
+	if err != nil {
+		return nil, fmt.Errorf("select duplicate candidates: %w", err)
+	}
+	defer rows.Close()
+
+	var pairs [][2]uuid.UUID
+	var ids []string
+	for rows.Next() {
+		var p [2]uuid.UUID
+		if err := rows.Scan(&p[0], &p[1]); err != nil {
+			return nil, err
+		}
+		pairs = append(pairs, p)
+		ids = append(ids, p[0].String(), p[1].String())
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(pairs) == 0 {
+		return nil, nil
+	}
+
+	txs, err := r.transactionsByID(ctx, userID, ids)
+	if err != nil {
+		return nil, err
+	}
+	out := make([][2]Transaction, 0, len(pairs))
+	for _, p := range pairs {
+		a, okA := txs[p[0]]
+		b, okB := txs[p[1]]
+		if okA && okB {
+			out = append(out, [2]Transaction{a, b})
+		}
+	}
+	return out, nil
+}
+
+// transactionsByID loads the given transactions of userID with their splits and tags.
+func (r *SQLRepository) transactionsByID(ctx context.Context, userID uuid.UUID, ids []string) (map[uuid.UUID]Transaction, error) {
+	query := fmt.Sprintf(`SELECT %s FROM finance.transactions t WHERE t.user_id = $1 AND t.id = ANY($2::uuid[]) AND t.deleted_at IS NULL`, transactionColumns)
+	rows, err := r.db.QueryContext(ctx, query, userID, ids)
+	if err != nil {
+		return nil, fmt.Errorf("select transactions: %w", err)
+	}
+	defer rows.Close()
+
+	var list []Transaction
+	for rows.Next() {
+		t, err := scanTransaction(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if err := r.attachSplits(ctx, list); err != nil {
+		return nil, err
+	}
+	if err := r.attachTags(ctx, list); err != nil {
+		return nil, err
+	}
+
+	out := make(map[uuid.UUID]Transaction, len(list))
+	for _, t := range list {
+		out[t.ID] = t
+	}
+	return out, nil
+}
+
+// WalletTransactionsBetween returns the wallet's transactions that occurred in [from, to].
+func (r *SQLRepository) WalletTransactionsBetween(ctx context.Context, walletID uuid.UUID, from, to time.Time) ([]Transaction, error) {
+	query := fmt.Sprintf(`SELECT %s FROM finance.transactions t
+		WHERE t.wallet_id = $1 AND t.deleted_at IS NULL AND t.occurred_at BETWEEN $2 AND $3 ORDER BY t.occurred_at ASC`, transactionColumns)
+	rows, err := r.db.QueryContext(ctx, query, walletID, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("select wallet transactions: %w", err)
+	}
+	defer rows.Close()
+
+	var out []Transaction
+	for rows.Next() {
+		t, err := scanTransaction(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, t)
+	}
+	return out, rows.Err()
+}
+
+// MergeDuplicate folds removeID into keepID inside one database transaction; see
+// Service.MergeDuplicates.
+func (r *SQLRepository) MergeDuplicate(ctx context.Context, userID, keepID, removeID uuid.UUID) (err error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	query := fmt.Sprintf(`SELECT %s FROM finance.transactions t WHERE t.user_id = $1 AND t.id IN ($2, $3) AND t.deleted_at IS NULL FOR UPDATE`, transactionColumns)
+	rows, err := tx.QueryContext(ctx, query, userID, keepID, removeID)
+	if err != nil {
+		return fmt.Errorf("lock duplicates: %w", err)
+	}
+	var keep, remove *Transaction
+	for rows.Next() {
+		t, scanErr := scanTransaction(rows)
+		if scanErr != nil {
+			rows.Close()
+			return scanErr
+		}
+		if t.ID == keepID {
+			keep = &t
+		} else {
+			remove = &t
+		}
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return err
+	}
+	if keep == nil || remove == nil {
+		return ErrTransactionNotFound
+	}
+	ck, _ := sqlutil.ToCents(keep.Amount)
+	cr, _ := sqlutil.ToCents(remove.Amount)
+	if keep.WalletID != remove.WalletID || keep.Kind != remove.Kind || ck != cr {
+		return fmt.Errorf("%w: transactions differ in wallet, kind or amount", ErrInvalidDuplicate)
+	}
+	if keep.TransferID != nil || remove.TransferID != nil {
+		return fmt.Errorf("%w: transfer legs cannot be merged", ErrInvalidDuplicate)
+	}
+
+	ids := []uuid.UUID{keepID, removeID}
+	before, err := snapshotHistory(ctx, tx, ids)
+	if err != nil {
+		return err
+	}
+
+	steps := []struct {
+		name  string
+		query string
+	}{
+		{"move tags", `INSERT INTO finance.transaction_tags (transaction_id, tag_id)
+			SELECT $1, tag_id FROM finance.transaction_tags WHERE transaction_id = $2 ON CONFLICT DO NOTHING`},
+		{"move attachments", `UPDATE finance.attachments SET transaction_id = $1 WHERE transaction_id = $2`},
+		{"move recurring occurrences", `UPDATE finance.recurring_occurrences SET transaction_id = $1 WHERE transaction_id = $2`},
+		// Rincian split hanya dipindah jika transaksi yang disimpan belum berkategori
+		{"move splits", `UPDATE finance.transaction_splits SET transaction_id = $1 WHERE transaction_id = $2
+			AND NOT EXISTS (SELECT 1 FROM finance.transactions WHERE id = $1 AND category_id IS NOT NULL)
+			AND NOT EXISTS (SELECT 1 FROM finance.transaction_splits WHERE transaction_id = $1)`},
+	}
+	for _, step := range steps {
+		if _, err = tx.ExecContext(ctx, step.query, keepID, removeID); err != nil {
+			return fmt.Errorf("%s: %w", step.name, err)
+		}
+	}
+
+	if _, err = tx.ExecContext(ctx, `DELETE FROM finance.transactions WHERE id = $1`, removeID); err != nil {
+		return fmt.Errorf("delete duplicate: %w", err)
+	}
+	// External id diisi setelah baris duplikat dihapus karena unik per dompet
+	if _, err = tx.ExecContext(ctx, `UPDATE finance.transactions SET
+			category_id = CASE WHEN EXISTS (SELECT 1 FROM finance.transaction_splits WHERE transaction_id = $1) THEN category_id ELSE COALESCE(category_id, $2) END,
+			note = COALESCE(note, $3), payee_id = COALESCE(payee_id, $4), external_id = COALESCE(external_id, $5)
+		WHERE id = $1`, keepID, remove.CategoryID, remove.Note, remove.PayeeID, remove.ExternalID); err != nil {
+		return fmt.Errorf("fill kept transaction: %w", err)
+	}
+
+	if err = recordChanges(ctx, tx, ids, before); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("commit tx: %w", err)
+	}
+	return nil
+}
+
+// DismissDuplicate records that a and b (a < b) are not duplicates.
+func (r *SQLRepository) DismissDuplicate(ctx context.Context, userID, a, b uuid.UUID) error {
+	var owned int
+	err := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM finance.transactions WHERE user_id = $1 AND id IN ($2, $3) AND deleted_at IS NULL`,
+		userID, a, b).Scan(&owned)
+	if err != nil {
+		return fmt.Errorf("check duplicates: %w", err)
+	}
+	if owned != 2 {
+		return ErrTransactionNotFound
+	}
+	query := `INSERT INTO finance.duplicate_dismissals (transaction_a, transaction_b, user_id, dismissed_at)
+		VALUES ($1, $2, $3, NOW()) ON CONFLICT DO NOTHING`
+	if _, err := r.db.ExecContext(ctx, query, a, b, userID); err != nil {
+		return fmt.Errorf("dismiss duplicate: %w", err)
+	}
+	return nil
+}
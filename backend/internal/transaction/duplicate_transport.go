@@ -0,0 +1,98 @@
+package transaction
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/google/uuid"
+
+	"github.com/Jomesi149/Implementasi-LASTI/backend/pkg/response"
+)
+
+// handleListDuplicates serves the review queue: GET /transactions/duplicates?limit=50
+func (h *HTTPHandler) handleListDuplicates(w http.ResponseWriter, r *http.Request) {
+	uid, err := getUserIDFromHeader(r)
+	if err != nil {
+		response.Error(w, http.StatusUnauthorized, "missing X-User-ID header")
+		return
+	}
+	limit := 0
+	if v := r.URL.Query().Get("limit"); v != "" {
+		if limit, err = strconv.Atoi(v); err != nil {
+			response.Error(w, http.StatusBadRequest, "invalid limit")
+			return
+		}
+	}
+	pairs, err := h.service.ListDuplicates(r.Context(), uid, limit)
+	if err != nil {
+		response.Error(w, errorStatus(err), err.Error())
+		return
+	}
+	response.JSON(w, http.StatusOK, pairs)
+}
+
+type mergeDuplicatesReq struct {
+	KeepID   string `json:"keep_id"`
+	RemoveID string `json:"remove_id"`
+}
+
+func (h *HTTPHandler) handleMergeDuplicates(w http.ResponseWriter, r *http.Request) {
+	uid, err := getUserIDFromHeader(r)
+	if err != nil {
+		response.Error(w, http.StatusUnauthorized, "missing X-User-ID header")
+		return
+	}
+	var req mergeDuplicatesReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.Error(w, http.StatusBadRequest, "invalid payload")
+		return
+	}
+	keepID, err := uuid.Parse(req.KeepID)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "invalid keep id")
+		return
+	}
+	removeID, err := uuid.Parse(req.RemoveID)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "invalid remove id")
+		return
+	}
+	t, err := h.service.MergeDuplicates(r.Context(), uid, keepID, removeID)
+	if err != nil {
+		response.Error(w, errorStatus(err), err.Error())
+		return
+	}
+	response.JSON(w, http.StatusOK, t)
+}
+
+type dismissDuplicateReq struct {
+	TransactionIDs []string `json:"transaction_ids"`
+}
+
+func (h *HTTPHandler) handleDismissDuplicate(w http.ResponseWriter, r *http.Request) {
+	uid, err := getUserIDFromHeader(r)
+	if err != nil {
+		response.Error(w, http.StatusUnauthorized, "missing X-User-ID header")
+		return
+	}
+	var req dismissDuplicateReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.Error(w, http.StatusBadRequest, "invalid payload")
+		return
+	}
+	if len(req.TransactionIDs) != 2 {
+		response.Error(w, http.StatusBadRequest, "transaction_ids must name exactly two transactions")
+		return
+	}
+	ids, err := parseUUIDList(req.TransactionIDs)
+	if err != nil || len(ids) != 2 {
+		response.Error(w, http.StatusBadRequest, "invalid transaction id")
+		return
+	}
+	if err := h.service.DismissDuplicate(r.Context(), uid, ids[0], ids[1]); err != nil {
+		response.Error(w, errorStatus(err), err.Error())
+		return
+	}
+	response.JSON(w, http.StatusOK, map[string]string{"status": "duplicate dismissed"})
+}
@@ -0,0 +1,209 @@
+package transaction
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ErrUnsupportedExport is returned for an unknown export format.
+var ErrUnsupportedExport = errors.New("unsupported_export_format")
+
+// ExportFormat describes how an export is served over HTTP.
+type ExportFormat struct {
+	Name        string
+	ContentType string
+	Extension   string
+}
+
+var exportFormats = map[string]ExportFormat{
+	"csv":  {Name: "csv", ContentType: "text/csv; charset=utf-8", Extension: "csv"},
+	"xlsx": {Name: "xlsx", ContentType: "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", Extension: "xlsx"},
+	"ofx":  {Name: "ofx", ContentType: "application/x-ofx", Extension: "ofx"},
+}
+
+// LookupExportFormat resolves the format query parameter; empty means csv.
+func LookupExportFormat(name string) (ExportFormat, error) {
+	if name == "" {
+		name = "csv"
+	}
+	f, ok := exportFormats[strings.ToLower(name)]
+	if !ok {
+		return ExportFormat{}, fmt.Errorf("%w: %s", ErrUnsupportedExport, name)
+	}
+	return f, nil
+}
+
+// exportColumns is shared by the CSV and XLSX writers. The names match the CSV import
+// aliases so an exported file can be imported again.
+var exportColumns = []string{"date", "wallet", "category", "parent_category", "kind", "amount", "note", "id"}
+
+// exportWriter receives rows one at a time and finishes the file on Close.
+type exportWriter interface {
+	Write(row ExportRow) error
+	Close() error
+}
+
+// ExportTransactions streams every transaction matching f to w in the given format.
+func (s *Service) ExportTransactions(ctx context.Context, userID uuid.UUID, f TransactionFilter, format ExportFormat, w io.Writer) error {
+	if err := validateFilter(f); err != nil {
+		return err
+	}
+
+	var ew exportWriter
+	switch format.Name {
+	case "csv":
+		ew = newCSVExportWriter(w)
+	case "xlsx":
+		xw, err := newXLSXExportWriter(w)
+		if err != nil {
+			return err
+		}
+		ew = xw
+	case "ofx":
+		ew = newOFXExportWriter(w)
+	default:
+		return fmt.Errorf("%w: %s", ErrUnsupportedExport, format.Name)
+	}
+
+	if err := s.repo.StreamTransactions(ctx, userID, f, format.Name == "ofx", ew.Write); err != nil {
+		return err
+	}
+	return ew.Close()
+}
+
+func exportDate(t time.Time) string {
+	return t.Format("2006-01-02 15:04:05")
+}
+
+func exportNote(row ExportRow) string {
+	if row.Note == nil {
+		return ""
+	}
+	return *row.Note
+}
+
+type csvExportWriter struct {
+	w *csv.Writer
+}
+
+func newCSVExportWriter(w io.Writer) *csvExportWriter {
+	cw := &csvExportWriter{w: csv.NewWriter(w)}
+	_ = cw.w.Write(exportColumns)
+	return cw
+}
+
+func (c *csvExportWriter) Write(row ExportRow) error {
+	return c.w.Write([]string{
+		exportDate(row.OccurredAt),
+		row.WalletName,
+		row.CategoryName,
+		row.ParentCategory,
+		row.Kind,
+		row.Amount,
+		exportNote(row),
+		row.ID.String(),
+	})
+}
+
+func (c *csvExportWriter) Close() error {
+	c.w.Flush()
+	return c.w.Error()
+}
+
+// ofxExportWriter writes an OFX 2.x document with one statement per wallet. Rows must
+// arrive grouped by wallet.
+type ofxExportWriter struct {
+	w       io.Writer
+	started bool
+	wallet  uuid.UUID
+	balance string
+	err     error
+}
+
+func newOFXExportWriter(w io.Writer) *ofxExportWriter {
+	return &ofxExportWriter{w: w}
+}
+
+func (o *ofxExportWriter) printf(format string, args ...any) {
+	if o.err == nil {
+		_, o.err = fmt.Fprintf(o.w, format, args...)
+	}
+}
+
+func (o *ofxExportWriter) Write(row ExportRow) error {
+	if !o.started {
+		o.printf(`<?xml version="1.0" encoding="UTF-8"?>` + "\n")
+		o.printf(`<?OFX OFXHEADER="200" VERSION="220" SECURITY="NONE" OLDFILEUID="NONE" NEWFILEUID="NONE"?>` + "\n")
+		o.printf("<OFX>\n<SIGNONMSGSRSV1><SONRS><STATUS><CODE>0</CODE><SEVERITY>INFO</SEVERITY></STATUS><DTSERVER>%s</DTSERVER><LANGUAGE>IND</LANGUAGE></SONRS></SIGNONMSGSRSV1>\n", ofxDate(time.Now()))
+		o.printf("<BANKMSGSRSV1>\n")
+		o.started = true
+	}
+	if row.WalletID != o.wallet {
+		if o.wallet != uuid.Nil {
+			o.closeStatement()
+		}
+		o.wallet, o.balance = row.WalletID, row.WalletBalance
+		o.printf("<STMTTRNRS><TRNUID>%s</TRNUID><STATUS><CODE>0</CODE><SEVERITY>INFO</SEVERITY></STATUS>\n", row.WalletID)
+		o.printf("<STMTRS><CURDEF>IDR</CURDEF><BANKACCTFROM><BANKID>LASTI</BANKID><ACCTID>%s</ACCTID><ACCTTYPE>CHECKING</ACCTTYPE></BANKACCTFROM>\n", xmlEscape(row.WalletName))
+		o.printf("<BANKTRANLIST>\n")
+	}
+
+	amount, trnType := row.Amount, "CREDIT"
+	if row.Kind == "out" {
+		amount, trnType = "-"+row.Amount, "DEBIT"
+	}
+	o.printf("<STMTTRN><TRNTYPE>%s</TRNTYPE><DTPOSTED>%s</DTPOSTED><TRNAMT>%s</TRNAMT><FITID>%s</FITID>", trnType, ofxDate(row.OccurredAt), amount, row.ID)
+	if row.CategoryName != "" {
+		o.printf("<NAME>%s</NAME>", xmlEscape(truncate(row.CategoryName, 32)))
+	}
+	if note := exportNote(row); note != "" {
+		o.printf("<MEMO>%s</MEMO>", xmlEscape(truncate(note, 255)))
+	}
+	o.printf("</STMTTRN>\n")
+	return o.err
+}
+
+func (o *ofxExportWriter) closeStatement() {
+	o.printf("</BANKTRANLIST>\n<LEDGERBAL><BALAMT>%s</BALAMT><DTASOF>%s</DTASOF></LEDGERBAL>\n</STMTRS></STMTTRNRS>\n", o.balance, ofxDate(time.Now()))
+}
+
+func (o *ofxExportWriter) Close() error {
+	if !o.started {
+		// Tanpa transaksi tetap kirim dokumen OFX yang valid
+		o.printf(`<?xml version="1.0" encoding="UTF-8"?>` + "\n")
+		o.printf(`<?OFX OFXHEADER="200" VERSION="220" SECURITY="NONE" OLDFILEUID="NONE" NEWFILEUID="NONE"?>` + "\n")
+		o.printf("<OFX>\n</OFX>\n")
+		return o.err
+	}
+	if o.wallet != uuid.Nil {
+		o.closeStatement()
+	}
+	o.printf("</BANKMSGSRSV1>\n</OFX>\n")
+	return o.err
+}
+
+func ofxDate(t time.Time) string {
+	return t.UTC().Format("20060102150405") + "[0:GMT]"
+}
+
+func xmlEscape(s string) string {
+	var b strings.Builder
+	_ = xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
+
+func truncate(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n])
+}
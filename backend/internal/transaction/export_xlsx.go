@@ -0,0 +1,113 @@
+package transaction
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// xlsxExportWriter writes a single-sheet workbook directly into a zip stream so rows
+// never have to be held in memory. Text goes into inline strings to avoid a shared
+// string table; amounts are numeric cells and dates use a date number format.
+type xlsxExportWriter struct {
+	zw    *zip.Writer
+	sheet io.Writer
+	row   int
+	err   error
+}
+
+var xlsxStaticParts = []struct{ name, body string }{
+	{"[Content_Types].xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types"><Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/><Default Extension="xml" ContentType="application/xml"/><Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/><Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/><Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/></Types>`},
+	{"_rels/.rels", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/></Relationships>`},
+	{"xl/workbook.xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets><sheet name="Transaksi" sheetId="1" r:id="rId1"/></sheets></workbook>`},
+	{"xl/_rels/workbook.xml.rels", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/><Relationship Id="rId2" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/></Relationships>`},
+	// Style 1 = tanggal+jam, style 2 = angka dengan pemisah ribuan dan 2 desimal
+	{"xl/styles.xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><numFmts count="1"><numFmt numFmtId="164" formatCode="yyyy-mm-dd hh:mm"/></numFmts><fonts count="1"><font><sz val="11"/><name val="Calibri"/></font></fonts><fills count="2"><fill><patternFill patternType="none"/></fill><fill><patternFill patternType="gray125"/></fill></fills><borders count="1"><border/></borders><cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs><cellXfs count="3"><xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/><xf numFmtId="164" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/><xf numFmtId="4" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/></cellXfs></styleSheet>`},
+}
+
+func newXLSXExportWriter(w io.Writer) (*xlsxExportWriter, error) {
+	zw := zip.NewWriter(w)
+	for _, part := range xlsxStaticParts {
+		f, err := zw.Create(part.name)
+		if err != nil {
+			return nil, fmt.Errorf("xlsx %s: %w", part.name, err)
+		}
+		if _, err := io.WriteString(f, part.body); err != nil {
+			return nil, fmt.Errorf("xlsx %s: %w", part.name, err)
+		}
+	}
+
+	sheet, err := zw.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return nil, fmt.Errorf("xlsx sheet: %w", err)
+	}
+	x := &xlsxExportWriter{zw: zw, sheet: sheet}
+	x.printf(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` + "\n")
+	x.printf(`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+	x.startRow()
+	for _, name := range exportColumns {
+		x.text(name)
+	}
+	x.printf("</row>")
+	return x, x.err
+}
+
+func (x *xlsxExportWriter) printf(format string, args ...any) {
+	if x.err == nil {
+		_, x.err = fmt.Fprintf(x.sheet, format, args...)
+	}
+}
+
+func (x *xlsxExportWriter) startRow() {
+	x.row++
+	x.printf(`<row r="%d">`, x.row)
+}
+
+func (x *xlsxExportWriter) text(s string) {
+	x.printf(`<c t="inlineStr"><is><t xml:space="preserve">%s</t></is></c>`, xmlEscape(s))
+}
+
+func (x *xlsxExportWriter) number(v string, style int) {
+	x.printf(`<c s="%d"><v>%s</v></c>`, style, v)
+}
+
+func (x *xlsxExportWriter) Write(row ExportRow) error {
+	x.startRow()
+	x.number(strconv.FormatFloat(excelSerial(row), 'f', 6, 64), 1)
+	x.text(row.WalletName)
+	x.text(row.CategoryName)
+	x.text(row.ParentCategory)
+	x.text(row.Kind)
+	if _, err := strconv.ParseFloat(row.Amount, 64); err == nil {
+		x.number(row.Amount, 2)
+	} else {
+		x.text(row.Amount)
+	}
+	x.text(exportNote(row))
+	x.text(row.ID.String())
+	x.printf("</row>")
+	return x.err
+}
+
+func (x *xlsxExportWriter) Close() error {
+	x.printf("</sheetData></worksheet>")
+	if x.err != nil {
+		return x.err
+	}
+	return x.zw.Close()
+}
+
+// excelSerial converts the transaction time to an Excel serial date (days since
+// 1899-12-30) in the transaction's own time zone.
+func excelSerial(row ExportRow) float64 {
+	t := row.OccurredAt
+	_, offset := t.Zone()
+	secs := float64(t.Unix()+int64(offset)) + float64(t.Nanosecond())/1e9
+	return secs/86400 + 25569
+}
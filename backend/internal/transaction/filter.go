@@ -0,0 +1,128 @@
+package transaction
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	defaultPageSize = 50
+	maxPageSize     = 200
+)
+
+// ErrInvalidCursor is returned when a pagination cursor cannot be decoded.
+var ErrInvalidCursor = errors.New("invalid_cursor")
+
+// pageCursor is the keyset position (occurred_at, id) of the last row on a page.
+type pageCursor struct {
+	OccurredAt time.Time
+	ID         uuid.UUID
+}
+
+func encodeCursor(t Transaction) string {
+	raw := t.OccurredAt.UTC().Format(time.RFC3339Nano) + "|" + t.ID.String()
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func decodeCursor(s string) (*pageCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	occurred, id, ok := strings.Cut(string(raw), "|")
+	if !ok {
+		return nil, ErrInvalidCursor
+	}
+	at, err := time.Parse(time.RFC3339Nano, occurred)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	uid, err := uuid.Parse(id)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	return &pageCursor{OccurredAt: at, ID: uid}, nil
+}
+
+// clampLimit applies the default page size and caps oversized requests.
+func clampLimit(limit int) int {
+	if limit <= 0 {
+		return defaultPageSize
+	}
+	if limit > maxPageSize {
+		return maxPageSize
+	}
+	return limit
+}
+
+// filterClause builds the WHERE conditions for f against the transactions alias t.
+// The user id is always bound as $1. The cursor is not included so the export can
+// reuse the same filter without pagination.
+func filterClause(userID uuid.UUID, f TransactionFilter) (string, []any) {
+	conds := []string{"t.user_id = $1", "t.deleted_at IS NULL"}
+	args := []any{userID}
+	add := func(cond string, v any) {
+		args = append(args, v)
+		conds = append(conds, fmt.Sprintf(cond, len(args)))
+	}
+
+	if f.From != nil {
+		add("t.occurred_at >= $%d", *f.From)
+	}
+	if f.To != nil {
+		add("t.occurred_at < $%d", *f.To)
+	}
+	if len(f.WalletIDs) > 0 {
+		add("t.wallet_id = ANY($%d::uuid[])", uuidStrings(f.WalletIDs))
+	}
+	if len(f.CategoryIDs) > 0 {
+		// Filter kategori induk ikut menyertakan sub-kategorinya; transaksi split cocok
+		// jika salah satu baris split-nya cocok
+		add(`EXISTS (SELECT 1 FROM finance.transaction_lines l
+			JOIN finance.categories c ON c.id = l.category_id AND c.deleted_at IS NULL
+			WHERE l.transaction_id = t.id AND c.user_id = $1
+			AND (c.id = ANY($%[1]d::uuid[]) OR c.parent_id = ANY($%[1]d::uuid[])))`, uuidStrings(f.CategoryIDs))
+	}
+	if len(f.TagIDs) > 0 {
+		add("EXISTS (SELECT 1 FROM finance.transaction_tags tt WHERE tt.transaction_id = t.id AND tt.tag_id = ANY($%d::uuid[]))", uuidStrings(f.TagIDs))
+	}
+	if len(f.PayeeIDs) > 0 {
+		add("t.payee_id = ANY($%d::uuid[])", uuidStrings(f.PayeeIDs))
+	}
+	if f.Kind != "" {
+		add("t.kind = $%d", f.Kind)
+	}
+	switch f.Status {
+	case StatusPlanned:
+		conds = append(conds, "t.planned")
+	case StatusPosted:
+		conds = append(conds, "NOT t.planned")
+	}
+	if f.MinAmount != nil {
+		add("t.amount >= $%d", *f.MinAmount)
+	}
+	if f.MaxAmount != nil {
+		add("t.amount <= $%d", *f.MaxAmount)
+	}
+	if q := strings.TrimSpace(f.Query); q != "" {
+		add(`t.note ILIKE '%%' || $%d || '%%' ESCAPE '\'`, escapeLike(q))
+	}
+	return strings.Join(conds, " AND "), args
+}
+
+func uuidStrings(ids []uuid.UUID) []string {
+	out := make([]string, len(ids))
+	for i, id := range ids {
+		out[i] = id.String()
+	}
+	return out
+}
+
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
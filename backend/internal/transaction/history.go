@@ -0,0 +1,174 @@
+package transaction
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/Jomesi149/Implementasi-LASTI/backend/internal/currency"
+	"github.com/Jomesi149/Implementasi-LASTI/backend/internal/sqlutil"
+)
+
+// History actions, derived from the state of a transaction before and after a change.
+const (
+	HistoryCreate  = "create"
+	HistoryUpdate  = "update"
+	HistoryDelete  = "delete"
+	HistoryRestore = "restore"
+)
+
+// Change sources recorded in the history.
+const (
+	SourceAPI       = "api"
+	SourceImport    = "import"
+	SourceRecurring = "recurring"
+	SourceBulk      = "bulk"
+	SourceRule      = "rule"
+	SourceMerge     = "merge"
+	SourceTrash     = "trash"
+)
+
+// Change describes who or what is changing transactions. ActorID is nil for changes made
+// by the system; ReferenceID points at the import batch, recurring rule or similar that
+// caused the change.
+type Change struct {
+	Source      string
+	ActorID     *uuid.UUID
+	ReferenceID *uuid.UUID
+}
+
+type changeKey struct{}
+
+// WithChange attaches c to ctx; history rows written with ctx record it. Callers outside
+// this package use it to mark system changes, e.g. transactions posted by a recurring rule.
+func WithChange(ctx context.Context, c Change) context.Context {
+	return context.WithValue(ctx, changeKey{}, c)
+}
+
+// changeFrom returns the change attached to ctx, or an anonymous API change.
+func changeFrom(ctx context.Context) Change {
+	if c, ok := ctx.Value(changeKey{}).(Change); ok {
+		return c
+	}
+	return Change{Source: SourceAPI}
+}
+
+// userChange attaches a change by userID from source unless the caller attached one.
+func userChange(ctx context.Context, source string, userID uuid.UUID, ref *uuid.UUID) context.Context {
+	if _, ok := ctx.Value(changeKey{}).(Change); ok {
+		return ctx
+	}
+	return WithChange(ctx, Change{Source: source, ActorID: &userID, ReferenceID: ref})
+}
+
+// HistoryEntry is one version of a transaction. OldValues and NewValues are snapshots of
+// the transaction (nil before it was created and after it was removed for good);
+// WalletDeltas is the change this version made to each wallet balance.
+type HistoryEntry struct {
+	Version      int               `json:"version"`
+	Action       string            `json:"action"`
+	Source       string            `json:"source"`
+	ActorID      *uuid.UUID        `json:"actor_id,omitempty"`
+	ReferenceID  *uuid.UUID        `json:"reference_id,omitempty"`
+	OldValues    json.RawMessage   `json:"old_values"`
+	NewValues    json.RawMessage   `json:"new_values"`
+	WalletDeltas map[string]string `json:"wallet_deltas"`
+	CreatedAt    time.Time         `json:"created_at"`
+}
+
+// historySnapshot is the part of a snapshot that decides its balance effect.
+type historySnapshot struct {
+	WalletID          uuid.UUID  `json:"wallet_id"`
+	Amount            string     `json:"amount"`
+	Kind              string     `json:"kind"`
+	Currency          string     `json:"currency"`
+	TransferID        *uuid.UUID `json:"transfer_id"`
+	DebtID            *uuid.UUID `json:"debt_id"`
+	Deleted           bool       `json:"deleted"`
+	DeletedWithWallet bool       `json:"deleted_with_wallet"`
+	Planned           bool       `json:"planned"`
+}
+
+// currency is the snapshot's currency; snapshots from before multi-currency are in
+// currency.DefaultCurrency.
+func (s *historySnapshot) currency() string {
+	if s.Currency == "" {
+		return currency.DefaultCurrency
+	}
+	return s.Currency
+}
+
+// historyAction names the change from old to new snapshot.
+func historyAction(old, new []byte) string {
+	before, after := parseSnapshot(old), parseSnapshot(new)
+	switch {
+	case before == nil:
+		return HistoryCreate
+	case after == nil || (after.Deleted && !before.Deleted):
+		return HistoryDelete
+	case before.Deleted && !after.Deleted:
+		return HistoryRestore
+	default:
+		return HistoryUpdate
+	}
+}
+
+func parseSnapshot(raw []byte) *historySnapshot {
+	if len(raw) == 0 {
+		return nil
+	}
+	var s historySnapshot
+	if err := json.Unmarshal(raw, &s); err != nil {
+		return nil
+	}
+	return &s
+}
+
+// balanceEffect is what a snapshot contributes to its wallet balance. Transactions that
+// went to the trash with their wallet still count, because deleting the wallet leaves its
+// balance untouched; planned ones count from when they are posted.
+func balanceEffect(s *historySnapshot) (uuid.UUID, int64) {
+	if s == nil || s.Planned || (s.Deleted && !s.DeletedWithWallet) {
+		return uuid.Nil, 0
+	}
+	return s.WalletID, signedCents(Transaction{Amount: s.Amount, Kind: s.Kind})
+}
+
+// walletDeltas compares the balance effects of two snapshots.
+func walletDeltas(old, new []byte) map[string]string {
+	cents := map[uuid.UUID]int64{}
+	if w, c := balanceEffect(parseSnapshot(old)); c != 0 {
+		cents[w] -= c
+	}
+	if w, c := balanceEffect(parseSnapshot(new)); c != 0 {
+		cents[w] += c
+	}
+	out := map[string]string{}
+	for w, c := range cents {
+		if c != 0 {
+			out[w.String()] = sqlutil.CentsString(c)
+		}
+	}
+	return out
+}
+
+// TransactionHistory returns every recorded version of a transaction, oldest first. The
+// history outlives the transaction, so it can be read after a purge.
+func (s *Service) TransactionHistory(ctx context.Context, userID, transactionID uuid.UUID) ([]HistoryEntry, error) {
+	entries, err := s.repo.TransactionHistory(ctx, userID, transactionID)
+	if err != nil {
+		return nil, err
+	}
+	if len(entries) == 0 {
+		// Transaksi lama dari sebelum riwayat dicatat belum punya versi
+		if _, err := s.repo.GetTransaction(ctx, userID, transactionID); err != nil {
+			return nil, err
+		}
+	}
+	for i := range entries {
+		entries[i].WalletDeltas = walletDeltas(entries[i].OldValues, entries[i].NewValues)
+	}
+	return entries, nil
+}
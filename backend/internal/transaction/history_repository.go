@@ -0,0 +1,161 @@
+package transaction
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+// historyValues builds the JSON snapshot of transaction t stored in the history.
+const historyValues = `jsonb_build_object(
+	'wallet_id', t.wallet_id, 'category_id', t.category_id, 'amount', t.amount::TEXT, 'kind', t.kind,
+	'note', t.note, 'occurred_at', t.occurred_at, 'payee_id', t.payee_id, 'external_id', t.external_id,
+	'tag_ids', COALESCE((SELECT jsonb_agg(tt.tag_id ORDER BY tt.tag_id) FROM finance.transaction_tags tt WHERE tt.transaction_id = t.id), '[]'::jsonb),
+	'splits', COALESCE((SELECT jsonb_agg(jsonb_build_object('category_id', s.category_id, 'amount', s.amount::TEXT, 'note', s.note) ORDER BY s.position)
+		FROM finance.transaction_splits s WHERE s.transaction_id = t.id), '[]'::jsonb),
+	'currency', t.currency, 'transfer_id', t.transfer_id, 'debt_id', t.debt_id,
+	'deleted', t.deleted_at IS NOT NULL, 'deleted_with_wallet', t.deleted_with_wallet, 'planned', t.planned)`
+
+// historyState is the snapshot of one transaction at a point inside a database transaction.
+type historyState struct {
+	userID uuid.UUID
+	values []byte
+}
+
+// snapshotHistory reads the current snapshot of each of ids that exists.
+func snapshotHistory(ctx context.Context, tx *sql.Tx, ids []uuid.UUID) (map[uuid.UUID]historyState, error) {
+	out := map[uuid.UUID]historyState{}
+	if len(ids) == 0 {
+		return out, nil
+	}
+	query := fmt.Sprintf(`SELECT t.id, t.user_id, %s FROM finance.transactions t WHERE t.id = ANY($1::uuid[])`, historyValues)
+	rows, err := tx.QueryContext(ctx, query, uuidStrings(ids))
+	if err != nil {
+		return nil, fmt.Errorf("snapshot transactions: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id uuid.UUID
+		var s historyState
+		if err := rows.Scan(&id, &s.userID, &s.values); err != nil {
+			return nil, err
+		}
+		out[id] = s
+	}
+	return out, rows.Err()
+}
+
+// recordChanges writes a new history version for each of ids whose snapshot differs
+// from before, attributed to the change attached to ctx, and books any change in its
+// balance effect on the ledger. It must run in the same database transaction as the
+// change, after it.
+func recordChanges(ctx context.Context, tx *sql.Tx, ids []uuid.UUID, before map[uuid.UUID]historyState) error {
+	_, err := recordWalletChanges(ctx, tx, ids, before)
+	return err
+}
+
+// recordWalletChanges is recordChanges returning the net balance change of each wallet
+// in cents. Each wallet balance is updated once for all of ids.
+func recordWalletChanges(ctx context.Context, tx *sql.Tx, ids []uuid.UUID, before map[uuid.UUID]historyState) (map[uuid.UUID]int64, error) {
+	after, err := snapshotHistory(ctx, tx, ids)
+	if err != nil {
+		return nil, err
+	}
+	change := changeFrom(ctx)
+
+	stmt, err := tx.PrepareContext(ctx, `INSERT INTO finance.transaction_history
+			(transaction_id, user_id, version, action, source, actor_id, reference_id, old_values, new_values, created_at)
+		SELECT $1, $2, COALESCE(MAX(version), 0) + 1, $3, $4, $5, $6, $7::jsonb, $8::jsonb, NOW()
+		FROM finance.transaction_history WHERE transaction_id = $1`)
+	if err != nil {
+		return nil, fmt.Errorf("prepare history: %w", err)
+	}
+	defer stmt.Close()
+
+	var entries []ledgerEntry
+	seen := map[uuid.UUID]bool{}
+	for _, id := range ids {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		old, hadOld := before[id]
+		cur, hasNew := after[id]
+		if (!hadOld && !hasNew) || (hadOld && hasNew && bytes.Equal(old.values, cur.values)) {
+			continue
+		}
+		userID := cur.userID
+		if !hasNew {
+			userID = old.userID
+		}
+		action := historyAction(old.values, cur.values)
+		if _, err := stmt.ExecContext(ctx, id, userID, action, change.Source,
+			change.ActorID, change.ReferenceID, nullJSON(old.values), nullJSON(cur.values)); err != nil {
+			return nil, fmt.Errorf("insert history: %w", err)
+		}
+		entries = append(entries, transactionEntries(id, userID, action, parseSnapshot(old.values), parseSnapshot(cur.values))...)
+	}
+	return postEntries(ctx, tx, entries)
+}
+
+// selectTransactionIDs returns the transaction ids selected by query.
+func selectTransactionIDs(ctx context.Context, tx *sql.Tx, query string, args ...any) ([]uuid.UUID, error) {
+	rows, err := tx.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("select transaction ids: %w", err)
+	}
+	defer rows.Close()
+	var ids []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+func nullJSON(b []byte) any {
+	if len(b) == 0 {
+		return nil
+	}
+	return string(b)
+}
+
+// TransactionHistory returns the versions of a transaction owned by userID, oldest first.
+func (r *SQLRepository) TransactionHistory(ctx context.Context, userID, transactionID uuid.UUID) ([]HistoryEntry, error) {
+	query := `SELECT version, action, source, actor_id, reference_id, old_values, new_values, created_at
+		FROM finance.transaction_history
+		WHERE transaction_id = $1 AND user_id = $2
+		ORDER BY version ASC`
+	rows, err := r.db.QueryContext(ctx, query, transactionID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("list history: %w", err)
+	}
+	defer rows.Close()
+
+	out := []HistoryEntry{}
+	for rows.Next() {
+		var e HistoryEntry
+		var actorID, referenceID uuid.NullUUID
+		var oldValues, newValues []byte
+		if err := rows.Scan(&e.Version, &e.Action, &e.Source, &actorID, &referenceID, &oldValues, &newValues, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		if actorID.Valid {
+			id := actorID.UUID
+			e.ActorID = &id
+		}
+		if referenceID.Valid {
+			id := referenceID.UUID
+			e.ReferenceID = &id
+		}
+		e.OldValues, e.NewValues = oldValues, newValues
+		out = append(out, e)
+	}
+	return out, rows.Err()
+}
@@ -0,0 +1,20 @@
+package transaction
+
+import (
+	"net/http"
+
+	"github.com/Jomesi149/Implementasi-LASTI/backend/pkg/response"
+)
+
+func (h *HTTPHandler) handleTransactionHistory(w http.ResponseWriter, r *http.Request) {
+	uid, id, ok := idParams(w, r, "transaction")
+	if !ok {
+		return
+	}
+	entries, err := h.service.TransactionHistory(r.Context(), uid, id)
+	if err != nil {
+		response.Error(w, errorStatus(err), err.Error())
+		return
+	}
+	response.JSON(w, http.StatusOK, entries)
+}
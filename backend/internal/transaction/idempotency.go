@@ -0,0 +1,89 @@
+package transaction
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	maxIdempotencyKey = 255
+	// idempotencyTTL is how long a stored response is replayed for the same key.
+	idempotencyTTL = 24 * time.Hour
+	// idempotencyLease is how long an unfinished request holds its key before a retry
+	// may take it over.
+	idempotencyLease = time.Minute
+)
+
+var (
+	// ErrInvalidIdempotencyKey indicates an empty or overlong Idempotency-Key.
+	ErrInvalidIdempotencyKey = errors.New("invalid_idempotency_key")
+	// ErrIdempotencyMismatch is returned when a key is reused with a different request body.
+	ErrIdempotencyMismatch = errors.New("idempotency_key_reused")
+	// ErrIdempotencyInProgress is returned while the first request with a key is still running.
+	ErrIdempotencyInProgress = errors.New("idempotency_key_in_progress")
+)
+
+// IdempotentResponse is a stored response replayed for a repeated Idempotency-Key.
+type IdempotentResponse struct {
+	StatusCode int
+	Body       []byte
+}
+
+// idempotencyClaim is the stored state of a key after an attempt to claim it.
+type idempotencyClaim struct {
+	Claimed     bool
+	RequestHash string
+	Response    *IdempotentResponse
+}
+
+// RequestHash fingerprints a request body so a reused key can be told apart from a retry.
+func RequestHash(body []byte) string {
+	sum := sha256.Sum256(body)
+	return hex.EncodeToString(sum[:])
+}
+
+// ClaimIdempotencyKey reserves key for a new request. It returns the stored response
+// when the same request already completed, ErrIdempotencyInProgress while it is still
+// running and ErrIdempotencyMismatch when the key was used for a different body.
+func (s *Service) ClaimIdempotencyKey(ctx context.Context, userID uuid.UUID, key, requestHash string) (*IdempotentResponse, error) {
+	if key == "" || len(key) > maxIdempotencyKey {
+		return nil, fmt.Errorf("%w: key must be 1-%d characters", ErrInvalidIdempotencyKey, maxIdempotencyKey)
+	}
+	claim, err := s.repo.ClaimIdempotencyKey(ctx, userID, key, requestHash, idempotencyTTL, idempotencyLease)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case claim.Claimed:
+		return nil, nil
+	case claim.RequestHash != requestHash:
+		return nil, ErrIdempotencyMismatch
+	case claim.Response == nil:
+		return nil, ErrIdempotencyInProgress
+	default:
+		return claim.Response, nil
+	}
+}
+
+// CompleteIdempotencyKey stores the response replayed for later requests with key.
+func (s *Service) CompleteIdempotencyKey(ctx context.Context, userID uuid.UUID, key string, resp IdempotentResponse) error {
+	return s.repo.CompleteIdempotencyKey(ctx, userID, key, resp)
+}
+
+// ReleaseIdempotencyKey frees key after a failed request so the client can retry.
+func (s *Service) ReleaseIdempotencyKey(ctx context.Context, userID uuid.UUID, key string) error {
+	return s.repo.ReleaseIdempotencyKey(ctx, userID, key)
+}
+
+// IdempotentTransactionID derives the transaction id for key. A retry after a crash
+// between saving the transaction and storing the response then hits
+// ErrDuplicateTransaction instead of creating a second transaction.
+func IdempotentTransactionID(userID uuid.UUID, key string) uuid.UUID {
+	return uuid.NewSHA1(userID, []byte("idempotency:"+key))
+}
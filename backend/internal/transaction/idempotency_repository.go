@@ -0,0 +1,60 @@
+package transaction
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ClaimIdempotencyKey inserts key, or takes over a row that expired or whose request
+// stopped without finishing. When the key is held it returns the stored row instead.
+func (r *SQLRepository) ClaimIdempotencyKey(ctx context.Context, userID uuid.UUID, key, requestHash string, ttl, lease time.Duration) (*idempotencyClaim, error) {
+	if _, err := r.db.ExecContext(ctx, `DELETE FROM finance.idempotency_keys WHERE user_id = $1 AND created_at < $2`,
+		userID, time.Now().Add(-ttl)); err != nil {
+		return nil, fmt.Errorf("purge idempotency keys: %w", err)
+	}
+
+	res, err := r.db.ExecContext(ctx, `INSERT INTO finance.idempotency_keys (user_id, key, request_hash, created_at)
+		VALUES ($1, $2, $3, NOW())
+		ON CONFLICT (user_id, key) DO UPDATE SET request_hash = EXCLUDED.request_hash, status_code = NULL, response = NULL, created_at = NOW()
+		WHERE finance.idempotency_keys.status_code IS NULL AND finance.idempotency_keys.created_at < $4`,
+		userID, key, requestHash, time.Now().Add(-lease))
+	if err != nil {
+		return nil, fmt.Errorf("claim idempotency key: %w", err)
+	}
+	if n, err := res.RowsAffected(); err == nil && n > 0 {
+		return &idempotencyClaim{Claimed: true, RequestHash: requestHash}, nil
+	}
+
+	var claim idempotencyClaim
+	var status sql.NullInt64
+	var body []byte
+	err = r.db.QueryRowContext(ctx, `SELECT request_hash, status_code, response FROM finance.idempotency_keys WHERE user_id = $1 AND key = $2`,
+		userID, key).Scan(&claim.RequestHash, &status, &body)
+	if err != nil {
+		return nil, fmt.Errorf("select idempotency key: %w", err)
+	}
+	if status.Valid {
+		claim.Response = &IdempotentResponse{StatusCode: int(status.Int64), Body: body}
+	}
+	return &claim, nil
+}
+
+func (r *SQLRepository) CompleteIdempotencyKey(ctx context.Context, userID uuid.UUID, key string, resp IdempotentResponse) error {
+	query := `UPDATE finance.idempotency_keys SET status_code = $3, response = $4 WHERE user_id = $1 AND key = $2`
+	if _, err := r.db.ExecContext(ctx, query, userID, key, resp.StatusCode, resp.Body); err != nil {
+		return fmt.Errorf("store idempotent response: %w", err)
+	}
+	return nil
+}
+
+func (r *SQLRepository) ReleaseIdempotencyKey(ctx context.Context, userID uuid.UUID, key string) error {
+	query := `DELETE FROM finance.idempotency_keys WHERE user_id = $1 AND key = $2 AND status_code IS NULL`
+	if _, err := r.db.ExecContext(ctx, query, userID, key); err != nil {
+		return fmt.Errorf("release idempotency key: %w", err)
+	}
+	return nil
+}
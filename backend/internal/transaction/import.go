@@ -0,0 +1,392 @@
+package transaction
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// MaxImportSize caps uploaded statement files.
+	MaxImportSize = 5 << 20
+	maxImportRows = 10000
+	// previewValidRows limits how many valid rows a preview echoes back; invalid rows are always returned.
+	previewValidRows = 100
+)
+
+var (
+	// ErrImportNotFound is returned when the import batch does not exist for the user.
+	ErrImportNotFound = errors.New("import_not_found")
+	// ErrInvalidImport indicates a file or mapping that cannot be turned into transactions.
+	ErrInvalidImport = errors.New("invalid_import")
+	// ErrImportState is returned when a batch is committed or undone twice.
+	ErrImportState = errors.New("import_state_conflict")
+	// ErrWalletNotFound is returned when the wallet does not exist for the user.
+	ErrWalletNotFound = errors.New("wallet_not_found")
+)
+
+// Import batch statuses.
+const (
+	ImportUploaded  = "uploaded"
+	ImportCommitted = "committed"
+	ImportUndone    = "undone"
+)
+
+// ColumnMapping tells the CSV parser which zero-based column holds which field.
+// Either Amount (signed) or Debit/Credit must be set.
+type ColumnMapping struct {
+	Date     *int `json:"date"`
+	Amount   *int `json:"amount,omitempty"`
+	Debit    *int `json:"debit,omitempty"`
+	Credit   *int `json:"credit,omitempty"`
+	Kind     *int `json:"kind,omitempty"`
+	Note     *int `json:"note,omitempty"`
+	Category *int `json:"category,omitempty"`
+}
+
+// ImportSettings holds the detected (or client-overridden) file format and column mapping.
+type ImportSettings struct {
+	Delimiter  string        `json:"delimiter,omitempty"`
+	DateFormat string        `json:"date_format,omitempty"`
+	DecimalSep string        `json:"decimal_separator,omitempty"`
+	HasHeader  bool          `json:"has_header"`
+	Mapping    ColumnMapping `json:"mapping"`
+}
+
+// ImportBatch is one uploaded statement file and its lifecycle.
+type ImportBatch struct {
+	ID          uuid.UUID      `json:"id"`
+	UserID      uuid.UUID      `json:"user_id"`
+	WalletID    uuid.UUID      `json:"wallet_id"`
+	Source      string         `json:"source"`
+	Filename    string         `json:"filename"`
+	Content     []byte         `json:"-"`
+	Settings    ImportSettings `json:"settings"`
+	Status      string         `json:"status"`
+	RowCount    int            `json:"row_count"`
+	CreatedAt   time.Time      `json:"created_at"`
+	CommittedAt *time.Time     `json:"committed_at,omitempty"`
+	UndoneAt    *time.Time     `json:"undone_at,omitempty"`
+}
+
+// ImportRow is one parsed statement line, valid when Errors is empty. Duplicate rows
+// carry an ExternalID already stored on the wallet and are skipped on commit, while
+// PossibleDuplicateOf only flags a similar existing transaction. RuleID is set when
+// CategoryID came from a categorisation rule.
+type ImportRow struct {
+	Line       int        `json:"line"`
+	OccurredAt *time.Time `json:"occurred_at,omitempty"`
+	Amount     string     `json:"amount,omitempty"`
+	Kind       string     `json:"kind,omitempty"`
+	Note       *string    `json:"note,omitempty"`
+	CategoryID *uuid.UUID `json:"category_id,omitempty"`
+	RuleID     *uuid.UUID `json:"rule_id,omitempty"`
+	ExternalID string     `json:"external_id,omitempty"`
+	Duplicate  bool       `json:"duplicate,omitempty"`
+	// PossibleDuplicateOf is the closest existing transaction with the same amount,
+	// a nearby date and a similar note.
+	PossibleDuplicateOf *uuid.UUID `json:"possible_duplicate_of,omitempty"`
+	Errors              []string   `json:"errors,omitempty"`
+	Warnings            []string   `json:"warnings,omitempty"`
+}
+
+// ImportPreview is the dry-run result shown to the user before committing.
+type ImportPreview struct {
+	Batch       ImportBatch `json:"batch"`
+	Headers     []string    `json:"headers,omitempty"`
+	Rows        []ImportRow `json:"rows"`
+	TotalRows   int         `json:"total_rows"`
+	ValidRows   int         `json:"valid_rows"`
+	InvalidRows int         `json:"invalid_rows"`
+	Duplicates  int         `json:"duplicate_rows"`
+	TotalIn     string      `json:"total_in"`
+	TotalOut    string      `json:"total_out"`
+}
+
+// UploadImport stores a statement file for walletID, detects its format and returns a
+// preview using the suggested column mapping.
+func (s *Service) UploadImport(ctx context.Context, userID, walletID uuid.UUID, source, filename string, content []byte) (*ImportPreview, error) {
+	if _, err := s.repo.GetWallet(ctx, userID, walletID); err != nil {
+		return nil, err
+	}
+
+	b := ImportBatch{
+		ID:        uuid.New(),
+		UserID:    userID,
+		WalletID:  walletID,
+		Source:    source,
+		Filename:  filename,
+		Content:   content,
+		Status:    ImportUploaded,
+		CreatedAt: time.Now(),
+	}
+	switch source {
+	case "csv":
+		settings, err := detectCSVSettings(content)
+		if err != nil {
+			return nil, err
+		}
+		b.Settings = settings
+	case "ofx":
+	case "qif":
+		b.Settings = ImportSettings{DateFormat: detectQIFDateFormat(content)}
+	default:
+		return nil, fmt.Errorf("%w: unsupported source %q", ErrInvalidImport, source)
+	}
+
+	if err := s.repo.CreateImportBatch(ctx, b); err != nil {
+		return nil, err
+	}
+	return s.buildPreview(ctx, b)
+}
+
+// GetImport re-parses a batch with its stored settings.
+func (s *Service) GetImport(ctx context.Context, userID, batchID uuid.UUID) (*ImportPreview, error) {
+	b, err := s.repo.GetImportBatch(ctx, userID, batchID)
+	if err != nil {
+		return nil, err
+	}
+	return s.buildPreview(ctx, *b)
+}
+
+// PreviewImport saves the client's format overrides and column mapping and returns the
+// dry-run result. Nothing is written to finance.transactions.
+func (s *Service) PreviewImport(ctx context.Context, userID, batchID uuid.UUID, settings ImportSettings) (*ImportPreview, error) {
+	b, err := s.repo.GetImportBatch(ctx, userID, batchID)
+	if err != nil {
+		return nil, err
+	}
+	if b.Status != ImportUploaded {
+		return nil, fmt.Errorf("%w: batch is %s", ErrImportState, b.Status)
+	}
+
+	// Field kosong berarti pakai hasil deteksi otomatis
+	if settings.Delimiter == "" {
+		settings.Delimiter = b.Settings.Delimiter
+	}
+	if settings.DateFormat == "" {
+		settings.DateFormat = b.Settings.DateFormat
+	}
+	if settings.DecimalSep == "" {
+		settings.DecimalSep = b.Settings.DecimalSep
+	}
+	b.Settings = settings
+	if err := s.repo.UpdateImportSettings(ctx, *b); err != nil {
+		return nil, err
+	}
+	return s.buildPreview(ctx, *b)
+}
+
+// CommitImport writes every row of the batch and the wallet balance change in one
+// database transaction. It refuses batches that still have invalid rows.
+func (s *Service) CommitImport(ctx context.Context, userID, batchID uuid.UUID) (*ImportBatch, error) {
+	b, err := s.repo.GetImportBatch(ctx, userID, batchID)
+	if err != nil {
+		return nil, err
+	}
+	if b.Status != ImportUploaded {
+		return nil, fmt.Errorf("%w: batch is %s", ErrImportState, b.Status)
+	}
+
+	rows, _, err := s.parseImport(ctx, *b)
+	if err != nil {
+		return nil, err
+	}
+	txs := make([]Transaction, 0, len(rows))
+	for _, row := range rows {
+		if len(row.Errors) > 0 {
+			return nil, fmt.Errorf("%w: line %d: %s", ErrInvalidImport, row.Line, row.Errors[0])
+		}
+		if row.Duplicate {
+			continue
+		}
+		var externalID *string
+		if row.ExternalID != "" {
+			id := row.ExternalID
+			externalID = &id
+		}
+		txs = append(txs, Transaction{
+			ID:            uuid.New(),
+			UserID:        userID,
+			WalletID:      b.WalletID,
+			CategoryID:    row.CategoryID,
+			Amount:        row.Amount,
+			Kind:          row.Kind,
+			Note:          row.Note,
+			OccurredAt:    *row.OccurredAt,
+			ImportBatchID: &b.ID,
+			ExternalID:    externalID,
+			CreatedAt:     time.Now(),
+		})
+	}
+	if len(txs) == 0 {
+		return nil, fmt.Errorf("%w: file has no new rows", ErrInvalidImport)
+	}
+
+	ctx = userChange(ctx, SourceImport, userID, &batchID)
+	if err := s.repo.CommitImport(ctx, *b, txs); err != nil {
+		return nil, err
+	}
+	s.forget(userID)
+	now := time.Now()
+	b.Status = ImportCommitted
+	b.RowCount = len(txs)
+	b.CommittedAt = &now
+	return b, nil
+}
+
+// UndoImport deletes every transaction created by the batch and reverses its wallet
+// balance change.
+func (s *Service) UndoImport(ctx context.Context, userID, batchID uuid.UUID) (*ImportBatch, error) {
+	b, err := s.repo.GetImportBatch(ctx, userID, batchID)
+	if err != nil {
+		return nil, err
+	}
+	if b.Status != ImportCommitted {
+		return nil, fmt.Errorf("%w: batch is %s", ErrImportState, b.Status)
+	}
+	ctx = userChange(ctx, SourceImport, userID, &batchID)
+	if err := s.repo.UndoImport(ctx, userID, batchID); err != nil {
+		return nil, err
+	}
+	s.forget(userID)
+	now := time.Now()
+	b.Status = ImportUndone
+	b.UndoneAt = &now
+	return b, nil
+}
+
+// parseImport turns the stored file into rows according to the batch source.
+func (s *Service) parseImport(ctx context.Context, b ImportBatch) ([]ImportRow, []string, error) {
+	categories, err := s.repo.ListCategories(ctx, b.UserID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var rows []ImportRow
+	var headers []string
+	switch b.Source {
+	case "csv":
+		headers, rows, err = parseCSV(b.Content, b.Settings, categories)
+	case "ofx":
+		rows, err = parseOFX(b.Content)
+	case "qif":
+		rows, err = parseQIF(b.Content, b.Settings, categories)
+	default:
+		err = fmt.Errorf("%w: unsupported source %q", ErrInvalidImport, b.Source)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(rows) > maxImportRows {
+		return nil, nil, fmt.Errorf("%w: file has more than %d rows", ErrInvalidImport, maxImportRows)
+	}
+	if b.Status == ImportUploaded {
+		if err := s.markDuplicates(ctx, b.WalletID, rows); err != nil {
+			return nil, nil, err
+		}
+		if err := s.flagPossibleDuplicates(ctx, b.WalletID, rows); err != nil {
+			return nil, nil, err
+		}
+	}
+	if err := s.categoriseRows(ctx, b, rows); err != nil {
+		return nil, nil, err
+	}
+	return rows, headers, nil
+}
+
+// categoriseRows fills in the category of valid rows that have none from the user's
+// categorisation rules.
+func (s *Service) categoriseRows(ctx context.Context, b ImportBatch, rows []ImportRow) error {
+	rules, err := s.loadRuleSet(ctx, b.UserID)
+	if err != nil || len(rules) == 0 {
+		return err
+	}
+	for i, row := range rows {
+		if row.CategoryID != nil || len(row.Errors) > 0 {
+			continue
+		}
+		t := Transaction{UserID: b.UserID, WalletID: b.WalletID, Amount: row.Amount, Kind: row.Kind, Note: row.Note}
+		if r := rules.match(t); r != nil {
+			categoryID, ruleID := r.CategoryID, r.ID
+			rows[i].CategoryID = &categoryID
+			rows[i].RuleID = &ruleID
+		}
+	}
+	return nil
+}
+
+// markDuplicates flags rows whose external id is already on the wallet or appears
+// earlier in the same file.
+func (s *Service) markDuplicates(ctx context.Context, walletID uuid.UUID, rows []ImportRow) error {
+	var ids []string
+	for _, row := range rows {
+		if row.ExternalID != "" {
+			ids = append(ids, row.ExternalID)
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+	existing, err := s.repo.ExistingExternalIDs(ctx, walletID, ids)
+	if err != nil {
+		return err
+	}
+
+	seen := map[string]bool{}
+	for i := range rows {
+		id := rows[i].ExternalID
+		if id == "" {
+			continue
+		}
+		switch {
+		case existing[id]:
+			rows[i].Duplicate = true
+			rows[i].Warnings = append(rows[i].Warnings, "already imported into this wallet")
+		case seen[id]:
+			rows[i].Duplicate = true
+			rows[i].Warnings = append(rows[i].Warnings, "repeated in this file")
+		}
+		seen[id] = true
+	}
+	return nil
+}
+
+func (s *Service) buildPreview(ctx context.Context, b ImportBatch) (*ImportPreview, error) {
+	rows, headers, err := s.parseImport(ctx, b)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &ImportPreview{Batch: b, Headers: headers, Rows: []ImportRow{}, TotalRows: len(rows)}
+	var totalIn, totalOut float64
+	for _, row := range rows {
+		if len(row.Errors) > 0 {
+			p.InvalidRows++
+			p.Rows = append(p.Rows, row)
+			continue
+		}
+		if row.Duplicate {
+			p.Duplicates++
+			p.Rows = append(p.Rows, row)
+			continue
+		}
+		p.ValidRows++
+		amount, _ := strconv.ParseFloat(row.Amount, 64)
+		if row.Kind == "in" {
+			totalIn += amount
+		} else {
+			totalOut += amount
+		}
+		if p.ValidRows <= previewValidRows {
+			p.Rows = append(p.Rows, row)
+		}
+	}
+	p.TotalIn = strconv.FormatFloat(totalIn, 'f', 2, 64)
+	p.TotalOut = strconv.FormatFloat(totalOut, 'f', 2, 64)
+	return p, nil
+}
@@ -0,0 +1,522 @@
+package transaction
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// csvDateFormats are tried in order; day-first layouts come before month-first ones
+// because most Indonesian bank exports use dd/mm/yyyy.
+var csvDateFormats = []string{
+	"2006-01-02",
+	"2006-01-02 15:04:05",
+	time.RFC3339,
+	"02/01/2006",
+	"02/01/2006 15:04",
+	"02/01/2006 15:04:05",
+	"2/1/2006",
+	"02-01-2006",
+	"02.01.2006",
+	"2006/01/02",
+	"01/02/2006",
+	"1/2/2006",
+	"02 Jan 2006",
+	"2 Jan 2006",
+	"Jan 2, 2006",
+	"02/01/06",
+}
+
+var csvDelimiters = []rune{',', ';', '\t', '|'}
+
+var amountPattern = regexp.MustCompile(`^\d+(\.\d+)?$`)
+
+// amountMarkers are debit/credit suffixes some banks append instead of a sign.
+var amountMarkers = []struct {
+	marker string
+	debit  bool
+}{
+	{"DB", true}, {"DR", true}, {"CR", false}, {"D", true}, {"K", false}, {"C", false},
+}
+
+// headerAliases maps lower-cased header names onto mapping fields.
+var headerAliases = map[string][]string{
+	"date":     {"date", "tanggal", "tgl", "tgl transaksi", "tanggal transaksi", "transaction date", "posting date"},
+	"amount":   {"amount", "jumlah", "nominal", "nilai", "mutasi"},
+	"debit":    {"debit", "debet", "keluar", "withdrawal", "pengeluaran"},
+	"credit":   {"credit", "kredit", "masuk", "deposit", "pemasukan"},
+	"kind":     {"type", "jenis", "kind", "db/cr", "d/k", "dk"},
+	"note":     {"note", "notes", "keterangan", "description", "deskripsi", "memo", "uraian", "catatan"},
+	"category": {"category", "kategori"},
+}
+
+// detectCSVSettings guesses delimiter, header row, date layout, decimal separator and a
+// column mapping from the file contents.
+func detectCSVSettings(content []byte) (ImportSettings, error) {
+	content = stripBOM(content)
+	settings := ImportSettings{Delimiter: detectDelimiter(content)}
+
+	records, err := readCSV(content, settings.Delimiter)
+	if err != nil {
+		return settings, err
+	}
+	if len(records) == 0 {
+		return settings, fmt.Errorf("%w: file is empty", ErrInvalidImport)
+	}
+
+	settings.HasHeader = looksLikeHeader(records[0])
+	data := records
+	if settings.HasHeader {
+		data = records[1:]
+	}
+	if len(data) > 50 {
+		data = data[:50]
+	}
+
+	width := 0
+	for _, rec := range records {
+		if len(rec) > width {
+			width = len(rec)
+		}
+	}
+
+	var dateCols, numericCols []int
+	var numericValues []string
+	for col := 0; col < width; col++ {
+		values := columnValues(data, col)
+		if len(values) == 0 {
+			continue
+		}
+		if format := detectDateFormat(values); format != "" {
+			if settings.DateFormat == "" {
+				settings.DateFormat = format
+			}
+			dateCols = append(dateCols, col)
+			continue
+		}
+		if allNumeric(values) {
+			numericCols = append(numericCols, col)
+			numericValues = append(numericValues, values...)
+		}
+	}
+	settings.DecimalSep = detectDecimalSeparator(numericValues)
+
+	if settings.HasHeader {
+		settings.Mapping = mappingFromHeader(records[0])
+	}
+	m := &settings.Mapping
+	if m.Date == nil && len(dateCols) > 0 {
+		m.Date = intPtr(dateCols[0])
+	}
+	if m.Amount == nil && m.Debit == nil && m.Credit == nil && len(numericCols) > 0 {
+		m.Amount = intPtr(numericCols[0])
+	}
+	if m.Note == nil {
+		for col := 0; col < width; col++ {
+			if !containsInt(dateCols, col) && !containsInt(numericCols, col) && !mappingUses(*m, col) {
+				m.Note = intPtr(col)
+				break
+			}
+		}
+	}
+	return settings, nil
+}
+
+// parseCSV applies settings to the file and returns the header row (if any) and one
+// ImportRow per data line.
+func parseCSV(content []byte, settings ImportSettings, categories []Category) ([]string, []ImportRow, error) {
+	m := settings.Mapping
+	if m.Date == nil {
+		return nil, nil, fmt.Errorf("%w: date column is not mapped", ErrInvalidImport)
+	}
+	if m.Amount == nil && m.Debit == nil && m.Credit == nil {
+		return nil, nil, fmt.Errorf("%w: amount or debit/credit columns are not mapped", ErrInvalidImport)
+	}
+	if settings.DateFormat == "" {
+		return nil, nil, fmt.Errorf("%w: date format is not set", ErrInvalidImport)
+	}
+
+	records, err := readCSV(stripBOM(content), settings.Delimiter)
+	if err != nil {
+		return nil, nil, err
+	}
+	var headers []string
+	if settings.HasHeader && len(records) > 0 {
+		headers = records[0]
+		records = records[1:]
+	}
+
+	firstLine := 1
+	if headers != nil {
+		firstLine = 2
+	}
+	rows := make([]ImportRow, 0, len(records))
+	for i, rec := range records {
+		if isBlankRecord(rec) {
+			continue
+		}
+		rows = append(rows, parseCSVRecord(rec, firstLine+i, settings, categories))
+	}
+	return headers, rows, nil
+}
+
+func parseCSVRecord(rec []string, line int, settings ImportSettings, categories []Category) ImportRow {
+	m := settings.Mapping
+	row := ImportRow{Line: line}
+
+	rawDate := cell(rec, m.Date)
+	if at, err := time.Parse(settings.DateFormat, rawDate); err != nil {
+		row.Errors = append(row.Errors, fmt.Sprintf("cannot parse date %q as %s", rawDate, settings.DateFormat))
+	} else {
+		row.OccurredAt = &at
+	}
+
+	var amount string
+	var negative bool
+	var err error
+	switch {
+	case m.Amount != nil:
+		amount, negative, err = parseAmount(cell(rec, m.Amount), settings.DecimalSep)
+	default:
+		debit, credit := cell(rec, m.Debit), cell(rec, m.Credit)
+		if d, _, derr := parseAmount(debit, settings.DecimalSep); derr == nil && !isZeroAmount(d) {
+			amount, negative = d, true
+		} else if c, _, cerr := parseAmount(credit, settings.DecimalSep); cerr == nil && !isZeroAmount(c) {
+			amount = c
+		} else {
+			err = fmt.Errorf("neither debit %q nor credit %q holds an amount", debit, credit)
+		}
+	}
+	switch {
+	case err != nil:
+		row.Errors = append(row.Errors, err.Error())
+	case isZeroAmount(amount):
+		row.Errors = append(row.Errors, "amount is zero")
+	default:
+		row.Amount = amount
+		row.Kind = "in"
+		if negative {
+			row.Kind = "out"
+		}
+	}
+
+	if m.Kind != nil {
+		raw := cell(rec, m.Kind)
+		if kind, ok := parseKind(raw); ok {
+			row.Kind = kind
+		} else {
+			row.Errors = append(row.Errors, fmt.Sprintf("unknown transaction type %q", raw))
+		}
+	}
+
+	if note := cell(rec, m.Note); note != "" {
+		row.Note = &note
+	}
+
+	if name := cell(rec, m.Category); name != "" {
+		if c := matchCategory(categories, name, row.Kind); c != nil {
+			row.CategoryID = &c.ID
+		} else {
+			row.Warnings = append(row.Warnings, fmt.Sprintf("category %q not found, left uncategorised", name))
+		}
+	}
+	return row
+}
+
+// parseAmount normalises a statement amount such as "Rp 1.234,56", "(1,234.56)",
+// "-50000" or "1,234.00 DB" into a plain "1234.56" string plus its sign.
+func parseAmount(raw, decimalSep string) (string, bool, error) {
+	s := strings.ToUpper(strings.TrimSpace(raw))
+	if s == "" {
+		return "", false, fmt.Errorf("amount is empty")
+	}
+
+	negative := false
+	// Penanda debit/kredit di akhir angka, misalnya "1,234.00 DB" atau "500.000CR"
+	for _, m := range amountMarkers {
+		marker, isDebit := m.marker, m.debit
+		trimmed := strings.TrimSuffix(s, marker)
+		if trimmed == s || trimmed == "" {
+			continue
+		}
+		if len(marker) == 1 && !strings.HasSuffix(trimmed, " ") {
+			continue
+		}
+		s = strings.TrimSpace(trimmed)
+		negative = isDebit
+		break
+	}
+	if strings.HasPrefix(s, "(") && strings.HasSuffix(s, ")") {
+		negative = true
+		s = s[1 : len(s)-1]
+	}
+	s = strings.NewReplacer("RP", "", "IDR", "", " ", "", "\u00a0", "").Replace(s)
+	switch {
+	case strings.HasPrefix(s, "-"):
+		negative = true
+		s = s[1:]
+	case strings.HasSuffix(s, "-"):
+		negative = true
+		s = s[:len(s)-1]
+	default:
+		s = strings.TrimPrefix(s, "+")
+	}
+
+	if decimalSep == "," {
+		s = strings.ReplaceAll(s, ".", "")
+		s = strings.ReplaceAll(s, ",", ".")
+	} else {
+		s = strings.ReplaceAll(s, ",", "")
+	}
+	if !amountPattern.MatchString(s) {
+		return "", false, fmt.Errorf("cannot parse amount %q", raw)
+	}
+	return s, negative, nil
+}
+
+func isZeroAmount(s string) bool {
+	return strings.Trim(s, "0.") == ""
+}
+
+func parseKind(raw string) (string, bool) {
+	switch strings.ToLower(strings.TrimSpace(raw)) {
+	case "in", "cr", "credit", "kredit", "k", "c", "masuk", "income", "deposit", "pemasukan":
+		return "in", true
+	case "out", "db", "dr", "debit", "debet", "d", "keluar", "expense", "withdrawal", "pengeluaran":
+		return "out", true
+	}
+	return "", false
+}
+
+// matchCategory finds an active category by case-insensitive name, preferring one with
+// the row's kind.
+func matchCategory(categories []Category, name, kind string) *Category {
+	var fallback *Category
+	for i := range categories {
+		c := &categories[i]
+		if c.ArchivedAt != nil || !strings.EqualFold(strings.TrimSpace(c.Name), strings.TrimSpace(name)) {
+			continue
+		}
+		if c.Kind == kind {
+			return c
+		}
+		if fallback == nil {
+			fallback = c
+		}
+	}
+	return fallback
+}
+
+func readCSV(content []byte, delimiter string) ([][]string, error) {
+	r := csv.NewReader(bytes.NewReader(content))
+	if delimiter != "" {
+		r.Comma = []rune(delimiter)[0]
+	}
+	r.FieldsPerRecord = -1
+	r.LazyQuotes = true
+	r.TrimLeadingSpace = true
+	records, err := r.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidImport, err)
+	}
+	return records, nil
+}
+
+// detectDelimiter picks the delimiter that splits the first lines into the most
+// consistent number of fields.
+func detectDelimiter(content []byte) string {
+	best, bestScore := ",", 0
+	for _, d := range csvDelimiters {
+		r := csv.NewReader(bytes.NewReader(content))
+		r.Comma = d
+		r.FieldsPerRecord = -1
+		r.LazyQuotes = true
+
+		counts := map[int]int{}
+		for i := 0; i < 20; i++ {
+			rec, err := r.Read()
+			if err != nil {
+				break
+			}
+			counts[len(rec)]++
+		}
+		for fields, n := range counts {
+			if fields < 2 {
+				continue
+			}
+			if score := n*100 + fields; score > bestScore {
+				best, bestScore = string(d), score
+			}
+		}
+	}
+	return best
+}
+
+// detectDateFormat returns the first CSV layout that parses every value, or "".
+func detectDateFormat(values []string) string {
+	return detectDateLayout(values, csvDateFormats)
+}
+
+func detectDateLayout(values, layouts []string) string {
+	for _, layout := range layouts {
+		ok := true
+		for _, v := range values {
+			if _, err := time.Parse(layout, v); err != nil {
+				ok = false
+				break
+			}
+		}
+		if ok {
+			return layout
+		}
+	}
+	return ""
+}
+
+// detectDecimalSeparator votes over numeric cells: "1.234,56" and "1.234.567" point to a
+// comma decimal separator, "1,234.56" and "1,234,567" to a dot. A lone group of three
+// digits such as "1.234" only counts when it carries a rupiah sign, which is never
+// written with cents after a dot.
+func detectDecimalSeparator(values []string) string {
+	comma, dot := 0, 0
+	for _, v := range values {
+		upper := strings.ToUpper(v)
+		rupiah := strings.Contains(upper, "RP") || strings.Contains(upper, "IDR")
+		// Hanya angka dan pemisah yang dihitung: "(1.234)" dan "1.234 DB" sama dengan "1.234"
+		v = strings.Map(func(r rune) rune {
+			if (r >= '0' && r <= '9') || r == '.' || r == ',' {
+				return r
+			}
+			return -1
+		}, v)
+		lastComma, lastDot := strings.LastIndex(v, ","), strings.LastIndex(v, ".")
+		switch {
+		case lastComma > lastDot && lastDot >= 0:
+			comma++
+		case lastDot > lastComma && lastComma >= 0:
+			dot++
+		case strings.Count(v, ".") > 1:
+			comma++
+		case strings.Count(v, ",") > 1:
+			dot++
+		case lastComma >= 0 && len(v)-lastComma-1 != 3:
+			comma++
+		case lastDot >= 0 && len(v)-lastDot-1 != 3:
+			dot++
+		case lastDot >= 0 && rupiah:
+			comma++
+		}
+	}
+	if comma > dot {
+		return ","
+	}
+	return "."
+}
+
+func allNumeric(values []string) bool {
+	for _, v := range values {
+		if _, _, err := parseAmount(v, "."); err != nil {
+			if _, _, err := parseAmount(v, ","); err != nil {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// looksLikeHeader reports whether no cell of the first record is a date or an amount.
+func looksLikeHeader(rec []string) bool {
+	for _, v := range rec {
+		v = strings.TrimSpace(v)
+		if v == "" {
+			continue
+		}
+		if detectDateFormat([]string{v}) != "" || allNumeric([]string{v}) {
+			return false
+		}
+	}
+	return true
+}
+
+func mappingFromHeader(header []string) ColumnMapping {
+	var m ColumnMapping
+	targets := map[string]**int{
+		"date": &m.Date, "amount": &m.Amount, "debit": &m.Debit, "credit": &m.Credit,
+		"kind": &m.Kind, "note": &m.Note, "category": &m.Category,
+	}
+	for col, name := range header {
+		name = strings.ToLower(strings.TrimSpace(name))
+		for field, aliases := range headerAliases {
+			if *targets[field] != nil {
+				continue
+			}
+			for _, alias := range aliases {
+				if name == alias {
+					*targets[field] = intPtr(col)
+				}
+			}
+		}
+	}
+	// Kolom debit/kredit terpisah lebih akurat daripada kolom jumlah tanpa tanda
+	if m.Debit != nil && m.Credit != nil {
+		m.Amount = nil
+	}
+	return m
+}
+
+func mappingUses(m ColumnMapping, col int) bool {
+	for _, p := range []*int{m.Date, m.Amount, m.Debit, m.Credit, m.Kind, m.Note, m.Category} {
+		if p != nil && *p == col {
+			return true
+		}
+	}
+	return false
+}
+
+func columnValues(records [][]string, col int) []string {
+	var out []string
+	for _, rec := range records {
+		if col < len(rec) {
+			if v := strings.TrimSpace(rec[col]); v != "" {
+				out = append(out, v)
+			}
+		}
+	}
+	return out
+}
+
+func cell(rec []string, col *int) string {
+	if col == nil || *col < 0 || *col >= len(rec) {
+		return ""
+	}
+	return strings.TrimSpace(rec[*col])
+}
+
+func isBlankRecord(rec []string) bool {
+	for _, v := range rec {
+		if strings.TrimSpace(v) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+func stripBOM(content []byte) []byte {
+	return bytes.TrimPrefix(content, []byte("\xef\xbb\xbf"))
+}
+
+func intPtr(v int) *int {
+	return &v
+}
+
+func containsInt(list []int, v int) bool {
+	for _, x := range list {
+		if x == v {
+			return true
+		}
+	}
+	return false
+}
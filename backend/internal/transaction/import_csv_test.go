@@ -0,0 +1,155 @@
+package transaction
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseAmount(t *testing.T) {
+	tests := []struct {
+		raw, sep string
+		want     string
+		negative bool
+		wantErr  bool
+	}{
+		{"1234.56", ".", "1234.56", false, false},
+		{"1.234,56", ",", "1234.56", false, false},
+		{"1,234.56", ".", "1234.56", false, false},
+		{"(1,234.56)", ".", "1234.56", true, false},
+		{"(1.234,56)", ",", "1234.56", true, false},
+		{"Rp 1.234", ",", "1234", false, false},
+		{"Rp1.234.567,00", ",", "1234567.00", false, false},
+		{"IDR 1.234.567", ",", "1234567", false, false},
+		{"-Rp 1.234", ",", "1234", true, false},
+		{"Rp -1.234", ",", "1234", true, false},
+		{"-50000", ".", "50000", true, false},
+		{"+50000", ".", "50000", false, false},
+		// Tanda minus di belakang angka
+		{"50000-", ".", "50000", true, false},
+		{"1.234,56-", ",", "1234.56", true, false},
+		{"1,234.56 -", ".", "1234.56", true, false},
+		{"1,234.00 DB", ".", "1234.00", true, false},
+		{"500.000CR", ",", "500000", false, false},
+		{"500.000 cr", ",", "500000", false, false},
+		{"75,000.00 D", ".", "75000.00", true, false},
+		{"75.000 K", ",", "75000", false, false},
+		{"1 234,56", ",", "1234.56", false, false},
+		{"1 234,56", ",", "1234.56", false, false},
+		{"", ".", "", false, true},
+		{"   ", ".", "", false, true},
+		{"abc", ".", "", false, true},
+		{"CR", ".", "", false, true},
+		{"500D", ".", "", false, true},
+		{"1.2.3", ".", "", false, true},
+		{"--5", ".", "", false, true},
+		{"1,5e3", ",", "", false, true},
+	}
+	for _, tt := range tests {
+		got, negative, err := parseAmount(tt.raw, tt.sep)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseAmount(%q, %q) err = %v, wantErr %v", tt.raw, tt.sep, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want || negative != tt.negative {
+			t.Errorf("parseAmount(%q, %q) = %q, %v; want %q, %v", tt.raw, tt.sep, got, negative, tt.want, tt.negative)
+		}
+	}
+}
+
+func TestDetectDecimalSeparator(t *testing.T) {
+	tests := []struct {
+		name   string
+		values []string
+		want   string
+	}{
+		{"comma decimals", []string{"1.234,56"}, ","},
+		{"dot decimals", []string{"1,234.56"}, "."},
+		{"dot thousands only", []string{"1.234.567"}, ","},
+		{"comma thousands only", []string{"1,234,567"}, "."},
+		{"short comma fraction", []string{"12,5"}, ","},
+		{"short dot fraction", []string{"12.5"}, "."},
+		{"rupiah thousands", []string{"Rp 1.234"}, ","},
+		{"rupiah in parentheses", []string{"(IDR 45.000)"}, ","},
+		{"negative with trailing minus", []string{"1.234,56-", "500"}, ","},
+		{"accounting negatives", []string{"(1,234.56)", "78.90"}, "."},
+		{"debit marker after comma cents", []string{"45.000,00 DB"}, ","},
+		{"credit marker after dot cents", []string{"1,234.56 CR"}, "."},
+		{"short fraction in parentheses", []string{"(12,5)"}, ","},
+		{"ambiguous thousands default to dot", []string{"1.234", "1,234"}, "."},
+		{"plain integers", []string{"50000", "125000"}, "."},
+		{"majority wins", []string{"1.234,56", "2.000,00", "1,234.56"}, ","},
+		{"no values", nil, "."},
+	}
+	for _, tt := range tests {
+		if got := detectDecimalSeparator(tt.values); got != tt.want {
+			t.Errorf("%s: detectDecimalSeparator(%q) = %q, want %q", tt.name, tt.values, got, tt.want)
+		}
+	}
+}
+
+func TestDetectCSVSettings(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    ImportSettings
+	}{
+		{
+			name: "bank export with debit and credit columns",
+			content: "\ufeffTanggal;Keterangan;Debet;Kredit;Saldo\n" +
+				"05/01/2024;Makan siang;45.000,00;;1.955.000,00\n" +
+				"25/01/2024;Gaji Januari;;7.500.000,00;9.455.000,00\n" +
+				"27/01/2024;SPBU;150.000,00;;9.305.000,00\n",
+			want: ImportSettings{Delimiter: ";", DateFormat: "02/01/2006", DecimalSep: ",", HasHeader: true,
+				Mapping: ColumnMapping{Date: intPtr(0), Note: intPtr(1), Debit: intPtr(2), Credit: intPtr(3)}},
+		},
+		{
+			name: "signed amounts without a header",
+			content: "2024-01-05,Kopi,-25000.00\n" +
+				"2024-01-06,Refund,12500.50\n",
+			want: ImportSettings{Delimiter: ",", DateFormat: "2006-01-02", DecimalSep: ".",
+				Mapping: ColumnMapping{Date: intPtr(0), Amount: intPtr(2), Note: intPtr(1)}},
+		},
+		{
+			name: "tab separated with accounting negatives and categories",
+			content: "date\tdescription\tamount\tcategory\n" +
+				"01/15/2024\tGroceries\t(1,234.56)\tFood\n" +
+				"01/16/2024\tSalary\t5,000.00\tIncome\n",
+			want: ImportSettings{Delimiter: "\t", DateFormat: "01/02/2006", DecimalSep: ".", HasHeader: true,
+				Mapping: ColumnMapping{Date: intPtr(0), Note: intPtr(1), Amount: intPtr(2), Category: intPtr(3)}},
+		},
+		{
+			name: "rupiah amounts with a type column",
+			content: "Tgl|Uraian|Nominal|DK\n" +
+				"02 Jan 2024|Pulsa|Rp 100.000|D\n" +
+				"03 Jan 2024|Transfer masuk|Rp 2.500.000|K\n",
+			want: ImportSettings{Delimiter: "|", DateFormat: "02 Jan 2006", DecimalSep: ",", HasHeader: true,
+				Mapping: ColumnMapping{Date: intPtr(0), Note: intPtr(1), Amount: intPtr(2), Kind: intPtr(3)}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := detectCSVSettings([]byte(tt.content))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("detectCSVSettings =\n%+v\nwant\n%+v", describeSettings(got), describeSettings(tt.want))
+			}
+		})
+	}
+	if _, err := detectCSVSettings([]byte("")); err == nil {
+		t.Error("detectCSVSettings accepted an empty file")
+	}
+}
+
+// describeSettings spells out the mapping pointers for failure messages.
+func describeSettings(s ImportSettings) map[string]any {
+	out := map[string]any{"delimiter": s.Delimiter, "date_format": s.DateFormat, "decimal_separator": s.DecimalSep, "has_header": s.HasHeader}
+	m := s.Mapping
+	for name, p := range map[string]*int{"date": m.Date, "amount": m.Amount, "debit": m.Debit, "credit": m.Credit, "kind": m.Kind, "note": m.Note, "category": m.Category} {
+		if p != nil {
+			out[name] = *p
+		}
+	}
+	return out
+}
@@ -0,0 +1,144 @@
+package transaction
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// parseOFX reads the <STMTTRN> entries of an OFX/QFX statement. Both the SGML flavour
+// (OFX 1.x, leaf tags left unclosed) and the XML flavour (OFX 2.x) are accepted because
+// values are read up to the next tag either way.
+func parseOFX(content []byte) ([]ImportRow, error) {
+	text := string(stripBOM(content))
+	upper := strings.ToUpper(text)
+	if !strings.Contains(upper, "<OFX>") {
+		return nil, fmt.Errorf("%w: not an OFX file", ErrInvalidImport)
+	}
+
+	var rows []ImportRow
+	offset := 0
+	for {
+		start := strings.Index(upper[offset:], "<STMTTRN>")
+		if start < 0 {
+			break
+		}
+		start += offset
+		end := strings.Index(upper[start:], "</STMTTRN>")
+		if end < 0 {
+			return nil, fmt.Errorf("%w: unterminated STMTTRN at line %d", ErrInvalidImport, lineAt(text, start))
+		}
+		end += start
+		rows = append(rows, parseOFXEntry(text[start:end], upper[start:end], lineAt(text, start)))
+		offset = end
+	}
+	return rows, nil
+}
+
+func parseOFXEntry(block, upper string, line int) ImportRow {
+	row := ImportRow{Line: line}
+
+	rawDate := ofxValue(block, upper, "DTPOSTED")
+	if at, err := parseOFXDate(rawDate); err != nil {
+		row.Errors = append(row.Errors, err.Error())
+	} else {
+		row.OccurredAt = &at
+	}
+
+	rawAmount := ofxValue(block, upper, "TRNAMT")
+	decimalSep := "."
+	if strings.Contains(rawAmount, ",") && !strings.Contains(rawAmount, ".") {
+		decimalSep = ","
+	}
+	amount, negative, err := parseAmount(rawAmount, decimalSep)
+	switch {
+	case err != nil:
+		row.Errors = append(row.Errors, err.Error())
+	case isZeroAmount(amount):
+		row.Errors = append(row.Errors, "amount is zero")
+	default:
+		row.Amount = amount
+		row.Kind = "in"
+		if negative {
+			row.Kind = "out"
+		}
+	}
+
+	row.ExternalID = ofxValue(block, upper, "FITID")
+	if row.ExternalID == "" {
+		row.Warnings = append(row.Warnings, "missing FITID, duplicate check skipped")
+	}
+
+	name := ofxValue(block, upper, "NAME")
+	if name == "" {
+		name = ofxValue(block, upper, "PAYEEID")
+	}
+	row.Note = joinNote(name, ofxValue(block, upper, "MEMO"))
+	return row
+}
+
+// ofxValue returns the text after <tag> up to the next tag.
+func ofxValue(block, upper, tag string) string {
+	i := strings.Index(upper, "<"+tag+">")
+	if i < 0 {
+		return ""
+	}
+	v := block[i+len(tag)+2:]
+	if j := strings.IndexByte(v, '<'); j >= 0 {
+		v = v[:j]
+	}
+	return unescapeSGML(strings.TrimSpace(v))
+}
+
+// parseOFXDate handles YYYYMMDD[HHMMSS[.XXX]][[offset:TZ]], e.g. 20240131120000[+7:WIB].
+func parseOFXDate(raw string) (time.Time, error) {
+	digits := raw
+	loc := time.UTC
+	if i := strings.IndexByte(raw, '['); i >= 0 {
+		digits = raw[:i]
+		tz := strings.Trim(raw[i:], "[]")
+		if j := strings.IndexByte(tz, ':'); j >= 0 {
+			tz = tz[:j]
+		}
+		if hours, err := strconv.ParseFloat(tz, 64); err == nil {
+			loc = time.FixedZone(strings.Trim(raw[i:], "[]"), int(hours*3600))
+		}
+	}
+	if i := strings.IndexByte(digits, '.'); i >= 0 {
+		digits = digits[:i]
+	}
+
+	switch {
+	case len(digits) >= 14:
+		return time.ParseInLocation("20060102150405", digits[:14], loc)
+	case len(digits) >= 8:
+		return time.ParseInLocation("20060102", digits[:8], loc)
+	}
+	return time.Time{}, fmt.Errorf("cannot parse date %q", raw)
+}
+
+func unescapeSGML(s string) string {
+	return strings.NewReplacer("&amp;", "&", "&lt;", "<", "&gt;", ">", "&quot;", `"`, "&apos;", "'").Replace(s)
+}
+
+// joinNote combines payee and memo into a transaction note, dropping empty or repeated parts.
+func joinNote(payee, memo string) *string {
+	payee, memo = strings.TrimSpace(payee), strings.TrimSpace(memo)
+	var note string
+	switch {
+	case payee == "" && memo == "":
+		return nil
+	case payee == "" || strings.EqualFold(payee, memo):
+		note = memo
+	case memo == "":
+		note = payee
+	default:
+		note = payee + " - " + memo
+	}
+	return &note
+}
+
+func lineAt(text string, offset int) int {
+	return strings.Count(text[:offset], "\n") + 1
+}
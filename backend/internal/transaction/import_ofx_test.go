@@ -0,0 +1,117 @@
+package transaction
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func readFixture(t *testing.T, name string) []byte {
+	t.Helper()
+	content, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return content
+}
+
+// wantRow is the part of an ImportRow the parser tests check.
+type wantRow struct {
+	date       time.Time
+	amount     string
+	kind       string
+	externalID string
+	note       string
+}
+
+func checkRows(t *testing.T, rows []ImportRow, want []wantRow) {
+	t.Helper()
+	if len(rows) != len(want) {
+		t.Fatalf("got %d rows, want %d", len(rows), len(want))
+	}
+	for i, w := range want {
+		row := rows[i]
+		if len(row.Errors) > 0 {
+			t.Errorf("row %d: unexpected errors %v", i+1, row.Errors)
+			continue
+		}
+		if row.OccurredAt == nil || !row.OccurredAt.Equal(w.date) {
+			t.Errorf("row %d: occurred_at = %v, want %v", i+1, row.OccurredAt, w.date)
+		}
+		if row.Amount != w.amount || row.Kind != w.kind {
+			t.Errorf("row %d: amount/kind = %s %s, want %s %s", i+1, row.Amount, row.Kind, w.amount, w.kind)
+		}
+		if w.externalID != "" && row.ExternalID != w.externalID {
+			t.Errorf("row %d: external_id = %q, want %q", i+1, row.ExternalID, w.externalID)
+		}
+		if row.Note == nil || *row.Note != w.note {
+			t.Errorf("row %d: note = %v, want %q", i+1, row.Note, w.note)
+		}
+		if row.Line <= 0 {
+			t.Errorf("row %d: line = %d, want a positive line number", i+1, row.Line)
+		}
+	}
+}
+
+func TestParseOFXFixtures(t *testing.T) {
+	wib := time.FixedZone("+7:WIB", 7*3600)
+	tests := []struct {
+		file string
+		want []wantRow
+	}{
+		// OFX 1.x: SGML tanpa tag penutup, entitas di-escape
+		{"statement_v1.ofx", []wantRow{
+			{time.Date(2024, 1, 25, 9, 0, 0, 0, wib), "7500000.00", "in", "202401250001", "PT MAJU JAYA - Gaji Januari"},
+			{time.Date(2024, 1, 26, 0, 0, 0, 0, time.UTC), "45000.00", "out", "202401260002", "GRAB* A-6JX"},
+			{time.Date(2024, 1, 27, 15, 30, 0, 0, wib), "1250000.00", "out", "202401270003", "INDOMARET & CO - Belanja bulanan"},
+		}},
+		// OFX 2.x: XML dengan tag penutup dan pecahan detik
+		{"statement_v2.qfx", []wantRow{
+			{time.Date(2024, 2, 5, 0, 0, 0, 0, wib), "186000.00", "out", "CC-20240205-01", "NETFLIX.COM - Langganan"},
+			{time.Date(2024, 2, 20, 0, 0, 0, 0, wib), "186000.00", "in", "CC-20240220-02", "PEMBAYARAN"},
+			{time.Date(2024, 2, 21, 0, 0, 0, 0, wib), "86000.00", "out", "CC-20240205-01", "NETFLIX.COM - Duplicate FITID in the same file"},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			rows, err := parseOFX(readFixture(t, tt.file))
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkRows(t, rows, tt.want)
+		})
+	}
+}
+
+func TestParseOFXRejectsOtherFiles(t *testing.T) {
+	if _, err := parseOFX(readFixture(t, "dompet.qif")); err == nil {
+		t.Error("parseOFX accepted a QIF file")
+	}
+}
+
+func TestParseOFXDate(t *testing.T) {
+	tests := []struct {
+		raw  string
+		want time.Time
+	}{
+		{"20240131", time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC)},
+		{"20240131120000", time.Date(2024, 1, 31, 12, 0, 0, 0, time.UTC)},
+		{"20240131120000.000[+7:WIB]", time.Date(2024, 1, 31, 5, 0, 0, 0, time.UTC)},
+		{"20240131120000[-5:EST]", time.Date(2024, 1, 31, 17, 0, 0, 0, time.UTC)},
+		{"20240131120000[+5.5:IST]", time.Date(2024, 1, 31, 6, 30, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		got, err := parseOFXDate(tt.raw)
+		if err != nil {
+			t.Errorf("parseOFXDate(%q): %v", tt.raw, err)
+			continue
+		}
+		if !got.Equal(tt.want) {
+			t.Errorf("parseOFXDate(%q) = %v, want %v", tt.raw, got, tt.want)
+		}
+	}
+	if _, err := parseOFXDate("2024-01-31"); err == nil {
+		t.Error("parseOFXDate accepted a dashed date")
+	}
+}
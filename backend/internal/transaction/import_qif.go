@@ -0,0 +1,199 @@
+package transaction
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// qifDateFormats puts month-first layouts first because Quicken writes US dates;
+// "1" and "2" also accept zero-padded values.
+var qifDateFormats = []string{
+	"1/2/2006",
+	"1/2/06",
+	"2006-01-02",
+	"2/1/2006",
+	"2/1/06",
+	"02.01.2006",
+	"02-01-2006",
+}
+
+// qifSections are the !Type headers that hold transactions; others (accounts,
+// categories, investments) are skipped.
+var qifSections = map[string]bool{
+	"bank": true, "cash": true, "ccard": true, "oth a": true, "oth l": true,
+}
+
+type qifRecord struct {
+	line   int
+	fields map[byte]string
+}
+
+// detectQIFDateFormat picks the layout that parses the most D lines of the file, so a
+// single malformed date is reported on its row instead of breaking every row.
+func detectQIFDateFormat(content []byte) string {
+	records, _ := readQIF(content)
+	best, bestCount := qifDateFormats[0], 0
+	for _, layout := range qifDateFormats {
+		count := 0
+		for _, rec := range records {
+			if _, err := time.Parse(layout, normalizeQIFDate(rec.fields['D'])); err == nil {
+				count++
+			}
+		}
+		if count > bestCount {
+			best, bestCount = layout, count
+		}
+	}
+	return best
+}
+
+// parseQIF maps QIF bank records onto import rows. The external id is derived from the
+// record contents because QIF has no transaction id, so re-importing an overlapping
+// file is still caught as a duplicate.
+func parseQIF(content []byte, settings ImportSettings, categories []Category) ([]ImportRow, error) {
+	records, err := readQIF(content)
+	if err != nil {
+		return nil, err
+	}
+
+	var amounts []string
+	for _, rec := range records {
+		amounts = append(amounts, qifAmount(rec))
+	}
+	decimalSep := settings.DecimalSep
+	if decimalSep == "" {
+		decimalSep = detectDecimalSeparator(amounts)
+	}
+	layout := settings.DateFormat
+	if layout == "" {
+		layout = qifDateFormats[0]
+	}
+
+	seen := map[string]int{}
+	rows := make([]ImportRow, 0, len(records))
+	for _, rec := range records {
+		row := ImportRow{Line: rec.line}
+
+		rawDate := normalizeQIFDate(rec.fields['D'])
+		if at, err := time.Parse(layout, rawDate); err != nil {
+			row.Errors = append(row.Errors, fmt.Sprintf("cannot parse date %q as %s", rec.fields['D'], layout))
+		} else {
+			row.OccurredAt = &at
+		}
+
+		amount, negative, err := parseAmount(qifAmount(rec), decimalSep)
+		switch {
+		case err != nil:
+			row.Errors = append(row.Errors, err.Error())
+		case isZeroAmount(amount):
+			row.Errors = append(row.Errors, "amount is zero")
+		default:
+			row.Amount = amount
+			row.Kind = "in"
+			if negative {
+				row.Kind = "out"
+			}
+		}
+
+		row.Note = joinNote(rec.fields['P'], rec.fields['M'])
+
+		// "[Nama Akun]" berarti transfer antar akun, bukan kategori
+		if name := rec.fields['L']; name != "" && !strings.HasPrefix(name, "[") {
+			if c := matchQIFCategory(categories, name, row.Kind); c != nil {
+				row.CategoryID = &c.ID
+			} else {
+				row.Warnings = append(row.Warnings, fmt.Sprintf("category %q not found, left uncategorised", name))
+			}
+		}
+
+		key := strings.Join([]string{rec.fields['D'], qifAmount(rec), rec.fields['P'], rec.fields['M'], rec.fields['N']}, "|")
+		sum := sha1.Sum([]byte(key))
+		id := "qif:" + hex.EncodeToString(sum[:8])
+		seen[id]++
+		if n := seen[id]; n > 1 {
+			id = fmt.Sprintf("%s#%d", id, n)
+		}
+		row.ExternalID = id
+
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// readQIF splits the file into records terminated by "^", keeping only records from
+// transaction sections.
+func readQIF(content []byte) ([]qifRecord, error) {
+	text := strings.ReplaceAll(string(stripBOM(content)), "\r\n", "\n")
+	if !strings.HasPrefix(strings.TrimSpace(text), "!") {
+		return nil, fmt.Errorf("%w: not a QIF file", ErrInvalidImport)
+	}
+
+	var records []qifRecord
+	inTransactions := false
+	current := qifRecord{fields: map[byte]string{}}
+	for i, line := range strings.Split(text, "\n") {
+		line = strings.TrimRight(line, " \r")
+		if line == "" {
+			continue
+		}
+		if line[0] == '!' {
+			header := strings.ToLower(line)
+			switch {
+			case strings.HasPrefix(header, "!type:"):
+				inTransactions = qifSections[strings.TrimPrefix(header, "!type:")]
+			case strings.HasPrefix(header, "!option"), strings.HasPrefix(header, "!clear"):
+			default:
+				inTransactions = false
+			}
+			continue
+		}
+		if !inTransactions {
+			continue
+		}
+		if line[0] == '^' {
+			if len(current.fields) > 0 {
+				records = append(records, current)
+			}
+			current = qifRecord{fields: map[byte]string{}}
+			continue
+		}
+		if current.line == 0 {
+			current.line = i + 1
+		}
+		// Baris split (S, E, $) diabaikan; hanya nilai pertama tiap kode yang dipakai
+		if _, ok := current.fields[line[0]]; !ok {
+			current.fields[line[0]] = strings.TrimSpace(line[1:])
+		}
+	}
+	if len(current.fields) > 0 {
+		records = append(records, current)
+	}
+	return records, nil
+}
+
+func qifAmount(rec qifRecord) string {
+	if v := rec.fields['T']; v != "" {
+		return v
+	}
+	return rec.fields['U']
+}
+
+// normalizeQIFDate turns Quicken's 1/ 5'24 style into 1/5/24.
+func normalizeQIFDate(s string) string {
+	s = strings.ReplaceAll(s, "'", "/")
+	return strings.ReplaceAll(s, " ", "")
+}
+
+// matchQIFCategory resolves "Parent:Child" categories, trying the child name first.
+func matchQIFCategory(categories []Category, name, kind string) *Category {
+	parts := strings.Split(name, ":")
+	for i := len(parts) - 1; i >= 0; i-- {
+		if c := matchCategory(categories, parts[i], kind); c != nil {
+			return c
+		}
+	}
+	return nil
+}
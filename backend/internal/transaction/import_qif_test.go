@@ -0,0 +1,92 @@
+package transaction
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestParseQIFFixture(t *testing.T) {
+	content := readFixture(t, "dompet.qif")
+	format := detectQIFDateFormat(content)
+	if format != "1/2/06" {
+		t.Fatalf("detectQIFDateFormat = %q, want 1/2/06", format)
+	}
+	makan := Category{ID: uuid.New(), Name: "Makan", Kind: "out"}
+	gaji := Category{ID: uuid.New(), Name: "Gaji", Kind: "in"}
+	bensin := Category{ID: uuid.New(), Name: "Bensin", Kind: "out"}
+
+	rows, err := parseQIF(content, ImportSettings{DateFormat: format}, []Category{makan, gaji, bensin})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Bagian !Type:Cat dilewati; baris terakhir bertanggal salah
+	if len(rows) != 5 {
+		t.Fatalf("got %d rows, want 5", len(rows))
+	}
+	checkRows(t, rows[:4], []wantRow{
+		{time.Date(2024, 1, 5, 0, 0, 0, 0, time.UTC), "45000.00", "out", "", "Warung Padang - Makan siang"},
+		{time.Date(2024, 1, 25, 0, 0, 0, 0, time.UTC), "7500000.00", "in", "", "PT Maju Jaya - Gaji Januari"},
+		{time.Date(2024, 1, 27, 0, 0, 0, 0, time.UTC), "150000.00", "out", "", "Shell"},
+		{time.Date(2024, 1, 28, 0, 0, 0, 0, time.UTC), "500000.00", "out", "", "Transfer ke tabungan"},
+	})
+
+	wantCategory := []*uuid.UUID{&makan.ID, &gaji.ID, &bensin.ID, nil}
+	for i, want := range wantCategory {
+		got := rows[i].CategoryID
+		if (got == nil) != (want == nil) || (got != nil && *got != *want) {
+			t.Errorf("row %d: category = %v, want %v", i+1, got, want)
+		}
+		if len(rows[i].Warnings) > 0 {
+			t.Errorf("row %d: unexpected warnings %v", i+1, rows[i].Warnings)
+		}
+	}
+
+	bad := rows[4]
+	if len(bad.Errors) != 1 || !strings.Contains(bad.Errors[0], "13/28'24") {
+		t.Errorf("row 5: errors = %v, want one date error", bad.Errors)
+	}
+	if bad.Amount != "10000.00" || bad.Kind != "out" {
+		t.Errorf("row 5: amount/kind = %s %s", bad.Amount, bad.Kind)
+	}
+
+	seen := map[string]bool{}
+	for i, row := range rows {
+		if !strings.HasPrefix(row.ExternalID, "qif:") || seen[row.ExternalID] {
+			t.Errorf("row %d: external_id %q is not a unique qif id", i+1, row.ExternalID)
+		}
+		seen[row.ExternalID] = true
+	}
+
+	again, err := parseQIF(content, ImportSettings{DateFormat: format}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range rows {
+		if again[i].ExternalID != rows[i].ExternalID {
+			t.Errorf("row %d: external_id changed between parses", i+1)
+		}
+	}
+}
+
+func TestParseQIFDuplicateRecords(t *testing.T) {
+	content := []byte("!Type:Bank\nD1/5/2024\nT-10.00\nPKopi\n^\nD1/5/2024\nT-10.00\nPKopi\n^\n")
+	rows, err := parseQIF(content, ImportSettings{}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 2 || rows[0].ExternalID == rows[1].ExternalID {
+		t.Fatalf("identical records got ids %q and %q", rows[0].ExternalID, rows[1].ExternalID)
+	}
+	if rows[1].ExternalID != rows[0].ExternalID+"#2" {
+		t.Errorf("second id = %q, want %q", rows[1].ExternalID, rows[0].ExternalID+"#2")
+	}
+}
+
+func TestParseQIFRejectsOtherFiles(t *testing.T) {
+	if _, err := parseQIF(readFixture(t, "statement_v1.ofx"), ImportSettings{}, nil); err == nil {
+		t.Error("parseQIF accepted an OFX file")
+	}
+}
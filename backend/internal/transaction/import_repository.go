@@ -0,0 +1,188 @@
+package transaction
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/Jomesi149/Implementasi-LASTI/backend/internal/sqlutil"
+)
+
+func (r *SQLRepository) CreateImportBatch(ctx context.Context, b ImportBatch) error {
+	settings, err := json.Marshal(b.Settings)
+	if err != nil {
+		return fmt.Errorf("encode import settings: %w", err)
+	}
+	query := `INSERT INTO finance.import_batches (id, user_id, wallet_id, source, filename, content, settings, status, created_at)
+		VALUES ($1,$2,$3,$4,$5,$6,$7,$8,NOW())`
+	if _, err := r.db.ExecContext(ctx, query, b.ID, b.UserID, b.WalletID, b.Source, b.Filename, b.Content, settings, b.Status); err != nil {
+		return fmt.Errorf("insert import batch: %w", err)
+	}
+	return nil
+}
+
+func (r *SQLRepository) GetImportBatch(ctx context.Context, userID, batchID uuid.UUID) (*ImportBatch, error) {
+	query := `SELECT id, user_id, wallet_id, source, filename, content, settings, status, row_count, created_at, committed_at, undone_at
+		FROM finance.import_batches WHERE id = $1 AND user_id = $2`
+	var b ImportBatch
+	var settings []byte
+	var committedAt, undoneAt sql.NullTime
+	err := r.db.QueryRowContext(ctx, query, batchID, userID).Scan(&b.ID, &b.UserID, &b.WalletID, &b.Source, &b.Filename, &b.Content,
+		&settings, &b.Status, &b.RowCount, &b.CreatedAt, &committedAt, &undoneAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrImportNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("select import batch: %w", err)
+	}
+	if err := json.Unmarshal(settings, &b.Settings); err != nil {
+		return nil, fmt.Errorf("decode import settings: %w", err)
+	}
+	if committedAt.Valid {
+		b.CommittedAt = &committedAt.Time
+	}
+	if undoneAt.Valid {
+		b.UndoneAt = &undoneAt.Time
+	}
+	return &b, nil
+}
+
+func (r *SQLRepository) UpdateImportSettings(ctx context.Context, b ImportBatch) error {
+	settings, err := json.Marshal(b.Settings)
+	if err != nil {
+		return fmt.Errorf("encode import settings: %w", err)
+	}
+	query := `UPDATE finance.import_batches SET settings = $3 WHERE id = $1 AND user_id = $2`
+	res, err := r.db.ExecContext(ctx, query, b.ID, b.UserID, settings)
+	if err != nil {
+		return fmt.Errorf("update import settings: %w", err)
+	}
+	return sqlutil.ExpectAffected(res, ErrImportNotFound)
+}
+
+// CommitImport inserts all rows of a batch and books them on the ledger inside one
+// database transaction.
+func (r *SQLRepository) CommitImport(ctx context.Context, b ImportBatch, txs []Transaction) (err error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	// Status dicek di dalam transaksi supaya commit ganda tidak menggandakan saldo
+	res, err := tx.ExecContext(ctx, `UPDATE finance.import_batches SET status = $3, row_count = $4, committed_at = NOW()
+		WHERE id = $1 AND user_id = $2 AND status = $5`, b.ID, b.UserID, ImportCommitted, len(txs), ImportUploaded)
+	if err != nil {
+		return fmt.Errorf("mark import committed: %w", err)
+	}
+	if err = sqlutil.ExpectAffected(res, ErrImportState); err != nil {
+		return err
+	}
+
+	stmt, err := tx.PrepareContext(ctx, `INSERT INTO finance.transactions (id, user_id, wallet_id, category_id, amount, kind, note, occurred_at, import_batch_id, external_id, planned, auto_post, currency, created_at)
+		VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,(SELECT currency FROM finance.wallets WHERE id = $3),NOW())`)
+	if err != nil {
+		return fmt.Errorf("prepare insert: %w", err)
+	}
+	defer stmt.Close()
+
+	now := time.Now()
+	for _, t := range txs {
+		// Baris bertanggal setelah hari ini masuk sebagai transaksi terencana
+		schedule(&t, now)
+		if _, err = stmt.ExecContext(ctx, t.ID, t.UserID, t.WalletID, t.CategoryID, t.Amount, t.Kind, t.Note, t.OccurredAt, t.ImportBatchID, t.ExternalID, t.Planned, t.AutoPost); err != nil {
+			return fmt.Errorf("insert transaction: %w", err)
+		}
+	}
+
+	ids := make([]uuid.UUID, len(txs))
+	for i, t := range txs {
+		ids[i] = t.ID
+	}
+	if err = recordChanges(ctx, tx, ids, nil); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("commit tx: %w", err)
+	}
+	return nil
+}
+
+// UndoImport reverses the wallet balance change of a committed batch and deletes its
+// transactions, inside one database transaction.
+func (r *SQLRepository) UndoImport(ctx context.Context, userID, batchID uuid.UUID) (err error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	res, err := tx.ExecContext(ctx, `UPDATE finance.import_batches SET status = $3, undone_at = NOW()
+		WHERE id = $1 AND user_id = $2 AND status = $4`, batchID, userID, ImportUndone, ImportCommitted)
+	if err != nil {
+		return fmt.Errorf("mark import undone: %w", err)
+	}
+	if err = sqlutil.ExpectAffected(res, ErrImportState); err != nil {
+		return err
+	}
+
+	ids, err := selectTransactionIDs(ctx, tx, `SELECT id FROM finance.transactions WHERE import_batch_id = $1 AND user_id = $2`, batchID, userID)
+	if err != nil {
+		return err
+	}
+	before, err := snapshotHistory(ctx, tx, ids)
+	if err != nil {
+		return err
+	}
+
+	if _, err = tx.ExecContext(ctx, `DELETE FROM finance.transactions WHERE import_batch_id = $1 AND user_id = $2`, batchID, userID); err != nil {
+		return fmt.Errorf("delete imported transactions: %w", err)
+	}
+	if err = recordChanges(ctx, tx, ids, before); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("commit tx: %w", err)
+	}
+	return nil
+}
+
+// ExistingExternalIDs reports which of ids are already stored on walletID. Transactions
+// in the trash count too because their external ids stay reserved.
+func (r *SQLRepository) ExistingExternalIDs(ctx context.Context, walletID uuid.UUID, ids []string) (map[string]bool, error) {
+	out := map[string]bool{}
+	if len(ids) == 0 {
+		return out, nil
+	}
+	rows, err := r.db.QueryContext(ctx, `SELECT external_id FROM finance.transactions WHERE wallet_id = $1 AND external_id = ANY($2::text[])`, walletID, ids)
+	if err != nil {
+		return nil, fmt.Errorf("select external ids: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		out[id] = true
+	}
+	return out, rows.Err()
+}
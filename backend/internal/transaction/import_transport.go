@@ -0,0 +1,159 @@
+package transaction
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"github.com/Jomesi149/Implementasi-LASTI/backend/pkg/response"
+)
+
+func (h *HTTPHandler) registerImportRoutes(r chi.Router) {
+	r.Route("/imports", func(r chi.Router) {
+		r.Post("/", h.handleUploadImport)
+		r.Get("/{id}", h.handleGetImport)
+		r.Post("/{id}/preview", h.handlePreviewImport)
+		r.Post("/{id}/commit", h.handleCommitImport)
+		r.Post("/{id}/undo", h.handleUndoImport)
+	})
+}
+
+// handleUploadImport accepts a multipart form with "file", "wallet_id" and an optional
+// "format"; without format the file extension decides.
+func (h *HTTPHandler) handleUploadImport(w http.ResponseWriter, r *http.Request) {
+	uid, err := getUserIDFromHeader(r)
+	if err != nil {
+		response.Error(w, http.StatusUnauthorized, "missing X-User-ID header")
+		return
+	}
+	r.Body = http.MaxBytesReader(w, r.Body, MaxImportSize+1<<20)
+	if err := r.ParseMultipartForm(MaxImportSize); err != nil {
+		response.Error(w, http.StatusBadRequest, "invalid multipart payload")
+		return
+	}
+	wid, err := uuid.Parse(r.FormValue("wallet_id"))
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "invalid wallet id")
+		return
+	}
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "file is required")
+		return
+	}
+	defer file.Close()
+
+	content, err := io.ReadAll(io.LimitReader(file, MaxImportSize+1))
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "cannot read file")
+		return
+	}
+	if len(content) > MaxImportSize {
+		response.Error(w, http.StatusRequestEntityTooLarge, "file is too large")
+		return
+	}
+
+	source := importSource(header.Filename, r.FormValue("format"))
+	preview, err := h.service.UploadImport(r.Context(), uid, wid, source, header.Filename, content)
+	if err != nil {
+		response.Error(w, errorStatus(err), err.Error())
+		return
+	}
+	response.JSON(w, http.StatusCreated, preview)
+}
+
+func (h *HTTPHandler) handleGetImport(w http.ResponseWriter, r *http.Request) {
+	uid, bid, ok := importParams(w, r)
+	if !ok {
+		return
+	}
+	preview, err := h.service.GetImport(r.Context(), uid, bid)
+	if err != nil {
+		response.Error(w, errorStatus(err), err.Error())
+		return
+	}
+	response.JSON(w, http.StatusOK, preview)
+}
+
+func (h *HTTPHandler) handlePreviewImport(w http.ResponseWriter, r *http.Request) {
+	uid, bid, ok := importParams(w, r)
+	if !ok {
+		return
+	}
+	var req ImportSettings
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.Error(w, http.StatusBadRequest, "invalid payload")
+		return
+	}
+	preview, err := h.service.PreviewImport(r.Context(), uid, bid, req)
+	if err != nil {
+		response.Error(w, errorStatus(err), err.Error())
+		return
+	}
+	response.JSON(w, http.StatusOK, preview)
+}
+
+func (h *HTTPHandler) handleCommitImport(w http.ResponseWriter, r *http.Request) {
+	uid, bid, ok := importParams(w, r)
+	if !ok {
+		return
+	}
+	batch, err := h.service.CommitImport(r.Context(), uid, bid)
+	if err != nil {
+		response.Error(w, errorStatus(err), err.Error())
+		return
+	}
+	response.JSON(w, http.StatusOK, batch)
+}
+
+func (h *HTTPHandler) handleUndoImport(w http.ResponseWriter, r *http.Request) {
+	uid, bid, ok := importParams(w, r)
+	if !ok {
+		return
+	}
+	batch, err := h.service.UndoImport(r.Context(), uid, bid)
+	if err != nil {
+		response.Error(w, errorStatus(err), err.Error())
+		return
+	}
+	response.JSON(w, http.StatusOK, batch)
+}
+
+// importParams reads the caller and the batch id, writing the error response itself.
+func importParams(w http.ResponseWriter, r *http.Request) (uuid.UUID, uuid.UUID, bool) {
+	uid, err := getUserIDFromHeader(r)
+	if err != nil {
+		response.Error(w, http.StatusUnauthorized, "missing X-User-ID header")
+		return uuid.Nil, uuid.Nil, false
+	}
+	bid, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "invalid import id")
+		return uuid.Nil, uuid.Nil, false
+	}
+	return uid, bid, true
+}
+
+// importSource resolves the import parser from an explicit format or the file extension.
+func importSource(filename, format string) string {
+	if format != "" {
+		if strings.EqualFold(format, "qfx") {
+			return "ofx"
+		}
+		return strings.ToLower(format)
+	}
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".csv", ".txt":
+		return "csv"
+	case ".ofx", ".qfx":
+		return "ofx"
+	case ".qif":
+		return "qif"
+	}
+	return strings.TrimPrefix(strings.ToLower(filepath.Ext(filename)), ".")
+}
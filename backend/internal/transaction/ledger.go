@@ -0,0 +1,183 @@
+package transaction
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/Jomesi149/Implementasi-LASTI/backend/internal/currency"
+)
+
+// Ledger account types. There is one wallet account per wallet and one income, expense
+// and equity account per user.
+const (
+	AccountWallet  = "wallet"
+	AccountIncome  = "income"
+	AccountExpense = "expense"
+	AccountEquity  = "equity"
+	// AccountTransfer is the counter-account of both legs of a transfer between
+	// wallets, one per user and currency.
+	AccountTransfer = "transfer"
+	// AccountDebt is the counter-account of money lent, borrowed and repaid; its
+	// balance is what the user is owed net of what they owe.
+	AccountDebt = "debt"
+)
+
+// ReasonOpening marks the entry that books a wallet's initial balance against equity.
+// Entries for transactions use the history action as their reason.
+const ReasonOpening = "opening"
+
+// posting moves cents into (positive, debit) or out of (negative, credit) an account.
+// WalletID is set for wallet accounts only.
+type posting struct {
+	AccountType string
+	WalletID    *uuid.UUID
+	Cents       int64
+}
+
+// ledgerEntry is one balanced journal entry in a single currency; its postings sum to
+// zero.
+type ledgerEntry struct {
+	ID            uuid.UUID
+	UserID        uuid.UUID
+	TransactionID *uuid.UUID
+	Reason        string
+	Currency      string
+	Postings      []posting
+}
+
+// WalletLedgerCheck compares a wallet's cached balance with the sum of its postings.
+type WalletLedgerCheck struct {
+	WalletID   uuid.UUID `json:"wallet_id"`
+	Name       string    `json:"name"`
+	Deleted    bool      `json:"deleted"`
+	Cached     string    `json:"cached_balance"`
+	Posted     string    `json:"posted_balance"`
+	Difference string    `json:"difference"`
+}
+
+// LedgerReport is the result of a consistency check. Consistent is true when every
+// wallet balance equals the sum of its postings, every entry balances and every
+// transaction's postings add up to its current effect.
+type LedgerReport struct {
+	Consistent             bool                `json:"consistent"`
+	Wallets                []WalletLedgerCheck `json:"wallets"`
+	UnbalancedEntries      int                 `json:"unbalanced_entries"`
+	MismatchedTransactions int                 `json:"mismatched_transactions"`
+	Repaired               int                 `json:"repaired,omitempty"`
+	CheckedAt              time.Time           `json:"checked_at"`
+}
+
+// transactionPostings books the move from the old to the new snapshot of a transaction:
+// the old balance effect is reversed and the new one posted, netted per account. A
+// change that leaves the effect alone, like a new category, needs no postings.
+func transactionPostings(old, new *historySnapshot) []posting {
+	type account struct {
+		accountType string
+		walletID    uuid.UUID
+	}
+	net := map[account]int64{}
+	var order []account
+	add := func(a account, cents int64) {
+		if _, ok := net[a]; !ok {
+			order = append(order, a)
+		}
+		net[a] += cents
+	}
+	book := func(s *historySnapshot, sign int64) {
+		walletID, cents := balanceEffect(s)
+		if cents == 0 {
+			return
+		}
+		nominal := AccountExpense
+		switch {
+		case s.TransferID != nil:
+			nominal = AccountTransfer
+		case s.DebtID != nil:
+			nominal = AccountDebt
+		case s.Kind == "in":
+			nominal = AccountIncome
+		}
+		add(account{AccountWallet, walletID}, sign*cents)
+		add(account{accountType: nominal}, -sign*cents)
+	}
+	book(old, -1)
+	book(new, 1)
+
+	var out []posting
+	for _, a := range order {
+		if net[a] == 0 {
+			continue
+		}
+		p := posting{AccountType: a.accountType, Cents: net[a]}
+		if a.accountType == AccountWallet {
+			id := a.walletID
+			p.WalletID = &id
+		}
+		out = append(out, p)
+	}
+	return out
+}
+
+// transactionEntries books a transaction going from the old to the new snapshot. A
+// move to a wallet in another currency takes two entries, since one entry never mixes
+// currencies.
+func transactionEntries(transactionID, userID uuid.UUID, action string, old, new *historySnapshot) []ledgerEntry {
+	entry := func(old, new *historySnapshot) ledgerEntry {
+		id := transactionID
+		code := currency.DefaultCurrency
+		if new != nil {
+			code = new.currency()
+		} else if old != nil {
+			code = old.currency()
+		}
+		return ledgerEntry{
+			ID:            uuid.New(),
+			UserID:        userID,
+			TransactionID: &id,
+			Reason:        action,
+			Currency:      code,
+			Postings:      transactionPostings(old, new),
+		}
+	}
+	if old != nil && new != nil && old.currency() != new.currency() {
+		return []ledgerEntry{entry(old, nil), entry(nil, new)}
+	}
+	return []ledgerEntry{entry(old, new)}
+}
+
+// openingEntry books the initial balance of a wallet against equity.
+func openingEntry(w Wallet, cents int64) ledgerEntry {
+	walletID := w.ID
+	return ledgerEntry{
+		ID:       uuid.New(),
+		UserID:   w.UserID,
+		Reason:   ReasonOpening,
+		Currency: w.Currency,
+		Postings: []posting{
+			{AccountType: AccountWallet, WalletID: &walletID, Cents: cents},
+			{AccountType: AccountEquity, Cents: -cents},
+		},
+	}
+}
+
+// CheckLedger proves the user's wallet balances against the ledger.
+func (s *Service) CheckLedger(ctx context.Context, userID uuid.UUID) (*LedgerReport, error) {
+	return s.repo.CheckLedger(ctx, userID)
+}
+
+// RebuildBalances resets every cached wallet balance of the user that drifted from its
+// postings and returns the check afterwards. The ledger itself is never changed.
+func (s *Service) RebuildBalances(ctx context.Context, userID uuid.UUID) (*LedgerReport, error) {
+	repaired, err := s.repo.RebuildBalances(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	report, err := s.repo.CheckLedger(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	report.Repaired = repaired
+	return report, nil
+}
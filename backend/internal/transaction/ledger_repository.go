@@ -0,0 +1,136 @@
+package transaction
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/Jomesi149/Implementasi-LASTI/backend/internal/sqlutil"
+)
+
+// postEntries writes balanced entries and then moves the cached balance of each wallet
+// they post to with a single UPDATE, however many entries touch it. It must run inside
+// the database transaction that makes the change. It returns the net change of each
+// wallet in cents.
+func postEntries(ctx context.Context, tx *sql.Tx, entries []ledgerEntry) (map[uuid.UUID]int64, error) {
+	deltas := map[uuid.UUID]int64{}
+	for _, e := range entries {
+		if len(e.Postings) == 0 {
+			continue
+		}
+		if _, err := tx.ExecContext(ctx, `INSERT INTO finance.ledger_entries (id, user_id, transaction_id, reason, created_at) VALUES ($1,$2,$3,$4,NOW())`,
+			e.ID, e.UserID, e.TransactionID, e.Reason); err != nil {
+			return nil, fmt.Errorf("insert ledger entry: %w", err)
+		}
+		for _, p := range e.Postings {
+			if _, err := tx.ExecContext(ctx, `INSERT INTO finance.ledger_postings (entry_id, user_id, account_type, wallet_id, amount, currency) VALUES ($1,$2,$3,$4,$5::NUMERIC,$6)`,
+				e.ID, e.UserID, p.AccountType, p.WalletID, sqlutil.CentsString(p.Cents), e.Currency); err != nil {
+				return nil, fmt.Errorf("insert ledger posting: %w", err)
+			}
+			if p.WalletID != nil {
+				deltas[*p.WalletID] += p.Cents
+			}
+		}
+	}
+
+	// Urutan tetap supaya dua transaksi database tidak saling mengunci dompet
+	walletIDs := make([]uuid.UUID, 0, len(deltas))
+	for id, cents := range deltas {
+		if cents != 0 {
+			walletIDs = append(walletIDs, id)
+		}
+	}
+	sort.Slice(walletIDs, func(i, j int) bool { return walletIDs[i].String() < walletIDs[j].String() })
+	for _, id := range walletIDs {
+		if _, err := tx.ExecContext(ctx, `UPDATE finance.wallets SET balance = balance + $1::NUMERIC, updated_at = NOW() WHERE id = $2`,
+			sqlutil.CentsString(deltas[id]), id); err != nil {
+			return nil, fmt.Errorf("update wallet: %w", err)
+		}
+	}
+	return deltas, nil
+}
+
+// CheckLedger compares every wallet of the user, including those in the trash, with its
+// postings and counts entries and transactions that do not add up.
+func (r *SQLRepository) CheckLedger(ctx context.Context, userID uuid.UUID) (*LedgerReport, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT w.id, w.name, w.deleted_at IS NOT NULL, w.balance::TEXT,
+			COALESCE((SELECT SUM(p.amount) FROM finance.ledger_postings p WHERE p.wallet_id = w.id), 0)::TEXT
+		FROM finance.wallets w
+		WHERE w.user_id = $1
+		ORDER BY w.name ASC, w.id ASC`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("check wallets: %w", err)
+	}
+	defer rows.Close()
+
+	report := &LedgerReport{Consistent: true, Wallets: []WalletLedgerCheck{}, CheckedAt: time.Now()}
+	for rows.Next() {
+		var c WalletLedgerCheck
+		if err := rows.Scan(&c.WalletID, &c.Name, &c.Deleted, &c.Cached, &c.Posted); err != nil {
+			return nil, err
+		}
+		cached, _ := sqlutil.ToCents(c.Cached)
+		posted, _ := sqlutil.ToCents(c.Posted)
+		c.Difference = sqlutil.CentsString(cached - posted)
+		if cached != posted {
+			report.Consistent = false
+		}
+		report.Wallets = append(report.Wallets, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	err = r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM (
+			SELECT entry_id FROM finance.ledger_postings WHERE user_id = $1 GROUP BY entry_id HAVING SUM(amount) <> 0
+		) unbalanced`, userID).Scan(&report.UnbalancedEntries)
+	if err != nil {
+		return nil, fmt.Errorf("check entries: %w", err)
+	}
+
+	// Efek transaksi saat ini harus sama dengan jumlah posting dompet dari semua jurnalnya
+	err = r.db.QueryRowContext(ctx, `SELECT COUNT(*)
+		FROM finance.transactions t
+		LEFT JOIN (
+			SELECT e.transaction_id, SUM(p.amount) AS posted
+			FROM finance.ledger_entries e
+			JOIN finance.ledger_postings p ON p.entry_id = e.id AND p.account_type = 'wallet'
+			WHERE e.user_id = $1 AND e.transaction_id IS NOT NULL
+			GROUP BY e.transaction_id
+		) l ON l.transaction_id = t.id
+		WHERE t.user_id = $1 AND COALESCE(l.posted, 0) <> CASE
+			WHEN t.planned OR (t.deleted_at IS NOT NULL AND NOT t.deleted_with_wallet) THEN 0
+			WHEN t.kind = 'in' THEN t.amount
+			ELSE -t.amount END`, userID).Scan(&report.MismatchedTransactions)
+	if err != nil {
+		return nil, fmt.Errorf("check transactions: %w", err)
+	}
+
+	if report.UnbalancedEntries > 0 || report.MismatchedTransactions > 0 {
+		report.Consistent = false
+	}
+	return report, nil
+}
+
+// RebuildBalances sets each drifted wallet balance of the user to the sum of its
+// postings and returns how many wallets changed.
+func (r *SQLRepository) RebuildBalances(ctx context.Context, userID uuid.UUID) (int, error) {
+	res, err := r.db.ExecContext(ctx, `UPDATE finance.wallets w SET balance = l.posted, updated_at = NOW()
+		FROM (
+			SELECT w2.id, COALESCE((SELECT SUM(p.amount) FROM finance.ledger_postings p WHERE p.wallet_id = w2.id), 0) AS posted
+			FROM finance.wallets w2 WHERE w2.user_id = $1
+		) l
+		WHERE w.id = l.id AND w.balance <> l.posted`, userID)
+	if err != nil {
+		return 0, fmt.Errorf("rebuild balances: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	return int(n), nil
+}
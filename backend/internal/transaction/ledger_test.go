@@ -0,0 +1,215 @@
+package transaction
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestTransactionEntriesBalance(t *testing.T) {
+	walletA, walletB, walletUSD := uuid.New(), uuid.New(), uuid.New()
+	transferID, debtID := uuid.New(), uuid.New()
+	snap := func(walletID uuid.UUID, amount, kind, code string) *historySnapshot {
+		return &historySnapshot{WalletID: walletID, Amount: amount, Kind: kind, Currency: code}
+	}
+	with := func(s *historySnapshot, edit func(*historySnapshot)) *historySnapshot {
+		c := *s
+		edit(&c)
+		return &c
+	}
+	expense := snap(walletA, "50000.00", "out", "IDR")
+	transferOut := with(snap(walletA, "100000.00", "out", "IDR"), func(s *historySnapshot) { s.TransferID = &transferID })
+	transferIn := with(snap(walletB, "100000.00", "in", "IDR"), func(s *historySnapshot) { s.TransferID = &transferID })
+	transferUSD := with(snap(walletUSD, "6.25", "in", "USD"), func(s *historySnapshot) { s.TransferID = &transferID })
+
+	type change struct{ old, new *historySnapshot }
+	tests := []struct {
+		name        string
+		action      string
+		changes     []change
+		wantEntries int
+		// Saldo akhir per dompet dan per akun nominal, dikunci dengan mata uangnya
+		wantWallets  map[uuid.UUID]int64
+		wantNominals map[string]int64
+	}{
+		{
+			name: "create", action: HistoryCreate,
+			changes:      []change{{nil, expense}},
+			wantEntries:  1,
+			wantWallets:  map[uuid.UUID]int64{walletA: -5000000},
+			wantNominals: map[string]int64{"expense/IDR": 5000000},
+		},
+		{
+			name: "update amount", action: HistoryUpdate,
+			changes:      []change{{expense, with(expense, func(s *historySnapshot) { s.Amount = "65000.00" })}},
+			wantEntries:  1,
+			wantWallets:  map[uuid.UUID]int64{walletA: -1500000},
+			wantNominals: map[string]int64{"expense/IDR": 1500000},
+		},
+		{
+			name: "update wallet", action: HistoryUpdate,
+			changes:      []change{{expense, with(expense, func(s *historySnapshot) { s.WalletID = walletB })}},
+			wantEntries:  1,
+			wantWallets:  map[uuid.UUID]int64{walletA: 5000000, walletB: -5000000},
+			wantNominals: map[string]int64{},
+		},
+		{
+			name: "update kind", action: HistoryUpdate,
+			changes:      []change{{expense, with(expense, func(s *historySnapshot) { s.Kind = "in" })}},
+			wantEntries:  1,
+			wantWallets:  map[uuid.UUID]int64{walletA: 10000000},
+			wantNominals: map[string]int64{"expense/IDR": -5000000, "income/IDR": -5000000},
+		},
+		{
+			name: "update wallet to another currency", action: HistoryUpdate,
+			changes: []change{{expense, with(expense, func(s *historySnapshot) {
+				s.WalletID, s.Amount, s.Currency = walletUSD, "3.13", "USD"
+			})}},
+			wantEntries:  2,
+			wantWallets:  map[uuid.UUID]int64{walletA: 5000000, walletUSD: -313},
+			wantNominals: map[string]int64{"expense/IDR": -5000000, "expense/USD": 313},
+		},
+		{
+			name: "update category only", action: HistoryUpdate,
+			changes:      []change{{expense, with(expense, func(*historySnapshot) {})}},
+			wantEntries:  1,
+			wantWallets:  map[uuid.UUID]int64{},
+			wantNominals: map[string]int64{},
+		},
+		{
+			name: "delete", action: HistoryDelete,
+			changes:      []change{{expense, with(expense, func(s *historySnapshot) { s.Deleted = true })}},
+			wantEntries:  1,
+			wantWallets:  map[uuid.UUID]int64{walletA: 5000000},
+			wantNominals: map[string]int64{"expense/IDR": -5000000},
+		},
+		{
+			name: "delete with wallet", action: HistoryDelete,
+			changes: []change{{expense, with(expense, func(s *historySnapshot) {
+				s.Deleted, s.DeletedWithWallet = true, true
+			})}},
+			wantEntries:  1,
+			wantWallets:  map[uuid.UUID]int64{},
+			wantNominals: map[string]int64{},
+		},
+		{
+			name: "restore", action: HistoryRestore,
+			changes:      []change{{with(expense, func(s *historySnapshot) { s.Deleted = true }), expense}},
+			wantEntries:  1,
+			wantWallets:  map[uuid.UUID]int64{walletA: -5000000},
+			wantNominals: map[string]int64{"expense/IDR": 5000000},
+		},
+		{
+			// Kedua kaki transfer saling meniadakan di akun transfer
+			name: "transfer pair", action: HistoryCreate,
+			changes:      []change{{nil, transferOut}, {nil, transferIn}},
+			wantEntries:  2,
+			wantWallets:  map[uuid.UUID]int64{walletA: -10000000, walletB: 10000000},
+			wantNominals: map[string]int64{"transfer/IDR": 0},
+		},
+		{
+			name: "cross-currency transfer", action: HistoryCreate,
+			changes:      []change{{nil, transferOut}, {nil, transferUSD}},
+			wantEntries:  2,
+			wantWallets:  map[uuid.UUID]int64{walletA: -10000000, walletUSD: 625},
+			wantNominals: map[string]int64{"transfer/IDR": 10000000, "transfer/USD": -625},
+		},
+		{
+			name: "debt leg", action: HistoryCreate,
+			changes: []change{{nil, with(snap(walletA, "250000.00", "out", "IDR"), func(s *historySnapshot) {
+				s.DebtID = &debtID
+			})}},
+			wantEntries:  1,
+			wantWallets:  map[uuid.UUID]int64{walletA: -25000000},
+			wantNominals: map[string]int64{"debt/IDR": 25000000},
+		},
+		{
+			name: "planned create", action: HistoryCreate,
+			changes:      []change{{nil, with(expense, func(s *historySnapshot) { s.Planned = true })}},
+			wantEntries:  1,
+			wantWallets:  map[uuid.UUID]int64{},
+			wantNominals: map[string]int64{},
+		},
+		{
+			name: "planned to posted", action: HistoryUpdate,
+			changes:      []change{{with(expense, func(s *historySnapshot) { s.Planned = true }), expense}},
+			wantEntries:  1,
+			wantWallets:  map[uuid.UUID]int64{walletA: -5000000},
+			wantNominals: map[string]int64{"expense/IDR": 5000000},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var entries []ledgerEntry
+			for _, c := range tt.changes {
+				entries = append(entries, transactionEntries(uuid.New(), uuid.New(), tt.action, c.old, c.new)...)
+			}
+			if len(entries) != tt.wantEntries {
+				t.Fatalf("got %d entries, want %d", len(entries), tt.wantEntries)
+			}
+			wallets, nominals := map[uuid.UUID]int64{}, map[string]int64{}
+			for _, e := range entries {
+				checkBalanced(t, e)
+				if e.Reason != tt.action {
+					t.Errorf("reason = %q, want %q", e.Reason, tt.action)
+				}
+				for _, p := range e.Postings {
+					if p.AccountType == AccountWallet {
+						wallets[*p.WalletID] += p.Cents
+					} else {
+						nominals[p.AccountType+"/"+e.Currency] += p.Cents
+					}
+				}
+			}
+			for id, cents := range wallets {
+				if cents == 0 {
+					delete(wallets, id)
+				}
+			}
+			if !reflect.DeepEqual(wallets, tt.wantWallets) {
+				t.Errorf("wallets = %v, want %v", wallets, tt.wantWallets)
+			}
+			for k, cents := range nominals {
+				if want, ok := tt.wantNominals[k]; !ok || cents != want {
+					t.Errorf("%s = %d, want %d", k, cents, want)
+				}
+			}
+			for k, want := range tt.wantNominals {
+				if _, ok := nominals[k]; !ok && want != 0 {
+					t.Errorf("%s missing, want %d", k, want)
+				}
+			}
+		})
+	}
+}
+
+func TestOpeningEntryBalances(t *testing.T) {
+	w := Wallet{ID: uuid.New(), UserID: uuid.New(), Currency: "IDR"}
+	for _, cents := range []int64{1000000, 0, -250000} {
+		e := openingEntry(w, cents)
+		checkBalanced(t, e)
+		if e.Reason != ReasonOpening || e.Currency != "IDR" || e.TransactionID != nil {
+			t.Errorf("opening entry = %+v", e)
+		}
+		if got := e.Postings[0]; got.AccountType != AccountWallet || *got.WalletID != w.ID || got.Cents != cents {
+			t.Errorf("wallet posting = %+v, want %d on %s", got, cents, w.ID)
+		}
+	}
+}
+
+// checkBalanced fails t unless the postings of e sum to zero and only wallet accounts
+// carry a wallet id.
+func checkBalanced(t *testing.T, e ledgerEntry) {
+	t.Helper()
+	var sum int64
+	for _, p := range e.Postings {
+		sum += p.Cents
+		if (p.AccountType == AccountWallet) != (p.WalletID != nil) {
+			t.Errorf("posting %+v: wallet id on the wrong account", p)
+		}
+	}
+	if sum != 0 {
+		t.Errorf("entry %s in %s sums to %d: %+v", e.Reason, e.Currency, sum, e.Postings)
+	}
+}
@@ -0,0 +1,44 @@
+package transaction
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/Jomesi149/Implementasi-LASTI/backend/pkg/response"
+)
+
+func (h *HTTPHandler) registerLedgerRoutes(r chi.Router) {
+	r.Route("/ledger", func(r chi.Router) {
+		r.Get("/check", h.handleCheckLedger)
+		r.Post("/rebuild-balances", h.handleRebuildBalances)
+	})
+}
+
+func (h *HTTPHandler) handleCheckLedger(w http.ResponseWriter, r *http.Request) {
+	uid, err := getUserIDFromHeader(r)
+	if err != nil {
+		response.Error(w, http.StatusUnauthorized, "missing X-User-ID header")
+		return
+	}
+	report, err := h.service.CheckLedger(r.Context(), uid)
+	if err != nil {
+		response.Error(w, errorStatus(err), err.Error())
+		return
+	}
+	response.JSON(w, http.StatusOK, report)
+}
+
+func (h *HTTPHandler) handleRebuildBalances(w http.ResponseWriter, r *http.Request) {
+	uid, err := getUserIDFromHeader(r)
+	if err != nil {
+		response.Error(w, http.StatusUnauthorized, "missing X-User-ID header")
+		return
+	}
+	report, err := h.service.RebuildBalances(r.Context(), uid)
+	if err != nil {
+		response.Error(w, errorStatus(err), err.Error())
+		return
+	}
+	response.JSON(w, http.StatusOK, report)
+}
@@ -6,31 +6,128 @@ import (
 	"github.com/google/uuid"
 )
 
+// Wallet holds money in one ISO 4217 currency, fixed when the wallet is created.
 type Wallet struct {
 	ID        uuid.UUID `json:"id"`
 	UserID    uuid.UUID `json:"user_id"`
 	Type      string    `json:"type"`
 	Name      string    `json:"name"`
 	Balance   string    `json:"balance"`
+	Currency  string    `json:"currency"`
 	CreatedAt time.Time `json:"created_at"`
 }
 
 type Category struct {
-	ID        uuid.UUID `json:"id"`
-	UserID    uuid.UUID `json:"user_id"`
-	Name      string    `json:"name"`
-	Kind      string    `json:"kind"`
-	CreatedAt time.Time `json:"created_at"`
+	ID         uuid.UUID  `json:"id"`
+	UserID     uuid.UUID  `json:"user_id"`
+	ParentID   *uuid.UUID `json:"parent_id,omitempty"`
+	Name       string     `json:"name"`
+	Kind       string     `json:"kind"`
+	ArchivedAt *time.Time `json:"archived_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
 }
 
 type Transaction struct {
+	ID            uuid.UUID   `json:"id"`
+	UserID        uuid.UUID   `json:"user_id"`
+	WalletID      uuid.UUID   `json:"wallet_id"`
+	CategoryID    *uuid.UUID  `json:"category_id,omitempty"`
+	Amount        string      `json:"amount"`
+	Kind          string      `json:"kind"`
+	Note          *string     `json:"note,omitempty"`
+	OccurredAt    time.Time   `json:"occurred_at"`
+	ImportBatchID *uuid.UUID  `json:"import_batch_id,omitempty"`
+	ExternalID    *string     `json:"external_id,omitempty"`
+	PayeeID       *uuid.UUID  `json:"payee_id,omitempty"`
+	TagIDs        []uuid.UUID `json:"tag_ids,omitempty"`
+	Splits        []Split     `json:"splits,omitempty"`
+	CreatedAt     time.Time   `json:"created_at"`
+	// ClearedAt is set once the transaction was seen on a bank statement;
+	// ReconciliationID once a completed reconciliation covered it.
+	ClearedAt        *time.Time `json:"cleared_at,omitempty"`
+	ReconciliationID *uuid.UUID `json:"reconciliation_id,omitempty"`
+	// Currency is always the currency of the wallet. TransferID links the two legs of a
+	// transfer between wallets; DebtID marks money lent, borrowed or repaid.
+	Currency   string     `json:"currency"`
+	TransferID *uuid.UUID `json:"transfer_id,omitempty"`
+	DebtID     *uuid.UUID `json:"debt_id,omitempty"`
+	// CreatedBy is the member who recorded the transaction; it differs from UserID when a
+	// household member or a wallet it was shared with added it.
+	CreatedBy uuid.UUID `json:"created_by"`
+	// Planned is set for a transaction dated after the day it was recorded; it does not
+	// change the wallet balance until it is posted. AutoPost posts it on its date,
+	// otherwise the user is asked to confirm it.
+	Planned  bool `json:"planned"`
+	AutoPost bool `json:"auto_post"`
+}
+
+// Split is one category line of a split transaction. The lines of a transaction sum to
+// its amount; the transaction itself then has no category.
+type Split struct {
 	ID         uuid.UUID  `json:"id"`
-	UserID     uuid.UUID  `json:"user_id"`
-	WalletID   uuid.UUID  `json:"wallet_id"`
 	CategoryID *uuid.UUID `json:"category_id,omitempty"`
 	Amount     string     `json:"amount"`
-	Kind       string     `json:"kind"`
 	Note       *string    `json:"note,omitempty"`
-	OccurredAt time.Time  `json:"occurred_at"`
-	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// NewTransaction is the input for Service.CreateTransaction. ID is optional; callers that
+// must never create the same transaction twice pass a deterministic one.
+type NewTransaction struct {
+	ID         uuid.UUID
+	UserID     uuid.UUID
+	WalletID   uuid.UUID
+	CategoryID *uuid.UUID
+	Amount     string
+	Kind       string
+	Note       *string
+	OccurredAt time.Time
+	Splits     []Split
+	PayeeID    *uuid.UUID
+	TagIDs     []uuid.UUID
+	// AutoPost decides whether a planned transaction is posted on its date or waits to be
+	// confirmed; nil posts it.
+	AutoPost *bool
+}
+
+// TransactionFilter narrows ListTransactions; zero values mean "no filter".
+type TransactionFilter struct {
+	From        *time.Time
+	To          *time.Time
+	WalletIDs   []uuid.UUID
+	CategoryIDs []uuid.UUID
+	TagIDs      []uuid.UUID
+	PayeeIDs    []uuid.UUID
+	Kind        string
+	Status      string
+	MinAmount   *float64
+	MaxAmount   *float64
+	Query       string
+	Ascending   bool
+	Cursor      string
+	Limit       int
+}
+
+// TransactionPage is one keyset page of transactions plus the cursor for the next page.
+type TransactionPage struct {
+	Items      []Transaction `json:"items"`
+	NextCursor string        `json:"next_cursor,omitempty"`
+}
+
+// Label is a tag or a payee. UsageCount is the number of transactions using it and is
+// only filled by list and autocomplete queries.
+type Label struct {
+	ID         uuid.UUID `json:"id"`
+	UserID     uuid.UUID `json:"user_id"`
+	Name       string    `json:"name"`
+	UsageCount int       `json:"usage_count"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// ExportRow is a transaction with its wallet and category names resolved for export.
+type ExportRow struct {
+	Transaction
+	WalletName     string
+	WalletBalance  string
+	CategoryName   string
+	ParentCategory string
 }
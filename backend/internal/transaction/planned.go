@@ -0,0 +1,284 @@
+package transaction
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/Jomesi149/Implementasi-LASTI/backend/internal/notification"
+	"github.com/Jomesi149/Implementasi-LASTI/backend/internal/sqlutil"
+)
+
+// Transaction statuses, as used by the status filter.
+const (
+	StatusPlanned = "planned"
+	StatusPosted  = "posted"
+)
+
+// SourcePlanned marks history rows written when a planned transaction is auto-posted.
+const SourcePlanned = "planned"
+
+// NotificationPlannedDue is the notification kind asking to post a planned transaction.
+const NotificationPlannedDue = "planned_due"
+
+// Projection horizons, in days from today.
+const (
+	DefaultProjectionDays = 30
+	MaxProjectionDays     = 366
+)
+
+var (
+	// ErrNotPlanned is returned when posting a transaction that is already posted.
+	ErrNotPlanned = errors.New("transaction_not_planned")
+	// ErrInvalidProjection indicates a projection end date in the past or too far ahead.
+	ErrInvalidProjection = errors.New("invalid_projection")
+)
+
+// ProjectedItem is a future movement of a wallet balance: a planned transaction or an
+// occurrence of something scheduled, such as a recurring rule.
+type ProjectedItem struct {
+	Date        time.Time `json:"date"`
+	Source      string    `json:"source"`
+	ReferenceID uuid.UUID `json:"reference_id"`
+	Kind        string    `json:"kind"`
+	Amount      string    `json:"amount"`
+	Note        *string   `json:"note,omitempty"`
+}
+
+// ProjectionSource lists the scheduled items of a wallet dated from through until, for
+// schedules kept outside this package.
+type ProjectionSource func(ctx context.Context, userID, walletID uuid.UUID, from, until time.Time) ([]ProjectedItem, error)
+
+// ProjectedDay is the expected end-of-day balance of a wallet.
+type ProjectedDay struct {
+	Date     time.Time       `json:"date"`
+	Inflow   string          `json:"inflow"`
+	Outflow  string          `json:"outflow"`
+	Balance  string          `json:"balance"`
+	Negative bool            `json:"negative"`
+	Items    []ProjectedItem `json:"items,omitempty"`
+}
+
+// Projection is the daily balance of a wallet from today through Until. Planned
+// transactions that are overdue count today.
+type Projection struct {
+	WalletID       uuid.UUID      `json:"wallet_id"`
+	Currency       string         `json:"currency"`
+	CurrentBalance string         `json:"current_balance"`
+	Until          time.Time      `json:"until"`
+	LowestBalance  string         `json:"lowest_balance"`
+	LowestDate     time.Time      `json:"lowest_date"`
+	NegativeDates  []time.Time    `json:"negative_dates"`
+	Days           []ProjectedDay `json:"days"`
+}
+
+// plannedFrom is the first moment a transaction recorded at now counts as planned: the
+// next midnight.
+func plannedFrom(now time.Time) time.Time {
+	y, m, d := now.Date()
+	return time.Date(y, m, d+1, 0, 0, 0, 0, now.Location())
+}
+
+// schedule marks t planned when it is dated after now's day, unless the caller already
+// did. Planned transactions nobody chose for are posted automatically on their date.
+func schedule(t *Transaction, now time.Time) {
+	if !t.Planned && !t.OccurredAt.Before(plannedFrom(now)) {
+		t.Planned, t.AutoPost = true, true
+	}
+}
+
+// PostTransaction posts a planned transaction so it changes the wallet balance. One
+// posted before its date is moved to now.
+func (s *Service) PostTransaction(ctx context.Context, userID, transactionID uuid.UUID) (*Transaction, error) {
+	ctx = userChange(ctx, SourceAPI, userID, nil)
+	if err := s.repo.PostPlanned(ctx, userID, transactionID, time.Now()); err != nil {
+		return nil, err
+	}
+	return s.repo.GetTransaction(ctx, userID, transactionID)
+}
+
+// PostDue posts the auto-post planned transactions dated on or before now's day and
+// returns how many were posted.
+func (s *Service) PostDue(ctx context.Context, now time.Time) (int, error) {
+	due, err := s.repo.DuePlanned(ctx, plannedFrom(now))
+	if err != nil {
+		return 0, err
+	}
+	n := 0
+	for _, t := range due {
+		if !t.AutoPost {
+			continue
+		}
+		id := t.ID
+		pctx := WithChange(ctx, Change{Source: SourcePlanned, ReferenceID: &id})
+		err := s.repo.PostPlanned(pctx, t.UserID, t.ID, now)
+		// Diposting manual atau dihapus sejak dibaca
+		if errors.Is(err, ErrNotPlanned) || errors.Is(err, ErrTransactionNotFound) {
+			continue
+		}
+		if err != nil {
+			return n, err
+		}
+		n++
+	}
+	return n, nil
+}
+
+// PlannedPrompts is a notification source asking users to post planned transactions
+// that are due and wait for confirmation.
+func (s *Service) PlannedPrompts(ctx context.Context, now time.Time) ([]notification.Notification, error) {
+	due, err := s.repo.DuePlanned(ctx, plannedFrom(now))
+	if err != nil {
+		return nil, err
+	}
+	var out []notification.Notification
+	for _, t := range due {
+		if t.AutoPost {
+			continue
+		}
+		id, date := t.ID, calendarDay(t.OccurredAt.In(now.Location()))
+		direction := "Payment"
+		if t.Kind == "in" {
+			direction = "Income"
+		}
+		out = append(out, notification.Notification{
+			UserID:      t.UserID,
+			Kind:        NotificationPlannedDue,
+			Title:       fmt.Sprintf("%s of %s %s is due", direction, t.Amount, t.Currency),
+			Body:        fmt.Sprintf("The transaction planned for %s has not been posted. Post it to update the wallet balance.", date.Format("2 Jan 2006")),
+			ReferenceID: &id,
+			DueDate:     &date,
+			DedupeKey:   fmt.Sprintf("%s:%s", NotificationPlannedDue, t.ID),
+		})
+	}
+	return out, nil
+}
+
+// Projection projects the daily balance of a wallet from today through until, or
+// DefaultProjectionDays ahead when until is zero, from its planned transactions and the
+// items of sources.
+func (s *Service) Projection(ctx context.Context, userID, walletID uuid.UUID, until time.Time, sources ...ProjectionSource) (*Projection, error) {
+	now := time.Now()
+	today := calendarDay(now)
+	if until.IsZero() {
+		until = today.AddDate(0, 0, DefaultProjectionDays)
+	}
+	until = calendarDay(until)
+	if until.Before(today) || until.After(today.AddDate(0, 0, MaxProjectionDays)) {
+		return nil, fmt.Errorf("%w: until must be between today and %d days ahead", ErrInvalidProjection, MaxProjectionDays)
+	}
+	w, err := s.repo.GetWallet(ctx, userID, walletID)
+	if err != nil {
+		return nil, err
+	}
+	balance, ok := sqlutil.ToCents(w.Balance)
+	if !ok {
+		return nil, fmt.Errorf("wallet balance %q is not a number", w.Balance)
+	}
+
+	planned, err := s.repo.WalletPlanned(ctx, userID, walletID, plannedFrom(time.Date(until.Year(), until.Month(), until.Day(), 0, 0, 0, 0, now.Location())))
+	if err != nil {
+		return nil, err
+	}
+	var items []ProjectedItem
+	for _, t := range planned {
+		date := calendarDay(t.OccurredAt.In(now.Location()))
+		if date.Before(today) {
+			date = today
+		}
+		items = append(items, ProjectedItem{Date: date, Source: StatusPlanned, ReferenceID: t.ID, Kind: t.Kind, Amount: t.Amount, Note: t.Note})
+	}
+	for _, src := range sources {
+		more, err := src(ctx, userID, walletID, today, until)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, more...)
+	}
+	sort.SliceStable(items, func(i, j int) bool { return items[i].Date.Before(items[j].Date) })
+
+	p := &Projection{
+		WalletID:       w.ID,
+		Currency:       w.Currency,
+		CurrentBalance: sqlutil.CentsString(balance),
+		Until:          until,
+		LowestBalance:  sqlutil.CentsString(balance),
+		LowestDate:     today,
+		NegativeDates:  []time.Time{},
+	}
+	lowest, next := balance, 0
+	for day := today; !day.After(until); day = day.AddDate(0, 0, 1) {
+		var in, out int64
+		var dayItems []ProjectedItem
+		for ; next < len(items) && !items[next].Date.After(day); next++ {
+			it := items[next]
+			cents, ok := sqlutil.ToCents(it.Amount)
+			if !ok {
+				return nil, fmt.Errorf("projected amount %q is not a number", it.Amount)
+			}
+			if it.Kind == "in" {
+				in += cents
+			} else {
+				out += cents
+			}
+			dayItems = append(dayItems, it)
+		}
+		balance += in - out
+		if balance < lowest {
+			lowest = balance
+			p.LowestBalance, p.LowestDate = sqlutil.CentsString(balance), day
+		}
+		if balance < 0 {
+			p.NegativeDates = append(p.NegativeDates, day)
+		}
+		p.Days = append(p.Days, ProjectedDay{
+			Date:     day,
+			Inflow:   sqlutil.CentsString(in),
+			Outflow:  sqlutil.CentsString(out),
+			Balance:  sqlutil.CentsString(balance),
+			Negative: balance < 0,
+			Items:    dayItems,
+		})
+	}
+	return p, nil
+}
+
+// PlannedPoster periodically posts planned transactions that are due.
+type PlannedPoster struct {
+	service  *Service
+	interval time.Duration
+}
+
+func NewPlannedPoster(service *Service, interval time.Duration) *PlannedPoster {
+	if interval <= 0 {
+		interval = time.Minute
+	}
+	return &PlannedPoster{service: service, interval: interval}
+}
+
+// Run posts immediately and then on every tick until ctx is cancelled.
+func (p *PlannedPoster) Run(ctx context.Context) {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		n, err := p.service.PostDue(ctx, time.Now())
+		if err != nil && ctx.Err() == nil {
+			log.Printf("[PLANNED_ERROR] post: %v", err)
+		}
+		if n > 0 {
+			log.Printf("[PLANNED] posted %d planned transaction(s)", n)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
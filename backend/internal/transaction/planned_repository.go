@@ -0,0 +1,98 @@
+package transaction
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// PostPlanned clears the planned flag of a transaction, and of the other leg of a
+// transfer, and books it on the wallet. The date is brought forward to at when it is
+// later.
+func (r *SQLRepository) PostPlanned(ctx context.Context, userID, transactionID uuid.UUID, at time.Time) (err error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	var planned bool
+	err = tx.QueryRowContext(ctx, `SELECT planned FROM finance.transactions
+		WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL FOR UPDATE`, transactionID, userID).Scan(&planned)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrTransactionNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("select transaction: %w", err)
+	}
+	if !planned {
+		return ErrNotPlanned
+	}
+	// Kaki transfer selalu diposting bersama pasangannya
+	ids, err := selectTransactionIDs(ctx, tx, `SELECT t.id FROM finance.transactions t
+		WHERE t.user_id = $2 AND t.deleted_at IS NULL AND t.planned
+			AND (t.id = $1 OR t.transfer_id = (SELECT transfer_id FROM finance.transactions WHERE id = $1))
+		FOR UPDATE`, transactionID, userID)
+	if err != nil {
+		return err
+	}
+	before, err := snapshotHistory(ctx, tx, ids)
+	if err != nil {
+		return err
+	}
+
+	if _, err = tx.ExecContext(ctx, `UPDATE finance.transactions SET planned = FALSE, occurred_at = LEAST(occurred_at, $2)
+		WHERE id = ANY($1::uuid[])`, uuidStrings(ids), at); err != nil {
+		return fmt.Errorf("post transaction: %w", err)
+	}
+	if err = recordChanges(ctx, tx, ids, before); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("commit tx: %w", err)
+	}
+	return nil
+}
+
+// DuePlanned returns the live planned transactions of every user dated before before.
+func (r *SQLRepository) DuePlanned(ctx context.Context, before time.Time) ([]Transaction, error) {
+	query := fmt.Sprintf(`SELECT %s FROM finance.transactions t
+		WHERE t.planned AND t.deleted_at IS NULL AND t.occurred_at < $1 ORDER BY t.occurred_at ASC, t.id ASC`, transactionColumns)
+	return r.selectPlanned(ctx, query, before)
+}
+
+// WalletPlanned returns the live planned transactions of a wallet dated before before.
+func (r *SQLRepository) WalletPlanned(ctx context.Context, userID, walletID uuid.UUID, before time.Time) ([]Transaction, error) {
+	query := fmt.Sprintf(`SELECT %s FROM finance.transactions t
+		WHERE t.user_id = $1 AND t.wallet_id = $2 AND t.planned AND t.deleted_at IS NULL AND t.occurred_at < $3
+		ORDER BY t.occurred_at ASC, t.id ASC`, transactionColumns)
+	return r.selectPlanned(ctx, query, userID, walletID, before)
+}
+
+func (r *SQLRepository) selectPlanned(ctx context.Context, query string, args ...any) ([]Transaction, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("select planned transactions: %w", err)
+	}
+	defer rows.Close()
+
+	var out []Transaction
+	for rows.Next() {
+		t, err := scanTransaction(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, t)
+	}
+	return out, rows.Err()
+}
@@ -0,0 +1,44 @@
+package transaction
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/Jomesi149/Implementasi-LASTI/backend/pkg/response"
+)
+
+func (h *HTTPHandler) handlePostTransaction(w http.ResponseWriter, r *http.Request) {
+	uid, id, ok := idParams(w, r, "transaction")
+	if !ok {
+		return
+	}
+	t, err := h.service.PostTransaction(r.Context(), uid, id)
+	if err != nil {
+		response.Error(w, errorStatus(err), err.Error())
+		return
+	}
+	response.JSON(w, http.StatusOK, t)
+}
+
+// handleProjection serves the daily balance of a wallet through ?until=YYYY-MM-DD.
+func (h *HTTPHandler) handleProjection(w http.ResponseWriter, r *http.Request) {
+	uid, id, ok := idParams(w, r, "wallet")
+	if !ok {
+		return
+	}
+	var until time.Time
+	if v := r.URL.Query().Get("until"); v != "" {
+		d, err := time.Parse("2006-01-02", v)
+		if err != nil {
+			response.Error(w, http.StatusBadRequest, "until must be YYYY-MM-DD")
+			return
+		}
+		until = d
+	}
+	p, err := h.service.Projection(r.Context(), uid, id, until, h.projections...)
+	if err != nil {
+		response.Error(w, errorStatus(err), err.Error())
+		return
+	}
+	response.JSON(w, http.StatusOK, p)
+}
@@ -0,0 +1,163 @@
+package transaction
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/Jomesi149/Implementasi-LASTI/backend/internal/sqlutil"
+)
+
+// Reconciliation statuses.
+const (
+	ReconciliationOpen      = "open"
+	ReconciliationCompleted = "completed"
+	ReconciliationCancelled = "cancelled"
+)
+
+// SourceReconciliation marks history rows written by a reconciliation adjustment.
+const SourceReconciliation = "reconciliation"
+
+// reconciliationNote is the note of adjustment transactions.
+const reconciliationNote = "Reconciliation adjustment"
+
+var (
+	// ErrReconciliationNotFound is returned when the reconciliation does not exist for the user.
+	ErrReconciliationNotFound = errors.New("reconciliation_not_found")
+	// ErrInvalidReconciliation indicates a malformed statement or clear request.
+	ErrInvalidReconciliation = errors.New("invalid_reconciliation")
+	// ErrReconciliationState is returned when the reconciliation is not open, or the
+	// wallet already has an open one.
+	ErrReconciliationState = errors.New("reconciliation_state_conflict")
+	// ErrReconciliationUnbalanced is returned when completing without an adjustment
+	// while the cleared balance differs from the statement.
+	ErrReconciliationUnbalanced = errors.New("reconciliation_unbalanced")
+)
+
+// Reconciliation matches a wallet against a bank statement. ClearedBalance is the
+// cleared balance at completion, before any adjustment.
+type Reconciliation struct {
+	ID                      uuid.UUID  `json:"id"`
+	UserID                  uuid.UUID  `json:"user_id"`
+	WalletID                uuid.UUID  `json:"wallet_id"`
+	StatementDate           time.Time  `json:"statement_date"`
+	StatementBalance        string     `json:"statement_balance"`
+	Status                  string     `json:"status"`
+	ClearedBalance          *string    `json:"cleared_balance,omitempty"`
+	AdjustmentTransactionID *uuid.UUID `json:"adjustment_transaction_id,omitempty"`
+	CreatedAt               time.Time  `json:"created_at"`
+	CompletedAt             *time.Time `json:"completed_at,omitempty"`
+}
+
+// ReconciliationStatus is the working view of a reconciliation. ClearedBalance is the
+// wallet balance without transactions that are not cleared or fall after the statement
+// date; Difference is the statement balance minus it. Unreconciled lists the live
+// transactions up to the statement date that no completed reconciliation covers yet,
+// cleared or not.
+type ReconciliationStatus struct {
+	Reconciliation Reconciliation `json:"reconciliation"`
+	WalletBalance  string         `json:"wallet_balance"`
+	ClearedBalance string         `json:"cleared_balance"`
+	Difference     string         `json:"difference"`
+	Unreconciled   []Transaction  `json:"unreconciled"`
+}
+
+// statementDay formats the statement date for DATE parameters, so the session time
+// zone cannot shift it.
+func (rec Reconciliation) statementDay() string {
+	return rec.StatementDate.Format("2006-01-02")
+}
+
+// adjustmentTransaction closes a gap of diff cents between the statement and the cleared
+// balance. It is cleared already and dated on the statement date.
+func adjustmentTransaction(rec Reconciliation, diff int64) Transaction {
+	kind := "in"
+	if diff < 0 {
+		kind, diff = "out", -diff
+	}
+	note := reconciliationNote
+	now := time.Now()
+	return Transaction{
+		ID:         uuid.New(),
+		UserID:     rec.UserID,
+		WalletID:   rec.WalletID,
+		Amount:     sqlutil.CentsString(diff),
+		Kind:       kind,
+		Note:       &note,
+		OccurredAt: rec.StatementDate,
+		ClearedAt:  &now,
+		CreatedAt:  now,
+	}
+}
+
+// StartReconciliation opens a reconciliation of walletID against a statement balance on
+// statementDate. A wallet has at most one open reconciliation.
+func (s *Service) StartReconciliation(ctx context.Context, userID, walletID uuid.UUID, statementDate time.Time, statementBalance string) (*ReconciliationStatus, error) {
+	cents, ok := sqlutil.ToCents(statementBalance)
+	if !ok {
+		return nil, fmt.Errorf("%w: statement_balance must be a number", ErrInvalidReconciliation)
+	}
+	if statementDate.IsZero() {
+		return nil, fmt.Errorf("%w: statement_date is required", ErrInvalidReconciliation)
+	}
+	if _, err := s.repo.GetWallet(ctx, userID, walletID); err != nil {
+		return nil, err
+	}
+	rec := Reconciliation{
+		ID:               uuid.New(),
+		UserID:           userID,
+		WalletID:         walletID,
+		StatementDate:    statementDate,
+		StatementBalance: sqlutil.CentsString(cents),
+		Status:           ReconciliationOpen,
+		CreatedAt:        time.Now(),
+	}
+	if err := s.repo.CreateReconciliation(ctx, rec); err != nil {
+		return nil, err
+	}
+	return s.repo.ReconciliationStatus(ctx, userID, rec.ID)
+}
+
+// GetReconciliation returns the working view of a reconciliation.
+func (s *Service) GetReconciliation(ctx context.Context, userID, id uuid.UUID) (*ReconciliationStatus, error) {
+	return s.repo.ReconciliationStatus(ctx, userID, id)
+}
+
+// ListReconciliations returns the reconciliations of a wallet, newest statement first.
+func (s *Service) ListReconciliations(ctx context.Context, userID, walletID uuid.UUID) ([]Reconciliation, error) {
+	if _, err := s.repo.GetWallet(ctx, userID, walletID); err != nil {
+		return nil, err
+	}
+	return s.repo.ListReconciliations(ctx, userID, walletID)
+}
+
+// ClearTransactions marks transactionIDs cleared (or not) for an open reconciliation.
+// They must be live transactions of its wallet up to the statement date that no
+// completed reconciliation covers.
+func (s *Service) ClearTransactions(ctx context.Context, userID, id uuid.UUID, transactionIDs []uuid.UUID, cleared bool) (*ReconciliationStatus, error) {
+	transactionIDs = uniqueIDs(transactionIDs)
+	if len(transactionIDs) == 0 {
+		return nil, fmt.Errorf("%w: transaction_ids is required", ErrInvalidReconciliation)
+	}
+	if err := s.repo.SetCleared(ctx, userID, id, transactionIDs, cleared); err != nil {
+		return nil, err
+	}
+	return s.repo.ReconciliationStatus(ctx, userID, id)
+}
+
+// CompleteReconciliation stamps every cleared transaction up to the statement date with
+// the reconciliation. A remaining difference is booked as an adjustment transaction when
+// adjust is set, and refused with ErrReconciliationUnbalanced otherwise.
+func (s *Service) CompleteReconciliation(ctx context.Context, userID, id uuid.UUID, adjust bool) (*Reconciliation, error) {
+	ctx = userChange(ctx, SourceReconciliation, userID, &id)
+	return s.repo.CompleteReconciliation(ctx, userID, id, adjust)
+}
+
+// CancelReconciliation closes an open reconciliation without changing anything; cleared
+// marks stay for the next one.
+func (s *Service) CancelReconciliation(ctx context.Context, userID, id uuid.UUID) error {
+	return s.repo.CancelReconciliation(ctx, userID, id)
+}
@@ -0,0 +1,261 @@
+package transaction
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+
+	"github.com/Jomesi149/Implementasi-LASTI/backend/internal/sqlutil"
+)
+
+const reconciliationColumns = `r.id, r.user_id, r.wallet_id, r.statement_date, r.statement_balance::TEXT, r.status,
+	r.cleared_balance::TEXT, r.adjustment_transaction_id, r.created_at, r.completed_at`
+
+// clearedBalanceQuery returns the balance of wallet $1 and its cleared balance as of
+// statement date $2.
+const clearedBalanceQuery = `SELECT w.balance::TEXT, (w.balance - COALESCE((
+		SELECT SUM(CASE WHEN t.kind = 'in' THEN t.amount ELSE -t.amount END)
+		FROM finance.transactions t
+		WHERE t.wallet_id = w.id AND t.deleted_at IS NULL AND NOT t.planned
+			AND (t.cleared_at IS NULL OR t.occurred_at >= $2::DATE + 1)
+	), 0))::TEXT
+	FROM finance.wallets w WHERE w.id = $1`
+
+type queryRower interface {
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+func scanReconciliation(row rowScanner) (*Reconciliation, error) {
+	var rec Reconciliation
+	var cleared sql.NullString
+	var adjustmentID uuid.NullUUID
+	var completedAt sql.NullTime
+	if err := row.Scan(&rec.ID, &rec.UserID, &rec.WalletID, &rec.StatementDate, &rec.StatementBalance, &rec.Status,
+		&cleared, &adjustmentID, &rec.CreatedAt, &completedAt); err != nil {
+		return nil, err
+	}
+	if cleared.Valid {
+		s := cleared.String
+		rec.ClearedBalance = &s
+	}
+	if adjustmentID.Valid {
+		id := adjustmentID.UUID
+		rec.AdjustmentTransactionID = &id
+	}
+	if completedAt.Valid {
+		at := completedAt.Time
+		rec.CompletedAt = &at
+	}
+	return &rec, nil
+}
+
+// getReconciliation loads a reconciliation of userID, locking it when forUpdate is set.
+func getReconciliation(ctx context.Context, q queryRower, userID, id uuid.UUID, forUpdate bool) (*Reconciliation, error) {
+	query := fmt.Sprintf(`SELECT %s FROM finance.reconciliations r WHERE r.id = $1 AND r.user_id = $2`, reconciliationColumns)
+	if forUpdate {
+		query += " FOR UPDATE"
+	}
+	rec, err := scanReconciliation(q.QueryRowContext(ctx, query, id, userID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrReconciliationNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("select reconciliation: %w", err)
+	}
+	return rec, nil
+}
+
+// openReconciliation loads and locks a reconciliation that must still be open.
+func openReconciliation(ctx context.Context, tx *sql.Tx, userID, id uuid.UUID) (*Reconciliation, error) {
+	rec, err := getReconciliation(ctx, tx, userID, id, true)
+	if err != nil {
+		return nil, err
+	}
+	if rec.Status != ReconciliationOpen {
+		return nil, fmt.Errorf("%w: reconciliation is %s", ErrReconciliationState, rec.Status)
+	}
+	return rec, nil
+}
+
+func (r *SQLRepository) CreateReconciliation(ctx context.Context, rec Reconciliation) error {
+	query := `INSERT INTO finance.reconciliations (id, user_id, wallet_id, statement_date, statement_balance, status, created_at)
+		VALUES ($1,$2,$3,$4::DATE,$5::NUMERIC,$6,NOW())`
+	if _, err := r.db.ExecContext(ctx, query, rec.ID, rec.UserID, rec.WalletID, rec.statementDay(), rec.StatementBalance, rec.Status); err != nil {
+		if isUniqueViolation(err, "idx_reconciliations_open_wallet") {
+			return fmt.Errorf("%w: the wallet already has an open reconciliation", ErrReconciliationState)
+		}
+		return fmt.Errorf("insert reconciliation: %w", err)
+	}
+	return nil
+}
+
+// ReconciliationStatus computes the working view of a reconciliation.
+func (r *SQLRepository) ReconciliationStatus(ctx context.Context, userID, id uuid.UUID) (*ReconciliationStatus, error) {
+	rec, err := getReconciliation(ctx, r.db, userID, id, false)
+	if err != nil {
+		return nil, err
+	}
+	st := &ReconciliationStatus{Reconciliation: *rec, Unreconciled: []Transaction{}}
+	if err := r.db.QueryRowContext(ctx, clearedBalanceQuery, rec.WalletID, rec.statementDay()).Scan(&st.WalletBalance, &st.ClearedBalance); err != nil {
+		return nil, fmt.Errorf("cleared balance: %w", err)
+	}
+	statement, _ := sqlutil.ToCents(rec.StatementBalance)
+	cleared, _ := sqlutil.ToCents(st.ClearedBalance)
+	st.Difference = sqlutil.CentsString(statement - cleared)
+
+	query := fmt.Sprintf(`SELECT %s FROM finance.transactions t
+		WHERE t.wallet_id = $1 AND t.deleted_at IS NULL AND NOT t.planned AND t.reconciliation_id IS NULL AND t.occurred_at < $2::DATE + 1
+		ORDER BY t.occurred_at ASC, t.id ASC`, transactionColumns)
+	rows, err := r.db.QueryContext(ctx, query, rec.WalletID, rec.statementDay())
+	if err != nil {
+		return nil, fmt.Errorf("list unreconciled: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		t, err := scanTransaction(rows)
+		if err != nil {
+			return nil, err
+		}
+		st.Unreconciled = append(st.Unreconciled, t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return st, nil
+}
+
+func (r *SQLRepository) ListReconciliations(ctx context.Context, userID, walletID uuid.UUID) ([]Reconciliation, error) {
+	query := fmt.Sprintf(`SELECT %s FROM finance.reconciliations r WHERE r.user_id = $1 AND r.wallet_id = $2
+		ORDER BY r.statement_date DESC, r.created_at DESC`, reconciliationColumns)
+	rows, err := r.db.QueryContext(ctx, query, userID, walletID)
+	if err != nil {
+		return nil, fmt.Errorf("list reconciliations: %w", err)
+	}
+	defer rows.Close()
+
+	out := []Reconciliation{}
+	for rows.Next() {
+		rec, err := scanReconciliation(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *rec)
+	}
+	return out, rows.Err()
+}
+
+// SetCleared marks transactionIDs cleared or uncleared for an open reconciliation. Either
+// all of them qualify or nothing changes.
+func (r *SQLRepository) SetCleared(ctx context.Context, userID, id uuid.UUID, transactionIDs []uuid.UUID, cleared bool) (err error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	rec, err := openReconciliation(ctx, tx, userID, id)
+	if err != nil {
+		return err
+	}
+	res, err := tx.ExecContext(ctx, `UPDATE finance.transactions SET cleared_at = CASE WHEN $4 THEN COALESCE(cleared_at, NOW()) ELSE NULL END
+		WHERE wallet_id = $1 AND id = ANY($2::uuid[]) AND deleted_at IS NULL AND NOT planned AND reconciliation_id IS NULL AND occurred_at < $3::DATE + 1`,
+		rec.WalletID, uuidStrings(transactionIDs), rec.statementDay(), cleared)
+	if err != nil {
+		return fmt.Errorf("set cleared: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if int(n) != len(transactionIDs) {
+		return fmt.Errorf("%w: only unreconciled transactions of the wallet up to the statement date can be cleared", ErrInvalidReconciliation)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("commit tx: %w", err)
+	}
+	return nil
+}
+
+// CompleteReconciliation closes an open reconciliation inside one database transaction;
+// see Service.CompleteReconciliation.
+func (r *SQLRepository) CompleteReconciliation(ctx context.Context, userID, id uuid.UUID, adjust bool) (rec *Reconciliation, err error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("begin tx: %w", err)
+	}
+
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	rec, err = openReconciliation(ctx, tx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+	// Dompet dikunci supaya saldo tidak berubah selama selisih dihitung
+	var balance, clearedBalance string
+	if _, err = tx.ExecContext(ctx, `SELECT 1 FROM finance.wallets WHERE id = $1 FOR UPDATE`, rec.WalletID); err != nil {
+		return nil, fmt.Errorf("lock wallet: %w", err)
+	}
+	if err = tx.QueryRowContext(ctx, clearedBalanceQuery, rec.WalletID, rec.statementDay()).Scan(&balance, &clearedBalance); err != nil {
+		return nil, fmt.Errorf("cleared balance: %w", err)
+	}
+	statement, _ := sqlutil.ToCents(rec.StatementBalance)
+	cleared, _ := sqlutil.ToCents(clearedBalance)
+
+	var adjustmentID *uuid.UUID
+	if diff := statement - cleared; diff != 0 {
+		if !adjust {
+			return nil, fmt.Errorf("%w: cleared balance differs from the statement by %s", ErrReconciliationUnbalanced, sqlutil.CentsString(diff))
+		}
+		t := adjustmentTransaction(*rec, diff)
+		if err = insertTransaction(ctx, tx, t); err != nil {
+			return nil, err
+		}
+		adjustmentID = &t.ID
+	}
+
+	if _, err = tx.ExecContext(ctx, `UPDATE finance.transactions SET reconciliation_id = $2
+		WHERE wallet_id = $1 AND deleted_at IS NULL AND NOT planned AND cleared_at IS NOT NULL AND reconciliation_id IS NULL AND occurred_at < $3::DATE + 1`,
+		rec.WalletID, rec.ID, rec.statementDay()); err != nil {
+		return nil, fmt.Errorf("mark reconciled: %w", err)
+	}
+	query := fmt.Sprintf(`UPDATE finance.reconciliations r SET status = $2, cleared_balance = $3::NUMERIC, adjustment_transaction_id = $4, completed_at = NOW()
+		WHERE r.id = $1 RETURNING %s`, reconciliationColumns)
+	rec, err = scanReconciliation(tx.QueryRowContext(ctx, query, rec.ID, ReconciliationCompleted, sqlutil.CentsString(cleared), adjustmentID))
+	if err != nil {
+		return nil, fmt.Errorf("complete reconciliation: %w", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("commit tx: %w", err)
+	}
+	return rec, nil
+}
+
+func (r *SQLRepository) CancelReconciliation(ctx context.Context, userID, id uuid.UUID) error {
+	res, err := r.db.ExecContext(ctx, `UPDATE finance.reconciliations SET status = $3 WHERE id = $1 AND user_id = $2 AND status = $4`,
+		id, userID, ReconciliationCancelled, ReconciliationOpen)
+	if err != nil {
+		return fmt.Errorf("cancel reconciliation: %w", err)
+	}
+	if n, err := res.RowsAffected(); err != nil || n > 0 {
+		return err
+	}
+	rec, err := getReconciliation(ctx, r.db, userID, id, false)
+	if err != nil {
+		return err
+	}
+	return fmt.Errorf("%w: reconciliation is %s", ErrReconciliationState, rec.Status)
+}
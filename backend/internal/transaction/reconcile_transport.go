@@ -0,0 +1,140 @@
+package transaction
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/Jomesi149/Implementasi-LASTI/backend/pkg/response"
+)
+
+func (h *HTTPHandler) registerReconciliationRoutes(r chi.Router) {
+	r.Route("/reconciliations", func(r chi.Router) {
+		r.Get("/{id}", h.handleGetReconciliation)
+		r.Post("/{id}/clear", h.handleClearTransactions)
+		r.Post("/{id}/complete", h.handleCompleteReconciliation)
+		r.Post("/{id}/cancel", h.handleCancelReconciliation)
+	})
+}
+
+type startReconciliationReq struct {
+	StatementDate    string `json:"statement_date"`
+	StatementBalance string `json:"statement_balance"`
+}
+
+func (h *HTTPHandler) handleStartReconciliation(w http.ResponseWriter, r *http.Request) {
+	uid, walletID, ok := idParams(w, r, "wallet")
+	if !ok {
+		return
+	}
+	var req startReconciliationReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.Error(w, http.StatusBadRequest, "invalid payload")
+		return
+	}
+	date, err := time.Parse("2006-01-02", req.StatementDate)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "invalid statement_date, expected YYYY-MM-DD")
+		return
+	}
+	st, err := h.service.StartReconciliation(r.Context(), uid, walletID, date, req.StatementBalance)
+	if err != nil {
+		response.Error(w, errorStatus(err), err.Error())
+		return
+	}
+	response.JSON(w, http.StatusCreated, st)
+}
+
+func (h *HTTPHandler) handleListReconciliations(w http.ResponseWriter, r *http.Request) {
+	uid, walletID, ok := idParams(w, r, "wallet")
+	if !ok {
+		return
+	}
+	recs, err := h.service.ListReconciliations(r.Context(), uid, walletID)
+	if err != nil {
+		response.Error(w, errorStatus(err), err.Error())
+		return
+	}
+	response.JSON(w, http.StatusOK, recs)
+}
+
+func (h *HTTPHandler) handleGetReconciliation(w http.ResponseWriter, r *http.Request) {
+	uid, id, ok := idParams(w, r, "reconciliation")
+	if !ok {
+		return
+	}
+	st, err := h.service.GetReconciliation(r.Context(), uid, id)
+	if err != nil {
+		response.Error(w, errorStatus(err), err.Error())
+		return
+	}
+	response.JSON(w, http.StatusOK, st)
+}
+
+type clearTransactionsReq struct {
+	TransactionIDs []string `json:"transaction_ids"`
+	// Cleared defaults to true; send false to unclear.
+	Cleared *bool `json:"cleared"`
+}
+
+func (h *HTTPHandler) handleClearTransactions(w http.ResponseWriter, r *http.Request) {
+	uid, id, ok := idParams(w, r, "reconciliation")
+	if !ok {
+		return
+	}
+	var req clearTransactionsReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.Error(w, http.StatusBadRequest, "invalid payload")
+		return
+	}
+	ids, err := parseUUIDList(req.TransactionIDs)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "invalid transaction id")
+		return
+	}
+	cleared := req.Cleared == nil || *req.Cleared
+	st, err := h.service.ClearTransactions(r.Context(), uid, id, ids, cleared)
+	if err != nil {
+		response.Error(w, errorStatus(err), err.Error())
+		return
+	}
+	response.JSON(w, http.StatusOK, st)
+}
+
+type completeReconciliationReq struct {
+	Adjust bool `json:"adjust"`
+}
+
+func (h *HTTPHandler) handleCompleteReconciliation(w http.ResponseWriter, r *http.Request) {
+	uid, id, ok := idParams(w, r, "reconciliation")
+	if !ok {
+		return
+	}
+	var req completeReconciliationReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		response.Error(w, http.StatusBadRequest, "invalid payload")
+		return
+	}
+	rec, err := h.service.CompleteReconciliation(r.Context(), uid, id, req.Adjust)
+	if err != nil {
+		response.Error(w, errorStatus(err), err.Error())
+		return
+	}
+	response.JSON(w, http.StatusOK, rec)
+}
+
+func (h *HTTPHandler) handleCancelReconciliation(w http.ResponseWriter, r *http.Request) {
+	uid, id, ok := idParams(w, r, "reconciliation")
+	if !ok {
+		return
+	}
+	if err := h.service.CancelReconciliation(r.Context(), uid, id); err != nil {
+		response.Error(w, errorStatus(err), err.Error())
+		return
+	}
+	response.JSON(w, http.StatusOK, map[string]string{"status": "reconciliation cancelled"})
+}
@@ -3,20 +3,98 @@ package transaction
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"strconv"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
+
+	"github.com/Jomesi149/Implementasi-LASTI/backend/internal/sqlutil"
 )
 
 // Repository defines persistence operations for finance domain.
 type Repository interface {
 	CreateWallet(ctx context.Context, w Wallet) error
 	ListWallets(ctx context.Context, userID uuid.UUID) ([]Wallet, error)
+	GetWallet(ctx context.Context, userID, walletID uuid.UUID) (*Wallet, error)
 	CreateCategory(ctx context.Context, c Category) error
 	ListCategories(ctx context.Context, userID uuid.UUID) ([]Category, error)
+	UpdateCategory(ctx context.Context, c Category) error
+	SetCategoryArchived(ctx context.Context, userID, categoryID uuid.UUID, archivedAt *time.Time) error
+	MergeCategories(ctx context.Context, userID, sourceID, targetID uuid.UUID) error
 	CreateTransaction(ctx context.Context, t Transaction) error
-	ListTransactions(ctx context.Context, userID uuid.UUID, limit int) ([]Transaction, error)
+	ListTransactions(ctx context.Context, userID uuid.UUID, f TransactionFilter) ([]Transaction, error)
+	GetTransaction(ctx context.Context, userID, transactionID uuid.UUID) (*Transaction, error)
+	StreamTransactions(ctx context.Context, userID uuid.UUID, f TransactionFilter, byWallet bool, fn func(ExportRow) error) error
+	CreateImportBatch(ctx context.Context, b ImportBatch) error
+	GetImportBatch(ctx context.Context, userID, batchID uuid.UUID) (*ImportBatch, error)
+	UpdateImportSettings(ctx context.Context, b ImportBatch) error
+	CommitImport(ctx context.Context, b ImportBatch, txs []Transaction) error
+	UndoImport(ctx context.Context, userID, batchID uuid.UUID) error
+	ExistingExternalIDs(ctx context.Context, walletID uuid.UUID, ids []string) (map[string]bool, error)
+	CreateLabel(ctx context.Context, kind LabelKind, l *Label) error
+	ListLabels(ctx context.Context, kind LabelKind, userID uuid.UUID, query string, limit int) ([]Label, error)
+	RenameLabel(ctx context.Context, kind LabelKind, userID, id uuid.UUID, name string) (*Label, error)
+	DeleteLabel(ctx context.Context, kind LabelKind, userID, id uuid.UUID) error
+	CountLabels(ctx context.Context, kind LabelKind, userID uuid.UUID, ids []uuid.UUID) (int, error)
+	SetTransactionLabels(ctx context.Context, userID, transactionID uuid.UUID, payeeID *uuid.UUID, tagIDs []uuid.UUID) error
+	CreateCategoryRule(ctx context.Context, r CategoryRule) error
+	ListCategoryRules(ctx context.Context, userID uuid.UUID) ([]CategoryRule, error)
+	GetCategoryRule(ctx context.Context, userID, ruleID uuid.UUID) (*CategoryRule, error)
+	UpdateCategoryRule(ctx context.Context, r CategoryRule) error
+	DeleteCategoryRule(ctx context.Context, userID, ruleID uuid.UUID) error
+	UncategorisedTransactions(ctx context.Context, userID, after uuid.UUID, limit int) ([]Transaction, error)
+	AssignCategories(ctx context.Context, userID uuid.UUID, changes []RuleChange) error
+	TrainingSamples(ctx context.Context, userID uuid.UUID, limit int) ([]trainingSample, error)
+	ClaimIdempotencyKey(ctx context.Context, userID uuid.UUID, key, requestHash string, ttl, lease time.Duration) (*idempotencyClaim, error)
+	CompleteIdempotencyKey(ctx context.Context, userID uuid.UUID, key string, resp IdempotentResponse) error
+	ReleaseIdempotencyKey(ctx context.Context, userID uuid.UUID, key string) error
+	DuplicateCandidates(ctx context.Context, userID uuid.UUID, window time.Duration, limit int) ([][2]Transaction, error)
+	WalletTransactionsBetween(ctx context.Context, walletID uuid.UUID, from, to time.Time) ([]Transaction, error)
+	MergeDuplicate(ctx context.Context, userID, keepID, removeID uuid.UUID) error
+	DismissDuplicate(ctx context.Context, userID, a, b uuid.UUID) error
+	TransactionsByID(ctx context.Context, userID uuid.UUID, ids []uuid.UUID) (map[uuid.UUID]Transaction, error)
+	ApplyBulk(ctx context.Context, userID uuid.UUID, plan bulkPlan) error
+	SoftDeleteTransaction(ctx context.Context, userID, transactionID uuid.UUID) error
+	SoftDeleteSharedTransaction(ctx context.Context, actorID, walletID, transactionID uuid.UUID) error
+	SoftDeleteWallet(ctx context.Context, userID, walletID uuid.UUID) error
+	SoftDeleteCategory(ctx context.Context, userID, categoryID uuid.UUID) error
+	ListTrash(ctx context.Context, userID uuid.UUID, itemType string) ([]TrashItem, error)
+	RestoreTransaction(ctx context.Context, userID, transactionID uuid.UUID) error
+	RestoreWallet(ctx context.Context, userID, walletID uuid.UUID) error
+	RestoreCategory(ctx context.Context, userID, categoryID uuid.UUID) error
+	PurgeDeleted(ctx context.Context, before time.Time) (*PurgeResult, error)
+	TransactionHistory(ctx context.Context, userID, transactionID uuid.UUID) ([]HistoryEntry, error)
+	CheckLedger(ctx context.Context, userID uuid.UUID) (*LedgerReport, error)
+	RebuildBalances(ctx context.Context, userID uuid.UUID) (int, error)
+	CreateReconciliation(ctx context.Context, rec Reconciliation) error
+	ReconciliationStatus(ctx context.Context, userID, id uuid.UUID) (*ReconciliationStatus, error)
+	ListReconciliations(ctx context.Context, userID, walletID uuid.UUID) ([]Reconciliation, error)
+	SetCleared(ctx context.Context, userID, id uuid.UUID, transactionIDs []uuid.UUID, cleared bool) error
+	CompleteReconciliation(ctx context.Context, userID, id uuid.UUID, adjust bool) (*Reconciliation, error)
+	CancelReconciliation(ctx context.Context, userID, id uuid.UUID) error
+	CreateTransfer(ctx context.Context, legs ...Transaction) error
+	TransferLegs(ctx context.Context, userID, transferID uuid.UUID) ([]Transaction, error)
+	CreateCreditCard(ctx context.Context, w Wallet, c CreditCard) error
+	ListCreditCards(ctx context.Context, userID uuid.UUID) ([]CreditCard, error)
+	AllCreditCards(ctx context.Context) ([]CreditCard, error)
+	GetCreditCard(ctx context.Context, userID, walletID uuid.UUID) (*CreditCard, error)
+	UpdateCreditCard(ctx context.Context, c CreditCard) error
+	CreditCardActivity(ctx context.Context, walletID uuid.UUID, closedOn time.Time) (*cardActivity, error)
+	FindOrCreatePayee(ctx context.Context, userID uuid.UUID, name string) (*Label, error)
+	CreateDebt(ctx context.Context, d Debt, movement *Transaction, linkID *uuid.UUID) error
+	GetDebt(ctx context.Context, userID, id uuid.UUID) (*Debt, error)
+	ListDebts(ctx context.Context, userID uuid.UUID, counterpartyID *uuid.UUID) ([]Debt, error)
+	DebtTransactions(ctx context.Context, userID uuid.UUID, debtIDs []uuid.UUID) (map[uuid.UUID][]Transaction, error)
+	UpdateDebt(ctx context.Context, d Debt) error
+	DeleteDebt(ctx context.Context, userID, id uuid.UUID) error
+	LinkDebtTransaction(ctx context.Context, userID, debtID, transactionID uuid.UUID, kind, currency string) error
+	UnlinkDebtTransaction(ctx context.Context, userID, debtID, transactionID uuid.UUID) error
+	PostPlanned(ctx context.Context, userID, transactionID uuid.UUID, at time.Time) error
+	DuePlanned(ctx context.Context, before time.Time) ([]Transaction, error)
+	WalletPlanned(ctx context.Context, userID, walletID uuid.UUID, before time.Time) ([]Transaction, error)
 }
 
 // SQLRepository implements Repository using PostgreSQL.
@@ -29,21 +107,49 @@ func NewRepository(db *sql.DB) *SQLRepository {
 	return &SQLRepository{db: db}
 }
 
-func (r *SQLRepository) CreateWallet(ctx context.Context, w Wallet) error {
-	// Convert balance string to float64 for database
-	balance, err := strconv.ParseFloat(w.Balance, 64)
+// CreateWallet inserts an empty wallet and books its initial balance as an opening
+// entry, so the balance starts out equal to its postings.
+func (r *SQLRepository) CreateWallet(ctx context.Context, w Wallet) (err error) {
+	tx, err := r.db.BeginTx(ctx, nil)
 	if err != nil {
-		return fmt.Errorf("invalid balance: %w", err)
+		return fmt.Errorf("begin tx: %w", err)
+	}
+
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	if err = insertWallet(ctx, tx, w); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("commit tx: %w", err)
 	}
-	query := `INSERT INTO finance.wallets (id, user_id, type, name, balance, created_at, updated_at) VALUES ($1,$2,$3,$4,$5,NOW(),NOW())`
-	if _, err := r.db.ExecContext(ctx, query, w.ID, w.UserID, w.Type, w.Name, balance); err != nil {
+	return nil
+}
+
+// insertWallet inserts w with a zero balance and posts its opening entry.
+func insertWallet(ctx context.Context, tx *sql.Tx, w Wallet) error {
+	cents, ok := sqlutil.ToCents(w.Balance)
+	if !ok {
+		return fmt.Errorf("invalid balance: %q", w.Balance)
+	}
+	query := `INSERT INTO finance.wallets (id, user_id, type, name, balance, currency, created_at, updated_at) VALUES ($1,$2,$3,$4,0,$5,NOW(),NOW())`
+	if _, err := tx.ExecContext(ctx, query, w.ID, w.UserID, w.Type, w.Name, w.Currency); err != nil {
 		return fmt.Errorf("insert wallet: %w", err)
 	}
+	if cents != 0 {
+		_, err := postEntries(ctx, tx, []ledgerEntry{openingEntry(w, cents)})
+		return err
+	}
 	return nil
 }
 
 func (r *SQLRepository) ListWallets(ctx context.Context, userID uuid.UUID) ([]Wallet, error) {
-	query := `SELECT id, user_id, type, name, balance, created_at FROM finance.wallets WHERE user_id = $1`
+	query := `SELECT id, user_id, type, name, balance, currency, created_at FROM finance.wallets WHERE user_id = $1 AND deleted_at IS NULL`
 	rows, err := r.db.QueryContext(ctx, query, userID)
 	if err != nil {
 		return nil, err
@@ -53,7 +159,7 @@ func (r *SQLRepository) ListWallets(ctx context.Context, userID uuid.UUID) ([]Wa
 	var out []Wallet
 	for rows.Next() {
 		var w Wallet
-		if err := rows.Scan(&w.ID, &w.UserID, &w.Type, &w.Name, &w.Balance, &w.CreatedAt); err != nil {
+		if err := rows.Scan(&w.ID, &w.UserID, &w.Type, &w.Name, &w.Balance, &w.Currency, &w.CreatedAt); err != nil {
 			return nil, err
 		}
 		out = append(out, w)
@@ -61,16 +167,30 @@ func (r *SQLRepository) ListWallets(ctx context.Context, userID uuid.UUID) ([]Wa
 	return out, nil
 }
 
+// GetWallet loads a wallet owned by userID.
+func (r *SQLRepository) GetWallet(ctx context.Context, userID, walletID uuid.UUID) (*Wallet, error) {
+	query := `SELECT id, user_id, type, name, balance, currency, created_at FROM finance.wallets WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL`
+	var w Wallet
+	err := r.db.QueryRowContext(ctx, query, walletID, userID).Scan(&w.ID, &w.UserID, &w.Type, &w.Name, &w.Balance, &w.Currency, &w.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrWalletNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("select wallet: %w", err)
+	}
+	return &w, nil
+}
+
 func (r *SQLRepository) CreateCategory(ctx context.Context, c Category) error {
-	query := `INSERT INTO finance.categories (id, user_id, name, kind, created_at) VALUES ($1,$2,$3,$4,NOW())`
-	if _, err := r.db.ExecContext(ctx, query, c.ID, c.UserID, c.Name, c.Kind); err != nil {
+	query := `INSERT INTO finance.categories (id, user_id, parent_id, name, kind, created_at, updated_at) VALUES ($1,$2,$3,$4,$5,NOW(),NOW())`
+	if _, err := r.db.ExecContext(ctx, query, c.ID, c.UserID, c.ParentID, c.Name, c.Kind); err != nil {
 		return fmt.Errorf("insert category: %w", err)
 	}
 	return nil
 }
 
 func (r *SQLRepository) ListCategories(ctx context.Context, userID uuid.UUID) ([]Category, error) {
-	query := `SELECT id, user_id, name, kind, created_at FROM finance.categories WHERE user_id = $1`
+	query := `SELECT id, user_id, parent_id, name, kind, archived_at, created_at FROM finance.categories WHERE user_id = $1 AND deleted_at IS NULL ORDER BY name ASC`
 	rows, err := r.db.QueryContext(ctx, query, userID)
 	if err != nil {
 		return nil, err
@@ -80,22 +200,46 @@ func (r *SQLRepository) ListCategories(ctx context.Context, userID uuid.UUID) ([
 	var out []Category
 	for rows.Next() {
 		var c Category
-		if err := rows.Scan(&c.ID, &c.UserID, &c.Name, &c.Kind, &c.CreatedAt); err != nil {
+		var parentID uuid.NullUUID
+		var archivedAt sql.NullTime
+		if err := rows.Scan(&c.ID, &c.UserID, &parentID, &c.Name, &c.Kind, &archivedAt, &c.CreatedAt); err != nil {
 			return nil, err
 		}
+		if parentID.Valid {
+			id := parentID.UUID
+			c.ParentID = &id
+		}
+		if archivedAt.Valid {
+			at := archivedAt.Time
+			c.ArchivedAt = &at
+		}
 		out = append(out, c)
 	}
 	return out, nil
 }
 
-// CreateTransaction inserts a transaction and updates wallet balance atomically.
-func (r *SQLRepository) CreateTransaction(ctx context.Context, t Transaction) error {
-	// Convert amount string to float64 for database
-	amount, err := strconv.ParseFloat(t.Amount, 64)
+func (r *SQLRepository) UpdateCategory(ctx context.Context, c Category) error {
+	query := `UPDATE finance.categories SET name = $3, kind = $4, parent_id = $5, updated_at = NOW() WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL`
+	res, err := r.db.ExecContext(ctx, query, c.ID, c.UserID, c.Name, c.Kind, c.ParentID)
 	if err != nil {
-		return fmt.Errorf("invalid amount: %w", err)
+		return fmt.Errorf("update category: %w", err)
 	}
+	return sqlutil.ExpectAffected(res, ErrCategoryNotFound)
+}
 
+// SetCategoryArchived archives a category when archivedAt is set and restores it when nil.
+func (r *SQLRepository) SetCategoryArchived(ctx context.Context, userID, categoryID uuid.UUID, archivedAt *time.Time) error {
+	query := `UPDATE finance.categories SET archived_at = $3, updated_at = NOW() WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL`
+	res, err := r.db.ExecContext(ctx, query, categoryID, userID, archivedAt)
+	if err != nil {
+		return fmt.Errorf("archive category: %w", err)
+	}
+	return sqlutil.ExpectAffected(res, ErrCategoryNotFound)
+}
+
+// MergeCategories moves transactions, budgets and sub-categories from source to target
+// and then removes source, all inside one database transaction.
+func (r *SQLRepository) MergeCategories(ctx context.Context, userID, sourceID, targetID uuid.UUID) (err error) {
 	tx, err := r.db.BeginTx(ctx, nil)
 	if err != nil {
 		return fmt.Errorf("begin tx: %w", err)
@@ -107,21 +251,72 @@ func (r *SQLRepository) CreateTransaction(ctx context.Context, t Transaction) er
 		}
 	}()
 
-	q := `INSERT INTO finance.transactions (id, user_id, wallet_id, category_id, amount, kind, note, occurred_at, created_at) VALUES ($1,$2,$3,$4,$5,$6,$7,$8,NOW())`
-	if _, err = tx.ExecContext(ctx, q, t.ID, t.UserID, t.WalletID, t.CategoryID, amount, t.Kind, t.Note, t.OccurredAt); err != nil {
-		return fmt.Errorf("insert transaction: %w", err)
+	// Transaksi yang kategorinya (atau kategori split-nya) ikut pindah dicatat di riwayat
+	ids, err := selectTransactionIDs(ctx, tx, `SELECT t.id FROM finance.transactions t WHERE t.user_id = $1 AND (t.category_id = $2
+		OR EXISTS (SELECT 1 FROM finance.transaction_splits s WHERE s.transaction_id = t.id AND s.category_id = $2))`, userID, sourceID)
+	if err != nil {
+		return err
+	}
+	before, err := snapshotHistory(ctx, tx, ids)
+	if err != nil {
+		return err
 	}
 
-	// Update wallet balance
-	var balanceOp string
-	if t.Kind == "in" {
-		balanceOp = "balance + $1"
-	} else {
-		balanceOp = "balance - $1"
+	steps := []struct {
+		name  string
+		query string
+	}{
+		{"move transactions", `UPDATE finance.transactions SET category_id = $3 WHERE category_id = $2 AND user_id = $1`},
+		// Budget sumber dijumlahkan ke budget target jika target sudah punya budget
+		{"combine budgets", `UPDATE finance.budgets t SET amount = t.amount + s.amount, updated_at = NOW()
+			FROM finance.budgets s
+			WHERE s.user_id = $1 AND s.category_id = $2 AND t.user_id = $1 AND t.category_id = $3`},
+		{"drop combined budget", `DELETE FROM finance.budgets WHERE user_id = $1 AND category_id = $2
+			AND EXISTS (SELECT 1 FROM finance.budgets WHERE user_id = $1 AND category_id = $3)`},
+		{"move budget", `UPDATE finance.budgets SET category_id = $3, updated_at = NOW() WHERE user_id = $1 AND category_id = $2`},
+		// Target yang tadinya sub-kategori dari source naik menjadi induk
+		{"detach target", `UPDATE finance.categories SET parent_id = NULL, updated_at = NOW() WHERE id = $3 AND user_id = $1 AND parent_id = $2`},
+		{"move children", `UPDATE finance.categories SET parent_id = COALESCE((SELECT parent_id FROM finance.categories WHERE id = $3), $3), updated_at = NOW()
+			WHERE parent_id = $2 AND user_id = $1`},
+		{"move splits", `UPDATE finance.transaction_splits SET category_id = $3
+			WHERE category_id = $2 AND transaction_id IN (SELECT id FROM finance.transactions WHERE user_id = $1)`},
+		{"move rules", `UPDATE finance.category_rules SET category_id = $3, updated_at = NOW() WHERE category_id = $2 AND user_id = $1`},
+		{"delete source", `DELETE FROM finance.categories WHERE id = $2 AND user_id = $1`},
 	}
-	uq := fmt.Sprintf("UPDATE finance.wallets SET balance = %s, updated_at = NOW() WHERE id = $2", balanceOp)
-	if _, err = tx.ExecContext(ctx, uq, amount, t.WalletID); err != nil {
-		return fmt.Errorf("update wallet: %w", err)
+	for _, step := range steps {
+		if _, err = tx.ExecContext(ctx, step.query, userID, sourceID, targetID); err != nil {
+			return fmt.Errorf("%s: %w", step.name, err)
+		}
+	}
+	if err = recordChanges(ctx, tx, ids, before); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("commit tx: %w", err)
+	}
+	return nil
+}
+
+// CreateTransaction inserts a transaction and books it on the ledger atomically.
+func (r *SQLRepository) CreateTransaction(ctx context.Context, t Transaction) error {
+	if _, err := strconv.ParseFloat(t.Amount, 64); err != nil {
+		return fmt.Errorf("invalid amount: %w", err)
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	if err = insertTransaction(ctx, tx, t); err != nil {
+		return err
 	}
 
 	if err = tx.Commit(); err != nil {
@@ -130,12 +325,55 @@ func (r *SQLRepository) CreateTransaction(ctx context.Context, t Transaction) er
 	return nil
 }
 
-func (r *SQLRepository) ListTransactions(ctx context.Context, userID uuid.UUID, limit int) ([]Transaction, error) {
+// insertTransaction writes t with its splits and tags and books it on the ledger inside
+// tx; a transaction dated after today is stored as planned. The actor of the change
+// attached to ctx is recorded as its creator.
+func insertTransaction(ctx context.Context, tx *sql.Tx, t Transaction) error {
+	schedule(&t, time.Now())
+	q := `INSERT INTO finance.transactions (id, user_id, wallet_id, category_id, amount, kind, note, occurred_at, payee_id, cleared_at, transfer_id, debt_id, created_by, planned, auto_post, currency, created_at)
+		VALUES ($1,$2,$3,$4,$5::NUMERIC,$6,$7,$8,$9,$10,$11,$12,$13,$14,$15,(SELECT currency FROM finance.wallets WHERE id = $3),NOW())`
+	if _, err := tx.ExecContext(ctx, q, t.ID, t.UserID, t.WalletID, t.CategoryID, t.Amount, t.Kind, t.Note, t.OccurredAt, t.PayeeID, t.ClearedAt, t.TransferID, t.DebtID, changeFrom(ctx).ActorID, t.Planned, t.AutoPost || !t.Planned); err != nil {
+		if isUniqueViolation(err, "transactions_pkey") {
+			return ErrDuplicateTransaction
+		}
+		return fmt.Errorf("insert transaction: %w", err)
+	}
+	if err := insertSplits(ctx, tx, t.ID, t.Splits); err != nil {
+		return err
+	}
+	if err := insertTags(ctx, tx, t.ID, t.TagIDs); err != nil {
+		return err
+	}
+	// Saldo dompet berubah lewat posting buku besar
+	return recordChanges(ctx, tx, []uuid.UUID{t.ID}, nil)
+}
+
+// ListTransactions returns up to f.Limit transactions matching f, ordered by
+// (occurred_at, id) and starting after f.Cursor when set.
+func (r *SQLRepository) ListTransactions(ctx context.Context, userID uuid.UUID, f TransactionFilter) ([]Transaction, error) {
+	where, args := filterClause(userID, f)
+
+	dir, cmp := "DESC", "<"
+	if f.Ascending {
+		dir, cmp = "ASC", ">"
+	}
+	if f.Cursor != "" {
+		c, err := decodeCursor(f.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, c.OccurredAt, c.ID)
+		where += fmt.Sprintf(" AND (t.occurred_at, t.id) %s ($%d, $%d)", cmp, len(args)-1, len(args))
+	}
+	limit := f.Limit
 	if limit <= 0 {
-		limit = 50
+		limit = defaultPageSize
 	}
-	query := `SELECT id, user_id, wallet_id, category_id, amount, kind, note, occurred_at, created_at FROM finance.transactions WHERE user_id = $1 ORDER BY occurred_at DESC LIMIT $2`
-	rows, err := r.db.QueryContext(ctx, query, userID, limit)
+	args = append(args, limit)
+
+	query := fmt.Sprintf(`SELECT %s FROM finance.transactions t WHERE %s ORDER BY t.occurred_at %s, t.id %s LIMIT $%d`,
+		transactionColumns, where, dir, dir, len(args))
+	rows, err := r.db.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -143,22 +381,214 @@ func (r *SQLRepository) ListTransactions(ctx context.Context, userID uuid.UUID,
 
 	var out []Transaction
 	for rows.Next() {
-		var t Transaction
-		var note sql.NullString
-		var catID sql.NullString
-
-		if err := rows.Scan(&t.ID, &t.UserID, &t.WalletID, &catID, &t.Amount, &t.Kind, &note, &t.OccurredAt, &t.CreatedAt); err != nil {
+		t, err := scanTransaction(rows)
+		if err != nil {
 			return nil, err
 		}
+		out = append(out, t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if err := r.attachSplits(ctx, out); err != nil {
+		return nil, err
+	}
+	if err := r.attachTags(ctx, out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// GetTransaction returns one transaction with its splits and tags.
+func (r *SQLRepository) GetTransaction(ctx context.Context, userID, transactionID uuid.UUID) (*Transaction, error) {
+	query := fmt.Sprintf(`SELECT %s FROM finance.transactions t WHERE t.id = $1 AND t.user_id = $2 AND t.deleted_at IS NULL`, transactionColumns)
+	t, err := scanTransaction(r.db.QueryRowContext(ctx, query, transactionID, userID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrTransactionNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get transaction: %w", err)
+	}
+	txs := []Transaction{t}
+	if err := r.attachSplits(ctx, txs); err != nil {
+		return nil, err
+	}
+	if err := r.attachTags(ctx, txs); err != nil {
+		return nil, err
+	}
+	return &txs[0], nil
+}
+
+// insertSplits stores the split lines of a transaction in their given order.
+func insertSplits(ctx context.Context, tx *sql.Tx, transactionID uuid.UUID, splits []Split) error {
+	q := `INSERT INTO finance.transaction_splits (id, transaction_id, category_id, amount, note, position) VALUES ($1,$2,$3,$4,$5,$6)`
+	for i, sp := range splits {
+		amount, err := strconv.ParseFloat(sp.Amount, 64)
+		if err != nil {
+			return fmt.Errorf("invalid split amount: %w", err)
+		}
+		if _, err := tx.ExecContext(ctx, q, sp.ID, transactionID, sp.CategoryID, amount, sp.Note, i); err != nil {
+			return fmt.Errorf("insert split: %w", err)
+		}
+	}
+	return nil
+}
+
+// attachSplits loads the split lines of txs with one query.
+func (r *SQLRepository) attachSplits(ctx context.Context, txs []Transaction) error {
+	if len(txs) == 0 {
+		return nil
+	}
+	ids := make([]uuid.UUID, len(txs))
+	index := make(map[uuid.UUID]int, len(txs))
+	for i, t := range txs {
+		ids[i] = t.ID
+		index[t.ID] = i
+	}
+
+	query := `SELECT transaction_id, id, category_id, amount::TEXT, note FROM finance.transaction_splits
+		WHERE transaction_id = ANY($1::uuid[]) ORDER BY transaction_id, position`
+	rows, err := r.db.QueryContext(ctx, query, uuidStrings(ids))
+	if err != nil {
+		return fmt.Errorf("load splits: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var txID uuid.UUID
+		var sp Split
+		var catID uuid.NullUUID
+		var note sql.NullString
+		if err := rows.Scan(&txID, &sp.ID, &catID, &sp.Amount, &note); err != nil {
+			return err
+		}
 		if catID.Valid {
-			id, _ := uuid.Parse(catID.String)
-			t.CategoryID = &id
+			id := catID.UUID
+			sp.CategoryID = &id
 		}
 		if note.Valid {
-			s := note.String
-			t.Note = &s
+			n := note.String
+			sp.Note = &n
 		}
-		out = append(out, t)
+		i := index[txID]
+		txs[i].Splits = append(txs[i].Splits, sp)
 	}
-	return out, nil
+	return rows.Err()
+}
+
+// StreamTransactions calls fn for every transaction matching f without loading the
+// whole result into memory. Cursor and limit are ignored. With byWallet the rows are
+// grouped per wallet, which the OFX writer needs.
+func (r *SQLRepository) StreamTransactions(ctx context.Context, userID uuid.UUID, f TransactionFilter, byWallet bool, fn func(ExportRow) error) error {
+	where, args := filterClause(userID, f)
+	dir := "DESC"
+	if f.Ascending {
+		dir = "ASC"
+	}
+	order := fmt.Sprintf("t.occurred_at %s, t.id %s", dir, dir)
+	if byWallet {
+		order = "w.name, t.wallet_id, " + order
+	}
+
+	// Transaksi split tidak punya kategori; tampilkan daftar kategori baris split-nya
+	query := fmt.Sprintf(`SELECT %s, w.name, w.balance::TEXT,
+			COALESCE(c.name, (SELECT string_agg(sc.name, ', ' ORDER BY s.position)
+				FROM finance.transaction_splits s JOIN finance.categories sc ON sc.id = s.category_id
+				WHERE s.transaction_id = t.id), ''),
+			COALESCE(p.name, '')
+		FROM finance.transactions t
+		JOIN finance.wallets w ON w.id = t.wallet_id
+		LEFT JOIN finance.categories c ON c.id = t.category_id AND c.deleted_at IS NULL
+		LEFT JOIN finance.categories p ON p.id = c.parent_id
+		WHERE %s ORDER BY %s`, transactionColumns, where, order)
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("query export: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var row ExportRow
+		t, err := scanTransaction(rows, &row.WalletName, &row.WalletBalance, &row.CategoryName, &row.ParentCategory)
+		if err != nil {
+			return err
+		}
+		row.Transaction = t
+		if err := fn(row); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+const transactionColumns = `t.id, t.user_id, t.wallet_id, t.category_id, t.amount, t.kind, t.note, t.occurred_at, t.import_batch_id, t.external_id, t.payee_id, t.created_at, t.cleared_at, t.reconciliation_id,
+	t.currency, t.transfer_id, t.debt_id, COALESCE(t.created_by, t.user_id), t.planned, t.auto_post AND t.planned`
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+// scanTransaction reads a row selected with transactionColumns followed by any extra
+// columns, which are scanned into extra.
+func scanTransaction(row rowScanner, extra ...any) (Transaction, error) {
+	var t Transaction
+	var note, externalID sql.NullString
+	var catID, batchID, payeeID, reconciliationID, transferID, debtID uuid.NullUUID
+	var clearedAt sql.NullTime
+
+	dest := []any{&t.ID, &t.UserID, &t.WalletID, &catID, &t.Amount, &t.Kind, &note, &t.OccurredAt, &batchID, &externalID, &payeeID, &t.CreatedAt,
+		&clearedAt, &reconciliationID, &t.Currency, &transferID, &debtID, &t.CreatedBy, &t.Planned, &t.AutoPost}
+	if err := row.Scan(append(dest, extra...)...); err != nil {
+		return t, err
+	}
+	if batchID.Valid {
+		id := batchID.UUID
+		t.ImportBatchID = &id
+	}
+	if catID.Valid {
+		id := catID.UUID
+		t.CategoryID = &id
+	}
+	if payeeID.Valid {
+		id := payeeID.UUID
+		t.PayeeID = &id
+	}
+	if note.Valid {
+		s := note.String
+		t.Note = &s
+	}
+	if externalID.Valid {
+		s := externalID.String
+		t.ExternalID = &s
+	}
+	if clearedAt.Valid {
+		at := clearedAt.Time
+		t.ClearedAt = &at
+	}
+	if reconciliationID.Valid {
+		id := reconciliationID.UUID
+		t.ReconciliationID = &id
+	}
+	if transferID.Valid {
+		id := transferID.UUID
+		t.TransferID = &id
+	}
+	if debtID.Valid {
+		id := debtID.UUID
+		t.DebtID = &id
+	}
+	return t, nil
+}
+
+// isUniqueViolation reports whether err is a PostgreSQL unique violation on constraint.
+func isUniqueViolation(err error, constraint string) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23505" && pgErr.ConstraintName == constraint
+}
+
+// isForeignKeyViolation reports whether err is a PostgreSQL foreign key violation on
+// constraint.
+func isForeignKeyViolation(err error, constraint string) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23503" && pgErr.ConstraintName == constraint
 }
@@ -0,0 +1,376 @@
+package transaction
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	maxRuleRegex = 500
+	// ruleBatchSize is how many uncategorised transactions one apply step loads.
+	ruleBatchSize = 500
+	// maxRuleChanges caps how many changes a dry run lists; the total is always counted.
+	maxRuleChanges      = 500
+	defaultRulePriority = 100
+)
+
+var (
+	// ErrRuleNotFound is returned when a categorisation rule does not exist for the user.
+	ErrRuleNotFound = errors.New("category_rule_not_found")
+	// ErrInvalidRule indicates a rule with no conditions, a bad regex or a bad range.
+	ErrInvalidRule = errors.New("invalid_category_rule")
+)
+
+// CategoryRule assigns CategoryID to uncategorised transactions that meet every set
+// condition. Rules are tried by ascending Priority and the first match wins.
+type CategoryRule struct {
+	ID           uuid.UUID  `json:"id"`
+	UserID       uuid.UUID  `json:"user_id"`
+	Name         string     `json:"name"`
+	Priority     int        `json:"priority"`
+	CategoryID   uuid.UUID  `json:"category_id"`
+	NoteContains *string    `json:"note_contains,omitempty"`
+	NoteRegex    *string    `json:"note_regex,omitempty"`
+	PayeeID      *uuid.UUID `json:"payee_id,omitempty"`
+	WalletID     *uuid.UUID `json:"wallet_id,omitempty"`
+	MinAmount    *float64   `json:"min_amount,omitempty"`
+	MaxAmount    *float64   `json:"max_amount,omitempty"`
+	Enabled      bool       `json:"enabled"`
+	CreatedAt    time.Time  `json:"created_at"`
+}
+
+// RuleChange is one transaction a rule would categorise (dry run) or did categorise.
+type RuleChange struct {
+	TransactionID uuid.UUID `json:"transaction_id"`
+	OccurredAt    time.Time `json:"occurred_at"`
+	Amount        string    `json:"amount"`
+	Kind          string    `json:"kind"`
+	Note          *string   `json:"note,omitempty"`
+	CategoryID    uuid.UUID `json:"category_id"`
+	RuleID        uuid.UUID `json:"rule_id"`
+}
+
+// RuleRun summarises applying rules to uncategorised transactions.
+type RuleRun struct {
+	DryRun  bool         `json:"dry_run"`
+	Matched int          `json:"matched"`
+	Changes []RuleChange `json:"changes"`
+}
+
+// compiledRule is a rule ready for matching.
+type compiledRule struct {
+	CategoryRule
+	contains string
+	regex    *regexp.Regexp
+	kind     string
+}
+
+func (r compiledRule) matches(t Transaction) bool {
+	if t.Kind != r.kind {
+		return false
+	}
+	if r.WalletID != nil && *r.WalletID != t.WalletID {
+		return false
+	}
+	if r.PayeeID != nil && (t.PayeeID == nil || *r.PayeeID != *t.PayeeID) {
+		return false
+	}
+	note := ""
+	if t.Note != nil {
+		note = *t.Note
+	}
+	if r.contains != "" && !strings.Contains(strings.ToLower(note), r.contains) {
+		return false
+	}
+	if r.regex != nil && !r.regex.MatchString(note) {
+		return false
+	}
+	if r.MinAmount != nil || r.MaxAmount != nil {
+		amount, err := strconv.ParseFloat(t.Amount, 64)
+		if err != nil {
+			return false
+		}
+		if r.MinAmount != nil && amount < *r.MinAmount {
+			return false
+		}
+		if r.MaxAmount != nil && amount > *r.MaxAmount {
+			return false
+		}
+	}
+	return true
+}
+
+// ruleSet is a user's enabled rules in evaluation order.
+type ruleSet []compiledRule
+
+// match returns the first rule matching t, or nil.
+func (rs ruleSet) match(t Transaction) *compiledRule {
+	for i := range rs {
+		if rs[i].matches(t) {
+			return &rs[i]
+		}
+	}
+	return nil
+}
+
+// compileRules keeps enabled rules whose category still exists and is not archived.
+// Each rule only fires for transactions of its category's kind.
+func compileRules(rules []CategoryRule, categories []Category) ruleSet {
+	var out ruleSet
+	for _, r := range rules {
+		if !r.Enabled {
+			continue
+		}
+		c := findCategory(categories, r.CategoryID)
+		if c == nil || c.ArchivedAt != nil {
+			continue
+		}
+		cr := compiledRule{CategoryRule: r, kind: c.Kind}
+		if r.NoteContains != nil {
+			cr.contains = strings.ToLower(*r.NoteContains)
+		}
+		if r.NoteRegex != nil {
+			re, err := regexp.Compile(*r.NoteRegex)
+			if err != nil {
+				continue
+			}
+			cr.regex = re
+		}
+		out = append(out, cr)
+	}
+	return out
+}
+
+func (s *Service) loadRuleSet(ctx context.Context, userID uuid.UUID) (ruleSet, error) {
+	rules, err := s.repo.ListCategoryRules(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if len(rules) == 0 {
+		return nil, nil
+	}
+	categories, err := s.repo.ListCategories(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return compileRules(rules, categories), nil
+}
+
+// categorise sets t.CategoryID from the user's rules when t has no category and no splits.
+func (s *Service) categorise(ctx context.Context, t *Transaction) error {
+	if t.CategoryID != nil || len(t.Splits) > 0 {
+		return nil
+	}
+	rules, err := s.loadRuleSet(ctx, t.UserID)
+	if err != nil {
+		return err
+	}
+	if r := rules.match(*t); r != nil {
+		id := r.CategoryID
+		t.CategoryID = &id
+	}
+	return nil
+}
+
+// validateRule normalises r and checks its conditions and category.
+func (s *Service) validateRule(ctx context.Context, r *CategoryRule) error {
+	r.Name = strings.TrimSpace(r.Name)
+	if r.Name == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidRule)
+	}
+	if r.NoteContains != nil && strings.TrimSpace(*r.NoteContains) == "" {
+		r.NoteContains = nil
+	}
+	if r.NoteRegex != nil && *r.NoteRegex == "" {
+		r.NoteRegex = nil
+	}
+	if r.NoteRegex != nil {
+		if len(*r.NoteRegex) > maxRuleRegex {
+			return fmt.Errorf("%w: regex is longer than %d characters", ErrInvalidRule, maxRuleRegex)
+		}
+		if _, err := regexp.Compile(*r.NoteRegex); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidRule, err)
+		}
+	}
+	if r.NoteContains == nil && r.NoteRegex == nil && r.PayeeID == nil && r.WalletID == nil && r.MinAmount == nil && r.MaxAmount == nil {
+		return fmt.Errorf("%w: at least one condition is required", ErrInvalidRule)
+	}
+	if r.MinAmount != nil && r.MaxAmount != nil && *r.MinAmount > *r.MaxAmount {
+		return fmt.Errorf("%w: min_amount is greater than max_amount", ErrInvalidRule)
+	}
+
+	categories, err := s.repo.ListCategories(ctx, r.UserID)
+	if err != nil {
+		return err
+	}
+	if findCategory(categories, r.CategoryID) == nil {
+		return ErrCategoryNotFound
+	}
+	if r.WalletID != nil {
+		if _, err := s.repo.GetWallet(ctx, r.UserID, *r.WalletID); err != nil {
+			return err
+		}
+	}
+	return s.checkLabels(ctx, r.UserID, r.PayeeID, nil)
+}
+
+// CreateCategoryRule validates and stores a new rule.
+func (s *Service) CreateCategoryRule(ctx context.Context, r CategoryRule) (*CategoryRule, error) {
+	r.ID = uuid.New()
+	r.CreatedAt = time.Now()
+	if err := s.validateRule(ctx, &r); err != nil {
+		return nil, err
+	}
+	if err := s.repo.CreateCategoryRule(ctx, r); err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+// ListCategoryRules returns the user's rules in evaluation order.
+func (s *Service) ListCategoryRules(ctx context.Context, userID uuid.UUID) ([]CategoryRule, error) {
+	rules, err := s.repo.ListCategoryRules(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if rules == nil {
+		rules = []CategoryRule{}
+	}
+	return rules, nil
+}
+
+// UpdateCategoryRule replaces every field of an existing rule.
+func (s *Service) UpdateCategoryRule(ctx context.Context, r CategoryRule) (*CategoryRule, error) {
+	existing, err := s.repo.GetCategoryRule(ctx, r.UserID, r.ID)
+	if err != nil {
+		return nil, err
+	}
+	r.CreatedAt = existing.CreatedAt
+	if err := s.validateRule(ctx, &r); err != nil {
+		return nil, err
+	}
+	if err := s.repo.UpdateCategoryRule(ctx, r); err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+// GetCategoryRule returns one rule of the user.
+func (s *Service) GetCategoryRule(ctx context.Context, userID, ruleID uuid.UUID) (*CategoryRule, error) {
+	return s.repo.GetCategoryRule(ctx, userID, ruleID)
+}
+
+// DeleteCategoryRule removes a rule. Categories it assigned earlier stay.
+func (s *Service) DeleteCategoryRule(ctx context.Context, userID, ruleID uuid.UUID) error {
+	return s.repo.DeleteCategoryRule(ctx, userID, ruleID)
+}
+
+// ApplyCategoryRules runs every enabled rule over the user's uncategorised transactions.
+// With dryRun nothing is written.
+func (s *Service) ApplyCategoryRules(ctx context.Context, userID uuid.UUID, dryRun bool) (*RuleRun, error) {
+	rules, err := s.loadRuleSet(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return s.runRules(ctx, userID, rules, nil, dryRun)
+}
+
+// DryRunCategoryRule shows which uncategorised transactions candidate would categorise
+// if it were saved: its position among the existing rules is respected, so
+// transactions claimed by a higher-priority rule are not listed. candidate.ID may
+// name an existing rule, which it then replaces.
+func (s *Service) DryRunCategoryRule(ctx context.Context, candidate CategoryRule) (*RuleRun, error) {
+	if candidate.ID == uuid.Nil {
+		candidate.ID = uuid.New()
+		candidate.CreatedAt = time.Now()
+	}
+	candidate.Enabled = true
+	if err := s.validateRule(ctx, &candidate); err != nil {
+		return nil, err
+	}
+
+	existing, err := s.repo.ListCategoryRules(ctx, candidate.UserID)
+	if err != nil {
+		return nil, err
+	}
+	rules := make([]CategoryRule, 0, len(existing)+1)
+	inserted := false
+	for _, r := range existing {
+		if r.ID == candidate.ID {
+			continue
+		}
+		if !inserted && candidate.Priority < r.Priority {
+			rules = append(rules, candidate)
+			inserted = true
+		}
+		rules = append(rules, r)
+	}
+	if !inserted {
+		rules = append(rules, candidate)
+	}
+
+	categories, err := s.repo.ListCategories(ctx, candidate.UserID)
+	if err != nil {
+		return nil, err
+	}
+	return s.runRules(ctx, candidate.UserID, compileRules(rules, categories), &candidate.ID, true)
+}
+
+// runRules walks uncategorised transactions in id order. When only is set, changes by
+// other rules are left out of the result.
+func (s *Service) runRules(ctx context.Context, userID uuid.UUID, rules ruleSet, only *uuid.UUID, dryRun bool) (*RuleRun, error) {
+	run := &RuleRun{DryRun: dryRun, Changes: []RuleChange{}}
+	if len(rules) == 0 {
+		return run, nil
+	}
+
+	ctx = userChange(ctx, SourceRule, userID, only)
+	after := uuid.Nil
+	for {
+		txs, err := s.repo.UncategorisedTransactions(ctx, userID, after, ruleBatchSize)
+		if err != nil {
+			return nil, err
+		}
+		if len(txs) == 0 {
+			return run, nil
+		}
+		after = txs[len(txs)-1].ID
+
+		var changes []RuleChange
+		for _, t := range txs {
+			r := rules.match(t)
+			if r == nil || (only != nil && r.ID != *only) {
+				continue
+			}
+			changes = append(changes, RuleChange{
+				TransactionID: t.ID,
+				OccurredAt:    t.OccurredAt,
+				Amount:        t.Amount,
+				Kind:          t.Kind,
+				Note:          t.Note,
+				CategoryID:    r.CategoryID,
+				RuleID:        r.ID,
+			})
+		}
+		if !dryRun && len(changes) > 0 {
+			if err := s.repo.AssignCategories(ctx, userID, changes); err != nil {
+				return nil, err
+			}
+			s.forget(userID)
+		}
+		run.Matched += len(changes)
+		for _, c := range changes {
+			if len(run.Changes) >= maxRuleChanges {
+				break
+			}
+			run.Changes = append(run.Changes, c)
+		}
+	}
+}
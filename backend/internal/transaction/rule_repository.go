@@ -0,0 +1,183 @@
+package transaction
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+
+	"github.com/Jomesi149/Implementasi-LASTI/backend/internal/sqlutil"
+)
+
+const categoryRuleColumns = `id, user_id, name, priority, category_id, note_contains, note_regex, payee_id, wallet_id,
+	min_amount::FLOAT8, max_amount::FLOAT8, enabled, created_at`
+
+func scanCategoryRule(row rowScanner) (CategoryRule, error) {
+	var r CategoryRule
+	var contains, regex sql.NullString
+	var payeeID, walletID uuid.NullUUID
+	var minAmount, maxAmount sql.NullFloat64
+	err := row.Scan(&r.ID, &r.UserID, &r.Name, &r.Priority, &r.CategoryID, &contains, &regex, &payeeID, &walletID,
+		&minAmount, &maxAmount, &r.Enabled, &r.CreatedAt)
+	if err != nil {
+		return r, err
+	}
+	if contains.Valid {
+		s := contains.String
+		r.NoteContains = &s
+	}
+	if regex.Valid {
+		s := regex.String
+		r.NoteRegex = &s
+	}
+	if payeeID.Valid {
+		id := payeeID.UUID
+		r.PayeeID = &id
+	}
+	if walletID.Valid {
+		id := walletID.UUID
+		r.WalletID = &id
+	}
+	if minAmount.Valid {
+		v := minAmount.Float64
+		r.MinAmount = &v
+	}
+	if maxAmount.Valid {
+		v := maxAmount.Float64
+		r.MaxAmount = &v
+	}
+	return r, nil
+}
+
+func (r *SQLRepository) CreateCategoryRule(ctx context.Context, rule CategoryRule) error {
+	query := `INSERT INTO finance.category_rules (id, user_id, name, priority, category_id, note_contains, note_regex,
+		payee_id, wallet_id, min_amount, max_amount, enabled, created_at, updated_at)
+		VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13,NOW())`
+	_, err := r.db.ExecContext(ctx, query, rule.ID, rule.UserID, rule.Name, rule.Priority, rule.CategoryID, rule.NoteContains,
+		rule.NoteRegex, rule.PayeeID, rule.WalletID, rule.MinAmount, rule.MaxAmount, rule.Enabled, rule.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("insert category rule: %w", err)
+	}
+	return nil
+}
+
+// ListCategoryRules returns all rules of the user in evaluation order.
+func (r *SQLRepository) ListCategoryRules(ctx context.Context, userID uuid.UUID) ([]CategoryRule, error) {
+	query := `SELECT ` + categoryRuleColumns + ` FROM finance.category_rules WHERE user_id = $1 ORDER BY priority ASC, created_at ASC`
+	rows, err := r.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("list category rules: %w", err)
+	}
+	defer rows.Close()
+
+	var out []CategoryRule
+	for rows.Next() {
+		rule, err := scanCategoryRule(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, rule)
+	}
+	return out, rows.Err()
+}
+
+// UpdateCategoryRule saves every editable field.
+func (r *SQLRepository) UpdateCategoryRule(ctx context.Context, rule CategoryRule) error {
+	query := `UPDATE finance.category_rules SET name = $3, priority = $4, category_id = $5, note_contains = $6,
+		note_regex = $7, payee_id = $8, wallet_id = $9, min_amount = $10, max_amount = $11, enabled = $12, updated_at = NOW()
+		WHERE id = $1 AND user_id = $2`
+	res, err := r.db.ExecContext(ctx, query, rule.ID, rule.UserID, rule.Name, rule.Priority, rule.CategoryID, rule.NoteContains,
+		rule.NoteRegex, rule.PayeeID, rule.WalletID, rule.MinAmount, rule.MaxAmount, rule.Enabled)
+	if err != nil {
+		return fmt.Errorf("update category rule: %w", err)
+	}
+	return sqlutil.ExpectAffected(res, ErrRuleNotFound)
+}
+
+func (r *SQLRepository) GetCategoryRule(ctx context.Context, userID, ruleID uuid.UUID) (*CategoryRule, error) {
+	query := `SELECT ` + categoryRuleColumns + ` FROM finance.category_rules WHERE id = $1 AND user_id = $2`
+	rule, err := scanCategoryRule(r.db.QueryRowContext(ctx, query, ruleID, userID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrRuleNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get category rule: %w", err)
+	}
+	return &rule, nil
+}
+
+func (r *SQLRepository) DeleteCategoryRule(ctx context.Context, userID, ruleID uuid.UUID) error {
+	res, err := r.db.ExecContext(ctx, `DELETE FROM finance.category_rules WHERE id = $1 AND user_id = $2`, ruleID, userID)
+	if err != nil {
+		return fmt.Errorf("delete category rule: %w", err)
+	}
+	return sqlutil.ExpectAffected(res, ErrRuleNotFound)
+}
+
+// UncategorisedTransactions returns up to limit transactions without a category or
+// splits, ordered by id and starting after the given id.
+func (r *SQLRepository) UncategorisedTransactions(ctx context.Context, userID, after uuid.UUID, limit int) ([]Transaction, error) {
+	query := fmt.Sprintf(`SELECT %s FROM finance.transactions t
+		WHERE t.user_id = $1 AND t.category_id IS NULL AND t.deleted_at IS NULL AND t.transfer_id IS NULL AND t.debt_id IS NULL AND t.id > $2
+		AND NOT EXISTS (SELECT 1 FROM finance.transaction_splits s WHERE s.transaction_id = t.id)
+		ORDER BY t.id ASC LIMIT $3`, transactionColumns)
+	rows, err := r.db.QueryContext(ctx, query, userID, after, limit)
+	if err != nil {
+		return nil, fmt.Errorf("list uncategorised: %w", err)
+	}
+	defer rows.Close()
+
+	var out []Transaction
+	for rows.Next() {
+		t, err := scanTransaction(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, t)
+	}
+	return out, rows.Err()
+}
+
+// AssignCategories sets the category of each changed transaction in one statement. Rows
+// that gained a category in the meantime are left alone.
+func (r *SQLRepository) AssignCategories(ctx context.Context, userID uuid.UUID, changes []RuleChange) (err error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	txIDs := make([]uuid.UUID, len(changes))
+	ids := make([]string, len(changes))
+	categories := make([]string, len(changes))
+	for i, c := range changes {
+		txIDs[i] = c.TransactionID
+		ids[i] = c.TransactionID.String()
+		categories[i] = c.CategoryID.String()
+	}
+	before, err := snapshotHistory(ctx, tx, txIDs)
+	if err != nil {
+		return err
+	}
+	query := `UPDATE finance.transactions t SET category_id = v.category_id
+		FROM (SELECT UNNEST($2::uuid[]) AS id, UNNEST($3::uuid[]) AS category_id) v
+		WHERE t.id = v.id AND t.user_id = $1 AND t.category_id IS NULL AND t.deleted_at IS NULL AND t.transfer_id IS NULL AND t.debt_id IS NULL`
+	if _, err = tx.ExecContext(ctx, query, userID, ids, categories); err != nil {
+		return fmt.Errorf("assign categories: %w", err)
+	}
+	if err = recordChanges(ctx, tx, txIDs, before); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("commit tx: %w", err)
+	}
+	return nil
+}
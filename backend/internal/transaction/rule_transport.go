@@ -0,0 +1,198 @@
+package transaction
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"github.com/Jomesi149/Implementasi-LASTI/backend/pkg/response"
+)
+
+func (h *HTTPHandler) registerRuleRoutes(r chi.Router) {
+	r.Route("/category-rules", func(r chi.Router) {
+		r.Post("/", h.handleCreateCategoryRule)
+		r.Get("/", h.handleListCategoryRules)
+		r.Post("/dry-run", h.handleDryRunCategoryRule)
+		r.Post("/apply", h.handleApplyCategoryRules)
+		r.Put("/{id}", h.handleUpdateCategoryRule)
+		r.Delete("/{id}", h.handleDeleteCategoryRule)
+		r.Post("/{id}/dry-run", h.handleDryRunSavedCategoryRule)
+	})
+}
+
+type categoryRuleReq struct {
+	Name         string   `json:"name"`
+	Priority     *int     `json:"priority"`
+	CategoryID   string   `json:"category_id"`
+	NoteContains *string  `json:"note_contains"`
+	NoteRegex    *string  `json:"note_regex"`
+	PayeeID      *string  `json:"payee_id"`
+	WalletID     *string  `json:"wallet_id"`
+	MinAmount    *float64 `json:"min_amount"`
+	MaxAmount    *float64 `json:"max_amount"`
+	Enabled      *bool    `json:"enabled"`
+}
+
+// decodeCategoryRule reads a rule payload; priority defaults to 100 and enabled to true.
+func decodeCategoryRule(w http.ResponseWriter, r *http.Request, userID uuid.UUID) (CategoryRule, bool) {
+	var req categoryRuleReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.Error(w, http.StatusBadRequest, "invalid payload")
+		return CategoryRule{}, false
+	}
+	categoryID, err := uuid.Parse(req.CategoryID)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "invalid category id")
+		return CategoryRule{}, false
+	}
+	payeeID, err := parseOptionalUUID(req.PayeeID)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "invalid payee id")
+		return CategoryRule{}, false
+	}
+	walletID, err := parseOptionalUUID(req.WalletID)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "invalid wallet id")
+		return CategoryRule{}, false
+	}
+
+	rule := CategoryRule{
+		UserID:       userID,
+		Name:         req.Name,
+		Priority:     defaultRulePriority,
+		CategoryID:   categoryID,
+		NoteContains: req.NoteContains,
+		NoteRegex:    req.NoteRegex,
+		PayeeID:      payeeID,
+		WalletID:     walletID,
+		MinAmount:    req.MinAmount,
+		MaxAmount:    req.MaxAmount,
+		Enabled:      true,
+	}
+	if req.Priority != nil {
+		rule.Priority = *req.Priority
+	}
+	if req.Enabled != nil {
+		rule.Enabled = *req.Enabled
+	}
+	return rule, true
+}
+
+func (h *HTTPHandler) handleCreateCategoryRule(w http.ResponseWriter, r *http.Request) {
+	uid, err := getUserIDFromHeader(r)
+	if err != nil {
+		response.Error(w, http.StatusUnauthorized, "missing X-User-ID header")
+		return
+	}
+	rule, ok := decodeCategoryRule(w, r, uid)
+	if !ok {
+		return
+	}
+	created, err := h.service.CreateCategoryRule(r.Context(), rule)
+	if err != nil {
+		response.Error(w, errorStatus(err), err.Error())
+		return
+	}
+	response.JSON(w, http.StatusCreated, created)
+}
+
+func (h *HTTPHandler) handleListCategoryRules(w http.ResponseWriter, r *http.Request) {
+	uid, err := getUserIDFromHeader(r)
+	if err != nil {
+		response.Error(w, http.StatusUnauthorized, "missing X-User-ID header")
+		return
+	}
+	rules, err := h.service.ListCategoryRules(r.Context(), uid)
+	if err != nil {
+		response.Error(w, errorStatus(err), err.Error())
+		return
+	}
+	response.JSON(w, http.StatusOK, rules)
+}
+
+func (h *HTTPHandler) handleUpdateCategoryRule(w http.ResponseWriter, r *http.Request) {
+	uid, id, ok := idParams(w, r, "rule")
+	if !ok {
+		return
+	}
+	rule, ok := decodeCategoryRule(w, r, uid)
+	if !ok {
+		return
+	}
+	rule.ID = id
+	updated, err := h.service.UpdateCategoryRule(r.Context(), rule)
+	if err != nil {
+		response.Error(w, errorStatus(err), err.Error())
+		return
+	}
+	response.JSON(w, http.StatusOK, updated)
+}
+
+func (h *HTTPHandler) handleDeleteCategoryRule(w http.ResponseWriter, r *http.Request) {
+	uid, id, ok := idParams(w, r, "rule")
+	if !ok {
+		return
+	}
+	if err := h.service.DeleteCategoryRule(r.Context(), uid, id); err != nil {
+		response.Error(w, errorStatus(err), err.Error())
+		return
+	}
+	response.JSON(w, http.StatusOK, map[string]string{"status": "rule deleted"})
+}
+
+// handleDryRunCategoryRule previews an unsaved rule sent in the body.
+func (h *HTTPHandler) handleDryRunCategoryRule(w http.ResponseWriter, r *http.Request) {
+	uid, err := getUserIDFromHeader(r)
+	if err != nil {
+		response.Error(w, http.StatusUnauthorized, "missing X-User-ID header")
+		return
+	}
+	rule, ok := decodeCategoryRule(w, r, uid)
+	if !ok {
+		return
+	}
+	run, err := h.service.DryRunCategoryRule(r.Context(), rule)
+	if err != nil {
+		response.Error(w, errorStatus(err), err.Error())
+		return
+	}
+	response.JSON(w, http.StatusOK, run)
+}
+
+// handleDryRunSavedCategoryRule previews a stored rule, even a disabled one.
+func (h *HTTPHandler) handleDryRunSavedCategoryRule(w http.ResponseWriter, r *http.Request) {
+	uid, id, ok := idParams(w, r, "rule")
+	if !ok {
+		return
+	}
+	rule, err := h.service.GetCategoryRule(r.Context(), uid, id)
+	if err != nil {
+		response.Error(w, errorStatus(err), err.Error())
+		return
+	}
+	run, err := h.service.DryRunCategoryRule(r.Context(), *rule)
+	if err != nil {
+		response.Error(w, errorStatus(err), err.Error())
+		return
+	}
+	response.JSON(w, http.StatusOK, run)
+}
+
+// handleApplyCategoryRules re-applies all rules to uncategorised transactions;
+// ?dry_run=true only reports what would change.
+func (h *HTTPHandler) handleApplyCategoryRules(w http.ResponseWriter, r *http.Request) {
+	uid, err := getUserIDFromHeader(r)
+	if err != nil {
+		response.Error(w, http.StatusUnauthorized, "missing X-User-ID header")
+		return
+	}
+	dryRun := r.URL.Query().Get("dry_run") == "true"
+	run, err := h.service.ApplyCategoryRules(r.Context(), uid, dryRun)
+	if err != nil {
+		response.Error(w, errorStatus(err), err.Error())
+		return
+	}
+	response.JSON(w, http.StatusOK, run)
+}
@@ -2,46 +2,185 @@ package transaction
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
+
+	"github.com/Jomesi149/Implementasi-LASTI/backend/internal/currency"
+	"github.com/Jomesi149/Implementasi-LASTI/backend/internal/sqlutil"
+)
+
+var (
+	// ErrCategoryNotFound is returned when the category does not exist for the user.
+	ErrCategoryNotFound = errors.New("category_not_found")
+	// ErrInvalidCategory indicates a category payload that breaks naming, kind or hierarchy rules.
+	ErrInvalidCategory = errors.New("invalid_category")
+	// ErrInvalidTransaction indicates a transaction payload with a bad kind or amount.
+	ErrInvalidTransaction = errors.New("invalid_transaction")
+	// ErrDuplicateTransaction is returned when a transaction with the same id already exists.
+	ErrDuplicateTransaction = errors.New("duplicate_transaction")
+	// ErrInvalidFilter indicates transaction list parameters that cannot be applied.
+	ErrInvalidFilter = errors.New("invalid_filter")
 )
 
 type Service struct {
-	repo Repository
+	repo           Repository
+	suggest        *suggester
+	trashRetention time.Duration
+	currencies     *currency.Service
 }
 
 type ServiceDeps struct {
 	Repo Repository
+	// TrashRetention is how long deleted records can be restored; zero means
+	// DefaultTrashRetention.
+	TrashRetention time.Duration
+	// Currencies supplies base currencies and exchange rates. Without it new wallets
+	// default to currency.DefaultCurrency and transfers need an explicit to_amount
+	// between currencies.
+	Currencies *currency.Service
 }
 
 func NewService(d ServiceDeps) *Service {
-	return &Service{repo: d.Repo}
+	retention := d.TrashRetention
+	if retention <= 0 {
+		retention = DefaultTrashRetention
+	}
+	return &Service{repo: d.Repo, suggest: newSuggester(), trashRetention: retention, currencies: d.Currencies}
 }
 
-// CreateWallet registers a new wallet for a user.
-func (s *Service) CreateWallet(ctx context.Context, userID uuid.UUID, kind, name, initialBalance string) (*Wallet, error) {
-	w := Wallet{ID: uuid.New(), UserID: userID, Type: kind, Name: name, Balance: initialBalance, CreatedAt: time.Now()}
+// CreateWallet registers a new wallet for a user. An empty code means the user's base
+// currency.
+func (s *Service) CreateWallet(ctx context.Context, userID uuid.UUID, kind, name, initialBalance, code string) (*Wallet, error) {
+	if kind == WalletCreditCard {
+		return nil, fmt.Errorf("%w: credit card wallets are created with POST /credit-cards", ErrInvalidCreditCard)
+	}
+	code, err := s.walletCurrency(ctx, userID, code)
+	if err != nil {
+		return nil, err
+	}
+	w := Wallet{ID: uuid.New(), UserID: userID, Type: kind, Name: name, Balance: initialBalance, Currency: code, CreatedAt: time.Now()}
 	if err := s.repo.CreateWallet(ctx, w); err != nil {
 		return nil, fmt.Errorf("create wallet: %w", err)
 	}
 	return &w, nil
 }
 
+// walletCurrency validates the currency of a new wallet, defaulting to the user's base
+// currency.
+func (s *Service) walletCurrency(ctx context.Context, userID uuid.UUID, code string) (string, error) {
+	if strings.TrimSpace(code) != "" {
+		return currency.Normalize(code)
+	}
+	if s.currencies == nil {
+		return currency.DefaultCurrency, nil
+	}
+	return s.currencies.BaseCurrency(ctx, userID)
+}
+
 func (s *Service) ListWallets(ctx context.Context, userID uuid.UUID) ([]Wallet, error) {
 	return s.repo.ListWallets(ctx, userID)
 }
 
-func (s *Service) CreateCategory(ctx context.Context, userID uuid.UUID, name, kind string) (*Category, error) {
-	c := Category{ID: uuid.New(), UserID: userID, Name: name, Kind: kind, CreatedAt: time.Now()}
+func (s *Service) CreateCategory(ctx context.Context, userID uuid.UUID, name, kind string, parentID *uuid.UUID) (*Category, error) {
+	c := Category{ID: uuid.New(), UserID: userID, ParentID: parentID, Name: strings.TrimSpace(name), Kind: kind, CreatedAt: time.Now()}
+	existing, err := s.repo.ListCategories(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateCategory(c, existing); err != nil {
+		return nil, err
+	}
 	if err := s.repo.CreateCategory(ctx, c); err != nil {
 		return nil, fmt.Errorf("create category: %w", err)
 	}
 	return &c, nil
 }
 
-func (s *Service) ListCategories(ctx context.Context, userID uuid.UUID) ([]Category, error) {
+// UpdateCategory renames a category, changes its kind or moves it under another parent.
+func (s *Service) UpdateCategory(ctx context.Context, userID, categoryID uuid.UUID, name, kind string, parentID *uuid.UUID) (*Category, error) {
+	existing, err := s.repo.ListCategories(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	current := findCategory(existing, categoryID)
+	if current == nil {
+		return nil, ErrCategoryNotFound
+	}
+
+	c := *current
+	c.Name = strings.TrimSpace(name)
+	c.Kind = kind
+	c.ParentID = parentID
+	if err := validateCategory(c, existing); err != nil {
+		return nil, err
+	}
+	if err := s.repo.UpdateCategory(ctx, c); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// ArchiveCategory hides a category from lists without touching its transactions.
+func (s *Service) ArchiveCategory(ctx context.Context, userID, categoryID uuid.UUID) error {
+	now := time.Now()
+	return s.repo.SetCategoryArchived(ctx, userID, categoryID, &now)
+}
+
+// UnarchiveCategory makes an archived category selectable again.
+func (s *Service) UnarchiveCategory(ctx context.Context, userID, categoryID uuid.UUID) error {
+	return s.repo.SetCategoryArchived(ctx, userID, categoryID, nil)
+}
+
+// MergeCategories folds source into target: transactions, budgets and sub-categories
+// are re-pointed to target and source is removed.
+func (s *Service) MergeCategories(ctx context.Context, userID, sourceID, targetID uuid.UUID) error {
+	if sourceID == targetID {
+		return fmt.Errorf("%w: cannot merge a category into itself", ErrInvalidCategory)
+	}
+	existing, err := s.repo.ListCategories(ctx, userID)
+	if err != nil {
+		return err
+	}
+	source := findCategory(existing, sourceID)
+	target := findCategory(existing, targetID)
+	if source == nil || target == nil {
+		return ErrCategoryNotFound
+	}
+	if source.Kind != target.Kind {
+		return fmt.Errorf("%w: cannot merge %q category into %q category", ErrInvalidCategory, source.Kind, target.Kind)
+	}
+	ctx = userChange(ctx, SourceMerge, userID, nil)
+	if err := s.repo.MergeCategories(ctx, userID, sourceID, targetID); err != nil {
+		return err
+	}
+	s.forget(userID)
+	return nil
+}
+
+func (s *Service) ListCategories(ctx context.Context, userID uuid.UUID, includeArchived bool) ([]Category, error) {
+	categories, err := s.listAllCategories(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if includeArchived {
+		return categories, nil
+	}
+
+	active := make([]Category, 0, len(categories))
+	for _, c := range categories {
+		if c.ArchivedAt == nil {
+			active = append(active, c)
+		}
+	}
+	return active, nil
+}
+
+func (s *Service) listAllCategories(ctx context.Context, userID uuid.UUID) ([]Category, error) {
 	categories, err := s.repo.ListCategories(ctx, userID)
 	if err != nil {
 		return nil, err
@@ -79,14 +218,197 @@ func (s *Service) ListCategories(ctx context.Context, userID uuid.UUID) ([]Categ
 	return categories, nil
 }
 
-func (s *Service) CreateTransaction(ctx context.Context, userID uuid.UUID, walletID uuid.UUID, categoryID *uuid.UUID, amount string, kind string, note *string, occurredAt time.Time) (*Transaction, error) {
-	t := Transaction{ID: uuid.New(), UserID: userID, WalletID: walletID, CategoryID: categoryID, Amount: amount, Kind: kind, Note: note, OccurredAt: occurredAt, CreatedAt: time.Now()}
+func validateFilter(f TransactionFilter) error {
+	if f.Kind != "" && f.Kind != "in" && f.Kind != "out" {
+		return fmt.Errorf("%w: kind must be in or out", ErrInvalidFilter)
+	}
+	if f.Status != "" && f.Status != StatusPlanned && f.Status != StatusPosted {
+		return fmt.Errorf("%w: status must be planned or posted", ErrInvalidFilter)
+	}
+	if f.From != nil && f.To != nil && f.To.Before(*f.From) {
+		return fmt.Errorf("%w: to must not be before from", ErrInvalidFilter)
+	}
+	return nil
+}
+
+// validateCategory enforces the category rules: non-empty name, in/out kind, and at most
+// one level of nesting under a parent of the same kind.
+func validateCategory(c Category, existing []Category) error {
+	if c.Name == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidCategory)
+	}
+	if c.Kind != "in" && c.Kind != "out" {
+		return fmt.Errorf("%w: kind must be in or out", ErrInvalidCategory)
+	}
+
+	// Sub-kategori harus ikut kind induknya
+	if current := findCategory(existing, c.ID); current != nil && current.Kind != c.Kind && hasSubCategories(existing, c.ID) {
+		return fmt.Errorf("%w: cannot change kind of a category with sub-categories", ErrInvalidCategory)
+	}
+
+	if c.ParentID == nil {
+		return nil
+	}
+	if *c.ParentID == c.ID {
+		return fmt.Errorf("%w: category cannot be its own parent", ErrInvalidCategory)
+	}
+	parent := findCategory(existing, *c.ParentID)
+	if parent == nil {
+		return fmt.Errorf("%w: parent category not found", ErrInvalidCategory)
+	}
+	if parent.ParentID != nil {
+		return fmt.Errorf("%w: sub-categories cannot have their own sub-categories", ErrInvalidCategory)
+	}
+	if parent.Kind != c.Kind {
+		return fmt.Errorf("%w: parent category must have the same kind", ErrInvalidCategory)
+	}
+	if hasSubCategories(existing, c.ID) {
+		return fmt.Errorf("%w: a category with sub-categories cannot become a sub-category", ErrInvalidCategory)
+	}
+	return nil
+}
+
+func hasSubCategories(categories []Category, id uuid.UUID) bool {
+	for _, c := range categories {
+		if c.ParentID != nil && *c.ParentID == id {
+			return true
+		}
+	}
+	return false
+}
+
+func findCategory(categories []Category, id uuid.UUID) *Category {
+	for i := range categories {
+		if categories[i].ID == id {
+			return &categories[i]
+		}
+	}
+	return nil
+}
+
+// CreateTransaction records a transaction and applies it to the wallet balance. A
+// transaction dated after today is recorded as planned and leaves the balance alone
+// until it is posted. A transaction without a category is categorised by the user's
+// rules. It returns ErrDuplicateTransaction when in.ID already exists.
+func (s *Service) CreateTransaction(ctx context.Context, in NewTransaction) (*Transaction, error) {
+	if in.Kind != "in" && in.Kind != "out" {
+		return nil, fmt.Errorf("%w: kind must be in or out", ErrInvalidTransaction)
+	}
+	if amount, err := strconv.ParseFloat(in.Amount, 64); err != nil || amount <= 0 {
+		return nil, fmt.Errorf("%w: amount must be a positive number", ErrInvalidTransaction)
+	}
+	if _, err := s.repo.GetWallet(ctx, in.UserID, in.WalletID); err != nil {
+		return nil, err
+	}
+	splits, err := s.prepareSplits(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+	if len(splits) > 0 {
+		in.CategoryID = nil
+	}
+	in.TagIDs = uniqueIDs(in.TagIDs)
+	if err := s.checkLabels(ctx, in.UserID, in.PayeeID, in.TagIDs); err != nil {
+		return nil, err
+	}
+
+	id := in.ID
+	if id == uuid.Nil {
+		id = uuid.New()
+	}
+	t := Transaction{ID: id, UserID: in.UserID, WalletID: in.WalletID, CategoryID: in.CategoryID, Amount: in.Amount, Kind: in.Kind, Note: in.Note, OccurredAt: in.OccurredAt, Splits: splits, PayeeID: in.PayeeID, TagIDs: in.TagIDs, CreatedAt: time.Now()}
+	schedule(&t, time.Now())
+	if t.Planned && in.AutoPost != nil {
+		t.AutoPost = *in.AutoPost
+	}
+	if err := s.categorise(ctx, &t); err != nil {
+		return nil, err
+	}
+	ctx = userChange(ctx, SourceAPI, in.UserID, nil)
+	t.CreatedBy = in.UserID
+	if actor := changeFrom(ctx).ActorID; actor != nil {
+		t.CreatedBy = *actor
+	}
 	if err := s.repo.CreateTransaction(ctx, t); err != nil {
 		return nil, fmt.Errorf("create transaction: %w", err)
 	}
+	s.learn(t)
 	return &t, nil
 }
 
-func (s *Service) ListTransactions(ctx context.Context, userID uuid.UUID, limit int) ([]Transaction, error) {
-	return s.repo.ListTransactions(ctx, userID, limit)
+// prepareSplits validates the split lines of in: at least two, each with a category of
+// the transaction's kind and a positive amount, summing exactly to the total.
+func (s *Service) prepareSplits(ctx context.Context, in NewTransaction) ([]Split, error) {
+	if len(in.Splits) == 0 {
+		return nil, nil
+	}
+	if len(in.Splits) < 2 {
+		return nil, fmt.Errorf("%w: a split needs at least two lines", ErrInvalidTransaction)
+	}
+	categories, err := s.listAllCategories(ctx, in.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	total, _ := sqlutil.ToCents(in.Amount)
+	var sum int64
+	out := make([]Split, len(in.Splits))
+	for i, line := range in.Splits {
+		if line.CategoryID == nil {
+			return nil, fmt.Errorf("%w: split line %d has no category", ErrInvalidTransaction, i+1)
+		}
+		c := findCategory(categories, *line.CategoryID)
+		if c == nil {
+			return nil, fmt.Errorf("%w: split line %d", ErrCategoryNotFound, i+1)
+		}
+		if c.Kind != in.Kind {
+			return nil, fmt.Errorf("%w: split line %d category is not an %s category", ErrInvalidTransaction, i+1, in.Kind)
+		}
+		cents, ok := sqlutil.ToCents(line.Amount)
+		if !ok || cents <= 0 {
+			return nil, fmt.Errorf("%w: split line %d amount must be a positive number", ErrInvalidTransaction, i+1)
+		}
+		sum += cents
+		out[i] = Split{ID: uuid.New(), CategoryID: line.CategoryID, Amount: line.Amount, Note: line.Note}
+	}
+	if sum != total {
+		return nil, fmt.Errorf("%w: split lines sum to %.2f, expected %.2f", ErrInvalidTransaction, float64(sum)/100, float64(total)/100)
+	}
+	return out, nil
+}
+
+// GetTransaction returns a transaction owned by userID.
+func (s *Service) GetTransaction(ctx context.Context, userID, transactionID uuid.UUID) (*Transaction, error) {
+	return s.repo.GetTransaction(ctx, userID, transactionID)
+}
+
+// GetWallet returns a wallet owned by userID.
+func (s *Service) GetWallet(ctx context.Context, userID, walletID uuid.UUID) (*Wallet, error) {
+	return s.repo.GetWallet(ctx, userID, walletID)
+}
+
+// ListTransactions returns one page of transactions matching f. NextCursor is empty
+// on the last page.
+func (s *Service) ListTransactions(ctx context.Context, userID uuid.UUID, f TransactionFilter) (*TransactionPage, error) {
+	if err := validateFilter(f); err != nil {
+		return nil, err
+	}
+
+	// Ambil satu baris ekstra untuk tahu apakah masih ada halaman berikutnya
+	limit := clampLimit(f.Limit)
+	f.Limit = limit + 1
+	items, err := s.repo.ListTransactions(ctx, userID, f)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &TransactionPage{Items: items}
+	if len(items) > limit {
+		page.Items = items[:limit]
+		page.NextCursor = encodeCursor(page.Items[limit-1])
+	}
+	if page.Items == nil {
+		page.Items = []Transaction{}
+	}
+	return page, nil
 }
@@ -0,0 +1,290 @@
+package transaction
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// maxTrainingSamples bounds how much history one model is trained from; the most
+	// recent lines are used.
+	maxTrainingSamples = 5000
+	// suggestModelTTL forces a retrain so edits made elsewhere (other instances, direct
+	// SQL) are eventually picked up.
+	suggestModelTTL        = 30 * time.Minute
+	defaultSuggestionLimit = 5
+	maxSuggestionLimit     = 20
+)
+
+// ErrInvalidSuggestion indicates a suggestion query with nothing to classify.
+var ErrInvalidSuggestion = errors.New("invalid_suggestion_query")
+
+// CategorySuggestion is one ranked category; the confidences of a response sum to at
+// most 1.
+type CategorySuggestion struct {
+	CategoryID uuid.UUID `json:"category_id"`
+	Name       string    `json:"name"`
+	Kind       string    `json:"kind"`
+	Confidence float64   `json:"confidence"`
+}
+
+// SuggestQuery describes a transaction being typed. Kind, when set, limits the result
+// to categories of that kind.
+type SuggestQuery struct {
+	UserID  uuid.UUID
+	Note    string
+	Amount  string
+	Kind    string
+	PayeeID *uuid.UUID
+	Limit   int
+}
+
+// trainingSample is one categorised line of history.
+type trainingSample struct {
+	CategoryID uuid.UUID
+	Note       *string
+	PayeeID    *uuid.UUID
+	Amount     string
+}
+
+// classifier is a multinomial naive Bayes model over note words, the payee and the
+// order of magnitude of the amount.
+type classifier struct {
+	docs    map[uuid.UUID]int
+	counts  map[uuid.UUID]map[string]int
+	totals  map[uuid.UUID]int
+	vocab   map[string]int
+	samples int
+	builtAt time.Time
+}
+
+func newClassifier() *classifier {
+	return &classifier{
+		docs:    make(map[uuid.UUID]int),
+		counts:  make(map[uuid.UUID]map[string]int),
+		totals:  make(map[uuid.UUID]int),
+		vocab:   make(map[string]int),
+		builtAt: time.Now(),
+	}
+}
+
+func (c *classifier) observe(categoryID uuid.UUID, features []string) {
+	if len(features) == 0 {
+		return
+	}
+	c.docs[categoryID]++
+	c.samples++
+	counts := c.counts[categoryID]
+	if counts == nil {
+		counts = make(map[string]int)
+		c.counts[categoryID] = counts
+	}
+	for _, f := range features {
+		counts[f]++
+		c.totals[categoryID]++
+		c.vocab[f]++
+	}
+}
+
+// scores returns the posterior probability of each allowed category given features,
+// using Laplace smoothing. Features never seen in training carry no information and
+// are ignored.
+func (c *classifier) scores(features []string, allowed func(uuid.UUID) bool) map[uuid.UUID]float64 {
+	logs := make(map[uuid.UUID]float64)
+	classes := len(c.docs)
+	vocab := float64(len(c.vocab))
+	for id, docs := range c.docs {
+		if !allowed(id) {
+			continue
+		}
+		score := math.Log(float64(docs+1) / float64(c.samples+classes))
+		for _, f := range features {
+			if c.vocab[f] == 0 {
+				continue
+			}
+			score += math.Log(float64(c.counts[id][f]+1) / (float64(c.totals[id]) + vocab))
+		}
+		logs[id] = score
+	}
+
+	// Softmax dengan pengurangan nilai maksimum agar exp tidak underflow
+	best := math.Inf(-1)
+	for _, v := range logs {
+		best = math.Max(best, v)
+	}
+	var sum float64
+	for id, v := range logs {
+		logs[id] = math.Exp(v - best)
+		sum += logs[id]
+	}
+	for id := range logs {
+		logs[id] /= sum
+	}
+	return logs
+}
+
+// suggestFeatures turns a transaction into classifier tokens: lower-cased note words of
+// two or more letters, the payee and an amount bucket.
+func suggestFeatures(note string, payeeID *uuid.UUID, amount string) []string {
+	var out []string
+	words := strings.FieldsFunc(strings.ToLower(note), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for _, w := range words {
+		if len([]rune(w)) < 2 || isNumber(w) {
+			continue
+		}
+		out = append(out, "w:"+w)
+	}
+	if payeeID != nil {
+		out = append(out, "p:"+payeeID.String())
+	}
+	if v, err := strconv.ParseFloat(amount, 64); err == nil && v > 0 {
+		// Dua bucket per kelipatan sepuluh: 10-31, 32-99, 100-316, ...
+		out = append(out, "a:"+strconv.Itoa(int(math.Floor(math.Log10(v)*2))))
+	}
+	return out
+}
+
+func isNumber(s string) bool {
+	for _, r := range s {
+		if !unicode.IsDigit(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// suggester caches one classifier per user. Models are trained lazily from history,
+// updated as transactions are saved and dropped when categories are reshuffled.
+type suggester struct {
+	mu     sync.Mutex
+	models map[uuid.UUID]*classifier
+}
+
+func newSuggester() *suggester {
+	return &suggester{models: make(map[uuid.UUID]*classifier)}
+}
+
+// model returns the user's classifier, training it when missing or stale.
+func (s *Service) model(ctx context.Context, userID uuid.UUID) (*classifier, error) {
+	s.suggest.mu.Lock()
+	m := s.suggest.models[userID]
+	s.suggest.mu.Unlock()
+	if m != nil && time.Since(m.builtAt) < suggestModelTTL {
+		return m, nil
+	}
+
+	samples, err := s.repo.TrainingSamples(ctx, userID, maxTrainingSamples)
+	if err != nil {
+		return nil, err
+	}
+	m = newClassifier()
+	for _, sample := range samples {
+		note := ""
+		if sample.Note != nil {
+			note = *sample.Note
+		}
+		m.observe(sample.CategoryID, suggestFeatures(note, sample.PayeeID, sample.Amount))
+	}
+
+	s.suggest.mu.Lock()
+	s.suggest.models[userID] = m
+	s.suggest.mu.Unlock()
+	return m, nil
+}
+
+// learn adds a freshly saved transaction to the user's model, if one is loaded.
+func (s *Service) learn(t Transaction) {
+	s.suggest.mu.Lock()
+	defer s.suggest.mu.Unlock()
+	m := s.suggest.models[t.UserID]
+	if m == nil {
+		return
+	}
+	note := ""
+	if t.Note != nil {
+		note = *t.Note
+	}
+	if t.CategoryID != nil {
+		m.observe(*t.CategoryID, suggestFeatures(note, t.PayeeID, t.Amount))
+	}
+	for _, sp := range t.Splits {
+		if sp.CategoryID != nil {
+			m.observe(*sp.CategoryID, suggestFeatures(note, t.PayeeID, sp.Amount))
+		}
+	}
+}
+
+// forget drops the user's model so the next suggestion retrains from history.
+func (s *Service) forget(userID uuid.UUID) {
+	s.suggest.mu.Lock()
+	delete(s.suggest.models, userID)
+	s.suggest.mu.Unlock()
+}
+
+// SuggestCategories ranks the user's active categories for a transaction being typed,
+// learning from the user's own categorised history.
+func (s *Service) SuggestCategories(ctx context.Context, q SuggestQuery) ([]CategorySuggestion, error) {
+	if q.Kind != "" && q.Kind != "in" && q.Kind != "out" {
+		return nil, fmt.Errorf("%w: kind must be in or out", ErrInvalidSuggestion)
+	}
+	if q.Amount != "" {
+		if v, err := strconv.ParseFloat(q.Amount, 64); err != nil || v <= 0 {
+			return nil, fmt.Errorf("%w: amount must be a positive number", ErrInvalidSuggestion)
+		}
+	}
+	features := suggestFeatures(q.Note, q.PayeeID, q.Amount)
+	if len(features) == 0 {
+		return nil, fmt.Errorf("%w: give a note, payee or amount", ErrInvalidSuggestion)
+	}
+	limit := q.Limit
+	if limit <= 0 {
+		limit = defaultSuggestionLimit
+	}
+	if limit > maxSuggestionLimit {
+		limit = maxSuggestionLimit
+	}
+
+	categories, err := s.repo.ListCategories(ctx, q.UserID)
+	if err != nil {
+		return nil, err
+	}
+	m, err := s.model(ctx, q.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	s.suggest.mu.Lock()
+	scores := m.scores(features, func(id uuid.UUID) bool {
+		c := findCategory(categories, id)
+		return c != nil && c.ArchivedAt == nil && (q.Kind == "" || c.Kind == q.Kind)
+	})
+	s.suggest.mu.Unlock()
+
+	out := make([]CategorySuggestion, 0, len(scores))
+	for id, p := range scores {
+		c := findCategory(categories, id)
+		out = append(out, CategorySuggestion{CategoryID: id, Name: c.Name, Kind: c.Kind, Confidence: math.Round(p*10000) / 10000})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Confidence != out[j].Confidence {
+			return out[i].Confidence > out[j].Confidence
+		}
+		return out[i].Name < out[j].Name
+	})
+	if len(out) > limit {
+		out = out[:limit]
+	}
+	return out, nil
+}
@@ -0,0 +1,44 @@
+package transaction
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+// TrainingSamples returns the user's most recent categorised lines, one per split line
+// for split transactions.
+func (r *SQLRepository) TrainingSamples(ctx context.Context, userID uuid.UUID, limit int) ([]trainingSample, error) {
+	query := `SELECT l.category_id, t.note, t.payee_id, l.amount::TEXT
+		FROM finance.transaction_lines l
+		JOIN finance.transactions t ON t.id = l.transaction_id
+		WHERE l.user_id = $1 AND l.category_id IS NOT NULL
+		ORDER BY l.occurred_at DESC LIMIT $2`
+	rows, err := r.db.QueryContext(ctx, query, userID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("list training samples: %w", err)
+	}
+	defer rows.Close()
+
+	var out []trainingSample
+	for rows.Next() {
+		var s trainingSample
+		var note sql.NullString
+		var payeeID uuid.NullUUID
+		if err := rows.Scan(&s.CategoryID, &note, &payeeID, &s.Amount); err != nil {
+			return nil, err
+		}
+		if note.Valid {
+			n := note.String
+			s.Note = &n
+		}
+		if payeeID.Valid {
+			id := payeeID.UUID
+			s.PayeeID = &id
+		}
+		out = append(out, s)
+	}
+	return out, rows.Err()
+}
@@ -0,0 +1,153 @@
+package transaction
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+const (
+	maxLabelName = 64
+	// defaultSuggestLimit is the autocomplete page size when the client sends none.
+	defaultSuggestLimit = 10
+)
+
+var (
+	// ErrTagNotFound is returned when a tag does not exist for the user.
+	ErrTagNotFound = errors.New("tag_not_found")
+	// ErrPayeeNotFound is returned when a payee does not exist for the user.
+	ErrPayeeNotFound = errors.New("payee_not_found")
+	// ErrTransactionNotFound is returned when a transaction does not exist for the user.
+	ErrTransactionNotFound = errors.New("transaction_not_found")
+	// ErrInvalidLabel indicates an empty or overly long tag or payee name.
+	ErrInvalidLabel = errors.New("invalid_label")
+	// ErrDuplicateLabel is returned when the user already has a tag or payee with that name.
+	ErrDuplicateLabel = errors.New("duplicate_label")
+	// ErrLabelInUse is returned when deleting a payee that is still the counterparty of a debt.
+	ErrLabelInUse = errors.New("label_in_use")
+)
+
+// LabelKind selects between tags and payees, which share storage shape and endpoints.
+type LabelKind struct {
+	table     string
+	nameIndex string
+	usage     string
+	notFound  error
+}
+
+var (
+	// TagLabels are free-form tags attached to transactions many-to-many.
+	TagLabels = LabelKind{
+		table:     "finance.tags",
+		nameIndex: "idx_tags_user_name",
+		usage:     "(SELECT COUNT(*) FROM finance.transaction_tags x JOIN finance.transactions t ON t.id = x.transaction_id WHERE x.tag_id = l.id AND t.deleted_at IS NULL)",
+		notFound:  ErrTagNotFound,
+	}
+	// PayeeLabels are merchants or counterparties; a transaction has at most one.
+	PayeeLabels = LabelKind{
+		table:     "finance.payees",
+		nameIndex: "idx_payees_user_name",
+		usage:     "(SELECT COUNT(*) FROM finance.transactions x WHERE x.payee_id = l.id AND x.deleted_at IS NULL)",
+		notFound:  ErrPayeeNotFound,
+	}
+)
+
+func normalizeLabel(name string) (string, error) {
+	name = strings.Join(strings.Fields(name), " ")
+	if name == "" {
+		return "", fmt.Errorf("%w: name is required", ErrInvalidLabel)
+	}
+	if len([]rune(name)) > maxLabelName {
+		return "", fmt.Errorf("%w: name is longer than %d characters", ErrInvalidLabel, maxLabelName)
+	}
+	return name, nil
+}
+
+// CreateLabel adds a tag or payee.
+func (s *Service) CreateLabel(ctx context.Context, kind LabelKind, userID uuid.UUID, name string) (*Label, error) {
+	name, err := normalizeLabel(name)
+	if err != nil {
+		return nil, err
+	}
+	l := Label{ID: uuid.New(), UserID: userID, Name: name}
+	if err := s.repo.CreateLabel(ctx, kind, &l); err != nil {
+		return nil, err
+	}
+	return &l, nil
+}
+
+// ListLabels returns the user's tags or payees, most used first. A non-empty query
+// narrows the list for autocomplete, ranking names that start with it first.
+func (s *Service) ListLabels(ctx context.Context, kind LabelKind, userID uuid.UUID, query string, limit int) ([]Label, error) {
+	labels, err := s.repo.ListLabels(ctx, kind, userID, strings.TrimSpace(query), limit)
+	if err != nil {
+		return nil, err
+	}
+	if labels == nil {
+		labels = []Label{}
+	}
+	return labels, nil
+}
+
+// RenameLabel changes the name of a tag or payee.
+func (s *Service) RenameLabel(ctx context.Context, kind LabelKind, userID, id uuid.UUID, name string) (*Label, error) {
+	name, err := normalizeLabel(name)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.RenameLabel(ctx, kind, userID, id, name)
+}
+
+// DeleteLabel removes a tag or payee. Transactions keep everything else; they only lose
+// the tag or payee reference.
+func (s *Service) DeleteLabel(ctx context.Context, kind LabelKind, userID, id uuid.UUID) error {
+	return s.repo.DeleteLabel(ctx, kind, userID, id)
+}
+
+// SetTransactionLabels replaces the payee and tags of an existing transaction.
+func (s *Service) SetTransactionLabels(ctx context.Context, userID, transactionID uuid.UUID, payeeID *uuid.UUID, tagIDs []uuid.UUID) error {
+	tagIDs = uniqueIDs(tagIDs)
+	if err := s.checkLabels(ctx, userID, payeeID, tagIDs); err != nil {
+		return err
+	}
+	ctx = userChange(ctx, SourceAPI, userID, nil)
+	return s.repo.SetTransactionLabels(ctx, userID, transactionID, payeeID, tagIDs)
+}
+
+// checkLabels makes sure the payee and every tag belong to userID.
+func (s *Service) checkLabels(ctx context.Context, userID uuid.UUID, payeeID *uuid.UUID, tagIDs []uuid.UUID) error {
+	if payeeID != nil {
+		n, err := s.repo.CountLabels(ctx, PayeeLabels, userID, []uuid.UUID{*payeeID})
+		if err != nil {
+			return err
+		}
+		if n != 1 {
+			return ErrPayeeNotFound
+		}
+	}
+	if len(tagIDs) > 0 {
+		n, err := s.repo.CountLabels(ctx, TagLabels, userID, tagIDs)
+		if err != nil {
+			return err
+		}
+		if n != len(tagIDs) {
+			return ErrTagNotFound
+		}
+	}
+	return nil
+}
+
+func uniqueIDs(ids []uuid.UUID) []uuid.UUID {
+	seen := make(map[uuid.UUID]bool, len(ids))
+	out := ids[:0:0]
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			out = append(out, id)
+		}
+	}
+	return out
+}
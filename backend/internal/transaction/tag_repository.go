@@ -0,0 +1,185 @@
+package transaction
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+
+	"github.com/Jomesi149/Implementasi-LASTI/backend/internal/sqlutil"
+)
+
+// CreateLabel inserts a tag or payee and fills in its creation time.
+func (r *SQLRepository) CreateLabel(ctx context.Context, kind LabelKind, l *Label) error {
+	query := fmt.Sprintf(`INSERT INTO %s (id, user_id, name, created_at) VALUES ($1, $2, $3, NOW()) RETURNING created_at`, kind.table)
+	if err := r.db.QueryRowContext(ctx, query, l.ID, l.UserID, l.Name).Scan(&l.CreatedAt); err != nil {
+		if isUniqueViolation(err, kind.nameIndex) {
+			return fmt.Errorf("%w: %s", ErrDuplicateLabel, l.Name)
+		}
+		return fmt.Errorf("insert label: %w", err)
+	}
+	return nil
+}
+
+// ListLabels lists labels with their usage count, most used first. With query set only
+// names containing it are returned, prefix matches first.
+func (r *SQLRepository) ListLabels(ctx context.Context, kind LabelKind, userID uuid.UUID, query string, limit int) ([]Label, error) {
+	args := []any{userID}
+	where, order := "l.user_id = $1", "usage DESC, l.name ASC"
+	if query != "" {
+		args = append(args, escapeLike(query))
+		where += ` AND l.name ILIKE '%' || $2 || '%' ESCAPE '\'`
+		order = `(l.name ILIKE $2 || '%' ESCAPE '\') DESC, ` + order
+	}
+	q := fmt.Sprintf(`SELECT l.id, l.user_id, l.name, %s AS usage, l.created_at FROM %s l WHERE %s ORDER BY %s`,
+		kind.usage, kind.table, where, order)
+	if limit > 0 {
+		args = append(args, limit)
+		q += fmt.Sprintf(" LIMIT $%d", len(args))
+	}
+
+	rows, err := r.db.QueryContext(ctx, q, args...)
+	if err != nil {
+		return nil, fmt.Errorf("list labels: %w", err)
+	}
+	defer rows.Close()
+
+	var out []Label
+	for rows.Next() {
+		var l Label
+		if err := rows.Scan(&l.ID, &l.UserID, &l.Name, &l.UsageCount, &l.CreatedAt); err != nil {
+			return nil, err
+		}
+		out = append(out, l)
+	}
+	return out, rows.Err()
+}
+
+func (r *SQLRepository) RenameLabel(ctx context.Context, kind LabelKind, userID, id uuid.UUID, name string) (*Label, error) {
+	query := fmt.Sprintf(`UPDATE %s l SET name = $3 WHERE l.id = $1 AND l.user_id = $2
+		RETURNING l.id, l.user_id, l.name, %s, l.created_at`, kind.table, kind.usage)
+	var l Label
+	err := r.db.QueryRowContext(ctx, query, id, userID, name).Scan(&l.ID, &l.UserID, &l.Name, &l.UsageCount, &l.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, kind.notFound
+	}
+	if err != nil {
+		if isUniqueViolation(err, kind.nameIndex) {
+			return nil, fmt.Errorf("%w: %s", ErrDuplicateLabel, name)
+		}
+		return nil, fmt.Errorf("rename label: %w", err)
+	}
+	return &l, nil
+}
+
+func (r *SQLRepository) DeleteLabel(ctx context.Context, kind LabelKind, userID, id uuid.UUID) error {
+	res, err := r.db.ExecContext(ctx, fmt.Sprintf(`DELETE FROM %s WHERE id = $1 AND user_id = $2`, kind.table), id, userID)
+	if isForeignKeyViolation(err, "debts_counterparty_id_fkey") {
+		return fmt.Errorf("%w: the payee is the counterparty of a debt", ErrLabelInUse)
+	}
+	if isForeignKeyViolation(err, "bills_payee_id_fkey") {
+		return fmt.Errorf("%w: the payee is used by a bill", ErrLabelInUse)
+	}
+	if err != nil {
+		return fmt.Errorf("delete label: %w", err)
+	}
+	return sqlutil.ExpectAffected(res, kind.notFound)
+}
+
+// CountLabels counts how many of ids belong to userID.
+func (r *SQLRepository) CountLabels(ctx context.Context, kind LabelKind, userID uuid.UUID, ids []uuid.UUID) (int, error) {
+	query := fmt.Sprintf(`SELECT COUNT(*) FROM %s WHERE user_id = $1 AND id = ANY($2::uuid[])`, kind.table)
+	var n int
+	if err := r.db.QueryRowContext(ctx, query, userID, uuidStrings(ids)).Scan(&n); err != nil {
+		return 0, fmt.Errorf("count labels: %w", err)
+	}
+	return n, nil
+}
+
+// SetTransactionLabels replaces the payee and tag set of a transaction in one database
+// transaction.
+func (r *SQLRepository) SetTransactionLabels(ctx context.Context, userID, transactionID uuid.UUID, payeeID *uuid.UUID, tagIDs []uuid.UUID) (err error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	ids := []uuid.UUID{transactionID}
+	before, err := snapshotHistory(ctx, tx, ids)
+	if err != nil {
+		return err
+	}
+
+	res, err := tx.ExecContext(ctx, `UPDATE finance.transactions SET payee_id = $3 WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL`, transactionID, userID, payeeID)
+	if err != nil {
+		return fmt.Errorf("update payee: %w", err)
+	}
+	if err = sqlutil.ExpectAffected(res, ErrTransactionNotFound); err != nil {
+		return err
+	}
+	if _, err = tx.ExecContext(ctx, `DELETE FROM finance.transaction_tags WHERE transaction_id = $1`, transactionID); err != nil {
+		return fmt.Errorf("clear tags: %w", err)
+	}
+	if err = insertTags(ctx, tx, transactionID, tagIDs); err != nil {
+		return err
+	}
+	if err = recordChanges(ctx, tx, ids, before); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("commit tx: %w", err)
+	}
+	return nil
+}
+
+func insertTags(ctx context.Context, tx *sql.Tx, transactionID uuid.UUID, tagIDs []uuid.UUID) error {
+	if len(tagIDs) == 0 {
+		return nil
+	}
+	q := `INSERT INTO finance.transaction_tags (transaction_id, tag_id) SELECT $1, UNNEST($2::uuid[]) ON CONFLICT DO NOTHING`
+	if _, err := tx.ExecContext(ctx, q, transactionID, uuidStrings(tagIDs)); err != nil {
+		return fmt.Errorf("insert tags: %w", err)
+	}
+	return nil
+}
+
+// attachTags loads the tag ids of txs with one query.
+func (r *SQLRepository) attachTags(ctx context.Context, txs []Transaction) error {
+	if len(txs) == 0 {
+		return nil
+	}
+	ids := make([]uuid.UUID, len(txs))
+	index := make(map[uuid.UUID]int, len(txs))
+	for i, t := range txs {
+		ids[i] = t.ID
+		index[t.ID] = i
+	}
+
+	query := `SELECT tt.transaction_id, tt.tag_id FROM finance.transaction_tags tt
+		JOIN finance.tags g ON g.id = tt.tag_id
+		WHERE tt.transaction_id = ANY($1::uuid[]) ORDER BY tt.transaction_id, g.name`
+	rows, err := r.db.QueryContext(ctx, query, uuidStrings(ids))
+	if err != nil {
+		return fmt.Errorf("load tags: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var txID, tagID uuid.UUID
+		if err := rows.Scan(&txID, &tagID); err != nil {
+			return err
+		}
+		i := index[txID]
+		txs[i].TagIDs = append(txs[i].TagIDs, tagID)
+	}
+	return rows.Err()
+}
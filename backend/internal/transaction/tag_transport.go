@@ -0,0 +1,185 @@
+package transaction
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"github.com/Jomesi149/Implementasi-LASTI/backend/pkg/response"
+)
+
+// maxSuggestLimit caps the autocomplete page size.
+const maxSuggestLimit = 50
+
+func (h *HTTPHandler) registerLabelRoutes(r chi.Router) {
+	for _, route := range []struct {
+		path string
+		kind LabelKind
+	}{
+		{"/tags", TagLabels},
+		{"/payees", PayeeLabels},
+	} {
+		kind := route.kind
+		r.Route(route.path, func(r chi.Router) {
+			r.Post("/", h.handleCreateLabel(kind))
+			r.Get("/", h.handleListLabels(kind))
+			r.Get("/autocomplete", h.handleAutocompleteLabels(kind))
+			r.Put("/{id}", h.handleRenameLabel(kind))
+			r.Delete("/{id}", h.handleDeleteLabel(kind))
+		})
+	}
+}
+
+type labelReq struct {
+	Name string `json:"name"`
+}
+
+// idParams reads the user id header and the {id} URL parameter; what names the
+// resource in the error message.
+func idParams(w http.ResponseWriter, r *http.Request, what string) (uuid.UUID, uuid.UUID, bool) {
+	uid, err := getUserIDFromHeader(r)
+	if err != nil {
+		response.Error(w, http.StatusUnauthorized, "missing X-User-ID header")
+		return uuid.Nil, uuid.Nil, false
+	}
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "invalid "+what+" id")
+		return uuid.Nil, uuid.Nil, false
+	}
+	return uid, id, true
+}
+
+func (h *HTTPHandler) handleCreateLabel(kind LabelKind) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		uid, err := getUserIDFromHeader(r)
+		if err != nil {
+			response.Error(w, http.StatusUnauthorized, "missing X-User-ID header")
+			return
+		}
+		var req labelReq
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			response.Error(w, http.StatusBadRequest, "invalid payload")
+			return
+		}
+		l, err := h.service.CreateLabel(r.Context(), kind, uid, req.Name)
+		if err != nil {
+			response.Error(w, errorStatus(err), err.Error())
+			return
+		}
+		response.JSON(w, http.StatusCreated, l)
+	}
+}
+
+func (h *HTTPHandler) handleListLabels(kind LabelKind) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		uid, err := getUserIDFromHeader(r)
+		if err != nil {
+			response.Error(w, http.StatusUnauthorized, "missing X-User-ID header")
+			return
+		}
+		labels, err := h.service.ListLabels(r.Context(), kind, uid, "", 0)
+		if err != nil {
+			response.Error(w, errorStatus(err), err.Error())
+			return
+		}
+		response.JSON(w, http.StatusOK, labels)
+	}
+}
+
+// handleAutocompleteLabels serves ?q=...&limit=... suggestions ranked by usage.
+func (h *HTTPHandler) handleAutocompleteLabels(kind LabelKind) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		uid, err := getUserIDFromHeader(r)
+		if err != nil {
+			response.Error(w, http.StatusUnauthorized, "missing X-User-ID header")
+			return
+		}
+		limit := defaultSuggestLimit
+		if v := r.URL.Query().Get("limit"); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 1 || n > maxSuggestLimit {
+				response.Error(w, http.StatusBadRequest, "invalid limit")
+				return
+			}
+			limit = n
+		}
+		labels, err := h.service.ListLabels(r.Context(), kind, uid, r.URL.Query().Get("q"), limit)
+		if err != nil {
+			response.Error(w, errorStatus(err), err.Error())
+			return
+		}
+		response.JSON(w, http.StatusOK, labels)
+	}
+}
+
+func (h *HTTPHandler) handleRenameLabel(kind LabelKind) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		uid, id, ok := idParams(w, r, "label")
+		if !ok {
+			return
+		}
+		var req labelReq
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			response.Error(w, http.StatusBadRequest, "invalid payload")
+			return
+		}
+		l, err := h.service.RenameLabel(r.Context(), kind, uid, id, req.Name)
+		if err != nil {
+			response.Error(w, errorStatus(err), err.Error())
+			return
+		}
+		response.JSON(w, http.StatusOK, l)
+	}
+}
+
+func (h *HTTPHandler) handleDeleteLabel(kind LabelKind) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		uid, id, ok := idParams(w, r, "label")
+		if !ok {
+			return
+		}
+		if err := h.service.DeleteLabel(r.Context(), kind, uid, id); err != nil {
+			response.Error(w, errorStatus(err), err.Error())
+			return
+		}
+		response.JSON(w, http.StatusOK, map[string]string{"status": "deleted"})
+	}
+}
+
+type transactionLabelsReq struct {
+	PayeeID *string  `json:"payee_id"`
+	TagIDs  []string `json:"tag_ids"`
+}
+
+// handleSetTransactionLabels replaces the payee and tags of a transaction; omitted
+// fields are cleared.
+func (h *HTTPHandler) handleSetTransactionLabels(w http.ResponseWriter, r *http.Request) {
+	uid, id, ok := idParams(w, r, "transaction")
+	if !ok {
+		return
+	}
+	var req transactionLabelsReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.Error(w, http.StatusBadRequest, "invalid payload")
+		return
+	}
+	payeeID, err := parseOptionalUUID(req.PayeeID)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "invalid payee id")
+		return
+	}
+	tagIDs, err := parseUUIDList(req.TagIDs)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "invalid tag id")
+		return
+	}
+	if err := h.service.SetTransactionLabels(r.Context(), uid, id, payeeID, tagIDs); err != nil {
+		response.Error(w, errorStatus(err), err.Error())
+		return
+	}
+	response.JSON(w, http.StatusOK, map[string]any{"payee_id": payeeID, "tag_ids": tagIDs})
+}
@@ -0,0 +1,177 @@
+package transaction
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/Jomesi149/Implementasi-LASTI/backend/internal/currency"
+	"github.com/Jomesi149/Implementasi-LASTI/backend/internal/sqlutil"
+)
+
+// SourceTransfer marks history rows written for a transfer between wallets.
+const SourceTransfer = "transfer"
+
+var (
+	// ErrTransferNotFound is returned when the transfer does not exist for the user.
+	ErrTransferNotFound = errors.New("transfer_not_found")
+	// ErrInvalidTransfer indicates a transfer with bad wallets or amounts.
+	ErrInvalidTransfer = errors.New("invalid_transfer")
+)
+
+// Transfer moves money between two wallets of a user. It is stored as an outgoing leg on
+// the source wallet and an incoming leg on the destination that share ID as their
+// transfer_id; neither counts as income or expense. Between currencies ToAmount is what
+// arrived, and Quote the rate used when it was converted rather than given.
+type Transfer struct {
+	ID           uuid.UUID       `json:"id"`
+	UserID       uuid.UUID       `json:"user_id"`
+	FromWalletID uuid.UUID       `json:"from_wallet_id"`
+	ToWalletID   uuid.UUID       `json:"to_wallet_id"`
+	Amount       string          `json:"amount"`
+	Currency     string          `json:"currency"`
+	ToAmount     string          `json:"to_amount"`
+	ToCurrency   string          `json:"to_currency"`
+	Note         *string         `json:"note,omitempty"`
+	OccurredAt   time.Time       `json:"occurred_at"`
+	Quote        *currency.Quote `json:"quote,omitempty"`
+	// A leg is missing when its wallet went to the trash without the other one.
+	Out *Transaction `json:"out,omitempty"`
+	In  *Transaction `json:"in,omitempty"`
+}
+
+// NewTransfer is the input for Service.CreateTransfer. ToAmount is optional: between
+// wallets of different currencies it defaults to Amount converted with the rate on
+// OccurredAt.
+type NewTransfer struct {
+	UserID       uuid.UUID
+	FromWalletID uuid.UUID
+	ToWalletID   uuid.UUID
+	Amount       string
+	ToAmount     string
+	Note         *string
+	OccurredAt   time.Time
+}
+
+// legs builds the two transactions of a transfer.
+func (tr Transfer) legs() (Transaction, Transaction) {
+	id := tr.ID
+	now := time.Now()
+	out := Transaction{ID: uuid.New(), UserID: tr.UserID, WalletID: tr.FromWalletID, Amount: tr.Amount, Kind: "out",
+		Note: tr.Note, OccurredAt: tr.OccurredAt, Currency: tr.Currency, TransferID: &id, CreatedAt: now}
+	in := Transaction{ID: uuid.New(), UserID: tr.UserID, WalletID: tr.ToWalletID, Amount: tr.ToAmount, Kind: "in",
+		Note: tr.Note, OccurredAt: tr.OccurredAt, Currency: tr.ToCurrency, TransferID: &id, CreatedAt: now}
+	schedule(&out, now)
+	schedule(&in, now)
+	return out, in
+}
+
+// transferFromLegs rebuilds a transfer from its live legs.
+func transferFromLegs(id uuid.UUID, legs []Transaction) *Transfer {
+	tr := &Transfer{ID: id}
+	for i := range legs {
+		t := legs[i]
+		tr.UserID, tr.Note, tr.OccurredAt = t.UserID, t.Note, t.OccurredAt
+		if t.Kind == "out" {
+			tr.FromWalletID, tr.Amount, tr.Currency, tr.Out = t.WalletID, t.Amount, t.Currency, &t
+		} else {
+			tr.ToWalletID, tr.ToAmount, tr.ToCurrency, tr.In = t.WalletID, t.Amount, t.Currency, &t
+		}
+	}
+	return tr
+}
+
+// CreateTransfer moves money from one wallet of the user to another, converting between
+// currencies when the wallets differ.
+func (s *Service) CreateTransfer(ctx context.Context, in NewTransfer) (*Transfer, error) {
+	cents, ok := sqlutil.ToCents(in.Amount)
+	if !ok || cents <= 0 {
+		return nil, fmt.Errorf("%w: amount must be a positive number", ErrInvalidTransfer)
+	}
+	if in.FromWalletID == in.ToWalletID {
+		return nil, fmt.Errorf("%w: source and destination wallet must differ", ErrInvalidTransfer)
+	}
+	from, err := s.repo.GetWallet(ctx, in.UserID, in.FromWalletID)
+	if err != nil {
+		return nil, err
+	}
+	to, err := s.repo.GetWallet(ctx, in.UserID, in.ToWalletID)
+	if err != nil {
+		return nil, err
+	}
+	if in.OccurredAt.IsZero() {
+		in.OccurredAt = time.Now()
+	}
+
+	tr := Transfer{
+		ID:           uuid.New(),
+		UserID:       in.UserID,
+		FromWalletID: from.ID,
+		ToWalletID:   to.ID,
+		Amount:       sqlutil.CentsString(cents),
+		Currency:     from.Currency,
+		ToCurrency:   to.Currency,
+		Note:         in.Note,
+		OccurredAt:   in.OccurredAt,
+	}
+	switch {
+	case in.ToAmount != "":
+		received, ok := sqlutil.ToCents(in.ToAmount)
+		if !ok || received <= 0 {
+			return nil, fmt.Errorf("%w: to_amount must be a positive number", ErrInvalidTransfer)
+		}
+		if from.Currency == to.Currency && received != cents {
+			return nil, fmt.Errorf("%w: to_amount must equal amount between wallets of the same currency", ErrInvalidTransfer)
+		}
+		tr.ToAmount = sqlutil.CentsString(received)
+	case from.Currency == to.Currency:
+		tr.ToAmount = tr.Amount
+	case s.currencies == nil:
+		return nil, fmt.Errorf("%w: to_amount is required between %s and %s", ErrInvalidTransfer, from.Currency, to.Currency)
+	default:
+		c, err := s.currencies.Convert(ctx, in.UserID, tr.Amount, from.Currency, to.Currency, in.OccurredAt)
+		if err != nil {
+			return nil, err
+		}
+		if converted, _ := sqlutil.ToCents(c.Converted); converted <= 0 {
+			return nil, fmt.Errorf("%w: amount is too small to convert into %s", ErrInvalidTransfer, to.Currency)
+		}
+		tr.ToAmount, tr.Quote = c.Converted, &c.Quote
+	}
+
+	out, inLeg := tr.legs()
+	ctx = userChange(ctx, SourceTransfer, in.UserID, &tr.ID)
+	if err := s.repo.CreateTransfer(ctx, out, inLeg); err != nil {
+		return nil, fmt.Errorf("create transfer: %w", err)
+	}
+	tr.Out, tr.In = &out, &inLeg
+	return &tr, nil
+}
+
+// GetTransfer returns a transfer with its live legs.
+func (s *Service) GetTransfer(ctx context.Context, userID, id uuid.UUID) (*Transfer, error) {
+	legs, err := s.repo.TransferLegs(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(legs) == 0 {
+		return nil, ErrTransferNotFound
+	}
+	return transferFromLegs(id, legs), nil
+}
+
+// DeleteTransfer moves both legs of a transfer to the trash; restoring either leg brings
+// both back.
+func (s *Service) DeleteTransfer(ctx context.Context, userID, id uuid.UUID) error {
+	legs, err := s.repo.TransferLegs(ctx, userID, id)
+	if err != nil {
+		return err
+	}
+	if len(legs) == 0 {
+		return ErrTransferNotFound
+	}
+	return s.DeleteTransaction(ctx, userID, legs[0].ID)
+}
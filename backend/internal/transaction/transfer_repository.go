@@ -0,0 +1,55 @@
+package transaction
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+// CreateTransfer inserts the legs of a transfer in one database transaction.
+func (r *SQLRepository) CreateTransfer(ctx context.Context, legs ...Transaction) (err error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	for _, t := range legs {
+		if err = insertTransaction(ctx, tx, t); err != nil {
+			return err
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("commit tx: %w", err)
+	}
+	return nil
+}
+
+// TransferLegs returns the live legs of a transfer, outgoing first.
+func (r *SQLRepository) TransferLegs(ctx context.Context, userID, transferID uuid.UUID) ([]Transaction, error) {
+	query := fmt.Sprintf(`SELECT %s FROM finance.transactions t
+		WHERE t.transfer_id = $1 AND t.user_id = $2 AND t.deleted_at IS NULL
+		ORDER BY t.kind DESC`, transactionColumns)
+	rows, err := r.db.QueryContext(ctx, query, transferID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("select transfer: %w", err)
+	}
+	defer rows.Close()
+
+	var out []Transaction
+	for rows.Next() {
+		t, err := scanTransaction(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, t)
+	}
+	return out, rows.Err()
+}
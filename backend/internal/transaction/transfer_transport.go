@@ -0,0 +1,88 @@
+package transaction
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"github.com/Jomesi149/Implementasi-LASTI/backend/pkg/response"
+)
+
+func (h *HTTPHandler) registerTransferRoutes(r chi.Router) {
+	r.Route("/transfers", func(r chi.Router) {
+		r.Post("/", h.handleCreateTransfer)
+		r.Get("/{id}", h.handleGetTransfer)
+		r.Delete("/{id}", h.handleDeleteTransfer)
+	})
+}
+
+type createTransferReq struct {
+	FromWalletID string `json:"from_wallet_id"`
+	ToWalletID   string `json:"to_wallet_id"`
+	Amount       string `json:"amount"`
+	// ToAmount is optional; between currencies it defaults to the converted amount.
+	ToAmount   string     `json:"to_amount"`
+	Note       *string    `json:"note"`
+	OccurredAt *time.Time `json:"occurred_at"`
+}
+
+func (h *HTTPHandler) handleCreateTransfer(w http.ResponseWriter, r *http.Request) {
+	uid, err := getUserIDFromHeader(r)
+	if err != nil {
+		response.Error(w, http.StatusUnauthorized, "missing X-User-ID header")
+		return
+	}
+	var req createTransferReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.Error(w, http.StatusBadRequest, "invalid payload")
+		return
+	}
+	from, err := uuid.Parse(req.FromWalletID)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "invalid from_wallet_id")
+		return
+	}
+	to, err := uuid.Parse(req.ToWalletID)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "invalid to_wallet_id")
+		return
+	}
+	in := NewTransfer{UserID: uid, FromWalletID: from, ToWalletID: to, Amount: req.Amount, ToAmount: req.ToAmount, Note: req.Note}
+	if req.OccurredAt != nil {
+		in.OccurredAt = *req.OccurredAt
+	}
+	tr, err := h.service.CreateTransfer(r.Context(), in)
+	if err != nil {
+		response.Error(w, errorStatus(err), err.Error())
+		return
+	}
+	response.JSON(w, http.StatusCreated, tr)
+}
+
+func (h *HTTPHandler) handleGetTransfer(w http.ResponseWriter, r *http.Request) {
+	uid, id, ok := idParams(w, r, "transfer")
+	if !ok {
+		return
+	}
+	tr, err := h.service.GetTransfer(r.Context(), uid, id)
+	if err != nil {
+		response.Error(w, errorStatus(err), err.Error())
+		return
+	}
+	response.JSON(w, http.StatusOK, tr)
+}
+
+func (h *HTTPHandler) handleDeleteTransfer(w http.ResponseWriter, r *http.Request) {
+	uid, id, ok := idParams(w, r, "transfer")
+	if !ok {
+		return
+	}
+	if err := h.service.DeleteTransfer(r.Context(), uid, id); err != nil {
+		response.Error(w, errorStatus(err), err.Error())
+		return
+	}
+	response.JSON(w, http.StatusOK, map[string]string{"status": "transfer moved to trash"})
+}
@@ -2,39 +2,74 @@ package transaction
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
 
+	"github.com/Jomesi149/Implementasi-LASTI/backend/internal/currency"
 	"github.com/Jomesi149/Implementasi-LASTI/backend/pkg/response"
 )
 
 // HTTPHandler exposes transaction endpoints.
 type HTTPHandler struct {
-	service *Service
+	service     *Service
+	projections []ProjectionSource
 }
 
-func NewHTTPHandler(s *Service) *HTTPHandler {
-	return &HTTPHandler{service: s}
+// NewHTTPHandler builds the handler. projections add scheduled items, such as recurring
+// rules, to wallet balance projections.
+func NewHTTPHandler(s *Service, projections ...ProjectionSource) *HTTPHandler {
+	return &HTTPHandler{service: s, projections: projections}
 }
 
 func (h *HTTPHandler) RegisterRoutes(r chi.Router) {
 	r.Route("/wallets", func(r chi.Router) {
 		r.Post("/", h.handleCreateWallet)
 		r.Get("/", h.handleListWallets)
+		r.Delete("/{id}", h.handleDeleteWallet)
+		r.Post("/{id}/reconciliations", h.handleStartReconciliation)
+		r.Get("/{id}/reconciliations", h.handleListReconciliations)
+		r.Get("/{id}/projection", h.handleProjection)
 	})
 	r.Route("/categories", func(r chi.Router) {
 		r.Post("/", h.handleCreateCategory)
 		r.Get("/", h.handleListCategories)
+		r.Get("/suggest", h.handleSuggestCategories)
+		r.Put("/{id}", h.handleUpdateCategory)
+		r.Delete("/{id}", h.handleDeleteCategory)
+		r.Post("/{id}/archive", h.handleArchiveCategory)
+		r.Post("/{id}/unarchive", h.handleUnarchiveCategory)
+		r.Post("/{id}/merge", h.handleMergeCategory)
 	})
 	r.Route("/transactions", func(r chi.Router) {
 		r.Post("/", h.handleCreateTransaction)
 		r.Get("/", h.handleListTransactions)
+		r.Get("/export", h.handleExportTransactions)
+		r.Post("/bulk", h.handleBulkTransactions)
+		r.Get("/duplicates", h.handleListDuplicates)
+		r.Post("/duplicates/merge", h.handleMergeDuplicates)
+		r.Post("/duplicates/dismiss", h.handleDismissDuplicate)
+		r.Delete("/{id}", h.handleDeleteTransaction)
+		r.Put("/{id}/labels", h.handleSetTransactionLabels)
+		r.Get("/{id}/history", h.handleTransactionHistory)
+		r.Post("/{id}/post", h.handlePostTransaction)
 	})
+	h.registerImportRoutes(r)
+	h.registerLabelRoutes(r)
+	h.registerRuleRoutes(r)
+	h.registerTrashRoutes(r)
+	h.registerLedgerRoutes(r)
+	h.registerReconciliationRoutes(r)
+	h.registerTransferRoutes(r)
+	h.registerCreditCardRoutes(r)
+	h.registerDebtRoutes(r)
 }
 
 func getUserIDFromHeader(r *http.Request) (uuid.UUID, error) {
@@ -42,10 +77,52 @@ func getUserIDFromHeader(r *http.Request) (uuid.UUID, error) {
 	return uuid.Parse(s)
 }
 
+// errorStatus maps service sentinel errors onto HTTP status codes.
+func errorStatus(err error) int {
+	switch {
+	case errors.Is(err, ErrCategoryNotFound), errors.Is(err, ErrWalletNotFound), errors.Is(err, ErrImportNotFound),
+		errors.Is(err, ErrTagNotFound), errors.Is(err, ErrPayeeNotFound), errors.Is(err, ErrTransactionNotFound),
+		errors.Is(err, ErrRuleNotFound), errors.Is(err, ErrTrashNotFound), errors.Is(err, ErrReconciliationNotFound),
+		errors.Is(err, ErrTransferNotFound), errors.Is(err, ErrCreditCardNotFound), errors.Is(err, ErrDebtNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, ErrInvalidCategory), errors.Is(err, ErrInvalidFilter), errors.Is(err, ErrInvalidCursor),
+		errors.Is(err, ErrInvalidImport), errors.Is(err, ErrInvalidTransaction), errors.Is(err, ErrUnsupportedExport),
+		errors.Is(err, ErrInvalidLabel), errors.Is(err, ErrInvalidRule), errors.Is(err, ErrInvalidSuggestion),
+		errors.Is(err, ErrInvalidIdempotencyKey), errors.Is(err, ErrInvalidDuplicate), errors.Is(err, ErrInvalidBulk),
+		errors.Is(err, ErrInvalidTrashType), errors.Is(err, ErrInvalidReconciliation), errors.Is(err, ErrInvalidTransfer),
+		errors.Is(err, currency.ErrInvalidCurrency), errors.Is(err, currency.ErrInvalidRate), errors.Is(err, ErrInvalidCreditCard),
+		errors.Is(err, ErrInvalidDebt), errors.Is(err, ErrInvalidProjection):
+		return http.StatusBadRequest
+	case errors.Is(err, ErrImportState), errors.Is(err, ErrDuplicateTransaction), errors.Is(err, ErrDuplicateLabel),
+		errors.Is(err, ErrIdempotencyInProgress), errors.Is(err, ErrBulkConflict), errors.Is(err, ErrTrashState),
+		errors.Is(err, ErrReconciliationState), errors.Is(err, ErrDebtState), errors.Is(err, ErrLabelInUse),
+		errors.Is(err, ErrNotPlanned):
+		return http.StatusConflict
+	case errors.Is(err, ErrIdempotencyMismatch), errors.Is(err, ErrReconciliationUnbalanced), errors.Is(err, currency.ErrRateNotFound):
+		return http.StatusUnprocessableEntity
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// parseOptionalUUID parses a nullable id from a JSON payload; empty strings count as nil.
+func parseOptionalUUID(s *string) (*uuid.UUID, error) {
+	if s == nil || *s == "" {
+		return nil, nil
+	}
+	id, err := uuid.Parse(*s)
+	if err != nil {
+		return nil, err
+	}
+	return &id, nil
+}
+
 type createWalletReq struct {
 	Type    string `json:"type"`
 	Name    string `json:"name"`
 	Balance string `json:"balance"`
+	// Currency is an ISO 4217 code; empty means the user's base currency.
+	Currency string `json:"currency"`
 }
 
 func (h *HTTPHandler) handleCreateWallet(w http.ResponseWriter, r *http.Request) {
@@ -59,9 +136,9 @@ func (h *HTTPHandler) handleCreateWallet(w http.ResponseWriter, r *http.Request)
 		response.Error(w, http.StatusBadRequest, "invalid payload")
 		return
 	}
-	wallet, err := h.service.CreateWallet(r.Context(), uid, req.Type, req.Name, req.Balance)
+	wallet, err := h.service.CreateWallet(r.Context(), uid, req.Type, req.Name, req.Balance, req.Currency)
 	if err != nil {
-		response.Error(w, http.StatusInternalServerError, err.Error())
+		response.Error(w, errorStatus(err), err.Error())
 		return
 	}
 	response.JSON(w, http.StatusCreated, wallet)
@@ -85,8 +162,9 @@ func (h *HTTPHandler) handleListWallets(w http.ResponseWriter, r *http.Request)
 }
 
 type createCategoryReq struct {
-	Name string `json:"name"`
-	Kind string `json:"kind"`
+	Name     string  `json:"name"`
+	Kind     string  `json:"kind"`
+	ParentID *string `json:"parent_id"`
 }
 
 func (h *HTTPHandler) handleCreateCategory(w http.ResponseWriter, r *http.Request) {
@@ -100,9 +178,14 @@ func (h *HTTPHandler) handleCreateCategory(w http.ResponseWriter, r *http.Reques
 		response.Error(w, http.StatusBadRequest, "invalid payload")
 		return
 	}
-	cat, err := h.service.CreateCategory(r.Context(), uid, req.Name, req.Kind)
+	parentID, err := parseOptionalUUID(req.ParentID)
 	if err != nil {
-		response.Error(w, http.StatusInternalServerError, err.Error())
+		response.Error(w, http.StatusBadRequest, "invalid parent id")
+		return
+	}
+	cat, err := h.service.CreateCategory(r.Context(), uid, req.Name, req.Kind, parentID)
+	if err != nil {
+		response.Error(w, errorStatus(err), err.Error())
 		return
 	}
 	response.JSON(w, http.StatusCreated, cat)
@@ -114,7 +197,8 @@ func (h *HTTPHandler) handleListCategories(w http.ResponseWriter, r *http.Reques
 		response.Error(w, http.StatusUnauthorized, "missing X-User-ID header")
 		return
 	}
-	categories, err := h.service.ListCategories(r.Context(), uid)
+	includeArchived := r.URL.Query().Get("include_archived") == "true"
+	categories, err := h.service.ListCategories(r.Context(), uid, includeArchived)
 	if err != nil {
 		response.Error(w, http.StatusInternalServerError, err.Error())
 		return
@@ -122,6 +206,137 @@ func (h *HTTPHandler) handleListCategories(w http.ResponseWriter, r *http.Reques
 	response.JSON(w, http.StatusOK, categories)
 }
 
+// handleSuggestCategories ranks categories for a note being typed:
+// GET /categories/suggest?note=...&amount=...&kind=out&payee_id=...&limit=5
+func (h *HTTPHandler) handleSuggestCategories(w http.ResponseWriter, r *http.Request) {
+	uid, err := getUserIDFromHeader(r)
+	if err != nil {
+		response.Error(w, http.StatusUnauthorized, "missing X-User-ID header")
+		return
+	}
+	q := r.URL.Query()
+	query := SuggestQuery{UserID: uid, Note: q.Get("note"), Amount: q.Get("amount"), Kind: q.Get("kind")}
+	if v := q.Get("payee_id"); v != "" {
+		id, err := uuid.Parse(v)
+		if err != nil {
+			response.Error(w, http.StatusBadRequest, "invalid payee id")
+			return
+		}
+		query.PayeeID = &id
+	}
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			response.Error(w, http.StatusBadRequest, "invalid limit")
+			return
+		}
+		query.Limit = n
+	}
+	suggestions, err := h.service.SuggestCategories(r.Context(), query)
+	if err != nil {
+		response.Error(w, errorStatus(err), err.Error())
+		return
+	}
+	response.JSON(w, http.StatusOK, suggestions)
+}
+
+func (h *HTTPHandler) handleUpdateCategory(w http.ResponseWriter, r *http.Request) {
+	uid, err := getUserIDFromHeader(r)
+	if err != nil {
+		response.Error(w, http.StatusUnauthorized, "missing X-User-ID header")
+		return
+	}
+	cid, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "invalid category id")
+		return
+	}
+	var req createCategoryReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.Error(w, http.StatusBadRequest, "invalid payload")
+		return
+	}
+	parentID, err := parseOptionalUUID(req.ParentID)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "invalid parent id")
+		return
+	}
+	cat, err := h.service.UpdateCategory(r.Context(), uid, cid, req.Name, req.Kind, parentID)
+	if err != nil {
+		response.Error(w, errorStatus(err), err.Error())
+		return
+	}
+	response.JSON(w, http.StatusOK, cat)
+}
+
+func (h *HTTPHandler) handleArchiveCategory(w http.ResponseWriter, r *http.Request) {
+	uid, err := getUserIDFromHeader(r)
+	if err != nil {
+		response.Error(w, http.StatusUnauthorized, "missing X-User-ID header")
+		return
+	}
+	cid, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "invalid category id")
+		return
+	}
+	if err := h.service.ArchiveCategory(r.Context(), uid, cid); err != nil {
+		response.Error(w, errorStatus(err), err.Error())
+		return
+	}
+	response.JSON(w, http.StatusOK, map[string]string{"status": "category archived"})
+}
+
+func (h *HTTPHandler) handleUnarchiveCategory(w http.ResponseWriter, r *http.Request) {
+	uid, err := getUserIDFromHeader(r)
+	if err != nil {
+		response.Error(w, http.StatusUnauthorized, "missing X-User-ID header")
+		return
+	}
+	cid, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "invalid category id")
+		return
+	}
+	if err := h.service.UnarchiveCategory(r.Context(), uid, cid); err != nil {
+		response.Error(w, errorStatus(err), err.Error())
+		return
+	}
+	response.JSON(w, http.StatusOK, map[string]string{"status": "category restored"})
+}
+
+type mergeCategoryReq struct {
+	TargetID string `json:"target_id"`
+}
+
+func (h *HTTPHandler) handleMergeCategory(w http.ResponseWriter, r *http.Request) {
+	uid, err := getUserIDFromHeader(r)
+	if err != nil {
+		response.Error(w, http.StatusUnauthorized, "missing X-User-ID header")
+		return
+	}
+	sourceID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "invalid category id")
+		return
+	}
+	var req mergeCategoryReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.Error(w, http.StatusBadRequest, "invalid payload")
+		return
+	}
+	targetID, err := uuid.Parse(req.TargetID)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "invalid target id")
+		return
+	}
+	if err := h.service.MergeCategories(r.Context(), uid, sourceID, targetID); err != nil {
+		response.Error(w, errorStatus(err), err.Error())
+		return
+	}
+	response.JSON(w, http.StatusOK, map[string]string{"status": "categories merged"})
+}
+
 type createTransactionReq struct {
 	WalletID   string     `json:"wallet_id"`
 	CategoryID *string    `json:"category_id"`
@@ -129,19 +344,50 @@ type createTransactionReq struct {
 	Kind       string     `json:"kind"`
 	Note       *string    `json:"note"`
 	OccurredAt *time.Time `json:"occurred_at"`
+	Splits     []splitReq `json:"splits"`
+	PayeeID    *string    `json:"payee_id"`
+	TagIDs     []string   `json:"tag_ids"`
+	AutoPost   *bool      `json:"auto_post"`
+}
+
+type splitReq struct {
+	CategoryID string  `json:"category_id"`
+	Amount     string  `json:"amount"`
+	Note       *string `json:"note"`
 }
 
+// handleCreateTransaction honours an optional Idempotency-Key header: a retry with the
+// same key and body gets the first response back instead of creating another
+// transaction.
 func (h *HTTPHandler) handleCreateTransaction(w http.ResponseWriter, r *http.Request) {
 	uid, err := getUserIDFromHeader(r)
 	if err != nil {
 		response.Error(w, http.StatusUnauthorized, "missing X-User-ID header")
 		return
 	}
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "invalid payload")
+		return
+	}
 	var req createTransactionReq
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+	if err := json.Unmarshal(body, &req); err != nil {
 		response.Error(w, http.StatusBadRequest, "invalid payload")
 		return
 	}
+	key := r.Header.Get("Idempotency-Key")
+	if key != "" {
+		replay, err := h.service.ClaimIdempotencyKey(r.Context(), uid, key, RequestHash(body))
+		if err != nil {
+			response.Error(w, errorStatus(err), err.Error())
+			return
+		}
+		if replay != nil {
+			w.Header().Set("Idempotent-Replayed", "true")
+			response.JSON(w, replay.StatusCode, json.RawMessage(replay.Body))
+			return
+		}
+	}
 	wid, err := uuid.Parse(req.WalletID)
 	if err != nil {
 		response.Error(w, http.StatusBadRequest, "invalid wallet id")
@@ -154,35 +400,237 @@ func (h *HTTPHandler) handleCreateTransaction(w http.ResponseWriter, r *http.Req
 			cid = &id
 		}
 	}
+	var splits []Split
+	for _, line := range req.Splits {
+		id, err := uuid.Parse(line.CategoryID)
+		if err != nil {
+			response.Error(w, http.StatusBadRequest, "invalid split category id")
+			return
+		}
+		splits = append(splits, Split{CategoryID: &id, Amount: line.Amount, Note: line.Note})
+	}
+	payeeID, err := parseOptionalUUID(req.PayeeID)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "invalid payee id")
+		return
+	}
+	tagIDs, err := parseUUIDList(req.TagIDs)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "invalid tag id")
+		return
+	}
 	occ := time.Now()
 	if req.OccurredAt != nil {
 		occ = *req.OccurredAt
 	}
-	t, err := h.service.CreateTransaction(r.Context(), uid, wid, cid, req.Amount, req.Kind, req.Note, occ)
+	var id uuid.UUID
+	if key != "" {
+		id = IdempotentTransactionID(uid, key)
+	}
+	t, err := h.service.CreateTransaction(r.Context(), NewTransaction{
+		ID:         id,
+		UserID:     uid,
+		WalletID:   wid,
+		CategoryID: cid,
+		Amount:     req.Amount,
+		Kind:       req.Kind,
+		Note:       req.Note,
+		OccurredAt: occ,
+		Splits:     splits,
+		PayeeID:    payeeID,
+		TagIDs:     tagIDs,
+		AutoPost:   req.AutoPost,
+	})
+	if key == "" {
+		if err != nil {
+			response.Error(w, errorStatus(err), err.Error())
+			return
+		}
+		response.JSON(w, http.StatusCreated, t)
+		return
+	}
+
+	// Transaksi sudah tersimpan oleh percobaan sebelumnya yang terputus sebelum respons dicatat
+	if errors.Is(err, ErrDuplicateTransaction) {
+		t, err = h.service.GetTransaction(r.Context(), uid, id)
+	}
+	if err != nil {
+		if relErr := h.service.ReleaseIdempotencyKey(r.Context(), uid, key); relErr != nil {
+			fmt.Printf("[IDEMPOTENCY_ERROR] Release key for user %s: %v\n", uid.String(), relErr)
+		}
+		response.Error(w, errorStatus(err), err.Error())
+		return
+	}
+	payload, err := json.Marshal(t)
 	if err != nil {
 		response.Error(w, http.StatusInternalServerError, err.Error())
 		return
 	}
-	response.JSON(w, http.StatusCreated, t)
+	if err := h.service.CompleteIdempotencyKey(r.Context(), uid, key, IdempotentResponse{StatusCode: http.StatusCreated, Body: payload}); err != nil {
+		fmt.Printf("[IDEMPOTENCY_ERROR] Store response for user %s: %v\n", uid.String(), err)
+	}
+	response.JSON(w, http.StatusCreated, json.RawMessage(payload))
 }
 
+// handleListTransactions returns the page as a plain JSON array so existing clients keep
+// working; the cursor for the next page is sent in the X-Next-Cursor header.
 func (h *HTTPHandler) handleListTransactions(w http.ResponseWriter, r *http.Request) {
 	uid, err := getUserIDFromHeader(r)
 	if err != nil {
 		response.Error(w, http.StatusUnauthorized, "missing X-User-ID header")
 		return
 	}
-	q := r.URL.Query().Get("limit")
-	limit := 50
-	if q != "" {
-		if v, err := strconv.Atoi(q); err == nil {
-			limit = v
-		}
+	f, err := ParseTransactionFilter(r)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, err.Error())
+		return
 	}
-	list, err := h.service.ListTransactions(r.Context(), uid, limit)
+	page, err := h.service.ListTransactions(r.Context(), uid, f)
 	if err != nil {
-		response.Error(w, http.StatusInternalServerError, err.Error())
+		response.Error(w, errorStatus(err), err.Error())
 		return
 	}
-	response.JSON(w, http.StatusOK, list)
+	if page.NextCursor != "" {
+		w.Header().Set("X-Next-Cursor", page.NextCursor)
+	}
+	response.JSON(w, http.StatusOK, page.Items)
+}
+
+// handleExportTransactions streams the filtered history as csv, xlsx or ofx. It accepts
+// the same query parameters as the list endpoint; cursor and limit are ignored.
+func (h *HTTPHandler) handleExportTransactions(w http.ResponseWriter, r *http.Request) {
+	uid, err := getUserIDFromHeader(r)
+	if err != nil {
+		response.Error(w, http.StatusUnauthorized, "missing X-User-ID header")
+		return
+	}
+	f, err := ParseTransactionFilter(r)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := validateFilter(f); err != nil {
+		response.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	format, err := LookupExportFormat(r.URL.Query().Get("format"))
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// Riwayat bertahun-tahun bisa melewati WriteTimeout server, jadi diperpanjang khusus di sini
+	_ = http.NewResponseController(w).SetWriteDeadline(time.Now().Add(10 * time.Minute))
+
+	w.Header().Set("Content-Type", format.ContentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="transaksi-%s.%s"`, time.Now().Format("20060102"), format.Extension))
+	w.WriteHeader(http.StatusOK)
+	if err := h.service.ExportTransactions(r.Context(), uid, f, format, w); err != nil {
+		// Header sudah terkirim, jadi error hanya bisa dicatat
+		fmt.Printf("[EXPORT_ERROR] user %s format %s: %v\n", uid, format.Name, err)
+	}
+}
+
+// ParseTransactionFilter reads list filters from the query string:
+// from, to, wallet_id, category_id, kind, status, min_amount, max_amount, q, sort, cursor,
+// limit.
+// Id parameters may be repeated or comma separated.
+func ParseTransactionFilter(r *http.Request) (TransactionFilter, error) {
+	q := r.URL.Query()
+	f := TransactionFilter{
+		Kind:   q.Get("kind"),
+		Status: q.Get("status"),
+		Query:  q.Get("q"),
+		Cursor: q.Get("cursor"),
+	}
+
+	if v := q.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil {
+			return f, fmt.Errorf("invalid limit")
+		}
+		f.Limit = limit
+	}
+	switch q.Get("sort") {
+	case "", "desc":
+	case "asc":
+		f.Ascending = true
+	default:
+		return f, fmt.Errorf("sort must be asc or desc")
+	}
+
+	var err error
+	if f.From, err = parseDateParam(q.Get("from"), false); err != nil {
+		return f, fmt.Errorf("invalid from: %w", err)
+	}
+	if f.To, err = parseDateParam(q.Get("to"), true); err != nil {
+		return f, fmt.Errorf("invalid to: %w", err)
+	}
+	if f.WalletIDs, err = parseUUIDList(q["wallet_id"]); err != nil {
+		return f, fmt.Errorf("invalid wallet_id")
+	}
+	if f.CategoryIDs, err = parseUUIDList(q["category_id"]); err != nil {
+		return f, fmt.Errorf("invalid category_id")
+	}
+	if f.TagIDs, err = parseUUIDList(q["tag_id"]); err != nil {
+		return f, fmt.Errorf("invalid tag_id")
+	}
+	if f.PayeeIDs, err = parseUUIDList(q["payee_id"]); err != nil {
+		return f, fmt.Errorf("invalid payee_id")
+	}
+	if f.MinAmount, err = parseAmountParam(q.Get("min_amount")); err != nil {
+		return f, fmt.Errorf("invalid min_amount")
+	}
+	if f.MaxAmount, err = parseAmountParam(q.Get("max_amount")); err != nil {
+		return f, fmt.Errorf("invalid max_amount")
+	}
+	return f, nil
+}
+
+// parseDateParam accepts RFC3339 timestamps or plain YYYY-MM-DD dates. A plain date used
+// as an upper bound covers the whole day.
+func parseDateParam(v string, endOfDay bool) (*time.Time, error) {
+	if v == "" {
+		return nil, nil
+	}
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return &t, nil
+	}
+	t, err := time.Parse("2006-01-02", v)
+	if err != nil {
+		return nil, err
+	}
+	if endOfDay {
+		t = t.AddDate(0, 0, 1)
+	}
+	return &t, nil
+}
+
+func parseUUIDList(values []string) ([]uuid.UUID, error) {
+	var out []uuid.UUID
+	for _, v := range values {
+		for _, part := range strings.Split(v, ",") {
+			part = strings.TrimSpace(part)
+			if part == "" {
+				continue
+			}
+			id, err := uuid.Parse(part)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, id)
+		}
+	}
+	return out, nil
+}
+
+func parseAmountParam(v string) (*float64, error) {
+	if v == "" {
+		return nil, nil
+	}
+	amount, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		return nil, err
+	}
+	return &amount, nil
 }
@@ -0,0 +1,211 @@
+package transaction
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DefaultTrashRetention is how long deleted records stay restorable when no retention
+// is configured.
+const DefaultTrashRetention = 30 * 24 * time.Hour
+
+// Trash item types, as used in the /trash routes.
+const (
+	TrashTransaction = "transaction"
+	TrashWallet      = "wallet"
+	TrashCategory    = "category"
+)
+
+var (
+	// ErrTrashNotFound is returned when the record is not in the user's trash.
+	ErrTrashNotFound = errors.New("trash_item_not_found")
+	// ErrInvalidTrashType indicates an unknown trash item type.
+	ErrInvalidTrashType = errors.New("invalid_trash_type")
+	// ErrTrashState is returned when a record cannot be restored before its wallet or
+	// parent category.
+	ErrTrashState = errors.New("trash_restore_conflict")
+	// ErrWalletForbidden is returned when a member of a shared wallet may not change a
+	// transaction, such as a transfer leg in a wallet they cannot edit.
+	ErrWalletForbidden = errors.New("wallet_forbidden")
+)
+
+// TrashItem is a soft-deleted record. Amount, Kind and WalletID are set for
+// transactions only.
+type TrashItem struct {
+	Type      string     `json:"type"`
+	ID        uuid.UUID  `json:"id"`
+	Label     string     `json:"label"`
+	Amount    string     `json:"amount,omitempty"`
+	Kind      string     `json:"kind,omitempty"`
+	WalletID  *uuid.UUID `json:"wallet_id,omitempty"`
+	DeletedAt time.Time  `json:"deleted_at"`
+	PurgeAt   time.Time  `json:"purge_at"`
+}
+
+// PurgeResult counts the records removed for good by one purge run. ObjectKeys are the
+// stored attachment files that belonged to purged transactions.
+type PurgeResult struct {
+	Transactions int
+	Wallets      int
+	Categories   int
+	ObjectKeys   []string
+}
+
+// DeleteTransaction moves a transaction to the trash and reverses its effect on the
+// wallet balance. A transfer leg takes the other leg with it.
+func (s *Service) DeleteTransaction(ctx context.Context, userID, transactionID uuid.UUID) error {
+	ctx = userChange(ctx, SourceAPI, userID, nil)
+	if err := s.repo.SoftDeleteTransaction(ctx, userID, transactionID); err != nil {
+		return err
+	}
+	s.forget(userID)
+	return nil
+}
+
+// DeleteSharedTransaction moves a transaction of ownerID's shared wallet to the owner's
+// trash on behalf of actorID. Access is checked against every wallet the transaction
+// touches.
+func (s *Service) DeleteSharedTransaction(ctx context.Context, ownerID, actorID, walletID, transactionID uuid.UUID) error {
+	ctx = userChange(ctx, SourceAPI, actorID, nil)
+	if err := s.repo.SoftDeleteSharedTransaction(ctx, actorID, walletID, transactionID); err != nil {
+		return err
+	}
+	s.forget(ownerID)
+	return nil
+}
+
+// DeleteWallet moves a wallet and all its transactions to the trash. The balance is
+// left as it was so restoring the wallet brings everything back unchanged.
+func (s *Service) DeleteWallet(ctx context.Context, userID, walletID uuid.UUID) error {
+	ctx = userChange(ctx, SourceAPI, userID, nil)
+	if err := s.repo.SoftDeleteWallet(ctx, userID, walletID); err != nil {
+		return err
+	}
+	s.forget(userID)
+	return nil
+}
+
+// DeleteCategory moves a category without sub-categories to the trash. Its
+// transactions keep the reference and count as uncategorised until it is restored.
+func (s *Service) DeleteCategory(ctx context.Context, userID, categoryID uuid.UUID) error {
+	categories, err := s.repo.ListCategories(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if findCategory(categories, categoryID) == nil {
+		return ErrCategoryNotFound
+	}
+	for _, c := range categories {
+		if c.ParentID != nil && *c.ParentID == categoryID {
+			return fmt.Errorf("%w: delete or move its sub-categories first", ErrInvalidCategory)
+		}
+	}
+	if err := s.repo.SoftDeleteCategory(ctx, userID, categoryID); err != nil {
+		return err
+	}
+	s.forget(userID)
+	return nil
+}
+
+// ListTrash returns the user's deleted records, newest first. An empty itemType lists
+// every type. Transactions deleted together with their wallet are not listed on their
+// own; they come back with the wallet.
+func (s *Service) ListTrash(ctx context.Context, userID uuid.UUID, itemType string) ([]TrashItem, error) {
+	if itemType != "" && itemType != TrashTransaction && itemType != TrashWallet && itemType != TrashCategory {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidTrashType, itemType)
+	}
+	items, err := s.repo.ListTrash(ctx, userID, itemType)
+	if err != nil {
+		return nil, err
+	}
+	for i := range items {
+		items[i].PurgeAt = items[i].DeletedAt.Add(s.trashRetention)
+	}
+	return items, nil
+}
+
+// RestoreTrash takes a record out of the trash. A restored transaction is applied to
+// its wallet balance again.
+func (s *Service) RestoreTrash(ctx context.Context, userID uuid.UUID, itemType string, id uuid.UUID) error {
+	var err error
+	ctx = userChange(ctx, SourceTrash, userID, nil)
+	switch itemType {
+	case TrashTransaction:
+		err = s.repo.RestoreTransaction(ctx, userID, id)
+	case TrashWallet:
+		err = s.repo.RestoreWallet(ctx, userID, id)
+	case TrashCategory:
+		err = s.repo.RestoreCategory(ctx, userID, id)
+	default:
+		return fmt.Errorf("%w: %s", ErrInvalidTrashType, itemType)
+	}
+	if err != nil {
+		return err
+	}
+	s.forget(userID)
+	return nil
+}
+
+// PurgeTrash permanently deletes records that have been in the trash longer than the
+// retention period, for all users.
+func (s *Service) PurgeTrash(ctx context.Context, now time.Time) (*PurgeResult, error) {
+	return s.repo.PurgeDeleted(ctx, now.Add(-s.trashRetention))
+}
+
+// ObjectStore deletes stored files; attachments of purged transactions are removed
+// through it.
+type ObjectStore interface {
+	Delete(ctx context.Context, key string) error
+}
+
+// TrashPurger periodically empties expired trash.
+type TrashPurger struct {
+	service  *Service
+	interval time.Duration
+	store    ObjectStore
+}
+
+func NewTrashPurger(service *Service, interval time.Duration, store ObjectStore) *TrashPurger {
+	if interval <= 0 {
+		interval = time.Hour
+	}
+	return &TrashPurger{service: service, interval: interval, store: store}
+}
+
+// Run purges immediately and then on every tick until ctx is cancelled.
+func (p *TrashPurger) Run(ctx context.Context) {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		res, err := p.service.PurgeTrash(ctx, time.Now())
+		if err != nil && ctx.Err() == nil {
+			log.Printf("[TRASH_ERROR] purge: %v", err)
+		}
+		if res != nil {
+			if n := res.Transactions + res.Wallets + res.Categories; n > 0 {
+				log.Printf("[TRASH] purged %d transaction(s), %d wallet(s), %d categor(ies)", res.Transactions, res.Wallets, res.Categories)
+			}
+			// File dihapus setelah commit; kegagalan hanya meninggalkan file yatim
+			for _, key := range res.ObjectKeys {
+				if p.store == nil {
+					break
+				}
+				if err := p.store.Delete(ctx, key); err != nil {
+					log.Printf("[TRASH_ERROR] delete object %s: %v", key, err)
+				}
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,411 @@
+package transaction
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/Jomesi149/Implementasi-LASTI/backend/internal/sqlutil"
+)
+
+// SoftDeleteTransaction marks a live transaction deleted; the ledger reverses its
+// balance effect.
+func (r *SQLRepository) SoftDeleteTransaction(ctx context.Context, userID, transactionID uuid.UUID) (err error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	// Kaki transfer selalu dihapus bersama pasangannya
+	ids, err := selectTransactionIDs(ctx, tx, `SELECT t.id FROM finance.transactions t
+		WHERE t.user_id = $2 AND t.deleted_at IS NULL
+			AND (t.id = $1 OR t.transfer_id = (SELECT transfer_id FROM finance.transactions WHERE id = $1))
+		FOR UPDATE`, transactionID, userID)
+	if err != nil {
+		return err
+	}
+	if !slices.Contains(ids, transactionID) {
+		return ErrTransactionNotFound
+	}
+	if err = trashTransactions(ctx, tx, ids); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("commit tx: %w", err)
+	}
+	return nil
+}
+
+// SoftDeleteSharedTransaction marks a live transaction of walletID deleted on behalf of
+// actorID, a member of the wallet. Every leg it takes with it must be in a wallet the
+// member may edit, and debt movements stay with the owner.
+func (r *SQLRepository) SoftDeleteSharedTransaction(ctx context.Context, actorID, walletID, transactionID uuid.UUID) (err error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	rows, err := tx.QueryContext(ctx, `SELECT t.id, t.wallet_id, t.debt_id IS NOT NULL,
+			COALESCE(finance.wallet_role(t.wallet_id, $2) IN ('owner', 'editor'), FALSE)
+		FROM finance.transactions t
+		WHERE t.deleted_at IS NULL
+			AND (t.id = $1 OR t.transfer_id = (SELECT transfer_id FROM finance.transactions WHERE id = $1))
+		FOR UPDATE`, transactionID, actorID)
+	if err != nil {
+		return fmt.Errorf("select transactions: %w", err)
+	}
+	var ids []uuid.UUID
+	found, allowed := false, true
+	for rows.Next() {
+		var id, wallet uuid.UUID
+		var debt, editable bool
+		if err = rows.Scan(&id, &wallet, &debt, &editable); err != nil {
+			rows.Close()
+			return fmt.Errorf("scan transaction: %w", err)
+		}
+		if id == transactionID {
+			found = wallet == walletID && editable
+		}
+		allowed = allowed && editable && !debt
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return fmt.Errorf("select transactions: %w", err)
+	}
+	if !found {
+		return ErrTransactionNotFound
+	}
+	if !allowed {
+		return fmt.Errorf("%w: the transaction is a debt movement or a transfer with a wallet you cannot edit", ErrWalletForbidden)
+	}
+	if err = trashTransactions(ctx, tx, ids); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("commit tx: %w", err)
+	}
+	return nil
+}
+
+// trashTransactions marks ids deleted in tx and records the change.
+func trashTransactions(ctx context.Context, tx *sql.Tx, ids []uuid.UUID) error {
+	before, err := snapshotHistory(ctx, tx, ids)
+	if err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `UPDATE finance.transactions SET deleted_at = NOW() WHERE id = ANY($1::uuid[])`, uuidStrings(ids)); err != nil {
+		return fmt.Errorf("delete transaction: %w", err)
+	}
+	return recordChanges(ctx, tx, ids, before)
+}
+
+// SoftDeleteWallet marks a wallet and its live transactions deleted at the same moment.
+func (r *SQLRepository) SoftDeleteWallet(ctx context.Context, userID, walletID uuid.UUID) (err error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	var deletedAt time.Time
+	err = tx.QueryRowContext(ctx, `UPDATE finance.wallets SET deleted_at = NOW(), updated_at = NOW()
+		WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL RETURNING deleted_at`, walletID, userID).Scan(&deletedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrWalletNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("delete wallet: %w", err)
+	}
+	ids, err := selectTransactionIDs(ctx, tx, `SELECT id FROM finance.transactions WHERE wallet_id = $1 AND deleted_at IS NULL FOR UPDATE`, walletID)
+	if err != nil {
+		return err
+	}
+	before, err := snapshotHistory(ctx, tx, ids)
+	if err != nil {
+		return err
+	}
+	if _, err = tx.ExecContext(ctx, `UPDATE finance.transactions SET deleted_at = $2, deleted_with_wallet = TRUE
+		WHERE wallet_id = $1 AND deleted_at IS NULL`, walletID, deletedAt); err != nil {
+		return fmt.Errorf("delete wallet transactions: %w", err)
+	}
+	if err = recordChanges(ctx, tx, ids, before); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("commit tx: %w", err)
+	}
+	return nil
+}
+
+// SoftDeleteCategory marks a live category without live sub-categories deleted.
+func (r *SQLRepository) SoftDeleteCategory(ctx context.Context, userID, categoryID uuid.UUID) error {
+	query := `UPDATE finance.categories c SET deleted_at = NOW(), updated_at = NOW()
+		WHERE c.id = $1 AND c.user_id = $2 AND c.deleted_at IS NULL
+		AND NOT EXISTS (SELECT 1 FROM finance.categories sc WHERE sc.parent_id = c.id AND sc.deleted_at IS NULL)`
+	res, err := r.db.ExecContext(ctx, query, categoryID, userID)
+	if err != nil {
+		return fmt.Errorf("delete category: %w", err)
+	}
+	return sqlutil.ExpectAffected(res, ErrCategoryNotFound)
+}
+
+// ListTrash returns deleted records of itemType (all types when empty), newest first.
+func (r *SQLRepository) ListTrash(ctx context.Context, userID uuid.UUID, itemType string) ([]TrashItem, error) {
+	query := `SELECT type, id, label, amount, kind, wallet_id, deleted_at FROM (
+			SELECT 'transaction' AS type, t.id, COALESCE(t.note, '') AS label, t.amount::TEXT AS amount, t.kind,
+				t.wallet_id, t.deleted_at
+			FROM finance.transactions t
+			WHERE t.user_id = $1 AND t.deleted_at IS NOT NULL AND NOT t.deleted_with_wallet
+			UNION ALL
+			SELECT 'wallet', w.id, w.name, NULL, NULL, NULL, w.deleted_at
+			FROM finance.wallets w WHERE w.user_id = $1 AND w.deleted_at IS NOT NULL
+			UNION ALL
+			SELECT 'category', c.id, c.name, NULL, c.kind, NULL, c.deleted_at
+			FROM finance.categories c WHERE c.user_id = $1 AND c.deleted_at IS NOT NULL
+		) trash
+		WHERE $2 = '' OR type = $2
+		ORDER BY deleted_at DESC, id ASC`
+	rows, err := r.db.QueryContext(ctx, query, userID, itemType)
+	if err != nil {
+		return nil, fmt.Errorf("list trash: %w", err)
+	}
+	defer rows.Close()
+
+	out := []TrashItem{}
+	for rows.Next() {
+		var it TrashItem
+		var amount, kind sql.NullString
+		var walletID uuid.NullUUID
+		if err := rows.Scan(&it.Type, &it.ID, &it.Label, &amount, &kind, &walletID, &it.DeletedAt); err != nil {
+			return nil, err
+		}
+		it.Amount, it.Kind = amount.String, kind.String
+		if walletID.Valid {
+			id := walletID.UUID
+			it.WalletID = &id
+		}
+		out = append(out, it)
+	}
+	return out, rows.Err()
+}
+
+// RestoreTransaction brings back a transaction deleted on its own and re-applies it to
+// the wallet, which must not be in the trash.
+func (r *SQLRepository) RestoreTransaction(ctx context.Context, userID, transactionID uuid.UUID) (err error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	var withWallet bool
+	err = tx.QueryRowContext(ctx, `SELECT deleted_with_wallet FROM finance.transactions
+		WHERE id = $1 AND user_id = $2 AND deleted_at IS NOT NULL FOR UPDATE`, transactionID, userID).
+		Scan(&withWallet)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrTrashNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("select deleted transaction: %w", err)
+	}
+	if withWallet {
+		return fmt.Errorf("%w: restore the wallet to bring this transaction back", ErrTrashState)
+	}
+
+	// Pasangan transfer yang dihapus bersamanya ikut dipulihkan
+	ids, err := selectTransactionIDs(ctx, tx, `SELECT t.id FROM finance.transactions t
+		WHERE t.deleted_at IS NOT NULL AND NOT t.deleted_with_wallet
+			AND (t.id = $1 OR t.transfer_id = (SELECT transfer_id FROM finance.transactions WHERE id = $1))
+		FOR UPDATE`, transactionID)
+	if err != nil {
+		return err
+	}
+	var trashedWallets int
+	if err = tx.QueryRowContext(ctx, `SELECT COUNT(*) FROM (
+			SELECT w.deleted_at FROM finance.wallets w
+			WHERE w.id IN (SELECT wallet_id FROM finance.transactions WHERE id = ANY($1::uuid[])) FOR UPDATE
+		) x WHERE x.deleted_at IS NOT NULL`, uuidStrings(ids)).Scan(&trashedWallets); err != nil {
+		return fmt.Errorf("select wallet: %w", err)
+	}
+	if trashedWallets > 0 {
+		return fmt.Errorf("%w: the wallet is in the trash", ErrTrashState)
+	}
+
+	before, err := snapshotHistory(ctx, tx, ids)
+	if err != nil {
+		return err
+	}
+	if _, err = tx.ExecContext(ctx, `UPDATE finance.transactions SET deleted_at = NULL WHERE id = ANY($1::uuid[])`, uuidStrings(ids)); err != nil {
+		return fmt.Errorf("restore transaction: %w", err)
+	}
+	if err = recordChanges(ctx, tx, ids, before); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("commit tx: %w", err)
+	}
+	return nil
+}
+
+// RestoreWallet brings back a wallet together with the transactions deleted with it.
+func (r *SQLRepository) RestoreWallet(ctx context.Context, userID, walletID uuid.UUID) (err error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	res, err := tx.ExecContext(ctx, `UPDATE finance.wallets SET deleted_at = NULL, updated_at = NOW()
+		WHERE id = $1 AND user_id = $2 AND deleted_at IS NOT NULL`, walletID, userID)
+	if err != nil {
+		return fmt.Errorf("restore wallet: %w", err)
+	}
+	if err = sqlutil.ExpectAffected(res, ErrTrashNotFound); err != nil {
+		return err
+	}
+	ids, err := selectTransactionIDs(ctx, tx, `SELECT id FROM finance.transactions WHERE wallet_id = $1 AND deleted_with_wallet FOR UPDATE`, walletID)
+	if err != nil {
+		return err
+	}
+	before, err := snapshotHistory(ctx, tx, ids)
+	if err != nil {
+		return err
+	}
+	if _, err = tx.ExecContext(ctx, `UPDATE finance.transactions SET deleted_at = NULL, deleted_with_wallet = FALSE
+		WHERE wallet_id = $1 AND deleted_with_wallet`, walletID); err != nil {
+		return fmt.Errorf("restore wallet transactions: %w", err)
+	}
+	if err = recordChanges(ctx, tx, ids, before); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("commit tx: %w", err)
+	}
+	return nil
+}
+
+// RestoreCategory brings back a category whose parent, if any, is not in the trash.
+func (r *SQLRepository) RestoreCategory(ctx context.Context, userID, categoryID uuid.UUID) error {
+	var parentDeleted bool
+	err := r.db.QueryRowContext(ctx, `SELECT COALESCE(p.deleted_at IS NOT NULL, FALSE)
+		FROM finance.categories c LEFT JOIN finance.categories p ON p.id = c.parent_id
+		WHERE c.id = $1 AND c.user_id = $2 AND c.deleted_at IS NOT NULL`, categoryID, userID).Scan(&parentDeleted)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrTrashNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("select deleted category: %w", err)
+	}
+	if parentDeleted {
+		return fmt.Errorf("%w: restore the parent category first", ErrTrashState)
+	}
+
+	res, err := r.db.ExecContext(ctx, `UPDATE finance.categories SET deleted_at = NULL, updated_at = NOW()
+		WHERE id = $1 AND user_id = $2 AND deleted_at IS NOT NULL`, categoryID, userID)
+	if err != nil {
+		return fmt.Errorf("restore category: %w", err)
+	}
+	return sqlutil.ExpectAffected(res, ErrTrashNotFound)
+}
+
+// PurgeDeleted permanently removes records deleted before the cutoff. Transactions of
+// purged wallets go too; their attachment rows are deleted here and the storage keys
+// returned so the caller can remove the files.
+func (r *SQLRepository) PurgeDeleted(ctx context.Context, before time.Time) (res *PurgeResult, err error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("begin tx: %w", err)
+	}
+
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	const expired = `(t.deleted_at < $1 OR t.wallet_id IN (SELECT id FROM finance.wallets WHERE deleted_at < $1))`
+	res = &PurgeResult{}
+	rows, err := tx.QueryContext(ctx, `DELETE FROM finance.attachments a USING finance.transactions t
+		WHERE a.transaction_id = t.id AND `+expired+` RETURNING a.storage_key, a.thumbnail_key`, before)
+	if err != nil {
+		return nil, fmt.Errorf("purge attachments: %w", err)
+	}
+	for rows.Next() {
+		var key string
+		var thumb sql.NullString
+		if err = rows.Scan(&key, &thumb); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		res.ObjectKeys = append(res.ObjectKeys, key)
+		if thumb.Valid {
+			res.ObjectKeys = append(res.ObjectKeys, thumb.String)
+		}
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	counts := []struct {
+		name  string
+		query string
+		n     *int
+	}{
+		{"purge transactions", `DELETE FROM finance.transactions t WHERE ` + expired, &res.Transactions},
+		{"purge wallets", `DELETE FROM finance.wallets WHERE deleted_at < $1`, &res.Wallets},
+		{"purge categories", `DELETE FROM finance.categories WHERE deleted_at < $1`, &res.Categories},
+	}
+	for _, c := range counts {
+		out, execErr := tx.ExecContext(ctx, c.query, before)
+		if execErr != nil {
+			err = fmt.Errorf("%s: %w", c.name, execErr)
+			return nil, err
+		}
+		n, _ := out.RowsAffected()
+		*c.n = int(n)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("commit tx: %w", err)
+	}
+	return res, nil
+}
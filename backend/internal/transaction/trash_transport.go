@@ -0,0 +1,86 @@
+package transaction
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"github.com/Jomesi149/Implementasi-LASTI/backend/pkg/response"
+)
+
+func (h *HTTPHandler) registerTrashRoutes(r chi.Router) {
+	r.Route("/trash", func(r chi.Router) {
+		r.Get("/", h.handleListTrash)
+		r.Post("/{type}/{id}/restore", h.handleRestoreTrash)
+	})
+}
+
+func (h *HTTPHandler) handleDeleteTransaction(w http.ResponseWriter, r *http.Request) {
+	uid, id, ok := idParams(w, r, "transaction")
+	if !ok {
+		return
+	}
+	if err := h.service.DeleteTransaction(r.Context(), uid, id); err != nil {
+		response.Error(w, errorStatus(err), err.Error())
+		return
+	}
+	response.JSON(w, http.StatusOK, map[string]string{"status": "transaction moved to trash"})
+}
+
+func (h *HTTPHandler) handleDeleteWallet(w http.ResponseWriter, r *http.Request) {
+	uid, id, ok := idParams(w, r, "wallet")
+	if !ok {
+		return
+	}
+	if err := h.service.DeleteWallet(r.Context(), uid, id); err != nil {
+		response.Error(w, errorStatus(err), err.Error())
+		return
+	}
+	response.JSON(w, http.StatusOK, map[string]string{"status": "wallet moved to trash"})
+}
+
+func (h *HTTPHandler) handleDeleteCategory(w http.ResponseWriter, r *http.Request) {
+	uid, id, ok := idParams(w, r, "category")
+	if !ok {
+		return
+	}
+	if err := h.service.DeleteCategory(r.Context(), uid, id); err != nil {
+		response.Error(w, errorStatus(err), err.Error())
+		return
+	}
+	response.JSON(w, http.StatusOK, map[string]string{"status": "category moved to trash"})
+}
+
+// handleListTrash lists deleted records: GET /trash?type=transaction|wallet|category
+func (h *HTTPHandler) handleListTrash(w http.ResponseWriter, r *http.Request) {
+	uid, err := getUserIDFromHeader(r)
+	if err != nil {
+		response.Error(w, http.StatusUnauthorized, "missing X-User-ID header")
+		return
+	}
+	items, err := h.service.ListTrash(r.Context(), uid, r.URL.Query().Get("type"))
+	if err != nil {
+		response.Error(w, errorStatus(err), err.Error())
+		return
+	}
+	response.JSON(w, http.StatusOK, items)
+}
+
+func (h *HTTPHandler) handleRestoreTrash(w http.ResponseWriter, r *http.Request) {
+	uid, err := getUserIDFromHeader(r)
+	if err != nil {
+		response.Error(w, http.StatusUnauthorized, "missing X-User-ID header")
+		return
+	}
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "invalid id")
+		return
+	}
+	if err := h.service.RestoreTrash(r.Context(), uid, chi.URLParam(r, "type"), id); err != nil {
+		response.Error(w, errorStatus(err), err.Error())
+		return
+	}
+	response.JSON(w, http.StatusOK, map[string]string{"status": "restored"})
+}
@@ -0,0 +1,13 @@
+-- 006_category_management.sql
+-- Kategori bisa diubah, diarsipkan, dan punya satu level sub-kategori
+
+ALTER TABLE finance.categories ADD COLUMN IF NOT EXISTS parent_id UUID NULL REFERENCES finance.categories(id) ON DELETE SET NULL;
+ALTER TABLE finance.categories ADD COLUMN IF NOT EXISTS archived_at TIMESTAMP WITH TIME ZONE NULL;
+ALTER TABLE finance.categories ADD COLUMN IF NOT EXISTS updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW();
+
+CREATE INDEX IF NOT EXISTS idx_categories_parent_id ON finance.categories(parent_id);
+
+-- CATATAN:
+-- 1. parent_id hanya boleh menunjuk kategori induk (tanpa parent) dengan kind yang sama
+-- 2. Pengeluaran sub-kategori dihitung ke budget dan analytics milik induknya
+-- 3. Kategori yang diarsipkan tidak muncul di daftar, tapi transaksi lamanya tetap utuh
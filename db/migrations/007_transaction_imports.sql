@@ -0,0 +1,23 @@
+-- 007_transaction_imports.sql
+-- Import riwayat transaksi dari file bank (preview dulu, commit, lalu bisa di-undo)
+
+CREATE TABLE IF NOT EXISTS finance.import_batches (
+    id UUID PRIMARY KEY,
+    user_id UUID NOT NULL,
+    wallet_id UUID NOT NULL REFERENCES finance.wallets(id) ON DELETE CASCADE,
+    source TEXT NOT NULL,                       -- csv
+    filename TEXT NOT NULL DEFAULT '',
+    content BYTEA NOT NULL,                     -- file asli, diparse ulang saat preview/commit
+    settings JSONB NOT NULL DEFAULT '{}'::JSONB, -- delimiter, format tanggal, desimal, mapping kolom
+    status TEXT NOT NULL DEFAULT 'uploaded',    -- uploaded | committed | undone
+    row_count INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    committed_at TIMESTAMP WITH TIME ZONE NULL,
+    undone_at TIMESTAMP WITH TIME ZONE NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_import_batches_user_id ON finance.import_batches(user_id);
+
+ALTER TABLE finance.transactions ADD COLUMN IF NOT EXISTS import_batch_id UUID NULL REFERENCES finance.import_batches(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_transactions_import_batch ON finance.transactions(import_batch_id);
@@ -0,0 +1,10 @@
+-- 008_transaction_external_ids.sql
+-- ID transaksi dari bank (FITID pada OFX) untuk mencegah import ganda
+
+ALTER TABLE finance.transactions ADD COLUMN IF NOT EXISTS external_id TEXT NULL;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_transactions_wallet_external_id
+    ON finance.transactions(wallet_id, external_id)
+    WHERE external_id IS NOT NULL;
+
+COMMENT ON COLUMN finance.import_batches.source IS 'csv | ofx | qif';
@@ -0,0 +1,41 @@
+-- 009_recurring_transactions.sql
+-- Transaksi berulang (gaji, sewa, langganan) yang dibuat otomatis oleh scheduler
+
+CREATE TABLE IF NOT EXISTS finance.recurring_rules (
+    id UUID PRIMARY KEY,
+    user_id UUID NOT NULL,
+    wallet_id UUID NOT NULL REFERENCES finance.wallets(id) ON DELETE CASCADE,
+    category_id UUID NULL REFERENCES finance.categories(id) ON DELETE SET NULL,
+    amount NUMERIC(20,2) NOT NULL,
+    kind TEXT NOT NULL,                           -- in | out
+    note TEXT NULL,
+    frequency TEXT NOT NULL,                      -- daily | weekly | monthly | yearly
+    interval_count INTEGER NOT NULL DEFAULT 1,    -- setiap N hari/minggu/bulan/tahun
+    day_of_month INTEGER NULL,                    -- 1-31, dipotong ke akhir bulan bila bulannya lebih pendek
+    start_date DATE NOT NULL,
+    end_date DATE NULL,
+    max_occurrences INTEGER NULL,
+    occurrences_count INTEGER NOT NULL DEFAULT 0,
+    next_run_date DATE NULL,                      -- NULL jika aturan sudah selesai
+    status TEXT NOT NULL DEFAULT 'active',        -- active | paused | completed
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_recurring_rules_user_id ON finance.recurring_rules(user_id);
+CREATE INDEX IF NOT EXISTS idx_recurring_rules_due ON finance.recurring_rules(next_run_date) WHERE status = 'active';
+
+-- Satu baris per tanggal jatuh tempo. UNIQUE(rule_id, due_date) menjamin satu tanggal
+-- hanya diproses sekali walau ada beberapa instance API atau restart.
+CREATE TABLE IF NOT EXISTS finance.recurring_occurrences (
+    id UUID PRIMARY KEY,
+    rule_id UUID NOT NULL REFERENCES finance.recurring_rules(id) ON DELETE CASCADE,
+    due_date DATE NOT NULL,
+    transaction_id UUID NULL,
+    status TEXT NOT NULL,                         -- pending | posted | skipped
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    UNIQUE(rule_id, due_date)
+);
+
+CREATE INDEX IF NOT EXISTS idx_recurring_occurrences_pending ON finance.recurring_occurrences(created_at) WHERE status = 'pending';
@@ -0,0 +1,28 @@
+-- 010_transaction_splits.sql
+-- Split transaksi: satu transaksi dibagi ke beberapa kategori (mis. belanja makanan + rumah tangga)
+
+CREATE TABLE IF NOT EXISTS finance.transaction_splits (
+    id UUID PRIMARY KEY,
+    transaction_id UUID NOT NULL REFERENCES finance.transactions(id) ON DELETE CASCADE,
+    category_id UUID NULL REFERENCES finance.categories(id) ON DELETE SET NULL,
+    amount NUMERIC(20,2) NOT NULL,
+    note TEXT NULL,
+    position INTEGER NOT NULL DEFAULT 0
+);
+
+CREATE INDEX IF NOT EXISTS idx_transaction_splits_transaction_id ON finance.transaction_splits(transaction_id);
+CREATE INDEX IF NOT EXISTS idx_transaction_splits_category_id ON finance.transaction_splits(category_id);
+
+-- Satu baris per kategori: transaksi biasa muncul sekali, transaksi split sekali per baris split.
+-- Dipakai untuk total per kategori (budget, analytics); saldo dompet tetap dari finance.transactions.
+CREATE OR REPLACE VIEW finance.transaction_lines AS
+SELECT
+    t.id AS transaction_id,
+    t.user_id,
+    t.wallet_id,
+    COALESCE(s.category_id, t.category_id) AS category_id,
+    COALESCE(s.amount, t.amount) AS amount,
+    t.kind,
+    t.occurred_at
+FROM finance.transactions t
+LEFT JOIN finance.transaction_splits s ON s.transaction_id = t.id;
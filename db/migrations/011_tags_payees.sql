@@ -0,0 +1,33 @@
+-- 011_tags_payees.sql
+-- Tag bebas (banyak-ke-banyak) dan payee/merchant pada transaksi
+
+CREATE TABLE IF NOT EXISTS finance.tags (
+    id UUID PRIMARY KEY,
+    user_id UUID NOT NULL,
+    name TEXT NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Nama tag unik per user tanpa membedakan huruf besar/kecil
+CREATE UNIQUE INDEX IF NOT EXISTS idx_tags_user_name ON finance.tags(user_id, LOWER(name));
+
+CREATE TABLE IF NOT EXISTS finance.transaction_tags (
+    transaction_id UUID NOT NULL REFERENCES finance.transactions(id) ON DELETE CASCADE,
+    tag_id UUID NOT NULL REFERENCES finance.tags(id) ON DELETE CASCADE,
+    PRIMARY KEY (transaction_id, tag_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_transaction_tags_tag_id ON finance.transaction_tags(tag_id);
+
+CREATE TABLE IF NOT EXISTS finance.payees (
+    id UUID PRIMARY KEY,
+    user_id UUID NOT NULL,
+    name TEXT NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_payees_user_name ON finance.payees(user_id, LOWER(name));
+
+ALTER TABLE finance.transactions ADD COLUMN IF NOT EXISTS payee_id UUID NULL REFERENCES finance.payees(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_transactions_payee_id ON finance.transactions(payee_id);
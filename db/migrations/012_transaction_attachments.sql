@@ -0,0 +1,17 @@
+-- 012_transaction_attachments.sql
+-- Lampiran transaksi (foto struk, invoice PDF). Isi file disimpan di storage (disk lokal / S3),
+-- tabel ini hanya menyimpan metadata dan key objeknya.
+
+CREATE TABLE IF NOT EXISTS finance.attachments (
+    id UUID PRIMARY KEY,
+    user_id UUID NOT NULL,
+    transaction_id UUID NOT NULL REFERENCES finance.transactions(id) ON DELETE CASCADE,
+    filename TEXT NOT NULL,
+    content_type TEXT NOT NULL,
+    size_bytes BIGINT NOT NULL,
+    storage_key TEXT NOT NULL,
+    thumbnail_key TEXT NULL,                    -- hanya untuk gambar
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_attachments_transaction_id ON finance.attachments(transaction_id);
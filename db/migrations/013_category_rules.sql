@@ -0,0 +1,22 @@
+-- 013_category_rules.sql
+-- Aturan kategorisasi otomatis: transaksi tanpa kategori diberi kategori dari aturan pertama
+-- (urut priority) yang semua kondisinya cocok
+
+CREATE TABLE IF NOT EXISTS finance.category_rules (
+    id UUID PRIMARY KEY,
+    user_id UUID NOT NULL,
+    name TEXT NOT NULL,
+    priority INTEGER NOT NULL DEFAULT 100,        -- angka kecil diperiksa lebih dulu
+    category_id UUID NOT NULL REFERENCES finance.categories(id) ON DELETE CASCADE,
+    note_contains TEXT NULL,                      -- cocok tanpa membedakan huruf besar/kecil
+    note_regex TEXT NULL,
+    payee_id UUID NULL REFERENCES finance.payees(id) ON DELETE CASCADE,
+    wallet_id UUID NULL REFERENCES finance.wallets(id) ON DELETE CASCADE,
+    min_amount NUMERIC(20,2) NULL,
+    max_amount NUMERIC(20,2) NULL,
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_category_rules_user_priority ON finance.category_rules(user_id, priority);
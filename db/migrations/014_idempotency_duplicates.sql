@@ -0,0 +1,30 @@
+-- 014_idempotency_duplicates.sql
+-- Idempotency-Key untuk POST /transactions (respons disimpan untuk diputar ulang) dan
+-- pasangan duplikat yang sudah ditolak user di antrean review
+
+CREATE TABLE IF NOT EXISTS finance.idempotency_keys (
+    user_id UUID NOT NULL,
+    key TEXT NOT NULL,
+    request_hash TEXT NOT NULL,
+    -- NULL selama request pertama masih diproses
+    status_code INT NULL,
+    response BYTEA NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    PRIMARY KEY (user_id, key)
+);
+
+CREATE INDEX IF NOT EXISTS idx_idempotency_keys_created_at ON finance.idempotency_keys(created_at);
+
+-- transaction_a < transaction_b agar satu pasangan hanya punya satu baris
+CREATE TABLE IF NOT EXISTS finance.duplicate_dismissals (
+    transaction_a UUID NOT NULL REFERENCES finance.transactions(id) ON DELETE CASCADE,
+    transaction_b UUID NOT NULL REFERENCES finance.transactions(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL,
+    dismissed_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    PRIMARY KEY (transaction_a, transaction_b),
+    CHECK (transaction_a < transaction_b)
+);
+
+-- Mempercepat pencarian kandidat duplikat (dompet + nominal + tanggal)
+CREATE INDEX IF NOT EXISTS idx_transactions_wallet_amount_occurred
+    ON finance.transactions(wallet_id, amount, occurred_at);
@@ -0,0 +1,33 @@
+-- 015_soft_delete.sql
+-- Soft delete untuk transaksi, dompet dan kategori: baris masuk tempat sampah (trash),
+-- bisa dipulihkan, dan baru dihapus permanen oleh job purge setelah masa retensi
+
+ALTER TABLE finance.transactions ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP WITH TIME ZONE NULL;
+-- TRUE jika transaksi ikut terhapus karena dompetnya dihapus; dipulihkan bersama dompetnya
+ALTER TABLE finance.transactions ADD COLUMN IF NOT EXISTS deleted_with_wallet BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE finance.wallets ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP WITH TIME ZONE NULL;
+ALTER TABLE finance.categories ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP WITH TIME ZONE NULL;
+
+CREATE INDEX IF NOT EXISTS idx_transactions_deleted_at ON finance.transactions(deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_wallets_deleted_at ON finance.wallets(deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_categories_deleted_at ON finance.categories(deleted_at) WHERE deleted_at IS NOT NULL;
+
+-- Transaksi di trash tidak ikut dihitung di budget dan analytics
+CREATE OR REPLACE VIEW finance.transaction_lines AS
+SELECT
+    t.id AS transaction_id,
+    t.user_id,
+    t.wallet_id,
+    COALESCE(s.category_id, t.category_id) AS category_id,
+    COALESCE(s.amount, t.amount) AS amount,
+    t.kind,
+    t.occurred_at
+FROM finance.transactions t
+LEFT JOIN finance.transaction_splits s ON s.transaction_id = t.id
+WHERE t.deleted_at IS NULL;
+
+-- CATATAN:
+-- 1. Menghapus transaksi membalik efeknya pada saldo dompet; memulihkan menerapkannya lagi
+-- 2. Menghapus dompet ikut menghapus transaksinya tanpa mengubah saldo
+-- 3. Transaksi dengan kategori di trash dianggap tanpa kategori di laporan
+-- 4. External id transaksi di trash tetap terpakai, jadi impor ulang baris yang sama dianggap duplikat
@@ -0,0 +1,28 @@
+-- 016_transaction_history.sql
+-- Riwayat perubahan transaksi: setiap create/update/delete/restore dicatat sebagai versi
+-- dengan nilai lama & baru, pelaku, waktu dan sumber perubahan (api, import, recurring, ...)
+
+CREATE TABLE IF NOT EXISTS finance.transaction_history (
+    id             UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    -- Tanpa foreign key supaya riwayat tetap ada setelah transaksi dihapus permanen
+    transaction_id UUID NOT NULL,
+    user_id        UUID NOT NULL,
+    version        INT NOT NULL,
+    action         TEXT NOT NULL CHECK (action IN ('create', 'update', 'delete', 'restore')),
+    source         TEXT NOT NULL,
+    -- NULL untuk perubahan oleh sistem, misalnya transaksi berulang
+    actor_id       UUID NULL,
+    -- ID objek pemicu: batch impor, aturan berulang, dsb.
+    reference_id   UUID NULL,
+    old_values     JSONB NULL,
+    new_values     JSONB NULL,
+    created_at     TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    UNIQUE (transaction_id, version)
+);
+
+CREATE INDEX IF NOT EXISTS idx_transaction_history_user ON finance.transaction_history(user_id, created_at);
+
+-- CATATAN:
+-- 1. Transaksi yang sudah ada sebelum migrasi ini belum punya riwayat; versi pertamanya
+--    adalah perubahan pertama setelah migrasi (old_values berisi nilai saat itu)
+-- 2. Purge trash tidak menghapus riwayat
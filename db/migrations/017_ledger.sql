@@ -0,0 +1,104 @@
+-- 017_ledger.sql
+-- Buku besar double-entry: setiap perubahan saldo dicatat sebagai jurnal (entry) berisi
+-- posting ke akun dompet dan akun pemasukan/pengeluaran/ekuitas yang jumlahnya selalu nol.
+-- finance.wallets.balance hanya cache dari jumlah posting akun dompet.
+
+CREATE TABLE IF NOT EXISTS finance.ledger_entries (
+    id             UUID PRIMARY KEY,
+    user_id        UUID NOT NULL,
+    -- Tanpa foreign key supaya jurnal tetap ada setelah transaksi dihapus permanen
+    transaction_id UUID NULL,
+    reason         TEXT NOT NULL CHECK (reason IN ('opening', 'create', 'update', 'delete', 'restore')),
+    created_at     TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS finance.ledger_postings (
+    id           UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    entry_id     UUID NOT NULL REFERENCES finance.ledger_entries(id),
+    user_id      UUID NOT NULL,
+    account_type TEXT NOT NULL CHECK (account_type IN ('wallet', 'income', 'expense', 'equity')),
+    -- Diisi hanya untuk akun dompet; akun lain satu per user
+    wallet_id    UUID NULL,
+    -- Debit positif, kredit negatif
+    amount       NUMERIC(20,2) NOT NULL CHECK (amount <> 0),
+    CHECK ((account_type = 'wallet') = (wallet_id IS NOT NULL))
+);
+
+CREATE INDEX IF NOT EXISTS idx_ledger_entries_transaction ON finance.ledger_entries(transaction_id);
+CREATE INDEX IF NOT EXISTS idx_ledger_postings_entry ON finance.ledger_postings(entry_id);
+CREATE INDEX IF NOT EXISTS idx_ledger_postings_wallet ON finance.ledger_postings(wallet_id) WHERE wallet_id IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_ledger_postings_user ON finance.ledger_postings(user_id, account_type);
+
+-- Isi awal: satu jurnal per transaksi yang masih mempengaruhi saldo (termasuk yang ikut
+-- terhapus bersama dompetnya), lalu jurnal pembukaan untuk sisa saldo tiap dompet
+INSERT INTO finance.ledger_entries (id, user_id, transaction_id, reason, created_at)
+SELECT md5('create:' || t.id::TEXT)::UUID, t.user_id, t.id, 'create', t.created_at
+FROM finance.transactions t
+WHERE t.deleted_at IS NULL OR t.deleted_with_wallet
+ON CONFLICT (id) DO NOTHING;
+
+INSERT INTO finance.ledger_postings (entry_id, user_id, account_type, wallet_id, amount)
+SELECT md5('create:' || t.id::TEXT)::UUID, t.user_id, 'wallet', t.wallet_id,
+    CASE WHEN t.kind = 'in' THEN t.amount ELSE -t.amount END
+FROM finance.transactions t
+WHERE (t.deleted_at IS NULL OR t.deleted_with_wallet) AND t.amount <> 0
+UNION ALL
+SELECT md5('create:' || t.id::TEXT)::UUID, t.user_id, CASE WHEN t.kind = 'in' THEN 'income' ELSE 'expense' END, NULL,
+    CASE WHEN t.kind = 'in' THEN -t.amount ELSE t.amount END
+FROM finance.transactions t
+WHERE (t.deleted_at IS NULL OR t.deleted_with_wallet) AND t.amount <> 0;
+
+CREATE TEMP TABLE ledger_opening AS
+SELECT w.id AS wallet_id, w.user_id, w.created_at,
+    w.balance - COALESCE((SELECT SUM(p.amount) FROM finance.ledger_postings p WHERE p.wallet_id = w.id), 0) AS amount
+FROM finance.wallets w;
+
+INSERT INTO finance.ledger_entries (id, user_id, transaction_id, reason, created_at)
+SELECT md5('opening:' || o.wallet_id::TEXT)::UUID, o.user_id, NULL, 'opening', o.created_at
+FROM ledger_opening o WHERE o.amount <> 0
+ON CONFLICT (id) DO NOTHING;
+
+INSERT INTO finance.ledger_postings (entry_id, user_id, account_type, wallet_id, amount)
+SELECT md5('opening:' || o.wallet_id::TEXT)::UUID, o.user_id, 'wallet', o.wallet_id, o.amount
+FROM ledger_opening o WHERE o.amount <> 0
+UNION ALL
+SELECT md5('opening:' || o.wallet_id::TEXT)::UUID, o.user_id, 'equity', NULL, -o.amount
+FROM ledger_opening o WHERE o.amount <> 0;
+
+DROP TABLE ledger_opening;
+
+-- Jurnal tidak boleh diubah atau dihapus; koreksi selalu lewat jurnal pembalik
+CREATE OR REPLACE FUNCTION finance.ledger_immutable() RETURNS TRIGGER AS $$
+BEGIN
+    RAISE EXCEPTION 'ledger rows are immutable';
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS ledger_entries_immutable ON finance.ledger_entries;
+CREATE TRIGGER ledger_entries_immutable BEFORE UPDATE OR DELETE ON finance.ledger_entries
+    FOR EACH ROW EXECUTE FUNCTION finance.ledger_immutable();
+DROP TRIGGER IF EXISTS ledger_postings_immutable ON finance.ledger_postings;
+CREATE TRIGGER ledger_postings_immutable BEFORE UPDATE OR DELETE ON finance.ledger_postings
+    FOR EACH ROW EXECUTE FUNCTION finance.ledger_immutable();
+
+-- Jumlah posting satu jurnal harus nol, dicek saat commit
+CREATE OR REPLACE FUNCTION finance.ledger_entry_balanced() RETURNS TRIGGER AS $$
+BEGIN
+    IF (SELECT SUM(amount) FROM finance.ledger_postings WHERE entry_id = NEW.entry_id) <> 0 THEN
+        RAISE EXCEPTION 'ledger entry % does not balance', NEW.entry_id;
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS ledger_postings_balanced ON finance.ledger_postings;
+CREATE CONSTRAINT TRIGGER ledger_postings_balanced AFTER INSERT ON finance.ledger_postings
+    DEFERRABLE INITIALLY DEFERRED
+    FOR EACH ROW EXECUTE FUNCTION finance.ledger_entry_balanced();
+
+-- CATATAN:
+-- 1. Saldo awal dompet dicatat sebagai jurnal 'opening' melawan akun ekuitas
+-- 2. Mengubah atau menghapus transaksi menulis jurnal baru yang membalik posting lama
+--    dan memposting nilai baru; jurnal lama tidak pernah disentuh
+-- 3. Mengganti kategori tidak membuat jurnal karena akun pemasukan/pengeluaran per user
+-- 4. GET /ledger/check membandingkan saldo cache dengan jumlah posting
@@ -0,0 +1,32 @@
+-- 018_reconciliation.sql
+-- Rekonsiliasi saldo dompet dengan saldo rekening koran: user memasukkan saldo & tanggal
+-- statement, menandai transaksi yang sudah muncul di bank (cleared), lalu menyelesaikan
+-- rekonsiliasi dengan transaksi penyesuaian untuk selisih yang tersisa
+
+CREATE TABLE IF NOT EXISTS finance.reconciliations (
+    id                        UUID PRIMARY KEY,
+    user_id                   UUID NOT NULL,
+    wallet_id                 UUID NOT NULL REFERENCES finance.wallets(id) ON DELETE CASCADE,
+    statement_date            DATE NOT NULL,
+    statement_balance         NUMERIC(20,2) NOT NULL,
+    status                    TEXT NOT NULL DEFAULT 'open' CHECK (status IN ('open', 'completed', 'cancelled')),
+    -- Saldo cleared saat rekonsiliasi selesai, sebelum penyesuaian
+    cleared_balance           NUMERIC(20,2) NULL,
+    adjustment_transaction_id UUID NULL,
+    created_at                TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    completed_at              TIMESTAMP WITH TIME ZONE NULL
+);
+
+-- Hanya satu rekonsiliasi terbuka per dompet
+CREATE UNIQUE INDEX IF NOT EXISTS idx_reconciliations_open_wallet ON finance.reconciliations(wallet_id) WHERE status = 'open';
+CREATE INDEX IF NOT EXISTS idx_reconciliations_wallet ON finance.reconciliations(wallet_id, statement_date);
+
+ALTER TABLE finance.transactions ADD COLUMN IF NOT EXISTS cleared_at TIMESTAMP WITH TIME ZONE NULL;
+-- Diisi saat rekonsiliasi selesai; transaksi ini sudah cocok dengan statement bank
+ALTER TABLE finance.transactions ADD COLUMN IF NOT EXISTS reconciliation_id UUID NULL REFERENCES finance.reconciliations(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_transactions_wallet_uncleared ON finance.transactions(wallet_id, occurred_at) WHERE cleared_at IS NULL AND deleted_at IS NULL;
+
+-- CATATAN:
+-- 1. Saldo cleared = saldo dompet dikurangi efek semua transaksi yang belum cleared
+-- 2. Transaksi yang sudah direkonsiliasi tidak bisa di-uncleared lagi
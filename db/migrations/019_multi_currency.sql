@@ -0,0 +1,92 @@
+-- 019_multi_currency.sql
+-- Multi mata uang: kode ISO 4217 di dompet & transaksi, mata uang dasar per user, tabel
+-- kurs (manual per user atau dari provider), transfer antar dompet, dan fungsi konversi
+-- yang dipakai budget & analytics
+
+ALTER TABLE finance.wallets ADD COLUMN IF NOT EXISTS currency CHAR(3) NOT NULL DEFAULT 'IDR' CHECK (currency ~ '^[A-Z]{3}$');
+-- Selalu sama dengan mata uang dompetnya; disimpan supaya laporan tidak perlu join dompet
+ALTER TABLE finance.transactions ADD COLUMN IF NOT EXISTS currency CHAR(3) NOT NULL DEFAULT 'IDR' CHECK (currency ~ '^[A-Z]{3}$');
+-- Dua transaksi (keluar & masuk) dengan transfer_id yang sama membentuk satu transfer
+ALTER TABLE finance.transactions ADD COLUMN IF NOT EXISTS transfer_id UUID NULL;
+CREATE INDEX IF NOT EXISTS idx_transactions_transfer_id ON finance.transactions(transfer_id) WHERE transfer_id IS NOT NULL;
+
+CREATE TABLE IF NOT EXISTS finance.user_settings (
+    user_id       UUID PRIMARY KEY,
+    base_currency CHAR(3) NOT NULL DEFAULT 'IDR' CHECK (base_currency ~ '^[A-Z]{3}$'),
+    updated_at    TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+-- 1 base_currency = rate quote_currency. user_id NULL berarti kurs dari provider (berlaku
+-- untuk semua user); kurs manual milik satu user dan didahulukan pada tanggal yang sama
+CREATE TABLE IF NOT EXISTS finance.exchange_rates (
+    id             UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id        UUID NULL,
+    base_currency  CHAR(3) NOT NULL,
+    quote_currency CHAR(3) NOT NULL,
+    rate_date      DATE NOT NULL,
+    rate           NUMERIC(24,10) NOT NULL CHECK (rate > 0),
+    source         TEXT NOT NULL,
+    created_at     TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    CHECK (base_currency <> quote_currency)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_exchange_rates_unique
+    ON finance.exchange_rates(COALESCE(user_id, '00000000-0000-0000-0000-000000000000'::UUID), base_currency, quote_currency, rate_date);
+CREATE INDEX IF NOT EXISTS idx_exchange_rates_pair ON finance.exchange_rates(base_currency, quote_currency, rate_date DESC);
+
+-- Posting buku besar dicatat dalam mata uang dompetnya; satu jurnal selalu satu mata uang
+ALTER TABLE finance.ledger_postings ADD COLUMN IF NOT EXISTS currency CHAR(3) NOT NULL DEFAULT 'IDR';
+-- Akun 'transfer' menampung sisi lawan transfer antar dompet
+ALTER TABLE finance.ledger_postings DROP CONSTRAINT IF EXISTS ledger_postings_account_type_check;
+ALTER TABLE finance.ledger_postings ADD CONSTRAINT ledger_postings_account_type_check
+    CHECK (account_type IN ('wallet', 'income', 'expense', 'equity', 'transfer'));
+
+CREATE OR REPLACE FUNCTION finance.base_currency(p_user UUID) RETURNS CHAR(3) AS $$
+    SELECT COALESCE((SELECT base_currency FROM finance.user_settings WHERE user_id = p_user), 'IDR'::CHAR(3));
+$$ LANGUAGE sql STABLE;
+
+-- Kurs terbaru pada atau sebelum p_on, langsung atau kebalikan dari pasangan sebaliknya
+CREATE OR REPLACE FUNCTION finance.exchange_rate_quote(p_from CHAR(3), p_to CHAR(3), p_on DATE, p_user UUID)
+RETURNS TABLE (rate NUMERIC, rate_date DATE) AS $$
+    SELECT x.rate, x.rate_date FROM (
+        SELECT r.rate, r.rate_date, r.user_id FROM finance.exchange_rates r
+        WHERE r.base_currency = p_from AND r.quote_currency = p_to AND r.rate_date <= p_on
+            AND (r.user_id = p_user OR r.user_id IS NULL)
+        UNION ALL
+        SELECT 1 / r.rate, r.rate_date, r.user_id FROM finance.exchange_rates r
+        WHERE r.base_currency = p_to AND r.quote_currency = p_from AND r.rate_date <= p_on
+            AND (r.user_id = p_user OR r.user_id IS NULL)
+    ) x
+    ORDER BY x.rate_date DESC, x.user_id NULLS LAST
+    LIMIT 1;
+$$ LANGUAGE sql STABLE;
+
+-- NULL jika belum ada kurs untuk pasangan tersebut
+CREATE OR REPLACE FUNCTION finance.convert_amount(p_amount NUMERIC, p_from CHAR(3), p_to CHAR(3), p_on DATE, p_user UUID)
+RETURNS NUMERIC AS $$
+    SELECT CASE WHEN p_from = p_to THEN p_amount
+        ELSE ROUND(p_amount * (SELECT q.rate FROM finance.exchange_rate_quote(p_from, p_to, p_on, p_user) q), 2) END;
+$$ LANGUAGE sql STABLE;
+
+-- Transfer antar dompet bukan pemasukan/pengeluaran, jadi tidak ikut di budget & analytics
+CREATE OR REPLACE VIEW finance.transaction_lines AS
+SELECT
+    t.id AS transaction_id,
+    t.user_id,
+    t.wallet_id,
+    COALESCE(s.category_id, t.category_id) AS category_id,
+    COALESCE(s.amount, t.amount) AS amount,
+    t.kind,
+    t.occurred_at,
+    t.currency
+FROM finance.transactions t
+LEFT JOIN finance.transaction_splits s ON s.transaction_id = t.id
+WHERE t.deleted_at IS NULL AND t.transfer_id IS NULL;
+
+-- CATATAN:
+-- 1. Data lama dianggap IDR, mata uang default aplikasi
+-- 2. Budget & analytics dihitung dalam mata uang dasar user dengan kurs pada tanggal transaksi
+--    (atau kurs terakhir sebelumnya); transaksi tanpa kurs tidak ikut dihitung
+-- 3. Mata uang dompet tidak bisa diubah setelah dibuat
+-- 4. Kedua kaki transfer dihapus & dipulihkan bersama; menghapus dompet hanya membawa
+--    kaki transfer di dompet itu
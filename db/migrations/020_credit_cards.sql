@@ -0,0 +1,45 @@
+-- 020_credit_cards.sql
+-- Kartu kredit sebagai jenis dompet (type = 'credit_card'): saldo negatif berarti utang,
+-- dengan limit, tanggal cetak tagihan dan jatuh tempo. Ditambah tabel notifikasi in-app
+-- untuk pengingat sebelum jatuh tempo
+
+CREATE TABLE IF NOT EXISTS finance.credit_cards (
+    wallet_id        UUID PRIMARY KEY REFERENCES finance.wallets(id) ON DELETE CASCADE,
+    user_id          UUID NOT NULL,
+    credit_limit     NUMERIC(20,2) NOT NULL CHECK (credit_limit >= 0),
+    -- Tanggal cetak tagihan; di bulan yang lebih pendek jatuh ke hari terakhir bulan itu
+    statement_day    SMALLINT NOT NULL CHECK (statement_day BETWEEN 1 AND 31),
+    -- Jatuh tempo = tanggal cetak + payment_due_days; maksimal 28 supaya tidak melewati
+    -- tanggal cetak berikutnya
+    payment_due_days SMALLINT NOT NULL CHECK (payment_due_days BETWEEN 1 AND 28),
+    -- Pengingat dikirim mulai sekian hari sebelum jatuh tempo
+    reminder_days    SMALLINT NOT NULL DEFAULT 3 CHECK (reminder_days BETWEEN 0 AND 28),
+    created_at       TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at       TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_credit_cards_user_id ON finance.credit_cards(user_id);
+
+CREATE TABLE IF NOT EXISTS finance.notifications (
+    id           UUID PRIMARY KEY,
+    user_id      UUID NOT NULL,
+    kind         TEXT NOT NULL,
+    title        TEXT NOT NULL,
+    body         TEXT NOT NULL,
+    -- Objek yang dibahas notifikasi, mis. dompet kartu kredit
+    reference_id UUID NULL,
+    due_date     DATE NULL,
+    -- Mencegah pengingat yang sama terkirim dua kali, mis. 'credit_card_due:<wallet>:<tanggal>'
+    dedupe_key   TEXT NOT NULL,
+    created_at   TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    read_at      TIMESTAMP WITH TIME ZONE NULL,
+    UNIQUE (user_id, dedupe_key)
+);
+
+CREATE INDEX IF NOT EXISTS idx_notifications_user ON finance.notifications(user_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_notifications_unread ON finance.notifications(user_id) WHERE read_at IS NULL;
+
+-- CATATAN:
+-- 1. Pembelian dengan kartu dicatat sebagai transaksi 'out' di dompet kartu; pembayaran
+--    tagihan dari dompet lain adalah transfer, jadi tidak dihitung sebagai pengeluaran
+-- 2. Dompet kartu dibuat lewat POST /credit-cards, bukan POST /wallets
@@ -0,0 +1,56 @@
+-- 021_debts.sql
+-- Hutang/piutang: uang yang dipinjamkan ke atau dipinjam dari seseorang (payee sebagai
+-- pihak lawan), dengan jatuh tempo & bunga opsional. Pencairan dan cicilan adalah
+-- transaksi biasa di dompet yang ditandai debt_id, jadi tidak dihitung sebagai
+-- pemasukan/pengeluaran
+
+CREATE TABLE IF NOT EXISTS finance.debts (
+    id              UUID PRIMARY KEY,
+    user_id         UUID NOT NULL,
+    -- Pihak lawan; payee tidak bisa dihapus selama masih punya hutang/piutang
+    counterparty_id UUID NOT NULL REFERENCES finance.payees(id) ON DELETE RESTRICT,
+    -- 'lent' = piutang (kita meminjamkan), 'borrowed' = hutang (kita meminjam)
+    direction       TEXT NOT NULL CHECK (direction IN ('lent', 'borrowed')),
+    principal       NUMERIC(20,2) NOT NULL CHECK (principal > 0),
+    currency        CHAR(3) NOT NULL CHECK (currency ~ '^[A-Z]{3}$'),
+    -- Bunga sederhana per tahun dalam persen, dihitung harian dari started_on
+    interest_rate   NUMERIC(7,4) NOT NULL DEFAULT 0 CHECK (interest_rate >= 0),
+    started_on      DATE NOT NULL,
+    due_date        DATE NULL,
+    note            TEXT NULL,
+    created_at      TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at      TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_debts_user_id ON finance.debts(user_id);
+CREATE INDEX IF NOT EXISTS idx_debts_counterparty_id ON finance.debts(counterparty_id);
+
+ALTER TABLE finance.transactions ADD COLUMN IF NOT EXISTS debt_id UUID NULL REFERENCES finance.debts(id) ON DELETE SET NULL;
+CREATE INDEX IF NOT EXISTS idx_transactions_debt_id ON finance.transactions(debt_id) WHERE debt_id IS NOT NULL;
+
+-- Akun 'debt' menampung sisi lawan transaksi hutang/piutang (saldo = piutang bersih)
+ALTER TABLE finance.ledger_postings DROP CONSTRAINT IF EXISTS ledger_postings_account_type_check;
+ALTER TABLE finance.ledger_postings ADD CONSTRAINT ledger_postings_account_type_check
+    CHECK (account_type IN ('wallet', 'income', 'expense', 'equity', 'transfer', 'debt'));
+
+-- Transaksi hutang/piutang bukan pemasukan/pengeluaran, jadi tidak ikut di budget & analytics
+CREATE OR REPLACE VIEW finance.transaction_lines AS
+SELECT
+    t.id AS transaction_id,
+    t.user_id,
+    t.wallet_id,
+    COALESCE(s.category_id, t.category_id) AS category_id,
+    COALESCE(s.amount, t.amount) AS amount,
+    t.kind,
+    t.occurred_at,
+    t.currency
+FROM finance.transactions t
+LEFT JOIN finance.transaction_splits s ON s.transaction_id = t.id
+WHERE t.deleted_at IS NULL AND t.transfer_id IS NULL AND t.debt_id IS NULL;
+
+-- CATATAN:
+-- 1. Pencairan: 'out' untuk piutang, 'in' untuk hutang; cicilan kebalikannya. Semua transaksi
+--    satu hutang harus dalam mata uang hutang tersebut
+-- 2. Hutang hanya bisa dihapus setelah semua transaksinya dilepas atau dihapus; transaksi di
+--    trash yang masih menunjuk ke hutang itu kembali menjadi transaksi biasa
+-- 3. Sisa = pokok + bunga - cicilan; bunga berhenti dihitung saat lunas
@@ -0,0 +1,34 @@
+-- 022_goals.sql
+-- Target tabungan: nominal target, tenggat opsional, dan sumber dana berupa dompet khusus
+-- (saldo dompet = dana terkumpul) atau sisihan dari transaksi (goal_contributions)
+
+CREATE TABLE IF NOT EXISTS finance.goals (
+    id            UUID PRIMARY KEY,
+    user_id       UUID NOT NULL,
+    name          TEXT NOT NULL,
+    target_amount NUMERIC(20,2) NOT NULL CHECK (target_amount > 0),
+    currency      CHAR(3) NOT NULL CHECK (currency ~ '^[A-Z]{3}$'),
+    deadline      DATE NULL,
+    -- NULL berarti target memakai sisihan dari transaksi
+    wallet_id     UUID NULL REFERENCES finance.wallets(id) ON DELETE SET NULL,
+    created_at    TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at    TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_goals_user_id ON finance.goals(user_id);
+
+-- Sebagian (atau seluruh) nominal transaksi yang disisihkan untuk satu target
+CREATE TABLE IF NOT EXISTS finance.goal_contributions (
+    goal_id        UUID NOT NULL REFERENCES finance.goals(id) ON DELETE CASCADE,
+    transaction_id UUID NOT NULL REFERENCES finance.transactions(id) ON DELETE CASCADE,
+    amount         NUMERIC(20,2) NOT NULL CHECK (amount > 0),
+    created_at     TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (goal_id, transaction_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_goal_contributions_transaction_id ON finance.goal_contributions(transaction_id);
+
+-- CATATAN:
+-- 1. Total sisihan satu transaksi ke semua target tidak boleh melebihi nominalnya
+-- 2. Sisihan dari transaksi yang ada di trash tidak dihitung sampai transaksinya dipulihkan
+-- 3. Laju menabung dihitung dari 90 hari terakhir: arus bersih dompet target, atau total sisihan
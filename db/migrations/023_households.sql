@@ -0,0 +1,98 @@
+-- 023_households.sql
+-- Dompet & ruang rumah tangga bersama: pemilik mengundang user lain lewat email dengan
+-- peran owner/editor/viewer, baik untuk satu dompet maupun satu household. Data tetap milik
+-- pemilik (user_id); anggota bekerja di atas data pemilik dan tercatat di created_by
+
+CREATE TABLE IF NOT EXISTS finance.households (
+    id         UUID PRIMARY KEY,
+    owner_id   UUID NOT NULL,
+    name       TEXT NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_households_owner_id ON finance.households(owner_id);
+
+-- Pemilik household juga tercatat sebagai anggota dengan peran 'owner'
+CREATE TABLE IF NOT EXISTS finance.household_members (
+    household_id UUID NOT NULL REFERENCES finance.households(id) ON DELETE CASCADE,
+    user_id      UUID NOT NULL,
+    role         TEXT NOT NULL CHECK (role IN ('owner', 'editor', 'viewer')),
+    created_at   TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (household_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_household_members_user_id ON finance.household_members(user_id);
+
+-- Dompet pemilik yang masuk ke ruang household; anggota household mengakses dompet ini
+ALTER TABLE finance.wallets ADD COLUMN IF NOT EXISTS household_id UUID NULL REFERENCES finance.households(id) ON DELETE SET NULL;
+CREATE INDEX IF NOT EXISTS idx_wallets_household_id ON finance.wallets(household_id) WHERE household_id IS NOT NULL;
+
+-- Dompet yang dibagikan langsung ke user lain, tanpa household
+CREATE TABLE IF NOT EXISTS finance.wallet_members (
+    wallet_id  UUID NOT NULL REFERENCES finance.wallets(id) ON DELETE CASCADE,
+    user_id    UUID NOT NULL,
+    role       TEXT NOT NULL CHECK (role IN ('editor', 'viewer')),
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (wallet_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_wallet_members_user_id ON finance.wallet_members(user_id);
+
+-- Undangan ke household atau ke satu dompet (tepat salah satu)
+CREATE TABLE IF NOT EXISTS finance.share_invites (
+    id           UUID PRIMARY KEY,
+    household_id UUID NULL REFERENCES finance.households(id) ON DELETE CASCADE,
+    wallet_id    UUID NULL REFERENCES finance.wallets(id) ON DELETE CASCADE,
+    email        TEXT NOT NULL,
+    role         TEXT NOT NULL CHECK (role IN ('editor', 'viewer')),
+    invited_by   UUID NOT NULL,
+    status       TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'accepted', 'declined', 'revoked')),
+    created_at   TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    responded_at TIMESTAMP WITH TIME ZONE NULL,
+    CHECK ((household_id IS NULL) <> (wallet_id IS NULL))
+);
+
+CREATE INDEX IF NOT EXISTS idx_share_invites_email ON finance.share_invites(LOWER(email)) WHERE status = 'pending';
+-- Satu undangan aktif per email & tujuan
+CREATE UNIQUE INDEX IF NOT EXISTS idx_share_invites_pending
+    ON finance.share_invites(COALESCE(household_id, wallet_id), LOWER(email)) WHERE status = 'pending';
+
+-- Anggota yang membuat transaksi; NULL berarti pemilik (data lama & impor)
+ALTER TABLE finance.transactions ADD COLUMN IF NOT EXISTS created_by UUID NULL;
+
+-- Budget bersama household: disimpan atas nama pemilik, satu budget per household & kategori.
+-- Budget pribadi tetap unik per user & kategori
+ALTER TABLE finance.budgets ADD COLUMN IF NOT EXISTS household_id UUID NULL REFERENCES finance.households(id) ON DELETE CASCADE;
+ALTER TABLE finance.budgets DROP CONSTRAINT IF EXISTS budgets_user_id_category_id_key;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_budgets_personal ON finance.budgets(user_id, category_id) WHERE household_id IS NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_budgets_household ON finance.budgets(household_id, category_id) WHERE household_id IS NOT NULL;
+
+-- Peran user di household, NULL jika bukan anggota
+CREATE OR REPLACE FUNCTION finance.household_role(p_household UUID, p_user UUID) RETURNS TEXT AS $$
+    SELECT role FROM finance.household_members WHERE household_id = p_household AND user_id = p_user;
+$$ LANGUAGE sql STABLE;
+
+-- Peran terkuat user atas dompet: pemilik dompet, lewat household, atau lewat undangan dompet
+CREATE OR REPLACE FUNCTION finance.wallet_role(p_wallet UUID, p_user UUID) RETURNS TEXT AS $$
+    SELECT CASE WHEN w.user_id = p_user THEN 'owner' ELSE (
+        SELECT x.role FROM (
+            SELECT m.role FROM finance.household_members m WHERE m.household_id = w.household_id AND m.user_id = p_user
+            UNION ALL
+            SELECT s.role FROM finance.wallet_members s WHERE s.wallet_id = w.id AND s.user_id = p_user
+        ) x
+        ORDER BY CASE x.role WHEN 'owner' THEN 0 WHEN 'editor' THEN 1 ELSE 2 END
+        LIMIT 1
+    ) END
+    FROM finance.wallets w WHERE w.id = p_wallet AND w.deleted_at IS NULL;
+$$ LANGUAGE sql STABLE;
+
+-- CATATAN:
+-- 1. Hanya dompet milik pemilik household yang bisa dimasukkan ke household; kategori,
+--    budget & kurs yang dipakai adalah milik pemilik
+-- 2. Viewer hanya membaca; editor boleh menambah & menghapus transaksi serta mengatur budget
+--    household; hanya pemilik yang mengundang, mengubah peran, dan mengeluarkan anggota
+-- 3. Budget & analitik household hanya menghitung transaksi di dompet household, dalam mata
+--    uang dasar pemilik
+-- 4. Undangan dicocokkan dengan email akun yang login; user yang belum terdaftar bisa
+--    menerima undangan setelah mendaftar dengan email tersebut
@@ -0,0 +1,74 @@
+-- 024_expense_splits.sql
+-- Patungan antar user (trip, kos bersama): grup dengan satu mata uang, pengeluaran yang
+-- dibagi rata / persentase / nominal pasti, dan pelunasan antar anggota. Transaksi
+-- pengeluaran & pelunasan dicatat di dompet grup masing-masing anggota
+
+CREATE TABLE IF NOT EXISTS finance.split_groups (
+    id         UUID PRIMARY KEY,
+    name       TEXT NOT NULL,
+    currency   CHAR(3) NOT NULL CHECK (currency ~ '^[A-Z]{3}$'),
+    created_by UUID NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+-- wallet_id: dompet anggota (bermata uang grup) untuk transaksi grup; NULL berarti tidak dicatat
+CREATE TABLE IF NOT EXISTS finance.split_group_members (
+    group_id   UUID NOT NULL REFERENCES finance.split_groups(id) ON DELETE CASCADE,
+    user_id    UUID NOT NULL,
+    wallet_id  UUID NULL REFERENCES finance.wallets(id) ON DELETE SET NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (group_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_split_group_members_user_id ON finance.split_group_members(user_id);
+
+CREATE TABLE IF NOT EXISTS finance.split_expenses (
+    id             UUID PRIMARY KEY,
+    group_id       UUID NOT NULL REFERENCES finance.split_groups(id) ON DELETE CASCADE,
+    description    TEXT NOT NULL,
+    amount         NUMERIC(20,2) NOT NULL CHECK (amount > 0),
+    paid_by        UUID NOT NULL,
+    method         TEXT NOT NULL CHECK (method IN ('equal', 'percent', 'exact')),
+    occurred_on    DATE NOT NULL,
+    -- transaksi 'out' di dompet grup pembayar
+    transaction_id UUID NULL REFERENCES finance.transactions(id) ON DELETE SET NULL,
+    created_by     UUID NOT NULL,
+    created_at     TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_split_expenses_group_id ON finance.split_expenses(group_id, occurred_on);
+
+-- Bagian tiap anggota; jumlahnya sama dengan nominal pengeluaran
+CREATE TABLE IF NOT EXISTS finance.split_shares (
+    expense_id UUID NOT NULL REFERENCES finance.split_expenses(id) ON DELETE CASCADE,
+    user_id    UUID NOT NULL,
+    amount     NUMERIC(20,2) NOT NULL CHECK (amount >= 0),
+    percent    NUMERIC(5,2) NULL,
+    PRIMARY KEY (expense_id, user_id)
+);
+
+-- Pelunasan dari from_user ke to_user; masing-masing sisi punya transaksinya sendiri
+CREATE TABLE IF NOT EXISTS finance.split_settlements (
+    id                  UUID PRIMARY KEY,
+    group_id            UUID NOT NULL REFERENCES finance.split_groups(id) ON DELETE CASCADE,
+    from_user           UUID NOT NULL,
+    to_user             UUID NOT NULL CHECK (to_user <> from_user),
+    amount              NUMERIC(20,2) NOT NULL CHECK (amount > 0),
+    occurred_on         DATE NOT NULL,
+    from_transaction_id UUID NULL REFERENCES finance.transactions(id) ON DELETE SET NULL,
+    to_transaction_id   UUID NULL REFERENCES finance.transactions(id) ON DELETE SET NULL,
+    created_by          UUID NOT NULL,
+    created_at          TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_split_settlements_group_id ON finance.split_settlements(group_id, occurred_on);
+
+-- CATATAN:
+-- 1. Saldo anggota = dibayar - bagian + pelunasan dibayar - pelunasan diterima; positif
+--    berarti anggota lain berutang kepadanya
+-- 2. Anggota hanya bisa keluar dari grup jika saldonya nol; hanya pembuat grup yang
+--    bisa mengeluarkan anggota lain
+-- 3. Transaksi hanya dicatat di dompet grup milik pemanggil sendiri; pengeluaran yang
+--    dibayar anggota lain tidak menyentuh dompetnya
+-- 4. Menghapus pengeluaran atau pelunasan ikut memindahkan transaksi pemanggil ke trash
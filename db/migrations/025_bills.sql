@@ -0,0 +1,46 @@
+-- 025_bills.sql
+-- Tagihan terjadwal (listrik, internet, sewa): nominal perkiraan, jadwal jatuh tempo dan
+-- pengingat. Tagihan tidak membuat transaksi; pembayarannya dicocokkan dengan transaksi keluar
+
+CREATE TABLE IF NOT EXISTS finance.bills (
+    id             UUID PRIMARY KEY,
+    user_id        UUID NOT NULL,
+    name           TEXT NOT NULL,
+    -- Payee tidak bisa dihapus selama masih dipakai tagihan
+    payee_id       UUID NOT NULL REFERENCES finance.payees(id) ON DELETE RESTRICT,
+    wallet_id      UUID NOT NULL REFERENCES finance.wallets(id) ON DELETE CASCADE,
+    amount         NUMERIC(20,2) NOT NULL CHECK (amount > 0),
+    -- Selisih nominal yang masih dianggap cocok, mis. tagihan listrik yang naik-turun
+    tolerance      NUMERIC(20,2) NOT NULL DEFAULT 0 CHECK (tolerance >= 0),
+    frequency      TEXT NOT NULL,                 -- daily | weekly | monthly | yearly
+    interval_count INTEGER NOT NULL DEFAULT 1,    -- setiap N hari/minggu/bulan/tahun
+    day_of_month   INTEGER NULL,                  -- 1-31, dipotong ke akhir bulan bila bulannya lebih pendek
+    start_date     DATE NOT NULL,
+    end_date       DATE NULL,
+    reminder_days  INTEGER NOT NULL DEFAULT 3 CHECK (reminder_days BETWEEN 0 AND 60),
+    created_at     TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at     TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_bills_user_id ON finance.bills(user_id);
+
+-- Satu baris per tanggal jatuh tempo yang sudah dibayar
+CREATE TABLE IF NOT EXISTS finance.bill_payments (
+    bill_id        UUID NOT NULL REFERENCES finance.bills(id) ON DELETE CASCADE,
+    due_date       DATE NOT NULL,
+    -- NULL berarti ditandai lunas secara manual tanpa transaksi
+    transaction_id UUID NULL REFERENCES finance.transactions(id) ON DELETE CASCADE,
+    method         TEXT NOT NULL CHECK (method IN ('auto', 'manual')),
+    matched_at     TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (bill_id, due_date)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_bill_payments_transaction_id ON finance.bill_payments(transaction_id)
+    WHERE transaction_id IS NOT NULL;
+
+-- CATATAN:
+-- 1. Pembayaran dicocokkan otomatis dengan transaksi keluar ke payee tagihan di dompet tagihan,
+--    dengan nominal dalam batas toleransi dan tanggal paling jauh 7 hari dari jatuh tempo
+-- 2. Satu transaksi hanya melunasi satu jatuh tempo; pembayaran lewat transaksi yang ada di
+--    trash tidak dihitung sampai transaksinya dipulihkan
+-- 3. Berbeda dari recurring_rules, tagihan tidak pernah membuat transaksi sendiri
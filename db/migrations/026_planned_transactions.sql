@@ -0,0 +1,35 @@
+-- 026_planned_transactions.sql
+-- Transaksi terencana: transaksi bertanggal setelah hari ini dicatat tanpa mengubah saldo
+-- dompet sampai diposting pada tanggalnya
+
+ALTER TABLE finance.transactions ADD COLUMN IF NOT EXISTS planned BOOLEAN NOT NULL DEFAULT FALSE;
+-- Hanya berarti untuk transaksi terencana: TRUE diposting otomatis pada tanggalnya,
+-- FALSE menunggu konfirmasi pengguna
+ALTER TABLE finance.transactions ADD COLUMN IF NOT EXISTS auto_post BOOLEAN NOT NULL DEFAULT TRUE;
+
+CREATE INDEX IF NOT EXISTS idx_transactions_planned ON finance.transactions(occurred_at)
+    WHERE planned AND deleted_at IS NULL;
+
+-- Transaksi terencana belum terjadi, jadi tidak ikut di budget & analytics
+CREATE OR REPLACE VIEW finance.transaction_lines AS
+SELECT
+    t.id AS transaction_id,
+    t.user_id,
+    t.wallet_id,
+    COALESCE(s.category_id, t.category_id) AS category_id,
+    COALESCE(s.amount, t.amount) AS amount,
+    t.kind,
+    t.occurred_at,
+    t.currency
+FROM finance.transactions t
+LEFT JOIN finance.transaction_splits s ON s.transaction_id = t.id
+WHERE t.deleted_at IS NULL AND t.transfer_id IS NULL AND t.debt_id IS NULL AND NOT t.planned;
+
+-- CATATAN:
+-- 1. Jurnal buku besar transaksi terencana baru ditulis saat diposting (jurnal 'update')
+-- 2. Pada tanggalnya transaksi auto_post diposting oleh scheduler; sisanya memunculkan
+--    notifikasi dan menunggu POST /transactions/{id}/post
+-- 3. Laporan, anggaran, rekonsiliasi, statement kartu kredit, target tabungan dan
+--    pencocokan tagihan hanya menghitung transaksi yang sudah diposting
+-- 4. GET /wallets/{id}/projection memproyeksikan saldo harian dari transaksi terencana
+--    dan aturan transaksi berulang